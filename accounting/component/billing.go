@@ -0,0 +1,365 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/mq"
+)
+
+type billingComponentImpl struct {
+	prices   database.AccountPriceStore
+	accounts database.AccountUserStore
+	invoices database.AccountInvoiceStore
+	sysMQ    *mq.NatsHandler
+}
+
+type BillingComponent interface {
+	CreatePrice(ctx context.Context, req types.AccountPriceReq) (*database.AccountPrice, error)
+	UpdatePrice(ctx context.Context, id int64, req types.AccountPriceReq) (*database.AccountPrice, error)
+	DeletePrice(ctx context.Context, id int64) error
+	GetPrice(ctx context.Context, id int64) (*database.AccountPrice, error)
+	ListPrices(ctx context.Context, per, page int) ([]database.AccountPrice, int, error)
+	ListAccounts(ctx context.Context, per, page int) ([]database.AccountUser, int, error)
+	GetAccount(ctx context.Context, ownerUUID string) (*database.AccountUser, error)
+	UpdateAccount(ctx context.Context, ownerUUID string, req types.UpdateAccountReq) (*database.AccountUser, error)
+	// Recharge adds the paid balance or prepaid credit to the account of owner
+	Recharge(ctx context.Context, ownerUUID string, req types.RechargeAccountReq) (*database.AccountStatement, error)
+	ListStatements(ctx context.Context, ownerUUID string, startTime, endTime time.Time, per, page int) ([]database.AccountStatement, int, error)
+	ListBills(ctx context.Context, ownerUUID string, startTime, endTime time.Time) ([]types.AccountBill, error)
+	ListInvoices(ctx context.Context, ownerUUID string, per, page int) ([]database.AccountInvoice, int, error)
+	// ChargeMeteringEvent charges the consumption of event to the account paying for the user of event,
+	// an event is charged only once
+	ChargeMeteringEvent(ctx context.Context, event *types.METERING_EVENT) error
+	// GenerateInvoices creates the invoices of the period for the accounts having statements in the period,
+	// it returns the number of invoices created
+	GenerateInvoices(ctx context.Context, periodStart, periodEnd time.Time) (int, error)
+	// RunInvoicing generates the invoices of last month for all accounts at the beginning of every month,
+	// until ctx is done
+	RunInvoicing(ctx context.Context)
+}
+
+func NewBillingComponent(sysMQ *mq.NatsHandler) BillingComponent {
+	return &billingComponentImpl{
+		prices:   database.NewAccountPriceStore(),
+		accounts: database.NewAccountUserStore(),
+		invoices: database.NewAccountInvoiceStore(),
+		sysMQ:    sysMQ,
+	}
+}
+
+func (c *billingComponentImpl) CreatePrice(ctx context.Context, req types.AccountPriceReq) (*database.AccountPrice, error) {
+	price := database.AccountPrice{}
+	applyPriceReq(&price, req)
+	return c.prices.Create(ctx, price)
+}
+
+func (c *billingComponentImpl) UpdatePrice(ctx context.Context, id int64, req types.AccountPriceReq) (*database.AccountPrice, error) {
+	price, err := c.GetPrice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	applyPriceReq(price, req)
+	err = c.prices.Update(ctx, price)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account price %d, error: %w", id, err)
+	}
+	return price, nil
+}
+
+func applyPriceReq(price *database.AccountPrice, req types.AccountPriceReq) {
+	price.SkuType = req.SkuType
+	price.Scene = req.Scene
+	price.ResourceID = req.ResourceID
+	price.UnitType = req.UnitType
+	price.SkuUnit = req.SkuUnit
+	price.Price = req.Price
+	price.Description = req.Description
+}
+
+func (c *billingComponentImpl) DeletePrice(ctx context.Context, id int64) error {
+	_, err := c.GetPrice(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.prices.Delete(ctx, id)
+}
+
+func (c *billingComponentImpl) GetPrice(ctx context.Context, id int64) (*database.AccountPrice, error) {
+	price, err := c.prices.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("account price %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find account price %d, error: %w", id, err)
+	}
+	return price, nil
+}
+
+func (c *billingComponentImpl) ListPrices(ctx context.Context, per, page int) ([]database.AccountPrice, int, error) {
+	return c.prices.List(ctx, per, page)
+}
+
+func (c *billingComponentImpl) ListAccounts(ctx context.Context, per, page int) ([]database.AccountUser, int, error) {
+	return c.accounts.List(ctx, per, page)
+}
+
+func (c *billingComponentImpl) GetAccount(ctx context.Context, ownerUUID string) (*database.AccountUser, error) {
+	account, err := c.accounts.FindByOwnerUUID(ctx, ownerUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("account of %s: %w", ownerUUID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find account of %s, error: %w", ownerUUID, err)
+	}
+	return account, nil
+}
+
+func (c *billingComponentImpl) UpdateAccount(ctx context.Context, ownerUUID string, req types.UpdateAccountReq) (*database.AccountUser, error) {
+	account, err := c.accounts.FindOrCreate(ctx, ownerUUID, req.OwnerType)
+	if err != nil {
+		return nil, err
+	}
+	if account.OwnerType != req.OwnerType {
+		return nil, fmt.Errorf("account of %s is owned by %s: %w", ownerUUID, account.OwnerType, ErrBadRequest)
+	}
+	if req.LowBalanceThreshold != nil {
+		account.LowBalanceThreshold = *req.LowBalanceThreshold
+	}
+	if req.PayerUUID != nil && *req.PayerUUID != "" {
+		if account.OwnerType != types.AccountOwnerUser {
+			return nil, fmt.Errorf("only the consumption of user can be charged to org: %w", ErrBadRequest)
+		}
+		payer, err := c.GetAccount(ctx, *req.PayerUUID)
+		if err != nil {
+			return nil, err
+		}
+		if payer.OwnerType != types.AccountOwnerOrg {
+			return nil, fmt.Errorf("payer %s is not an org: %w", *req.PayerUUID, ErrBadRequest)
+		}
+	}
+	if req.PayerUUID != nil {
+		account.PayerUUID = *req.PayerUUID
+	}
+	oldStatus := account.Status
+	account.Status = types.AccountStatusOf(account.Balance, account.LowBalanceThreshold)
+	err = c.accounts.Update(ctx, account, "low_balance_threshold", "payer_uuid", "status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account of %s, error: %w", ownerUUID, err)
+	}
+	if account.Status != oldStatus {
+		c.notifyBalance(ctx, account)
+	}
+	return account, nil
+}
+
+func (c *billingComponentImpl) Recharge(ctx context.Context, ownerUUID string, req types.RechargeAccountReq) (*database.AccountStatement, error) {
+	account, err := c.accounts.FindOrCreate(ctx, ownerUUID, req.OwnerType)
+	if err != nil {
+		return nil, err
+	}
+	statement := &database.AccountStatement{
+		EventUUID:   uuid.New(),
+		Type:        req.Type,
+		Amount:      req.Amount,
+		Description: req.Description,
+		OpUID:       req.OpUID,
+		RecordedAt:  time.Now(),
+	}
+	before, after, _, err := c.accounts.ApplyStatement(ctx, account.ID, statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recharge account of %s, error: %w", ownerUUID, err)
+	}
+	if before.Status != after.Status {
+		c.notifyBalance(ctx, after)
+	}
+	return statement, nil
+}
+
+func (c *billingComponentImpl) ListStatements(ctx context.Context, ownerUUID string, startTime, endTime time.Time, per, page int) ([]database.AccountStatement, int, error) {
+	account, err := c.GetAccount(ctx, ownerUUID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return c.accounts.ListStatements(ctx, account.ID, startTime, endTime, per, page)
+}
+
+func (c *billingComponentImpl) ListBills(ctx context.Context, ownerUUID string, startTime, endTime time.Time) ([]types.AccountBill, error) {
+	account, err := c.GetAccount(ctx, ownerUUID)
+	if err != nil {
+		return nil, err
+	}
+	return c.accounts.SumBills(ctx, account.ID, startTime, endTime)
+}
+
+func (c *billingComponentImpl) ListInvoices(ctx context.Context, ownerUUID string, per, page int) ([]database.AccountInvoice, int, error) {
+	account, err := c.GetAccount(ctx, ownerUUID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return c.invoices.ListByAccountID(ctx, account.ID, per, page)
+}
+
+func (c *billingComponentImpl) ChargeMeteringEvent(ctx context.Context, event *types.METERING_EVENT) error {
+	if event.UserUUID == "" {
+		return nil
+	}
+	unitType := getUnitString(event.Scene, event.ValueType)
+	scene := types.SceneType(event.Scene)
+	price, err := c.prices.FindForResource(ctx, scene, event.ResourceID, unitType)
+	if err != nil {
+		return fmt.Errorf("failed to find price of resource %s, error: %w", event.ResourceID, err)
+	}
+	var cost types.Money
+	if price != nil {
+		cost = price.Cost(event.Value)
+	}
+
+	account, err := c.accounts.FindOrCreate(ctx, event.UserUUID, types.AccountOwnerUser)
+	if err != nil {
+		return err
+	}
+	if account.PayerUUID != "" {
+		account, err = c.GetAccount(ctx, account.PayerUUID)
+		if err != nil {
+			return fmt.Errorf("failed to find payer of user %s, error: %w", event.UserUUID, err)
+		}
+	}
+	statement := &database.AccountStatement{
+		EventUUID:    event.Uuid,
+		Type:         types.AccountStatementCharge,
+		UserUUID:     event.UserUUID,
+		Scene:        scene,
+		ResourceID:   event.ResourceID,
+		ResourceName: event.ResourceName,
+		CustomerID:   event.CustomerID,
+		Value:        float64(event.Value),
+		UnitType:     unitType,
+		Amount:       -cost,
+		OpUID:        event.OpUID,
+		RecordedAt:   event.CreatedAt,
+	}
+	before, after, applied, err := c.accounts.ApplyStatement(ctx, account.ID, statement)
+	if err != nil {
+		return fmt.Errorf("failed to charge metering event %s, error: %w", event.Uuid, err)
+	}
+	if applied && before.Status != after.Status {
+		c.notifyBalance(ctx, after)
+	}
+	return nil
+}
+
+// notifyBalance publishes the status change of account, so that the owner is notified and the deploys
+// charged to an overdrawn account are stopped by csghub server
+func (c *billingComponentImpl) notifyBalance(ctx context.Context, account *database.AccountUser) {
+	notification := types.BalanceNotification{
+		OwnerUUID: account.OwnerUUID,
+		OwnerType: account.OwnerType,
+		Balance:   account.Balance,
+		CreatedAt: time.Now(),
+	}
+	switch account.Status {
+	case types.AccountStatusArrears:
+		notification.Event = types.BalanceEventNo
+	case types.AccountStatusLowBalance:
+		notification.Event = types.BalanceEventLow
+	default:
+		notification.Event = types.BalanceEventRecovered
+	}
+	if account.OwnerType == types.AccountOwnerOrg {
+		members, err := c.accounts.ListByPayer(ctx, account.OwnerUUID)
+		if err != nil {
+			slog.Error("failed to list users charged to org account", slog.String("owner_uuid", account.OwnerUUID), slog.Any("error", err))
+		}
+		for _, m := range members {
+			notification.UserUUIDs = append(notification.UserUUIDs, m.OwnerUUID)
+		}
+	} else {
+		notification.UserUUIDs = []string{account.OwnerUUID}
+	}
+
+	data, err := json.Marshal(notification)
+	if err != nil {
+		slog.Error("failed to marshal balance notification", slog.Any("notification", notification), slog.Any("error", err))
+		return
+	}
+	err = c.sysMQ.PublishBalanceNotification(data)
+	if err != nil {
+		slog.Error("failed to publish balance notification", slog.String("data", string(data)), slog.Any("error", err))
+		return
+	}
+	slog.Info("balance notification published", slog.String("owner_uuid", account.OwnerUUID),
+		slog.String("event", string(notification.Event)), slog.String("balance", account.Balance.String()))
+}
+
+func (c *billingComponentImpl) GenerateInvoices(ctx context.Context, periodStart, periodEnd time.Time) (int, error) {
+	accounts, err := c.accounts.ListAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list accounts, error: %w", err)
+	}
+	created := 0
+	for _, account := range accounts {
+		bills, err := c.accounts.SumBills(ctx, account.ID, periodStart, periodEnd)
+		if err != nil {
+			return created, fmt.Errorf("failed to sum bills of account %d, error: %w", account.ID, err)
+		}
+		recharged, err := c.accounts.SumRecharged(ctx, account.ID, periodStart, periodEnd)
+		if err != nil {
+			return created, fmt.Errorf("failed to sum recharges of account %d, error: %w", account.ID, err)
+		}
+		if len(bills) == 0 && recharged == 0 {
+			continue
+		}
+		invoice := &database.AccountInvoice{
+			AccountID:   account.ID,
+			PeriodStart: periodStart,
+			PeriodEnd:   periodEnd,
+			Recharged:   recharged,
+			Items:       bills,
+		}
+		for _, bill := range bills {
+			invoice.Amount += bill.Amount
+		}
+		ok, err := c.invoices.Create(ctx, invoice)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// invoicingDelay waits for the metering events of the last minutes of a period to be charged
+const invoicingDelay = time.Hour
+
+func (c *billingComponentImpl) RunInvoicing(ctx context.Context) {
+	for {
+		now := time.Now()
+		periodEnd := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		periodStart := periodEnd.AddDate(0, -1, 0)
+		if now.Sub(periodEnd) >= invoicingDelay {
+			created, err := c.GenerateInvoices(ctx, periodStart, periodEnd)
+			if err != nil {
+				slog.Error("failed to generate invoices", slog.Time("period_start", periodStart), slog.Any("error", err))
+			} else {
+				slog.Info("invoices generated", slog.Time("period_start", periodStart), slog.Int("count", created))
+			}
+			periodEnd = periodEnd.AddDate(0, 1, 0)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(periodEnd.Add(invoicingDelay))):
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/mq"
+)
+
+type dlqComponentImpl struct {
+	sysMQ     *mq.NatsHandler
+	meterComp MeteringComponent
+	billComp  BillingComponent
+}
+
+type DLQComponent interface {
+	ListMessages(ctx context.Context, fromSeq uint64, limit int) ([]types.DLQMessage, error)
+	GetMessage(ctx context.Context, seq uint64) (*types.DLQMessage, error)
+	// Replay handles the metering event of message again with patch applied, the message is removed
+	// from DLQ once the event is handled
+	Replay(ctx context.Context, seq uint64, patch *types.MeteringEventPatch) error
+	// ReplayAll replays every message of DLQ, the messages failed to be replayed are kept in DLQ
+	ReplayAll(ctx context.Context) ([]types.DLQReplayResult, error)
+	DeleteMessage(ctx context.Context, seq uint64) error
+	Purge(ctx context.Context) error
+}
+
+func NewDLQComponent(sysMQ *mq.NatsHandler) DLQComponent {
+	return &dlqComponentImpl{
+		sysMQ:     sysMQ,
+		meterComp: NewMeteringComponent(),
+		billComp:  NewBillingComponent(sysMQ),
+	}
+}
+
+func (c *dlqComponentImpl) ListMessages(ctx context.Context, fromSeq uint64, limit int) ([]types.DLQMessage, error) {
+	msgs, err := c.sysMQ.ListDLQMessages(ctx, fromSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DLQ messages, error: %w", err)
+	}
+	result := make([]types.DLQMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		result = append(result, toDLQMessage(msg))
+	}
+	return result, nil
+}
+
+func (c *dlqComponentImpl) GetMessage(ctx context.Context, seq uint64) (*types.DLQMessage, error) {
+	msg, err := c.sysMQ.GetDLQMessage(ctx, seq)
+	if errors.Is(err, jetstream.ErrMsgNotFound) {
+		return nil, fmt.Errorf("DLQ message %d: %w", seq, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DLQ message %d, error: %w", seq, err)
+	}
+	m := toDLQMessage(msg)
+	return &m, nil
+}
+
+func toDLQMessage(msg *jetstream.RawStreamMsg) types.DLQMessage {
+	m := types.DLQMessage{
+		Sequence:   msg.Sequence,
+		Subject:    msg.Subject,
+		ReceivedAt: msg.Time,
+		Data:       string(msg.Data),
+	}
+	var event types.METERING_EVENT
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		m.ParseError = err.Error()
+	} else {
+		m.Event = &event
+	}
+	return m
+}
+
+func (c *dlqComponentImpl) Replay(ctx context.Context, seq uint64, patch *types.MeteringEventPatch) error {
+	msg, err := c.GetMessage(ctx, seq)
+	if err != nil {
+		return err
+	}
+	if msg.Event == nil {
+		return fmt.Errorf("DLQ message %d is not a metering event, %s: %w", seq, msg.ParseError, ErrBadRequest)
+	}
+	event := msg.Event
+	patch.Apply(event)
+	if event.Uuid == uuid.Nil {
+		return fmt.Errorf("metering event of DLQ message %d has no uuid: %w", seq, ErrBadRequest)
+	}
+	if event.UserUUID == "" {
+		return fmt.Errorf("metering event of DLQ message %d has no user uuid: %w", seq, ErrBadRequest)
+	}
+
+	// both saving and charging are idempotent on the uuid of event, an event is never billed twice
+	err = c.meterComp.SaveMeteringEventRecord(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to replay DLQ message %d, error: %w", seq, err)
+	}
+	err = c.billComp.ChargeMeteringEvent(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to replay DLQ message %d, error: %w", seq, err)
+	}
+	err = c.sysMQ.DeleteDLQMessage(ctx, seq)
+	if err != nil {
+		return fmt.Errorf("failed to delete replayed DLQ message %d, error: %w", seq, err)
+	}
+	slog.Info("DLQ message replayed", slog.Uint64("sequence", seq), slog.Any("event_uuid", event.Uuid))
+	return nil
+}
+
+const dlqReplayBatchSize = 100
+
+func (c *dlqComponentImpl) ReplayAll(ctx context.Context) ([]types.DLQReplayResult, error) {
+	var results []types.DLQReplayResult
+	var seq uint64
+	for {
+		msgs, err := c.sysMQ.ListDLQMessages(ctx, seq, dlqReplayBatchSize)
+		if err != nil {
+			return results, fmt.Errorf("failed to list DLQ messages, error: %w", err)
+		}
+		if len(msgs) == 0 {
+			return results, nil
+		}
+		for _, msg := range msgs {
+			result := types.DLQReplayResult{Sequence: msg.Sequence}
+			if err := c.Replay(ctx, msg.Sequence, nil); err != nil {
+				result.Error = err.Error()
+			}
+			results = append(results, result)
+		}
+		seq = msgs[len(msgs)-1].Sequence + 1
+	}
+}
+
+func (c *dlqComponentImpl) DeleteMessage(ctx context.Context, seq uint64) error {
+	err := c.sysMQ.DeleteDLQMessage(ctx, seq)
+	if errors.Is(err, jetstream.ErrMsgNotFound) {
+		return fmt.Errorf("DLQ message %d: %w", seq, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete DLQ message %d, error: %w", seq, err)
+	}
+	return nil
+}
+
+func (c *dlqComponentImpl) Purge(ctx context.Context) error {
+	err := c.sysMQ.PurgeDLQ(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to purge DLQ, error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+package component
+
+import "errors"
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrBadRequest = errors.New("bad request")
+)
@@ -3,6 +3,7 @@ package component
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/types"
@@ -13,6 +14,7 @@ type meteringComponentImpl struct {
 }
 
 type MeteringComponent interface {
+	// SaveMeteringEventRecord saves the event and adds it to the daily usage of user, an event is saved only once
 	SaveMeteringEventRecord(ctx context.Context, req *types.METERING_EVENT) error
 	ListMeteringByUserIDAndDate(ctx context.Context, req types.ACCT_STATEMENTS_REQ) ([]database.AccountMetering, int, error)
 }
@@ -37,12 +39,26 @@ func (mc *meteringComponentImpl) SaveMeteringEventRecord(ctx context.Context, re
 		CustomerID:   req.CustomerID,
 		RecordedAt:   req.CreatedAt,
 		Extra:        req.Extra,
-		SkuUnitType:  getUnitString(req.Scene),
+		SkuUnitType:  getUnitString(req.Scene, req.ValueType),
 	}
-	err := mc.ams.Create(ctx, am)
+	usage := &database.AccountUsageDaily{
+		Day:          database.UsageDay(req.CreatedAt),
+		UserUUID:     req.UserUUID,
+		Scene:        am.Scene,
+		ResourceID:   req.ResourceID,
+		ResourceName: req.ResourceName,
+		CustomerID:   req.CustomerID,
+		UnitType:     am.SkuUnitType,
+		Value:        am.Value,
+		EventCount:   1,
+	}
+	created, err := mc.ams.CreateWithUsage(ctx, am, usage)
 	if err != nil {
 		return fmt.Errorf("failed to save metering event record, error: %w", err)
 	}
+	if !created {
+		slog.Warn("metering event has been saved", slog.Any("event_uuid", req.Uuid))
+	}
 	return nil
 }
 
@@ -54,7 +70,11 @@ func (mc *meteringComponentImpl) ListMeteringByUserIDAndDate(ctx context.Context
 	return meters, total, nil
 }
 
-func getUnitString(scene int) string {
+func getUnitString(scene, valueType int) string {
+	// inference endpoints are metered by both duration and tokens
+	if valueType == types.TokenNumberType {
+		return types.UnitToken
+	}
 	switch types.SceneType(scene) {
 	case types.SceneModelInference:
 		return types.UnitMinute
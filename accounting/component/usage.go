@@ -0,0 +1,59 @@
+package component
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type usageComponentImpl struct {
+	usage    database.AccountUsageStore
+	accounts database.AccountUserStore
+}
+
+type UsageComponent interface {
+	AggregateUsage(ctx context.Context, req types.UsageAggregateReq) ([]types.UsageAggregate, error)
+	// RebuildUsage rolls the metering events of the days in [start, end) up again, e.g. to backfill the
+	// events saved without rollups, it's safe to run again
+	RebuildUsage(ctx context.Context, start, end time.Time) (int64, error)
+}
+
+func NewUsageComponent() UsageComponent {
+	return &usageComponentImpl{
+		usage:    database.NewAccountUsageStore(),
+		accounts: database.NewAccountUserStore(),
+	}
+}
+
+func (c *usageComponentImpl) AggregateUsage(ctx context.Context, req types.UsageAggregateReq) ([]types.UsageAggregate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", err.Error(), ErrBadRequest)
+	}
+	userUUIDs := []string{req.OwnerUUID}
+	if req.OwnerType == types.AccountOwnerOrg {
+		// the usage of org is the consumption of the members charged to it
+		members, err := c.accounts.ListByPayer(ctx, req.OwnerUUID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members of org %s, error: %w", req.OwnerUUID, err)
+		}
+		userUUIDs = userUUIDs[:0]
+		for _, m := range members {
+			userUUIDs = append(userUUIDs, m.OwnerUUID)
+		}
+	}
+	return c.usage.Aggregate(ctx, userUUIDs, req)
+}
+
+func (c *usageComponentImpl) RebuildUsage(ctx context.Context, start, end time.Time) (int64, error) {
+	if !start.Before(end) {
+		return 0, fmt.Errorf("start must be before end: %w", ErrBadRequest)
+	}
+	rows, err := c.usage.Rebuild(ctx, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild usage, error: %w", err)
+	}
+	return rows, nil
+}
@@ -18,12 +18,14 @@ import (
 type Metering struct {
 	sysMQ     *mq.NatsHandler
 	meterComp component.MeteringComponent
+	billComp  component.BillingComponent
 }
 
 func NewMetering(natHandler *mq.NatsHandler, config *config.Config) *Metering {
 	meter := &Metering{
 		sysMQ:     natHandler,
 		meterComp: component.NewMeteringComponent(),
+		billComp:  component.NewBillingComponent(natHandler),
 	}
 	return meter
 }
@@ -59,6 +61,13 @@ func (m *Metering) preReadMsgs() {
 			time.Sleep(2 * time.Second)
 			continue
 		}
+		err = m.sysMQ.BuildNotifyStream()
+		if err != nil {
+			tip := fmt.Sprintf("fail to build notify stream in metering for the %d time", i)
+			slog.Error(tip, slog.Any("error", err))
+			time.Sleep(2 * time.Second)
+			continue
+		}
 		break
 	}
 }
@@ -142,6 +151,10 @@ func (m *Metering) handleMsgData(msg jetstream.Msg) (*types.METERING_EVENT, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to save meter event, %v, %w", event, err)
 	}
+	err = m.billComp.ChargeMeteringEvent(ctx, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to charge meter event, %v, %w", event, err)
+	}
 	return event, nil
 }
 
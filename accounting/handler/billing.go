@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/accounting/utils"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/mq"
+)
+
+func NewBillingHandler(sysMQ *mq.NatsHandler) (*BillingHandler, error) {
+	return &BillingHandler{
+		bc: component.NewBillingComponent(sysMQ),
+	}, nil
+}
+
+type BillingHandler struct {
+	bc component.BillingComponent
+}
+
+func (bh *BillingHandler) ListPrices(ctx *gin.Context) {
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request pagination format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	prices, total, err := bh.bc.ListPrices(ctx, per, page)
+	if err != nil {
+		slog.Error("fail to list account prices", slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, gin.H{
+		"data":  prices,
+		"total": total,
+	})
+}
+
+func (bh *BillingHandler) CreatePrice(ctx *gin.Context) {
+	var req types.AccountPriceReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	price, err := bh.bc.CreatePrice(ctx, req)
+	if err != nil {
+		slog.Error("fail to create account price", slog.Any("req", req), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, price)
+}
+
+func (bh *BillingHandler) GetPrice(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request price id", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	price, err := bh.bc.GetPrice(ctx, id)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, price)
+}
+
+func (bh *BillingHandler) UpdatePrice(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request price id", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.AccountPriceReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	price, err := bh.bc.UpdatePrice(ctx, id, req)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, price)
+}
+
+func (bh *BillingHandler) DeletePrice(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request price id", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = bh.bc.DeletePrice(ctx, id)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (bh *BillingHandler) ListAccounts(ctx *gin.Context) {
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request pagination format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	accounts, total, err := bh.bc.ListAccounts(ctx, per, page)
+	if err != nil {
+		slog.Error("fail to list accounts", slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, gin.H{
+		"data":  accounts,
+		"total": total,
+	})
+}
+
+func (bh *BillingHandler) GetAccount(ctx *gin.Context) {
+	account, err := bh.bc.GetAccount(ctx, ctx.Param("id"))
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, account)
+}
+
+func (bh *BillingHandler) UpdateAccount(ctx *gin.Context) {
+	var req types.UpdateAccountReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	account, err := bh.bc.UpdateAccount(ctx, ctx.Param("id"), req)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, account)
+}
+
+func (bh *BillingHandler) Recharge(ctx *gin.Context) {
+	var req types.RechargeAccountReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	statement, err := bh.bc.Recharge(ctx, ctx.Param("id"), req)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, statement)
+}
+
+func (bh *BillingHandler) ListStatements(ctx *gin.Context) {
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request pagination format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	startTime, endTime, err := getTimeRangeFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request datetime format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	statements, total, err := bh.bc.ListStatements(ctx, ctx.Param("id"), startTime, endTime, per, page)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, gin.H{
+		"data":  statements,
+		"total": total,
+	})
+}
+
+func (bh *BillingHandler) ListBills(ctx *gin.Context) {
+	startTime, endTime, err := getTimeRangeFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request datetime format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	bills, err := bh.bc.ListBills(ctx, ctx.Param("id"), startTime, endTime)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, bills)
+}
+
+func (bh *BillingHandler) ListInvoices(ctx *gin.Context) {
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request pagination format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	invoices, total, err := bh.bc.ListInvoices(ctx, ctx.Param("id"), per, page)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, gin.H{
+		"data":  invoices,
+		"total": total,
+	})
+}
+
+// getTimeRangeFromContext parses the required start_time and end_time of query, format: '2024-06-12 08:27:22'
+func getTimeRangeFromContext(ctx *gin.Context) (time.Time, time.Time, error) {
+	startStr := ctx.Query("start_time")
+	endStr := ctx.Query("end_time")
+	if !utils.ValidateDateTimeFormat(startStr, "2006-01-02 15:04:05") || !utils.ValidateDateTimeFormat(endStr, "2006-01-02 15:04:05") {
+		return time.Time{}, time.Time{}, fmt.Errorf("bad request datetime format")
+	}
+	startTime, _ := time.ParseInLocation("2006-01-02 15:04:05", startStr, time.Local)
+	endTime, _ := time.ParseInLocation("2006-01-02 15:04:05", endStr, time.Local)
+	return startTime, endTime, nil
+}
+
+func handleBillingError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrBadRequest):
+		httpbase.BadRequest(ctx, err.Error())
+	default:
+		slog.Error("fail to handle billing request", slog.String("path", ctx.FullPath()), slog.Any("error", err))
+		httpbase.ServerError(ctx, err)
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/mq"
+)
+
+func NewDLQHandler(sysMQ *mq.NatsHandler) (*DLQHandler, error) {
+	return &DLQHandler{
+		dc: component.NewDLQComponent(sysMQ),
+	}, nil
+}
+
+type DLQHandler struct {
+	dc component.DLQComponent
+}
+
+func (dh *DLQHandler) ListMessages(ctx *gin.Context) {
+	fromSeq, err := strconv.ParseUint(ctx.DefaultQuery("from", "0"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request from sequence", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		slog.Error("Bad request limit", "limit", ctx.Query("limit"))
+		httpbase.BadRequest(ctx, "limit must be between 1 and 100")
+		return
+	}
+	msgs, err := dh.dc.ListMessages(ctx, fromSeq, limit)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, msgs)
+}
+
+func (dh *DLQHandler) GetMessage(ctx *gin.Context) {
+	seq, err := strconv.ParseUint(ctx.Param("seq"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request sequence", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	msg, err := dh.dc.GetMessage(ctx, seq)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, msg)
+}
+
+// Replay handles a DLQ message again, the optional body patches the metering event before replay
+func (dh *DLQHandler) Replay(ctx *gin.Context) {
+	seq, err := strconv.ParseUint(ctx.Param("seq"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request sequence", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var patch *types.MeteringEventPatch
+	if ctx.Request.ContentLength > 0 {
+		patch = &types.MeteringEventPatch{}
+		if err := ctx.ShouldBindJSON(patch); err != nil {
+			slog.Error("Bad request format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
+	err = dh.dc.Replay(ctx, seq, patch)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (dh *DLQHandler) ReplayAll(ctx *gin.Context) {
+	results, err := dh.dc.ReplayAll(ctx)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, results)
+}
+
+func (dh *DLQHandler) DeleteMessage(ctx *gin.Context) {
+	seq, err := strconv.ParseUint(ctx.Param("seq"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request sequence", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = dh.dc.DeleteMessage(ctx, seq)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (dh *DLQHandler) Purge(ctx *gin.Context) {
+	err := dh.dc.Purge(ctx)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
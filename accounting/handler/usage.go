@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func NewUsageHandler() (*UsageHandler, error) {
+	return &UsageHandler{
+		uc: component.NewUsageComponent(),
+	}, nil
+}
+
+type UsageHandler struct {
+	uc component.UsageComponent
+}
+
+// AggregateUsage returns the usage of an account owner aggregated by day, week or month,
+// optionally grouped by scene, resource or org member, as json or csv
+func (uh *UsageHandler) AggregateUsage(ctx *gin.Context) {
+	req := types.UsageAggregateReq{
+		OwnerUUID: ctx.Param("id"),
+		OwnerType: types.AccountOwnerType(ctx.DefaultQuery("owner_type", string(types.AccountOwnerUser))),
+		Period:    types.UsagePeriod(ctx.DefaultQuery("period", string(types.UsagePeriodDay))),
+		GroupBy:   types.UsageGroupBy(ctx.Query("group_by")),
+	}
+	var err error
+	req.StartDate, err = time.ParseInLocation(time.DateOnly, ctx.Query("start_date"), time.Local)
+	if err != nil {
+		slog.Error("Bad request start date format", "error", err)
+		httpbase.BadRequest(ctx, "Bad request start_date format, e.g. 2024-06-01")
+		return
+	}
+	req.EndDate, err = time.ParseInLocation(time.DateOnly, ctx.Query("end_date"), time.Local)
+	if err != nil {
+		slog.Error("Bad request end date format", "error", err)
+		httpbase.BadRequest(ctx, "Bad request end_date format, e.g. 2024-07-01")
+		return
+	}
+	if sceneStr := ctx.Query("scene"); sceneStr != "" {
+		scene, err := strconv.Atoi(sceneStr)
+		if err != nil {
+			slog.Error("Bad request scene format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		s := types.SceneType(scene)
+		req.Scene = &s
+	}
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		httpbase.BadRequest(ctx, "Bad request format, json or csv is supported")
+		return
+	}
+
+	usages, err := uh.uc.AggregateUsage(ctx, req)
+	if err != nil {
+		handleBillingError(ctx, err)
+		return
+	}
+	if format == "csv" {
+		writeUsageCSV(ctx, req, usages)
+		return
+	}
+	httpbase.OK(ctx, usages)
+}
+
+func writeUsageCSV(ctx *gin.Context, req types.UsageAggregateReq, usages []types.UsageAggregate) {
+	filename := fmt.Sprintf("usage_%s_%s_%s.csv", req.OwnerUUID, req.StartDate.Format(time.DateOnly), req.EndDate.Format(time.DateOnly))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Status(http.StatusOK)
+
+	w := csv.NewWriter(ctx.Writer)
+	records := [][]string{{"period_start", "scene", "resource_id", "resource_name", "user_uuid", "unit_type", "value", "event_count"}}
+	for _, u := range usages {
+		records = append(records, []string{
+			u.PeriodStart.Format(time.DateOnly),
+			strconv.Itoa(int(u.Scene)),
+			u.ResourceID,
+			u.ResourceName,
+			u.UserUUID,
+			u.UnitType,
+			strconv.FormatFloat(u.Value, 'f', -1, 64),
+			strconv.FormatInt(u.EventCount, 10),
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		slog.Error("fail to write usage csv", slog.Any("req", req), slog.Any("error", err))
+	}
+}
@@ -7,13 +7,15 @@ import (
 	"opencsg.com/csghub-server/accounting/handler"
 	"opencsg.com/csghub-server/api/middleware"
 	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/mq"
 )
 
-func NewAccountRouter(config *config.Config) (*gin.Engine, error) {
+func NewAccountRouter(config *config.Config, mqHandler *mq.NatsHandler) (*gin.Engine, error) {
 	r := gin.New()
 	r.Use(gin.Recovery())
 	r.Use(middleware.Log())
 	r.Use(middleware.Authenticator(config))
+	needAPIKey := middleware.OnlyAPIKeyAuthenticator(config)
 
 	// metering
 	meterHandler, err := handler.NewMeteringHandler()
@@ -28,5 +30,58 @@ func NewAccountRouter(config *config.Config) (*gin.Engine, error) {
 		meterGroup.GET("/:id/statements", meterHandler.QueryMeteringStatementByUserID)
 	}
 
+	// billing
+	billHandler, err := handler.NewBillingHandler(mqHandler)
+	if err != nil {
+		return nil, fmt.Errorf("error creating billing handler:%w", err)
+	}
+
+	priceGroup := apiGroup.Group("/prices")
+	{
+		priceGroup.GET("", needAPIKey, billHandler.ListPrices)
+		priceGroup.POST("", needAPIKey, billHandler.CreatePrice)
+		priceGroup.GET("/:id", needAPIKey, billHandler.GetPrice)
+		priceGroup.PUT("/:id", needAPIKey, billHandler.UpdatePrice)
+		priceGroup.DELETE("/:id", needAPIKey, billHandler.DeletePrice)
+	}
+
+	accountGroup := apiGroup.Group("/accounts")
+	{
+		accountGroup.GET("", needAPIKey, billHandler.ListAccounts)
+		accountGroup.GET("/:id", needAPIKey, billHandler.GetAccount)
+		accountGroup.PUT("/:id", needAPIKey, billHandler.UpdateAccount)
+		accountGroup.POST("/:id/recharge", needAPIKey, billHandler.Recharge)
+		accountGroup.GET("/:id/statements", needAPIKey, billHandler.ListStatements)
+		accountGroup.GET("/:id/bills", needAPIKey, billHandler.ListBills)
+		accountGroup.GET("/:id/invoices", needAPIKey, billHandler.ListInvoices)
+	}
+
+	// usage analytics
+	usageHandler, err := handler.NewUsageHandler()
+	if err != nil {
+		return nil, fmt.Errorf("error creating usage handler:%w", err)
+	}
+
+	usageGroup := apiGroup.Group("/usage")
+	{
+		usageGroup.GET("/:id", needAPIKey, usageHandler.AggregateUsage)
+	}
+
+	// dead-letter queue of metering events
+	dlqHandler, err := handler.NewDLQHandler(mqHandler)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dlq handler:%w", err)
+	}
+
+	dlqGroup := apiGroup.Group("/dlq")
+	{
+		dlqGroup.GET("", needAPIKey, dlqHandler.ListMessages)
+		dlqGroup.DELETE("", needAPIKey, dlqHandler.Purge)
+		dlqGroup.POST("/replay", needAPIKey, dlqHandler.ReplayAll)
+		dlqGroup.GET("/:seq", needAPIKey, dlqHandler.GetMessage)
+		dlqGroup.DELETE("/:seq", needAPIKey, dlqHandler.DeleteMessage)
+		dlqGroup.POST("/:seq/replay", needAPIKey, dlqHandler.Replay)
+	}
+
 	return r, nil
 }
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+func NewBatchInferenceJobHandler(config *config.Config) (*BatchInferenceJobHandler, error) {
+	c, err := component.NewBatchInferenceJobComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchInferenceJobHandler{
+		c: c,
+	}, nil
+}
+
+type BatchInferenceJobHandler struct {
+	c component.BatchInferenceJobComponent
+}
+
+// CreateBatchInferenceJob godoc
+// @Security     ApiKey
+// @Summary      Submit a batch inference job
+// @Description  call a deployed model with every row of a parquet or jsonl file in a dataset, the outputs are written to a new branch of the dataset or a new dataset
+// @Tags         BatchInference
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        body body types.CreateBatchInferenceJobReq true "body"
+// @Success      200  {object}  types.Response{data=types.BatchInferenceJob} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /batch_inference_jobs [post]
+func (h *BatchInferenceJobHandler) Create(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	var req types.CreateBatchInferenceJobReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.CurrentUser = currentUser
+	job, err := h.c.Create(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, job)
+}
+
+// GetBatchInferenceJobs godoc
+// @Security     ApiKey
+// @Summary      List batch inference jobs
+// @Description  list batch inference jobs of current user
+// @Tags         BatchInference
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        per query int false "per" default(50)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.BatchInferenceJob,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /batch_inference_jobs [get]
+func (h *BatchInferenceJobHandler) Index(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	jobs, total, err := h.c.Index(ctx, currentUser, per, page)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  jobs,
+		"total": total,
+	}
+	httpbase.OK(ctx, respData)
+}
+
+// GetBatchInferenceJob godoc
+// @Security     ApiKey
+// @Summary      Get a batch inference job
+// @Description  get a batch inference job with its progress and token usage
+// @Tags         BatchInference
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "batch inference job id"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{data=types.BatchInferenceJob} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /batch_inference_jobs/{id} [get]
+func (h *BatchInferenceJobHandler) Show(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	job, err := h.c.Show(ctx, currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, job)
+}
+
+// CancelBatchInferenceJob godoc
+// @Security     ApiKey
+// @Summary      Cancel a batch inference job
+// @Description  stop an unfinished batch inference job, outputs of the processed rows are not written
+// @Tags         BatchInference
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "batch inference job id"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /batch_inference_jobs/{id}/cancel [post]
+func (h *BatchInferenceJobHandler) Cancel(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = h.c.Cancel(ctx, currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (h *BatchInferenceJobHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden), errors.Is(err, component.ErrUserNotFound):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrBadRequest), errors.Is(err, component.ErrAlreadyExists):
+		httpbase.BadRequest(ctx, err.Error())
+	default:
+		slog.Error("Failed to handle batch inference job request", slog.String("path", ctx.Request.URL.Path), "error", err)
+		httpbase.ServerError(ctx, err)
+	}
+}
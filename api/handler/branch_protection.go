@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+type BranchProtectionHandler struct {
+	c component.BranchProtectionComponent
+}
+
+func NewBranchProtectionHandler(cfg *config.Config) (*BranchProtectionHandler, error) {
+	c, err := component.NewBranchProtectionComponent(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create branch protection component: %w", err)
+	}
+	return &BranchProtectionHandler{
+		c: c,
+	}, nil
+}
+
+// ListBranchProtections godoc
+// @Security     ApiKey
+// @Summary      List branch protection rules of a repository
+// @Tags         BranchProtection
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Success      200  {object}  types.Response{data=[]types.BranchProtection} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/branch_protections [get]
+func (h *BranchProtectionHandler) Index(ctx *gin.Context) {
+	req, err := h.baseReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	protections, err := h.c.Index(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to list branch protections", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, protections)
+}
+
+// CreateBranchProtection godoc
+// @Security     ApiKey
+// @Summary      Create a branch protection rule
+// @Description  protect the branches matching the pattern from force push, deletion, push by non-admins or direct push
+// @Tags         BranchProtection
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        body body types.BranchProtectionReq true "body"
+// @Success      200  {object}  types.Response{data=types.BranchProtection} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/branch_protections [post]
+func (h *BranchProtectionHandler) Create(ctx *gin.Context) {
+	req, err := h.baseReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	protection, err := h.c.Create(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to create branch protection", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, protection)
+}
+
+// UpdateBranchProtection godoc
+// @Security     ApiKey
+// @Summary      Update a branch protection rule
+// @Tags         BranchProtection
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the branch protection rule"
+// @Param        body body types.BranchProtectionReq true "body"
+// @Success      200  {object}  types.Response{data=types.BranchProtection} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/branch_protections/{id} [put]
+func (h *BranchProtectionHandler) Update(ctx *gin.Context) {
+	req, err := h.baseReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.ID, err = strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		httpbase.BadRequest(ctx, fmt.Sprintf("invalid branch protection id: %s", ctx.Param("id")))
+		return
+	}
+	protection, err := h.c.Update(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to update branch protection", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, protection)
+}
+
+// DeleteBranchProtection godoc
+// @Security     ApiKey
+// @Summary      Delete a branch protection rule
+// @Tags         BranchProtection
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the branch protection rule"
+// @Success      200  {object}  types.Response "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/branch_protections/{id} [delete]
+func (h *BranchProtectionHandler) Delete(ctx *gin.Context) {
+	req, err := h.baseReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	req.ID, err = strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		httpbase.BadRequest(ctx, fmt.Sprintf("invalid branch protection id: %s", ctx.Param("id")))
+		return
+	}
+	err = h.c.Delete(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to delete branch protection", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (h *BranchProtectionHandler) baseReq(ctx *gin.Context) (*types.BranchProtectionReq, error) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace and name from request context: %w", err)
+	}
+	return &types.BranchProtectionReq{
+		RepoType:    types.RepositoryType(strings.TrimRight(ctx.Param("repo_type"), "s")),
+		Namespace:   namespace,
+		Name:        name,
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	}, nil
+}
+
+func (h *BranchProtectionHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrAlreadyExists), errors.Is(err, component.ErrBadRequest):
+		httpbase.BadRequest(ctx, err.Error())
+	default:
+		httpbase.ServerError(ctx, err)
+	}
+}
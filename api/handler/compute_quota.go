@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+func NewComputeQuotaHandler(config *config.Config) (*ComputeQuotaHandler, error) {
+	c, err := component.NewComputeQuotaComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ComputeQuotaHandler{
+		c: c,
+	}, nil
+}
+
+type ComputeQuotaHandler struct {
+	c component.ComputeQuotaComponent
+}
+
+// GetComputeQuotas godoc
+// @Security     ApiKey
+// @Summary      List compute quotas
+// @Description  list compute quotas of users and organizations, for admin only
+// @Tags         ComputeQuota
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        per query int false "per" default(50)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.ComputeQuota,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /compute_quotas [get]
+func (h *ComputeQuotaHandler) Index(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	quotas, total, err := h.c.Index(ctx, currentUser, per, page)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  quotas,
+		"total": total,
+	}
+	httpbase.OK(ctx, respData)
+}
+
+// GetComputeQuota godoc
+// @Security     ApiKey
+// @Summary      Get a compute quota
+// @Description  get a compute quota with the resources used by its user or organization, for admin only
+// @Tags         ComputeQuota
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "compute quota id"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{data=types.ComputeQuota} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /compute_quotas/{id} [get]
+func (h *ComputeQuotaHandler) Show(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	quota, err := h.c.Show(ctx, currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, quota)
+}
+
+// CreateComputeQuota godoc
+// @Security     ApiKey
+// @Summary      Create a compute quota
+// @Description  create a compute quota for a user or organization, a limit not set means unlimited, for admin only
+// @Tags         ComputeQuota
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        body body types.CreateComputeQuotaReq true "body"
+// @Success      200  {object}  types.Response{data=types.ComputeQuota} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /compute_quotas [post]
+func (h *ComputeQuotaHandler) Create(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	var req types.CreateComputeQuotaReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.CurrentUser = currentUser
+	quota, err := h.c.Create(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, quota)
+}
+
+// UpdateComputeQuota godoc
+// @Security     ApiKey
+// @Summary      Update a compute quota
+// @Description  replace the limits of a compute quota, a limit not set means unlimited, for admin only
+// @Tags         ComputeQuota
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "compute quota id"
+// @Param        current_user query string true "current user"
+// @Param        body body types.UpdateComputeQuotaReq true "body"
+// @Success      200  {object}  types.Response{data=types.ComputeQuota} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /compute_quotas/{id} [put]
+func (h *ComputeQuotaHandler) Update(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.UpdateComputeQuotaReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.ID = id
+	req.CurrentUser = currentUser
+	quota, err := h.c.Update(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, quota)
+}
+
+// DeleteComputeQuota godoc
+// @Security     ApiKey
+// @Summary      Delete a compute quota
+// @Description  delete a compute quota, the user or organization becomes unlimited, for admin only
+// @Tags         ComputeQuota
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "compute quota id"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /compute_quotas/{id} [delete]
+func (h *ComputeQuotaHandler) Delete(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = h.c.Delete(ctx, currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (h *ComputeQuotaHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden), errors.Is(err, component.ErrUserNotFound):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrAlreadyExists):
+		httpbase.BadRequest(ctx, err.Error())
+	default:
+		slog.Error("Failed to handle compute quota request", slog.String("path", ctx.Request.URL.Path), "error", err)
+		httpbase.ServerError(ctx, err)
+	}
+}
+
+// quotaExceeded responds with the error code of the exceeded limit if a deploy exceeds the compute quota
+func quotaExceeded(ctx *gin.Context, err error) bool {
+	var quotaErr *component.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	httpbase.ForbiddenError(ctx, quotaErr.Code(), quotaErr, quotaErr.ComputeQuotaExceeded)
+	return true
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/component"
+)
+
+func NewDeployQueueHandler(config *config.Config) (*DeployQueueHandler, error) {
+	c, err := component.NewDeployQueueComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &DeployQueueHandler{
+		c: c,
+	}, nil
+}
+
+type DeployQueueHandler struct {
+	c component.DeployQueueComponent
+}
+
+// GetDeployQueue godoc
+// @Security     ApiKey
+// @Summary      List the deploy task queue
+// @Description  list the running deploy tasks and the waiting ones in the order they will run, for admin only
+// @Tags         Deploy
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{data=[]types.DeployQueueTask} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /deploy_queue [get]
+func (h *DeployQueueHandler) Index(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	tasks, err := h.c.Index(ctx, currentUser)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, tasks)
+}
+
+// SetDeployTaskPriority godoc
+// @Security     ApiKey
+// @Summary      Set priority of a deploy task
+// @Description  move a waiting deploy task up or down in the queue, the priority adds to the priority of deploy type, for admin only
+// @Tags         Deploy
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "deploy task id"
+// @Param        current_user query string true "current user"
+// @Param        body body types.SetDeployTaskPriorityReq true "body"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /deploy_queue/{id}/priority [put]
+func (h *DeployQueueHandler) SetPriority(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.SetDeployTaskPriorityReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.TaskID = id
+	req.CurrentUser = currentUser
+	err = h.c.SetPriority(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (h *DeployQueueHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden), errors.Is(err, component.ErrUserNotFound):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	default:
+		slog.Error("Failed to handle deploy queue request", slog.String("path", ctx.Request.URL.Path), "error", err)
+		httpbase.ServerError(ctx, err)
+	}
+}
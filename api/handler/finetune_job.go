@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+func NewFinetuneJobHandler(config *config.Config) (*FinetuneJobHandler, error) {
+	c, err := component.NewFinetuneJobComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &FinetuneJobHandler{
+		c: c,
+	}, nil
+}
+
+type FinetuneJobHandler struct {
+	c component.FinetuneJobComponent
+}
+
+// CreateFinetuneJob godoc
+// @Security     ApiKey
+// @Summary      Submit a finetune job
+// @Description  finetune a model on a dataset, the weights are pushed to a new model on success
+// @Tags         Finetune
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        body body types.CreateFinetuneJobReq true "body"
+// @Success      200  {object}  types.Response{data=types.FinetuneJob} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /finetune_jobs [post]
+func (h *FinetuneJobHandler) Create(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	var req types.CreateFinetuneJobReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.CurrentUser = currentUser
+	job, err := h.c.Create(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, job)
+}
+
+// GetFinetuneJobs godoc
+// @Security     ApiKey
+// @Summary      List finetune jobs
+// @Description  list finetune jobs of current user
+// @Tags         Finetune
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        per query int false "per" default(50)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.FinetuneJob,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /finetune_jobs [get]
+func (h *FinetuneJobHandler) Index(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	jobs, total, err := h.c.Index(ctx, currentUser, per, page)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  jobs,
+		"total": total,
+	}
+	httpbase.OK(ctx, respData)
+}
+
+// GetFinetuneJob godoc
+// @Security     ApiKey
+// @Summary      Get a finetune job
+// @Description  get a finetune job with its progress and checkpoints
+// @Tags         Finetune
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "finetune job id"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{data=types.FinetuneJob} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /finetune_jobs/{id} [get]
+func (h *FinetuneJobHandler) Show(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	job, err := h.c.Show(ctx, currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, job)
+}
+
+// CancelFinetuneJob godoc
+// @Security     ApiKey
+// @Summary      Cancel a finetune job
+// @Description  stop the training container of an unfinished finetune job
+// @Tags         Finetune
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "finetune job id"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /finetune_jobs/{id}/cancel [post]
+func (h *FinetuneJobHandler) Cancel(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = h.c.Cancel(ctx, currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// GetFinetuneJobLogs godoc
+// @Security     ApiKey
+// @Summary      Stream logs of a finetune job
+// @Description  stream logs of the training container as server sent events
+// @Tags         Finetune
+// @Accept       json
+// @Produce      text/event-stream
+// @Param        id path int true "finetune job id"
+// @Param        current_user query string true "current user"
+// @Success      200  {string}  string "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /finetune_jobs/{id}/logs [get]
+func (h *FinetuneJobHandler) Logs(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+
+	// user http request context instead of gin context, so that server knows the life cycle of the request
+	logReader, err := h.c.Logs(ctx.Request.Context(), currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	if logReader.RunLog() == nil {
+		httpbase.ServerError(ctx, errors.New("don't find any finetune job log"))
+		return
+	}
+
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Writer.Header().Set("Transfer-Encoding", "chunked")
+	// to quickly respond the http request
+	ctx.Writer.WriteHeader(http.StatusOK)
+	ctx.Writer.Flush()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			slog.Info("finetune job logs request context done", slog.Any("error", ctx.Request.Context().Err()))
+			return
+		case data, ok := <-logReader.RunLog():
+			if ok {
+				ctx.SSEvent("Container", string(data))
+				ctx.Writer.Flush()
+			}
+		}
+	}
+}
+
+// ReportFinetuneJobEvent godoc
+// @Security     ApiKey
+// @Summary      Report an event of a finetune job
+// @Description  called by the training container with the access token of job owner and the event token of the job to report progress, checkpoints and the result
+// @Tags         Finetune
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "finetune job id"
+// @Param        X-Finetune-Job-Token header string true "event token passed to the training container in env FINETUNE_JOB_TOKEN"
+// @Param        body body types.FinetuneJobEventReq true "body"
+// @Success      200  {object}  types.Response{data=types.FinetuneJobEventResp} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /finetune_jobs/{id}/events [post]
+func (h *FinetuneJobHandler) ReportEvent(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.FinetuneJobEventReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.JobID = id
+	req.CurrentUser = currentUser
+	req.EventToken = ctx.GetHeader(types.FinetuneJobEventTokenHeader)
+	resp, err := h.c.ReportEvent(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, resp)
+}
+
+func (h *FinetuneJobHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case quotaExceeded(ctx, err):
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden), errors.Is(err, component.ErrUserNotFound):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrBadRequest), errors.Is(err, component.ErrAlreadyExists):
+		httpbase.BadRequest(ctx, err.Error())
+	default:
+		slog.Error("Failed to handle finetune job request", slog.String("path", ctx.Request.URL.Path), "error", err)
+		httpbase.ServerError(ctx, err)
+	}
+}
@@ -12,7 +12,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/gddo/httputil"
+	"go.temporal.io/sdk/client"
 	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/api/workflow"
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
@@ -25,12 +27,14 @@ func NewGitHTTPHandler(config *config.Config) (*GitHTTPHandler, error) {
 		return nil, err
 	}
 	return &GitHTTPHandler{
-		c: uc,
+		c:      uc,
+		config: config,
 	}, nil
 }
 
 type GitHTTPHandler struct {
-	c component.GitHTTPComponent
+	c      component.GitHTTPComponent
+	config *config.Config
 }
 
 func (h *GitHTTPHandler) InfoRefs(ctx *gin.Context) {
@@ -146,6 +150,12 @@ func (h *GitHTTPHandler) GitReceivePack(ctx *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, component.ErrBranchProtected) {
+			ctx.PureJSON(http.StatusForbidden, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -263,18 +273,49 @@ func (h *GitHTTPHandler) LfsVerify(ctx *gin.Context) {
 		httpbase.BadRequest(ctx, err.Error())
 		return
 	}
+	// the completion request of multipart transfer carries no size, which is in the query of the link
+	if size := ctx.Query("size"); size != "" && pointer.Size == 0 {
+		var err error
+		pointer.Size, err = strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			slog.Error("Bad request format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
 
 	verifyRequest.Namespace = ctx.GetString("namespace")
 	verifyRequest.Name = ctx.GetString("name")
 	verifyRequest.RepoType = types.RepositoryType(ctx.GetString("repo_type"))
 	verifyRequest.CurrentUser = httpbase.GetCurrentUser(ctx)
 
-	err := h.c.LfsVerify(ctx, verifyRequest, pointer)
+	contentReq, err := h.c.LfsVerify(ctx, verifyRequest, pointer)
 	if err != nil {
+		if errors.Is(err, component.ErrForbidden) {
+			ctx.PureJSON(http.StatusForbidden, gin.H{
+				"error": "You do not have permission to access this repository.",
+			})
+			return
+		}
 		slog.Error("Bad request format", "error", err)
 		httpbase.BadRequest(ctx, err.Error())
 		return
 	}
+	if contentReq != nil {
+		// verify requests of the same object share the workflow
+		workflowOptions := client.StartWorkflowOptions{
+			ID:        fmt.Sprintf("verify-lfs-object-%d-%s", contentReq.StorageID, contentReq.Oid),
+			TaskQueue: workflow.HandlePushQueueName,
+		}
+		we, err := workflow.GetWorkflowClient().ExecuteWorkflow(ctx, workflowOptions, workflow.VerifyLfsObjectContentWorkflow,
+			contentReq, h.config)
+		if err != nil {
+			slog.Error("failed to start verify lfs object content workflow", slog.String("oid", contentReq.Oid), slog.Any("error", err))
+			httpbase.ServerError(ctx, err)
+			return
+		}
+		slog.Info("start verify lfs object content workflow", slog.String("workflow_id", we.GetID()))
+	}
 	ctx.PureJSON(http.StatusOK, nil)
 }
 
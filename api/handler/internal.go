@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -54,27 +55,52 @@ func (h *InternalHandler) SSHAllowed(ctx *gin.Context) {
 		httpbase.BadRequest(ctx, err.Error())
 		return
 	}
-	if rawReq.Protocol == "ssh" {
-		if rawReq.GlRepository != "" {
-			repoPath = rawReq.GlRepository
-		} else {
-			repoPath = rawReq.Project
+	if rawReq.GlRepository != "" {
+		repoPath = rawReq.GlRepository
+	} else {
+		repoPath = rawReq.Project
+	}
+	req.RepoType, req.Namespace, req.Name = getRepoInfoFronClonePath(repoPath)
+	req.Action = rawReq.Action
+	req.Changes = rawReq.Changes
+	req.KeyID = rawReq.KeyID
+	req.UserID = rawReq.UserID
+	req.Env = rawReq.Env
+	req.Protocol = rawReq.Protocol
+	req.CheckIP = rawReq.CheckIP
+
+	switch {
+	// pre-receive hook of pushes identified by user instead of ssh key. Operations of csghub itself like editing files
+	// and merging pull requests are sent with `web` protocol, they are committed as the git server user and are checked
+	// against the branch protection rules by the components with the user of request instead
+	case (rawReq.Protocol == "ssh" || rawReq.Protocol == "http") && rawReq.Action == "git-receive-pack" &&
+		rawReq.KeyID == "" && rawReq.UserID != "":
+		err := h.c.PushAllowed(ctx, req)
+		if err != nil {
+			if errors.Is(err, component.ErrBranchProtected) {
+				h.pushDenied(ctx, err)
+				return
+			}
+			httpbase.ServerError(ctx, err)
+			return
 		}
-		req.RepoType, req.Namespace, req.Name = getRepoInfoFronClonePath(repoPath)
-		req.Action = rawReq.Action
-		req.Changes = rawReq.Changes
-		req.KeyID = rawReq.KeyID
-		req.Protocol = rawReq.Protocol
-		req.CheckIP = rawReq.CheckIP
-
+		ctx.PureJSON(http.StatusOK, gin.H{
+			"status":  true,
+			"message": "allowed",
+		})
+	case rawReq.Protocol == "ssh":
 		resp, err := h.c.SSHAllowed(ctx, req)
 		if err != nil {
+			if errors.Is(err, component.ErrBranchProtected) {
+				h.pushDenied(ctx, err)
+				return
+			}
 			httpbase.ServerError(ctx, err)
 			return
 		}
 
 		ctx.PureJSON(http.StatusOK, resp)
-	} else {
+	default:
 		ctx.PureJSON(http.StatusOK, gin.H{
 			"status":  true,
 			"message": "allowed",
@@ -82,6 +108,14 @@ func (h *InternalHandler) SSHAllowed(ctx *gin.Context) {
 	}
 }
 
+// pushDenied tells gitlab-shell or gitaly hook to reject the push, the message is shown to the git client
+func (h *InternalHandler) pushDenied(ctx *gin.Context, err error) {
+	ctx.PureJSON(http.StatusForbidden, gin.H{
+		"status":  false,
+		"message": err.Error(),
+	})
+}
+
 func (h *InternalHandler) LfsAuthenticate(ctx *gin.Context) {
 	var req types.LfsAuthenticateReq
 	if err := ctx.ShouldBind(&req); err != nil {
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+	"opencsg.com/csghub-server/api/httpbase"
+	deployStatus "opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/proxy"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/component"
+)
+
+// max size of the request body read to find the model of request
+const llmGatewayMaxBodyBytes = 32 << 20
+
+type LLMGatewayHandler struct {
+	gateway            component.LLMGatewayComponent
+	rateLimitPerMinute int
+	limiters           *userRateLimiters
+}
+
+// userRateLimiters keeps a rate limiter of every user calling the gateway, the limiters idle for longer
+// than idleTimeout are evicted since they are full again and the same as new ones
+type userRateLimiters struct {
+	limit       rate.Limit
+	burst       int
+	idleTimeout time.Duration
+
+	mu        sync.Mutex
+	limiters  map[string]*userRateLimiter
+	lastSweep time.Time
+}
+
+type userRateLimiter struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+func newUserRateLimiters(perMinute int) *userRateLimiters {
+	return &userRateLimiters{
+		limit:       rate.Limit(float64(perMinute) / 60),
+		burst:       perMinute,
+		idleTimeout: time.Minute,
+		limiters:    make(map[string]*userRateLimiter),
+	}
+}
+
+// reserve reserves a request of user at now, the delay the request has to wait is returned, the
+// reservation is canceled if there is a delay
+func (l *userRateLimiters) reserve(username string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= l.idleTimeout {
+		for name, limiter := range l.limiters {
+			if now.Sub(limiter.lastSeen) >= l.idleTimeout {
+				delete(l.limiters, name)
+			}
+		}
+		l.lastSweep = now
+	}
+	limiter, ok := l.limiters[username]
+	if !ok {
+		limiter = &userRateLimiter{Limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[username] = limiter
+	}
+	limiter.lastSeen = now
+	r := limiter.ReserveN(now, 1)
+	delay := r.DelayFrom(now)
+	if delay > 0 {
+		r.CancelAt(now)
+	}
+	return delay
+}
+
+func NewLLMGatewayHandler(config *config.Config) (*LLMGatewayHandler, error) {
+	gateway, err := component.NewLLMGatewayComponent(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create llm gateway component,%w", err)
+	}
+	return &LLMGatewayHandler{
+		gateway:            gateway,
+		rateLimitPerMinute: config.LLMGateway.RateLimitPerMinute,
+		limiters:           newUserRateLimiters(config.LLMGateway.RateLimitPerMinute),
+	}, nil
+}
+
+// RequireUser rejects the requests without a valid CSGHub access token
+func (h *LLMGatewayHandler) RequireUser(ctx *gin.Context) {
+	if httpbase.GetCurrentUser(ctx) == "" {
+		openAIError(ctx, http.StatusUnauthorized, "invalid_request_error", "invalid_api_key",
+			errors.New("a valid access token is required in the Authorization header"))
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
+
+// RateLimit limits the requests of every user to the configured rate per minute
+func (h *LLMGatewayHandler) RateLimit(ctx *gin.Context) {
+	if h.rateLimitPerMinute <= 0 {
+		ctx.Next()
+		return
+	}
+	username := httpbase.GetCurrentUser(ctx)
+	if delay := h.limiters.reserve(username, time.Now()); delay > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+		openAIError(ctx, http.StatusTooManyRequests, "rate_limit_error", "rate_limit_exceeded",
+			fmt.Errorf("rate limit of %d requests per minute exceeded", h.rateLimitPerMinute))
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
+
+func (h *LLMGatewayHandler) ListModels(ctx *gin.Context) {
+	models, err := h.gateway.ListModels(ctx, httpbase.GetCurrentUser(ctx))
+	if err != nil {
+		slog.Error("failed to list models of llm gateway", slog.Any("error", err))
+		openAIError(ctx, http.StatusInternalServerError, "api_error", "", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, types.OpenAIModelList{
+		Object: "list",
+		Data:   models,
+	})
+}
+
+// Proxy routes the openai compatible request to the endpoint of the model in request body
+func (h *LLMGatewayHandler) Proxy(ctx *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, llmGatewayMaxBodyBytes))
+	if err != nil {
+		openAIError(ctx, http.StatusBadRequest, "invalid_request_error", "", fmt.Errorf("failed to read request body, %w", err))
+		return
+	}
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Model == "" {
+		openAIError(ctx, http.StatusBadRequest, "invalid_request_error", "",
+			errors.New("request body must be json with the model field"))
+		return
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	ctx.Request.ContentLength = int64(len(body))
+
+	username := httpbase.GetCurrentUser(ctx)
+	deploy, err := h.gateway.FindEndpoint(ctx, username, req.Model)
+	if err != nil {
+		switch {
+		case errors.Is(err, component.ErrNotFound):
+			openAIError(ctx, http.StatusNotFound, "invalid_request_error", "model_not_found", err)
+		case errors.Is(err, component.ErrForbidden):
+			openAIError(ctx, http.StatusForbidden, "invalid_request_error", "model_not_found", err)
+		default:
+			slog.Error("failed to find endpoint in llm gateway", slog.String("model", req.Model), slog.Any("error", err))
+			openAIError(ctx, http.StatusInternalServerError, "api_error", "", err)
+		}
+		return
+	}
+
+	h.gateway.RecordEndpointRequest(deploy)
+	if !h.endpointReady(ctx, deploy) {
+		return
+	}
+
+	// the access token of CSGHub is not passed to the model
+	ctx.Request.Header.Del("Authorization")
+	rp, err := proxy.NewReverseProxy(h.gateway.Target(deploy))
+	if err != nil {
+		slog.Error("invalid endpoint of deploy", slog.Int64("deploy_id", deploy.ID), slog.Any("error", err))
+		openAIError(ctx, http.StatusInternalServerError, "api_error", "", err)
+		return
+	}
+	w := &usageResponseWriter{ResponseWriter: ctx.Writer}
+	rp.ServeHTTP(w, ctx.Request, ctx.Request.URL.Path)
+	if usage, ok := w.Usage(); ok {
+		go h.gateway.PublishEndpointTokenUsage(context.Background(), username, deploy, usage)
+	}
+}
+
+// endpointReady wakes up the endpoint scaled to zero, requests are rejected with 503 until
+// the endpoint is running
+func (h *LLMGatewayHandler) endpointReady(ctx *gin.Context, deploy *database.Deploy) bool {
+	switch deploy.Status {
+	case deployStatus.Running:
+		return true
+	case deployStatus.Sleeping:
+		err := h.gateway.WakeupEndpoint(ctx, deploy)
+		if err != nil {
+			slog.Error("failed to wake up endpoint", slog.Any("deployID", deploy.ID), slog.Any("error", err))
+			openAIError(ctx, http.StatusInternalServerError, "api_error", "", fmt.Errorf("failed to wake up endpoint, %w", err))
+			return false
+		}
+	}
+	ctx.Header("Retry-After", "30")
+	openAIError(ctx, http.StatusServiceUnavailable, "api_error", "model_not_ready",
+		errors.New("model endpoint is starting, please retry later"))
+	return false
+}
+
+func openAIError(ctx *gin.Context, status int, errType, code string, err error) {
+	ctx.JSON(status, types.OpenAIError{
+		Error: types.OpenAIErrorDetail{
+			Message: err.Error(),
+			Type:    errType,
+			Code:    code,
+		},
+	})
+}
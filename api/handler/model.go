@@ -658,6 +658,9 @@ func (h *ModelHandler) DeployDedicated(ctx *gin.Context) {
 	if err != nil {
 		slog.Error("failed to deploy model as inference", slog.String("namespace", namespace),
 			slog.String("name", name), slog.Any("currentUser", currentUser), slog.Any("req", req), slog.Any("error", err))
+		if quotaExceeded(ctx, err) {
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -740,6 +743,9 @@ func (h *ModelHandler) FinetuneCreate(ctx *gin.Context) {
 	if err != nil {
 		slog.Error("failed to deploy model as notebook instance", slog.String("namespace", namespace),
 			slog.String("name", name), slog.Any("error", err))
+		if quotaExceeded(ctx, err) {
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -966,6 +972,9 @@ func (h *ModelHandler) DeployStart(ctx *gin.Context) {
 	err = h.repo.DeployStart(ctx, startReq)
 	if err != nil {
 		slog.Error("Failed to start deploy", slog.Any("error", err), slog.Any("repoType", types.ModelRepo), slog.String("namespace", namespace), slog.String("name", name), slog.Any("deployID", id))
+		if quotaExceeded(ctx, err) {
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -1133,6 +1142,9 @@ func (h *ModelHandler) FinetuneStart(ctx *gin.Context) {
 	err = h.repo.DeployStart(ctx, startReq)
 	if err != nil {
 		slog.Error("Failed to start deploy", slog.Any("error", err), slog.Any("repoType", types.ModelRepo), slog.String("namespace", namespace), slog.String("name", name), slog.Any("deployID", id))
+		if quotaExceeded(ctx, err) {
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -1429,6 +1441,9 @@ func (h *ModelHandler) DeployServerless(ctx *gin.Context) {
 	if err != nil {
 		slog.Error("failed to deploy model as serverless", slog.String("namespace", namespace),
 			slog.String("name", name), slog.Any("currentUser", currentUser), slog.Any("req", req), slog.Any("error", err))
+		if quotaExceeded(ctx, err) {
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -1492,6 +1507,9 @@ func (h *ModelHandler) ServerlessStart(ctx *gin.Context) {
 	err = h.repo.DeployStart(ctx, startReq)
 	if err != nil {
 		slog.Error("Failed to start deploy", slog.Any("error", err), slog.Any("repoType", types.ModelRepo), slog.String("namespace", namespace), slog.String("name", name), slog.Any("deployID", id))
+		if quotaExceeded(ctx, err) {
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
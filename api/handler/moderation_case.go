@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+func NewModerationCaseHandler(config *config.Config) (*ModerationCaseHandler, error) {
+	c, err := component.NewModerationCaseComponent(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation case component: %w", err)
+	}
+	return &ModerationCaseHandler{
+		c: c,
+	}, nil
+}
+
+type ModerationCaseHandler struct {
+	c component.ModerationCaseComponent
+}
+
+// GetModerationCases godoc
+// @Security     ApiKey
+// @Summary      List moderation cases
+// @Description  list the moderation cases of a repository for its admins, or the review queue of all repositories for moderators
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        status query string false "status of cases" Enums(open,appealed,upheld,cleared)
+// @Param        per query int false "per" default(50)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.ModerationCase,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/moderation_cases [get]
+// @Router       /moderation_cases [get]
+func (h *ModerationCaseHandler) Index(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ModerationCasesReq{
+		Status:      types.ModerationCaseStatus(ctx.Query("status")),
+		CurrentUser: currentUser,
+		Per:         per,
+		Page:        page,
+	}
+	if ctx.Param("name") != "" {
+		req.Namespace, req.Name, err = common.GetNamespaceAndNameFromContext(ctx)
+		if err != nil {
+			slog.Error("Bad request format", "error", err)
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		req.RepoType = types.RepositoryType(strings.TrimRight(ctx.Param("repo_type"), "s"))
+	}
+	cases, total, err := h.c.Index(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  cases,
+		"total": total,
+	}
+	httpbase.OK(ctx, respData)
+}
+
+// GetModerationCase godoc
+// @Security     ApiKey
+// @Summary      Get a moderation case
+// @Description  get a moderation case with its audit log, for admins of the repository and moderators
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "moderation case id"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{data=types.ModerationCase} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /moderation_cases/{id} [get]
+func (h *ModerationCaseHandler) Show(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	mc, err := h.c.Show(ctx, currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, mc)
+}
+
+// AppealModerationCase godoc
+// @Security     ApiKey
+// @Summary      Appeal a moderation case
+// @Description  appeal an open moderation case for review by moderators, for admins of the repository
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "moderation case id"
+// @Param        current_user query string true "current user"
+// @Param        body body types.AppealModerationCaseReq true "body"
+// @Success      200  {object}  types.Response{data=types.ModerationCase} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /moderation_cases/{id}/appeal [post]
+func (h *ModerationCaseHandler) Appeal(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.AppealModerationCaseReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.ID = id
+	req.CurrentUser = currentUser
+	mc, err := h.c.Appeal(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, mc)
+}
+
+// DecideModerationCase godoc
+// @Security     ApiKey
+// @Summary      Decide a moderation case
+// @Description  approve or reject the appeal of a moderation case, or override the check result to clear the case, for moderators only
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "moderation case id"
+// @Param        current_user query string true "current user"
+// @Param        body body types.DecideModerationCaseReq true "body"
+// @Success      200  {object}  types.Response{data=types.ModerationCase} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /moderation_cases/{id}/decision [post]
+func (h *ModerationCaseHandler) Decide(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.DecideModerationCaseReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.ID = id
+	req.CurrentUser = currentUser
+	mc, err := h.c.Decide(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, mc)
+}
+
+func (h *ModerationCaseHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden),
+		errors.Is(err, component.ErrUserNotFound):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrBadRequest):
+		httpbase.BadRequest(ctx, err.Error())
+	default:
+		slog.Error("Failed to handle moderation case request", slog.String("path", ctx.Request.URL.Path), "error", err)
+		httpbase.ServerError(ctx, err)
+	}
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+func NewNotificationHandler(config *config.Config) (*NotificationHandler, error) {
+	c, err := component.NewNotificationComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &NotificationHandler{
+		c: c,
+	}, nil
+}
+
+type NotificationHandler struct {
+	c component.NotificationComponent
+}
+
+// GetNotifications godoc
+// @Security     ApiKey
+// @Summary      List notifications
+// @Description  list the notifications of current user, the latest go first
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        unread query bool false "list unread notifications only"
+// @Param        per query int false "per" default(50)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.Notification,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /notifications [get]
+func (h *NotificationHandler) Index(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	unreadOnly := ctx.Query("unread") == "true"
+	notifications, total, err := h.c.Index(ctx, currentUser, unreadOnly, per, page)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  notifications,
+		"total": total,
+	}
+	httpbase.OK(ctx, respData)
+}
+
+// MarkNotificationRead godoc
+// @Security     ApiKey
+// @Summary      Mark a notification as read
+// @Description  mark a notification of current user as read
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "notification id"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /notifications/{id}/read [put]
+func (h *NotificationHandler) MarkRead(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = h.c.MarkRead(ctx, currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// MarkAllNotificationsRead godoc
+// @Security     ApiKey
+// @Summary      Mark all notifications as read
+// @Description  mark all notifications of current user as read
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /notifications/read [put]
+func (h *NotificationHandler) MarkAllRead(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	err := h.c.MarkAllRead(ctx, currentUser)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (h *NotificationHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUserNotFound):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	default:
+		slog.Error("Failed to handle notification request", slog.String("path", ctx.Request.URL.Path), "error", err)
+		httpbase.ServerError(ctx, err)
+	}
+}
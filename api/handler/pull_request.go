@@ -0,0 +1,532 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+type PullRequestHandler struct {
+	c  component.PullRequestComponent
+	sc component.SensitiveComponent
+}
+
+func NewPullRequestHandler(cfg *config.Config) (*PullRequestHandler, error) {
+	c, err := component.NewPullRequestComponent(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request component: %w", err)
+	}
+	sc, err := component.NewSensitiveComponent(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sensitive component: %w", err)
+	}
+	return &PullRequestHandler{
+		c:  c,
+		sc: sc,
+	}, nil
+}
+
+// CreatePullRequest godoc
+// @Security     ApiKey
+// @Summary      Create a pull request
+// @Description  create a pull request to merge the source branch into the target branch of the repository, the source branch can be in another repository like a fork
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        body body types.CreatePullRequestReq true "body"
+// @Success      200  {object}  types.Response{data=types.PullRequest} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls [post]
+func (h *PullRequestHandler) Create(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.CreatePullRequestReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	_, err = h.sc.CheckRequestV2(ctx, &req)
+	if err != nil {
+		slog.Error("failed to check sensitive request", slog.Any("error", err))
+		httpbase.BadRequest(ctx, fmt.Errorf("sensitive check failed: %w", err).Error())
+		return
+	}
+
+	req.CurrentUser = currentUser
+	req.RepoType = h.getRepoType(ctx)
+	req.Namespace = namespace
+	req.Name = name
+	pr, err := h.c.Create(ctx, &req)
+	if err != nil {
+		slog.Error("Failed to create pull request", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to create pull request: %w", err))
+		return
+	}
+	httpbase.OK(ctx, pr)
+}
+
+// ListPullRequests godoc
+// @Security     ApiKey
+// @Summary      List pull requests of a repository
+// @Description  list pull requests of a repository, newest first
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string false "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        status query string false "filter by status" Enums(open,closed,merged)
+// @Param        per query int false "per" default(20)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.PullRequest,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls [get]
+func (h *PullRequestHandler) Index(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ListPullRequestsReq{
+		RepoType:    h.getRepoType(ctx),
+		Namespace:   namespace,
+		Name:        name,
+		Status:      types.PullRequestStatus(ctx.Query("status")),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+		Per:         per,
+		Page:        page,
+	}
+	prs, total, err := h.c.Index(ctx, &req)
+	if err != nil {
+		slog.Error("Failed to list pull requests", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to list pull requests: %w", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  prs,
+		"total": total,
+	})
+}
+
+// ShowPullRequest godoc
+// @Security     ApiKey
+// @Summary      Show a pull request
+// @Description  show a pull request with its review comments
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string false "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "the pull request number"
+// @Success      200  {object}  types.Response{data=types.PullRequest} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id} [get]
+func (h *PullRequestHandler) Show(ctx *gin.Context) {
+	req, err := h.actReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	pr, err := h.c.Show(ctx, req)
+	if err != nil {
+		slog.Error("Failed to show pull request", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to show pull request: %w", err))
+		return
+	}
+	httpbase.OK(ctx, pr)
+}
+
+// UpdatePullRequest godoc
+// @Security     ApiKey
+// @Summary      Update a pull request
+// @Description  update title or description of a pull request
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "the pull request number"
+// @Param        body body types.UpdatePullRequestReq true "body"
+// @Success      200  {object}  types.Response{data=types.PullRequest} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id} [put]
+func (h *PullRequestHandler) Update(ctx *gin.Context) {
+	actReq, err := h.actReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if actReq.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	var req types.UpdatePullRequestReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	_, err = h.sc.CheckRequestV2(ctx, &req)
+	if err != nil {
+		slog.Error("failed to check sensitive request", slog.Any("error", err))
+		httpbase.BadRequest(ctx, fmt.Errorf("sensitive check failed: %w", err).Error())
+		return
+	}
+
+	req.RepoType = actReq.RepoType
+	req.Namespace = actReq.Namespace
+	req.Name = actReq.Name
+	req.ID = actReq.ID
+	req.CurrentUser = actReq.CurrentUser
+	pr, err := h.c.Update(ctx, &req)
+	if err != nil {
+		slog.Error("Failed to update pull request", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to update pull request: %w", err))
+		return
+	}
+	httpbase.OK(ctx, pr)
+}
+
+// ClosePullRequest godoc
+// @Security     ApiKey
+// @Summary      Close a pull request
+// @Description  close an open pull request without merging it
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "the pull request number"
+// @Success      200  {object}  types.Response "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/close [put]
+func (h *PullRequestHandler) Close(ctx *gin.Context) {
+	req, err := h.actReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	err = h.c.Close(ctx, req)
+	if err != nil {
+		slog.Error("Failed to close pull request", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to close pull request: %w", err))
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// ReopenPullRequest godoc
+// @Security     ApiKey
+// @Summary      Reopen a pull request
+// @Description  reopen a closed pull request
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "the pull request number"
+// @Success      200  {object}  types.Response "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/reopen [put]
+func (h *PullRequestHandler) Reopen(ctx *gin.Context) {
+	req, err := h.actReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	err = h.c.Reopen(ctx, req)
+	if err != nil {
+		slog.Error("Failed to reopen pull request", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to reopen pull request: %w", err))
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// PullRequestDiff godoc
+// @Security     ApiKey
+// @Summary      Get diff of a pull request
+// @Description  get the changes of the source branch since it diverged from the target branch
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string false "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "the pull request number"
+// @Success      200  {object}  types.Response{data=types.PullRequestDiff} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/diff [get]
+func (h *PullRequestHandler) Diff(ctx *gin.Context) {
+	req, err := h.actReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	diff, err := h.c.Diff(ctx, req)
+	if err != nil {
+		slog.Error("Failed to get pull request diff", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to get pull request diff: %w", err))
+		return
+	}
+	httpbase.OK(ctx, diff)
+}
+
+// MergePullRequest godoc
+// @Security     ApiKey
+// @Summary      Merge a pull request
+// @Description  merge the source branch into the target branch with the given strategy
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "the pull request number"
+// @Param        body body types.MergePullRequestReq false "body"
+// @Success      200  {object}  types.Response{data=types.PullRequest} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/merge [post]
+func (h *PullRequestHandler) Merge(ctx *gin.Context) {
+	actReq, err := h.actReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if actReq.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	var req types.MergePullRequestReq
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
+	if req.Strategy != "" && !req.Strategy.Valid() {
+		httpbase.BadRequest(ctx, fmt.Sprintf("invalid merge strategy: %s", req.Strategy))
+		return
+	}
+	req.PullRequestActReq = *actReq
+	pr, err := h.c.Merge(ctx, &req)
+	if err != nil {
+		slog.Error("Failed to merge pull request", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to merge pull request: %w", err))
+		return
+	}
+	httpbase.OK(ctx, pr)
+}
+
+// CreatePullRequestComment godoc
+// @Security     ApiKey
+// @Summary      Create a review comment of a pull request
+// @Description  create a review comment, optionally anchored to a line of a file
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "the pull request number"
+// @Param        body body types.CreatePullRequestCommentReq true "body"
+// @Success      200  {object}  types.Response{data=types.PullRequestComment} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/comments [post]
+func (h *PullRequestHandler) CreateComment(ctx *gin.Context) {
+	actReq, err := h.actReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if actReq.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	var req types.CreatePullRequestCommentReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	_, err = h.sc.CheckRequestV2(ctx, &req)
+	if err != nil {
+		slog.Error("failed to check sensitive request", slog.Any("error", err))
+		httpbase.BadRequest(ctx, fmt.Errorf("sensitive check failed: %w", err).Error())
+		return
+	}
+	req.PullRequestActReq = *actReq
+	comment, err := h.c.CreateComment(ctx, &req)
+	if err != nil {
+		slog.Error("Failed to create pull request comment", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to create pull request comment: %w", err))
+		return
+	}
+	httpbase.OK(ctx, comment)
+}
+
+// ListPullRequestComments godoc
+// @Security     ApiKey
+// @Summary      List review comments of a pull request
+// @Description  list review comments of a pull request, oldest first
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string false "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "the pull request number"
+// @Success      200  {object}  types.Response{data=[]types.PullRequestComment} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/comments [get]
+func (h *PullRequestHandler) ListComments(ctx *gin.Context) {
+	req, err := h.actReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	comments, err := h.c.ListComments(ctx, req)
+	if err != nil {
+		slog.Error("Failed to list pull request comments", "error", err, "request", req)
+		h.handleError(ctx, fmt.Errorf("failed to list pull request comments: %w", err))
+		return
+	}
+	httpbase.OK(ctx, comments)
+}
+
+// DeletePullRequestComment godoc
+// @Security     ApiKey
+// @Summary      Delete a review comment of a pull request
+// @Description  delete a review comment, only the author or repository admin can delete it
+// @Tags         PullRequest
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "the pull request number"
+// @Param        comment_id path int true "the comment id"
+// @Success      200  {object}  types.Response "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/pulls/{id}/comments/{comment_id} [delete]
+func (h *PullRequestHandler) DeleteComment(ctx *gin.Context) {
+	req, err := h.actReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	commentID, err := strconv.ParseInt(ctx.Param("comment_id"), 10, 64)
+	if err != nil {
+		httpbase.BadRequest(ctx, "invalid comment id:"+ctx.Param("comment_id"))
+		return
+	}
+	err = h.c.DeleteComment(ctx, req, commentID)
+	if err != nil {
+		slog.Error("Failed to delete pull request comment", "error", err, "request", req, "comment_id", commentID)
+		h.handleError(ctx, fmt.Errorf("failed to delete pull request comment: %w", err))
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (h *PullRequestHandler) actReq(ctx *gin.Context) (*types.PullRequestActReq, error) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace and name from request context: %w", err)
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pull request id: %s", ctx.Param("id"))
+	}
+	return &types.PullRequestActReq{
+		RepoType:    h.getRepoType(ctx),
+		Namespace:   namespace,
+		Name:        name,
+		ID:          id,
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	}, nil
+}
+
+func (h *PullRequestHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrBranchProtected):
+		httpbase.ForbiddenError(ctx, types.ErrCodeBranchProtected, err, nil)
+	default:
+		httpbase.ServerError(ctx, err)
+	}
+}
+
+func (h *PullRequestHandler) getRepoType(ctx *gin.Context) types.RepositoryType {
+	repoType := ctx.Param("repo_type")
+	repoType = strings.TrimRight(repoType, "s")
+	return types.RepositoryType(repoType)
+}
@@ -28,13 +28,19 @@ func NewRepoHandler(config *config.Config) (*RepoHandler, error) {
 	if err != nil {
 		return nil, err
 	}
+	sc, err := component.NewSensitiveComponent(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sensitive component: %w", err)
+	}
 	return &RepoHandler{
-		c: uc,
+		c:  uc,
+		sc: sc,
 	}, nil
 }
 
 type RepoHandler struct {
-	c component.RepoComponent
+	c  component.RepoComponent
+	sc component.SensitiveComponent
 }
 
 // CreateRepoFile godoc
@@ -84,6 +90,10 @@ func (h *RepoHandler) CreateFile(ctx *gin.Context) {
 	resp, err := h.c.CreateFile(ctx, req)
 	if err != nil {
 		slog.Error("Failed to create repo file", slog.String("repo_type", string(req.RepoType)), slog.Any("error", err))
+		if errors.Is(err, component.ErrBranchProtected) {
+			httpbase.ForbiddenError(ctx, types.ErrCodeBranchProtected, err, nil)
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -139,6 +149,10 @@ func (h *RepoHandler) UpdateFile(ctx *gin.Context) {
 	resp, err := h.c.UpdateFile(ctx, req)
 	if err != nil {
 		slog.Error("Failed to update repo file", slog.String("repo_type", string(req.RepoType)), slog.Any("error", err))
+		if errors.Is(err, component.ErrBranchProtected) {
+			httpbase.ForbiddenError(ctx, types.ErrCodeBranchProtected, err, nil)
+			return
+		}
 		httpbase.ServerError(ctx, err)
 		return
 	}
@@ -714,6 +728,10 @@ func (h *RepoHandler) UploadFile(ctx *gin.Context) {
 		err = h.c.UploadFile(ctx, upload)
 		if err != nil {
 			slog.Error("Failed to upload repo file", slog.String("repo_type", string(upload.RepoType)), slog.Any("error", err), slog.String("file_path", filePath))
+			if errors.Is(err, component.ErrBranchProtected) {
+				httpbase.ForbiddenError(ctx, types.ErrCodeBranchProtected, err, nil)
+				return
+			}
 			httpbase.ServerError(ctx, err)
 			return
 		}
@@ -1744,6 +1762,600 @@ func (h *RepoHandler) SyncMirror(ctx *gin.Context) {
 	httpbase.OK(ctx, nil)
 }
 
+// Fork godoc
+// @Security     ApiKey
+// @Summary      Fork a repository
+// @Description  create a copy of the repository in the given namespace, the copy keeps tracking the repository as upstream
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        current_user query string true "current user"
+// @Param        body body types.ForkRepoReq false "body"
+// @Success      200  {object}  types.Response{data=types.ForkRepoResp} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/fork [post]
+func (h *RepoHandler) Fork(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.ForkRepoReq
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
+	req.SourceNamespace = namespace
+	req.SourceName = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	resp, err := h.c.Fork(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to fork repo", slog.String("repo_type", string(req.RepoType)), slog.String("path", fmt.Sprintf("%s/%s", namespace, name)), "error", err)
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, resp)
+}
+
+// ForkStatus godoc
+// @Security     ApiKey
+// @Summary      Compare a forked repository with its upstream
+// @Description  get how many commits the forked repository is behind and ahead of its upstream repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        current_user query string false "current user"
+// @Param        branch query string false "branch to compare, default to the default branch"
+// @Success      200  {object}  types.Response{data=types.ForkStatus} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/fork/upstream [get]
+func (h *RepoHandler) ForkStatus(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := types.ForkStatusReq{
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+		Branch:      ctx.Query("branch"),
+	}
+	status, err := h.c.ForkStatus(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		if errors.Is(err, component.ErrNotFound) {
+			httpbase.NotFoundError(ctx, fmt.Errorf("repo is not a fork"))
+			return
+		}
+		slog.Error("Failed to get fork status", slog.String("repo_type", string(req.RepoType)), slog.String("path", fmt.Sprintf("%s/%s", namespace, name)), "error", err)
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, status)
+}
+
+// SyncFork godoc
+// @Security     ApiKey
+// @Summary      Sync a forked repository from its upstream
+// @Description  merge the new commits of upstream repository into the forked repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        current_user query string true "current user"
+// @Param        body body types.ForkStatusReq false "body"
+// @Success      200  {object}  types.Response{data=types.ForkStatus} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/fork/sync [post]
+func (h *RepoHandler) SyncFork(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.ForkStatusReq
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+	}
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	status, err := h.c.SyncFork(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) || errors.Is(err, component.ErrForbidden) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		if errors.Is(err, component.ErrNotFound) {
+			httpbase.NotFoundError(ctx, fmt.Errorf("repo is not a fork"))
+			return
+		}
+		slog.Error("Failed to sync fork from upstream", slog.String("repo_type", string(req.RepoType)), slog.String("path", fmt.Sprintf("%s/%s", namespace, name)), "error", err)
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, status)
+}
+
+// GitTags godoc
+// @Security     ApiKey
+// @Summary      Get the git tags of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        current_user query string false "current user name"
+// @Param        per query int false "per" default(20)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.Response{data=[]types.Tag} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/git_tags [get]
+func (h *RepoHandler) GitTags(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := &types.GetGitTagsReq{
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+		Per:         per,
+		Page:        page,
+	}
+	tags, err := h.c.GitTags(ctx, req)
+	if err != nil {
+		if errors.Is(err, component.ErrUnauthorized) {
+			httpbase.UnauthorizedError(ctx, err)
+			return
+		}
+		slog.Error("Failed to get repo git tags", slog.String("repo_type", string(req.RepoType)), slog.String("path", fmt.Sprintf("%s/%s", namespace, name)), "error", err)
+		httpbase.ServerError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, tags)
+}
+
+// CreateGitTag godoc
+// @Security     ApiKey
+// @Summary      Create a git tag
+// @Description  create a git tag pointing to the target branch or commit, the tag is annotated if message is not empty
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        current_user query string true "current user"
+// @Param        body body types.CreateGitTagReq true "body"
+// @Success      200  {object}  types.Response{data=types.Tag} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/git_tags [post]
+func (h *RepoHandler) CreateGitTag(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.CreateGitTagReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	tag, err := h.c.CreateGitTag(ctx, &req)
+	if err != nil {
+		h.handleReleaseError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, tag)
+}
+
+// DeleteGitTag godoc
+// @Security     ApiKey
+// @Summary      Delete a git tag
+// @Description  delete a git tag, the tag of a release can not be deleted until the release is deleted
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        tag path string true "tag name"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/git_tags/{tag} [delete]
+func (h *RepoHandler) DeleteGitTag(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := &types.DeleteGitTagReq{
+		Tag:         ctx.Param("tag"),
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: currentUser,
+	}
+	err = h.c.DeleteGitTag(ctx, req)
+	if err != nil {
+		h.handleReleaseError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// CreateRelease godoc
+// @Security     ApiKey
+// @Summary      Create a release
+// @Description  create a release pinned to the commit of its tag, the tag is created from target if not exists
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        current_user query string true "current user"
+// @Param        body body types.CreateReleaseReq true "body"
+// @Success      200  {object}  types.Response{data=types.Release} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/releases [post]
+func (h *RepoHandler) CreateRelease(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.CreateReleaseReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	_, err = h.sc.CheckRequestV2(ctx, &req)
+	if err != nil {
+		slog.Error("failed to check sensitive request", slog.Any("error", err))
+		httpbase.BadRequest(ctx, fmt.Errorf("sensitive check failed: %w", err).Error())
+		return
+	}
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	release, err := h.c.CreateRelease(ctx, &req)
+	if err != nil {
+		h.handleReleaseError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, release)
+}
+
+// ListReleases godoc
+// @Security     ApiKey
+// @Summary      Get the releases of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        current_user query string false "current user"
+// @Param        per query int false "per" default(20)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.Release} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/releases [get]
+func (h *RepoHandler) ListReleases(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := &types.ReleaseReq{
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+		Per:         per,
+		Page:        page,
+	}
+	releases, total, err := h.c.ListReleases(ctx, req)
+	if err != nil {
+		h.handleReleaseError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  releases,
+		"total": total,
+	})
+}
+
+// GetRelease godoc
+// @Security     ApiKey
+// @Summary      Get a release by its tag name
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        tag path string true "tag name of the release"
+// @Param        current_user query string false "current user"
+// @Success      200  {object}  types.Response{data=types.Release} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/releases/{tag} [get]
+func (h *RepoHandler) GetRelease(ctx *gin.Context) {
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := &types.ReleaseReq{
+		TagName:     ctx.Param("tag"),
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	}
+	release, err := h.c.GetRelease(ctx, req)
+	if err != nil {
+		h.handleReleaseError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, release)
+}
+
+// UpdateRelease godoc
+// @Security     ApiKey
+// @Summary      Update a release
+// @Description  update title, notes or assets of a release, the pinned commit can not be changed
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        tag path string true "tag name of the release"
+// @Param        current_user query string true "current user"
+// @Param        body body types.UpdateReleaseReq true "body"
+// @Success      200  {object}  types.Response{data=types.Release} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/releases/{tag} [put]
+func (h *RepoHandler) UpdateRelease(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.UpdateReleaseReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	_, err = h.sc.CheckRequestV2(ctx, &req)
+	if err != nil {
+		slog.Error("failed to check sensitive request", slog.Any("error", err))
+		httpbase.BadRequest(ctx, fmt.Errorf("sensitive check failed: %w", err).Error())
+		return
+	}
+	req.TagName = ctx.Param("tag")
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	release, err := h.c.UpdateRelease(ctx, &req)
+	if err != nil {
+		h.handleReleaseError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, release)
+}
+
+// DeleteRelease godoc
+// @Security     ApiKey
+// @Summary      Delete a release
+// @Description  delete a release, its git tag is kept
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        tag path string true "tag name of the release"
+// @Param        current_user query string true "current user"
+// @Success      200  {object}  types.Response{} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/releases/{tag} [delete]
+func (h *RepoHandler) DeleteRelease(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req := &types.ReleaseReq{
+		TagName:     ctx.Param("tag"),
+		Namespace:   namespace,
+		Name:        name,
+		RepoType:    common.RepoTypeFromContext(ctx),
+		CurrentUser: currentUser,
+	}
+	err = h.c.DeleteRelease(ctx, req)
+	if err != nil {
+		h.handleReleaseError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (h *RepoHandler) handleReleaseError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden), errors.Is(err, component.ErrUserNotFound):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrAlreadyExists):
+		httpbase.BadRequest(ctx, err.Error())
+	default:
+		slog.Error("Failed to handle repo tag or release request", slog.String("path", ctx.Request.URL.Path), "error", err)
+		httpbase.ServerError(ctx, err)
+	}
+}
+
+// Transfer godoc
+// @Security     ApiKey
+// @Summary      Transfer a repository to another namespace
+// @Description  move the repository to another user or organization, urls of the old path redirect to the new path
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        repo_type path string true "models,datasets,codes or spaces" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "repo owner name"
+// @Param        name path string true "repo name"
+// @Param        current_user query string true "current user"
+// @Param        body body types.TransferRepoReq true "body"
+// @Success      200  {object}  types.Response{data=types.TransferRepoResp} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/transfer [post]
+func (h *RepoHandler) Transfer(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.TransferRepoReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.Namespace = namespace
+	req.Name = name
+	req.RepoType = common.RepoTypeFromContext(ctx)
+	req.CurrentUser = currentUser
+	resp, err := h.c.Transfer(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden), errors.Is(err, component.ErrUserNotFound):
+			httpbase.UnauthorizedError(ctx, err)
+		case errors.Is(err, component.ErrNotFound):
+			httpbase.NotFoundError(ctx, err)
+		case errors.Is(err, component.ErrAlreadyExists), errors.Is(err, component.ErrBadRequest):
+			httpbase.BadRequest(ctx, err.Error())
+		default:
+			slog.Error("Failed to transfer repo", slog.String("repo_type", string(req.RepoType)), slog.String("path", fmt.Sprintf("%s/%s", namespace, name)), "error", err)
+			httpbase.ServerError(ctx, err)
+		}
+		return
+	}
+	httpbase.OK(ctx, resp)
+}
+
 func (h *RepoHandler) testStatus(ctx *gin.Context) {
 	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
 	ctx.Writer.Header().Set("Cache-Control", "no-cache")
@@ -1833,6 +2445,14 @@ func (h *RepoHandler) DeployUpdate(ctx *gin.Context) {
 		}
 	}
 
+	if req.Autoscaling != nil {
+		if err := req.Autoscaling.Validate(); err != nil {
+			slog.Error("Bad autoscaling policy for deploy", slog.Any("autoscaling", *req.Autoscaling), slog.Any("err", err))
+			httpbase.BadRequest(ctx, fmt.Sprintf("Bad autoscaling policy for deploy, %v", err))
+			return
+		}
+	}
+
 	repoType := common.RepoTypeFromContext(ctx)
 	deployID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
@@ -1851,6 +2471,9 @@ func (h *RepoHandler) DeployUpdate(ctx *gin.Context) {
 	err = h.c.DeployUpdate(ctx, updateReq, req)
 	if err != nil {
 		slog.Error("failed to update deploy", slog.String("namespace", namespace), slog.String("name", name), slog.Any("username", currentUser), slog.Int64("deploy_id", deployID), slog.Any("error", err))
+		if quotaExceeded(ctx, err) {
+			return
+		}
 		httpbase.ServerError(ctx, fmt.Errorf("failed to update deploy, %w", err))
 		return
 	}
@@ -2195,6 +2818,14 @@ func (h *RepoHandler) ServerlessUpdate(ctx *gin.Context) {
 		}
 	}
 
+	if req.Autoscaling != nil {
+		if err := req.Autoscaling.Validate(); err != nil {
+			slog.Error("Bad autoscaling policy for serverless", slog.Any("autoscaling", *req.Autoscaling), slog.Any("err", err))
+			httpbase.BadRequest(ctx, fmt.Sprintf("Bad autoscaling policy for serverless, %v", err))
+			return
+		}
+	}
+
 	deployID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
 		slog.Error("Bad request format", slog.Any("error", err), slog.Any("id", ctx.Param("id")))
@@ -2212,6 +2843,9 @@ func (h *RepoHandler) ServerlessUpdate(ctx *gin.Context) {
 	err = h.c.DeployUpdate(ctx, updateReq, req)
 	if err != nil {
 		slog.Error("failed to update serverless", slog.String("namespace", namespace), slog.String("name", name), slog.Any("username", currentUser), slog.Int64("deploy_id", deployID), slog.Any("error", err))
+		if quotaExceeded(ctx, err) {
+			return
+		}
 		httpbase.ServerError(ctx, fmt.Errorf("failed to update serverless, %w", err))
 		return
 	}
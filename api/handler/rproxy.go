@@ -1,16 +1,24 @@
 package handler
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"opencsg.com/csghub-server/api/httpbase"
+	deployStatus "opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/llm"
 	"opencsg.com/csghub-server/builder/proxy"
+	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
 	"opencsg.com/csghub-server/component"
 )
 
@@ -72,6 +80,12 @@ func (r *RProxyHandler) Proxy(ctx *gin.Context) {
 	}
 
 	if allow {
+		if deploy.ModelID > 0 {
+			r.repoComp.RecordEndpointRequest(deploy)
+			if !r.endpointAwake(ctx, deploy) {
+				return
+			}
+		}
 		apiname := ctx.Param("api")
 		target := fmt.Sprintf("http://%s.%s", appSrvName, r.SpaceRootDomain)
 		if deploy.Endpoint != "" {
@@ -79,18 +93,164 @@ func (r *RProxyHandler) Proxy(ctx *gin.Context) {
 			target = deploy.Endpoint
 		}
 		rp, _ := proxy.NewReverseProxy(target)
+		var w http.ResponseWriter = ctx.Writer
+		var usageWriter *usageResponseWriter
 		if deploy.Type == 1 || deploy.Type == 3 {
 			//for infernece,no need context path
 			contextPath := fmt.Sprintf("/%s/%s", "endpoint", appSrvName)
 			apiname = strings.TrimPrefix(apiname, contextPath)
+			// meter the tokens of openai compatible api calls
+			usageWriter = &usageResponseWriter{ResponseWriter: ctx.Writer}
+			// streamed responses carry the usage only if requested, the usage chunk is hidden
+			// from the clients not requesting it
+			usageWriter.hideUsage = includeStreamUsage(ctx.Request)
+			w = usageWriter
+		}
+		rp.ServeHTTP(w, ctx.Request, apiname)
+		if usageWriter != nil {
+			usageWriter.flushPending()
+			if usage, ok := usageWriter.Usage(); ok {
+				go r.repoComp.PublishEndpointTokenUsage(context.Background(), username, deploy, usage)
+			}
 		}
-		rp.ServeHTTP(ctx.Writer, ctx.Request, apiname)
 	} else {
 		slog.Warn("user not allowed to call endpoint api", slog.String("srv_name", appSrvName), slog.Any("user_name", username), slog.Any("deployID", deploy.ID))
 		ctx.Status(http.StatusForbidden)
 	}
 }
 
+// endpointAwake wakes up the inference endpoint scaled to zero, requests are rejected with
+// 503 until the endpoint is running again
+func (r *RProxyHandler) endpointAwake(ctx *gin.Context, deploy *database.Deploy) bool {
+	switch deploy.Status {
+	case deployStatus.Sleeping:
+		err := r.repoComp.WakeupEndpoint(ctx, deploy)
+		if err != nil {
+			slog.Error("failed to wake up endpoint", slog.Any("deployID", deploy.ID), slog.Any("error", err))
+			httpbase.ServerError(ctx, fmt.Errorf("failed to wake up endpoint, %w", err))
+			return false
+		}
+	case deployStatus.Pending, deployStatus.Deploying, deployStatus.Startup:
+		// only the endpoint waking up asks clients to retry, others keep the old behavior
+		if deploy.Autoscaling == nil || deploy.Autoscaling.ScaleToZeroIdleMinutes == 0 {
+			return true
+		}
+	default:
+		return true
+	}
+	httpbase.ServiceUnavailableError(ctx, errors.New("endpoint is waking up, please retry later"), 30)
+	return false
+}
+
+// maxStreamRequestSize is the max size of request body to set stream_options.include_usage in,
+// larger requests are proxied unchanged
+const maxStreamRequestSize = 8 << 20
+
+// includeStreamUsage asks for the usage in the streamed response of completion request,
+// true is returned if the request body is changed
+func includeStreamUsage(req *http.Request) bool {
+	if req.Method != http.MethodPost || req.Body == nil || !strings.Contains(req.Header.Get("Content-Type"), "json") {
+		return false
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxStreamRequestSize+1))
+	if err != nil || len(body) > maxStreamRequestSize {
+		req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		return false
+	}
+	newBody, changed := llm.IncludeStreamUsage(body)
+	req.Body = io.NopCloser(bytes.NewReader(newBody))
+	req.ContentLength = int64(len(newBody))
+	req.Header.Set("Content-Length", strconv.Itoa(len(newBody)))
+	return changed
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// usageResponseWriter records the token usage in the json or streamed responses of inference endpoints
+type usageResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	recorder *llm.UsageRecorder
+	skip     bool
+	// hideUsage drops the usage chunk of streamed response, the incomplete last line is kept in pending
+	hideUsage bool
+	stream    bool
+	pending   []byte
+}
+
+func (w *usageResponseWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *usageResponseWriter) Write(p []byte) (int, error) {
+	if w.recorder == nil && !w.skip {
+		contentType := w.Header().Get("Content-Type")
+		w.skip = w.status >= http.StatusMultipleChoices ||
+			!(strings.Contains(contentType, "json") || strings.HasPrefix(contentType, "text/event-stream"))
+		if !w.skip {
+			w.recorder = llm.NewUsageRecorder(contentType)
+			w.stream = strings.HasPrefix(contentType, "text/event-stream")
+		}
+	}
+	if w.recorder != nil {
+		_, _ = w.recorder.Write(p)
+	}
+	if !w.hideUsage || !w.stream {
+		return w.ResponseWriter.Write(p)
+	}
+	w.pending = append(w.pending, p...)
+	var out []byte
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := w.pending[:i+1]
+		if !llm.IsUsageChunk(line) {
+			out = append(out, line...)
+		}
+		w.pending = w.pending[i+1:]
+	}
+	if len(out) > 0 {
+		if _, err := w.ResponseWriter.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flushPending writes the last line of streamed response not ending with a line break
+func (w *usageResponseWriter) flushPending() {
+	if len(w.pending) == 0 {
+		return
+	}
+	if !llm.IsUsageChunk(w.pending) {
+		_, _ = w.ResponseWriter.Write(w.pending)
+	}
+	w.pending = nil
+}
+
+func (w *usageResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *usageResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *usageResponseWriter) Usage() (types.LLMUsage, bool) {
+	if w.recorder == nil {
+		return types.LLMUsage{}, false
+	}
+	return w.recorder.Usage()
+}
+
 // get service name based on request
 func (r *RProxyHandler) GetSrvName(ctx *gin.Context) string {
 	URI := ctx.Request.RequestURI
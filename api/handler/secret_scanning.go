@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+func NewSecretScanningHandler(config *config.Config) (*SecretScanningHandler, error) {
+	c, err := component.NewSecretScanningComponent(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret scanning component: %w", err)
+	}
+	return &SecretScanningHandler{
+		c: c,
+	}, nil
+}
+
+type SecretScanningHandler struct {
+	c component.SecretScanningComponent
+}
+
+// GetSecretFindings godoc
+// @Security     ApiKey
+// @Summary      List secret findings
+// @Description  list the secrets like cloud keys and tokens found in the files of repository, for admins of the repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        status query string false "status of findings" Enums(open,dismissed,fixed)
+// @Param        per query int false "per" default(50)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.SecretFinding,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/secret_findings [get]
+func (h *SecretScanningHandler) IndexFindings(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	per, page, err := common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	findings, total, err := h.c.IndexFindings(ctx, types.SecretFindingsReq{
+		Status:      types.SecretFindingStatus(ctx.Query("status")),
+		RepoType:    h.getRepoType(ctx),
+		Namespace:   namespace,
+		Name:        name,
+		CurrentUser: currentUser,
+		Per:         per,
+		Page:        page,
+	})
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	respData := gin.H{
+		"data":  findings,
+		"total": total,
+	}
+	httpbase.OK(ctx, respData)
+}
+
+// DismissSecretFinding godoc
+// @Security     ApiKey
+// @Summary      Dismiss a secret finding
+// @Description  dismiss a secret finding like a test credential or a revoked key, and optionally add the secret to the allowlist of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the secret finding"
+// @Param        body body types.DismissSecretFindingReq true "body"
+// @Success      200  {object}  types.Response{data=types.SecretFinding} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/secret_findings/{id}/dismiss [post]
+func (h *SecretScanningHandler) DismissFinding(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.DismissSecretFindingReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.ID = id
+	req.RepoType = h.getRepoType(ctx)
+	req.Namespace = namespace
+	req.Name = name
+	req.CurrentUser = currentUser
+	finding, err := h.c.DismissFinding(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, finding)
+}
+
+// GetSecretAllowlist godoc
+// @Security     ApiKey
+// @Summary      List secret allowlist
+// @Description  list the allowlist entries of secrets not to be reported by the secret scanning of repository
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Success      200  {object}  types.Response{data=[]types.SecretAllowlistEntry} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/secret_allowlist [get]
+func (h *SecretScanningHandler) ListAllowlist(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	entries, err := h.c.ListAllowlist(ctx, h.getRepoType(ctx), namespace, name, currentUser)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, entries)
+}
+
+// CreateSecretAllowlistEntry godoc
+// @Security     ApiKey
+// @Summary      Add a secret allowlist entry
+// @Description  allow the secret of a fingerprint, or all secrets in the files matching a path pattern like tests/*, the open findings allowed are dismissed
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        body body types.CreateSecretAllowlistEntryReq true "body"
+// @Success      200  {object}  types.Response{data=types.SecretAllowlistEntry} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/secret_allowlist [post]
+func (h *SecretScanningHandler) CreateAllowlistEntry(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	var req types.CreateSecretAllowlistEntryReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	req.RepoType = h.getRepoType(ctx)
+	req.Namespace = namespace
+	req.Name = name
+	req.CurrentUser = currentUser
+	entry, err := h.c.CreateAllowlistEntry(ctx, req)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, entry)
+}
+
+// DeleteSecretAllowlistEntry godoc
+// @Security     ApiKey
+// @Summary      Delete a secret allowlist entry
+// @Tags         Repository
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the allowlist entry"
+// @Success      200  {object}  types.Response "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/secret_allowlist/{id} [delete]
+func (h *SecretScanningHandler) DeleteAllowlistEntry(ctx *gin.Context) {
+	currentUser := httpbase.GetCurrentUser(ctx)
+	if currentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		slog.Error("Bad request format", "error", err)
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	err = h.c.DeleteAllowlistEntry(ctx, h.getRepoType(ctx), namespace, name, currentUser, id)
+	if err != nil {
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+func (h *SecretScanningHandler) getRepoType(ctx *gin.Context) types.RepositoryType {
+	repoType := ctx.Param("repo_type")
+	repoType = strings.TrimRight(repoType, "s")
+	return types.RepositoryType(repoType)
+}
+
+func (h *SecretScanningHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden),
+		errors.Is(err, component.ErrUserNotFound):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrBadRequest):
+		httpbase.BadRequest(ctx, err.Error())
+	default:
+		slog.Error("Failed to handle secret scanning request", slog.String("path", ctx.Request.URL.Path), "error", err)
+		httpbase.ServerError(ctx, err)
+	}
+}
@@ -312,6 +312,9 @@ func (h *SpaceHandler) Run(ctx *gin.Context) {
 	if err != nil {
 		slog.Error("failed to deploy space", slog.String("namespace", namespace),
 			slog.String("name", name), slog.Any("error", err))
+		if quotaExceeded(ctx, err) {
+			return
+		}
 		httpbase.ServerError(ctx, errors.New("failed to deploy space"))
 		return
 	}
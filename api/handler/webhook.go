@@ -0,0 +1,401 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+	"opencsg.com/csghub-server/component"
+)
+
+type WebhookHandler struct {
+	c component.WebhookComponent
+}
+
+func NewWebhookHandler(cfg *config.Config) (*WebhookHandler, error) {
+	c, err := component.NewWebhookComponent(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook component: %w", err)
+	}
+	return &WebhookHandler{
+		c: c,
+	}, nil
+}
+
+// ListWebhooks godoc
+// @Security     ApiKey
+// @Summary      List webhooks of a repository or an organization
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Success      200  {object}  types.Response{data=[]types.Webhook} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/webhooks [get]
+// @Router       /organization/{namespace}/webhooks [get]
+func (h *WebhookHandler) Index(ctx *gin.Context) {
+	req, err := h.baseReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	webhooks, err := h.c.Index(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to list webhooks", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, webhooks)
+}
+
+// CreateWebhook godoc
+// @Security     ApiKey
+// @Summary      Create a webhook
+// @Description  post the subscribed events to the url, the payload is signed with the secret in header X-CSGHub-Signature-256
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        body body types.WebhookReq true "body"
+// @Success      200  {object}  types.Response{data=types.Webhook} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/webhooks [post]
+// @Router       /organization/{namespace}/webhooks [post]
+func (h *WebhookHandler) Create(ctx *gin.Context) {
+	req, err := h.baseReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	webhook, err := h.c.Create(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to create webhook", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, webhook)
+}
+
+// GetWebhook godoc
+// @Security     ApiKey
+// @Summary      Get a webhook
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the webhook"
+// @Success      200  {object}  types.Response{data=types.Webhook} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/webhooks/{id} [get]
+// @Router       /organization/{namespace}/webhooks/{id} [get]
+func (h *WebhookHandler) Get(ctx *gin.Context) {
+	req, err := h.webhookReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	webhook, err := h.c.Get(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to get webhook", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, webhook)
+}
+
+// UpdateWebhook godoc
+// @Security     ApiKey
+// @Summary      Update a webhook
+// @Description  the secret is kept unchanged if it is empty
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the webhook"
+// @Param        body body types.WebhookReq true "body"
+// @Success      200  {object}  types.Response{data=types.Webhook} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/webhooks/{id} [put]
+// @Router       /organization/{namespace}/webhooks/{id} [put]
+func (h *WebhookHandler) Update(ctx *gin.Context) {
+	req, err := h.webhookReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	webhook, err := h.c.Update(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to update webhook", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, webhook)
+}
+
+// DeleteWebhook godoc
+// @Security     ApiKey
+// @Summary      Delete a webhook and its deliveries
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the webhook"
+// @Success      200  {object}  types.Response "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/webhooks/{id} [delete]
+// @Router       /organization/{namespace}/webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(ctx *gin.Context) {
+	req, err := h.webhookReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	err = h.c.Delete(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to delete webhook", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, nil)
+}
+
+// PingWebhook godoc
+// @Security     ApiKey
+// @Summary      Send a ping event to a webhook
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the webhook"
+// @Success      200  {object}  types.Response{data=types.WebhookDelivery} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/webhooks/{id}/ping [post]
+// @Router       /organization/{namespace}/webhooks/{id}/ping [post]
+func (h *WebhookHandler) Ping(ctx *gin.Context) {
+	req, err := h.webhookReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	delivery, err := h.c.Ping(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to ping webhook", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, delivery)
+}
+
+// ListWebhookDeliveries godoc
+// @Security     ApiKey
+// @Summary      List deliveries of a webhook
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the webhook"
+// @Param        per query int false "per" default(20)
+// @Param        page query int false "page index" default(1)
+// @Success      200  {object}  types.ResponseWithTotal{data=[]types.WebhookDelivery,total=int} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/webhooks/{id}/deliveries [get]
+// @Router       /organization/{namespace}/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(ctx *gin.Context) {
+	req, err := h.deliveriesReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	req.Per, req.Page, err = common.GetPerAndPageFromContext(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	deliveries, total, err := h.c.ListDeliveries(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to list webhook deliveries", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  deliveries,
+		"total": total,
+	})
+}
+
+// RedeliverWebhookDelivery godoc
+// @Security     ApiKey
+// @Summary      Send the payload of a webhook delivery again
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        current_user query string true "current user"
+// @Param        repo_type path string true "repository type" Enums(models,datasets,codes,spaces)
+// @Param        namespace path string true "namespace"
+// @Param        name path string true "name"
+// @Param        id path int true "id of the webhook"
+// @Param        delivery_id path int true "id of the delivery"
+// @Success      200  {object}  types.Response{data=types.WebhookDelivery} "OK"
+// @Failure      400  {object}  types.APIBadRequest "Bad request"
+// @Failure      500  {object}  types.APIInternalServerError "Internal server error"
+// @Router       /{repo_type}/{namespace}/{name}/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+// @Router       /organization/{namespace}/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(ctx *gin.Context) {
+	req, err := h.deliveriesReq(ctx)
+	if err != nil {
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	if req.CurrentUser == "" {
+		httpbase.UnauthorizedError(ctx, component.ErrUserNotFound)
+		return
+	}
+	req.DeliveryID, err = strconv.ParseInt(ctx.Param("delivery_id"), 10, 64)
+	if err != nil {
+		httpbase.BadRequest(ctx, fmt.Sprintf("invalid webhook delivery id: %s", ctx.Param("delivery_id")))
+		return
+	}
+	delivery, err := h.c.Redeliver(ctx, *req)
+	if err != nil {
+		slog.Error("Failed to redeliver webhook delivery", "error", err, "request", req)
+		h.handleError(ctx, err)
+		return
+	}
+	httpbase.OK(ctx, delivery)
+}
+
+// baseReq reads the scope of webhooks from path, routes of organization webhooks have no name
+func (h *WebhookHandler) baseReq(ctx *gin.Context) (*types.WebhookReq, error) {
+	if ctx.Param("name") == "" {
+		namespace := ctx.Param("namespace")
+		if namespace == "" {
+			return nil, fmt.Errorf("invalid namespace")
+		}
+		return &types.WebhookReq{
+			Namespace:   namespace,
+			CurrentUser: httpbase.GetCurrentUser(ctx),
+		}, nil
+	}
+	namespace, name, err := common.GetNamespaceAndNameFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace and name from request context: %w", err)
+	}
+	return &types.WebhookReq{
+		RepoType:    types.RepositoryType(strings.TrimRight(ctx.Param("repo_type"), "s")),
+		Namespace:   namespace,
+		Name:        name,
+		CurrentUser: httpbase.GetCurrentUser(ctx),
+	}, nil
+}
+
+func (h *WebhookHandler) webhookReq(ctx *gin.Context) (*types.WebhookReq, error) {
+	req, err := h.baseReq(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.ID, err = strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook id: %s", ctx.Param("id"))
+	}
+	return req, nil
+}
+
+func (h *WebhookHandler) deliveriesReq(ctx *gin.Context) (*types.WebhookDeliveriesReq, error) {
+	req, err := h.webhookReq(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &types.WebhookDeliveriesReq{
+		WebhookID:   req.ID,
+		RepoType:    req.RepoType,
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		CurrentUser: req.CurrentUser,
+	}, nil
+}
+
+func (h *WebhookHandler) handleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, component.ErrUnauthorized), errors.Is(err, component.ErrForbidden),
+		errors.Is(err, component.ErrUserNotFound):
+		httpbase.UnauthorizedError(ctx, err)
+	case errors.Is(err, component.ErrNotFound):
+		httpbase.NotFoundError(ctx, err)
+	case errors.Is(err, component.ErrBadRequest):
+		httpbase.BadRequest(ctx, err.Error())
+	default:
+		httpbase.ServerError(ctx, err)
+	}
+}
@@ -2,6 +2,7 @@ package httpbase
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -62,6 +63,32 @@ func NotFoundError(c *gin.Context, err error) {
 	})
 }
 
+// ForbiddenError responds with a JSON-formatted error message, an error code and details of the error.
+//
+// Example:
+//
+//	ForbiddenError(c, 40301, errors.New("compute quota exceeded"), details)
+func ForbiddenError(c *gin.Context, code int, err error, data any) {
+	c.PureJSON(http.StatusForbidden, R{
+		Code: code,
+		Msg:  err.Error(),
+		Data: data,
+	})
+}
+
+// ServiceUnavailableError responds with a JSON-formatted error message, and asks the client
+// to retry after the seconds.
+//
+// Example:
+//
+//	ServiceUnavailableError(c, errors.New("endpoint is waking up"), 30)
+func ServiceUnavailableError(c *gin.Context, err error, retryAfter int) {
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.PureJSON(http.StatusServiceUnavailable, R{
+		Msg: err.Error(),
+	})
+}
+
 // R is the response envelope
 type R struct {
 	Code int    `json:"code,omitempty"`
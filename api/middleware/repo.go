@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"log/slog"
+	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -53,6 +55,70 @@ func RepoMapping(repo_type types.RepositoryType) gin.HandlerFunc {
 	}
 }
 
+// RepoRedirect redirects requests to the former path of a transferred repository to its current path,
+// repository type is taken from the `repo_type` param if t is empty
+func RepoRedirect(t types.RepositoryType) gin.HandlerFunc {
+	repoStore := database.NewRepoStore()
+	redirectStore := database.NewRepoRedirectStore()
+	return func(ctx *gin.Context) {
+		// repositories mapped from mirror sources are never transferred
+		if _, mapped := ctx.Get("namespace_mapped"); mapped {
+			ctx.Next()
+			return
+		}
+		repoType := t
+		if repoType == "" {
+			repoType = types.RepositoryType(strings.TrimSuffix(ctx.Param("repo_type"), "s"))
+		}
+		namespace := ctx.Param("namespace")
+		name := strings.TrimSuffix(ctx.Param("name"), gitSuffix)
+		exists, err := repoStore.Exists(ctx, repoType, namespace, name)
+		if err != nil || exists {
+			ctx.Next()
+			return
+		}
+		redirect, err := redirectStore.FindByPath(ctx, repoType, namespace, name)
+		if err != nil {
+			ctx.Next()
+			return
+		}
+		newNamespace, newName := redirect.Repository.NamespaceAndName()
+		if strings.HasSuffix(ctx.Param("name"), gitSuffix) {
+			newName += gitSuffix
+		}
+		location := url.URL{
+			Path:     redirectPath(ctx, newNamespace, newName),
+			RawQuery: ctx.Request.URL.RawQuery,
+		}
+		slog.Debug("redirect request of transferred repository", slog.String("from", ctx.Request.URL.Path), slog.String("to", location.Path))
+		// 308 keeps the method and body of requests like git-receive-pack
+		code := http.StatusPermanentRedirect
+		if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		ctx.Redirect(code, location.String())
+		ctx.Abort()
+	}
+}
+
+// redirectPath builds the request path from the matched route with the namespace and name replaced
+func redirectPath(ctx *gin.Context, namespace, name string) string {
+	segments := strings.Split(ctx.FullPath(), "/")
+	for i, seg := range segments {
+		switch {
+		case seg == ":namespace":
+			segments[i] = namespace
+		case seg == ":name":
+			segments[i] = name
+		case strings.HasPrefix(seg, ":"):
+			segments[i] = ctx.Param(seg[1:])
+		case strings.HasPrefix(seg, "*"):
+			segments[i] = strings.TrimPrefix(ctx.Param(seg[1:]), "/")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
 func GetMapping(ctx *gin.Context) types.Mapping {
 	rawRp := ctx.Query("mirror")
 	if rawRp == "" {
@@ -33,6 +33,7 @@ func NewRouter(config *config.Config, enableSwagger bool) (*gin.Engine, error) {
 	}
 	gitHTTP := r.Group("/:repo_type/:namespace/:name")
 	gitHTTP.Use(middleware.GitHTTPParamMiddleware())
+	gitHTTP.Use(middleware.RepoRedirect(""))
 	gitHTTP.Use(middleware.GetCurrentUserFromHeader())
 	{
 		gitHTTP.GET("/info/refs", gitHTTPHandler.InfoRefs)
@@ -299,6 +300,67 @@ func NewRouter(config *config.Config, enableSwagger bool) (*gin.Engine, error) {
 		cluster.PUT("/:id", needAPIKey, clusterHandler.Update)
 	}
 
+	// deploy scheduler queue
+	deployQueueHandler, err := handler.NewDeployQueueHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to creating deploy queue handler: %w", err)
+	}
+	deployQueue := apiGroup.Group("/deploy_queue")
+	{
+		deployQueue.GET("", deployQueueHandler.Index)
+		deployQueue.PUT("/:id/priority", deployQueueHandler.SetPriority)
+	}
+
+	computeQuotaHandler, err := handler.NewComputeQuotaHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to creating compute quota handler: %w", err)
+	}
+	computeQuotas := apiGroup.Group("/compute_quotas")
+	{
+		computeQuotas.GET("", computeQuotaHandler.Index)
+		computeQuotas.POST("", computeQuotaHandler.Create)
+		computeQuotas.GET("/:id", computeQuotaHandler.Show)
+		computeQuotas.PUT("/:id", computeQuotaHandler.Update)
+		computeQuotas.DELETE("/:id", computeQuotaHandler.Delete)
+	}
+
+	notificationHandler, err := handler.NewNotificationHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to creating notification handler: %w", err)
+	}
+	notifications := apiGroup.Group("/notifications")
+	{
+		notifications.GET("", notificationHandler.Index)
+		notifications.PUT("/read", notificationHandler.MarkAllRead)
+		notifications.PUT("/:id/read", notificationHandler.MarkRead)
+	}
+
+	finetuneJobHandler, err := handler.NewFinetuneJobHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to creating finetune job handler: %w", err)
+	}
+	finetuneJobs := apiGroup.Group("/finetune_jobs")
+	{
+		finetuneJobs.GET("", finetuneJobHandler.Index)
+		finetuneJobs.POST("", finetuneJobHandler.Create)
+		finetuneJobs.GET("/:id", finetuneJobHandler.Show)
+		finetuneJobs.POST("/:id/cancel", finetuneJobHandler.Cancel)
+		finetuneJobs.GET("/:id/logs", finetuneJobHandler.Logs)
+		finetuneJobs.POST("/:id/events", finetuneJobHandler.ReportEvent)
+	}
+
+	batchInferenceJobHandler, err := handler.NewBatchInferenceJobHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to creating batch inference job handler: %w", err)
+	}
+	batchInferenceJobs := apiGroup.Group("/batch_inference_jobs")
+	{
+		batchInferenceJobs.GET("", batchInferenceJobHandler.Index)
+		batchInferenceJobs.POST("", batchInferenceJobHandler.Create)
+		batchInferenceJobs.GET("/:id", batchInferenceJobHandler.Show)
+		batchInferenceJobs.POST("/:id/cancel", batchInferenceJobHandler.Cancel)
+	}
+
 	eventHandler, err := handler.NewEventHandler()
 	if err != nil {
 		return nil, fmt.Errorf("error creating event handler:%w", err)
@@ -377,6 +439,36 @@ func NewRouter(config *config.Config, enableSwagger bool) (*gin.Engine, error) {
 	}
 	createDiscussionRoutes(apiGroup, needAPIKey, discussionHandler)
 
+	pullRequestHandler, err := handler.NewPullRequestHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating pull request handler:%w", err)
+	}
+	createPullRequestRoutes(apiGroup, pullRequestHandler)
+
+	branchProtectionHandler, err := handler.NewBranchProtectionHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating branch protection handler:%w", err)
+	}
+	createBranchProtectionRoutes(apiGroup, branchProtectionHandler)
+
+	webhookHandler, err := handler.NewWebhookHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating webhook handler:%w", err)
+	}
+	createWebhookRoutes(apiGroup, webhookHandler)
+
+	moderationCaseHandler, err := handler.NewModerationCaseHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating moderation case handler:%w", err)
+	}
+	createModerationCaseRoutes(apiGroup, moderationCaseHandler)
+
+	secretScanningHandler, err := handler.NewSecretScanningHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating secret scanning handler:%w", err)
+	}
+	createSecretScanningRoutes(apiGroup, secretScanningHandler)
+
 	// prompt
 	promptHandler, err := handler.NewPromptHandler(config)
 	if err != nil {
@@ -394,7 +486,7 @@ func createModelRoutes(config *config.Config, apiGroup *gin.RouterGroup, needAPI
 		modelsGroup.GET("", modelHandler.Index)
 		modelsGroup.PUT("/:namespace/:name", modelHandler.Update)
 		modelsGroup.DELETE("/:namespace/:name", modelHandler.Delete)
-		modelsGroup.GET("/:namespace/:name", modelHandler.Show)
+		modelsGroup.GET("/:namespace/:name", middleware.RepoRedirect(types.ModelRepo), modelHandler.Show)
 		modelsGroup.GET("/:namespace/:name/all_files", modelHandler.AllFiles)
 		modelsGroup.GET("/:namespace/:name/relations", modelHandler.Relations)
 		modelsGroup.PUT("/:namespace/:name/relations", modelHandler.SetRelations)
@@ -419,7 +511,7 @@ func createModelRoutes(config *config.Config, apiGroup *gin.RouterGroup, needAPI
 		// and an lfs parameter needs to be added.
 		// 2. DownloadFile returns an object store url for lfs files, while SDKDownload redirects directly.
 		modelsGroup.GET("/:namespace/:name/download/*file_path", middleware.RepoType(types.ModelRepo), repoCommonHandler.DownloadFile)
-		modelsGroup.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.ModelRepo), repoCommonHandler.ResolveDownload)
+		modelsGroup.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.ModelRepo), middleware.RepoRedirect(types.ModelRepo), repoCommonHandler.ResolveDownload)
 		modelsGroup.POST("/:namespace/:name/raw/*file_path", middleware.RepoType(types.ModelRepo), repoCommonHandler.CreateFile)
 		modelsGroup.PUT("/:namespace/:name/raw/*file_path", middleware.RepoType(types.ModelRepo), repoCommonHandler.UpdateFile)
 		modelsGroup.POST("/:namespace/:name/update_downloads", middleware.RepoType(types.ModelRepo), repoCommonHandler.UpdateDownloads)
@@ -439,6 +531,21 @@ func createModelRoutes(config *config.Config, apiGroup *gin.RouterGroup, needAPI
 			modelsGroup.POST("/:namespace/:name/mirror_from_saas", middleware.RepoType(types.ModelRepo), repoCommonHandler.MirrorFromSaas)
 		}
 
+		// fork
+		modelsGroup.POST("/:namespace/:name/fork", middleware.RepoType(types.ModelRepo), repoCommonHandler.Fork)
+		modelsGroup.GET("/:namespace/:name/fork/upstream", middleware.RepoType(types.ModelRepo), repoCommonHandler.ForkStatus)
+		modelsGroup.POST("/:namespace/:name/fork/sync", middleware.RepoType(types.ModelRepo), repoCommonHandler.SyncFork)
+		modelsGroup.POST("/:namespace/:name/transfer", middleware.RepoType(types.ModelRepo), repoCommonHandler.Transfer)
+		// git tags and releases
+		modelsGroup.GET("/:namespace/:name/git_tags", middleware.RepoType(types.ModelRepo), repoCommonHandler.GitTags)
+		modelsGroup.POST("/:namespace/:name/git_tags", middleware.RepoType(types.ModelRepo), repoCommonHandler.CreateGitTag)
+		modelsGroup.DELETE("/:namespace/:name/git_tags/:tag", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeleteGitTag)
+		modelsGroup.GET("/:namespace/:name/releases", middleware.RepoType(types.ModelRepo), repoCommonHandler.ListReleases)
+		modelsGroup.POST("/:namespace/:name/releases", middleware.RepoType(types.ModelRepo), repoCommonHandler.CreateRelease)
+		modelsGroup.GET("/:namespace/:name/releases/:tag", middleware.RepoType(types.ModelRepo), repoCommonHandler.GetRelease)
+		modelsGroup.PUT("/:namespace/:name/releases/:tag", middleware.RepoType(types.ModelRepo), repoCommonHandler.UpdateRelease)
+		modelsGroup.DELETE("/:namespace/:name/releases/:tag", middleware.RepoType(types.ModelRepo), repoCommonHandler.DeleteRelease)
+
 		// runtime framework
 		modelsGroup.GET("/:namespace/:name/runtime_framework", middleware.RepoType(types.ModelRepo), repoCommonHandler.RuntimeFrameworkList)
 		modelsGroup.POST("/:namespace/:name/runtime_framework", needAPIKey, middleware.RepoType(types.ModelRepo), repoCommonHandler.RuntimeFrameworkCreate)
@@ -488,7 +595,7 @@ func createDatasetRoutes(config *config.Config, apiGroup *gin.RouterGroup, dsHan
 		datasetsGroup.GET("", dsHandler.Index)
 		datasetsGroup.PUT("/:namespace/:name", dsHandler.Update)
 		datasetsGroup.DELETE("/:namespace/:name", dsHandler.Delete)
-		datasetsGroup.GET("/:namespace/:name", dsHandler.Show)
+		datasetsGroup.GET("/:namespace/:name", middleware.RepoRedirect(types.DatasetRepo), dsHandler.Show)
 		datasetsGroup.GET("/:namespace/:name/all_files", dsHandler.AllFiles)
 		datasetsGroup.GET("/:namespace/:name/relations", dsHandler.Relations)
 		datasetsGroup.GET("/:namespace/:name/branches", middleware.RepoType(types.DatasetRepo), repoCommonHandler.Branches)
@@ -503,7 +610,7 @@ func createDatasetRoutes(config *config.Config, apiGroup *gin.RouterGroup, dsHan
 		datasetsGroup.GET("/:namespace/:name/raw/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.FileRaw)
 		datasetsGroup.GET("/:namespace/:name/blob/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.FileInfo)
 		datasetsGroup.GET("/:namespace/:name/download/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.DownloadFile)
-		datasetsGroup.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.ResolveDownload)
+		datasetsGroup.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.DatasetRepo), middleware.RepoRedirect(types.DatasetRepo), repoCommonHandler.ResolveDownload)
 		datasetsGroup.PUT("/:namespace/:name/raw/*file_path", middleware.RepoType(types.DatasetRepo), repoCommonHandler.UpdateFile)
 		datasetsGroup.POST("/:namespace/:name/update_downloads", middleware.RepoType(types.DatasetRepo), repoCommonHandler.UpdateDownloads)
 		datasetsGroup.PUT("/:namespace/:name/incr_downloads", middleware.RepoType(types.DatasetRepo), repoCommonHandler.IncrDownloads)
@@ -518,6 +625,21 @@ func createDatasetRoutes(config *config.Config, apiGroup *gin.RouterGroup, dsHan
 		if !config.Saas {
 			datasetsGroup.POST("/:namespace/:name/mirror_from_saas", middleware.RepoType(types.DatasetRepo), repoCommonHandler.MirrorFromSaas)
 		}
+
+		// fork
+		datasetsGroup.POST("/:namespace/:name/fork", middleware.RepoType(types.DatasetRepo), repoCommonHandler.Fork)
+		datasetsGroup.GET("/:namespace/:name/fork/upstream", middleware.RepoType(types.DatasetRepo), repoCommonHandler.ForkStatus)
+		datasetsGroup.POST("/:namespace/:name/fork/sync", middleware.RepoType(types.DatasetRepo), repoCommonHandler.SyncFork)
+		datasetsGroup.POST("/:namespace/:name/transfer", middleware.RepoType(types.DatasetRepo), repoCommonHandler.Transfer)
+		// git tags and releases
+		datasetsGroup.GET("/:namespace/:name/git_tags", middleware.RepoType(types.DatasetRepo), repoCommonHandler.GitTags)
+		datasetsGroup.POST("/:namespace/:name/git_tags", middleware.RepoType(types.DatasetRepo), repoCommonHandler.CreateGitTag)
+		datasetsGroup.DELETE("/:namespace/:name/git_tags/:tag", middleware.RepoType(types.DatasetRepo), repoCommonHandler.DeleteGitTag)
+		datasetsGroup.GET("/:namespace/:name/releases", middleware.RepoType(types.DatasetRepo), repoCommonHandler.ListReleases)
+		datasetsGroup.POST("/:namespace/:name/releases", middleware.RepoType(types.DatasetRepo), repoCommonHandler.CreateRelease)
+		datasetsGroup.GET("/:namespace/:name/releases/:tag", middleware.RepoType(types.DatasetRepo), repoCommonHandler.GetRelease)
+		datasetsGroup.PUT("/:namespace/:name/releases/:tag", middleware.RepoType(types.DatasetRepo), repoCommonHandler.UpdateRelease)
+		datasetsGroup.DELETE("/:namespace/:name/releases/:tag", middleware.RepoType(types.DatasetRepo), repoCommonHandler.DeleteRelease)
 	}
 }
 
@@ -528,7 +650,7 @@ func createCodeRoutes(config *config.Config, apiGroup *gin.RouterGroup, codeHand
 		codesGroup.GET("", codeHandler.Index)
 		codesGroup.PUT("/:namespace/:name", codeHandler.Update)
 		codesGroup.DELETE("/:namespace/:name", codeHandler.Delete)
-		codesGroup.GET("/:namespace/:name", codeHandler.Show)
+		codesGroup.GET("/:namespace/:name", middleware.RepoRedirect(types.CodeRepo), codeHandler.Show)
 		codesGroup.GET("/:namespace/:name/relations", codeHandler.Relations)
 		codesGroup.GET("/:namespace/:name/branches", middleware.RepoType(types.CodeRepo), repoCommonHandler.Branches)
 		codesGroup.GET("/:namespace/:name/tags", middleware.RepoType(types.CodeRepo), repoCommonHandler.Tags)
@@ -542,7 +664,7 @@ func createCodeRoutes(config *config.Config, apiGroup *gin.RouterGroup, codeHand
 		codesGroup.GET("/:namespace/:name/raw/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.FileRaw)
 		codesGroup.GET("/:namespace/:name/blob/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.FileInfo)
 		codesGroup.GET("/:namespace/:name/download/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.DownloadFile)
-		codesGroup.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.ResolveDownload)
+		codesGroup.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.CodeRepo), middleware.RepoRedirect(types.CodeRepo), repoCommonHandler.ResolveDownload)
 		codesGroup.PUT("/:namespace/:name/raw/*file_path", middleware.RepoType(types.CodeRepo), repoCommonHandler.UpdateFile)
 		codesGroup.POST("/:namespace/:name/update_downloads", middleware.RepoType(types.CodeRepo), repoCommonHandler.UpdateDownloads)
 		codesGroup.PUT("/:namespace/:name/incr_downloads", middleware.RepoType(types.CodeRepo), repoCommonHandler.IncrDownloads)
@@ -557,6 +679,21 @@ func createCodeRoutes(config *config.Config, apiGroup *gin.RouterGroup, codeHand
 		if !config.Saas {
 			codesGroup.POST("/:namespace/:name/mirror_from_saas", middleware.RepoType(types.CodeRepo), repoCommonHandler.MirrorFromSaas)
 		}
+
+		// fork
+		codesGroup.POST("/:namespace/:name/fork", middleware.RepoType(types.CodeRepo), repoCommonHandler.Fork)
+		codesGroup.GET("/:namespace/:name/fork/upstream", middleware.RepoType(types.CodeRepo), repoCommonHandler.ForkStatus)
+		codesGroup.POST("/:namespace/:name/fork/sync", middleware.RepoType(types.CodeRepo), repoCommonHandler.SyncFork)
+		codesGroup.POST("/:namespace/:name/transfer", middleware.RepoType(types.CodeRepo), repoCommonHandler.Transfer)
+		// git tags and releases
+		codesGroup.GET("/:namespace/:name/git_tags", middleware.RepoType(types.CodeRepo), repoCommonHandler.GitTags)
+		codesGroup.POST("/:namespace/:name/git_tags", middleware.RepoType(types.CodeRepo), repoCommonHandler.CreateGitTag)
+		codesGroup.DELETE("/:namespace/:name/git_tags/:tag", middleware.RepoType(types.CodeRepo), repoCommonHandler.DeleteGitTag)
+		codesGroup.GET("/:namespace/:name/releases", middleware.RepoType(types.CodeRepo), repoCommonHandler.ListReleases)
+		codesGroup.POST("/:namespace/:name/releases", middleware.RepoType(types.CodeRepo), repoCommonHandler.CreateRelease)
+		codesGroup.GET("/:namespace/:name/releases/:tag", middleware.RepoType(types.CodeRepo), repoCommonHandler.GetRelease)
+		codesGroup.PUT("/:namespace/:name/releases/:tag", middleware.RepoType(types.CodeRepo), repoCommonHandler.UpdateRelease)
+		codesGroup.DELETE("/:namespace/:name/releases/:tag", middleware.RepoType(types.CodeRepo), repoCommonHandler.DeleteRelease)
 	}
 }
 
@@ -567,7 +704,7 @@ func createSpaceRoutes(config *config.Config, apiGroup *gin.RouterGroup, spaceHa
 		spaces.GET("", spaceHandler.Index)
 		spaces.POST("", spaceHandler.Create)
 		// show a user or org's space
-		spaces.GET("/:namespace/:name", spaceHandler.Show)
+		spaces.GET("/:namespace/:name", middleware.RepoRedirect(types.SpaceRepo), spaceHandler.Show)
 		spaces.PUT("/:namespace/:name", spaceHandler.Update)
 		spaces.DELETE("/:namespace/:name", spaceHandler.Delete)
 		// depoly and start running the space
@@ -595,7 +732,7 @@ func createSpaceRoutes(config *config.Config, apiGroup *gin.RouterGroup, spaceHa
 		spaces.GET("/:namespace/:name/raw/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.FileRaw)
 		spaces.GET("/:namespace/:name/blob/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.FileInfo)
 		spaces.GET("/:namespace/:name/download/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DownloadFile)
-		spaces.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.ResolveDownload)
+		spaces.GET("/:namespace/:name/resolve/*file_path", middleware.RepoType(types.SpaceRepo), middleware.RepoRedirect(types.SpaceRepo), repoCommonHandler.ResolveDownload)
 		spaces.PUT("/:namespace/:name/raw/*file_path", middleware.RepoType(types.SpaceRepo), repoCommonHandler.UpdateFile)
 		spaces.POST("/:namespace/:name/update_downloads", middleware.RepoType(types.SpaceRepo), repoCommonHandler.UpdateDownloads)
 		spaces.PUT("/:namespace/:name/incr_downloads", middleware.RepoType(types.SpaceRepo), repoCommonHandler.IncrDownloads)
@@ -610,6 +747,21 @@ func createSpaceRoutes(config *config.Config, apiGroup *gin.RouterGroup, spaceHa
 		if !config.Saas {
 			spaces.POST("/:namespace/:name/mirror_from_saas", middleware.RepoType(types.SpaceRepo), repoCommonHandler.MirrorFromSaas)
 		}
+
+		// fork
+		spaces.POST("/:namespace/:name/fork", middleware.RepoType(types.SpaceRepo), repoCommonHandler.Fork)
+		spaces.GET("/:namespace/:name/fork/upstream", middleware.RepoType(types.SpaceRepo), repoCommonHandler.ForkStatus)
+		spaces.POST("/:namespace/:name/fork/sync", middleware.RepoType(types.SpaceRepo), repoCommonHandler.SyncFork)
+		spaces.POST("/:namespace/:name/transfer", middleware.RepoType(types.SpaceRepo), repoCommonHandler.Transfer)
+		// git tags and releases
+		spaces.GET("/:namespace/:name/git_tags", middleware.RepoType(types.SpaceRepo), repoCommonHandler.GitTags)
+		spaces.POST("/:namespace/:name/git_tags", middleware.RepoType(types.SpaceRepo), repoCommonHandler.CreateGitTag)
+		spaces.DELETE("/:namespace/:name/git_tags/:tag", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeleteGitTag)
+		spaces.GET("/:namespace/:name/releases", middleware.RepoType(types.SpaceRepo), repoCommonHandler.ListReleases)
+		spaces.POST("/:namespace/:name/releases", middleware.RepoType(types.SpaceRepo), repoCommonHandler.CreateRelease)
+		spaces.GET("/:namespace/:name/releases/:tag", middleware.RepoType(types.SpaceRepo), repoCommonHandler.GetRelease)
+		spaces.PUT("/:namespace/:name/releases/:tag", middleware.RepoType(types.SpaceRepo), repoCommonHandler.UpdateRelease)
+		spaces.DELETE("/:namespace/:name/releases/:tag", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeleteRelease)
 		spaces.GET("/:namespace/:name/run", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeployList)
 		spaces.GET("/:namespace/:name/run/:id", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeployDetail)
 		spaces.GET("/:namespace/:name/run/:id/status", middleware.RepoType(types.SpaceRepo), repoCommonHandler.DeployStatus)
@@ -692,12 +844,12 @@ func createHFRoutes(r *gin.Engine, hfdsHandler *handler.HFDatasetHandler, repoCo
 	// Huggingface SDK routes
 	hfGroup := r.Group("/hf")
 	{
-		hfGroup.GET("/:namespace/:name/resolve/:branch/*file_path", middleware.RepoMapping(types.ModelRepo), repoCommonHandler.SDKDownload)
-		hfGroup.HEAD("/:namespace/:name/resolve/:branch/*file_path", middleware.RepoMapping(types.ModelRepo), repoCommonHandler.HeadSDKDownload)
+		hfGroup.GET("/:namespace/:name/resolve/:branch/*file_path", middleware.RepoMapping(types.ModelRepo), middleware.RepoRedirect(types.ModelRepo), repoCommonHandler.SDKDownload)
+		hfGroup.HEAD("/:namespace/:name/resolve/:branch/*file_path", middleware.RepoMapping(types.ModelRepo), middleware.RepoRedirect(types.ModelRepo), repoCommonHandler.HeadSDKDownload)
 		hfdsFileGroup := hfGroup.Group("/datasets")
 		{
-			hfdsFileGroup.GET("/:namespace/:name/resolve/:branch/*file_path", middleware.RepoMapping(types.DatasetRepo), repoCommonHandler.SDKDownload)
-			hfdsFileGroup.HEAD("/:namespace/:name/resolve/:branch/*file_path", middleware.RepoMapping(types.DatasetRepo), repoCommonHandler.HeadSDKDownload)
+			hfdsFileGroup.GET("/:namespace/:name/resolve/:branch/*file_path", middleware.RepoMapping(types.DatasetRepo), middleware.RepoRedirect(types.DatasetRepo), repoCommonHandler.SDKDownload)
+			hfdsFileGroup.HEAD("/:namespace/:name/resolve/:branch/*file_path", middleware.RepoMapping(types.DatasetRepo), middleware.RepoRedirect(types.DatasetRepo), repoCommonHandler.HeadSDKDownload)
 		}
 		hfAPIGroup := hfGroup.Group("/api")
 		{
@@ -705,14 +857,14 @@ func createHFRoutes(r *gin.Engine, hfdsHandler *handler.HFDatasetHandler, repoCo
 			hfModelAPIGroup := hfAPIGroup.Group("/models")
 			{
 				// compitable with HF model info api, used for sdk like this:  huggingface_hub.model_info(repo_id, revision)
-				hfModelAPIGroup.GET("/:namespace/:name/revision/:ref", middleware.RepoMapping(types.ModelRepo), modelHandler.SDKModelInfo)
-				hfModelAPIGroup.GET("/:namespace/:name", middleware.RepoMapping(types.ModelRepo), modelHandler.SDKModelInfo)
+				hfModelAPIGroup.GET("/:namespace/:name/revision/:ref", middleware.RepoMapping(types.ModelRepo), middleware.RepoRedirect(types.ModelRepo), modelHandler.SDKModelInfo)
+				hfModelAPIGroup.GET("/:namespace/:name", middleware.RepoMapping(types.ModelRepo), middleware.RepoRedirect(types.ModelRepo), modelHandler.SDKModelInfo)
 			}
 			hfDSAPIGroup := hfAPIGroup.Group("/datasets")
 			{
 				// compitable with HF dataset info api, used for sdk like this: huggingface_hub.dataset_info(repo_id, revision)
-				hfDSAPIGroup.GET("/:namespace/:name/revision/:ref", middleware.RepoMapping(types.DatasetRepo), repoCommonHandler.SDKListFiles)
-				hfDSAPIGroup.GET("/:namespace/:name", middleware.RepoMapping(types.DatasetRepo), repoCommonHandler.SDKListFiles)
+				hfDSAPIGroup.GET("/:namespace/:name/revision/:ref", middleware.RepoMapping(types.DatasetRepo), middleware.RepoRedirect(types.DatasetRepo), repoCommonHandler.SDKListFiles)
+				hfDSAPIGroup.GET("/:namespace/:name", middleware.RepoMapping(types.DatasetRepo), middleware.RepoRedirect(types.DatasetRepo), repoCommonHandler.SDKListFiles)
 				hfDSAPIGroup.POST("/:namespace/:name/paths-info/:ref", hfdsHandler.DatasetPathsInfo)
 				hfDSAPIGroup.GET("/:namespace/:name/tree/:ref/*path_in_repo", hfdsHandler.DatasetTree)
 				hfDSAPIGroup.GET("/:namespace/:name/resolve/:ref/.huggingface.yaml", hfdsHandler.HandleHFYaml)
@@ -733,6 +885,60 @@ func createDiscussionRoutes(apiGroup *gin.RouterGroup, needAPIKey gin.HandlerFun
 	apiGroup.DELETE("/discussions/:id/comments/:comment_id", discussionHandler.DeleteComment)
 }
 
+func createPullRequestRoutes(apiGroup *gin.RouterGroup, pullRequestHandler *handler.PullRequestHandler) {
+	apiGroup.POST("/:repo_type/:namespace/:name/pulls", pullRequestHandler.Create)
+	apiGroup.GET("/:repo_type/:namespace/:name/pulls", pullRequestHandler.Index)
+	apiGroup.GET("/:repo_type/:namespace/:name/pulls/:id", pullRequestHandler.Show)
+	apiGroup.PUT("/:repo_type/:namespace/:name/pulls/:id", pullRequestHandler.Update)
+	apiGroup.PUT("/:repo_type/:namespace/:name/pulls/:id/close", pullRequestHandler.Close)
+	apiGroup.PUT("/:repo_type/:namespace/:name/pulls/:id/reopen", pullRequestHandler.Reopen)
+	apiGroup.GET("/:repo_type/:namespace/:name/pulls/:id/diff", pullRequestHandler.Diff)
+	apiGroup.POST("/:repo_type/:namespace/:name/pulls/:id/merge", pullRequestHandler.Merge)
+	apiGroup.POST("/:repo_type/:namespace/:name/pulls/:id/comments", pullRequestHandler.CreateComment)
+	apiGroup.GET("/:repo_type/:namespace/:name/pulls/:id/comments", pullRequestHandler.ListComments)
+	apiGroup.DELETE("/:repo_type/:namespace/:name/pulls/:id/comments/:comment_id", pullRequestHandler.DeleteComment)
+}
+
+func createBranchProtectionRoutes(apiGroup *gin.RouterGroup, branchProtectionHandler *handler.BranchProtectionHandler) {
+	apiGroup.GET("/:repo_type/:namespace/:name/branch_protections", branchProtectionHandler.Index)
+	apiGroup.POST("/:repo_type/:namespace/:name/branch_protections", branchProtectionHandler.Create)
+	apiGroup.PUT("/:repo_type/:namespace/:name/branch_protections/:id", branchProtectionHandler.Update)
+	apiGroup.DELETE("/:repo_type/:namespace/:name/branch_protections/:id", branchProtectionHandler.Delete)
+}
+
+func createWebhookRoutes(apiGroup *gin.RouterGroup, webhookHandler *handler.WebhookHandler) {
+	for _, prefix := range []string{"/:repo_type/:namespace/:name/webhooks", "/organization/:namespace/webhooks"} {
+		apiGroup.GET(prefix, webhookHandler.Index)
+		apiGroup.POST(prefix, webhookHandler.Create)
+		apiGroup.GET(prefix+"/:id", webhookHandler.Get)
+		apiGroup.PUT(prefix+"/:id", webhookHandler.Update)
+		apiGroup.DELETE(prefix+"/:id", webhookHandler.Delete)
+		apiGroup.POST(prefix+"/:id/ping", webhookHandler.Ping)
+		apiGroup.GET(prefix+"/:id/deliveries", webhookHandler.ListDeliveries)
+		apiGroup.POST(prefix+"/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+	}
+}
+
+func createModerationCaseRoutes(apiGroup *gin.RouterGroup, moderationCaseHandler *handler.ModerationCaseHandler) {
+	apiGroup.GET("/:repo_type/:namespace/:name/moderation_cases", moderationCaseHandler.Index)
+	moderationCases := apiGroup.Group("/moderation_cases")
+	{
+		moderationCases.GET("", moderationCaseHandler.Index)
+		moderationCases.GET("/:id", moderationCaseHandler.Show)
+		moderationCases.POST("/:id/appeal", moderationCaseHandler.Appeal)
+		moderationCases.POST("/:id/decision", moderationCaseHandler.Decide)
+	}
+}
+
+func createSecretScanningRoutes(apiGroup *gin.RouterGroup, secretScanningHandler *handler.SecretScanningHandler) {
+	prefix := "/:repo_type/:namespace/:name"
+	apiGroup.GET(prefix+"/secret_findings", secretScanningHandler.IndexFindings)
+	apiGroup.POST(prefix+"/secret_findings/:id/dismiss", secretScanningHandler.DismissFinding)
+	apiGroup.GET(prefix+"/secret_allowlist", secretScanningHandler.ListAllowlist)
+	apiGroup.POST(prefix+"/secret_allowlist", secretScanningHandler.CreateAllowlistEntry)
+	apiGroup.DELETE(prefix+"/secret_allowlist/:id", secretScanningHandler.DeleteAllowlistEntry)
+}
+
 func createPromptRoutes(apiGroup *gin.RouterGroup, promptHandler *handler.PromptHandler) {
 	promptGrp := apiGroup.Group("/prompts")
 	{
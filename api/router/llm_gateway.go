@@ -0,0 +1,33 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"opencsg.com/csghub-server/api/handler"
+	"opencsg.com/csghub-server/api/middleware"
+	"opencsg.com/csghub-server/common/config"
+)
+
+// NewLLMGatewayRouter serves the openai compatible apis of all deployed models at one base url
+func NewLLMGatewayRouter(config *config.Config) (*gin.Engine, error) {
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.Log())
+	r.Use(middleware.Authenticator(config))
+
+	gatewayHandler, err := handler.NewLLMGatewayHandler(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating llm gateway handler:%w", err)
+	}
+
+	v1 := r.Group("/v1", gatewayHandler.RequireUser, gatewayHandler.RateLimit)
+	{
+		v1.GET("/models", gatewayHandler.ListModels)
+		v1.POST("/chat/completions", gatewayHandler.Proxy)
+		v1.POST("/completions", gatewayHandler.Proxy)
+		v1.POST("/embeddings", gatewayHandler.Proxy)
+	}
+
+	return r, nil
+}
@@ -64,3 +64,13 @@ func SensitiveCheck(ctx context.Context, req *types.GiteaCallbackPushReq, config
 	callbackComponent.SetRepoVisibility(true)
 	return callbackComponent.SensitiveCheck(ctx, req)
 }
+
+func NotifyWebhooks(ctx context.Context, req *types.GiteaCallbackPushReq, config *config.Config) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("notify webhooks start", "req", req)
+	callbackComponent, err := callback.NewGitCallback(config)
+	if err != nil {
+		return fmt.Errorf("failed to create callback component, error: %w", err)
+	}
+	return callbackComponent.NotifyWebhooks(ctx, req)
+}
@@ -0,0 +1,21 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+
+	"go.temporal.io/sdk/activity"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/component"
+)
+
+func VerifyLfsObjectContent(ctx context.Context, req *types.VerifyLfsObjectContentReq, config *config.Config) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("verify lfs object content start", "req", req)
+	gitHTTPComponent, err := component.NewGitHTTPComponent(config)
+	if err != nil {
+		return fmt.Errorf("failed to create git http component, error: %w", err)
+	}
+	return gitHTTPComponent.VerifyLfsObjectContent(ctx, *req)
+}
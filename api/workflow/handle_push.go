@@ -46,6 +46,12 @@ func HandlePushWorkflow(ctx workflow.Context, req *types.GiteaCallbackPushReq, c
 		return err
 	}
 
+	// Notify webhooks, a failure should not stop updating the repo
+	err = workflow.ExecuteActivity(ctx, activity.NotifyWebhooks, req, config).Get(ctx, nil)
+	if err != nil {
+		logger.Error("failed to notify webhooks", "error", err, "req", req)
+	}
+
 	// Update repo infos
 	err = workflow.ExecuteActivity(ctx, activity.UpdateRepoInfos, req, config).Get(ctx, nil)
 	if err != nil {
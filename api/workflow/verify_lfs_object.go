@@ -0,0 +1,33 @@
+package workflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"opencsg.com/csghub-server/api/workflow/activity"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// VerifyLfsObjectContentWorkflow checks the content of lfs object matches its oid in background, as reading
+// large objects takes too long for the verify request of lfs clients
+func VerifyLfsObjectContentWorkflow(ctx workflow.Context, req *types.VerifyLfsObjectContentReq, config *config.Config) error {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("verify lfs object content workflow started", "oid", req.Oid)
+
+	options := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Hour * 6,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 3,
+		},
+	}
+	ctx = workflow.WithActivityOptions(ctx, options)
+	err := workflow.ExecuteActivity(ctx, activity.VerifyLfsObjectContent, req, config).Get(ctx, nil)
+	if err != nil {
+		logger.Error("failed to verify lfs object content", "error", err, "req", req)
+		return err
+	}
+	return nil
+}
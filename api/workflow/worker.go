@@ -24,11 +24,14 @@ func StartWorker(config *config.Config) error {
 	}
 	wfWorker = worker.New(wfClient, HandlePushQueueName, worker.Options{})
 	wfWorker.RegisterWorkflow(HandlePushWorkflow)
+	wfWorker.RegisterWorkflow(VerifyLfsObjectContentWorkflow)
 	wfWorker.RegisterActivity(activity.WatchSpaceChange)
 	wfWorker.RegisterActivity(activity.WatchRepoRelation)
 	wfWorker.RegisterActivity(activity.SetRepoUpdateTime)
 	wfWorker.RegisterActivity(activity.UpdateRepoInfos)
 	wfWorker.RegisterActivity(activity.SensitiveCheck)
+	wfWorker.RegisterActivity(activity.NotifyWebhooks)
+	wfWorker.RegisterActivity(activity.VerifyLfsObjectContent)
 
 	return wfWorker.Start()
 }
@@ -14,4 +14,9 @@ type DeployConfig struct {
 	SSHDomain               string
 	//download lfs object from internal s3 address
 	S3Internal bool
+	// limits of the tasks scheduler runs at the same time, 0 means no limit
+	MaxRunningTasks        int
+	MaxRunningTasksPerUser int
+	MaxRunningTasksPerOrg  int
+	TaskTimeoutInMin       int
 }
@@ -39,6 +39,9 @@ type Deployer interface {
 	UpdateDeploy(ctx context.Context, dur *types.DeployUpdateReq, deploy *database.Deploy) error
 	StartDeploy(ctx context.Context, deploy *database.Deploy) error
 	CheckResourceAvailable(ctx context.Context, clusterId string, hardWare *types.HardWare) (bool, error)
+	// ListDeployQueue returns the deploy tasks running and waiting in the scheduler
+	ListDeployQueue(ctx context.Context) ([]types.DeployQueueTask, error)
+	SetDeployTaskPriority(ctx context.Context, taskID int64, priority int) error
 }
 
 var _ Deployer = (*deployer)(nil)
@@ -81,6 +84,7 @@ func newDeployer(s scheduler.Scheduler, ib imagebuilder.Builder, ir imagerunner.
 	go d.refreshStatus()
 	go d.s.Run()
 	go d.startAccounting()
+	go d.startAutoscaling()
 
 	return d, nil
 }
@@ -165,6 +169,7 @@ func (d *deployer) dedicatedDeploy(ctx context.Context, dr types.DeployRepo) (*d
 		Type:             dr.Type,
 		UserUUID:         dr.UserUUID,
 		SKU:              dr.SKU,
+		Autoscaling:      dr.Autoscaling,
 	}
 	err := d.store.CreateDeploy(ctx, deploy)
 	return deploy, err
@@ -580,6 +585,13 @@ func (d *deployer) UpdateDeploy(ctx context.Context, dur *types.DeployUpdateReq,
 		deploy.ClusterID = *dur.ClusterID
 	}
 
+	if dur.Autoscaling != nil {
+		if err := dur.Autoscaling.Validate(); err != nil {
+			return fmt.Errorf("invalid autoscaling policy, %w", err)
+		}
+		deploy.Autoscaling = dur.Autoscaling
+	}
+
 	// update deploy table
 	err = d.store.UpdateDeploy(ctx, deploy)
 	if err != nil {
@@ -597,18 +609,70 @@ func (d *deployer) StartDeploy(ctx context.Context, deploy *database.Deploy) err
 		return fmt.Errorf("failed to update deploy, %w", err)
 	}
 
-	// start model as inference/serverless task
+	return d.queueRunTask(ctx, deploy.ID)
+}
+
+// queueRunTask starts model as inference/serverless task
+func (d *deployer) queueRunTask(ctx context.Context, deployID int64) error {
 	runTask := &database.DeployTask{
-		DeployID: deploy.ID,
+		DeployID: deployID,
 		TaskType: 1,
 	}
-	d.store.CreateDeployTask(ctx, runTask)
+	err := d.store.CreateDeployTask(ctx, runTask)
+	if err != nil {
+		return fmt.Errorf("failed to create deploy run task, %w", err)
+	}
 
 	go d.s.Queue(runTask.ID)
 
 	return nil
 }
 
+// autoscaling timer, scales idle inference endpoints to zero
+func (d *deployer) startAutoscaling() {
+	for {
+		time.Sleep(time.Minute)
+		d.sleepIdleDeploys()
+	}
+}
+
+func (d *deployer) sleepIdleDeploys() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	deploys, err := d.store.ListIdleDeploys(ctx, time.Now())
+	if err != nil {
+		slog.Error("failed to list idle deploys", slog.Any("error", err))
+		return
+	}
+	for _, deploy := range deploys {
+		// mark deploy sleeping first, so that new requests wake it up rather than reach the stopping service
+		ok, err := d.store.UpdateDeployStatusIf(ctx, deploy.ID, common.Running, common.Sleeping)
+		if err != nil {
+			slog.Error("failed to update status of idle deploy", slog.Int64("deploy_id", deploy.ID), slog.Any("error", err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		namespace, name, _ := strings.Cut(strings.TrimPrefix(deploy.GitPath, string(types.ModelRepo)+"s_"), "/")
+		err = d.Stop(ctx, types.DeployRepo{
+			DeployID:  deploy.ID,
+			ModelID:   deploy.ModelID,
+			Namespace: namespace,
+			Name:      name,
+			SvcName:   deploy.SvcName,
+			ClusterID: deploy.ClusterID,
+		})
+		if err != nil {
+			// keep deploy running as it's not stopped
+			_, _ = d.store.UpdateDeployStatusIf(ctx, deploy.ID, common.Sleeping, common.Running)
+			continue
+		}
+		slog.Info("scale idle inference endpoint to zero", slog.Int64("deploy_id", deploy.ID), slog.String("svc_name", deploy.SvcName),
+			slog.Int("idle_minutes", deploy.Autoscaling.ScaleToZeroIdleMinutes))
+	}
+}
+
 // accounting timer
 func (d *deployer) startAccounting() {
 	d.startAccountingMetering()
@@ -656,7 +720,7 @@ func (d *deployer) startAccountingRequestMeter(resMap map[string]string, svcRes
 		return
 	}
 	slog.Debug("metering service", slog.Any("svcRes", svcRes))
-	sceneType := getValidSceneType(svcRes.DeployType)
+	sceneType := GetValidSceneType(svcRes.DeployType)
 	if sceneType == types.SceneUnknow {
 		slog.Error("invalid deploy type of service for metering", slog.Any("svcRes", svcRes))
 		return
@@ -687,7 +751,8 @@ func (d *deployer) startAccountingRequestMeter(resMap map[string]string, svcRes
 	}
 }
 
-func getValidSceneType(deployType int) types.SceneType {
+// GetValidSceneType returns the accounting scene of deploy type
+func GetValidSceneType(deployType int) types.SceneType {
 	switch deployType {
 	case types.SpaceType:
 		return types.SceneSpace
@@ -755,3 +820,11 @@ func CheckResource(clusterResources *types.ClusterRes, hardware *types.HardWare)
 	}
 	return false
 }
+
+func (d *deployer) ListDeployQueue(ctx context.Context) ([]types.DeployQueueTask, error) {
+	return d.s.ListQueue(ctx)
+}
+
+func (d *deployer) SetDeployTaskPriority(ctx context.Context, taskID int64, priority int) error {
+	return d.s.SetPriority(ctx, taskID, priority)
+}
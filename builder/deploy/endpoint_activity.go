@@ -0,0 +1,93 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/store/database"
+)
+
+// EndpointActivity tracks the requests of inference endpoints in the proxies, which run without the
+// deployer. The last request time of endpoints is saved every minute for the deployer to find the idle
+// ones, and the endpoints scaled to zero are woken up with a run task picked up by the scheduler
+type EndpointActivity struct {
+	store        database.DeployTaskStore
+	lastRequests map[int64]time.Time
+	mu           sync.Mutex
+	once         sync.Once
+}
+
+var defaultEndpointActivity = &EndpointActivity{
+	lastRequests: make(map[int64]time.Time),
+}
+
+// NewEndpointActivity returns the endpoint activity tracker shared in the process
+func NewEndpointActivity() *EndpointActivity {
+	return defaultEndpointActivity
+}
+
+func (a *EndpointActivity) init() {
+	a.once.Do(func() {
+		a.store = database.NewDeployTaskStore()
+		go a.flushPeriodically()
+	})
+}
+
+// RecordRequest marks the inference endpoint of deploy as in use, the endpoint is scaled to zero
+// after it's not in use for the idle minutes of its autoscaling policy
+func (a *EndpointActivity) RecordRequest(deployID int64) {
+	a.init()
+	a.mu.Lock()
+	a.lastRequests[deployID] = time.Now()
+	a.mu.Unlock()
+}
+
+// Wakeup starts the inference endpoint scaled to zero again, it's a no-op if the endpoint
+// is not sleeping or has been woken up by another request
+func (a *EndpointActivity) Wakeup(ctx context.Context, deploy *database.Deploy) error {
+	a.init()
+	woken, err := a.store.UpdateDeployStatusIf(ctx, deploy.ID, common.Sleeping, common.Pending)
+	if err != nil {
+		return fmt.Errorf("failed to update deploy status, %w", err)
+	}
+	if !woken {
+		return nil
+	}
+	slog.Info("wake up idle inference endpoint", slog.Int64("deploy_id", deploy.ID), slog.String("svc_name", deploy.SvcName))
+	deploy.Status = common.Pending
+	err = a.store.CreateDeployTask(ctx, &database.DeployTask{
+		DeployID: deploy.ID,
+		TaskType: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create deploy run task, %w", err)
+	}
+	return nil
+}
+
+func (a *EndpointActivity) flushPeriodically() {
+	for {
+		time.Sleep(time.Minute)
+		a.flush()
+	}
+}
+
+func (a *EndpointActivity) flush() {
+	a.mu.Lock()
+	lastRequests := a.lastRequests
+	a.lastRequests = make(map[int64]time.Time)
+	a.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for deployID, lastRequestAt := range lastRequests {
+		err := a.store.SetLastRequestAt(ctx, deployID, lastRequestAt)
+		if err != nil {
+			slog.Error("failed to save last request time of deploy", slog.Int64("deploy_id", deployID), slog.Any("error", err))
+		}
+	}
+}
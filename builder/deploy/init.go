@@ -10,8 +10,8 @@ import (
 )
 
 var (
-	fifoScheduler   scheduler.Scheduler
-	defaultDeployer Deployer
+	defaultScheduler scheduler.Scheduler
+	defaultDeployer  Deployer
 )
 
 func Init(c common.DeployConfig) error {
@@ -25,8 +25,8 @@ func Init(c common.DeployConfig) error {
 		panic(fmt.Errorf("failed to create image runner:%w", err))
 	}
 
-	fifoScheduler = scheduler.NewFIFOScheduler(ib, ir, c)
-	deployer, err := newDeployer(fifoScheduler, ib, ir)
+	defaultScheduler = scheduler.NewPriorityScheduler(ib, ir, c)
+	deployer, err := newDeployer(defaultScheduler, ib, ir)
 	if err != nil {
 		return fmt.Errorf("failed to create deployer:%w", err)
 	}
@@ -11,24 +11,29 @@ import (
 	"opencsg.com/csghub-server/builder/deploy/common"
 	"opencsg.com/csghub-server/builder/deploy/imagebuilder"
 	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/webhook"
 )
 
 // BuilderRunner defines a docker image building task
 type BuilderRunner struct {
-	repo        *RepoInfo
-	task        *database.DeployTask
-	ib          imagebuilder.Builder
-	deployStore database.DeployTaskStore
-	tokenStore  database.AccessTokenStore
+	repo            *RepoInfo
+	task            *database.DeployTask
+	ib              imagebuilder.Builder
+	deployStore     database.DeployTaskStore
+	tokenStore      database.AccessTokenStore
+	repoStore       database.RepoStore
+	webhookNotifier webhook.Notifier
 }
 
 func NewBuidRunner(b imagebuilder.Builder, r *RepoInfo, t *database.DeployTask) Runner {
 	return &BuilderRunner{
-		repo:        r,
-		task:        t,
-		ib:          b,
-		deployStore: database.NewDeployTaskStore(),
-		tokenStore:  database.NewAccessTokenStore(),
+		repo:            r,
+		task:            t,
+		ib:              b,
+		deployStore:     database.NewDeployTaskStore(),
+		tokenStore:      database.NewAccessTokenStore(),
+		repoStore:       database.NewRepoStore(),
+		webhookNotifier: webhook.NewNotifier(),
 	}
 }
 
@@ -136,6 +141,7 @@ func (t *BuilderRunner) Run(ctx context.Context) error {
 }
 
 func (t *BuilderRunner) buildInProgress() {
+	changed := t.task.Deploy.Status != common.Building
 	t.task.Status = buildInProgress
 	t.task.Message = "build in progress"
 	// change to buidling status
@@ -144,10 +150,15 @@ func (t *BuilderRunner) buildInProgress() {
 	defer cancel()
 	if err := t.deployStore.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
 		slog.Error("failed to change deploy status to `Building`", "error", err)
+		return
+	}
+	if changed {
+		notifyDeployStatus(t.webhookNotifier, t.repoStore, t.task.Deploy, t.task.Message)
 	}
 }
 
 func (t *BuilderRunner) buildSuccess(resp imagebuilder.StatusResponse) {
+	changed := t.task.Deploy.Status != common.BuildSuccess
 	t.task.Status = buildSucceed
 	t.task.Message = "build succeeded"
 	// change to buidling status
@@ -157,10 +168,15 @@ func (t *BuilderRunner) buildSuccess(resp imagebuilder.StatusResponse) {
 	defer cancel()
 	if err := t.deployStore.UpdateInTx(ctx, []string{"status", "image_id"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
 		slog.Error("failed to change deploy status to `BuildSuccess`", "error", err)
+		return
+	}
+	if changed {
+		notifyDeployStatus(t.webhookNotifier, t.repoStore, t.task.Deploy, t.task.Message)
 	}
 }
 
 func (t *BuilderRunner) buildFailed() {
+	changed := t.task.Deploy.Status != common.BuildFailed
 	t.task.Status = buildFailed
 	t.task.Message = "build failed"
 	// change to buidling status
@@ -169,6 +185,10 @@ func (t *BuilderRunner) buildFailed() {
 	defer cancel()
 	if err := t.deployStore.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
 		slog.Error("failed to change deploy status to `BuildFailed`", "error", err)
+		return
+	}
+	if changed {
+		notifyDeployStatus(t.webhookNotifier, t.repoStore, t.task.Deploy, t.task.Message)
 	}
 }
 
@@ -13,6 +13,7 @@ import (
 	"opencsg.com/csghub-server/builder/deploy/common"
 	"opencsg.com/csghub-server/builder/deploy/imagerunner"
 	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/webhook"
 	"opencsg.com/csghub-server/common/types"
 )
 
@@ -25,6 +26,8 @@ type DeployRunner struct {
 	tokenStore      database.AccessTokenStore
 	deployStartTime time.Time
 	deployCfg       common.DeployConfig
+	repoStore       database.RepoStore
+	webhookNotifier webhook.Notifier
 }
 
 func NewDeployRunner(ir imagerunner.Runner, r *RepoInfo, t *database.DeployTask, deployCfg common.DeployConfig) Runner {
@@ -36,6 +39,8 @@ func NewDeployRunner(ir imagerunner.Runner, r *RepoInfo, t *database.DeployTask,
 		deployStartTime: time.Now(),
 		tokenStore:      database.NewAccessTokenStore(),
 		deployCfg:       deployCfg,
+		repoStore:       database.NewRepoStore(),
+		webhookNotifier: webhook.NewNotifier(),
 	}
 
 }
@@ -143,6 +148,7 @@ func (t *DeployRunner) Run(ctx context.Context) error {
 func (t *DeployRunner) WatchID() int64 { return t.task.ID }
 
 func (t *DeployRunner) deployInProgress(svcName string) {
+	changed := t.task.Deploy.Status != common.Deploying
 	t.task.Status = deploying
 	t.task.Message = "deploy in progress"
 	// change to buidling status
@@ -154,10 +160,15 @@ func (t *DeployRunner) deployInProgress(svcName string) {
 	defer cancel()
 	if err := t.store.UpdateInTx(ctx, []string{"status", "svc_name"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
 		slog.Error("failed to change deploy status to `Deploying`", "error", err)
+		return
+	}
+	if changed {
+		notifyDeployStatus(t.webhookNotifier, t.repoStore, t.task.Deploy, t.task.Message)
 	}
 }
 
 func (t *DeployRunner) deploySuccess() {
+	changed := t.task.Deploy.Status != common.Startup
 	t.task.Status = deployStartUp
 	t.task.Message = "deploy succeeded, wati for startup"
 	// change to buidling status
@@ -166,10 +177,15 @@ func (t *DeployRunner) deploySuccess() {
 	defer cancel()
 	if err := t.store.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
 		slog.Error("failed to change deploy status to `Startup`", "error", err)
+		return
+	}
+	if changed {
+		notifyDeployStatus(t.webhookNotifier, t.repoStore, t.task.Deploy, t.task.Message)
 	}
 }
 
 func (t *DeployRunner) deployFailed(msg string) {
+	changed := t.task.Deploy.Status != common.DeployFailed
 	t.task.Status = deployFailed
 	t.task.Message = msg
 	// change to buidling status
@@ -178,10 +194,15 @@ func (t *DeployRunner) deployFailed(msg string) {
 	defer cancel()
 	if err := t.store.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
 		slog.Error("failed to change deploy status to `DeployFailed`", "error", err)
+		return
+	}
+	if changed {
+		notifyDeployStatus(t.webhookNotifier, t.repoStore, t.task.Deploy, t.task.Message)
 	}
 }
 
 func (t *DeployRunner) running(endpoint string) {
+	changed := t.task.Deploy.Status != common.Running
 	t.task.Status = deployRunning
 	t.task.Message = "running"
 	// change to buidling status
@@ -191,10 +212,15 @@ func (t *DeployRunner) running(endpoint string) {
 	defer cancel()
 	if err := t.store.UpdateInTx(ctx, []string{"status", "endpoint"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
 		slog.Error("failed to change deploy status to `Running`", "error", err)
+		return
+	}
+	if changed {
+		notifyDeployStatus(t.webhookNotifier, t.repoStore, t.task.Deploy, t.task.Message)
 	}
 }
 
 func (t *DeployRunner) runtimeError(msg string) {
+	changed := t.task.Deploy.Status != common.RunTimeError
 	t.task.Status = deployRunTimeError
 	t.task.Message = msg
 	// change to buidling status
@@ -203,6 +229,10 @@ func (t *DeployRunner) runtimeError(msg string) {
 	defer cancel()
 	if err := t.store.UpdateInTx(ctx, []string{"status"}, []string{"status", "message"}, t.task.Deploy, t.task); err != nil {
 		slog.Error("failed to change deploy status to `RunTimeError`", "error", err)
+		return
+	}
+	if changed {
+		notifyDeployStatus(t.webhookNotifier, t.repoStore, t.task.Deploy, t.task.Message)
 	}
 }
 
@@ -312,6 +342,7 @@ func (t *DeployRunner) makeDeployRequest() (*types.RunRequest, error) {
 		DeployType:  deploy.Type,
 		UserID:      deploy.UserUUID,
 		Sku:         deploy.SKU,
+		Autoscaling: deploy.Autoscaling,
 	}, nil
 }
 
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// deployTypePriorities ranks deploy types, tasks of higher priority run first
+var deployTypePriorities = map[int]int{
+	types.ServerlessType: 300,
+	types.InferenceType:  200,
+	types.FinetuneType:   100,
+	types.SpaceType:      0,
+}
+
+type queueLimits struct {
+	// total is not checked by orderQueue, the number of slots is passed instead
+	total   int
+	perUser int
+	perOrg  int
+}
+
+type queuedTask struct {
+	task *database.DeployTask
+	org  string
+	// priority of deploy type plus the priority set by admin
+	priority int
+}
+
+func newQueuedTask(task *database.DeployTask, org string) *queuedTask {
+	return &queuedTask{
+		task:     task,
+		org:      org,
+		priority: deployTypePriorities[task.Deploy.Type] + task.Priority,
+	}
+}
+
+func (t *queuedTask) userKey() string {
+	return fmt.Sprintf("user:%d", t.task.Deploy.UserID)
+}
+
+func (t *queuedTask) orgKey() string {
+	if t.org == "" {
+		return ""
+	}
+	return "org:" + t.org
+}
+
+func (t *queuedTask) toQueueTask(position int) types.DeployQueueTask {
+	return types.DeployQueueTask{
+		TaskID:             t.task.ID,
+		TaskType:           t.task.TaskType,
+		Status:             t.task.Status,
+		DeployID:           t.task.DeployID,
+		DeployName:         t.task.Deploy.DeployName,
+		DeployType:         t.task.Deploy.Type,
+		RepoID:             t.task.Deploy.RepoID,
+		UserID:             t.task.Deploy.UserID,
+		Org:                t.org,
+		Priority:           t.priority,
+		PriorityAdjustment: t.task.Priority,
+		Running:            position == 0,
+		Position:           position,
+		CreatedAt:          t.task.CreatedAt,
+	}
+}
+
+// runningCounts counts the running tasks of each user and organization
+func runningCounts(running []*queuedTask) map[string]int {
+	counts := make(map[string]int)
+	for _, t := range running {
+		counts[t.userKey()]++
+		if key := t.orgKey(); key != "" {
+			counts[key]++
+		}
+	}
+	return counts
+}
+
+// allow checks whether the task can run without exceeding the limits of its user and organization
+func (l queueLimits) allow(counts map[string]int, t *queuedTask) bool {
+	if l.perUser > 0 && counts[t.userKey()] >= l.perUser {
+		return false
+	}
+	if key := t.orgKey(); key != "" && l.perOrg > 0 && counts[key] >= l.perOrg {
+		return false
+	}
+	return true
+}
+
+// orderQueue picks at most slots tasks (no limit if slots < 0) in the order to run. Tasks of higher priority go first,
+// among tasks of the same priority the user with fewer running tasks goes first, so that a burst of tasks
+// from one user does not starve the others, and the older task goes first at last.
+func orderQueue(waiting []*queuedTask, running map[string]int, limits queueLimits, slots int) []*queuedTask {
+	counts := make(map[string]int, len(running))
+	for k, v := range running {
+		counts[k] = v
+	}
+	remaining := make([]*queuedTask, len(waiting))
+	copy(remaining, waiting)
+
+	var picked []*queuedTask
+	for slots < 0 || len(picked) < slots {
+		best := -1
+		for i, t := range remaining {
+			if !limits.allow(counts, t) {
+				continue
+			}
+			if best < 0 || runsBefore(t, remaining[best], counts) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		t := remaining[best]
+		picked = append(picked, t)
+		counts[t.userKey()]++
+		if key := t.orgKey(); key != "" {
+			counts[key]++
+		}
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return picked
+}
+
+func runsBefore(a, b *queuedTask, counts map[string]int) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if ca, cb := counts[a.userKey()], counts[b.userKey()]; ca != cb {
+		return ca < cb
+	}
+	return a.task.ID < b.task.ID
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func newTestTask(id, userID int64, deployType int, org string) *queuedTask {
+	return newQueuedTask(&database.DeployTask{
+		ID:     id,
+		Deploy: &database.Deploy{UserID: userID, Type: deployType},
+	}, org)
+}
+
+func taskIDs(tasks []*queuedTask) []int64 {
+	var ids []int64
+	for _, t := range tasks {
+		ids = append(ids, t.task.ID)
+	}
+	return ids
+}
+
+func TestOrderQueue_Priority(t *testing.T) {
+	waiting := []*queuedTask{
+		newTestTask(1, 1, types.SpaceType, ""),
+		newTestTask(2, 1, types.FinetuneType, ""),
+		newTestTask(3, 1, types.InferenceType, ""),
+		newTestTask(4, 1, types.ServerlessType, ""),
+	}
+	ordered := orderQueue(waiting, map[string]int{}, queueLimits{}, -1)
+	require.Equal(t, []int64{4, 3, 2, 1}, taskIDs(ordered))
+
+	// admin moves the space task to the front
+	waiting[0].priority += 1000
+	ordered = orderQueue(waiting, map[string]int{}, queueLimits{}, -1)
+	require.Equal(t, []int64{1, 4, 3, 2}, taskIDs(ordered))
+}
+
+func TestOrderQueue_FairShare(t *testing.T) {
+	// user 1 queues a burst of builds before user 2
+	waiting := []*queuedTask{
+		newTestTask(1, 1, types.SpaceType, ""),
+		newTestTask(2, 1, types.SpaceType, ""),
+		newTestTask(3, 1, types.SpaceType, ""),
+		newTestTask(4, 2, types.SpaceType, ""),
+		newTestTask(5, 2, types.SpaceType, ""),
+	}
+	ordered := orderQueue(waiting, map[string]int{}, queueLimits{}, -1)
+	require.Equal(t, []int64{1, 4, 2, 5, 3}, taskIDs(ordered))
+
+	// user 1 has tasks running already
+	ordered = orderQueue(waiting, map[string]int{"user:1": 2}, queueLimits{}, 2)
+	require.Equal(t, []int64{4, 5}, taskIDs(ordered))
+}
+
+func TestOrderQueue_Limits(t *testing.T) {
+	waiting := []*queuedTask{
+		newTestTask(1, 1, types.InferenceType, "org1"),
+		newTestTask(2, 1, types.InferenceType, "org1"),
+		newTestTask(3, 2, types.InferenceType, "org1"),
+		newTestTask(4, 3, types.SpaceType, ""),
+	}
+	limits := queueLimits{perUser: 1, perOrg: 2}
+	ordered := orderQueue(waiting, map[string]int{}, limits, -1)
+	require.Equal(t, []int64{1, 3, 4}, taskIDs(ordered))
+
+	ordered = orderQueue(waiting, map[string]int{"org:org1": 2}, limits, -1)
+	require.Equal(t, []int64{4}, taskIDs(ordered))
+
+	ordered = orderQueue(waiting, map[string]int{}, limits, 1)
+	require.Equal(t, []int64{1}, taskIDs(ordered))
+}
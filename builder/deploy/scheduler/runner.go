@@ -4,6 +4,10 @@ import (
 	"context"
 	"log/slog"
 	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/webhook"
+	"opencsg.com/csghub-server/common/types"
 )
 
 type Runner interface {
@@ -47,3 +51,24 @@ const (
 	deployRunning      = 4
 	deployRunTimeError = 5
 )
+
+// notifyDeployStatus queues a deploy status event for the webhooks of the deployed repository
+func notifyDeployStatus(notifier webhook.Notifier, repoStore database.RepoStore, deploy *database.Deploy, message string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	repo, err := repoStore.FindById(ctx, deploy.RepoID)
+	if err != nil {
+		slog.Error("failed to find repo of deploy for webhooks", slog.Int64("deploy_id", deploy.ID), slog.Any("error", err))
+		return
+	}
+	err = notifier.Notify(ctx, repo, types.WebhookEventDeployStatus, types.WebhookDeployStatusData{
+		DeployID:   deploy.ID,
+		DeployName: deploy.DeployName,
+		SvcName:    deploy.SvcName,
+		Status:     deploy.Status,
+		Message:    message,
+	})
+	if err != nil {
+		slog.Error("failed to notify webhooks of deploy status", slog.Int64("deploy_id", deploy.ID), slog.Any("error", err))
+	}
+}
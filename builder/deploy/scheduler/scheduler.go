@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
 	"sync"
 	"time"
@@ -20,125 +21,246 @@ import (
 type Scheduler interface {
 	Run() error
 	Queue(deployTaskID int64) error
+	// ListQueue returns the running tasks and the waiting tasks in the order they will run
+	ListQueue(ctx context.Context) ([]types.DeployQueueTask, error)
+	// SetPriority moves the task up or down in the queue
+	SetPriority(ctx context.Context, deployTaskID int64, priority int) error
 }
 
-// a Scheduler will run tasks in their arrival order
-type FIFOScheduler struct {
+// PriorityScheduler runs tasks of the deploy types with higher priority first, tasks with the same priority
+// are shared fairly between users, and the running tasks of a user or an organization are limited
+type PriorityScheduler struct {
 	timeout time.Duration
-	// parallel running tasks
-	tasks chan Runner
-	last  *database.DeployTask
+	limits  queueLimits
+	// trigger wakes up the scheduler to check new tasks
+	trigger chan struct{}
+
+	lock    *sync.Mutex
+	running map[int64]*queuedTask
+	// finished keeps tasks which are ended by the scheduler but not updated in database,
+	// to avoid running them again
+	finished map[int64]struct{}
 
 	store               database.DeployTaskStore
 	spaceStore          database.SpaceStore
 	modelStore          database.ModelStore
+	repoStore           database.RepoStore
+	namespaceStore      database.NamespaceStore
 	spaceResourcesStore database.SpaceResourceStore
 	ib                  imagebuilder.Builder
 	ir                  imagerunner.Runner
 
-	nextLock  *sync.Mutex
 	deployCfg common.DeployConfig
 	config    *config.Config
 }
 
-func NewFIFOScheduler(ib imagebuilder.Builder, ir imagerunner.Runner, confg common.DeployConfig) Scheduler {
-	s := &FIFOScheduler{}
-	// TODO:allow config
-	s.timeout = 30 * time.Minute
+func NewPriorityScheduler(ib imagebuilder.Builder, ir imagerunner.Runner, confg common.DeployConfig) Scheduler {
+	s := &PriorityScheduler{}
+	s.timeout = time.Duration(confg.TaskTimeoutInMin) * time.Minute
+	if s.timeout <= 0 {
+		s.timeout = 60 * time.Minute
+	}
+	s.limits = queueLimits{
+		total:   confg.MaxRunningTasks,
+		perUser: confg.MaxRunningTasksPerUser,
+		perOrg:  confg.MaxRunningTasksPerOrg,
+	}
+	s.trigger = make(chan struct{}, 1)
+	s.lock = &sync.Mutex{}
+	s.running = make(map[int64]*queuedTask)
+	s.finished = make(map[int64]struct{})
 	s.store = database.NewDeployTaskStore()
 	s.spaceStore = database.NewSpaceStore()
 	s.modelStore = database.NewModelStore()
+	s.repoStore = database.NewRepoStore()
+	s.namespaceStore = database.NewNamespaceStore()
 	s.spaceResourcesStore = database.NewSpaceResourceStore()
-	// allow concurrent deployment tasks
-	s.tasks = make(chan Runner, 100)
-	// s.ib = imagebuilder.NewLocalBuilder()
-	// s.ir = imagerunner.NewLocalRunner()
 	s.ib = ib
 	s.ir = ir
-	s.nextLock = &sync.Mutex{}
 	s.deployCfg = confg
 	//TODO: avoid load config, use config from params
 	s.config, _ = config.LoadConfig()
 	return s
 }
 
-// Run will load tasks and run them currently
-func (rs *FIFOScheduler) Run() error {
-	slog.Info("FIFOScheduler run started")
-
-	go func() {
-		for count := 0; count <= cap(rs.tasks); count++ {
-			_, err := rs.next()
-			if err != nil {
-				slog.Error("failed to get next task", "error", err)
-				continue
-			}
+// Run keeps starting the waiting tasks as long as the limits allow
+func (rs *PriorityScheduler) Run() error {
+	slog.Info("PriorityScheduler run started")
+	for {
+		rs.schedule()
+		select {
+		case <-rs.trigger:
+		case <-time.After(5 * time.Second):
 		}
-	}()
-
-	slog.Debug("scheduler try to loop through tasks channel")
-	for t := range rs.tasks {
-		go func(t Runner) {
-			slog.Debug("dequeue a task to run", slog.Any("task", t.WatchID()))
-			ctx, cancel := context.WithTimeout(context.Background(), rs.timeout)
-			defer cancel()
-
-			if err := t.Run(ctx); err != nil {
-				slog.Error("failed to run task", slog.Any("error", err), slog.Any("task", t.WatchID()))
-				rs.failDeployFollowingTasks(t.WatchID(), err.Error())
-			}
-
-			rs.next()
-		}(t)
 	}
+}
 
+func (rs *PriorityScheduler) Queue(deployTaskID int64) error {
+	rs.wakeup()
 	return nil
 }
 
-func (rs *FIFOScheduler) Queue(deployTaskID int64) error {
-	// simply trigger next task
-	rs.next()
+func (rs *PriorityScheduler) ListQueue(ctx context.Context) ([]types.DeployQueueTask, error) {
+	waiting, running, err := rs.loadQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]types.DeployQueueTask, 0, len(waiting)+len(running))
+	for _, t := range running {
+		result = append(result, t.toQueueTask(0))
+	}
+	// show the order when there are enough slots, limits only delay the tasks of busy users
+	ordered := orderQueue(waiting, runningCounts(running), queueLimits{}, -1)
+	for i, t := range ordered {
+		result = append(result, t.toQueueTask(i+1))
+	}
+	return result, nil
+}
 
+func (rs *PriorityScheduler) SetPriority(ctx context.Context, deployTaskID int64, priority int) error {
+	err := rs.store.UpdateTaskPriority(ctx, deployTaskID, priority)
+	if err != nil {
+		return fmt.Errorf("failed to update priority of deploy task %d, error: %w", deployTaskID, err)
+	}
+	rs.wakeup()
 	return nil
 }
 
-// run next task
-func (rs *FIFOScheduler) next() (Runner, error) {
-	rs.nextLock.Lock()
-	slog.Debug("FIFOScheduler try to get next task", slog.Any("last", rs.last))
-	defer rs.nextLock.Unlock()
+func (rs *PriorityScheduler) wakeup() {
+	select {
+	case rs.trigger <- struct{}{}:
+	default:
+	}
+}
 
-	var (
-		deployTask *database.DeployTask
-		t          Runner
-		err        error
-	)
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// schedule starts the waiting tasks picked by priority and fair share
+func (rs *PriorityScheduler) schedule() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	if rs.last == nil {
-		deployTask, err = rs.store.GetNewTaskFirst(ctx)
-		slog.Debug("GetNewTaskFirst", slog.Any("deploy_task", deployTask), slog.Any("error", err))
-	} else {
-		deployTask, err = rs.store.GetNewTaskAfter(ctx, rs.last.ID)
-		slog.Debug("GetNewTaskAfter", slog.Any("deploy_task", deployTask), slog.Any("last", rs.last.ID), slog.Any("error", err))
+	waiting, running, err := rs.loadQueue(ctx)
+	if err != nil {
+		slog.Error("PriorityScheduler cannot load waiting tasks", slog.Any("error", err))
+		return
 	}
+	slots := -1
+	if rs.limits.total > 0 {
+		slots = rs.limits.total - len(running)
+		if slots <= 0 {
+			return
+		}
+	}
+	for _, t := range orderQueue(waiting, runningCounts(running), rs.limits, slots) {
+		runner, err := rs.newRunner(ctx, t.task)
+		if err != nil {
+			slog.Error("PriorityScheduler cannot create runner of task", slog.Int64("deploy_task_id", t.task.ID), slog.Any("error", err))
+			continue
+		}
+		rs.lock.Lock()
+		rs.running[t.task.ID] = t
+		rs.lock.Unlock()
+		slog.Info("start to run deploy task", slog.Int64("deploy_task_id", t.task.ID), slog.Int("priority", t.priority),
+			slog.Int64("user_id", t.task.Deploy.UserID), slog.String("org", t.org))
+		go rs.run(t, runner)
+	}
+}
+
+func (rs *PriorityScheduler) run(t *queuedTask, runner Runner) {
+	ctx, cancel := context.WithTimeout(context.Background(), rs.timeout)
+	defer cancel()
+
+	if err := runner.Run(ctx); err != nil {
+		slog.Error("failed to run task", slog.Any("error", err), slog.Any("task", runner.WatchID()))
+		rs.failDeployFollowingTasks(runner.WatchID(), err.Error())
+	} else if task, err := rs.store.GetDeployTask(context.Background(), runner.WatchID()); err == nil &&
+		task.TaskType == 0 && task.Status == buildFailed {
+		// a failed build does not return error, the run task of the deploy can not start without image
+		rs.failDeployFollowingTasks(task.ID, task.Message)
+	}
+
+	rs.lock.Lock()
+	delete(rs.running, t.task.ID)
+	rs.finished[t.task.ID] = struct{}{}
+	rs.lock.Unlock()
+	rs.wakeup()
+}
+
+// loadQueue returns the tasks waiting to run and the tasks running now, only the first unfinished task
+// of a deploy waits to run, as the run task needs the image of build task
+func (rs *PriorityScheduler) loadQueue(ctx context.Context) ([]*queuedTask, []*queuedTask, error) {
+	tasks, err := rs.store.ListUnfinishedTasks(ctx)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			slog.Debug("no more tasks to run, schedule a sleeping task")
-			// using a sleep task to pause the scheduler
-		} else {
-			slog.Error("FIFOScheduler cannot get next task by db error", slog.Any("error", err))
+		return nil, nil, fmt.Errorf("failed to list unfinished deploy tasks, error: %w", err)
+	}
+
+	rs.lock.Lock()
+	running := make([]*queuedTask, 0, len(rs.running))
+	busyDeploys := make(map[int64]struct{})
+	for _, t := range rs.running {
+		running = append(running, t)
+		busyDeploys[t.task.DeployID] = struct{}{}
+	}
+	unfinished := make(map[int64]struct{}, len(tasks))
+	for _, t := range tasks {
+		unfinished[t.ID] = struct{}{}
+	}
+	for id := range rs.finished {
+		// the status of task is updated in database, no need to keep it
+		if _, ok := unfinished[id]; !ok {
+			delete(rs.finished, id)
+		}
+	}
+	var candidates []*database.DeployTask
+	for _, t := range tasks {
+		if _, ok := rs.running[t.ID]; ok {
+			continue
+		}
+		if _, ok := rs.finished[t.ID]; ok {
+			continue
 		}
+		if _, ok := busyDeploys[t.DeployID]; ok {
+			continue
+		}
+		busyDeploys[t.DeployID] = struct{}{}
+		candidates = append(candidates, t)
+	}
+	rs.lock.Unlock()
 
-		t = &sleepTask{
-			du: 5 * time.Second,
+	waiting := make([]*queuedTask, 0, len(candidates))
+	orgs := make(map[int64]string)
+	for _, t := range candidates {
+		if t.Deploy == nil {
+			continue
 		}
-		rs.tasks <- t
-		return t, nil
+		org, ok := orgs[t.Deploy.RepoID]
+		if !ok {
+			org = rs.orgOfRepo(ctx, t.Deploy.RepoID)
+			orgs[t.Deploy.RepoID] = org
+		}
+		waiting = append(waiting, newQueuedTask(t, org))
 	}
+	return waiting, running, nil
+}
 
-	var repo RepoInfo
+// orgOfRepo returns the organization the repository belongs to, or empty string for repositories of users
+func (rs *PriorityScheduler) orgOfRepo(ctx context.Context, repoID int64) string {
+	repo, err := rs.repoStore.FindById(ctx, repoID)
+	if err != nil {
+		return ""
+	}
+	namespace, _ := repo.NamespaceAndName()
+	ns, err := rs.namespaceStore.FindByPath(ctx, namespace)
+	if err != nil || ns.NamespaceType != database.OrgNamespace {
+		return ""
+	}
+	return ns.Path
+}
 
+// newRunner creates the runner of task, the task is cancelled if its repository does not exist anymore
+func (rs *PriorityScheduler) newRunner(ctx context.Context, deployTask *database.DeployTask) (Runner, error) {
+	var (
+		repo RepoInfo
+		err  error
+	)
 	if deployTask.Deploy.SpaceID > 0 {
 		// handle space
 		var s *database.Space
@@ -172,38 +294,31 @@ func (rs *FIFOScheduler) next() (Runner, error) {
 			repo.RepoType = string(types.ModelRepo)
 		}
 	}
-
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			slog.Warn("cancel deploy task as repo not found", slog.Any("deploy_task", deployTask))
 			// mark task as cancelled
 			deployTask.Status = cancelled
 			deployTask.Message = "repo not found"
-			rs.store.UpdateDeployTask(ctx, deployTask)
-		}
-		t = &sleepTask{
-			du: 5 * time.Second,
+			_ = rs.store.UpdateDeployTask(ctx, deployTask)
 		}
-		rs.last = deployTask
-		rs.tasks <- t
-		return t, nil
+		return nil, err
 	}
+
 	// for build task
 	if deployTask.TaskType == 0 {
-		t = NewBuidRunner(rs.ib, &repo, deployTask)
-	} else {
-		t = NewDeployRunner(rs.ir, &repo, deployTask, rs.deployCfg)
+		return NewBuidRunner(rs.ib, &repo, deployTask), nil
 	}
-
-	rs.last = deployTask
-	rs.tasks <- t
-	slog.Info("enqueue next task", slog.Any("task", t.WatchID()))
-	return t, err
+	return NewDeployRunner(rs.ir, &repo, deployTask, rs.deployCfg), nil
 }
 
-func (rs *FIFOScheduler) failDeployFollowingTasks(deploytaskID int64, reason string) {
+func (rs *PriorityScheduler) failDeployFollowingTasks(deploytaskID int64, reason string) {
 	slog.Info("scheduler fail following tasks", slog.Any("deploy_task_id", deploytaskID))
-	t, _ := rs.store.GetDeployTask(context.Background(), deploytaskID)
+	t, err := rs.store.GetDeployTask(context.Background(), deploytaskID)
+	if err != nil {
+		slog.Error("failed to get deploy task to fail its following tasks", slog.Any("error", err), slog.Int64("deploy_task_id", deploytaskID))
+		return
+	}
 
 	dps, err := rs.store.GetDeployTasksOfDeploy(context.Background(), t.DeployID)
 	if err != nil {
@@ -338,3 +338,20 @@ func (c *Client) GetDiffBetweenTwoCommits(ctx context.Context, req gitserver.Get
 	}
 	return callback, nil
 }
+
+func (c *Client) IsAncestor(ctx context.Context, req gitserver.IsAncestorReq) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	repository := c.repository(req.RepoType, req.Namespace, req.Name)
+	repository.GitObjectDirectory = req.ObjectDirectory
+	repository.GitAlternateObjectDirectories = req.AlternateObjectDirectories
+	resp, err := c.commitClient.CommitIsAncestor(ctx, &gitalypb.CommitIsAncestorRequest{
+		Repository: repository,
+		AncestorId: req.Ancestor,
+		ChildId:    req.Descendant,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check if %s is ancestor of %s, error: %w", req.Ancestor, req.Descendant, err)
+	}
+	return resp.Value, nil
+}
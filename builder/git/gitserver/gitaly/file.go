@@ -533,8 +533,12 @@ func (c *Client) GetRepoAllFiles(ctx context.Context, req gitserver.GetRepoAllFi
 func (c *Client) GetRepoAllLfsPointers(ctx context.Context, req gitserver.GetRepoAllFilesReq) ([]*types.LFSPointer, error) {
 	var pointers []*types.LFSPointer
 	repoType := fmt.Sprintf("%ss", string(req.RepoType))
-	ctx, cancel := context.WithTimeout(ctx, time.Second*3)
-	defer cancel()
+	// listing pointers of large repositories takes long, respect the deadline of caller if there is one
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Second*3)
+		defer cancel()
+	}
 
 	allPointersReq := &gitalypb.ListAllLFSPointersRequest{
 		Repository: &gitalypb.Repository{
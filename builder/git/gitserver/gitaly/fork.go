@@ -0,0 +1,78 @@
+package gitaly
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gitlab.com/gitlab-org/gitaly/v16/proto/go/gitalypb"
+	"google.golang.org/grpc/metadata"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+)
+
+func (c *Client) ForkRepo(ctx context.Context, req gitserver.ForkRepoReq) (*gitserver.CreateRepoResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+
+	ctx, err := c.withGitalyServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.repoClient.CreateFork(ctx, &gitalypb.CreateForkRequest{
+		Repository:       c.repository(req.RepoType, req.Namespace, req.Name),
+		SourceRepository: c.repository(req.RepoType, req.SourceNamespace, req.SourceName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork repository %s/%s, error: %w", req.SourceNamespace, req.SourceName, err)
+	}
+
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	return &gitserver.CreateRepoResp{
+		Username:      req.Username,
+		Namespace:     req.Namespace,
+		Name:          req.Name,
+		Nickname:      req.Name,
+		Description:   req.Description,
+		DefaultBranch: req.DefaultBranch,
+		RepoType:      req.RepoType,
+		GitPath:       strings.TrimSuffix(BuildRelativePath(repoType, req.Namespace, req.Name), ".git"),
+		Private:       req.Private,
+	}, nil
+}
+
+func (c *Client) CountDivergingCommits(ctx context.Context, req gitserver.CompareReq) (int, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+
+	repository := c.repository(req.RepoType, req.Namespace, req.Name)
+	baseSHA, headSHA, _, err := c.resolveCompare(ctx, repository, req)
+	if err != nil {
+		return 0, 0, err
+	}
+	resp, err := c.commitClient.CountDivergingCommits(ctx, &gitalypb.CountDivergingCommitsRequest{
+		Repository: repository,
+		From:       []byte(headSHA),
+		To:         []byte(baseSHA),
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count diverging commits, error: %w", err)
+	}
+	// left count is the commits only reachable from `From`
+	return int(resp.LeftCount), int(resp.RightCount), nil
+}
+
+// withGitalyServers tells gitaly how to reach the storage of the other repository in RPCs involving two repositories
+func (c *Client) withGitalyServers(ctx context.Context) (context.Context, error) {
+	servers, err := json.Marshal(map[string]map[string]string{
+		c.config.GitalyServer.Storge: {
+			"address": c.config.GitalyServer.Address,
+			"token":   c.config.GitalyServer.Token,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, "gitaly-servers", base64.StdEncoding.EncodeToString(servers)), nil
+}
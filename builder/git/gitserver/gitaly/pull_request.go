@@ -0,0 +1,244 @@
+package gitaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"gitlab.com/gitlab-org/gitaly/v16/proto/go/gitalypb"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func (c *Client) GetCompareDiff(ctx context.Context, req gitserver.CompareReq) (*types.PullRequestDiff, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+
+	repository := c.repository(req.RepoType, req.Namespace, req.Name)
+	baseSHA, headSHA, mergeBase, err := c.resolveCompare(ctx, repository, req)
+	if err != nil {
+		return nil, err
+	}
+	result := &types.PullRequestDiff{
+		BaseCommitID:      baseSHA,
+		HeadCommitID:      headSHA,
+		MergeBaseCommitID: mergeBase,
+	}
+
+	var additions, deletions int
+	statsStream, err := c.diffClient.DiffStats(ctx, &gitalypb.DiffStatsRequest{
+		Repository:    repository,
+		LeftCommitId:  mergeBase,
+		RightCommitId: headSHA,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		data, err := statsStream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		for _, stat := range data.Stats {
+			result.Files = append(result.Files, string(stat.Path))
+			additions += int(stat.Additions)
+			deletions += int(stat.Deletions)
+		}
+	}
+	result.Stats = &types.CommitStats{
+		Additions: additions,
+		Deletions: deletions,
+		Total:     additions + deletions,
+	}
+
+	diffStream, err := c.diffClient.RawDiff(ctx, &gitalypb.RawDiffRequest{
+		Repository:    repository,
+		LeftCommitId:  mergeBase,
+		RightCommitId: headSHA,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		data, err := diffStream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		result.Diff = append(result.Diff, data.Data...)
+	}
+
+	return result, nil
+}
+
+func (c *Client) MergeBranch(ctx context.Context, req gitserver.MergeBranchReq) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+
+	repository := c.repository(req.RepoType, req.Namespace, req.Name)
+	baseSHA, headSHA, mergeBase, err := c.resolveCompare(ctx, repository, req.CompareReq)
+	if err != nil {
+		return "", err
+	}
+	user := &gitalypb.User{
+		GlId:       "user-1",
+		Name:       []byte(req.Username),
+		GlUsername: req.Username,
+		Email:      []byte(req.Email),
+	}
+
+	switch req.Strategy {
+	case types.MergeStrategyFastForward:
+		if mergeBase != baseSHA {
+			return "", errors.New("fast-forward is not possible, the target branch has diverged")
+		}
+		return c.fastForward(ctx, repository, user, req.BaseRef, headSHA, baseSHA)
+	case types.MergeStrategySquash:
+		squashResp, err := c.operationClient.UserSquash(ctx, &gitalypb.UserSquashRequest{
+			Repository:    repository,
+			User:          user,
+			Author:        user,
+			StartSha:      mergeBase,
+			EndSha:        headSHA,
+			CommitMessage: []byte(req.Message),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to squash commits, error: %w", err)
+		}
+		// squash commit is based on the merge base, so it can only be fast-forwarded if the target branch did not move
+		if mergeBase == baseSHA {
+			return c.fastForward(ctx, repository, user, req.BaseRef, squashResp.SquashSha, baseSHA)
+		}
+		return c.mergeCommit(ctx, repository, user, req.BaseRef, squashResp.SquashSha, baseSHA, req.Message)
+	default:
+		return c.mergeCommit(ctx, repository, user, req.BaseRef, headSHA, baseSHA, req.Message)
+	}
+}
+
+func (c *Client) repository(repoType types.RepositoryType, namespace, name string) *gitalypb.Repository {
+	return &gitalypb.Repository{
+		StorageName:  c.config.GitalyServer.Storge,
+		RelativePath: BuildRelativePath(fmt.Sprintf("%ss", string(repoType)), namespace, name),
+	}
+}
+
+// resolveCompare fetches the head ref into base repository if needed,
+// and returns commit ids of base ref, head ref and their merge base
+func (c *Client) resolveCompare(ctx context.Context, repository *gitalypb.Repository, req gitserver.CompareReq) (string, string, string, error) {
+	headRev := req.HeadRef
+	if req.CrossRepo() {
+		if req.FetchRef == "" {
+			return "", "", "", errors.New("fetch ref is required to compare with another repository")
+		}
+		fetchCtx, err := c.withGitalyServers(ctx)
+		if err != nil {
+			return "", "", "", err
+		}
+		fetchResp, err := c.repoClient.FetchSourceBranch(fetchCtx, &gitalypb.FetchSourceBranchRequest{
+			Repository:       repository,
+			SourceRepository: c.repository(req.RepoType, req.HeadNamespace, req.HeadName),
+			SourceBranch:     []byte(req.HeadRef),
+			TargetRef:        []byte(req.FetchRef),
+		})
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to fetch source branch '%s' from %s/%s, error: %w", req.HeadRef, req.HeadNamespace, req.HeadName, err)
+		}
+		if !fetchResp.Result {
+			return "", "", "", fmt.Errorf("source branch '%s' not found in %s/%s", req.HeadRef, req.HeadNamespace, req.HeadName)
+		}
+		headRev = req.FetchRef
+	}
+
+	baseSHA, err := c.commitID(ctx, repository, req.BaseRef)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to find target branch '%s', error: %w", req.BaseRef, err)
+	}
+	headSHA, err := c.commitID(ctx, repository, headRev)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to find source branch '%s', error: %w", req.HeadRef, err)
+	}
+	mergeBaseResp, err := c.repoClient.FindMergeBase(ctx, &gitalypb.FindMergeBaseRequest{
+		Repository: repository,
+		Revisions:  [][]byte{[]byte(baseSHA), []byte(headSHA)},
+	})
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to find merge base, error: %w", err)
+	}
+	if mergeBaseResp.Base == "" {
+		return "", "", "", errors.New("source and target branch have no common history")
+	}
+	return baseSHA, headSHA, mergeBaseResp.Base, nil
+}
+
+func (c *Client) commitID(ctx context.Context, repository *gitalypb.Repository, revision string) (string, error) {
+	resp, err := c.commitClient.FindCommit(ctx, &gitalypb.FindCommitRequest{
+		Repository: repository,
+		Revision:   []byte(revision),
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Commit == nil {
+		return "", errors.New("commit not found")
+	}
+	return resp.Commit.Id, nil
+}
+
+func (c *Client) fastForward(ctx context.Context, repository *gitalypb.Repository, user *gitalypb.User, branch, commitID, expectedOldOid string) (string, error) {
+	resp, err := c.operationClient.UserFFBranch(ctx, &gitalypb.UserFFBranchRequest{
+		Repository:     repository,
+		User:           user,
+		CommitId:       commitID,
+		Branch:         []byte(branch),
+		ExpectedOldOid: expectedOldOid,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to fast-forward branch '%s', error: %w", branch, err)
+	}
+	if resp.PreReceiveError != "" {
+		return "", fmt.Errorf("failed to fast-forward branch '%s', error: %s", branch, resp.PreReceiveError)
+	}
+	return commitID, nil
+}
+
+func (c *Client) mergeCommit(ctx context.Context, repository *gitalypb.Repository, user *gitalypb.User, branch, commitID, expectedOldOid, message string) (string, error) {
+	stream, err := c.operationClient.UserMergeBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+	err = stream.Send(&gitalypb.UserMergeBranchRequest{
+		Repository:     repository,
+		User:           user,
+		CommitId:       commitID,
+		Branch:         []byte(branch),
+		Message:        []byte(message),
+		ExpectedOldOid: expectedOldOid,
+	})
+	if err != nil {
+		return "", err
+	}
+	// the first response carries the merge commit, the branch is updated only after it is confirmed
+	resp, err := stream.Recv()
+	if err != nil {
+		return "", fmt.Errorf("failed to create merge commit, error: %w", err)
+	}
+	mergeCommitID := resp.CommitId
+	err = stream.Send(&gitalypb.UserMergeBranchRequest{Apply: true})
+	if err != nil {
+		return "", err
+	}
+	_, err = stream.Recv()
+	if err != nil {
+		return "", fmt.Errorf("failed to update branch '%s' to merge commit, error: %w", branch, err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return "", err
+	}
+	return mergeCommitID, nil
+}
@@ -2,11 +2,125 @@ package gitaly
 
 import (
 	"context"
+	"fmt"
+	"io"
 
+	"gitlab.com/gitlab-org/gitaly/v16/proto/go/gitalypb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"opencsg.com/csghub-server/builder/git/gitserver"
 	"opencsg.com/csghub-server/common/types"
 )
 
 func (c *Client) GetRepoTags(ctx context.Context, req gitserver.GetRepoTagsReq) (tags []*types.Tag, err error) {
-	return
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	stream, err := c.refClient.FindAllTags(ctx, &gitalypb.FindAllTagsRequest{
+		Repository: c.repository(req.RepoType, req.Namespace, req.Name),
+		SortBy: &gitalypb.FindAllTagsRequest_SortBy{
+			Key:       gitalypb.FindAllTagsRequest_SortBy_CREATORDATE,
+			Direction: gitalypb.SortDirection_DESCENDING,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		for _, tag := range resp.Tags {
+			tags = append(tags, toTag(tag))
+		}
+	}
+
+	if req.Per > 0 && req.Page > 0 {
+		start := (req.Page - 1) * req.Per
+		if start >= len(tags) {
+			return []*types.Tag{}, nil
+		}
+		end := start + req.Per
+		if end > len(tags) {
+			end = len(tags)
+		}
+		tags = tags[start:end]
+	}
+	return tags, nil
+}
+
+func (c *Client) CreateTag(ctx context.Context, req gitserver.CreateTagReq) (*types.Tag, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	resp, err := c.operationClient.UserCreateTag(ctx, &gitalypb.UserCreateTagRequest{
+		Repository: c.repository(req.RepoType, req.Namespace, req.Name),
+		TagName:    []byte(req.Tag),
+		User: &gitalypb.User{
+			GlId:       "user-1",
+			Name:       []byte(req.Username),
+			GlUsername: req.Username,
+			Email:      []byte(req.Email),
+		},
+		TargetRevision: []byte(req.Target),
+		Message:        []byte(req.Message),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag '%s', error: %w", req.Tag, err)
+	}
+	return toTag(resp.Tag), nil
+}
+
+func (c *Client) DeleteTag(ctx context.Context, req gitserver.DeleteTagReq) error {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	resp, err := c.operationClient.UserDeleteTag(ctx, &gitalypb.UserDeleteTagRequest{
+		Repository: c.repository(req.RepoType, req.Namespace, req.Name),
+		TagName:    []byte(req.Tag),
+		User: &gitalypb.User{
+			GlId:       "user-1",
+			Name:       []byte(req.Username),
+			GlUsername: req.Username,
+			Email:      []byte(req.Email),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete tag '%s', error: %w", req.Tag, err)
+	}
+	if resp.PreReceiveError != "" {
+		return fmt.Errorf("failed to delete tag '%s', error: %s", req.Tag, resp.PreReceiveError)
+	}
+	return nil
+}
+
+func (c *Client) GetTag(ctx context.Context, req gitserver.GetTagReq) (*types.Tag, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+	resp, err := c.refClient.FindTag(ctx, &gitalypb.FindTagRequest{
+		Repository: c.repository(req.RepoType, req.Namespace, req.Name),
+		TagName:    []byte(req.Tag),
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tag '%s', error: %w", req.Tag, err)
+	}
+	return toTag(resp.Tag), nil
+}
+
+func toTag(tag *gitalypb.Tag) *types.Tag {
+	if tag == nil {
+		return nil
+	}
+	t := &types.Tag{
+		Name:    string(tag.Name),
+		Message: string(tag.Message),
+	}
+	if tag.TargetCommit != nil {
+		t.Commit.ID = tag.TargetCommit.Id
+	}
+	return t
 }
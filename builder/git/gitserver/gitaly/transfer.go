@@ -0,0 +1,94 @@
+package gitaly
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gitlab.com/gitlab-org/gitaly/v16/proto/go/gitalypb"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+)
+
+// TransferRepo copies the repository to the new path, gitaly has no rpc to rename a repository in place.
+// The old repository is kept until the transfer is completed, so that aborting it only removes the copy.
+func (c *Client) TransferRepo(ctx context.Context, req gitserver.TransferRepoReq) (*gitserver.CreateRepoResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+
+	source := c.repository(req.RepoType, req.Namespace, req.Name)
+	target := c.repository(req.RepoType, req.NewNamespace, req.Name)
+	forkCtx, err := c.withGitalyServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := c.refsChecksum(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.repoClient.CreateFork(forkCtx, &gitalypb.CreateForkRequest{
+		Repository:       target,
+		SourceRepository: source,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy repository %s/%s to namespace %s, error: %w", req.Namespace, req.Name, req.NewNamespace, err)
+	}
+	// the source stays writable while it's copied, the pushes to it would be lost when it's removed
+	checksumAfter, err := c.refsChecksum(ctx, source)
+	if err == nil && checksumAfter != checksum {
+		err = fmt.Errorf("repository %s/%s was pushed to during the transfer, please try again", req.Namespace, req.Name)
+	}
+	if err != nil {
+		if abortErr := c.AbortTransferRepo(ctx, req); abortErr != nil {
+			return nil, fmt.Errorf("%w, and %w", err, abortErr)
+		}
+		return nil, err
+	}
+
+	repoType := fmt.Sprintf("%ss", string(req.RepoType))
+	return &gitserver.CreateRepoResp{
+		Username:      req.Username,
+		Namespace:     req.NewNamespace,
+		Name:          req.Name,
+		Nickname:      req.Nickname,
+		Description:   req.Description,
+		DefaultBranch: req.DefaultBranch,
+		RepoType:      req.RepoType,
+		GitPath:       strings.TrimSuffix(BuildRelativePath(repoType, req.NewNamespace, req.Name), ".git"),
+		Private:       req.Private,
+	}, nil
+}
+
+// refsChecksum returns the checksum of all refs of the repository, it changes with any ref update
+func (c *Client) refsChecksum(ctx context.Context, repo *gitalypb.Repository) (string, error) {
+	resp, err := c.repoClient.CalculateChecksum(ctx, &gitalypb.CalculateChecksumRequest{Repository: repo})
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate checksum of repository %s, error: %w", repo.RelativePath, err)
+	}
+	return resp.Checksum, nil
+}
+
+// CompleteTransferRepo removes the old repository copied by TransferRepo
+func (c *Client) CompleteTransferRepo(ctx context.Context, req gitserver.TransferRepoReq) error {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+
+	source := c.repository(req.RepoType, req.Namespace, req.Name)
+	_, err := c.repoClient.RemoveRepository(ctx, &gitalypb.RemoveRepositoryRequest{Repository: source})
+	if err != nil {
+		return fmt.Errorf("failed to remove repository %s/%s, error: %w", req.Namespace, req.Name, err)
+	}
+	return nil
+}
+
+// AbortTransferRepo removes the copy of repository created by TransferRepo, the old repository is untouched
+func (c *Client) AbortTransferRepo(ctx context.Context, req gitserver.TransferRepoReq) error {
+	ctx, cancel := context.WithTimeout(ctx, timeoutTime)
+	defer cancel()
+
+	target := c.repository(req.RepoType, req.NewNamespace, req.Name)
+	_, err := c.repoClient.RemoveRepository(ctx, &gitalypb.RemoveRepositoryRequest{Repository: target})
+	if err != nil {
+		return fmt.Errorf("failed to remove copy of repository %s/%s in namespace %s, error: %w", req.Namespace, req.Name, req.NewNamespace, err)
+	}
+	return nil
+}
@@ -23,6 +23,9 @@ var _ gitserver.GitServer = (*Client)(nil)
 type Client struct {
 	giteaClient *gitea.Client
 	config      *config.Config
+	// token and httpClient are used by the api calls not covered by gitea sdk
+	token      string
+	httpClient *http.Client
 }
 
 type Response struct {
@@ -55,7 +58,7 @@ func NewClient(config *config.Config) (client *Client, err error) {
 		return nil, err
 	}
 
-	return &Client{giteaClient: giteaClient, config: config}, nil
+	return &Client{giteaClient: giteaClient, config: config, token: token.Token, httpClient: httpClient}, nil
 }
 
 func findOrCreateAccessToken(ctx context.Context, config *config.Config) (*database.GitServerAccessToken, error) {
@@ -168,3 +168,13 @@ func (c *Client) GetSingleCommit(ctx context.Context, req gitserver.GetRepoLastC
 func (c *Client) GetDiffBetweenTwoCommits(ctx context.Context, req gitserver.GetDiffBetweenTwoCommitsReq) (*types.GiteaCallbackPushReq, error) {
 	return nil, nil
 }
+
+func (c *Client) IsAncestor(ctx context.Context, req gitserver.IsAncestorReq) (bool, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	// the ancestor has no commits which are not in the descendant
+	count, err := c.countCompareCommits(ctx, namespace, req.Name, req.Descendant, namespace, req.Ancestor)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if %s is ancestor of %s, error: %w", req.Ancestor, req.Descendant, err)
+	}
+	return count == 0, nil
+}
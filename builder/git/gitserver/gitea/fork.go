@@ -0,0 +1,120 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/OpenCSGs/gitea-go-sdk/gitea"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+func (c *Client) ForkRepo(ctx context.Context, req gitserver.ForkRepoReq) (*gitserver.CreateRepoResp, error) {
+	// every namespace is an organization in gitea
+	organization := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	giteaRepo, _, err := c.giteaClient.CreateFork(
+		common.WithPrefix(req.SourceNamespace, repoPrefixByType(req.RepoType)),
+		req.SourceName,
+		gitea.CreateForkOption{
+			Organization: &organization,
+			Name:         &req.Name,
+		},
+	)
+	if err != nil {
+		slog.Error("fail to call gitea to fork repository", slog.Any("req", req), slog.String("error", err.Error()))
+		return nil, err
+	}
+	if giteaRepo.Private != req.Private {
+		giteaRepo, _, err = c.giteaClient.EditRepo(organization, req.Name, gitea.EditRepoOption{
+			Private: &req.Private,
+		})
+		if err != nil {
+			slog.Error("fail to call gitea to update forked repository", slog.Any("req", req), slog.String("error", err.Error()))
+			return nil, err
+		}
+	}
+
+	resp := &gitserver.CreateRepoResp{
+		Username:      req.Username,
+		Namespace:     req.Namespace,
+		Name:          req.Name,
+		Nickname:      req.Name,
+		Description:   req.Description,
+		DefaultBranch: giteaRepo.DefaultBranch,
+		RepoType:      req.RepoType,
+		GitPath:       giteaRepo.FullName,
+		SshCloneURL:   giteaRepo.SSHURL,
+		HttpCloneURL:  common.PortalCloneUrl(giteaRepo.CloneURL, req.RepoType, c.config.GitServer.URL, c.config.Frontend.URL),
+		Private:       req.Private,
+	}
+	return resp, nil
+}
+
+func (c *Client) CountDivergingCommits(ctx context.Context, req gitserver.CompareReq) (int, int, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	headNamespace := namespace
+	headName := req.Name
+	if req.CrossRepo() {
+		headNamespace = common.WithPrefix(req.HeadNamespace, repoPrefixByType(req.RepoType))
+		headName = req.HeadName
+	}
+	// commits of the head not in the base
+	ahead, err := c.countCompareCommits(ctx, namespace, req.Name, req.BaseRef, headNamespace, req.HeadRef)
+	if err != nil {
+		return 0, 0, err
+	}
+	// commits of the base not in the head
+	behind, err := c.countCompareCommits(ctx, headNamespace, headName, req.HeadRef, namespace, req.BaseRef)
+	if err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
+type compareResponse struct {
+	TotalCommits int `json:"total_commits"`
+	// commits of the head ref which are not in the base ref, the latest first
+	Commits []*gitea.Commit `json:"commits"`
+}
+
+// countCompareCommits counts the commits of the head ref which are not in the base ref
+func (c *Client) countCompareCommits(ctx context.Context, owner, repo, baseRef, headOwner, headRef string) (int, error) {
+	compare, err := c.compare(ctx, owner, repo, baseRef, headOwner, headRef)
+	if err != nil {
+		return 0, err
+	}
+	return compare.TotalCommits, nil
+}
+
+// compare calls the compare api of gitea for the commits of the head ref which are not in the base ref,
+// gitea sdk does not support the compare api yet
+func (c *Client) compare(ctx context.Context, owner, repo, baseRef, headOwner, headRef string) (*compareResponse, error) {
+	basehead := fmt.Sprintf("%s...%s:%s", baseRef, headOwner, headRef)
+	compareURL := fmt.Sprintf("%s/api/v1/repos/%s/%s/compare/%s", c.config.GitServer.Host,
+		url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(basehead))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, compareURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "token "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call gitea compare api, error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to compare %s in gitea repo %s/%s, status: %d", basehead, owner, repo, resp.StatusCode)
+	}
+	var compare compareResponse
+	err = json.NewDecoder(resp.Body).Decode(&compare)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gitea compare response, error: %w", err)
+	}
+	return &compare, nil
+}
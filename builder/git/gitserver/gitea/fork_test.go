@@ -0,0 +1,66 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestClient_CountDivergingCommits(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token secret", r.Header.Get("Authorization"))
+		paths = append(paths, r.URL.EscapedPath())
+		if len(paths) == 1 {
+			fmt.Fprint(w, `{"total_commits": 3}`)
+			return
+		}
+		fmt.Fprint(w, `{"total_commits": 1}`)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.GitServer.Host = srv.URL
+	c := &Client{config: cfg, token: "secret", httpClient: &http.Client{Timeout: time.Second}}
+
+	ahead, behind, err := c.CountDivergingCommits(context.Background(), gitserver.CompareReq{
+		Namespace:     "org",
+		Name:          "model",
+		RepoType:      types.ModelRepo,
+		BaseRef:       "main",
+		HeadNamespace: "user",
+		HeadName:      "fork",
+		HeadRef:       "main",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, ahead)
+	require.Equal(t, 1, behind)
+	require.Equal(t, []string{
+		"/api/v1/repos/models_org/model/compare/main...models_user:main",
+		"/api/v1/repos/models_user/fork/compare/main...models_org:main",
+	}, paths)
+}
+
+func TestClient_CountDivergingCommits_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.GitServer.Host = srv.URL
+	c := &Client{config: cfg, httpClient: &http.Client{Timeout: 50 * time.Millisecond}}
+
+	_, _, err := c.CountDivergingCommits(context.Background(), gitserver.CompareReq{
+		Namespace: "org", Name: "model", RepoType: types.ModelRepo, BaseRef: "main", HeadRef: "dev",
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,201 @@
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/OpenCSGs/gitea-go-sdk/gitea"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+// the max number of the latest commits of which the diffs are joined as the compare diff
+const maxCompareDiffCommits = 100
+
+// GetCompareDiff compares the branches by the compare api of gitea, which is read only. Gitea api does not
+// serve the diff between refs, so the diff is joined of the diffs of the commits compared
+func (c *Client) GetCompareDiff(ctx context.Context, req gitserver.CompareReq) (*types.PullRequestDiff, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	headNamespace := namespace
+	if req.CrossRepo() {
+		headNamespace = common.WithPrefix(req.HeadNamespace, repoPrefixByType(req.RepoType))
+	}
+	compare, err := c.compare(ctx, namespace, req.Name, req.BaseRef, headNamespace, req.HeadRef)
+	if err != nil {
+		return nil, err
+	}
+	base, _, err := c.giteaClient.GetRepoBranch(namespace, req.Name, req.BaseRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gitea branch '%s', error: %w", req.BaseRef, err)
+	}
+	// the commits are in the head repository for cross repository compares
+	headName := req.Name
+	if req.CrossRepo() {
+		headName = req.HeadName
+	}
+	result, err := compareDiff(compare.Commits, func(sha string) ([]byte, error) {
+		diff, _, err := c.giteaClient.GetCommitDiff(headNamespace, headName, sha)
+		return diff, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if base.Commit != nil {
+		result.BaseCommitID = base.Commit.ID
+		if result.MergeBaseCommitID == "" {
+			// nothing to merge, the base contains the head
+			result.MergeBaseCommitID = base.Commit.ID
+		}
+	}
+	return result, nil
+}
+
+// compareDiff builds the diff of the commits compared, which are the latest first. Merge commits are
+// skipped as their changes are of the other commits compared
+func compareDiff(commits []*gitea.Commit, commitDiff func(sha string) ([]byte, error)) (*types.PullRequestDiff, error) {
+	result := &types.PullRequestDiff{
+		Stats: &types.CommitStats{},
+	}
+	if len(commits) == 0 {
+		return result, nil
+	}
+	if commits[0].CommitMeta != nil {
+		result.HeadCommitID = commits[0].SHA
+	}
+	oldest := commits[len(commits)-1]
+	if len(oldest.Parents) > 0 && oldest.Parents[0] != nil {
+		result.MergeBaseCommitID = oldest.Parents[0].SHA
+	}
+
+	seen := make(map[string]bool)
+	var diff bytes.Buffer
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		if commit.CommitMeta == nil || len(commit.Parents) > 1 {
+			continue
+		}
+		for _, file := range commit.Files {
+			if !seen[file.Filename] {
+				seen[file.Filename] = true
+				result.Files = append(result.Files, file.Filename)
+			}
+		}
+		if commit.Stats != nil {
+			result.Stats.Additions += commit.Stats.Additions
+			result.Stats.Deletions += commit.Stats.Deletions
+		}
+		if i >= maxCompareDiffCommits {
+			continue
+		}
+		data, err := commitDiff(commit.SHA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gitea commit '%s' diff, error: %w", commit.SHA, err)
+		}
+		diff.Write(data)
+	}
+	result.Stats.Total = result.Stats.Additions + result.Stats.Deletions
+	result.Diff = diff.Bytes()
+	return result, nil
+}
+
+func (c *Client) MergeBranch(ctx context.Context, req gitserver.MergeBranchReq) (string, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	pr, err := c.findOrCreatePullRequest(req.CompareReq)
+	if err != nil {
+		return "", err
+	}
+	var style gitea.MergeStyle
+	switch req.Strategy {
+	case types.MergeStrategySquash:
+		style = gitea.MergeStyleSquash
+	case types.MergeStrategyFastForward:
+		if pr.Base == nil || pr.MergeBase != pr.Base.Sha {
+			return "", errors.New("fast-forward is not possible, the target branch has diverged")
+		}
+		// rebase onto an unchanged base is a fast-forward
+		style = gitea.MergeStyleRebase
+	default:
+		style = gitea.MergeStyleMerge
+	}
+	merged, _, err := c.giteaClient.MergePullRequest(namespace, req.Name, pr.Index, gitea.MergePullRequestOption{
+		Style:   style,
+		Message: req.Message,
+	})
+	if err != nil || !merged {
+		// close the pull request opened for the merge, it's opened again by the next merge
+		state := gitea.StateClosed
+		_, _, closeErr := c.giteaClient.EditPullRequest(namespace, req.Name, pr.Index, gitea.EditPullRequestOption{State: &state})
+		if closeErr != nil {
+			slog.Error("failed to close gitea pull request of failed merge", slog.Int64("index", pr.Index), slog.Any("error", closeErr))
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to merge gitea pull request, error: %w", err)
+	}
+	if !merged {
+		return "", errors.New("gitea pull request can not be merged")
+	}
+	branch, _, err := c.giteaClient.GetRepoBranch(namespace, req.Name, req.BaseRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to get branch '%s' after merge, error: %w", req.BaseRef, err)
+	}
+	return branch.Commit.ID, nil
+}
+
+// isPullRequestOf reports whether the gitea pull request merges the head of req into its base, the repository
+// of head is always compared, so that a pull request from a fork with the same branch names never matches
+// the merge of branches in the same repository
+func isPullRequestOf(pr *gitea.PullRequest, req gitserver.CompareReq) bool {
+	if pr.Base == nil || pr.Head == nil || pr.Base.Ref != req.BaseRef || pr.Head.Ref != req.HeadRef {
+		return false
+	}
+	headNamespace, headName := req.Namespace, req.Name
+	if req.CrossRepo() {
+		headNamespace, headName = req.HeadNamespace, req.HeadName
+	}
+	repo := pr.Head.Repository
+	if repo == nil || repo.Owner == nil {
+		return false
+	}
+	return repo.Owner.UserName == common.WithPrefix(headNamespace, repoPrefixByType(req.RepoType)) && repo.Name == headName
+}
+
+// gitea merges branches by pull requests, so a gitea pull request is opened on demand for the head and
+// base to merge, which is closed by the merge
+func (c *Client) findOrCreatePullRequest(req gitserver.CompareReq) (*gitea.PullRequest, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	head := req.HeadRef
+	if req.CrossRepo() {
+		head = fmt.Sprintf("%s:%s", common.WithPrefix(req.HeadNamespace, repoPrefixByType(req.RepoType)), req.HeadRef)
+	}
+	for page := 1; ; page++ {
+		prs, _, err := c.giteaClient.ListRepoPullRequests(namespace, req.Name, gitea.ListPullRequestsOptions{
+			ListOptions: gitea.ListOptions{Page: page, PageSize: 50},
+			State:       gitea.StateOpen,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gitea pull requests, error: %w", err)
+		}
+		for _, pr := range prs {
+			if isPullRequestOf(pr, req) {
+				return pr, nil
+			}
+		}
+		if len(prs) < 50 {
+			break
+		}
+	}
+	pr, _, err := c.giteaClient.CreatePullRequest(namespace, req.Name, gitea.CreatePullRequestOption{
+		Head:  head,
+		Base:  req.BaseRef,
+		Title: fmt.Sprintf("Merge %s into %s", head, req.BaseRef),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gitea pull request, error: %w", err)
+	}
+	return pr, nil
+}
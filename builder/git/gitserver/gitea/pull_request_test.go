@@ -0,0 +1,82 @@
+package gitea
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OpenCSGs/gitea-go-sdk/gitea"
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func compareCommit(sha string, parents []string, files ...string) *gitea.Commit {
+	commit := &gitea.Commit{
+		CommitMeta: &gitea.CommitMeta{SHA: sha},
+		Stats:      &gitea.CommitStats{Additions: 2, Deletions: 1},
+	}
+	for _, p := range parents {
+		commit.Parents = append(commit.Parents, &gitea.CommitMeta{SHA: p})
+	}
+	for _, f := range files {
+		commit.Files = append(commit.Files, &gitea.CommitAffectedFiles{Filename: f})
+	}
+	return commit
+}
+
+func TestCompareDiff(t *testing.T) {
+	// the latest first, as returned by the compare api
+	commits := []*gitea.Commit{
+		compareCommit("c3", []string{"c2"}, "README.md"),
+		compareCommit("m1", []string{"c1", "x1"}, "other.py"),
+		compareCommit("c2", []string{"c1"}, "train.py", "README.md"),
+		compareCommit("c1", []string{"base"}, "config.json"),
+	}
+	var requested []string
+	diff, err := compareDiff(commits, func(sha string) ([]byte, error) {
+		requested = append(requested, sha)
+		return []byte("diff of " + sha + "\n"), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "c3", diff.HeadCommitID)
+	require.Equal(t, "base", diff.MergeBaseCommitID)
+	// oldest first, merge commits skipped
+	require.Equal(t, []string{"c1", "c2", "c3"}, requested)
+	require.Equal(t, "diff of c1\ndiff of c2\ndiff of c3\n", string(diff.Diff))
+	require.Equal(t, []string{"config.json", "train.py", "README.md"}, diff.Files)
+	require.Equal(t, 6, diff.Stats.Additions)
+	require.Equal(t, 3, diff.Stats.Deletions)
+	require.Equal(t, 9, diff.Stats.Total)
+
+	diff, err = compareDiff(nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, diff.HeadCommitID)
+	require.Empty(t, diff.Diff)
+
+	_, err = compareDiff(commits, func(sha string) ([]byte, error) {
+		return nil, errors.New("not found")
+	})
+	require.Error(t, err)
+}
+
+func TestIsPullRequestOf(t *testing.T) {
+	pr := func(owner, name, head string) *gitea.PullRequest {
+		return &gitea.PullRequest{
+			Base: &gitea.PRBranchInfo{Ref: "main"},
+			Head: &gitea.PRBranchInfo{Ref: head, Repository: &gitea.Repository{
+				Name:  name,
+				Owner: &gitea.User{UserName: owner},
+			}},
+		}
+	}
+	req := gitserver.CompareReq{Namespace: "user1", Name: "model1", RepoType: types.ModelRepo, BaseRef: "main", HeadRef: "dev"}
+	require.True(t, isPullRequestOf(pr("models_user1", "model1", "dev"), req))
+	// a pull request from fork with the same branch names
+	require.False(t, isPullRequestOf(pr("models_user2", "model1", "dev"), req))
+	require.False(t, isPullRequestOf(pr("models_user1", "model1", "feature"), req))
+	require.False(t, isPullRequestOf(&gitea.PullRequest{Base: &gitea.PRBranchInfo{Ref: "main"}, Head: &gitea.PRBranchInfo{Ref: "dev"}}, req))
+
+	req.HeadNamespace, req.HeadName = "user2", "model1-fork"
+	require.True(t, isPullRequestOf(pr("models_user2", "model1-fork", "dev"), req))
+	require.False(t, isPullRequestOf(pr("models_user1", "model1", "dev"), req))
+}
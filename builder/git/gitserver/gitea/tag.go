@@ -2,6 +2,8 @@ package gitea
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 
 	"github.com/OpenCSGs/gitea-go-sdk/gitea"
 	"opencsg.com/csghub-server/builder/git/gitserver"
@@ -36,3 +38,51 @@ func (c *Client) GetRepoTags(ctx context.Context, req gitserver.GetRepoTagsReq)
 	}
 	return
 }
+
+func (c *Client) CreateTag(ctx context.Context, req gitserver.CreateTagReq) (*types.Tag, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	giteaTag, _, err := c.giteaClient.CreateTag(namespace, req.Name, gitea.CreateTagOption{
+		TagName: req.Tag,
+		Message: req.Message,
+		Target:  req.Target,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag '%s', error: %w", req.Tag, err)
+	}
+	tag := &types.Tag{
+		Name:    giteaTag.Name,
+		Message: giteaTag.Message,
+	}
+	if giteaTag.Commit != nil {
+		tag.Commit.ID = giteaTag.Commit.SHA
+	}
+	return tag, nil
+}
+
+func (c *Client) DeleteTag(ctx context.Context, req gitserver.DeleteTagReq) error {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	_, err := c.giteaClient.DeleteTag(namespace, req.Name, req.Tag)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag '%s', error: %w", req.Tag, err)
+	}
+	return nil
+}
+
+func (c *Client) GetTag(ctx context.Context, req gitserver.GetTagReq) (*types.Tag, error) {
+	namespace := common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType))
+	giteaTag, resp, err := c.giteaClient.GetTag(namespace, req.Name, req.Tag)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag '%s', error: %w", req.Tag, err)
+	}
+	tag := &types.Tag{
+		Name:    giteaTag.Name,
+		Message: giteaTag.Message,
+	}
+	if giteaTag.Commit != nil {
+		tag.Commit.ID = giteaTag.Commit.SHA
+	}
+	return tag, nil
+}
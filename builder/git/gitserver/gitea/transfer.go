@@ -0,0 +1,62 @@
+package gitea
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/OpenCSGs/gitea-go-sdk/gitea"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+// TransferRepo renames the repository in gitea, which takes effect immediately
+func (c *Client) TransferRepo(ctx context.Context, req gitserver.TransferRepoReq) (*gitserver.CreateRepoResp, error) {
+	// every namespace is an organization in gitea, transfers by the admin token take effect immediately
+	giteaRepo, _, err := c.giteaClient.TransferRepo(
+		common.WithPrefix(req.Namespace, repoPrefixByType(req.RepoType)),
+		req.Name,
+		gitea.TransferRepoOption{
+			NewOwner: common.WithPrefix(req.NewNamespace, repoPrefixByType(req.RepoType)),
+		},
+	)
+	if err != nil {
+		slog.Error("fail to call gitea to transfer repository", slog.Any("req", req), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	resp := &gitserver.CreateRepoResp{
+		Username:      req.Username,
+		Namespace:     req.NewNamespace,
+		Name:          req.Name,
+		Nickname:      req.Nickname,
+		Description:   req.Description,
+		DefaultBranch: giteaRepo.DefaultBranch,
+		RepoType:      req.RepoType,
+		GitPath:       giteaRepo.FullName,
+		SshCloneURL:   giteaRepo.SSHURL,
+		HttpCloneURL:  common.PortalCloneUrl(giteaRepo.CloneURL, req.RepoType, c.config.GitServer.URL, c.config.Frontend.URL),
+		Private:       req.Private,
+	}
+	return resp, nil
+}
+
+// CompleteTransferRepo does nothing as the repository is renamed by TransferRepo already
+func (c *Client) CompleteTransferRepo(ctx context.Context, req gitserver.TransferRepoReq) error {
+	return nil
+}
+
+// AbortTransferRepo transfers the repository back to the old namespace
+func (c *Client) AbortTransferRepo(ctx context.Context, req gitserver.TransferRepoReq) error {
+	_, err := c.TransferRepo(ctx, gitserver.TransferRepoReq{
+		Namespace:     req.NewNamespace,
+		Name:          req.Name,
+		NewNamespace:  req.Namespace,
+		Username:      req.Username,
+		Nickname:      req.Nickname,
+		Description:   req.Description,
+		DefaultBranch: req.DefaultBranch,
+		RepoType:      req.RepoType,
+		Private:       req.Private,
+	})
+	return err
+}
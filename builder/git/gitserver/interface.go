@@ -41,6 +41,32 @@ type GitServer interface {
 	GetRepoAllFiles(ctx context.Context, req GetRepoAllFilesReq) ([]*types.File, error)
 	GetRepoAllLfsPointers(ctx context.Context, req GetRepoAllFilesReq) ([]*types.LFSPointer, error)
 	GetDiffBetweenTwoCommits(ctx context.Context, req GetDiffBetweenTwoCommitsReq) (*types.GiteaCallbackPushReq, error)
+	// IsAncestor checks whether the ancestor commit is reachable from the descendant commit, used to detect force pushes
+	IsAncestor(ctx context.Context, req IsAncestorReq) (bool, error)
+
+	// Tag
+	GetRepoTags(ctx context.Context, req GetRepoTagsReq) ([]*types.Tag, error)
+	// CreateTag creates a tag pointing to the target branch or commit, an annotated tag is created if message is not empty
+	CreateTag(ctx context.Context, req CreateTagReq) (*types.Tag, error)
+	DeleteTag(ctx context.Context, req DeleteTagReq) error
+	// GetTag returns the tag of name, nil is returned if the tag does not exist
+	GetTag(ctx context.Context, req GetTagReq) (*types.Tag, error)
+
+	// Pull request
+	// GetCompareDiff returns the changes of the head ref since it diverged from the base ref
+	GetCompareDiff(ctx context.Context, req CompareReq) (*types.PullRequestDiff, error)
+	// MergeBranch merges the head ref into the base branch and returns the new commit id of the base branch
+	MergeBranch(ctx context.Context, req MergeBranchReq) (string, error)
+	// CountDivergingCommits returns the number of commits only in the head ref (ahead) and only in the base ref (behind)
+	CountDivergingCommits(ctx context.Context, req CompareReq) (ahead int, behind int, err error)
+
+	// Fork
+	ForkRepo(ctx context.Context, req ForkRepoReq) (*CreateRepoResp, error)
+	// TransferRepo moves the repository to another namespace, the move is finished by CompleteTransferRepo after
+	// the transfer is saved in database, or undone by AbortTransferRepo if it failed to, with the same request
+	TransferRepo(ctx context.Context, req TransferRepoReq) (*CreateRepoResp, error)
+	CompleteTransferRepo(ctx context.Context, req TransferRepoReq) error
+	AbortTransferRepo(ctx context.Context, req TransferRepoReq) error
 
 	CreateSSHKey(*types.CreateSSHKeyRequest) (*database.SSHKey, error)
 	// ListSSHKeys(string, int, int) ([]*database.SSHKey, error)
@@ -143,6 +143,89 @@ type GetRepoAllFilesReq struct {
 
 type GetRepoTagsReq = GetBranchesReq
 
+type CreateTagReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Tag       string               `json:"tag"`
+	// branch name or commit id the tag points to
+	Target   string `json:"target"`
+	Message  string `json:"message"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type DeleteTagReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Tag       string               `json:"tag"`
+	Username  string               `json:"username"`
+	Email     string               `json:"email"`
+}
+
+type GetTagReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	Tag       string               `json:"tag"`
+}
+
+// CompareReq compares the head ref with the base branch of a repository,
+// the head ref can live in another repository, e.g. a fork
+type CompareReq struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	RepoType  types.RepositoryType `json:"repo_type"`
+	BaseRef   string               `json:"base_ref"`
+	// HeadNamespace and HeadName is the repository of head ref, empty means the same repository
+	HeadNamespace string `json:"head_namespace"`
+	HeadName      string `json:"head_name"`
+	HeadRef       string `json:"head_ref"`
+	// FetchRef is the ref of the base repository where the head ref is fetched to,
+	// required only if the head ref is in another repository
+	FetchRef string `json:"fetch_ref"`
+}
+
+func (r CompareReq) CrossRepo() bool {
+	if r.HeadNamespace == "" && r.HeadName == "" {
+		return false
+	}
+	return r.HeadNamespace != r.Namespace || r.HeadName != r.Name
+}
+
+type MergeBranchReq struct {
+	CompareReq
+	Strategy types.MergeStrategy `json:"strategy"`
+	Message  string              `json:"message"`
+	Username string              `json:"username"`
+	Email    string              `json:"email"`
+}
+
+type ForkRepoReq struct {
+	SourceNamespace string               `json:"source_namespace"`
+	SourceName      string               `json:"source_name"`
+	Namespace       string               `json:"namespace"`
+	Name            string               `json:"name"`
+	Username        string               `json:"username"`
+	Description     string               `json:"description"`
+	DefaultBranch   string               `json:"default_branch"`
+	RepoType        types.RepositoryType `json:"type"`
+	Private         bool                 `json:"private"`
+}
+
+type TransferRepoReq struct {
+	Namespace     string               `json:"namespace"`
+	Name          string               `json:"name"`
+	NewNamespace  string               `json:"new_namespace"`
+	Username      string               `json:"username"`
+	Nickname      string               `json:"nickname"`
+	Description   string               `json:"description"`
+	DefaultBranch string               `json:"default_branch"`
+	RepoType      types.RepositoryType `json:"type"`
+	Private       bool                 `json:"private"`
+}
+
 const (
 	TaskStatusQueued   TaskStatus = iota // 0 task is queued
 	TaskStatusRunning                    // 1 task is running
@@ -205,3 +288,15 @@ type UploadPackReq struct {
 }
 
 type ReceivePackReq = UploadPackReq
+
+type IsAncestorReq struct {
+	Namespace  string               `json:"namespace"`
+	Name       string               `json:"name"`
+	RepoType   types.RepositoryType `json:"repo_type"`
+	Ancestor   string               `json:"ancestor"`
+	Descendant string               `json:"descendant"`
+	// quarantine directories of the objects being pushed, relative to the repository,
+	// set when checking inside the pre-receive hook, only used by gitaly
+	ObjectDirectory            string   `json:"object_directory"`
+	AlternateObjectDirectories []string `json:"alternate_object_directories"`
+}
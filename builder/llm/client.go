@@ -23,14 +23,39 @@ func NewClient() *Client {
 	}
 }
 
-func (c *Client) Chat(ctx context.Context, endpoint string, headers map[string]string, data types.LLMReqBody) (<-chan string, error) {
+// Chat sends a stream chat request and returns the lines of response, onUsage is called with the
+// token usage in response after the stream ends, it's not called if the response has no usage.
+// The usage is requested by stream_options if onUsage is set, the usage chunk is not returned then.
+func (c *Client) Chat(ctx context.Context, endpoint string, headers map[string]string, data types.LLMReqBody, onUsage func(types.LLMUsage)) (<-chan string, error) {
 	slog.Debug("chat with llm", slog.Any("endpoint", endpoint), slog.Any("data", data))
+	hideUsage := false
+	if onUsage != nil && data.StreamOptions == nil {
+		data.StreamOptions = &types.LLMStreamOptions{IncludeUsage: true}
+		hideUsage = true
+	}
 	rc, err := c.doSteamRequest(ctx, http.MethodPost, endpoint, headers, data)
 	if err != nil {
 		return nil, fmt.Errorf("do llm stream request, error: %w", err)
 	}
 
-	return c.readToChannel(rc), nil
+	return c.readToChannel(rc, onUsage, hideUsage), nil
+}
+
+// ChatCompletion sends a non-stream chat request and returns the whole response with token usage
+func (c *Client) ChatCompletion(ctx context.Context, endpoint string, headers map[string]string, data types.LLMReqBody) (*types.LLMResponse, error) {
+	data.Stream = false
+	rc, err := c.doSteamRequest(ctx, http.MethodPost, endpoint, headers, data)
+	if err != nil {
+		return nil, fmt.Errorf("do llm request, error: %w", err)
+	}
+	defer rc.Close()
+
+	var resp types.LLMResponse
+	err = json.NewDecoder(rc).Decode(&resp)
+	if err != nil {
+		return nil, fmt.Errorf("decode llm response, error: %w", err)
+	}
+	return &resp, nil
 }
 
 func (c *Client) doSteamRequest(ctx context.Context, method, url string, headers map[string]string, data interface{}) (io.ReadCloser, error) {
@@ -65,9 +90,10 @@ func (c *Client) doSteamRequest(ctx context.Context, method, url string, headers
 	return resp.Body, nil
 }
 
-func (c *Client) readToChannel(rc io.ReadCloser) <-chan string {
+func (c *Client) readToChannel(rc io.ReadCloser, onUsage func(types.LLMUsage), hideUsage bool) <-chan string {
 	output := make(chan string, 2)
 	br := bufio.NewReader(rc)
+	usage := NewUsageRecorder("text/event-stream")
 
 	go func() {
 		for {
@@ -79,9 +105,16 @@ func (c *Client) readToChannel(rc io.ReadCloser) <-chan string {
 				break
 			}
 			if len(line) > 0 {
+				usage.WriteLine(string(line))
+				if hideUsage && IsUsageChunk(line) {
+					continue
+				}
 				output <- string(line)
 			}
 		}
+		if u, ok := usage.Usage(); ok && onUsage != nil {
+			onUsage(u)
+		}
 	}()
 
 	return output
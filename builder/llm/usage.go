@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+// maxUsageBodySize is the max size of json response kept to parse usage, usage of larger
+// responses is not recorded
+const maxUsageBodySize = 8 << 20
+
+// UsageRecorder parses the openai style token usage from the response body written to it,
+// both json response and streamed response of server-sent events are supported. In streamed
+// responses the usage is in the last chunk, which is sent only if the request sets
+// stream_options.include_usage
+type UsageRecorder struct {
+	stream   bool
+	buf      bytes.Buffer
+	overflow bool
+	usage    types.LLMUsage
+}
+
+// NewUsageRecorder creates a recorder for the response of content type
+func NewUsageRecorder(contentType string) *UsageRecorder {
+	return &UsageRecorder{
+		stream: strings.HasPrefix(contentType, "text/event-stream"),
+	}
+}
+
+func (r *UsageRecorder) Write(p []byte) (int, error) {
+	if r.overflow {
+		return len(p), nil
+	}
+	r.buf.Write(p)
+	if !r.stream {
+		if r.buf.Len() > maxUsageBodySize {
+			r.overflow = true
+			r.buf = bytes.Buffer{}
+		}
+		return len(p), nil
+	}
+	// parse complete lines only, the incomplete last line is kept for next write
+	for {
+		i := bytes.IndexByte(r.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		r.parseEvent(r.buf.Next(i + 1))
+	}
+	if r.buf.Len() > maxUsageBodySize {
+		r.overflow = true
+		r.buf = bytes.Buffer{}
+	}
+	return len(p), nil
+}
+
+// WriteLine feeds a line of streamed response without line ending
+func (r *UsageRecorder) WriteLine(line string) {
+	r.parseEvent([]byte(line))
+}
+
+// Usage returns the token usage of response, false is returned if there is no usage in response
+func (r *UsageRecorder) Usage() (types.LLMUsage, bool) {
+	if r.stream {
+		// the stream may end without line ending
+		if r.buf.Len() > 0 {
+			r.parseEvent(r.buf.Bytes())
+			r.buf.Reset()
+		}
+	} else if !r.overflow && r.buf.Len() > 0 {
+		r.usage = parseUsage(r.buf.Bytes())
+		r.buf.Reset()
+	}
+	return r.usage, r.usage.PromptTokens+r.usage.CompletionTokens > 0
+}
+
+func (r *UsageRecorder) parseEvent(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	if !ok {
+		return
+	}
+	data = bytes.TrimSpace(data)
+	// skip the chunks without usage fast, most chunks are content deltas
+	if !bytes.Contains(data, []byte(`"usage"`)) {
+		return
+	}
+	usage := parseUsage(data)
+	if usage.PromptTokens+usage.CompletionTokens > 0 {
+		r.usage = usage
+	}
+}
+
+func parseUsage(data []byte) types.LLMUsage {
+	var resp struct {
+		Usage *types.LLMUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Usage == nil {
+		return types.LLMUsage{}
+	}
+	if resp.Usage.TotalTokens == 0 {
+		resp.Usage.TotalTokens = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+	}
+	return *resp.Usage
+}
+
+// IncludeStreamUsage sets stream_options.include_usage in the body of a streamed completion request,
+// so that the usage is sent in the last chunk. The body is returned unchanged with false if the request
+// is not streamed, is not json, or asks for the usage itself.
+func IncludeStreamUsage(body []byte) ([]byte, bool) {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body, false
+	}
+	var stream bool
+	if err := json.Unmarshal(req["stream"], &stream); err != nil || !stream {
+		return body, false
+	}
+	options := make(map[string]json.RawMessage)
+	if raw, ok := req["stream_options"]; ok && !bytes.Equal(raw, []byte("null")) {
+		if err := json.Unmarshal(raw, &options); err != nil {
+			return body, false
+		}
+	}
+	var includeUsage bool
+	if err := json.Unmarshal(options["include_usage"], &includeUsage); err == nil && includeUsage {
+		return body, false
+	}
+	options["include_usage"] = json.RawMessage("true")
+	raw, err := json.Marshal(options)
+	if err != nil {
+		return body, false
+	}
+	req["stream_options"] = raw
+	newBody, err := json.Marshal(req)
+	if err != nil {
+		return body, false
+	}
+	return newBody, true
+}
+
+// IsUsageChunk reports whether the line of streamed response is the chunk carrying the usage only,
+// which is sent when stream_options.include_usage is set
+func IsUsageChunk(line []byte) bool {
+	data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	if !ok || !bytes.Contains(data, []byte(`"usage"`)) {
+		return false
+	}
+	var chunk struct {
+		Choices []json.RawMessage `json:"choices"`
+		Usage   *types.LLMUsage   `json:"usage"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &chunk); err != nil {
+		return false
+	}
+	return chunk.Usage != nil && len(chunk.Choices) == 0
+}
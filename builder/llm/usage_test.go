@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestUsageRecorder_JSON(t *testing.T) {
+	r := NewUsageRecorder("application/json")
+	_, _ = r.Write([]byte(`{"choices":[{"message":{"content":"hi"}}],`))
+	_, _ = r.Write([]byte(`"usage":{"prompt_tokens":10,"completion_tokens":2}}`))
+	usage, ok := r.Usage()
+	require.True(t, ok)
+	require.Equal(t, types.LLMUsage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}, usage)
+
+	r = NewUsageRecorder("application/json")
+	_, _ = r.Write([]byte(`{"choices":[]}`))
+	_, ok = r.Usage()
+	require.False(t, ok)
+}
+
+func TestUsageRecorder_Stream(t *testing.T) {
+	r := NewUsageRecorder("text/event-stream; charset=utf-8")
+	_, _ = r.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"h\"}}],\"usage\":null}\n\n"))
+	_, _ = r.Write([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":7,"))
+	_, _ = r.Write([]byte("\"completion_tokens\":3,\"total_tokens\":10}}\n\ndata: [DONE]"))
+	usage, ok := r.Usage()
+	require.True(t, ok)
+	require.Equal(t, types.LLMUsage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}, usage)
+
+	r = NewUsageRecorder("text/event-stream")
+	r.WriteLine(`data: {"choices":[{"delta":{"content":"h"}}]}`)
+	r.WriteLine(`data: [DONE]`)
+	_, ok = r.Usage()
+	require.False(t, ok)
+}
+
+func TestIncludeStreamUsage(t *testing.T) {
+	body, ok := IncludeStreamUsage([]byte(`{"model":"m","stream":true,"messages":[]}`))
+	require.True(t, ok)
+	require.JSONEq(t, `{"model":"m","stream":true,"messages":[],"stream_options":{"include_usage":true}}`, string(body))
+
+	// other stream options are kept
+	body, ok = IncludeStreamUsage([]byte(`{"stream":true,"stream_options":{"include_usage":false,"x":1}}`))
+	require.True(t, ok)
+	require.JSONEq(t, `{"stream":true,"stream_options":{"include_usage":true,"x":1}}`, string(body))
+
+	for _, req := range []string{
+		`{"stream":true,"stream_options":{"include_usage":true}}`,
+		`{"stream":false}`,
+		`{"messages":[]}`,
+		`not json`,
+	} {
+		body, ok = IncludeStreamUsage([]byte(req))
+		require.False(t, ok)
+		require.Equal(t, req, string(body))
+	}
+}
+
+func TestIsUsageChunk(t *testing.T) {
+	require.True(t, IsUsageChunk([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":7}}\n")))
+	require.False(t, IsUsageChunk([]byte(`data: {"choices":[{"delta":{"content":"h"}}],"usage":null}`)))
+	require.False(t, IsUsageChunk([]byte(`data: {"choices":[{"delta":{}}],"usage":{"prompt_tokens":7}}`)))
+	require.False(t, IsUsageChunk([]byte("data: [DONE]")))
+	require.False(t, IsUsageChunk([]byte("\n")))
+}
@@ -3,16 +3,41 @@ package parquet
 import (
 	"database/sql"
 	"fmt"
-	"log"
 	"log/slog"
+	"path"
+	"strings"
 
 	_ "github.com/marcboeker/go-duckdb"
+	"opencsg.com/csghub-server/builder/store/s3"
 	"opencsg.com/csghub-server/common/config"
 )
 
 type Reader interface {
 	RowCount(objName string) (count int, err error)
 	TopN(objName string, count int) (columns []string, rows [][]interface{}, err error)
+	// CountRows returns the total number of rows in a parquet or jsonl file
+	CountRows(objName string, format FileFormat) (count int, err error)
+	// ReadRows returns at most limit rows of a parquet or jsonl file from offset
+	ReadRows(objName string, format FileFormat, offset, limit int) (columns []string, rows [][]interface{}, err error)
+}
+
+type FileFormat string
+
+const (
+	FormatParquet FileFormat = "parquet"
+	FormatJSONL   FileFormat = "jsonl"
+)
+
+// FormatOf returns the format of a file by its extension
+func FormatOf(filePath string) (FileFormat, bool) {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".parquet":
+		return FormatParquet, true
+	case ".jsonl":
+		return FormatJSONL, true
+	default:
+		return "", false
+	}
 }
 
 type duckdbReader struct {
@@ -22,6 +47,15 @@ type duckdbReader struct {
 
 // NewS3Reader create a new reader to read from s3 compatible object storage service
 func NewS3Reader(cfg *config.Config) (Reader, error) {
+	return newS3Reader(s3.Options{
+		Endpoint:        cfg.S3.Endpoint,
+		AccessKeyID:     cfg.S3.AccessKeyID,
+		AccessKeySecret: cfg.S3.AccessKeySecret,
+		Region:          cfg.S3.Region,
+	}, cfg.S3.Bucket)
+}
+
+func newS3Reader(opts s3.Options, bucket string) (*duckdbReader, error) {
 	s3SetupSql := fmt.Sprintf(`
 	INSTALL httpfs;
 	LOAD httpfs;
@@ -30,25 +64,35 @@ func NewS3Reader(cfg *config.Config) (Reader, error) {
 	SET s3_url_style = 'vhost';
 	SET s3_access_key_id = '%s';
 	SET s3_secret_access_key = '%s';
-	`, cfg.S3.Region, cfg.S3.Endpoint, cfg.S3.AccessKeyID, cfg.S3.AccessKeySecret)
+	`, opts.Region, opts.Endpoint, opts.AccessKeyID, opts.AccessKeySecret)
 	db, err := sql.Open("duckdb", "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to duckdb, cause:%w", err)
 	}
-	slog.Debug("setup duckdb", slog.String("sql", s3SetupSql))
+	slog.Debug("setup duckdb", slog.String("endpoint", opts.Endpoint), slog.String("bucket", bucket))
 	_, err = db.Exec(s3SetupSql)
 	if err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to setup s3 for duckdb, cause:%w", err)
 	}
 	slog.Info("setup duckdb succeeded")
 
-	return &duckdbReader{db: db, bucket: cfg.S3.Bucket}, nil
+	return &duckdbReader{db: db, bucket: bucket}, nil
 }
 
 // RowCount returns the total number of rows in a parquet file in S3 bucket.
 func (r *duckdbReader) RowCount(objName string) (int, error) {
 	selectCount := fmt.Sprintf("select count(*) from read_parquet('s3://%s/%s');", r.bucket, objName)
-	fmt.Println(selectCount)
+	return r.count(selectCount)
+}
+
+// CountRows returns the total number of rows in a parquet or jsonl file in S3 bucket.
+func (r *duckdbReader) CountRows(objName string, format FileFormat) (int, error) {
+	return r.count(fmt.Sprintf("select count(*) from %s;", r.source(objName, format)))
+}
+
+func (r *duckdbReader) count(selectCount string) (int, error) {
+	slog.Debug("query row count", slog.String("query", selectCount))
 	row := r.db.QueryRow(selectCount)
 	if row.Err() != nil {
 		return 0, fmt.Errorf("failed to get row count: %w", row.Err())
@@ -62,7 +106,26 @@ func (r *duckdbReader) RowCount(objName string) (int, error) {
 func (r *duckdbReader) TopN(objName string, count int) ([]string, [][]interface{}, error) {
 	topN := fmt.Sprintf("select * from read_parquet('s3://%s/%s') limit %d;", r.bucket, objName, count)
 	slog.Debug("query topN", slog.String("query", topN))
-	rows, err := r.db.Query(topN)
+	return r.query(topN)
+}
+
+// ReadRows returns at most limit rows of a parquet or jsonl file in S3 bucket from offset.
+func (r *duckdbReader) ReadRows(objName string, format FileFormat, offset, limit int) ([]string, [][]interface{}, error) {
+	// rows of a file are scanned in order without sorting, keep the order stable between pages
+	readRows := fmt.Sprintf("select * from %s limit %d offset %d;", r.source(objName, format), limit, offset)
+	slog.Debug("query rows", slog.String("query", readRows))
+	return r.query(readRows)
+}
+
+func (r *duckdbReader) source(objName string, format FileFormat) string {
+	if format == FormatJSONL {
+		return fmt.Sprintf("read_json_auto('s3://%s/%s', format = 'newline_delimited')", r.bucket, objName)
+	}
+	return fmt.Sprintf("read_parquet('s3://%s/%s')", r.bucket, objName)
+}
+
+func (r *duckdbReader) query(query string) ([]string, [][]interface{}, error) {
+	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to execute query,cause:%w", err)
 	}
@@ -84,7 +147,7 @@ func (r *duckdbReader) TopN(objName string, count int) ([]string, [][]interface{
 
 		// Scan values into the slice
 		if err := rows.Scan(pointers...); err != nil {
-			log.Fatal(err)
+			return nil, nil, fmt.Errorf("failed to scan row,cause:%w", err)
 		}
 		values = append(values, fields)
 	}
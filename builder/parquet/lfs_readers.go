@@ -0,0 +1,47 @@
+package parquet
+
+import (
+	"sync"
+
+	"opencsg.com/csghub-server/builder/store/s3"
+)
+
+// LfsReaders holds the readers of the lfs storages, as a duckdb reader is connected to one s3 storage only
+type LfsReaders struct {
+	mu sync.Mutex
+	// readers by storage id, the reader is recreated when the router returns a new storage after it's updated.
+	// The old reader is not closed as it may be still in use, storages are rarely updated
+	readers map[int64]lfsReader
+	// newReader is replaceable in tests
+	newReader func(storage *s3.LfsStorage) (Reader, error)
+}
+
+type lfsReader struct {
+	storage *s3.LfsStorage
+	reader  Reader
+}
+
+func NewLfsReaders() *LfsReaders {
+	return &LfsReaders{
+		readers: make(map[int64]lfsReader),
+		newReader: func(storage *s3.LfsStorage) (Reader, error) {
+			return newS3Reader(storage.Options, storage.Bucket)
+		},
+	}
+}
+
+// Of returns the reader of the objects in the lfs storage
+func (r *LfsReaders) Of(storage *s3.LfsStorage) (Reader, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cached, ok := r.readers[storage.ID]
+	if ok && cached.storage == storage {
+		return cached.reader, nil
+	}
+	reader, err := r.newReader(storage)
+	if err != nil {
+		return nil, err
+	}
+	r.readers[storage.ID] = lfsReader{storage: storage, reader: reader}
+	return reader, nil
+}
@@ -0,0 +1,40 @@
+package parquet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/s3"
+)
+
+type bucketReader struct {
+	Reader
+	bucket string
+}
+
+func TestLfsReaders_Of(t *testing.T) {
+	readers := NewLfsReaders()
+	created := 0
+	readers.newReader = func(storage *s3.LfsStorage) (Reader, error) {
+		created++
+		return &bucketReader{bucket: storage.Bucket}, nil
+	}
+
+	defaultStorage := &s3.LfsStorage{ID: s3.DefaultLfsStorageID, Bucket: "lfs"}
+	hot := &s3.LfsStorage{ID: 2, Bucket: "hot-lfs"}
+	r, err := readers.Of(defaultStorage)
+	require.NoError(t, err)
+	require.Equal(t, "lfs", r.(*bucketReader).bucket)
+	r, err = readers.Of(hot)
+	require.NoError(t, err)
+	require.Equal(t, "hot-lfs", r.(*bucketReader).bucket)
+	_, err = readers.Of(hot)
+	require.NoError(t, err)
+	require.Equal(t, 2, created)
+
+	// the router returns a new storage after it's updated
+	r, err = readers.Of(&s3.LfsStorage{ID: 2, Bucket: "hot-lfs-v2"})
+	require.NoError(t, err)
+	require.Equal(t, "hot-lfs-v2", r.(*bucketReader).bucket)
+	require.Equal(t, 3, created)
+}
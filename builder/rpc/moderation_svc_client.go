@@ -10,6 +10,8 @@ import (
 type ModerationSvcClient interface {
 	PassTextCheck(ctx context.Context, scenario, text string) (*CheckResult, error)
 	PassImageCheck(ctx context.Context, scenario, ossBucketName, ossObjectName string) (*CheckResult, error)
+	// PassImageURLCheck checks the image of url, which is downloaded by moderation service
+	PassImageURLCheck(ctx context.Context, scenario, imageURL string) (*CheckResult, error)
 	SubmitRepoCheck(ctx context.Context, repoType types.RepositoryType, namespace, name string) error
 }
 
@@ -70,6 +72,26 @@ func (c *ModerationSvcHttpClient) PassImageCheck(ctx context.Context, scenario,
 	return resp.Data.(*CheckResult), nil
 }
 
+func (c *ModerationSvcHttpClient) PassImageURLCheck(ctx context.Context, scenario, imageURL string) (*CheckResult, error) {
+	type CheckRequest struct {
+		Scenario string `json:"scenario"`
+		URL      string `json:"url"`
+	}
+
+	req := &CheckRequest{
+		Scenario: scenario,
+		URL:      imageURL,
+	}
+	var resp httpbase.R
+	resp.Data = &CheckResult{}
+	const path = "/api/v1/image_url"
+	err := c.hc.Post(ctx, path, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data.(*CheckResult), nil
+}
+
 func (c *ModerationSvcHttpClient) SubmitRepoCheck(ctx context.Context, repoType types.RepositoryType, namespace, name string) error {
 	type CheckRequest struct {
 		RepoType  types.RepositoryType `json:"repo_type"`
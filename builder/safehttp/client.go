@@ -0,0 +1,112 @@
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const maxRedirects = 5
+
+// ErrInternalAddress is returned when connecting to the internal addresses, like loopback and private ones
+var ErrInternalAddress = errors.New("connecting to internal address is not allowed")
+
+// NewClient returns the http client to request the urls given by users, like webhooks and images. It refuses to
+// connect to loopback, private, link-local and metadata addresses, so that the urls can not be used to reach the
+// internal services. Addresses are checked when dialing, which covers the redirects and the hostnames resolved to
+// internal addresses, including the ones resolved differently after validated. Proxies from environment are not
+// used as the addresses of targets would not be checked.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		CheckRedirect: checkRedirect,
+	}
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to unsupported scheme '%s'", req.URL.Scheme)
+	}
+	return nil
+}
+
+// control checks the resolved address before connecting
+func control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address '%s', error: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid ip '%s'", host)
+	}
+	if IsInternalIP(ip) {
+		return fmt.Errorf("%w: %s", ErrInternalAddress, ip)
+	}
+	return nil
+}
+
+// IsInternalIP returns whether the ip is not a public one, like loopback, private, link-local and metadata addresses.
+// Metadata services of clouds listen on link-local (169.254.169.254), carrier-grade nat (100.100.100.200) or
+// unique local (fd00:ec2::254) addresses, which are all internal
+func IsInternalIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, n := range internalNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// the networks not covered by the checks of net.IP
+var internalNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		// "this" network
+		"0.0.0.0/8",
+		// carrier-grade nat, also used by the internal services of clouds
+		"100.64.0.0/10",
+		// ietf protocol assignments
+		"192.0.0.0/24",
+		// benchmarking
+		"198.18.0.0/15",
+		// reserved and broadcast
+		"240.0.0.0/4",
+		// nat64 addresses embedding ipv4 ones, ipv4 mapped addresses are checked as ipv4 ones
+		"64:ff9b::/96",
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, n)
+	}
+	return networks
+}()
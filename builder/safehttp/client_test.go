@@ -0,0 +1,63 @@
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsInternalIP(t *testing.T) {
+	for _, ip := range []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.100.100.200",
+		"0.0.0.0", "::1", "fe80::1", "fd00:ec2::254", "::ffff:127.0.0.1", "::ffff:7f00:1",
+	} {
+		require.True(t, IsInternalIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"8.8.8.8", "1.1.1.1", "2606:4700:4700::1111"} {
+		require.False(t, IsInternalIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	c := NewClient(time.Second)
+
+	_, err := c.Get(srv.URL)
+	require.ErrorIs(t, err, ErrInternalAddress)
+	// hostnames are checked after resolved
+	_, err = c.Get(strings.Replace(srv.URL, "127.0.0.1", "localhost", 1))
+	require.ErrorIs(t, err, ErrInternalAddress)
+}
+
+func TestNewClient_Redirect(t *testing.T) {
+	// the public server redirecting to internal address is faked by skipping the check of the first dial
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer internal.Close()
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	}))
+	defer public.Close()
+
+	c := NewClient(time.Second)
+	dialer := &net.Dialer{Control: control}
+	c.Transport.(*http.Transport).DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == strings.TrimPrefix(public.URL, "http://") {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	_, err := c.Get(public.URL)
+	require.True(t, errors.Is(err, ErrInternalAddress), err)
+
+	req, err := http.NewRequest(http.MethodGet, "file:///etc/passwd", nil)
+	require.NoError(t, err)
+	require.Error(t, checkRedirect(req, nil))
+}
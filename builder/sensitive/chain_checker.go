@@ -0,0 +1,186 @@
+package sensitive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/s3"
+	"opencsg.com/csghub-server/common/config"
+)
+
+// providers of content moderation, which are chained to check the content
+const (
+	// ProviderDFA matches the sensitive words of config locally
+	ProviderDFA = "dfa"
+	// ProviderRegex matches the regex rules in the rules file of config locally
+	ProviderRegex = "regex"
+	// ProviderClassifier calls the classifier service deployed locally
+	ProviderClassifier = "classifier"
+	// ProviderAliyun calls the content moderation service of Aliyun green
+	ProviderAliyun = "aliyun"
+)
+
+// ChainChecker implements SensitiveChecker by calling the checkers in order, the content is sensitive if any of
+// them reports so. The checkers after are not called once the content is found sensitive, so the cheap local
+// checkers should go first
+type ChainChecker struct {
+	checkers []SensitiveChecker
+}
+
+var _ SensitiveChecker = (*ChainChecker)(nil)
+
+func NewChainChecker(checkers ...SensitiveChecker) *ChainChecker {
+	return &ChainChecker{checkers: checkers}
+}
+
+func (c *ChainChecker) PassTextCheck(ctx context.Context, scenario Scenario, text string) (*CheckResult, error) {
+	for _, checker := range c.checkers {
+		result, err := checker.PassTextCheck(ctx, scenario, text)
+		if err != nil {
+			return nil, err
+		}
+		if result.IsSensitive {
+			return result, nil
+		}
+	}
+	return &CheckResult{IsSensitive: false}, nil
+}
+
+func (c *ChainChecker) PassImageCheck(ctx context.Context, scenario Scenario, ossBucketName, ossObjectName string) (*CheckResult, error) {
+	for _, checker := range c.checkers {
+		result, err := checker.PassImageCheck(ctx, scenario, ossBucketName, ossObjectName)
+		if err != nil {
+			return nil, err
+		}
+		if result.IsSensitive {
+			return result, nil
+		}
+	}
+	return &CheckResult{IsSensitive: false}, nil
+}
+
+// ScenarioChecker implements SensitiveChecker by dispatching the checks to the checker of scenario, the
+// default checker is used for the scenarios without their own checkers
+type ScenarioChecker struct {
+	defaultChecker SensitiveChecker
+	scenarios      map[Scenario]SensitiveChecker
+}
+
+var _ SensitiveChecker = (*ScenarioChecker)(nil)
+
+func (c *ScenarioChecker) PassTextCheck(ctx context.Context, scenario Scenario, text string) (*CheckResult, error) {
+	return c.checker(scenario).PassTextCheck(ctx, scenario, text)
+}
+
+func (c *ScenarioChecker) PassImageCheck(ctx context.Context, scenario Scenario, ossBucketName, ossObjectName string) (*CheckResult, error) {
+	return c.checker(scenario).PassImageCheck(ctx, scenario, ossBucketName, ossObjectName)
+}
+
+func (c *ScenarioChecker) checker(scenario Scenario) SensitiveChecker {
+	if checker, ok := c.scenarios[scenario]; ok {
+		return checker
+	}
+	return c.defaultChecker
+}
+
+// NewSensitiveChecker creates the checker of the providers chained in config, the scenarios configured with
+// their own providers are checked by them instead of the default ones
+func NewSensitiveChecker(cfg *config.Config) (SensitiveChecker, error) {
+	f := &providerFactory{cfg: cfg, providers: make(map[string]SensitiveChecker)}
+	defaultChecker, err := f.chain(cfg.Moderation.Providers)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(cfg.Moderation.ScenarioProviders) == "" {
+		return defaultChecker, nil
+	}
+
+	c := &ScenarioChecker{
+		defaultChecker: defaultChecker,
+		scenarios:      make(map[Scenario]SensitiveChecker),
+	}
+	for _, s := range strings.Split(cfg.Moderation.ScenarioProviders, ";") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		name, providers, ok := strings.Cut(s, ":")
+		scenario, valid := Scenario("").FromString(strings.TrimSpace(name))
+		if !ok || !valid {
+			return nil, fmt.Errorf("invalid scenario providers %s, must be in the format of `scenario:provider,provider`", s)
+		}
+		c.scenarios[scenario], err = f.chain(providers)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// providerFactory creates the providers of config, each provider is created once and shared by the chains
+type providerFactory struct {
+	cfg       *config.Config
+	providers map[string]SensitiveChecker
+}
+
+func (f *providerFactory) chain(providers string) (SensitiveChecker, error) {
+	var checkers []SensitiveChecker
+	for _, name := range strings.Split(providers, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		checker, err := f.provider(name)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+	if len(checkers) == 0 {
+		return nil, fmt.Errorf("no moderation provider in chain %q", providers)
+	}
+	if len(checkers) == 1 {
+		return checkers[0], nil
+	}
+	return NewChainChecker(checkers...), nil
+}
+
+func (f *providerFactory) provider(name string) (SensitiveChecker, error) {
+	if checker, ok := f.providers[name]; ok {
+		return checker, nil
+	}
+
+	var checker SensitiveChecker
+	var err error
+	switch name {
+	case ProviderDFA:
+		checker = NewLocalWordChecker(f.cfg.Moderation.EncodedSensitiveWords)
+	case ProviderRegex:
+		if f.cfg.Moderation.RegexRulesFile == "" {
+			return nil, fmt.Errorf("regex rules file is required by moderation provider %s", name)
+		}
+		checker, err = NewRegexCheckerFromFile(f.cfg.Moderation.RegexRulesFile)
+	case ProviderClassifier:
+		if f.cfg.Moderation.ClassifierEndpoint == "" {
+			return nil, fmt.Errorf("classifier endpoint is required by moderation provider %s", name)
+		}
+		var images *s3.Client
+		images, err = s3.NewMinio(f.cfg)
+		if err != nil {
+			return nil, err
+		}
+		checker = NewClassifierChecker(f.cfg.Moderation.ClassifierEndpoint, f.cfg.Moderation.ClassifierApiKey,
+			time.Duration(f.cfg.Moderation.ClassifierTimeout)*time.Second, images)
+	case ProviderAliyun:
+		checker = NewAliyunGreenChecker(f.cfg)
+	default:
+		return nil, fmt.Errorf("unknown moderation provider %s, must be one of %s, %s, %s and %s", name,
+			ProviderDFA, ProviderRegex, ProviderClassifier, ProviderAliyun)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.providers[name] = checker
+	return checker, nil
+}
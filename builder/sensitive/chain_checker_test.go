@@ -0,0 +1,113 @@
+package sensitive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/common/config"
+)
+
+type fakeChecker struct {
+	result *CheckResult
+	err    error
+	calls  int
+}
+
+func (c *fakeChecker) PassTextCheck(ctx context.Context, scenario Scenario, text string) (*CheckResult, error) {
+	c.calls++
+	return c.result, c.err
+}
+
+func (c *fakeChecker) PassImageCheck(ctx context.Context, scenario Scenario, ossBucketName, ossObjectName string) (*CheckResult, error) {
+	c.calls++
+	return c.result, c.err
+}
+
+func TestChainChecker(t *testing.T) {
+	pass := &fakeChecker{result: &CheckResult{}}
+	block := &fakeChecker{result: &CheckResult{IsSensitive: true, Reason: "blocked"}}
+	after := &fakeChecker{result: &CheckResult{}}
+
+	result, err := NewChainChecker(pass, block, after).PassTextCheck(context.Background(), ScenarioCommentDetection, "text")
+	require.NoError(t, err)
+	require.True(t, result.IsSensitive)
+	require.Equal(t, "blocked", result.Reason)
+	require.Equal(t, 0, after.calls)
+
+	result, err = NewChainChecker(pass, after).PassImageCheck(context.Background(), ScenarioImageBaseLineCheck, "bucket", "object")
+	require.NoError(t, err)
+	require.False(t, result.IsSensitive)
+
+	failed := &fakeChecker{err: errors.New("unavailable")}
+	_, err = NewChainChecker(pass, failed, block).PassTextCheck(context.Background(), ScenarioCommentDetection, "text")
+	require.Error(t, err)
+}
+
+func TestRegexChecker(t *testing.T) {
+	c, err := NewRegexChecker(`
+# phone numbers
+phone 1[3-9]\d{9}
+gambling (?i)online\s+casino
+`)
+	require.NoError(t, err)
+
+	result, err := c.PassTextCheck(context.Background(), ScenarioCommentDetection, "call 13912345678 now")
+	require.NoError(t, err)
+	require.Equal(t, &CheckResult{IsSensitive: true, Reason: "phone"}, result)
+
+	result, err = c.PassTextCheck(context.Background(), ScenarioCommentDetection, "best Online  Casino")
+	require.NoError(t, err)
+	require.Equal(t, "gambling", result.Reason)
+
+	result, err = c.PassTextCheck(context.Background(), ScenarioCommentDetection, "a model card")
+	require.NoError(t, err)
+	require.False(t, result.IsSensitive)
+
+	_, err = NewRegexChecker("phone")
+	require.Error(t, err)
+	_, err = NewRegexChecker("bad [a-")
+	require.Error(t, err)
+}
+
+func TestNewSensitiveChecker(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.txt")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`phone 1[3-9]\d{9}`), 0o644))
+
+	cfg := &config.Config{}
+	// 敏感词,sensitiveword
+	cfg.Moderation.EncodedSensitiveWords = "5pWP5oSf6K+NLHNlbnNpdGl2ZXdvcmQ="
+	cfg.Moderation.Providers = "dfa"
+	cfg.Moderation.ScenarioProviders = "nickname_detection:dfa,regex"
+	cfg.Moderation.RegexRulesFile = rulesFile
+
+	c, err := NewSensitiveChecker(cfg)
+	require.NoError(t, err)
+
+	result, err := c.PassTextCheck(context.Background(), ScenarioNicknameDetection, "13912345678")
+	require.NoError(t, err)
+	require.True(t, result.IsSensitive)
+	// regex rules are only checked for nicknames
+	result, err = c.PassTextCheck(context.Background(), ScenarioCommentDetection, "13912345678")
+	require.NoError(t, err)
+	require.False(t, result.IsSensitive)
+	result, err = c.PassTextCheck(context.Background(), ScenarioCommentDetection, "Sensitive Word")
+	require.NoError(t, err)
+	require.True(t, result.IsSensitive)
+
+	cfg.Moderation.ScenarioProviders = "unknown_detection:dfa"
+	_, err = NewSensitiveChecker(cfg)
+	require.Error(t, err)
+
+	cfg.Moderation.ScenarioProviders = ""
+	cfg.Moderation.Providers = "dfa,unknown"
+	_, err = NewSensitiveChecker(cfg)
+	require.Error(t, err)
+
+	cfg.Moderation.Providers = "classifier"
+	_, err = NewSensitiveChecker(cfg)
+	require.Error(t, err)
+}
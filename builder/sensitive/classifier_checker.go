@@ -0,0 +1,81 @@
+package sensitive
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"opencsg.com/csghub-server/builder/rpc"
+	"opencsg.com/csghub-server/builder/store/s3"
+)
+
+// ClassifierChecker implements SensitiveChecker by calling a classifier service deployed locally, like a
+// text or image classification model served behind http, for deployments without access to cloud services.
+//
+// The service accepts the json requests below, and responds with the json of CheckResult:
+//
+//	POST /text  {"scenario": "comment_detection", "text": "..."}
+//	POST /image {"scenario": "baselineCheck", "oss_bucket_name": "...", "oss_object_name": "...", "image_url": "..."}
+//
+// The image url is presigned for the service to download the image without credentials of the bucket
+type ClassifierChecker struct {
+	hc      *rpc.HttpClient
+	timeout time.Duration
+	images  *s3.Client
+}
+
+var _ SensitiveChecker = (*ClassifierChecker)(nil)
+
+const imageURLExpiry = 10 * time.Minute
+
+// NewClassifierChecker creates a ClassifierChecker of the service endpoint, requests are sent with the api key
+// as bearer token if it's not empty. Image urls are presigned by the s3 client, and left empty if it's nil
+func NewClassifierChecker(endpoint, apiKey string, timeout time.Duration, images *s3.Client) *ClassifierChecker {
+	var opts []rpc.RequestOption
+	if apiKey != "" {
+		opts = append(opts, rpc.AuthWithApiKey(apiKey))
+	}
+	return &ClassifierChecker{
+		hc:      rpc.NewHttpClient(endpoint, opts...),
+		timeout: timeout,
+		images:  images,
+	}
+}
+
+func (c *ClassifierChecker) PassTextCheck(ctx context.Context, scenario Scenario, text string) (*CheckResult, error) {
+	req := map[string]string{
+		"scenario": string(scenario),
+		"text":     text,
+	}
+	return c.post(ctx, "/text", req)
+}
+
+func (c *ClassifierChecker) PassImageCheck(ctx context.Context, scenario Scenario, ossBucketName, ossObjectName string) (*CheckResult, error) {
+	req := map[string]string{
+		"scenario":        string(scenario),
+		"oss_bucket_name": ossBucketName,
+		"oss_object_name": ossObjectName,
+	}
+	if c.images != nil {
+		u, err := c.images.PresignedGetObject(ctx, ossBucketName, ossObjectName, imageURLExpiry, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to presign image url, error: %w", err)
+		}
+		req["image_url"] = u.String()
+	}
+	return c.post(ctx, "/image", req)
+}
+
+func (c *ClassifierChecker) post(ctx context.Context, path string, req map[string]string) (*CheckResult, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	var result CheckResult
+	err := c.hc.Post(ctx, path, req, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call classifier service, error: %w", err)
+	}
+	return &result, nil
+}
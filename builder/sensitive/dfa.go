@@ -1,4 +1,6 @@
-package checker
+package sensitive
+
+import "unicode"
 
 // DFA State
 type State struct {
@@ -23,6 +25,10 @@ func (d *DFA) BuildDFA(words []string) {
 	for _, word := range words {
 		current := d.root
 		for _, char := range word {
+			if isIgnoredCharacter(char) {
+				continue
+			}
+			char = normalizeCharacter(char)
 			if next, exists := current.transitions[char]; exists {
 				current = next
 			} else {
@@ -31,29 +37,48 @@ func (d *DFA) BuildDFA(words []string) {
 				current = newState
 			}
 		}
-		current.isEnd = true // Mark the end of a sensitive word
+		if current != d.root {
+			current.isEnd = true // Mark the end of a sensitive word
+		}
 	}
 }
 
-// ContainsSensitiveWord checks if the input text contains any sensitive words
+// ContainsSensitiveWord checks if the input text contains any sensitive words. Characters are matched
+// case-insensitively and full-width forms match their ASCII ones, ignored characters between the
+// characters of a word are skipped
 func (d *DFA) ContainsSensitiveWord(text string) bool {
-	current := d.root
+	chars := make([]rune, 0, len(text))
 	for _, char := range text {
 		if isIgnoredCharacter(char) {
 			continue
 		}
-		if next, exists := current.transitions[char]; exists {
+		chars = append(chars, normalizeCharacter(char))
+	}
+	// match from every position, as a word may start in the middle of a partial match of another word
+	for i := range chars {
+		current := d.root
+		for _, char := range chars[i:] {
+			next, exists := current.transitions[char]
+			if !exists {
+				break
+			}
 			current = next
 			if current.isEnd {
 				return true
 			}
-		} else {
-			current = d.root // Reset to the root state
 		}
 	}
 	return false
 }
 
+// normalizeCharacter folds the full-width ASCII variants to ASCII, and letters to lower case
+func normalizeCharacter(c rune) rune {
+	if c >= '\uFF01' && c <= '\uFF5E' {
+		c -= 0xFEE0
+	}
+	return unicode.ToLower(c)
+}
+
 // isIgnoredCharacter checks if a character is in the specified ignored set
 func isIgnoredCharacter(c rune) bool {
 	ignoredCharacters := []rune{' ', '\u3000', '\t', '&', '%', '$', '@', '*', '！', '!', '#', '^', '~', '_', '—', '｜', '\'', '"', ';', '.', '，', ',', '?', '<', '>', '《', '》', '：', ':'}
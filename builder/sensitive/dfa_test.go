@@ -1,21 +1,12 @@
-package checker
+package sensitive
 
 import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"opencsg.com/csghub-server/common/config"
 )
 
 func TestContainsSensitiveWord(t *testing.T) {
-	cfg := &config.Config{}
-	cfg.SensitiveCheck.Enable = true
-	cfg.SensitiveCheck.AccessKeyID = "accessKeyID"
-	cfg.SensitiveCheck.AccessKeySecret = "accessKeySecret"
-	cfg.SensitiveCheck.Endpoint = "endpoint"
-	cfg.SensitiveCheck.Region = "region"
-	Init(cfg)
-
 	d := NewDFA()
 	d.BuildDFA(getSensitiveWordList(`5pWP5oSf6K+NLHNlbnNpdGl2ZXdvcmQ=`))
 	assert.True(t, d.ContainsSensitiveWord("敏感词"))
@@ -29,3 +20,22 @@ func TestContainsSensitiveWord(t *testing.T) {
 	assert.True(t, d.ContainsSensitiveWord("sensitive word123"))
 	assert.False(t, d.ContainsSensitiveWord("sensitive ord"))
 }
+
+func TestContainsSensitiveWord_Normalized(t *testing.T) {
+	d := NewDFA()
+	d.BuildDFA([]string{"bad word", "abc"})
+	assert.True(t, d.ContainsSensitiveWord("BAD_Word"))
+	assert.True(t, d.ContainsSensitiveWord("ｂａｄ ｗｏｒｄ"))
+	// a word starting in the middle of a partial match
+	assert.True(t, d.ContainsSensitiveWord("aabc"))
+	assert.True(t, d.ContainsSensitiveWord("babad word"))
+	assert.False(t, d.ContainsSensitiveWord("ab1c"))
+}
+
+func Test_getSensitiveWordList(t *testing.T) {
+	words := getSensitiveWordList(`5pWP5oSf6K+NLHNlbnNpdGl2ZXdvcmQ=`)
+
+	assert.Equal(t, 2, len(words))
+	assert.Equal(t, "敏感词", words[0])
+	assert.Equal(t, "sensitiveword", words[1])
+}
@@ -0,0 +1,134 @@
+package sensitive
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// LocalWordChecker implements SensitiveChecker by matching the text with a list of sensitive words locally,
+// images are not checked
+type LocalWordChecker struct {
+	dfa *DFA
+}
+
+var _ SensitiveChecker = (*LocalWordChecker)(nil)
+
+// NewLocalWordChecker creates a LocalWordChecker of the sensitive words, which are comma splitted and base64 encoded
+func NewLocalWordChecker(encodedWords string) *LocalWordChecker {
+	dfa := NewDFA()
+	dfa.BuildDFA(getSensitiveWordList(encodedWords))
+	return &LocalWordChecker{dfa: dfa}
+}
+
+func (c *LocalWordChecker) PassTextCheck(ctx context.Context, scenario Scenario, text string) (*CheckResult, error) {
+	if c.dfa.ContainsSensitiveWord(text) {
+		return &CheckResult{IsSensitive: true, Reason: "contains sensitive word"}, nil
+	}
+	return &CheckResult{IsSensitive: false}, nil
+}
+
+func (c *LocalWordChecker) PassImageCheck(ctx context.Context, scenario Scenario, ossBucketName, ossObjectName string) (*CheckResult, error) {
+	return &CheckResult{IsSensitive: false}, nil
+}
+
+func getSensitiveWordList(encodedWords string) []string {
+	r := base64.NewDecoder(base64.StdEncoding, strings.NewReader(encodedWords))
+	s := bufio.NewScanner(r)
+	s.Split(commaSplit)
+
+	var words []string
+	for s.Scan() {
+		words = append(words, s.Text())
+	}
+
+	return words
+}
+
+// Custom split function that splits on commas
+func commaSplit(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) == 0 {
+		// When there's no data, return normally
+		return 0, nil, nil
+	}
+
+	// Find the first comma
+	if i := strings.IndexByte(string(data), ','); i >= 0 {
+		// We've found a comma, return the part before it
+		return i + 1, data[:i], nil
+	}
+
+	// If we've reached EOF and there's data left, return it
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	// If we haven't found a comma and we're not at EOF,
+	// we need more data
+	return 0, nil, nil
+}
+
+// RegexChecker implements SensitiveChecker by matching the text with regex rules locally, for the sensitive
+// content which can't be listed as words, like phone numbers or urls of gambling sites. Images are not checked
+type RegexChecker struct {
+	rules []regexRule
+}
+
+type regexRule struct {
+	label   string
+	pattern *regexp.Regexp
+}
+
+var _ SensitiveChecker = (*RegexChecker)(nil)
+
+// NewRegexChecker creates a RegexChecker of the rules, one rule per line in the format of `label pattern`,
+// the label is the reason of sensitive text matching the pattern. Empty lines and lines starting with # are skipped
+func NewRegexChecker(rules string) (*RegexChecker, error) {
+	c := &RegexChecker{}
+	s := bufio.NewScanner(strings.NewReader(rules))
+	line := 0
+	for s.Scan() {
+		line++
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		label, pattern, ok := strings.Cut(text, " ")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid regex rule at line %d, must be in the format of `label pattern`", line)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex rule at line %d, error: %w", line, err)
+		}
+		c.rules = append(c.rules, regexRule{label: label, pattern: re})
+	}
+	return c, s.Err()
+}
+
+// NewRegexCheckerFromFile creates a RegexChecker of the rules in file
+func NewRegexCheckerFromFile(file string) (*RegexChecker, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read regex rules file %s, error: %w", file, err)
+	}
+	return NewRegexChecker(string(data))
+}
+
+func (c *RegexChecker) PassTextCheck(ctx context.Context, scenario Scenario, text string) (*CheckResult, error) {
+	for _, rule := range c.rules {
+		if rule.pattern.MatchString(text) {
+			return &CheckResult{IsSensitive: true, Reason: rule.label}, nil
+		}
+	}
+	return &CheckResult{IsSensitive: false}, nil
+}
+
+func (c *RegexChecker) PassImageCheck(ctx context.Context, scenario Scenario, ossBucketName, ossObjectName string) (*CheckResult, error) {
+	return &CheckResult{IsSensitive: false}, nil
+}
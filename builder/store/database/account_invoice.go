@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+type accountInvoiceStoreImpl struct {
+	db *DB
+}
+
+type AccountInvoiceStore interface {
+	// Create saves the invoice, false is returned if the account has had the invoice of the period
+	Create(ctx context.Context, invoice *AccountInvoice) (bool, error)
+	FindByID(ctx context.Context, id int64) (*AccountInvoice, error)
+	ListByAccountID(ctx context.Context, accountID int64, per, page int) ([]AccountInvoice, int, error)
+}
+
+func NewAccountInvoiceStore() AccountInvoiceStore {
+	return &accountInvoiceStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// AccountInvoice is the consumption of account in a billing period
+type AccountInvoice struct {
+	ID          int64     `bun:",pk,autoincrement" json:"id"`
+	AccountID   int64     `bun:",notnull" json:"account_id"`
+	PeriodStart time.Time `bun:",notnull" json:"period_start"`
+	PeriodEnd   time.Time `bun:",notnull" json:"period_end"`
+	// total charges in the period
+	Amount types.Money `bun:",notnull" json:"amount"`
+	// total recharges and credits in the period
+	Recharged types.Money         `bun:",notnull" json:"recharged"`
+	Items     []types.AccountBill `bun:",type:jsonb" json:"items"`
+	times
+}
+
+func (s *accountInvoiceStoreImpl) Create(ctx context.Context, invoice *AccountInvoice) (bool, error) {
+	res, err := s.db.Operator.Core.NewInsert().
+		Model(invoice).
+		On("CONFLICT (account_id, period_start) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to create account invoice, error: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *accountInvoiceStoreImpl) FindByID(ctx context.Context, id int64) (*AccountInvoice, error) {
+	var invoice AccountInvoice
+	err := s.db.Operator.Core.NewSelect().Model(&invoice).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (s *accountInvoiceStoreImpl) ListByAccountID(ctx context.Context, accountID int64, per, page int) ([]AccountInvoice, int, error) {
+	var invoices []AccountInvoice
+	total, err := s.db.Operator.Core.NewSelect().
+		Model(&invoices).
+		Where("account_id = ?", accountID).
+		Order("period_start DESC").
+		Limit(per).
+		Offset((page - 1) * per).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return invoices, total, nil
+}
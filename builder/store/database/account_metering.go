@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/uptrace/bun"
 	"opencsg.com/csghub-server/common/types"
 	commonTypes "opencsg.com/csghub-server/common/types"
 )
@@ -16,6 +17,9 @@ type accountMeteringStoreImpl struct {
 
 type AccountMeteringStore interface {
 	Create(ctx context.Context, input AccountMetering) error
+	// CreateWithUsage saves the metering event and adds it to the daily usage in a transaction, nothing is
+	// changed if the event has been saved, and false is returned
+	CreateWithUsage(ctx context.Context, input AccountMetering, usage *AccountUsageDaily) (bool, error)
 	ListByUserIDAndTime(ctx context.Context, req commonTypes.ACCT_STATEMENTS_REQ) ([]AccountMetering, int, error)
 	ListAllByUserUUID(ctx context.Context, userUUID string) ([]AccountMetering, error)
 }
@@ -51,6 +55,26 @@ func (am *accountMeteringStoreImpl) Create(ctx context.Context, input AccountMet
 	return nil
 }
 
+func (am *accountMeteringStoreImpl) CreateWithUsage(ctx context.Context, input AccountMetering, usage *AccountUsageDaily) (bool, error) {
+	created := false
+	err := am.db.Operator.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewInsert().
+			Model(&input).
+			On("CONFLICT (event_uuid) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to save metering event, error: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			// the event has been saved
+			return nil
+		}
+		created = true
+		return addUsage(ctx, tx, usage)
+	})
+	return created, err
+}
+
 func (am *accountMeteringStoreImpl) ListByUserIDAndTime(ctx context.Context, req commonTypes.ACCT_STATEMENTS_REQ) ([]AccountMetering, int, error) {
 	var accountMeters []AccountMetering
 	q := am.db.Operator.Core.NewSelect().Model(&accountMeters).Where("user_uuid = ? and scene = ? and customer_id = ? and recorded_at >= ? and recorded_at <= ?", req.UserUUID, req.Scene, req.InstanceName, req.StartTime, req.EndTime)
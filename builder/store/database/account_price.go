@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+type accountPriceStoreImpl struct {
+	db *DB
+}
+
+type AccountPriceStore interface {
+	Create(ctx context.Context, price AccountPrice) (*AccountPrice, error)
+	Update(ctx context.Context, price *AccountPrice) error
+	Delete(ctx context.Context, id int64) error
+	FindByID(ctx context.Context, id int64) (*AccountPrice, error)
+	List(ctx context.Context, per, page int) ([]AccountPrice, int, error)
+	// FindForResource returns the price of resource in scene, the default price of scene is returned if
+	// the resource has no price, nil is returned if there is no price at all
+	FindForResource(ctx context.Context, scene types.SceneType, resourceID, unitType string) (*AccountPrice, error)
+}
+
+func NewAccountPriceStore() AccountPriceStore {
+	return &accountPriceStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// AccountPrice is the price of SkuUnit units of a resource used in a scene
+type AccountPrice struct {
+	ID      int64           `bun:",pk,autoincrement" json:"id"`
+	SkuType types.SKUType   `bun:",notnull" json:"sku_type"`
+	Scene   types.SceneType `bun:",notnull" json:"scene"`
+	// sku of space resource, empty for the default price of scene
+	ResourceID  string      `bun:",notnull" json:"resource_id"`
+	UnitType    string      `bun:",notnull" json:"unit_type"`
+	SkuUnit     int64       `bun:",notnull" json:"sku_unit"`
+	Price       types.Money `bun:",notnull" json:"price"`
+	Description string      `bun:",nullzero" json:"description"`
+	times
+}
+
+// Cost returns the fee of value units, rounded half up to the micro unit
+func (p *AccountPrice) Cost(value int64) types.Money {
+	if p.SkuUnit <= 0 || value <= 0 || p.Price <= 0 {
+		return 0
+	}
+	// value * price may overflow int64 for large token counts
+	cost := new(big.Int).Mul(big.NewInt(value), big.NewInt(int64(p.Price)))
+	cost.Add(cost, big.NewInt(p.SkuUnit/2))
+	cost.Quo(cost, big.NewInt(p.SkuUnit))
+	if !cost.IsInt64() {
+		return types.Money(math.MaxInt64)
+	}
+	return types.Money(cost.Int64())
+}
+
+func (s *accountPriceStoreImpl) Create(ctx context.Context, price AccountPrice) (*AccountPrice, error) {
+	res, err := s.db.Operator.Core.NewInsert().Model(&price).Exec(ctx, &price)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create account price, error: %w", err)
+	}
+	return &price, nil
+}
+
+func (s *accountPriceStoreImpl) Update(ctx context.Context, price *AccountPrice) error {
+	price.UpdatedAt = time.Now()
+	return assertAffectedOneRow(s.db.Operator.Core.NewUpdate().
+		Model(price).
+		WherePK().
+		ExcludeColumn("created_at").
+		Exec(ctx))
+}
+
+func (s *accountPriceStoreImpl) Delete(ctx context.Context, id int64) error {
+	return assertAffectedOneRow(s.db.Operator.Core.NewDelete().
+		Model((*AccountPrice)(nil)).
+		Where("id = ?", id).
+		Exec(ctx))
+}
+
+func (s *accountPriceStoreImpl) FindByID(ctx context.Context, id int64) (*AccountPrice, error) {
+	var price AccountPrice
+	err := s.db.Operator.Core.NewSelect().Model(&price).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (s *accountPriceStoreImpl) List(ctx context.Context, per, page int) ([]AccountPrice, int, error) {
+	var prices []AccountPrice
+	total, err := s.db.Operator.Core.NewSelect().
+		Model(&prices).
+		Order("scene ASC", "resource_id ASC", "unit_type ASC").
+		Limit(per).
+		Offset((page - 1) * per).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return prices, total, nil
+}
+
+func (s *accountPriceStoreImpl) FindForResource(ctx context.Context, scene types.SceneType, resourceID, unitType string) (*AccountPrice, error) {
+	var price AccountPrice
+	err := s.db.Operator.Core.NewSelect().
+		Model(&price).
+		Where("scene = ? AND unit_type = ?", scene, unitType).
+		Where("resource_id IN (?, '')", resourceID).
+		// the price of resource goes before the default price
+		OrderExpr("resource_id = '' ASC").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
@@ -0,0 +1,27 @@
+package database_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestAccountPrice_Cost(t *testing.T) {
+	// 0.01 per 1000 tokens
+	price := database.AccountPrice{SkuUnit: 1000, Price: 10_000}
+	require.Equal(t, types.Money(10_000), price.Cost(1000))
+	require.Equal(t, types.Money(10), price.Cost(1))
+	require.Equal(t, types.Money(0), price.Cost(0))
+
+	// rounded half up
+	price = database.AccountPrice{SkuUnit: 3, Price: 1}
+	require.Equal(t, types.Money(0), price.Cost(1))
+	require.Equal(t, types.Money(1), price.Cost(2))
+
+	price = database.AccountPrice{SkuUnit: 1, Price: types.MoneyUnit}
+	require.Equal(t, types.Money(math.MaxInt64), price.Cost(math.MaxInt64))
+	require.Equal(t, types.Money(0), (&database.AccountPrice{}).Cost(10))
+}
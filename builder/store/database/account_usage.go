@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type accountUsageStoreImpl struct {
+	db *DB
+}
+
+type AccountUsageStore interface {
+	// Aggregate sums the daily rollups of users by the period and dimension of req
+	Aggregate(ctx context.Context, userUUIDs []string, req types.UsageAggregateReq) ([]types.UsageAggregate, error)
+	// Rebuild replaces the rollups of the days in [start, end) with the sums of the metering events recorded
+	// in them, the number of rollups is returned
+	Rebuild(ctx context.Context, start, end time.Time) (int64, error)
+}
+
+func NewAccountUsageStore() AccountUsageStore {
+	return &accountUsageStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// AccountUsageDaily is the rollup of the metering events of a user on a resource in a day
+type AccountUsageDaily struct {
+	ID           int64           `bun:",pk,autoincrement" json:"id"`
+	Day          time.Time       `bun:"type:date,notnull" json:"day"`
+	UserUUID     string          `bun:",notnull" json:"user_uuid"`
+	Scene        types.SceneType `bun:",notnull" json:"scene"`
+	ResourceID   string          `bun:",notnull" json:"resource_id"`
+	ResourceName string          `bun:",nullzero" json:"resource_name"`
+	CustomerID   string          `bun:",notnull" json:"customer_id"`
+	UnitType     string          `bun:",notnull" json:"unit_type"`
+	Value        float64         `bun:",notnull,default:0" json:"value"`
+	EventCount   int64           `bun:",notnull,default:0" json:"event_count"`
+	times
+}
+
+// UsageDay returns the day of the rollup a metering event recorded at t goes to, days are in UTC
+func UsageDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// addUsage accumulates the value of usage into the rollup of its day
+func addUsage(ctx context.Context, db bun.IDB, usage *AccountUsageDaily) error {
+	_, err := db.NewInsert().
+		Model(usage).
+		On("CONFLICT (day, user_uuid, scene, resource_id, customer_id, unit_type) DO UPDATE").
+		Set("value = account_usage_daily.value + EXCLUDED.value").
+		Set("event_count = account_usage_daily.event_count + EXCLUDED.event_count").
+		Set("resource_name = EXCLUDED.resource_name").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add usage of %s, error: %w", usage.UserUUID, err)
+	}
+	return nil
+}
+
+func (s *accountUsageStoreImpl) Aggregate(ctx context.Context, userUUIDs []string, req types.UsageAggregateReq) ([]types.UsageAggregate, error) {
+	var result []types.UsageAggregate
+	if len(userUUIDs) == 0 {
+		return result, nil
+	}
+	query := s.db.Operator.Core.NewSelect().
+		Model((*AccountUsageDaily)(nil)).
+		ColumnExpr("date_trunc(?, day) AS period_start", string(req.Period))
+	groups := []string{"period_start"}
+	switch req.GroupBy {
+	case types.UsageGroupByScene:
+		query = query.Column("scene")
+		groups = append(groups, "scene")
+	case types.UsageGroupByResource:
+		query = query.ColumnExpr("scene, resource_id, MAX(resource_name) AS resource_name")
+		groups = append(groups, "scene", "resource_id")
+	case types.UsageGroupByMember:
+		query = query.Column("user_uuid")
+		groups = append(groups, "user_uuid")
+	}
+	groups = append(groups, "unit_type")
+	query = query.
+		ColumnExpr("unit_type, SUM(value) AS value, SUM(event_count) AS event_count").
+		Where("user_uuid IN (?)", bun.In(userUUIDs)).
+		Where("day >= ? AND day < ?", req.StartDate.Format(time.DateOnly), req.EndDate.Format(time.DateOnly))
+	if req.Scene != nil {
+		query = query.Where("scene = ?", *req.Scene)
+	}
+	err := query.
+		Group(groups...).
+		Order(groups...).
+		Scan(ctx, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate usage, error: %w", err)
+	}
+	return result, nil
+}
+
+func (s *accountUsageStoreImpl) Rebuild(ctx context.Context, start, end time.Time) (int64, error) {
+	var rows int64
+	err := s.db.Operator.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		rows, err = RebuildUsageDaily(ctx, tx, start, end)
+		return err
+	})
+	return rows, err
+}
+
+// RebuildUsageDaily replaces the rollups of the days in [start, end) with the sums of the metering events
+// recorded in them, it runs in tx so that it's safe to run again. The rollup table is locked against
+// concurrent writes, metering events saved after the lock are added to the rebuilt rollups as usual
+func RebuildUsageDaily(ctx context.Context, tx bun.Tx, start, end time.Time) (int64, error) {
+	startDay, endDay := UsageDay(start), UsageDay(end)
+	_, err := tx.ExecContext(ctx, "LOCK TABLE account_usage_dailies IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock usage rollups, error: %w", err)
+	}
+	_, err = tx.NewDelete().
+		Model((*AccountUsageDaily)(nil)).
+		Where("day >= ? AND day < ?", startDay.Format(time.DateOnly), endDay.Format(time.DateOnly)).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete usage rollups, error: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO account_usage_dailies
+	(day, user_uuid, scene, resource_id, resource_name, customer_id, unit_type, value, event_count, created_at, updated_at)
+SELECT (recorded_at AT TIME ZONE 'UTC')::date, user_uuid, scene, COALESCE(resource_id, ''), MAX(resource_name),
+	COALESCE(customer_id, ''), COALESCE(sku_unit_type, ''), SUM(value), COUNT(*), now(), now()
+FROM account_meterings
+WHERE recorded_at >= ? AND recorded_at < ?
+GROUP BY 1, user_uuid, scene, COALESCE(resource_id, ''), COALESCE(customer_id, ''), COALESCE(sku_unit_type, '')`,
+		startDay, endDay)
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up metering events, error: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,15 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+)
+
+func TestUsageDay(t *testing.T) {
+	cst := time.FixedZone("CST", 8*3600)
+	require.Equal(t, time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), database.UsageDay(time.Date(2024, 7, 1, 7, 59, 0, 0, cst)))
+	require.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), database.UsageDay(time.Date(2024, 7, 1, 8, 0, 0, 0, cst)))
+}
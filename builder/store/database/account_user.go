@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type accountUserStoreImpl struct {
+	db *DB
+}
+
+type AccountUserStore interface {
+	FindByOwnerUUID(ctx context.Context, ownerUUID string) (*AccountUser, error)
+	// FindOrCreate returns the account of owner, an empty account is created if owner has no account
+	FindOrCreate(ctx context.Context, ownerUUID string, ownerType types.AccountOwnerType) (*AccountUser, error)
+	List(ctx context.Context, per, page int) ([]AccountUser, int, error)
+	ListAll(ctx context.Context) ([]AccountUser, error)
+	// ListByPayer returns the user accounts charged to the payer account
+	ListByPayer(ctx context.Context, payerUUID string) ([]AccountUser, error)
+	// Update saves the columns of account, all columns are saved if columns is empty
+	Update(ctx context.Context, account *AccountUser, columns ...string) error
+	// ApplyStatement adds the amount of statement to the balance of account and saves the statement in
+	// a transaction, the status of account follows the new balance. The account before the change is
+	// returned with the changed one. Nothing is changed if the event of statement has been applied, and
+	// false is returned
+	ApplyStatement(ctx context.Context, accountID int64, statement *AccountStatement) (before, after *AccountUser, applied bool, err error)
+	ListStatements(ctx context.Context, accountID int64, startTime, endTime time.Time, per, page int) ([]AccountStatement, int, error)
+	// SumBills returns the charges of account in the period grouped by resource and customer
+	SumBills(ctx context.Context, accountID int64, startTime, endTime time.Time) ([]types.AccountBill, error)
+	// SumRecharged returns the total recharge and credit of account in the period
+	SumRecharged(ctx context.Context, accountID int64, startTime, endTime time.Time) (types.Money, error)
+}
+
+func NewAccountUserStore() AccountUserStore {
+	return &accountUserStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// AccountUser is the balance account of a user or an organization
+type AccountUser struct {
+	ID                  int64                  `bun:",pk,autoincrement" json:"id"`
+	OwnerUUID           string                 `bun:",notnull,unique" json:"owner_uuid"`
+	OwnerType           types.AccountOwnerType `bun:",notnull" json:"owner_type"`
+	Balance             types.Money            `bun:",notnull,default:0" json:"balance"`
+	LowBalanceThreshold types.Money            `bun:",notnull,default:0" json:"low_balance_threshold"`
+	// org account the consumption of user is charged to
+	PayerUUID string              `bun:",nullzero" json:"payer_uuid"`
+	Status    types.AccountStatus `bun:",notnull" json:"status"`
+	times
+}
+
+// AccountStatement is a change of the balance of account
+type AccountStatement struct {
+	ID        int64                      `bun:",pk,autoincrement" json:"id"`
+	AccountID int64                      `bun:",notnull" json:"account_id"`
+	EventUUID uuid.UUID                  `bun:"type:uuid,notnull" json:"event_uuid"`
+	Type      types.AccountStatementType `bun:",notnull" json:"type"`
+	// user the consumption belongs to, it's a member for the account of org
+	UserUUID     string          `bun:",nullzero" json:"user_uuid"`
+	Scene        types.SceneType `bun:",notnull,default:0" json:"scene"`
+	ResourceID   string          `bun:",nullzero" json:"resource_id"`
+	ResourceName string          `bun:",nullzero" json:"resource_name"`
+	CustomerID   string          `bun:",nullzero" json:"customer_id"`
+	Value        float64         `bun:",notnull,default:0" json:"value"`
+	UnitType     string          `bun:",nullzero" json:"unit_type"`
+	// negative for charge
+	Amount       types.Money `bun:",notnull" json:"amount"`
+	BalanceAfter types.Money `bun:",notnull" json:"balance_after"`
+	Description  string      `bun:",nullzero" json:"description"`
+	OpUID        string      `bun:",nullzero" json:"op_uid"`
+	RecordedAt   time.Time   `bun:",notnull" json:"recorded_at"`
+	times
+}
+
+func (s *accountUserStoreImpl) FindByOwnerUUID(ctx context.Context, ownerUUID string) (*AccountUser, error) {
+	var account AccountUser
+	err := s.db.Operator.Core.NewSelect().Model(&account).Where("owner_uuid = ?", ownerUUID).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (s *accountUserStoreImpl) FindOrCreate(ctx context.Context, ownerUUID string, ownerType types.AccountOwnerType) (*AccountUser, error) {
+	account := AccountUser{
+		OwnerUUID: ownerUUID,
+		OwnerType: ownerType,
+		Status:    types.AccountStatusNormal,
+	}
+	_, err := s.db.Operator.Core.NewInsert().
+		Model(&account).
+		On("CONFLICT (owner_uuid) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account of %s, error: %w", ownerUUID, err)
+	}
+	return s.FindByOwnerUUID(ctx, ownerUUID)
+}
+
+func (s *accountUserStoreImpl) List(ctx context.Context, per, page int) ([]AccountUser, int, error) {
+	var accounts []AccountUser
+	total, err := s.db.Operator.Core.NewSelect().
+		Model(&accounts).
+		Order("id ASC").
+		Limit(per).
+		Offset((page - 1) * per).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return accounts, total, nil
+}
+
+func (s *accountUserStoreImpl) ListAll(ctx context.Context) ([]AccountUser, error) {
+	var accounts []AccountUser
+	err := s.db.Operator.Core.NewSelect().Model(&accounts).Order("id ASC").Scan(ctx)
+	return accounts, err
+}
+
+func (s *accountUserStoreImpl) ListByPayer(ctx context.Context, payerUUID string) ([]AccountUser, error) {
+	var accounts []AccountUser
+	err := s.db.Operator.Core.NewSelect().Model(&accounts).Where("payer_uuid = ?", payerUUID).Scan(ctx)
+	return accounts, err
+}
+
+func (s *accountUserStoreImpl) Update(ctx context.Context, account *AccountUser, columns ...string) error {
+	account.UpdatedAt = time.Now()
+	query := s.db.Operator.Core.NewUpdate().
+		Model(account).
+		WherePK()
+	if len(columns) > 0 {
+		query = query.Column(append(columns, "updated_at")...)
+	} else {
+		query = query.ExcludeColumn("created_at")
+	}
+	return assertAffectedOneRow(query.Exec(ctx))
+}
+
+func (s *accountUserStoreImpl) ApplyStatement(ctx context.Context, accountID int64, statement *AccountStatement) (*AccountUser, *AccountUser, bool, error) {
+	var before, after AccountUser
+	applied := false
+	err := s.db.Operator.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().Model(&before).Where("id = ?", accountID).For("UPDATE").Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to lock account %d, error: %w", accountID, err)
+		}
+		after = before
+		after.Balance += statement.Amount
+		after.Status = types.AccountStatusOf(after.Balance, after.LowBalanceThreshold)
+		after.UpdatedAt = time.Now()
+
+		statement.AccountID = accountID
+		statement.BalanceAfter = after.Balance
+		res, err := tx.NewInsert().
+			Model(statement).
+			On("CONFLICT (event_uuid) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to save account statement, error: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			// the event has been applied
+			after = before
+			return nil
+		}
+		applied = true
+		return assertAffectedOneRow(tx.NewUpdate().
+			Model(&after).
+			WherePK().
+			Column("balance", "status", "updated_at").
+			Exec(ctx))
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return &before, &after, applied, nil
+}
+
+func (s *accountUserStoreImpl) ListStatements(ctx context.Context, accountID int64, startTime, endTime time.Time, per, page int) ([]AccountStatement, int, error) {
+	var statements []AccountStatement
+	total, err := s.db.Operator.Core.NewSelect().
+		Model(&statements).
+		Where("account_id = ?", accountID).
+		Where("recorded_at >= ? AND recorded_at < ?", startTime, endTime).
+		Order("recorded_at DESC", "id DESC").
+		Limit(per).
+		Offset((page - 1) * per).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return statements, total, nil
+}
+
+func (s *accountUserStoreImpl) SumBills(ctx context.Context, accountID int64, startTime, endTime time.Time) ([]types.AccountBill, error) {
+	var bills []types.AccountBill
+	err := s.db.Operator.Core.NewSelect().
+		Model((*AccountStatement)(nil)).
+		ColumnExpr("scene, resource_id, MAX(resource_name) AS resource_name, customer_id, unit_type").
+		ColumnExpr("SUM(value) AS value, -SUM(amount)::BIGINT AS amount").
+		Where("account_id = ? AND type = ?", accountID, types.AccountStatementCharge).
+		Where("recorded_at >= ? AND recorded_at < ?", startTime, endTime).
+		Group("scene", "resource_id", "customer_id", "unit_type").
+		Order("scene ASC", "resource_id ASC", "customer_id ASC").
+		Scan(ctx, &bills)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return bills, nil
+}
+
+func (s *accountUserStoreImpl) SumRecharged(ctx context.Context, accountID int64, startTime, endTime time.Time) (types.Money, error) {
+	var recharged types.Money
+	err := s.db.Operator.Core.NewSelect().
+		Model((*AccountStatement)(nil)).
+		ColumnExpr("COALESCE(SUM(amount), 0)::BIGINT").
+		Where("account_id = ? AND type IN (?)", accountID, bun.In([]types.AccountStatementType{
+			types.AccountStatementRecharge, types.AccountStatementCredit,
+		})).
+		Where("recorded_at >= ? AND recorded_at < ?", startTime, endTime).
+		Scan(ctx, &recharged)
+	return recharged, err
+}
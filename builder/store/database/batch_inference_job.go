@@ -0,0 +1,201 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type batchInferenceJobStoreImpl struct {
+	db *DB
+}
+
+type BatchInferenceJobStore interface {
+	Create(ctx context.Context, job BatchInferenceJob) (*BatchInferenceJob, error)
+	FindByID(ctx context.Context, id int64) (*BatchInferenceJob, error)
+	ListByUserID(ctx context.Context, userID int64, per, page int) ([]BatchInferenceJob, int, error)
+	// Update saves the columns of job, all columns are saved if columns is empty
+	Update(ctx context.Context, job *BatchInferenceJob, columns ...string) error
+	// ClaimUnfinished leases an unfinished job not leased by any runner, so that it is not run by other runners
+	// at the same time, nil is returned if there is no such job
+	ClaimUnfinished(ctx context.Context, lease time.Duration) (*BatchInferenceJob, error)
+	// SaveProgress saves the results of rows and the progress columns of job in a transaction
+	SaveProgress(ctx context.Context, job *BatchInferenceJob, results []BatchInferenceResult) error
+	// ListResults returns at most limit results of the job ordered by row index, from the row index offset
+	ListResults(ctx context.Context, jobID int64, offset, limit int) ([]BatchInferenceResult, error)
+}
+
+func NewBatchInferenceJobStore() BatchInferenceJobStore {
+	return &batchInferenceJobStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// BatchInferenceJob calls a deployed model with every row of a dataset file and writes the outputs to a dataset repo
+type BatchInferenceJob struct {
+	ID            int64       `bun:",pk,autoincrement" json:"id"`
+	UserID        int64       `bun:",notnull" json:"user_id"`
+	User          *User       `bun:"rel:belongs-to,join:user_id=id" json:"user"`
+	DeployID      int64       `bun:",notnull" json:"deploy_id"`
+	DatasetRepoID int64       `bun:",notnull" json:"dataset_repo_id"`
+	DatasetRepo   *Repository `bun:"rel:belongs-to,join:dataset_repo_id=id" json:"dataset_repo"`
+	Revision      string      `bun:",notnull" json:"revision"`
+	// parquet or jsonl file to read rows from
+	InputFile    string  `bun:",notnull" json:"input_file"`
+	InputColumn  string  `bun:",notnull" json:"input_column"`
+	SystemPrompt string  `bun:",nullzero" json:"system_prompt"`
+	MaxTokens    int     `bun:",nullzero" json:"max_tokens"`
+	Temperature  float64 `bun:",notnull,default:0" json:"temperature"`
+	// dataset repo and branch to write outputs to, the repo is the input dataset for branch output
+	OutputType      types.BatchInferenceOutputType `bun:",notnull" json:"output_type"`
+	OutputNamespace string                         `bun:",notnull" json:"output_namespace"`
+	OutputName      string                         `bun:",notnull" json:"output_name"`
+	OutputBranch    string                         `bun:",nullzero" json:"output_branch"`
+	// dataset repo created for dataset output
+	OutputRepoID     int64                         `bun:",nullzero" json:"output_repo_id"`
+	Status           types.BatchInferenceJobStatus `bun:",notnull" json:"status"`
+	Message          string                        `bun:",nullzero" json:"message"`
+	TotalRows        int                           `bun:",notnull,default:0" json:"total_rows"`
+	ProcessedRows    int                           `bun:",notnull,default:0" json:"processed_rows"`
+	FailedRows       int                           `bun:",notnull,default:0" json:"failed_rows"`
+	PromptTokens     int64                         `bun:",notnull,default:0" json:"prompt_tokens"`
+	CompletionTokens int64                         `bun:",notnull,default:0" json:"completion_tokens"`
+	// the job is run by a runner until the lease expires
+	LeaseUntil time.Time `bun:",nullzero" json:"lease_until"`
+	StartedAt  time.Time `bun:",nullzero" json:"started_at"`
+	FinishedAt time.Time `bun:",nullzero" json:"finished_at"`
+	times
+}
+
+// BatchInferenceResult is the model output of a row, kept until the outputs are written to the output repo
+type BatchInferenceResult struct {
+	ID       int64  `bun:",pk,autoincrement" json:"id"`
+	JobID    int64  `bun:",notnull" json:"job_id"`
+	RowIndex int    `bun:",notnull" json:"row_index"`
+	Input    string `bun:",notnull" json:"input"`
+	Output   string `bun:",notnull" json:"output"`
+	Error    string `bun:",nullzero" json:"error"`
+	times
+}
+
+var batchInferenceProgressColumns = []string{"total_rows", "processed_rows", "failed_rows",
+	"prompt_tokens", "completion_tokens", "lease_until"}
+
+func (s *batchInferenceJobStoreImpl) Create(ctx context.Context, job BatchInferenceJob) (*BatchInferenceJob, error) {
+	res, err := s.db.Operator.Core.NewInsert().Model(&job).Exec(ctx, &job)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create batch inference job, error: %w", err)
+	}
+	return &job, nil
+}
+
+func (s *batchInferenceJobStoreImpl) FindByID(ctx context.Context, id int64) (*BatchInferenceJob, error) {
+	var job BatchInferenceJob
+	err := s.db.Operator.Core.NewSelect().
+		Model(&job).
+		Relation("User").
+		Relation("DatasetRepo").
+		Where("batch_inference_job.id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *batchInferenceJobStoreImpl) ListByUserID(ctx context.Context, userID int64, per, page int) ([]BatchInferenceJob, int, error) {
+	var jobs []BatchInferenceJob
+	query := s.db.Operator.Core.NewSelect().
+		Model(&jobs).
+		Relation("User").
+		Relation("DatasetRepo").
+		Where("batch_inference_job.user_id = ?", userID).
+		Order("batch_inference_job.id DESC").
+		Limit(per).
+		Offset((page - 1) * per)
+	total, err := query.ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+func (s *batchInferenceJobStoreImpl) Update(ctx context.Context, job *BatchInferenceJob, columns ...string) error {
+	job.UpdatedAt = time.Now()
+	query := s.db.Operator.Core.NewUpdate().
+		Model(job).
+		WherePK()
+	if len(columns) > 0 {
+		query = query.Column(append(columns, "updated_at")...)
+	} else {
+		query = query.ExcludeColumn("created_at")
+	}
+	return assertAffectedOneRow(query.Exec(ctx))
+}
+
+func (s *batchInferenceJobStoreImpl) ClaimUnfinished(ctx context.Context, lease time.Duration) (*BatchInferenceJob, error) {
+	var jobs []BatchInferenceJob
+	now := time.Now()
+	unleased := s.db.Operator.Core.NewSelect().Model((*BatchInferenceJob)(nil)).
+		Column("id").
+		Where("status IN (?)", bun.In([]types.BatchInferenceJobStatus{
+			types.BatchInferenceJobPending, types.BatchInferenceJobRunning, types.BatchInferenceJobWriting,
+		})).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("lease_until IS NULL").WhereOr("lease_until <= ?", now)
+		}).
+		Order("id ASC").
+		Limit(1).
+		For("UPDATE SKIP LOCKED")
+	err := s.db.Operator.Core.NewUpdate().Model((*BatchInferenceJob)(nil)).
+		Set("lease_until = ?", now.Add(lease)).
+		Where("id IN (?)", unleased).
+		Returning("id").
+		Scan(ctx, &jobs)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return s.FindByID(ctx, jobs[0].ID)
+}
+
+func (s *batchInferenceJobStoreImpl) SaveProgress(ctx context.Context, job *BatchInferenceJob, results []BatchInferenceResult) error {
+	job.UpdatedAt = time.Now()
+	return s.db.Operator.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if len(results) > 0 {
+			// rows may be processed again if the job is resumed by another runner
+			_, err := tx.NewInsert().
+				Model(&results).
+				On("CONFLICT (job_id, row_index) DO UPDATE").
+				Set("input = EXCLUDED.input, output = EXCLUDED.output, error = EXCLUDED.error, updated_at = EXCLUDED.updated_at").
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to save batch inference results, error: %w", err)
+			}
+		}
+		return assertAffectedOneRow(tx.NewUpdate().
+			Model(job).
+			WherePK().
+			Column(append(batchInferenceProgressColumns, "updated_at")...).
+			Exec(ctx))
+	})
+}
+
+func (s *batchInferenceJobStoreImpl) ListResults(ctx context.Context, jobID int64, offset, limit int) ([]BatchInferenceResult, error) {
+	var results []BatchInferenceResult
+	err := s.db.Operator.Core.NewSelect().
+		Model(&results).
+		Where("job_id = ?", jobID).
+		Where("row_index >= ?", offset).
+		Order("row_index ASC").
+		Limit(limit).
+		Scan(ctx)
+	return results, err
+}
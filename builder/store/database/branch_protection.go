@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// BranchProtection is a protection rule of the branches matching the pattern of a repository
+type BranchProtection struct {
+	ID              int64  `bun:",pk,autoincrement" json:"id"`
+	RepositoryID    int64  `bun:",notnull" json:"repository_id"`
+	Pattern         string `bun:",notnull" json:"pattern"`
+	BlockForcePush  bool   `bun:",notnull" json:"block_force_push"`
+	BlockDeletion   bool   `bun:",notnull" json:"block_deletion"`
+	RequireAdmin    bool   `bun:",notnull" json:"require_admin"`
+	BlockDirectPush bool   `bun:",notnull" json:"block_direct_push"`
+	times
+}
+
+type branchProtectionStoreImpl struct {
+	db *DB
+}
+
+type BranchProtectionStore interface {
+	Create(ctx context.Context, protection BranchProtection) (*BranchProtection, error)
+	FindByID(ctx context.Context, id int64) (*BranchProtection, error)
+	ListByRepoID(ctx context.Context, repoID int64) ([]BranchProtection, error)
+	Update(ctx context.Context, protection BranchProtection) (*BranchProtection, error)
+	Delete(ctx context.Context, protection BranchProtection) error
+}
+
+func NewBranchProtectionStore() BranchProtectionStore {
+	return &branchProtectionStoreImpl{
+		db: defaultDB,
+	}
+}
+
+func (s *branchProtectionStoreImpl) Create(ctx context.Context, protection BranchProtection) (*BranchProtection, error) {
+	res, err := s.db.Core.NewInsert().Model(&protection).Exec(ctx)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create branch protection, error: %w", err)
+	}
+	return &protection, nil
+}
+
+func (s *branchProtectionStoreImpl) FindByID(ctx context.Context, id int64) (*BranchProtection, error) {
+	var protection BranchProtection
+	err := s.db.Core.NewSelect().Model(&protection).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &protection, nil
+}
+
+func (s *branchProtectionStoreImpl) ListByRepoID(ctx context.Context, repoID int64) ([]BranchProtection, error) {
+	var protections []BranchProtection
+	err := s.db.Core.NewSelect().Model(&protections).
+		Where("repository_id = ?", repoID).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return protections, nil
+}
+
+func (s *branchProtectionStoreImpl) Update(ctx context.Context, protection BranchProtection) (*BranchProtection, error) {
+	_, err := s.db.Core.NewUpdate().Model(&protection).WherePK().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &protection, nil
+}
+
+func (s *branchProtectionStoreImpl) Delete(ctx context.Context, protection BranchProtection) error {
+	_, err := s.db.Core.NewDelete().Model(&protection).WherePK().Exec(ctx)
+	return err
+}
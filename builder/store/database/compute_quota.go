@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type computeQuotaStoreImpl struct {
+	db *DB
+}
+
+type ComputeQuotaStore interface {
+	Create(ctx context.Context, quota ComputeQuota) (*ComputeQuota, error)
+	Index(ctx context.Context, per, page int) ([]ComputeQuota, int, error)
+	Get(ctx context.Context, id int64) (*ComputeQuota, error)
+	FindByNamespace(ctx context.Context, namespace string) (*ComputeQuota, error)
+	Update(ctx context.Context, quota ComputeQuota) (*ComputeQuota, error)
+	Delete(ctx context.Context, quota ComputeQuota) error
+	// ListActiveDeploysByUser lists the deploys other than spaces started by the user which are holding resources
+	ListActiveDeploysByUser(ctx context.Context, userID int64) ([]Deploy, error)
+	// ListActiveDeploysByNamespace lists the deploys of repos in the namespace which are holding resources
+	ListActiveDeploysByNamespace(ctx context.Context, namespace string) ([]Deploy, error)
+	// SumMinutesByUser sums the minutes of the scene metered to the user since the given time
+	SumMinutesByUser(ctx context.Context, userUUID string, scene types.SceneType, since time.Time) (float64, error)
+	// SumMinutesByNamespace sums the minutes of the scene metered to deploys of repos in the namespace since the given time
+	SumMinutesByNamespace(ctx context.Context, namespace string, scene types.SceneType, since time.Time) (float64, error)
+	// LockNamespace blocks until no other caller holds the lock of the namespace, the returned func releases it
+	LockNamespace(ctx context.Context, namespace string) (func(), error)
+}
+
+func NewComputeQuotaStore() ComputeQuotaStore {
+	return &computeQuotaStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// ComputeQuota limits the compute resources used by a user or an organization, a nil limit means unlimited
+type ComputeQuota struct {
+	ID int64 `bun:",pk,autoincrement" json:"id"`
+	// path of the user or organization namespace
+	Namespace           string `bun:",notnull,unique" json:"namespace"`
+	MaxGPUs             *int   `bun:",nullzero" json:"max_gpus"`
+	MaxRunningInstances *int   `bun:",nullzero" json:"max_running_instances"`
+	// max minutes per calendar month of each scene, scenes not in the map are unlimited
+	MonthlyMinutes map[types.SceneType]int64 `bun:",type:jsonb,nullzero" json:"monthly_minutes"`
+	times
+}
+
+// inactiveDeployStatuses are the statuses of deploys that do not hold any resources
+var inactiveDeployStatuses = []int{
+	common.BuildFailed,
+	common.DeployFailed,
+	common.RunTimeError,
+	common.Sleeping,
+	common.Stopped,
+	common.Deleted,
+}
+
+func (s *computeQuotaStoreImpl) Create(ctx context.Context, quota ComputeQuota) (*ComputeQuota, error) {
+	res, err := s.db.Operator.Core.NewInsert().Model(&quota).Exec(ctx, &quota)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create compute quota, error: %w", err)
+	}
+	return &quota, nil
+}
+
+func (s *computeQuotaStoreImpl) Index(ctx context.Context, per, page int) ([]ComputeQuota, int, error) {
+	var quotas []ComputeQuota
+	query := s.db.Operator.Core.NewSelect().
+		Model(&quotas).
+		Order("namespace ASC").
+		Limit(per).
+		Offset((page - 1) * per)
+	total, err := query.ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return quotas, total, nil
+}
+
+func (s *computeQuotaStoreImpl) Get(ctx context.Context, id int64) (*ComputeQuota, error) {
+	var quota ComputeQuota
+	err := s.db.Operator.Core.NewSelect().
+		Model(&quota).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func (s *computeQuotaStoreImpl) FindByNamespace(ctx context.Context, namespace string) (*ComputeQuota, error) {
+	var quota ComputeQuota
+	err := s.db.Operator.Core.NewSelect().
+		Model(&quota).
+		Where("namespace = ?", namespace).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func (s *computeQuotaStoreImpl) Update(ctx context.Context, quota ComputeQuota) (*ComputeQuota, error) {
+	quota.UpdatedAt = time.Now()
+	err := assertAffectedOneRow(s.db.Operator.Core.NewUpdate().
+		Model(&quota).
+		Column("max_gpus", "max_running_instances", "monthly_minutes", "updated_at").
+		WherePK().
+		Exec(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update compute quota, error: %w", err)
+	}
+	return &quota, nil
+}
+
+func (s *computeQuotaStoreImpl) Delete(ctx context.Context, quota ComputeQuota) error {
+	_, err := s.db.Operator.Core.NewDelete().
+		Model(&quota).
+		WherePK().
+		Exec(ctx)
+	return err
+}
+
+func (s *computeQuotaStoreImpl) ListActiveDeploysByUser(ctx context.Context, userID int64) ([]Deploy, error) {
+	var deploys []Deploy
+	err := s.db.Operator.Core.NewSelect().
+		Model(&deploys).
+		Where("user_id = ?", userID).
+		Where("type <> ?", types.SpaceType).
+		Where("status NOT IN (?)", bun.In(inactiveDeployStatuses)).
+		Scan(ctx)
+	return deploys, err
+}
+
+func (s *computeQuotaStoreImpl) ListActiveDeploysByNamespace(ctx context.Context, namespace string) ([]Deploy, error) {
+	var deploys []Deploy
+	err := s.db.Operator.Core.NewSelect().
+		Model(&deploys).
+		Join("JOIN repositories AS repository ON repository.id = deploy.repo_id").
+		Where("repository.path LIKE ? ESCAPE '\\'", namespacePattern(namespace)).
+		Where("deploy.status NOT IN (?)", bun.In(inactiveDeployStatuses)).
+		Scan(ctx)
+	return deploys, err
+}
+
+func (s *computeQuotaStoreImpl) SumMinutesByUser(ctx context.Context, userUUID string, scene types.SceneType, since time.Time) (float64, error) {
+	var minutes float64
+	err := s.db.Operator.Core.NewSelect().
+		Model((*AccountMetering)(nil)).
+		ColumnExpr("COALESCE(SUM(value), 0)").
+		Where("user_uuid = ?", userUUID).
+		Where("value_type = ?", types.TimeDurationMinType).
+		Where("scene = ?", scene).
+		Where("recorded_at >= ?", since).
+		Scan(ctx, &minutes)
+	return minutes, err
+}
+
+func (s *computeQuotaStoreImpl) SumMinutesByNamespace(ctx context.Context, namespace string, scene types.SceneType, since time.Time) (float64, error) {
+	var minutes float64
+	err := s.db.Operator.Core.NewSelect().
+		Model((*AccountMetering)(nil)).
+		ColumnExpr("COALESCE(SUM(account_metering.value), 0)").
+		Join("JOIN deploys AS deploy ON deploy.svc_name = account_metering.customer_id").
+		Join("JOIN repositories AS repository ON repository.id = deploy.repo_id").
+		Where("repository.path LIKE ? ESCAPE '\\'", namespacePattern(namespace)).
+		Where("account_metering.value_type = ?", types.TimeDurationMinType).
+		Where("account_metering.scene = ?", scene).
+		Where("account_metering.recorded_at >= ?", since).
+		Scan(ctx, &minutes)
+	return minutes, err
+}
+
+// LockNamespace takes a postgres advisory lock on a connection of its own, so that the lock
+// is held across the queries of the caller and between api server replicas
+func (s *computeQuotaStoreImpl) LockNamespace(ctx context.Context, namespace string) (func(), error) {
+	conn, err := s.db.BunDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection, error: %w", err)
+	}
+	key := "compute_quota:" + namespace
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext(?))", key)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to lock namespace %s, error: %w", namespace, err)
+	}
+	return func() {
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext(?))", key)
+		if err != nil {
+			slog.Error("failed to unlock namespace", slog.String("namespace", namespace), slog.Any("error", err))
+			// the lock is released with the session, drop the connection instead of returning it to the pool
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			return
+		}
+		conn.Close()
+	}, nil
+}
+
+// namespacePattern returns the LIKE pattern matching the paths of repos in the namespace
+func namespacePattern(namespace string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(namespace) + "/%"
+}
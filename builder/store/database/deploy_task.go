@@ -47,6 +47,10 @@ type Deploy struct {
 	Type             int    `json:"type"`         // 0-space, 1-inference, 2-finetune, 3-serverless
 	UserUUID         string `bun:"," json:"user_uuid"`
 	SKU              string `bun:"," json:"sku"`
+	// autoscaling policy of inference endpoint, the runner default is used if it's nil
+	Autoscaling *types.AutoscalingPolicy `bun:",type:jsonb,nullzero" json:"autoscaling"`
+	// time of the last request through the endpoint proxy, flushed periodically by deployer
+	LastRequestAt time.Time `bun:",nullzero" json:"last_request_at"`
 	times
 }
 
@@ -58,6 +62,9 @@ type DeployTask struct {
 	Message  string  `bun:",nullzero" json:"message"`
 	DeployID int64   `bun:",notnull" json:"deploy_id"`
 	Deploy   *Deploy `bun:"rel:belongs-to,join:deploy_id=id" json:"deploy"`
+	// Priority is set by admin to move the task up or down in the queue of scheduler,
+	// it adds to the priority of deploy type
+	Priority int `bun:",notnull,default:0" json:"priority"`
 	times
 }
 
@@ -77,6 +84,9 @@ type DeployTaskStore interface {
 	GetNewTaskAfter(ctx context.Context, currentDeployTaskID int64) (*DeployTask, error)
 	// GetNewTaskFirst returns the first task which has  not end
 	GetNewTaskFirst(ctx context.Context) (*DeployTask, error)
+	// ListUnfinishedTasks returns the tasks which have not end in the order of creation
+	ListUnfinishedTasks(ctx context.Context) ([]*DeployTask, error)
+	UpdateTaskPriority(ctx context.Context, id int64, priority int) error
 	UpdateInTx(ctx context.Context, deployColumns, deployTaskColumns []string, deploy *Deploy, deployTasks ...*DeployTask) error
 	ListDeploy(ctx context.Context, repoType types.RepositoryType, repoID, userID int64) ([]Deploy, error)
 	DeleteDeploy(ctx context.Context, repoType types.RepositoryType, repoID, userID int64, deployID int64) error
@@ -88,6 +98,17 @@ type DeployTaskStore interface {
 	GetServerlessDeployByRepID(ctx context.Context, repoID int64) (*Deploy, error)
 	ListServerless(ctx context.Context, req types.DeployReq) ([]Deploy, int, error)
 	ListAllDeployments(ctx context.Context, userID int64) ([]Deploy, error)
+	// SetLastRequestAt moves the last request time of deploy forward, an earlier time is ignored
+	SetLastRequestAt(ctx context.Context, deployID int64, lastRequestAt time.Time) error
+	// ListIdleDeploys returns the running inference and serverless deploys which got no request
+	// for the scale to zero idle minutes of their autoscaling policy
+	ListIdleDeploys(ctx context.Context, now time.Time) ([]Deploy, error)
+	// UpdateDeployStatusIf changes the status of deploy only if it's still in status from,
+	// it returns false if the status has been changed by others
+	UpdateDeployStatusIf(ctx context.Context, deployID int64, from, to int) (bool, error)
+	// ListServingEndpoints returns the inference and serverless deploys which are running, sleeping or
+	// starting, the deploys of all models are returned if repoID is 0
+	ListServingEndpoints(ctx context.Context, repoID int64) ([]Deploy, error)
 }
 
 func NewDeployTaskStore() DeployTaskStore {
@@ -158,6 +179,24 @@ func (s *deployTaskStoreImpl) GetNewTaskFirst(ctx context.Context) (*DeployTask,
 	return deployTask, err
 }
 
+func (s *deployTaskStoreImpl) ListUnfinishedTasks(ctx context.Context) ([]*DeployTask, error) {
+	var deployTasks []*DeployTask
+	err := s.db.Core.NewSelect().Model(&deployTasks).Relation("Deploy").
+		Where("(task_type = 0 and deploy_task.status in (0,1)) or (task_type = 1 and deploy_task.status in (0,1,3))").
+		Order("id ASC").
+		Scan(ctx)
+	return deployTasks, err
+}
+
+func (s *deployTaskStoreImpl) UpdateTaskPriority(ctx context.Context, id int64, priority int) error {
+	res, err := s.db.Core.NewUpdate().Model((*DeployTask)(nil)).
+		Set("priority = ?", priority).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id).
+		Exec(ctx)
+	return assertAffectedOneRow(res, err)
+}
+
 func (s *deployTaskStoreImpl) UpdateInTx(ctx context.Context, deployColumns, deployTaskColumns []string, deploy *Deploy, deployTasks ...*DeployTask) error {
 	tx, err := s.db.Core.BeginTx(ctx, nil)
 	if err != nil {
@@ -319,3 +358,58 @@ func (s *deployTaskStoreImpl) ListAllDeployments(ctx context.Context, userID int
 
 	return result, err
 }
+
+func (s *deployTaskStoreImpl) SetLastRequestAt(ctx context.Context, deployID int64, lastRequestAt time.Time) error {
+	_, err := s.db.Operator.Core.NewUpdate().
+		Model((*Deploy)(nil)).
+		Set("last_request_at = ?", lastRequestAt).
+		Where("id = ?", deployID).
+		WhereGroup(" AND ", func(q *bun.UpdateQuery) *bun.UpdateQuery {
+			return q.Where("last_request_at IS NULL").WhereOr("last_request_at < ?", lastRequestAt)
+		}).
+		Exec(ctx)
+	return err
+}
+
+func (s *deployTaskStoreImpl) ListIdleDeploys(ctx context.Context, now time.Time) ([]Deploy, error) {
+	var result []Deploy
+	err := s.db.Operator.Core.NewSelect().
+		Model(&result).
+		Where("status = ?", common.Running).
+		Where("type IN (?)", bun.In([]int{types.InferenceType, types.ServerlessType})).
+		Where("(autoscaling->>'scale_to_zero_idle_minutes')::int > 0").
+		// a deploy just started or woken up is not idle even if its last request is old
+		Where("GREATEST(last_request_at, updated_at) < ?::timestamptz - make_interval(mins => (autoscaling->>'scale_to_zero_idle_minutes')::int)", now).
+		Scan(ctx)
+	return result, err
+}
+
+func (s *deployTaskStoreImpl) ListServingEndpoints(ctx context.Context, repoID int64) ([]Deploy, error) {
+	var result []Deploy
+	query := s.db.Operator.Core.NewSelect().
+		Model(&result).
+		Where("type IN (?)", bun.In([]int{types.InferenceType, types.ServerlessType})).
+		Where("status IN (?)", bun.In([]int{common.Pending, common.Deploying, common.Startup, common.Running, common.Sleeping}))
+	if repoID > 0 {
+		query = query.Where("repo_id = ?", repoID)
+	}
+	err := query.Order("id ASC").Scan(ctx)
+	return result, err
+}
+
+func (s *deployTaskStoreImpl) UpdateDeployStatusIf(ctx context.Context, deployID int64, from, to int) (bool, error) {
+	res, err := s.db.Operator.Core.NewUpdate().
+		Model((*Deploy)(nil)).
+		Set("status = ?", to).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ? AND status = ?", deployID, from).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+type finetuneJobStoreImpl struct {
+	db *DB
+}
+
+type FinetuneJobStore interface {
+	Create(ctx context.Context, job FinetuneJob) (*FinetuneJob, error)
+	FindByID(ctx context.Context, id int64) (*FinetuneJob, error)
+	ListByUserID(ctx context.Context, userID int64, per, page int) ([]FinetuneJob, int, error)
+	// Update saves the columns of job, all columns are saved if columns is empty
+	Update(ctx context.Context, job *FinetuneJob, columns ...string) error
+	CreateCheckpoint(ctx context.Context, checkpoint FinetuneCheckpoint) (*FinetuneCheckpoint, error)
+	ListCheckpoints(ctx context.Context, jobID int64) ([]FinetuneCheckpoint, error)
+}
+
+func NewFinetuneJobStore() FinetuneJobStore {
+	return &finetuneJobStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// FinetuneJob trains a base model on a dataset and pushes the weights to a new model repo
+type FinetuneJob struct {
+	ID            int64       `bun:",pk,autoincrement" json:"id"`
+	UserID        int64       `bun:",notnull" json:"user_id"`
+	User          *User       `bun:"rel:belongs-to,join:user_id=id" json:"user"`
+	ModelRepoID   int64       `bun:",notnull" json:"model_repo_id"`
+	ModelRepo     *Repository `bun:"rel:belongs-to,join:model_repo_id=id" json:"model_repo"`
+	Revision      string      `bun:",notnull" json:"revision"`
+	DatasetRepoID int64       `bun:",notnull" json:"dataset_repo_id"`
+	DatasetRepo   *Repository `bun:"rel:belongs-to,join:dataset_repo_id=id" json:"dataset_repo"`
+	// hyperparameters passed to the training container
+	Hyperparameters types.FinetuneHyperparameters `bun:",type:jsonb,notnull" json:"hyperparameters"`
+	Status          types.FinetuneJobStatus       `bun:",notnull" json:"status"`
+	Message         string                        `bun:",nullzero" json:"message"`
+	// deploy running the training container
+	DeployID        int64  `bun:",nullzero" json:"deploy_id"`
+	OutputNamespace string `bun:",notnull" json:"output_namespace"`
+	OutputName      string `bun:",notnull" json:"output_name"`
+	// model repo created when training is done
+	OutputRepoID int64 `bun:",nullzero" json:"output_repo_id"`
+	// secret passed to the training container only, events reported without it are rejected
+	EventToken   string `bun:",nullzero" json:"-"`
+	CurrentEpoch int    `bun:",notnull,default:0" json:"current_epoch"`
+	CurrentStep  int    `bun:",notnull,default:0" json:"current_step"`
+	// metrics of the latest progress reported
+	Metrics    map[string]float64 `bun:",type:jsonb,nullzero" json:"metrics"`
+	StartedAt  time.Time          `bun:",nullzero" json:"started_at"`
+	FinishedAt time.Time          `bun:",nullzero" json:"finished_at"`
+	times
+}
+
+// FinetuneCheckpoint is saved by the training container during training
+type FinetuneCheckpoint struct {
+	ID    int64 `bun:",pk,autoincrement" json:"id"`
+	JobID int64 `bun:",notnull" json:"job_id"`
+	Epoch int   `bun:",notnull" json:"epoch"`
+	Step  int   `bun:",notnull" json:"step"`
+	// directory the checkpoint saved in
+	Name    string             `bun:",notnull" json:"name"`
+	Metrics map[string]float64 `bun:",type:jsonb,nullzero" json:"metrics"`
+	times
+}
+
+func (s *finetuneJobStoreImpl) Create(ctx context.Context, job FinetuneJob) (*FinetuneJob, error) {
+	res, err := s.db.Operator.Core.NewInsert().Model(&job).Exec(ctx, &job)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create finetune job, error: %w", err)
+	}
+	return &job, nil
+}
+
+func (s *finetuneJobStoreImpl) FindByID(ctx context.Context, id int64) (*FinetuneJob, error) {
+	var job FinetuneJob
+	err := s.db.Operator.Core.NewSelect().
+		Model(&job).
+		Relation("User").
+		Relation("ModelRepo").
+		Relation("DatasetRepo").
+		Where("finetune_job.id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *finetuneJobStoreImpl) ListByUserID(ctx context.Context, userID int64, per, page int) ([]FinetuneJob, int, error) {
+	var jobs []FinetuneJob
+	query := s.db.Operator.Core.NewSelect().
+		Model(&jobs).
+		Relation("User").
+		Relation("ModelRepo").
+		Relation("DatasetRepo").
+		Where("finetune_job.user_id = ?", userID).
+		Order("finetune_job.id DESC").
+		Limit(per).
+		Offset((page - 1) * per)
+	total, err := query.ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+func (s *finetuneJobStoreImpl) Update(ctx context.Context, job *FinetuneJob, columns ...string) error {
+	job.UpdatedAt = time.Now()
+	query := s.db.Operator.Core.NewUpdate().
+		Model(job).
+		WherePK()
+	if len(columns) > 0 {
+		query = query.Column(append(columns, "updated_at")...)
+	} else {
+		query = query.ExcludeColumn("created_at")
+	}
+	return assertAffectedOneRow(query.Exec(ctx))
+}
+
+func (s *finetuneJobStoreImpl) CreateCheckpoint(ctx context.Context, checkpoint FinetuneCheckpoint) (*FinetuneCheckpoint, error) {
+	res, err := s.db.Operator.Core.NewInsert().Model(&checkpoint).Exec(ctx, &checkpoint)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create finetune checkpoint, error: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+func (s *finetuneJobStoreImpl) ListCheckpoints(ctx context.Context, jobID int64) ([]FinetuneCheckpoint, error) {
+	var checkpoints []FinetuneCheckpoint
+	err := s.db.Operator.Core.NewSelect().
+		Model(&checkpoints).
+		Where("job_id = ?", jobID).
+		Order("id ASC").
+		Scan(ctx)
+	return checkpoints, err
+}
@@ -17,6 +17,11 @@ type LfsMetaObjectStore interface {
 	RemoveByOid(ctx context.Context, oid string, repoID int64) error
 	UpdateOrCreate(ctx context.Context, input LfsMetaObject) (*LfsMetaObject, error)
 	BulkUpdateOrCreate(ctx context.Context, input []LfsMetaObject) error
+	UpdateStorageID(ctx context.Context, id, storageID int64) error
+	// ExistsInStorage checks whether any repository still references the object stored in the storage
+	ExistsInStorage(ctx context.Context, oid string, storageID int64) (bool, error)
+	ListByOid(ctx context.Context, oid string) ([]LfsMetaObject, error)
+	RemoveByOidAndStorage(ctx context.Context, oid string, storageID int64) error
 }
 
 func NewLfsMetaObjectStore() LfsMetaObjectStore {
@@ -32,6 +37,8 @@ type LfsMetaObject struct {
 	RepositoryID int64      `bun:",notnull" json:"repository_id"`
 	Repository   Repository `bun:"rel:belongs-to,join:repository_id=id" json:"repository"`
 	Existing     bool       `bun:",notnull" json:"existing"`
+	// StorageID is the id of the lfs storage holding the object, 0 means the default storage in config
+	StorageID int64 `bun:",notnull,default:0" json:"storage_id"`
 	times
 }
 
@@ -108,3 +115,40 @@ func (s *lfsMetaObjectStoreImpl) BulkUpdateOrCreate(ctx context.Context, input [
 		Exec(ctx)
 	return err
 }
+
+func (s *lfsMetaObjectStoreImpl) UpdateStorageID(ctx context.Context, id, storageID int64) error {
+	_, err := s.db.Core.NewUpdate().
+		Model((*LfsMetaObject)(nil)).
+		Set("storage_id = ?", storageID).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+func (s *lfsMetaObjectStoreImpl) ExistsInStorage(ctx context.Context, oid string, storageID int64) (bool, error) {
+	return s.db.Core.NewSelect().
+		Model((*LfsMetaObject)(nil)).
+		Where("oid = ? and storage_id = ?", oid, storageID).
+		Exists(ctx)
+}
+
+func (s *lfsMetaObjectStoreImpl) ListByOid(ctx context.Context, oid string) ([]LfsMetaObject, error) {
+	var lfsMetaObjects []LfsMetaObject
+	err := s.db.Core.NewSelect().
+		Model(&lfsMetaObjects).
+		Where("oid = ?", oid).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lfsMetaObjects, nil
+}
+
+func (s *lfsMetaObjectStoreImpl) RemoveByOidAndStorage(ctx context.Context, oid string, storageID int64) error {
+	_, err := s.db.Core.NewDelete().
+		Model((*LfsMetaObject)(nil)).
+		Where("oid = ? and storage_id = ?", oid, storageID).
+		Exec(ctx)
+	return err
+}
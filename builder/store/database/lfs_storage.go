@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// LfsStorage is an s3 compatible storage for lfs objects besides the default one in config
+type LfsStorage struct {
+	ID               int64  `bun:",pk,autoincrement" json:"id"`
+	Name             string `bun:",notnull,unique" json:"name"`
+	Endpoint         string `bun:",notnull" json:"endpoint"`
+	InternalEndpoint string `bun:"," json:"internal_endpoint"`
+	AccessKeyID      string `bun:",notnull" json:"access_key_id"`
+	AccessKeySecret  string `bun:",notnull" json:"-"`
+	Region           string `bun:"," json:"region"`
+	Bucket           string `bun:",notnull" json:"bucket"`
+	EnableSSL        bool   `bun:",notnull" json:"enable_ssl"`
+	times
+}
+
+// LfsStorageRoute routes the lfs objects uploaded to repositories of a namespace to a storage
+type LfsStorageRoute struct {
+	ID        int64       `bun:",pk,autoincrement" json:"id"`
+	Namespace string      `bun:",notnull,unique" json:"namespace"`
+	StorageID int64       `bun:",notnull" json:"storage_id"`
+	Storage   *LfsStorage `bun:"rel:belongs-to,join:storage_id=id" json:"storage"`
+	times
+}
+
+type lfsStorageStoreImpl struct {
+	db *DB
+}
+
+type LfsStorageStore interface {
+	Create(ctx context.Context, storage LfsStorage) (*LfsStorage, error)
+	FindByID(ctx context.Context, id int64) (*LfsStorage, error)
+	FindByName(ctx context.Context, name string) (*LfsStorage, error)
+	// FindByNamespace returns the storage the namespace is routed to
+	FindByNamespace(ctx context.Context, namespace string) (*LfsStorage, error)
+	List(ctx context.Context) ([]LfsStorage, error)
+	// SetRoute routes the namespace to the storage, storageID 0 routes it back to the default storage
+	SetRoute(ctx context.Context, namespace string, storageID int64) error
+}
+
+func NewLfsStorageStore() LfsStorageStore {
+	return &lfsStorageStoreImpl{
+		db: defaultDB,
+	}
+}
+
+func (s *lfsStorageStoreImpl) Create(ctx context.Context, storage LfsStorage) (*LfsStorage, error) {
+	res, err := s.db.Core.NewInsert().Model(&storage).Exec(ctx)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create lfs storage, error: %w", err)
+	}
+	return &storage, nil
+}
+
+func (s *lfsStorageStoreImpl) FindByID(ctx context.Context, id int64) (*LfsStorage, error) {
+	var storage LfsStorage
+	err := s.db.Core.NewSelect().Model(&storage).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &storage, nil
+}
+
+func (s *lfsStorageStoreImpl) FindByName(ctx context.Context, name string) (*LfsStorage, error) {
+	var storage LfsStorage
+	err := s.db.Core.NewSelect().Model(&storage).
+		Where("name = ?", name).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &storage, nil
+}
+
+func (s *lfsStorageStoreImpl) FindByNamespace(ctx context.Context, namespace string) (*LfsStorage, error) {
+	var route LfsStorageRoute
+	err := s.db.Core.NewSelect().Model(&route).
+		Relation("Storage").
+		Where("lfs_storage_route.namespace = ?", namespace).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return route.Storage, nil
+}
+
+func (s *lfsStorageStoreImpl) List(ctx context.Context) ([]LfsStorage, error) {
+	var storages []LfsStorage
+	err := s.db.Core.NewSelect().Model(&storages).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storages, nil
+}
+
+func (s *lfsStorageStoreImpl) SetRoute(ctx context.Context, namespace string, storageID int64) error {
+	if storageID == 0 {
+		_, err := s.db.Core.NewDelete().Model((*LfsStorageRoute)(nil)).
+			Where("namespace = ?", namespace).
+			Exec(ctx)
+		return err
+	}
+	route := LfsStorageRoute{
+		Namespace: namespace,
+		StorageID: storageID,
+	}
+	_, err := s.db.Core.NewInsert().Model(&route).
+		On("CONFLICT (namespace) DO UPDATE").
+		Set("storage_id = EXCLUDED.storage_id, updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	return err
+}
@@ -15,6 +15,8 @@ type MemberStore interface {
 	Delete(ctx context.Context, orgID, userID int64, role string) error
 	UserMembers(ctx context.Context, userID int64) ([]Member, error)
 	OrganizationMembers(ctx context.Context, orgID int64, pageSize, page int) ([]Member, int, error)
+	// ListByRole lists the members of organization in the role
+	ListByRole(ctx context.Context, orgID int64, role string) ([]Member, error)
 }
 
 func NewMemberStore() MemberStore {
@@ -86,3 +88,13 @@ func (s *memberStoreImpl) OrganizationMembers(ctx context.Context, orgID int64,
 	}
 	return members, total, nil
 }
+
+func (s *memberStoreImpl) ListByRole(ctx context.Context, orgID int64, role string) ([]Member, error) {
+	var members []Member
+	err := s.db.Core.NewSelect().
+		Model(&members).
+		Where("organization_id = ? AND role = ?", orgID, role).
+		Order("id ASC").
+		Scan(ctx)
+	return members, err
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PullRequest struct {
+	ID            int64     `bun:",pk,autoincrement" json:"id"`
+	Number        int64     `bun:",notnull" json:"number"`
+	RepositoryID  int64     `bun:",notnull" json:"repository_id"`
+	SourceRepoID  int64     `bun:",notnull" json:"source_repo_id"`
+	SourceBranch  string    `bun:",notnull" json:"source_branch"`
+	TargetBranch  string    `bun:",notnull" json:"target_branch"`
+	Title         string    `bun:",notnull" json:"title"`
+	Description   string    `bun:",nullzero" json:"description"`
+	Status        string    `bun:",notnull" json:"status"`
+	UserID        int64     `bun:",notnull" json:"user_id"`
+	MergeStrategy string    `bun:",nullzero" json:"merge_strategy"`
+	MergeCommitID string    `bun:",nullzero" json:"merge_commit_id"`
+	MergedByID    int64     `bun:",nullzero" json:"merged_by_id"`
+	MergedAt      time.Time `bun:",nullzero" json:"merged_at"`
+	ClosedAt      time.Time `bun:",nullzero" json:"closed_at"`
+	CommentCount  int64     `bun:",notnull,default:0" json:"comment_count"`
+	times
+}
+
+type PullRequestComment struct {
+	ID            int64  `bun:",pk,autoincrement" json:"id"`
+	PullRequestID int64  `bun:",notnull" json:"pull_request_id"`
+	UserID        int64  `bun:",notnull" json:"user_id"`
+	Content       string `bun:",notnull" json:"content"`
+	Path          string `bun:",nullzero" json:"path"`
+	Line          int    `bun:",nullzero" json:"line"`
+	CommitID      string `bun:",nullzero" json:"commit_id"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, PullRequest{}, PullRequestComment{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*PullRequest)(nil)).
+			Index("idx_unique_pull_requests_repositoryid_number").
+			Column("repository_id", "number").
+			Unique().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table pull_requests: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*PullRequestComment)(nil)).
+			Index("idx_pull_request_comments_pullrequestid").
+			Column("pull_request_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table pull_request_comments: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, PullRequest{}, PullRequestComment{})
+	})
+}
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE repo_relations ADD COLUMN IF NOT EXISTS relation_type VARCHAR DEFAULT 'related' NOT NULL;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS idx_repo_relations_relation_type ON repo_relations (relation_type);
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+type Release struct {
+	ID           int64    `bun:",pk,autoincrement" json:"id"`
+	RepositoryID int64    `bun:",notnull" json:"repository_id"`
+	TagName      string   `bun:",notnull" json:"tag_name"`
+	Title        string   `bun:",nullzero" json:"title"`
+	Notes        string   `bun:",nullzero" json:"notes"`
+	CommitID     string   `bun:",notnull" json:"commit_id"`
+	Assets       []string `bun:"type:jsonb" json:"assets"`
+	UserID       int64    `bun:",notnull" json:"user_id"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, Release{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*Release)(nil)).
+			Index("idx_unique_releases_repositoryid_tagname").
+			Column("repository_id", "tag_name").
+			Unique().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table releases: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, Release{})
+	})
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+type BranchProtection struct {
+	ID              int64  `bun:",pk,autoincrement" json:"id"`
+	RepositoryID    int64  `bun:",notnull" json:"repository_id"`
+	Pattern         string `bun:",notnull" json:"pattern"`
+	BlockForcePush  bool   `bun:",notnull" json:"block_force_push"`
+	BlockDeletion   bool   `bun:",notnull" json:"block_deletion"`
+	RequireAdmin    bool   `bun:",notnull" json:"require_admin"`
+	BlockDirectPush bool   `bun:",notnull" json:"block_direct_push"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, BranchProtection{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*BranchProtection)(nil)).
+			Index("idx_unique_branch_protections_repositoryid_pattern").
+			Column("repository_id", "pattern").
+			Unique().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table branch_protections: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, BranchProtection{})
+	})
+}
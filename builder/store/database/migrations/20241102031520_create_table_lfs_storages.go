@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+type LfsStorage struct {
+	ID               int64  `bun:",pk,autoincrement" json:"id"`
+	Name             string `bun:",notnull,unique" json:"name"`
+	Endpoint         string `bun:",notnull" json:"endpoint"`
+	InternalEndpoint string `bun:"," json:"internal_endpoint"`
+	AccessKeyID      string `bun:",notnull" json:"access_key_id"`
+	AccessKeySecret  string `bun:",notnull" json:"-"`
+	Region           string `bun:"," json:"region"`
+	Bucket           string `bun:",notnull" json:"bucket"`
+	EnableSSL        bool   `bun:",notnull" json:"enable_ssl"`
+	times
+}
+
+type LfsStorageRoute struct {
+	ID        int64  `bun:",pk,autoincrement" json:"id"`
+	Namespace string `bun:",notnull,unique" json:"namespace"`
+	StorageID int64  `bun:",notnull" json:"storage_id"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return createTables(ctx, db, LfsStorage{}, LfsStorageRoute{})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, LfsStorage{}, LfsStorageRoute{})
+	})
+}
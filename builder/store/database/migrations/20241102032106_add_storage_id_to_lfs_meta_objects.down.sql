@@ -0,0 +1,5 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE lfs_meta_objects DROP COLUMN IF EXISTS storage_id;
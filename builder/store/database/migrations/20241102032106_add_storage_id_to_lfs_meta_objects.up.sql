@@ -0,0 +1,5 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE lfs_meta_objects ADD COLUMN IF NOT EXISTS storage_id BIGINT NOT NULL DEFAULT 0;
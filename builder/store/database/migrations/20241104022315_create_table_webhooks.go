@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Webhook struct {
+	ID           int64    `bun:",pk,autoincrement" json:"id"`
+	RepositoryID int64    `bun:",nullzero" json:"repository_id"`
+	Namespace    string   `bun:",nullzero" json:"namespace"`
+	URL          string   `bun:",notnull" json:"url"`
+	Secret       string   `bun:"," json:"-"`
+	Events       []string `bun:",type:jsonb" json:"events"`
+	Active       bool     `bun:",notnull" json:"active"`
+	UserID       int64    `bun:",notnull" json:"user_id"`
+	times
+}
+
+type WebhookDelivery struct {
+	ID             int64     `bun:",pk,autoincrement" json:"id"`
+	WebhookID      int64     `bun:",notnull" json:"webhook_id"`
+	Event          string    `bun:",notnull" json:"event"`
+	Payload        string    `bun:",notnull" json:"payload"`
+	Status         string    `bun:",notnull" json:"status"`
+	Attempts       int       `bun:",notnull" json:"attempts"`
+	NextAttemptAt  time.Time `bun:",nullzero" json:"next_attempt_at"`
+	ResponseStatus int       `bun:"," json:"response_status"`
+	ResponseBody   string    `bun:"," json:"response_body"`
+	Error          string    `bun:"," json:"error"`
+	DeliveredAt    time.Time `bun:",nullzero" json:"delivered_at"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, Webhook{}, WebhookDelivery{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*Webhook)(nil)).
+			Index("idx_webhooks_repository_id").
+			Column("repository_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table webhooks: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*Webhook)(nil)).
+			Index("idx_webhooks_namespace").
+			Column("namespace").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table webhooks: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*WebhookDelivery)(nil)).
+			Index("idx_webhook_deliveries_webhook_id").
+			Column("webhook_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table webhook_deliveries: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*WebhookDelivery)(nil)).
+			Index("idx_webhook_deliveries_status_next_attempt_at").
+			Column("status", "next_attempt_at").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table webhook_deliveries: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, Webhook{}, WebhookDelivery{})
+	})
+}
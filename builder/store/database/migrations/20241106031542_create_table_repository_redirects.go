@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+type RepositoryRedirect struct {
+	ID             int64  `bun:",pk,autoincrement" json:"id"`
+	RepositoryID   int64  `bun:",notnull" json:"repository_id"`
+	RepositoryType string `bun:",notnull" json:"repository_type"`
+	Path           string `bun:",notnull" json:"path"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, RepositoryRedirect{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*RepositoryRedirect)(nil)).
+			Index("idx_repository_redirects_type_path").
+			Unique().
+			Column("repository_type").
+			ColumnExpr("LOWER(path)").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table repository_redirects: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*RepositoryRedirect)(nil)).
+			Index("idx_repository_redirects_repository_id").
+			Column("repository_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table repository_redirects: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, RepositoryRedirect{})
+	})
+}
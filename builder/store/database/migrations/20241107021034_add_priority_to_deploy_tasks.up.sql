@@ -0,0 +1,5 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE deploy_tasks ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0;
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type ComputeQuota struct {
+	ID                  int64                     `bun:",pk,autoincrement" json:"id"`
+	Namespace           string                    `bun:",notnull,unique" json:"namespace"`
+	MaxGPUs             *int                      `bun:",nullzero" json:"max_gpus"`
+	MaxRunningInstances *int                      `bun:",nullzero" json:"max_running_instances"`
+	MonthlyMinutes      map[types.SceneType]int64 `bun:",type:jsonb,nullzero" json:"monthly_minutes"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return createTables(ctx, db, ComputeQuota{})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, ComputeQuota{})
+	})
+}
@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type FinetuneJob struct {
+	ID              int64                         `bun:",pk,autoincrement" json:"id"`
+	UserID          int64                         `bun:",notnull" json:"user_id"`
+	ModelRepoID     int64                         `bun:",notnull" json:"model_repo_id"`
+	Revision        string                        `bun:",notnull" json:"revision"`
+	DatasetRepoID   int64                         `bun:",notnull" json:"dataset_repo_id"`
+	Hyperparameters types.FinetuneHyperparameters `bun:",type:jsonb,notnull" json:"hyperparameters"`
+	Status          types.FinetuneJobStatus       `bun:",notnull" json:"status"`
+	Message         string                        `bun:",nullzero" json:"message"`
+	DeployID        int64                         `bun:",nullzero" json:"deploy_id"`
+	OutputNamespace string                        `bun:",notnull" json:"output_namespace"`
+	OutputName      string                        `bun:",notnull" json:"output_name"`
+	OutputRepoID    int64                         `bun:",nullzero" json:"output_repo_id"`
+	CurrentEpoch    int                           `bun:",notnull,default:0" json:"current_epoch"`
+	CurrentStep     int                           `bun:",notnull,default:0" json:"current_step"`
+	Metrics         map[string]float64            `bun:",type:jsonb,nullzero" json:"metrics"`
+	StartedAt       time.Time                     `bun:",nullzero" json:"started_at"`
+	FinishedAt      time.Time                     `bun:",nullzero" json:"finished_at"`
+	EventToken      string                        `bun:",nullzero" json:"-"`
+	times
+}
+
+type FinetuneCheckpoint struct {
+	ID      int64              `bun:",pk,autoincrement" json:"id"`
+	JobID   int64              `bun:",notnull" json:"job_id"`
+	Epoch   int                `bun:",notnull" json:"epoch"`
+	Step    int                `bun:",notnull" json:"step"`
+	Name    string             `bun:",notnull" json:"name"`
+	Metrics map[string]float64 `bun:",type:jsonb,nullzero" json:"metrics"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, FinetuneJob{}, FinetuneCheckpoint{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*FinetuneJob)(nil)).
+			Index("idx_finetune_jobs_user_id").
+			Column("user_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table finetune_jobs: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*FinetuneCheckpoint)(nil)).
+			Index("idx_finetune_checkpoints_job_id").
+			Column("job_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table finetune_checkpoints: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, FinetuneJob{}, FinetuneCheckpoint{})
+	})
+}
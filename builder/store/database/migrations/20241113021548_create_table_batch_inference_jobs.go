@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type BatchInferenceJob struct {
+	ID               int64                          `bun:",pk,autoincrement" json:"id"`
+	UserID           int64                          `bun:",notnull" json:"user_id"`
+	DeployID         int64                          `bun:",notnull" json:"deploy_id"`
+	DatasetRepoID    int64                          `bun:",notnull" json:"dataset_repo_id"`
+	Revision         string                         `bun:",notnull" json:"revision"`
+	InputFile        string                         `bun:",notnull" json:"input_file"`
+	InputColumn      string                         `bun:",notnull" json:"input_column"`
+	SystemPrompt     string                         `bun:",nullzero" json:"system_prompt"`
+	MaxTokens        int                            `bun:",nullzero" json:"max_tokens"`
+	Temperature      float64                        `bun:",notnull,default:0" json:"temperature"`
+	OutputType       types.BatchInferenceOutputType `bun:",notnull" json:"output_type"`
+	OutputNamespace  string                         `bun:",notnull" json:"output_namespace"`
+	OutputName       string                         `bun:",notnull" json:"output_name"`
+	OutputBranch     string                         `bun:",nullzero" json:"output_branch"`
+	OutputRepoID     int64                          `bun:",nullzero" json:"output_repo_id"`
+	Status           types.BatchInferenceJobStatus  `bun:",notnull" json:"status"`
+	Message          string                         `bun:",nullzero" json:"message"`
+	TotalRows        int                            `bun:",notnull,default:0" json:"total_rows"`
+	ProcessedRows    int                            `bun:",notnull,default:0" json:"processed_rows"`
+	FailedRows       int                            `bun:",notnull,default:0" json:"failed_rows"`
+	PromptTokens     int64                          `bun:",notnull,default:0" json:"prompt_tokens"`
+	CompletionTokens int64                          `bun:",notnull,default:0" json:"completion_tokens"`
+	LeaseUntil       time.Time                      `bun:",nullzero" json:"lease_until"`
+	StartedAt        time.Time                      `bun:",nullzero" json:"started_at"`
+	FinishedAt       time.Time                      `bun:",nullzero" json:"finished_at"`
+	times
+}
+
+type BatchInferenceResult struct {
+	ID       int64  `bun:",pk,autoincrement" json:"id"`
+	JobID    int64  `bun:",notnull" json:"job_id"`
+	RowIndex int    `bun:",notnull" json:"row_index"`
+	Input    string `bun:",notnull" json:"input"`
+	Output   string `bun:",notnull" json:"output"`
+	Error    string `bun:",nullzero" json:"error"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, BatchInferenceJob{}, BatchInferenceResult{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*BatchInferenceJob)(nil)).
+			Index("idx_batch_inference_jobs_user_id").
+			Column("user_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table batch_inference_jobs: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*BatchInferenceJob)(nil)).
+			Index("idx_batch_inference_jobs_status_lease_until").
+			Column("status", "lease_until").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table batch_inference_jobs: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*BatchInferenceResult)(nil)).
+			Index("idx_batch_inference_results_job_id_row_index").
+			Column("job_id", "row_index").
+			Unique().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table batch_inference_results: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, BatchInferenceJob{}, BatchInferenceResult{})
+	})
+}
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE deploys DROP COLUMN IF EXISTS autoscaling;
+
+--bun:split
+
+ALTER TABLE deploys DROP COLUMN IF EXISTS last_request_at;
@@ -0,0 +1,9 @@
+SET statement_timeout = 0;
+
+--bun:split
+
+ALTER TABLE deploys ADD COLUMN IF NOT EXISTS autoscaling JSONB;
+
+--bun:split
+
+ALTER TABLE deploys ADD COLUMN IF NOT EXISTS last_request_at TIMESTAMP;
@@ -0,0 +1,121 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type AccountPrice struct {
+	ID          int64           `bun:",pk,autoincrement" json:"id"`
+	SkuType     types.SKUType   `bun:",notnull" json:"sku_type"`
+	Scene       types.SceneType `bun:",notnull" json:"scene"`
+	ResourceID  string          `bun:",notnull" json:"resource_id"`
+	UnitType    string          `bun:",notnull" json:"unit_type"`
+	SkuUnit     int64           `bun:",notnull" json:"sku_unit"`
+	Price       types.Money     `bun:",notnull" json:"price"`
+	Description string          `bun:",nullzero" json:"description"`
+	times
+}
+
+type AccountUser struct {
+	ID                  int64                  `bun:",pk,autoincrement" json:"id"`
+	OwnerUUID           string                 `bun:",notnull,unique" json:"owner_uuid"`
+	OwnerType           types.AccountOwnerType `bun:",notnull" json:"owner_type"`
+	Balance             types.Money            `bun:",notnull,default:0" json:"balance"`
+	LowBalanceThreshold types.Money            `bun:",notnull,default:0" json:"low_balance_threshold"`
+	PayerUUID           string                 `bun:",nullzero" json:"payer_uuid"`
+	Status              types.AccountStatus    `bun:",notnull" json:"status"`
+	times
+}
+
+type AccountStatement struct {
+	ID           int64                      `bun:",pk,autoincrement" json:"id"`
+	AccountID    int64                      `bun:",notnull" json:"account_id"`
+	EventUUID    uuid.UUID                  `bun:"type:uuid,notnull" json:"event_uuid"`
+	Type         types.AccountStatementType `bun:",notnull" json:"type"`
+	UserUUID     string                     `bun:",nullzero" json:"user_uuid"`
+	Scene        types.SceneType            `bun:",notnull,default:0" json:"scene"`
+	ResourceID   string                     `bun:",nullzero" json:"resource_id"`
+	ResourceName string                     `bun:",nullzero" json:"resource_name"`
+	CustomerID   string                     `bun:",nullzero" json:"customer_id"`
+	Value        float64                    `bun:",notnull,default:0" json:"value"`
+	UnitType     string                     `bun:",nullzero" json:"unit_type"`
+	Amount       types.Money                `bun:",notnull" json:"amount"`
+	BalanceAfter types.Money                `bun:",notnull" json:"balance_after"`
+	Description  string                     `bun:",nullzero" json:"description"`
+	OpUID        string                     `bun:",nullzero" json:"op_uid"`
+	RecordedAt   time.Time                  `bun:",notnull" json:"recorded_at"`
+	times
+}
+
+type AccountInvoice struct {
+	ID          int64               `bun:",pk,autoincrement" json:"id"`
+	AccountID   int64               `bun:",notnull" json:"account_id"`
+	PeriodStart time.Time           `bun:",notnull" json:"period_start"`
+	PeriodEnd   time.Time           `bun:",notnull" json:"period_end"`
+	Amount      types.Money         `bun:",notnull" json:"amount"`
+	Recharged   types.Money         `bun:",notnull" json:"recharged"`
+	Items       []types.AccountBill `bun:",type:jsonb" json:"items"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, AccountPrice{}, AccountUser{}, AccountStatement{}, AccountInvoice{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*AccountPrice)(nil)).
+			Index("idx_account_prices_scene_resource_id_unit_type").
+			Column("scene", "resource_id", "unit_type").
+			Unique().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_prices: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*AccountUser)(nil)).
+			Index("idx_account_users_payer_uuid").
+			Column("payer_uuid").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_users: %w", err)
+		}
+		// a metering event is charged only once
+		_, err = db.NewCreateIndex().
+			Model((*AccountStatement)(nil)).
+			Index("idx_account_statements_event_uuid").
+			Column("event_uuid").
+			Unique().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_statements: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*AccountStatement)(nil)).
+			Index("idx_account_statements_account_id_recorded_at").
+			Column("account_id", "recorded_at").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_statements: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*AccountInvoice)(nil)).
+			Index("idx_account_invoices_account_id_period_start").
+			Column("account_id", "period_start").
+			Unique().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_invoices: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, AccountPrice{}, AccountUser{}, AccountStatement{}, AccountInvoice{})
+	})
+}
@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type AccountUsageDaily struct {
+	ID           int64           `bun:",pk,autoincrement" json:"id"`
+	Day          time.Time       `bun:"type:date,notnull" json:"day"`
+	UserUUID     string          `bun:",notnull" json:"user_uuid"`
+	Scene        types.SceneType `bun:",notnull" json:"scene"`
+	ResourceID   string          `bun:",notnull" json:"resource_id"`
+	ResourceName string          `bun:",nullzero" json:"resource_name"`
+	CustomerID   string          `bun:",notnull" json:"customer_id"`
+	UnitType     string          `bun:",notnull" json:"unit_type"`
+	Value        float64         `bun:",notnull,default:0" json:"value"`
+	EventCount   int64           `bun:",notnull,default:0" json:"event_count"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, AccountUsageDaily{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*AccountUsageDaily)(nil)).
+			Index("idx_account_usage_dailies_day_user_resource").
+			Column("day", "user_uuid", "scene", "resource_id", "customer_id", "unit_type").
+			Unique().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_usage_dailies: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*AccountUsageDaily)(nil)).
+			Index("idx_account_usage_dailies_user_uuid_day").
+			Column("user_uuid", "day").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table account_usage_dailies: %w", err)
+		}
+		// roll up the metering events recorded before the rollup table exists
+		_, err = db.ExecContext(ctx, `INSERT INTO account_usage_dailies
+	(day, user_uuid, scene, resource_id, resource_name, customer_id, unit_type, value, event_count)
+SELECT recorded_at::date, user_uuid, scene, COALESCE(resource_id, ''), MAX(resource_name),
+	COALESCE(customer_id, ''), COALESCE(sku_unit_type, ''), SUM(value), COUNT(*)
+FROM account_meterings
+GROUP BY recorded_at::date, user_uuid, scene, COALESCE(resource_id, ''), COALESCE(customer_id, ''), COALESCE(sku_unit_type, '')`)
+		if err != nil {
+			return fmt.Errorf("failed to roll up account meterings: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, AccountUsageDaily{})
+	})
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type ModerationCase struct {
+	ID             int64                      `bun:",pk,autoincrement" json:"id"`
+	RepositoryID   int64                      `bun:",notnull" json:"repository_id"`
+	RepoFileID     int64                      `bun:",notnull" json:"repo_file_id"`
+	Path           string                     `bun:",notnull" json:"path"`
+	CommitSha      string                     `bun:",nullzero" json:"commit_sha"`
+	Message        string                     `bun:",nullzero" json:"message"`
+	Status         types.ModerationCaseStatus `bun:",notnull" json:"status"`
+	RepoWasPrivate bool                       `bun:",notnull,default:false" json:"repo_was_private"`
+	AppealReason   string                     `bun:",nullzero" json:"appeal_reason"`
+	AppealedBy     string                     `bun:",nullzero" json:"appealed_by"`
+	AppealedAt     time.Time                  `bun:",nullzero" json:"appealed_at"`
+	Decision       types.ModerationAction     `bun:",nullzero" json:"decision"`
+	DecisionReason string                     `bun:",nullzero" json:"decision_reason"`
+	DecidedBy      string                     `bun:",nullzero" json:"decided_by"`
+	DecidedAt      time.Time                  `bun:",nullzero" json:"decided_at"`
+	times
+}
+
+type ModerationCaseLog struct {
+	ID         int64                      `bun:",pk,autoincrement" json:"id"`
+	CaseID     int64                      `bun:",notnull" json:"case_id"`
+	Action     types.ModerationAction     `bun:",notnull" json:"action"`
+	Operator   string                     `bun:",notnull" json:"operator"`
+	FromStatus types.ModerationCaseStatus `bun:",nullzero" json:"from_status"`
+	ToStatus   types.ModerationCaseStatus `bun:",notnull" json:"to_status"`
+	Comment    string                     `bun:",nullzero" json:"comment"`
+	CreatedAt  time.Time                  `bun:",nullzero,notnull,skipupdate,default:current_timestamp" json:"created_at"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, ModerationCase{}, ModerationCaseLog{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*ModerationCase)(nil)).
+			Index("idx_moderation_cases_repository_id_status").
+			Column("repository_id", "status").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table moderation_cases: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*ModerationCase)(nil)).
+			Index("idx_moderation_cases_repo_file_id").
+			Column("repo_file_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table moderation_cases: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*ModerationCase)(nil)).
+			Index("idx_moderation_cases_status_updated_at").
+			Column("status", "updated_at").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table moderation_cases: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*ModerationCaseLog)(nil)).
+			Index("idx_moderation_case_logs_case_id").
+			Column("case_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table moderation_case_logs: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, ModerationCase{}, ModerationCaseLog{})
+	})
+}
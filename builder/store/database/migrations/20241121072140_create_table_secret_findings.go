@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type SecretFinding struct {
+	ID            int64                     `bun:",pk,autoincrement" json:"id"`
+	RepositoryID  int64                     `bun:",notnull" json:"repository_id"`
+	RepoFileID    int64                     `bun:",notnull" json:"repo_file_id"`
+	Path          string                    `bun:",notnull" json:"path"`
+	CommitSha     string                    `bun:",nullzero" json:"commit_sha"`
+	Line          int                       `bun:",notnull" json:"line"`
+	RuleID        string                    `bun:",notnull" json:"rule_id"`
+	Description   string                    `bun:",nullzero" json:"description"`
+	Fingerprint   string                    `bun:",notnull" json:"fingerprint"`
+	Redacted      string                    `bun:",nullzero" json:"redacted"`
+	Status        types.SecretFindingStatus `bun:",notnull" json:"status"`
+	DismissedBy   string                    `bun:",nullzero" json:"dismissed_by"`
+	DismissedAt   time.Time                 `bun:",nullzero" json:"dismissed_at"`
+	DismissReason string                    `bun:",nullzero" json:"dismiss_reason"`
+	times
+}
+
+type SecretAllowlistEntry struct {
+	ID           int64  `bun:",pk,autoincrement" json:"id"`
+	RepositoryID int64  `bun:",notnull" json:"repository_id"`
+	Fingerprint  string `bun:",nullzero" json:"fingerprint"`
+	PathPattern  string `bun:",nullzero" json:"path_pattern"`
+	Reason       string `bun:",nullzero" json:"reason"`
+	CreatedBy    string `bun:",notnull" json:"created_by"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, SecretFinding{}, SecretAllowlistEntry{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*SecretFinding)(nil)).
+			Index("idx_secret_findings_repository_id_path_fingerprint").
+			Column("repository_id", "path", "fingerprint").
+			Unique().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table secret_findings: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*SecretFinding)(nil)).
+			Index("idx_secret_findings_repository_id_status").
+			Column("repository_id", "status").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table secret_findings: %w", err)
+		}
+		_, err = db.NewCreateIndex().
+			Model((*SecretAllowlistEntry)(nil)).
+			Index("idx_secret_allowlist_entries_repository_id").
+			Column("repository_id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table secret_allowlist_entries: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, SecretFinding{}, SecretAllowlistEntry{})
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type Notification struct {
+	ID      int64                  `bun:",pk,autoincrement" json:"id"`
+	UserID  int64                  `bun:",notnull" json:"user_id"`
+	Kind    types.NotificationKind `bun:",notnull" json:"kind"`
+	Title   string                 `bun:",notnull" json:"title"`
+	Content string                 `bun:",nullzero" json:"content"`
+	Link    string                 `bun:",nullzero" json:"link"`
+	ReadAt  time.Time              `bun:",nullzero" json:"read_at"`
+	times
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		err := createTables(ctx, db, Notification{})
+		if err != nil {
+			return err
+		}
+		_, err = db.NewCreateIndex().
+			Model((*Notification)(nil)).
+			Index("idx_notifications_user_id_read_at").
+			Column("user_id", "read_at").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create index for table notifications: %w", err)
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return dropTables(ctx, db, Notification{})
+	})
+}
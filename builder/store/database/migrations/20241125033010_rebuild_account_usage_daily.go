@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/builder/store/database"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// roll all metering events up again by days in UTC, including the events saved without rollups
+		// between the creation of the rollup table and the deploy of the rolling up consumer
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			_, err := database.RebuildUsageDaily(ctx, tx, time.Unix(0, 0), time.Now().AddDate(0, 0, 2))
+			if err != nil {
+				return fmt.Errorf("failed to rebuild account usage dailies: %w", err)
+			}
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type moderationCaseStoreImpl struct {
+	db *DB
+}
+
+type ModerationCaseStore interface {
+	// Create opens a case with the audit log of opening in a transaction
+	Create(ctx context.Context, mc ModerationCase, log ModerationCaseLog) (*ModerationCase, error)
+	FindByID(ctx context.Context, id int64) (*ModerationCase, error)
+	// FindLatestByRepoFile returns the last case opened for the repository file, nil is returned if there is no case
+	FindLatestByRepoFile(ctx context.Context, repoFileID int64) (*ModerationCase, error)
+	// ListUnresolvedByRepo lists the cases of repository which are not cleared
+	ListUnresolvedByRepo(ctx context.Context, repoID int64) ([]ModerationCase, error)
+	// Index lists the cases in the status, or all cases if status is empty, the last updated go first. Cases of
+	// all repositories are listed if repoID is 0
+	Index(ctx context.Context, repoID int64, status types.ModerationCaseStatus, per, page int) ([]ModerationCase, int, error)
+	// Transit saves the columns of case if it's still in the from status, and adds the audit log in a transaction.
+	// false is returned if the status of case has been changed by others
+	Transit(ctx context.Context, mc *ModerationCase, from types.ModerationCaseStatus, log ModerationCaseLog, columns ...string) (bool, error)
+	ListLogs(ctx context.Context, caseID int64) ([]ModerationCaseLog, error)
+}
+
+func NewModerationCaseStore() ModerationCaseStore {
+	return &moderationCaseStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// ModerationCase is opened for a repository file failing the sensitive check, the repository is kept
+// private until all of its cases are cleared by moderators
+type ModerationCase struct {
+	ID           int64       `bun:",pk,autoincrement" json:"id"`
+	RepositoryID int64       `bun:",notnull" json:"repository_id"`
+	Repository   *Repository `bun:"rel:belongs-to,join:repository_id=id" json:"repository"`
+	RepoFileID   int64       `bun:",notnull" json:"repo_file_id"`
+	Path         string      `bun:",notnull" json:"path"`
+	// commit of the file checked, a cleared file is not reported again until it's changed
+	CommitSha string                     `bun:",nullzero" json:"commit_sha"`
+	Message   string                     `bun:",nullzero" json:"message"`
+	Status    types.ModerationCaseStatus `bun:",notnull" json:"status"`
+	// visibility of repository before it's made private, restored once all cases of repository are cleared
+	RepoWasPrivate bool                   `bun:",notnull,default:false" json:"repo_was_private"`
+	AppealReason   string                 `bun:",nullzero" json:"appeal_reason"`
+	AppealedBy     string                 `bun:",nullzero" json:"appealed_by"`
+	AppealedAt     time.Time              `bun:",nullzero" json:"appealed_at"`
+	Decision       types.ModerationAction `bun:",nullzero" json:"decision"`
+	DecisionReason string                 `bun:",nullzero" json:"decision_reason"`
+	DecidedBy      string                 `bun:",nullzero" json:"decided_by"`
+	DecidedAt      time.Time              `bun:",nullzero" json:"decided_at"`
+	times
+}
+
+// ModerationCaseLog is the audit log of an action taken on a moderation case
+type ModerationCaseLog struct {
+	ID         int64                      `bun:",pk,autoincrement" json:"id"`
+	CaseID     int64                      `bun:",notnull" json:"case_id"`
+	Action     types.ModerationAction     `bun:",notnull" json:"action"`
+	Operator   string                     `bun:",notnull" json:"operator"`
+	FromStatus types.ModerationCaseStatus `bun:",nullzero" json:"from_status"`
+	ToStatus   types.ModerationCaseStatus `bun:",notnull" json:"to_status"`
+	Comment    string                     `bun:",nullzero" json:"comment"`
+	CreatedAt  time.Time                  `bun:",nullzero,notnull,skipupdate,default:current_timestamp" json:"created_at"`
+}
+
+func (s *moderationCaseStoreImpl) Create(ctx context.Context, mc ModerationCase, log ModerationCaseLog) (*ModerationCase, error) {
+	err := s.db.Operator.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewInsert().Model(&mc).Exec(ctx, &mc)
+		if err := assertAffectedOneRow(res, err); err != nil {
+			return fmt.Errorf("failed to create moderation case, error: %w", err)
+		}
+		log.CaseID = mc.ID
+		res, err = tx.NewInsert().Model(&log).Exec(ctx)
+		if err := assertAffectedOneRow(res, err); err != nil {
+			return fmt.Errorf("failed to create moderation case log, error: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mc, nil
+}
+
+func (s *moderationCaseStoreImpl) FindByID(ctx context.Context, id int64) (*ModerationCase, error) {
+	var mc ModerationCase
+	err := s.db.Operator.Core.NewSelect().
+		Model(&mc).
+		Relation("Repository").
+		Where("moderation_case.id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &mc, nil
+}
+
+func (s *moderationCaseStoreImpl) FindLatestByRepoFile(ctx context.Context, repoFileID int64) (*ModerationCase, error) {
+	var mc ModerationCase
+	err := s.db.Operator.Core.NewSelect().
+		Model(&mc).
+		Where("repo_file_id = ?", repoFileID).
+		Order("id DESC").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mc, nil
+}
+
+func (s *moderationCaseStoreImpl) ListUnresolvedByRepo(ctx context.Context, repoID int64) ([]ModerationCase, error) {
+	var cases []ModerationCase
+	err := s.db.Operator.Core.NewSelect().
+		Model(&cases).
+		Where("repository_id = ?", repoID).
+		Where("status != ?", types.ModerationCaseCleared).
+		Order("id ASC").
+		Scan(ctx)
+	return cases, err
+}
+
+func (s *moderationCaseStoreImpl) Index(ctx context.Context, repoID int64, status types.ModerationCaseStatus, per, page int) ([]ModerationCase, int, error) {
+	var cases []ModerationCase
+	query := s.db.Operator.Core.NewSelect().
+		Model(&cases).
+		Relation("Repository")
+	if repoID > 0 {
+		query = query.Where("moderation_case.repository_id = ?", repoID)
+	}
+	if status != "" {
+		query = query.Where("moderation_case.status = ?", status)
+	}
+	total, err := query.
+		Order("moderation_case.updated_at DESC").
+		Limit(per).
+		Offset((page - 1) * per).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cases, total, nil
+}
+
+func (s *moderationCaseStoreImpl) Transit(ctx context.Context, mc *ModerationCase, from types.ModerationCaseStatus, log ModerationCaseLog, columns ...string) (bool, error) {
+	var updated bool
+	err := s.db.Operator.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		mc.UpdatedAt = time.Now()
+		res, err := tx.NewUpdate().
+			Model(mc).
+			Column(append(columns, "status", "updated_at")...).
+			WherePK().
+			Where("status = ?", from).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update moderation case, error: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get affected rows, error: %w", err)
+		}
+		if affected == 0 {
+			return nil
+		}
+		log.CaseID = mc.ID
+		res, err = tx.NewInsert().Model(&log).Exec(ctx)
+		if err := assertAffectedOneRow(res, err); err != nil {
+			return fmt.Errorf("failed to create moderation case log, error: %w", err)
+		}
+		updated = true
+		return nil
+	})
+	return updated, err
+}
+
+func (s *moderationCaseStoreImpl) ListLogs(ctx context.Context, caseID int64) ([]ModerationCaseLog, error) {
+	var logs []ModerationCaseLog
+	err := s.db.Operator.Core.NewSelect().
+		Model(&logs).
+		Where("case_id = ?", caseID).
+		Order("id ASC").
+		Scan(ctx)
+	return logs, err
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+type notificationStoreImpl struct {
+	db *DB
+}
+
+type NotificationStore interface {
+	Create(ctx context.Context, notification Notification) (*Notification, error)
+	// ListByUserID lists the notifications of user, the latest go first
+	ListByUserID(ctx context.Context, userID int64, unreadOnly bool, per, page int) ([]Notification, int, error)
+	CountUnread(ctx context.Context, userID int64) (int, error)
+	// MarkRead marks the notification of user as read, sql.ErrNoRows is returned if user has no such
+	// notification
+	MarkRead(ctx context.Context, userID, id int64) error
+	MarkAllRead(ctx context.Context, userID int64) error
+}
+
+func NewNotificationStore() NotificationStore {
+	return &notificationStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// Notification is an in-app message to a user
+type Notification struct {
+	ID      int64                  `bun:",pk,autoincrement" json:"id"`
+	UserID  int64                  `bun:",notnull" json:"user_id"`
+	Kind    types.NotificationKind `bun:",notnull" json:"kind"`
+	Title   string                 `bun:",notnull" json:"title"`
+	Content string                 `bun:",nullzero" json:"content"`
+	// page of the subject in the hub, e.g. the path of a repository
+	Link   string    `bun:",nullzero" json:"link"`
+	ReadAt time.Time `bun:",nullzero" json:"read_at"`
+	times
+}
+
+func (s *notificationStoreImpl) Create(ctx context.Context, notification Notification) (*Notification, error) {
+	res, err := s.db.Operator.Core.NewInsert().Model(&notification).Exec(ctx, &notification)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create notification, error: %w", err)
+	}
+	return &notification, nil
+}
+
+func (s *notificationStoreImpl) ListByUserID(ctx context.Context, userID int64, unreadOnly bool, per, page int) ([]Notification, int, error) {
+	var notifications []Notification
+	query := s.db.Operator.Core.NewSelect().
+		Model(&notifications).
+		Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	total, err := query.
+		Order("id DESC").
+		Limit(per).
+		Offset((page - 1) * per).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (s *notificationStoreImpl) CountUnread(ctx context.Context, userID int64) (int, error) {
+	return s.db.Operator.Core.NewSelect().
+		Model((*Notification)(nil)).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(ctx)
+}
+
+func (s *notificationStoreImpl) MarkRead(ctx context.Context, userID, id int64) error {
+	now := time.Now()
+	res, err := s.db.Operator.Core.NewUpdate().
+		Model((*Notification)(nil)).
+		Set("read_at = COALESCE(read_at, ?)", now).
+		Set("updated_at = ?", now).
+		Where("id = ? AND user_id = ?", id, userID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *notificationStoreImpl) MarkAllRead(ctx context.Context, userID int64) error {
+	now := time.Now()
+	_, err := s.db.Operator.Core.NewUpdate().
+		Model((*Notification)(nil)).
+		Set("read_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Exec(ctx)
+	return err
+}
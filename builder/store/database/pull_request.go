@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type PullRequest struct {
+	ID int64 `bun:",pk,autoincrement" json:"id"`
+	// Number is the sequence of the pull request inside its target repository
+	Number        int64                   `bun:",notnull" json:"number"`
+	RepositoryID  int64                   `bun:",notnull" json:"repository_id"`
+	Repository    *Repository             `bun:"rel:belongs-to,join:repository_id=id" json:"repository"`
+	SourceRepoID  int64                   `bun:",notnull" json:"source_repo_id"`
+	SourceRepo    *Repository             `bun:"rel:belongs-to,join:source_repo_id=id" json:"source_repo"`
+	SourceBranch  string                  `bun:",notnull" json:"source_branch"`
+	TargetBranch  string                  `bun:",notnull" json:"target_branch"`
+	Title         string                  `bun:",notnull" json:"title"`
+	Description   string                  `bun:",nullzero" json:"description"`
+	Status        types.PullRequestStatus `bun:",notnull" json:"status"`
+	UserID        int64                   `bun:",notnull" json:"user_id"`
+	User          *User                   `bun:"rel:belongs-to,join:user_id=id" json:"user"`
+	MergeStrategy types.MergeStrategy     `bun:",nullzero" json:"merge_strategy"`
+	MergeCommitID string                  `bun:",nullzero" json:"merge_commit_id"`
+	MergedByID    int64                   `bun:",nullzero" json:"merged_by_id"`
+	MergedBy      *User                   `bun:"rel:belongs-to,join:merged_by_id=id" json:"merged_by"`
+	MergedAt      time.Time               `bun:",nullzero" json:"merged_at"`
+	ClosedAt      time.Time               `bun:",nullzero" json:"closed_at"`
+	CommentCount  int64                   `bun:",notnull,default:0" json:"comment_count"`
+	times
+}
+
+// PullRequestComment is a review comment of a pull request,
+// optionally anchored to a line of a file at a given commit
+type PullRequestComment struct {
+	ID            int64  `bun:",pk,autoincrement" json:"id"`
+	PullRequestID int64  `bun:",notnull" json:"pull_request_id"`
+	UserID        int64  `bun:",notnull" json:"user_id"`
+	User          *User  `bun:"rel:belongs-to,join:user_id=id" json:"user"`
+	Content       string `bun:",notnull" json:"content"`
+	Path          string `bun:",nullzero" json:"path"`
+	Line          int    `bun:",nullzero" json:"line"`
+	CommitID      string `bun:",nullzero" json:"commit_id"`
+	times
+}
+
+type pullRequestStoreImpl struct {
+	db *DB
+}
+
+type PullRequestStore interface {
+	// Create saves a new pull request and assigns the next number of the target repository to it
+	Create(ctx context.Context, pr PullRequest) (*PullRequest, error)
+	FindByID(ctx context.Context, id int64) (*PullRequest, error)
+	// FindByNumber finds a pull request by its number inside the target repository
+	FindByNumber(ctx context.Context, repoID, number int64) (*PullRequest, error)
+	ListByRepoID(ctx context.Context, repoID int64, status types.PullRequestStatus, per, page int) ([]PullRequest, int, error)
+	Update(ctx context.Context, pr PullRequest) (*PullRequest, error)
+	CreateComment(ctx context.Context, comment PullRequestComment) (*PullRequestComment, error)
+	FindCommentByID(ctx context.Context, id int64) (*PullRequestComment, error)
+	ListComments(ctx context.Context, pullRequestID int64) ([]PullRequestComment, error)
+	DeleteComment(ctx context.Context, comment PullRequestComment) error
+}
+
+func NewPullRequestStore() PullRequestStore {
+	return &pullRequestStoreImpl{
+		db: defaultDB,
+	}
+}
+
+func (s *pullRequestStoreImpl) Create(ctx context.Context, pr PullRequest) (*PullRequest, error) {
+	err := s.db.Core.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		// serialize number allocation of the same repository
+		_, err := tx.NewSelect().Model((*Repository)(nil)).
+			Column("id").
+			Where("id = ?", pr.RepositoryID).
+			For("UPDATE").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to lock repository %d, error: %w", pr.RepositoryID, err)
+		}
+		var maxNumber int64
+		err = tx.NewSelect().Model((*PullRequest)(nil)).
+			ColumnExpr("COALESCE(MAX(number), 0)").
+			Where("repository_id = ?", pr.RepositoryID).
+			Scan(ctx, &maxNumber)
+		if err != nil {
+			return fmt.Errorf("failed to get max pull request number, error: %w", err)
+		}
+		pr.Number = maxNumber + 1
+		res, err := tx.NewInsert().Model(&pr).Exec(ctx)
+		return assertAffectedOneRow(res, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request, error: %w", err)
+	}
+	return &pr, nil
+}
+
+func (s *pullRequestStoreImpl) FindByID(ctx context.Context, id int64) (*PullRequest, error) {
+	var pr PullRequest
+	err := s.db.Core.NewSelect().Model(&pr).
+		Relation("Repository").
+		Relation("SourceRepo").
+		Relation("User").
+		Relation("MergedBy").
+		Where("pull_request.id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (s *pullRequestStoreImpl) FindByNumber(ctx context.Context, repoID, number int64) (*PullRequest, error) {
+	var pr PullRequest
+	err := s.db.Core.NewSelect().Model(&pr).
+		Relation("Repository").
+		Relation("SourceRepo").
+		Relation("User").
+		Relation("MergedBy").
+		Where("pull_request.repository_id = ? AND pull_request.number = ?", repoID, number).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (s *pullRequestStoreImpl) ListByRepoID(ctx context.Context, repoID int64, status types.PullRequestStatus, per, page int) ([]PullRequest, int, error) {
+	var prs []PullRequest
+	q := s.db.Core.NewSelect().Model(&prs).
+		Relation("SourceRepo").
+		Relation("User").
+		Where("pull_request.repository_id = ?", repoID)
+	if status != "" {
+		q = q.Where("pull_request.status = ?", status)
+	}
+	count, err := q.Order("pull_request.number DESC").
+		Limit(per).
+		Offset((page - 1) * per).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return prs, count, nil
+}
+
+func (s *pullRequestStoreImpl) Update(ctx context.Context, pr PullRequest) (*PullRequest, error) {
+	_, err := s.db.Core.NewUpdate().Model(&pr).WherePK().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (s *pullRequestStoreImpl) CreateComment(ctx context.Context, comment PullRequestComment) (*PullRequestComment, error) {
+	err := s.db.Core.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewInsert().Model(&comment).Exec(ctx)
+		if err := assertAffectedOneRow(res, err); err != nil {
+			return err
+		}
+		_, err = tx.NewUpdate().Model((*PullRequest)(nil)).
+			Set("comment_count = comment_count + 1").
+			Where("id = ?", comment.PullRequestID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request comment, error: %w", err)
+	}
+	return &comment, nil
+}
+
+func (s *pullRequestStoreImpl) FindCommentByID(ctx context.Context, id int64) (*PullRequestComment, error) {
+	var comment PullRequestComment
+	err := s.db.Core.NewSelect().Model(&comment).
+		Relation("User").
+		Where("pull_request_comment.id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (s *pullRequestStoreImpl) ListComments(ctx context.Context, pullRequestID int64) ([]PullRequestComment, error) {
+	var comments []PullRequestComment
+	err := s.db.Core.NewSelect().Model(&comments).
+		Relation("User").
+		Where("pull_request_comment.pull_request_id = ?", pullRequestID).
+		Order("pull_request_comment.id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (s *pullRequestStoreImpl) DeleteComment(ctx context.Context, comment PullRequestComment) error {
+	return s.db.Core.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().Model(&comment).WherePK().Exec(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewUpdate().Model((*PullRequest)(nil)).
+			Set("comment_count = comment_count - 1").
+			Where("id = ? AND comment_count > 0", comment.PullRequestID).
+			Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// Release is a named version of a repository, pinned to the commit its tag pointed to when it was created
+type Release struct {
+	ID           int64    `bun:",pk,autoincrement" json:"id"`
+	RepositoryID int64    `bun:",notnull" json:"repository_id"`
+	TagName      string   `bun:",notnull" json:"tag_name"`
+	Title        string   `bun:",nullzero" json:"title"`
+	Notes        string   `bun:",nullzero" json:"notes"`
+	CommitID     string   `bun:",notnull" json:"commit_id"`
+	Assets       []string `bun:"type:jsonb" json:"assets"`
+	UserID       int64    `bun:",notnull" json:"user_id"`
+	User         *User    `bun:"rel:belongs-to,join:user_id=id" json:"user"`
+	times
+}
+
+type releaseStoreImpl struct {
+	db *DB
+}
+
+type ReleaseStore interface {
+	Create(ctx context.Context, release Release) (*Release, error)
+	FindByTagName(ctx context.Context, repoID int64, tagName string) (*Release, error)
+	ListByRepoID(ctx context.Context, repoID int64, per, page int) ([]Release, int, error)
+	Update(ctx context.Context, release Release) (*Release, error)
+	Delete(ctx context.Context, release Release) error
+}
+
+func NewReleaseStore() ReleaseStore {
+	return &releaseStoreImpl{
+		db: defaultDB,
+	}
+}
+
+func (s *releaseStoreImpl) Create(ctx context.Context, release Release) (*Release, error) {
+	res, err := s.db.Core.NewInsert().Model(&release).Exec(ctx)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create release, error: %w", err)
+	}
+	return &release, nil
+}
+
+func (s *releaseStoreImpl) FindByTagName(ctx context.Context, repoID int64, tagName string) (*Release, error) {
+	var release Release
+	err := s.db.Core.NewSelect().Model(&release).
+		Relation("User").
+		Where("release.repository_id = ? AND release.tag_name = ?", repoID, tagName).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (s *releaseStoreImpl) ListByRepoID(ctx context.Context, repoID int64, per, page int) ([]Release, int, error) {
+	var releases []Release
+	count, err := s.db.Core.NewSelect().Model(&releases).
+		Relation("User").
+		Where("release.repository_id = ?", repoID).
+		Order("release.id DESC").
+		Limit(per).
+		Offset((page - 1) * per).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return releases, count, nil
+}
+
+func (s *releaseStoreImpl) Update(ctx context.Context, release Release) (*Release, error) {
+	_, err := s.db.Core.NewUpdate().Model(&release).WherePK().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (s *releaseStoreImpl) Delete(ctx context.Context, release Release) error {
+	_, err := s.db.Core.NewDelete().Model(&release).WherePK().Exec(ctx)
+	return err
+}
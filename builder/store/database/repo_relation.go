@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"fmt"
+
+	"opencsg.com/csghub-server/common/types"
 )
 
 type repoRelationsStoreImpl struct {
@@ -20,6 +22,16 @@ type RepoRelationsStore interface {
 	Override(ctx context.Context, from int64, to ...int64) error
 	// Delete removes a relationship from a repository to another
 	Delete(ctx context.Context, from, to int64) error
+	// SetForkParent records the upstream repository of a forked repository
+	SetForkParent(ctx context.Context, forkRepoID, parentRepoID int64) error
+	// ForkParent gets the fork relationship of a forked repository to its upstream repository
+	ForkParent(ctx context.Context, forkRepoID int64) (*RepoRelation, error)
+	// Forks gets the fork relationships to an upstream repository
+	Forks(ctx context.Context, parentRepoID int64) ([]*RepoRelation, error)
+	// SetFinetuneSources records the base model and datasets a finetuned model was trained from
+	SetFinetuneSources(ctx context.Context, repoID int64, sourceRepoIDs ...int64) error
+	// FinetuneSources gets the finetune relationships of a finetuned model to its base model and datasets
+	FinetuneSources(ctx context.Context, repoID int64) ([]*RepoRelation, error)
 }
 
 func NewRepoRelationsStore() RepoRelationsStore {
@@ -29,22 +41,27 @@ func NewRepoRelationsStore() RepoRelationsStore {
 }
 
 type RepoRelation struct {
-	ID         int64 `bun:",pk,autoincrement" json:"id"`
-	FromRepoID int64 `bun:",notnull" json:"from_repo_id"`
-	ToRepoID   int64 `bun:",notnull" json:"to_repo_id"`
+	ID           int64                  `bun:",pk,autoincrement" json:"id"`
+	FromRepoID   int64                  `bun:",notnull" json:"from_repo_id"`
+	ToRepoID     int64                  `bun:",notnull" json:"to_repo_id"`
+	RelationType types.RepoRelationType `bun:",notnull,default:'related'" json:"relation_type"`
 }
 
 // From gets the relationships from a repository
 func (r *repoRelationsStoreImpl) From(ctx context.Context, repoID int64) ([]*RepoRelation, error) {
 	var rrs []*RepoRelation
-	err := r.db.Core.NewSelect().Model(&rrs).Where("from_repo_id = ?", repoID).Scan(ctx)
+	err := r.db.Core.NewSelect().Model(&rrs).
+		Where("from_repo_id = ? and relation_type = ?", repoID, types.RepoRelationRelated).
+		Scan(ctx)
 	return rrs, err
 }
 
 // To gets the relationships to a repository
 func (r *repoRelationsStoreImpl) To(ctx context.Context, repoID int64) ([]*RepoRelation, error) {
 	var rrs []*RepoRelation
-	err := r.db.Core.NewSelect().Model(&rrs).Where("to_repo_id = ?", repoID).Scan(ctx)
+	err := r.db.Core.NewSelect().Model(&rrs).
+		Where("to_repo_id = ? and relation_type = ?", repoID, types.RepoRelationRelated).
+		Scan(ctx)
 	return rrs, err
 }
 
@@ -55,8 +72,9 @@ func (r *repoRelationsStoreImpl) Override(ctx context.Context, from int64, to ..
 	var relations []*RepoRelation
 	for _, toRepoID := range to {
 		relations = append(relations, &RepoRelation{
-			FromRepoID: from,
-			ToRepoID:   toRepoID,
+			FromRepoID:   from,
+			ToRepoID:     toRepoID,
+			RelationType: types.RepoRelationRelated,
 		})
 	}
 
@@ -64,7 +82,7 @@ func (r *repoRelationsStoreImpl) Override(ctx context.Context, from int64, to ..
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	_, err = tx.NewDelete().Model((*RepoRelation)(nil)).Where("from_repo_id = ?", from).Exec(ctx)
+	_, err = tx.NewDelete().Model((*RepoRelation)(nil)).Where("from_repo_id = ? and relation_type = ?", from, types.RepoRelationRelated).Exec(ctx)
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to delete existing relations: %w", err)
@@ -86,7 +104,66 @@ func (r *repoRelationsStoreImpl) Override(ctx context.Context, from int64, to ..
 func (r *repoRelationsStoreImpl) Delete(ctx context.Context, from, to int64) error {
 	result, err := r.db.Core.NewDelete().
 		Model((*RepoRelation)(nil)).
-		Where("from_repo_id = ? and to_repo_id = ?", from, to).
+		Where("from_repo_id = ? and to_repo_id = ? and relation_type = ?", from, to, types.RepoRelationRelated).
 		Exec(ctx)
 	return assertAffectedOneRow(result, err)
 }
+
+// SetForkParent records the upstream repository of a forked repository
+func (r *repoRelationsStoreImpl) SetForkParent(ctx context.Context, forkRepoID, parentRepoID int64) error {
+	relation := RepoRelation{
+		FromRepoID:   forkRepoID,
+		ToRepoID:     parentRepoID,
+		RelationType: types.RepoRelationFork,
+	}
+	result, err := r.db.Core.NewInsert().Model(&relation).Exec(ctx)
+	return assertAffectedOneRow(result, err)
+}
+
+// ForkParent gets the fork relationship of a forked repository to its upstream repository
+func (r *repoRelationsStoreImpl) ForkParent(ctx context.Context, forkRepoID int64) (*RepoRelation, error) {
+	var rr RepoRelation
+	err := r.db.Core.NewSelect().Model(&rr).
+		Where("from_repo_id = ? and relation_type = ?", forkRepoID, types.RepoRelationFork).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rr, nil
+}
+
+// Forks gets the fork relationships to an upstream repository
+func (r *repoRelationsStoreImpl) Forks(ctx context.Context, parentRepoID int64) ([]*RepoRelation, error) {
+	var rrs []*RepoRelation
+	err := r.db.Core.NewSelect().Model(&rrs).
+		Where("to_repo_id = ? and relation_type = ?", parentRepoID, types.RepoRelationFork).
+		Scan(ctx)
+	return rrs, err
+}
+
+// SetFinetuneSources records the base model and datasets a finetuned model was trained from
+func (r *repoRelationsStoreImpl) SetFinetuneSources(ctx context.Context, repoID int64, sourceRepoIDs ...int64) error {
+	var relations []*RepoRelation
+	for _, sourceRepoID := range sourceRepoIDs {
+		relations = append(relations, &RepoRelation{
+			FromRepoID:   repoID,
+			ToRepoID:     sourceRepoID,
+			RelationType: types.RepoRelationFinetune,
+		})
+	}
+	if len(relations) == 0 {
+		return nil
+	}
+	_, err := r.db.Core.NewInsert().Model(&relations).Exec(ctx)
+	return err
+}
+
+// FinetuneSources gets the finetune relationships of a finetuned model to its base model and datasets
+func (r *repoRelationsStoreImpl) FinetuneSources(ctx context.Context, repoID int64) ([]*RepoRelation, error) {
+	var rrs []*RepoRelation
+	err := r.db.Core.NewSelect().Model(&rrs).
+		Where("from_repo_id = ? and relation_type = ?", repoID, types.RepoRelationFinetune).
+		Scan(ctx)
+	return rrs, err
+}
@@ -27,6 +27,8 @@ type RepoStore interface {
 	CreateRepoTx(ctx context.Context, tx bun.Tx, input Repository) (*Repository, error)
 	CreateRepo(ctx context.Context, input Repository) (*Repository, error)
 	UpdateRepo(ctx context.Context, input Repository) (*Repository, error)
+	// Transfer saves the new path of the repository moved from the old path, and redirects the old path to it
+	Transfer(ctx context.Context, input Repository, oldPath string) (*Repository, error)
 	DeleteRepo(ctx context.Context, input Repository) error
 	Find(ctx context.Context, owner, repoType, repoName string) (*Repository, error)
 	FindById(ctx context.Context, id int64) (*Repository, error)
@@ -61,6 +63,7 @@ type RepoStore interface {
 	FindWithBatch(ctx context.Context, batchSize, batch int) ([]Repository, error)
 	FindByRepoSourceWithBatch(ctx context.Context, repoSource types.RepositorySource, batchSize, batch int) ([]Repository, error)
 	ByUser(ctx context.Context, userID int64) ([]Repository, error)
+	ByNamespace(ctx context.Context, namespace string) ([]Repository, error)
 }
 
 func NewRepoStore() RepoStore {
@@ -156,6 +159,47 @@ func (s *repoStoreImpl) UpdateRepo(ctx context.Context, input Repository) (*Repo
 	return &input, err
 }
 
+func (s *repoStoreImpl) Transfer(ctx context.Context, input Repository, oldPath string) (*Repository, error) {
+	err := s.db.Operator.Core.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().Model(&input).
+			Column("user_id", "path", "git_path", "http_clone_url", "ssh_clone_url").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update repository path, error: %w", err)
+		}
+		// deploys clone the repository by git path
+		_, err = tx.NewUpdate().Model((*Deploy)(nil)).
+			Set("git_path = ?", input.GitPath).
+			Where("repo_id = ?", input.ID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update git path of deploys, error: %w", err)
+		}
+		// the new path is taken by the repository now, redirects from it are not needed anymore
+		_, err = tx.NewDelete().Model((*RepositoryRedirect)(nil)).
+			Where("repository_type = ?", input.RepositoryType).
+			Where("LOWER(path) IN (LOWER(?), LOWER(?))", input.Path, oldPath).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete repository redirects, error: %w", err)
+		}
+		res, err := tx.NewInsert().Model(&RepositoryRedirect{
+			RepositoryID:   input.ID,
+			RepositoryType: input.RepositoryType,
+			Path:           oldPath,
+		}).Exec(ctx)
+		if err := assertAffectedOneRow(res, err); err != nil {
+			return fmt.Errorf("failed to create repository redirect, error: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &input, nil
+}
+
 func (s *repoStoreImpl) DeleteRepo(ctx context.Context, input Repository) error {
 	_, err := s.db.Core.NewDelete().Model(&input).WherePK().Exec(ctx)
 
@@ -711,3 +755,9 @@ func (s *repoStoreImpl) ByUser(ctx context.Context, userID int64) ([]Repository,
 	err := s.db.Operator.Core.NewSelect().Model(&repos).Where("user_id = ?", userID).Scan(ctx)
 	return repos, err
 }
+
+func (s *repoStoreImpl) ByNamespace(ctx context.Context, namespace string) ([]Repository, error) {
+	var repos []Repository
+	err := s.db.Operator.Core.NewSelect().Model(&repos).Where("split_part(path, '/', 1) = ?", namespace).Scan(ctx)
+	return repos, err
+}
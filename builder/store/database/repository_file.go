@@ -3,8 +3,10 @@ package database
 import (
 	"context"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/uptrace/bun"
 	"opencsg.com/csghub-server/common/types"
 )
 
@@ -31,6 +33,16 @@ type RepoFileStore interface {
 	BatchGetUnchcked(ctx context.Context, repoID, lastRepoFileID, batch int64) ([]*RepositoryFile, error)
 	Exists(ctx context.Context, file RepositoryFile) (bool, error)
 	ExistsSensitiveCheckRecord(ctx context.Context, repoID int64, branch string, status types.SensitiveCheckStatus) (bool, error)
+	// ListLatestChecksByExts returns the check results of the latest version of the files on branch which have
+	// one of the extensions, files not checked yet are in pending status
+	ListLatestChecksByExts(ctx context.Context, repoID int64, branch string, exts []string) ([]RepositoryFileWithCheck, error)
+}
+
+// RepositoryFileWithCheck is a repository file with its sensitive check result
+type RepositoryFileWithCheck struct {
+	Path    string                     `bun:"path"`
+	Status  types.SensitiveCheckStatus `bun:"status"`
+	Message string                     `bun:"message"`
 }
 
 func NewRepoFileStore() RepoFileStore {
@@ -84,3 +96,26 @@ func (s *repoFileStoreImpl) ExistsSensitiveCheckRecord(ctx context.Context, repo
 		Where("rf.repository_id = ? and rf.branch = ? and repository_file_check.status = ?", repoID, branch, status).
 		Exists(ctx)
 }
+
+func (s *repoFileStoreImpl) ListLatestChecksByExts(ctx context.Context, repoID int64, branch string, exts []string) ([]RepositoryFileWithCheck, error) {
+	var files []RepositoryFileWithCheck
+	if len(exts) == 0 {
+		return files, nil
+	}
+	err := s.db.Operator.Core.NewSelect().
+		TableExpr("repository_files AS rf").
+		ColumnExpr("rf.path, COALESCE(rfc.status, 0) AS status, COALESCE(rfc.message, '') AS message").
+		Join("LEFT JOIN repository_file_checks rfc ON rfc.repo_file_id = rf.id").
+		Where("rf.repository_id = ? and rf.branch = ?", repoID, branch).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			for _, ext := range exts {
+				q = q.WhereOr("lower(rf.path) LIKE ?", "%"+strings.ToLower(ext))
+			}
+			return q
+		}).
+		DistinctOn("rf.path").
+		// the latest check of the latest version of file
+		OrderExpr("rf.path, rf.id DESC, rfc.id DESC NULLS LAST").
+		Scan(ctx, &files)
+	return files, err
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+// RepositoryRedirect keeps a former path of a transferred repository, so that the old urls keep working
+type RepositoryRedirect struct {
+	ID             int64                `bun:",pk,autoincrement" json:"id"`
+	RepositoryID   int64                `bun:",notnull" json:"repository_id"`
+	Repository     *Repository          `bun:"rel:belongs-to,join:repository_id=id" json:"repository"`
+	RepositoryType types.RepositoryType `bun:",notnull" json:"repository_type"`
+	// Path is the former path of repository in format of `namespace/name`
+	Path string `bun:",notnull" json:"path"`
+	times
+}
+
+type repoRedirectStoreImpl struct {
+	db *DB
+}
+
+type RepoRedirectStore interface {
+	// FindByPath returns the redirect of the former repository path together with the repository it points to
+	FindByPath(ctx context.Context, repoType types.RepositoryType, namespace, name string) (*RepositoryRedirect, error)
+}
+
+func NewRepoRedirectStore() RepoRedirectStore {
+	return &repoRedirectStoreImpl{
+		db: defaultDB,
+	}
+}
+
+func (s *repoRedirectStoreImpl) FindByPath(ctx context.Context, repoType types.RepositoryType, namespace, name string) (*RepositoryRedirect, error) {
+	var redirect RepositoryRedirect
+	err := s.db.Operator.Core.NewSelect().
+		Model(&redirect).
+		Relation("Repository").
+		Where("repository_redirect.repository_type = ?", repoType).
+		Where("LOWER(repository_redirect.path) = LOWER(?)", fmt.Sprintf("%s/%s", namespace, name)).
+		// the repository may have been deleted after transfer
+		Where("repository.id IS NOT NULL").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &redirect, nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+type secretAllowlistStoreImpl struct {
+	db *DB
+}
+
+type SecretAllowlistStore interface {
+	Create(ctx context.Context, entry SecretAllowlistEntry) (*SecretAllowlistEntry, error)
+	// Delete deletes the entry of repository
+	Delete(ctx context.Context, repoID, id int64) error
+	ListByRepo(ctx context.Context, repoID int64) ([]SecretAllowlistEntry, error)
+}
+
+func NewSecretAllowlistStore() SecretAllowlistStore {
+	return &secretAllowlistStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// SecretAllowlistEntry allows the secret of fingerprint, or the secrets in files matching the path pattern
+// of a repository. Both are matched if both are set
+type SecretAllowlistEntry struct {
+	ID           int64  `bun:",pk,autoincrement" json:"id"`
+	RepositoryID int64  `bun:",notnull" json:"repository_id"`
+	Fingerprint  string `bun:",nullzero" json:"fingerprint"`
+	PathPattern  string `bun:",nullzero" json:"path_pattern"`
+	Reason       string `bun:",nullzero" json:"reason"`
+	CreatedBy    string `bun:",notnull" json:"created_by"`
+	times
+}
+
+func (s *secretAllowlistStoreImpl) Create(ctx context.Context, entry SecretAllowlistEntry) (*SecretAllowlistEntry, error) {
+	res, err := s.db.Operator.Core.NewInsert().Model(&entry).Exec(ctx, &entry)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create secret allowlist entry, error: %w", err)
+	}
+	return &entry, nil
+}
+
+func (s *secretAllowlistStoreImpl) Delete(ctx context.Context, repoID, id int64) error {
+	res, err := s.db.Operator.Core.NewDelete().
+		Model((*SecretAllowlistEntry)(nil)).
+		Where("repository_id = ?", repoID).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return fmt.Errorf("failed to delete secret allowlist entry %d, error: %w", id, err)
+	}
+	return nil
+}
+
+func (s *secretAllowlistStoreImpl) ListByRepo(ctx context.Context, repoID int64) ([]SecretAllowlistEntry, error) {
+	var entries []SecretAllowlistEntry
+	err := s.db.Operator.Core.NewSelect().
+		Model(&entries).
+		Where("repository_id = ?", repoID).
+		Order("id ASC").
+		Scan(ctx)
+	return entries, err
+}
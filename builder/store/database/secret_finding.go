@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+type secretFindingStoreImpl struct {
+	db *DB
+}
+
+type SecretFindingStore interface {
+	Create(ctx context.Context, finding SecretFinding) (*SecretFinding, error)
+	// Update saves the columns of finding
+	Update(ctx context.Context, finding *SecretFinding, columns ...string) error
+	FindByID(ctx context.Context, id int64) (*SecretFinding, error)
+	// ListByRepoPath lists the findings of all statuses in the file of repository
+	ListByRepoPath(ctx context.Context, repoID int64, path string) ([]SecretFinding, error)
+	// ListByRepo lists the findings of repository in the status, or all findings if status is empty
+	ListByRepo(ctx context.Context, repoID int64, status types.SecretFindingStatus) ([]SecretFinding, error)
+	// Index lists the findings of repository in the status, or all findings if status is empty, the last
+	// updated go first
+	Index(ctx context.Context, repoID int64, status types.SecretFindingStatus, per, page int) ([]SecretFinding, int, error)
+}
+
+func NewSecretFindingStore() SecretFindingStore {
+	return &secretFindingStoreImpl{
+		db: defaultDB,
+	}
+}
+
+// SecretFinding is a secret found in a file of repository by the secret scanning, secrets are identified
+// by fingerprint and only the redacted secret is saved
+type SecretFinding struct {
+	ID           int64  `bun:",pk,autoincrement" json:"id"`
+	RepositoryID int64  `bun:",notnull" json:"repository_id"`
+	RepoFileID   int64  `bun:",notnull" json:"repo_file_id"`
+	Path         string `bun:",notnull" json:"path"`
+	// commit of the file the secret was last found in
+	CommitSha     string                    `bun:",nullzero" json:"commit_sha"`
+	Line          int                       `bun:",notnull" json:"line"`
+	RuleID        string                    `bun:",notnull" json:"rule_id"`
+	Description   string                    `bun:",nullzero" json:"description"`
+	Fingerprint   string                    `bun:",notnull" json:"fingerprint"`
+	Redacted      string                    `bun:",nullzero" json:"redacted"`
+	Status        types.SecretFindingStatus `bun:",notnull" json:"status"`
+	DismissedBy   string                    `bun:",nullzero" json:"dismissed_by"`
+	DismissedAt   time.Time                 `bun:",nullzero" json:"dismissed_at"`
+	DismissReason string                    `bun:",nullzero" json:"dismiss_reason"`
+	times
+}
+
+func (s *secretFindingStoreImpl) Create(ctx context.Context, finding SecretFinding) (*SecretFinding, error) {
+	res, err := s.db.Operator.Core.NewInsert().Model(&finding).Exec(ctx, &finding)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create secret finding, error: %w", err)
+	}
+	return &finding, nil
+}
+
+func (s *secretFindingStoreImpl) Update(ctx context.Context, finding *SecretFinding, columns ...string) error {
+	finding.UpdatedAt = time.Now()
+	res, err := s.db.Operator.Core.NewUpdate().
+		Model(finding).
+		Column(append(columns, "updated_at")...).
+		WherePK().
+		Exec(ctx)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return fmt.Errorf("failed to update secret finding %d, error: %w", finding.ID, err)
+	}
+	return nil
+}
+
+func (s *secretFindingStoreImpl) FindByID(ctx context.Context, id int64) (*SecretFinding, error) {
+	var finding SecretFinding
+	err := s.db.Operator.Core.NewSelect().
+		Model(&finding).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &finding, nil
+}
+
+func (s *secretFindingStoreImpl) ListByRepoPath(ctx context.Context, repoID int64, path string) ([]SecretFinding, error) {
+	var findings []SecretFinding
+	err := s.db.Operator.Core.NewSelect().
+		Model(&findings).
+		Where("repository_id = ?", repoID).
+		Where("path = ?", path).
+		Order("id ASC").
+		Scan(ctx)
+	return findings, err
+}
+
+func (s *secretFindingStoreImpl) ListByRepo(ctx context.Context, repoID int64, status types.SecretFindingStatus) ([]SecretFinding, error) {
+	var findings []SecretFinding
+	query := s.db.Operator.Core.NewSelect().
+		Model(&findings).
+		Where("repository_id = ?", repoID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id ASC").Scan(ctx)
+	return findings, err
+}
+
+func (s *secretFindingStoreImpl) Index(ctx context.Context, repoID int64, status types.SecretFindingStatus, per, page int) ([]SecretFinding, int, error) {
+	var findings []SecretFinding
+	query := s.db.Operator.Core.NewSelect().
+		Model(&findings).
+		Where("repository_id = ?", repoID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	total, err := query.
+		Order("updated_at DESC").
+		Limit(per).
+		Offset((page - 1) * per).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return findings, total, nil
+}
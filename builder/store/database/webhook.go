@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+// Webhook posts events of a repository, or of all repositories in an organization, to the url
+type Webhook struct {
+	ID int64 `bun:",pk,autoincrement" json:"id"`
+	// RepositoryID is set for webhooks of a repository
+	RepositoryID int64 `bun:",nullzero" json:"repository_id"`
+	// Namespace is the path of organization for webhooks of an organization
+	Namespace string               `bun:",nullzero" json:"namespace"`
+	URL       string               `bun:",notnull" json:"url"`
+	Secret    string               `bun:"," json:"-"`
+	Events    []types.WebhookEvent `bun:",type:jsonb" json:"events"`
+	Active    bool                 `bun:",notnull" json:"active"`
+	UserID    int64                `bun:",notnull" json:"user_id"`
+	times
+}
+
+// WebhookDelivery is an event sent or to be sent to the url of webhook
+type WebhookDelivery struct {
+	ID        int64              `bun:",pk,autoincrement" json:"id"`
+	WebhookID int64              `bun:",notnull" json:"webhook_id"`
+	Event     types.WebhookEvent `bun:",notnull" json:"event"`
+	// Payload is kept as the exact bytes signed and sent
+	Payload        string                      `bun:",notnull" json:"payload"`
+	Status         types.WebhookDeliveryStatus `bun:",notnull" json:"status"`
+	Attempts       int                         `bun:",notnull" json:"attempts"`
+	NextAttemptAt  time.Time                   `bun:",nullzero" json:"next_attempt_at"`
+	ResponseStatus int                         `bun:"," json:"response_status"`
+	ResponseBody   string                      `bun:"," json:"response_body"`
+	Error          string                      `bun:"," json:"error"`
+	DeliveredAt    time.Time                   `bun:",nullzero" json:"delivered_at"`
+	times
+}
+
+type webhookStoreImpl struct {
+	db *DB
+}
+
+type WebhookStore interface {
+	Create(ctx context.Context, webhook Webhook) (*Webhook, error)
+	FindByID(ctx context.Context, id int64) (*Webhook, error)
+	ListByRepoID(ctx context.Context, repoID int64) ([]Webhook, error)
+	ListByNamespace(ctx context.Context, namespace string) ([]Webhook, error)
+	// ListActiveForRepo returns the active webhooks of the repository and of its organization
+	ListActiveForRepo(ctx context.Context, repoID int64, namespace string) ([]Webhook, error)
+	Update(ctx context.Context, webhook Webhook) (*Webhook, error)
+	Delete(ctx context.Context, webhook Webhook) error
+
+	CreateDelivery(ctx context.Context, delivery WebhookDelivery) (*WebhookDelivery, error)
+	FindDeliveryByID(ctx context.Context, id int64) (*WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, webhookID int64, per, page int) ([]WebhookDelivery, int, error)
+	// ClaimDueDeliveries leases the pending deliveries due to send by postponing their next attempt,
+	// so that they are not sent by other dispatchers at the same time
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery WebhookDelivery) error
+}
+
+func NewWebhookStore() WebhookStore {
+	return &webhookStoreImpl{
+		db: defaultDB,
+	}
+}
+
+func (s *webhookStoreImpl) Create(ctx context.Context, webhook Webhook) (*Webhook, error) {
+	res, err := s.db.Core.NewInsert().Model(&webhook).Exec(ctx)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create webhook, error: %w", err)
+	}
+	return &webhook, nil
+}
+
+func (s *webhookStoreImpl) FindByID(ctx context.Context, id int64) (*Webhook, error) {
+	var webhook Webhook
+	err := s.db.Core.NewSelect().Model(&webhook).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (s *webhookStoreImpl) ListByRepoID(ctx context.Context, repoID int64) ([]Webhook, error) {
+	var webhooks []Webhook
+	err := s.db.Core.NewSelect().Model(&webhooks).
+		Where("repository_id = ?", repoID).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (s *webhookStoreImpl) ListByNamespace(ctx context.Context, namespace string) ([]Webhook, error) {
+	var webhooks []Webhook
+	err := s.db.Core.NewSelect().Model(&webhooks).
+		Where("namespace = ?", namespace).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (s *webhookStoreImpl) ListActiveForRepo(ctx context.Context, repoID int64, namespace string) ([]Webhook, error) {
+	var webhooks []Webhook
+	err := s.db.Core.NewSelect().Model(&webhooks).
+		Where("active = ?", true).
+		Where("repository_id = ? OR namespace = ?", repoID, namespace).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (s *webhookStoreImpl) Update(ctx context.Context, webhook Webhook) (*Webhook, error) {
+	_, err := s.db.Core.NewUpdate().Model(&webhook).WherePK().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (s *webhookStoreImpl) Delete(ctx context.Context, webhook Webhook) error {
+	_, err := s.db.Core.NewDelete().Model(&webhook).WherePK().Exec(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Core.NewDelete().Model((*WebhookDelivery)(nil)).
+		Where("webhook_id = ?", webhook.ID).
+		Exec(ctx)
+	return err
+}
+
+func (s *webhookStoreImpl) CreateDelivery(ctx context.Context, delivery WebhookDelivery) (*WebhookDelivery, error) {
+	res, err := s.db.Core.NewInsert().Model(&delivery).Exec(ctx)
+	if err := assertAffectedOneRow(res, err); err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery, error: %w", err)
+	}
+	return &delivery, nil
+}
+
+func (s *webhookStoreImpl) FindDeliveryByID(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := s.db.Core.NewSelect().Model(&delivery).
+		Where("id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (s *webhookStoreImpl) ListDeliveries(ctx context.Context, webhookID int64, per, page int) ([]WebhookDelivery, int, error) {
+	var deliveries []WebhookDelivery
+	query := s.db.Core.NewSelect().Model(&deliveries).
+		Where("webhook_id = ?", webhookID)
+	count, err := query.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id DESC").
+		Limit(per).
+		Offset((page - 1) * per).
+		Scan(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, count, nil
+}
+
+func (s *webhookStoreImpl) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	now := time.Now()
+	due := s.db.Core.NewSelect().Model((*WebhookDelivery)(nil)).
+		Column("id").
+		Where("status = ?", types.WebhookDeliveryPending).
+		Where("next_attempt_at <= ?", now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+	err := s.db.Core.NewUpdate().Model((*WebhookDelivery)(nil)).
+		Set("next_attempt_at = ?", now.Add(lease)).
+		Where("id IN (?)", due).
+		Returning("*").
+		Scan(ctx, &deliveries)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (s *webhookStoreImpl) UpdateDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	_, err := s.db.Core.NewUpdate().Model(&delivery).WherePK().Exec(ctx)
+	return err
+}
@@ -0,0 +1,70 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// PresignedPutLfsObject presigns the url to upload an lfs object with its sha256 checksum signed, so that s3 rejects
+// the content not matching the oid. The returned header must be sent with the upload request.
+func (c *Client) PresignedPutLfsObject(ctx context.Context, bucketName, objectName, oid string, expires time.Duration) (*url.URL, http.Header, error) {
+	sum, err := hex.DecodeString(oid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid lfs oid '%s', error: %w", oid, err)
+	}
+	header := make(http.Header)
+	header.Set("X-Amz-Checksum-Sha256", base64.StdEncoding.EncodeToString(sum))
+	u, err := c.Client.PresignHeader(ctx, http.MethodPut, bucketName, objectName, expires, nil, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, header, nil
+}
+
+// StoredObjectSHA256 returns the hex encoded sha256 of the object stored by s3, which is checked by s3 when the
+// object was uploaded with the checksum, like the urls presigned by PresignedPutLfsObject. Empty string is returned
+// if there is no such checksum, the checksum of multipart upload is calculated from the checksums of parts and
+// suffixed with the part count, which is not the sha256 of the object.
+func (c *Client) StoredObjectSHA256(ctx context.Context, bucketName, objectName string) (string, error) {
+	info, err := c.Client.StatObject(ctx, bucketName, objectName, minio.StatObjectOptions{Checksum: true})
+	if err != nil {
+		return "", err
+	}
+	if info.ChecksumSHA256 == "" || strings.Contains(info.ChecksumSHA256, "-") {
+		return "", nil
+	}
+	sum, err := base64.StdEncoding.DecodeString(info.ChecksumSHA256)
+	if err != nil {
+		return "", nil
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// ObjectSHA256 returns the hex encoded sha256 of the object. The checksum stored by s3 is used if the object was
+// uploaded with one, otherwise the object is read to calculate it, which takes long for large objects.
+func (c *Client) ObjectSHA256(ctx context.Context, bucketName, objectName string) (string, error) {
+	sha, err := c.StoredObjectSHA256(ctx, bucketName, objectName)
+	if err != nil || sha != "" {
+		return sha, err
+	}
+	obj, err := c.Client.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+	defer obj.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, obj); err != nil {
+		return "", fmt.Errorf("failed to read object, error: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
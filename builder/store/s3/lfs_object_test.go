@@ -0,0 +1,81 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeObjectServer serves the objects by path like s3 in path style, with the stored sha256 checksums
+func fakeObjectServer(t *testing.T, objects map[string]string, checksums map[string]string) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		if checksum, ok := checksums[r.URL.Path]; ok && r.Header.Get("X-Amz-Checksum-Mode") == "ENABLED" {
+			w.Header().Set("X-Amz-Checksum-Sha256", checksum)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(content))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client, err := NewMinioWithOptions(Options{
+		Endpoint:        strings.TrimPrefix(srv.URL, "http://"),
+		AccessKeyID:     "ak",
+		AccessKeySecret: "sk",
+		Region:          "us-east-1",
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestObjectSHA256(t *testing.T) {
+	ctx := context.Background()
+	sum := sha256.Sum256([]byte("content"))
+	oid := hex.EncodeToString(sum[:])
+	client := fakeObjectServer(t, map[string]string{
+		"/lfs/uploaded":  "content",
+		"/lfs/multipart": "content",
+		"/lfs/api":       "content",
+	}, map[string]string{
+		"/lfs/uploaded":  base64.StdEncoding.EncodeToString(sum[:]),
+		"/lfs/multipart": "Zm9vYmFy-2",
+	})
+
+	// the checksum of objects uploaded to presigned urls is checked by s3
+	sha, err := client.StoredObjectSHA256(ctx, "lfs", "uploaded")
+	require.NoError(t, err)
+	require.Equal(t, oid, sha)
+	// the checksum of multipart uploads is not the sha256 of object, which is read to calculate it
+	sha, err = client.StoredObjectSHA256(ctx, "lfs", "multipart")
+	require.NoError(t, err)
+	require.Empty(t, sha)
+	sha, err = client.ObjectSHA256(ctx, "lfs", "multipart")
+	require.NoError(t, err)
+	require.Equal(t, oid, sha)
+	sha, err = client.StoredObjectSHA256(ctx, "lfs", "api")
+	require.NoError(t, err)
+	require.Empty(t, sha)
+	sha, err = client.ObjectSHA256(ctx, "lfs", "api")
+	require.NoError(t, err)
+	require.Equal(t, oid, sha)
+
+	_, err = client.ObjectSHA256(ctx, "lfs", "missing")
+	require.Error(t, err)
+}
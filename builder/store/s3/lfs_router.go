@@ -0,0 +1,135 @@
+package s3
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+)
+
+// DefaultLfsStorageID is the id of the storage configured by config.S3
+const DefaultLfsStorageID int64 = 0
+
+// LfsStorage is a bucket of an s3 compatible storage holding lfs objects
+type LfsStorage struct {
+	ID     int64
+	Name   string
+	Client *Client
+	Bucket string
+	// Options is the connection options of the storage, for the readers other than the s3 client like duckdb
+	Options Options
+}
+
+// LfsRouter decides which storage the lfs objects are stored in
+type LfsRouter interface {
+	// ForNamespace returns the storage new lfs objects of the repositories in the namespace are uploaded to
+	ForNamespace(ctx context.Context, namespace string) (*LfsStorage, error)
+	// ByID returns the storage by id, DefaultLfsStorageID returns the default storage
+	ByID(ctx context.Context, id int64) (*LfsStorage, error)
+	// ForObject returns the storage holding the lfs object of the repository, objects without meta object are
+	// looked up in the storage the namespace of the repository is routed to
+	ForObject(ctx context.Context, repo *database.Repository, oid string) (*LfsStorage, error)
+}
+
+type lfsRouterImpl struct {
+	defaultStorage *LfsStorage
+	store          database.LfsStorageStore
+	metaObjects    database.LfsMetaObjectStore
+
+	mu sync.Mutex
+	// clients of the storages in database, the client is recreated when the storage is updated
+	storages map[int64]cachedLfsStorage
+}
+
+type cachedLfsStorage struct {
+	storage   *LfsStorage
+	updatedAt time.Time
+}
+
+func NewLfsRouter(cfg *config.Config) (LfsRouter, error) {
+	client, err := NewMinio(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &lfsRouterImpl{
+		defaultStorage: &LfsStorage{
+			ID:      DefaultLfsStorageID,
+			Name:    "default",
+			Client:  client,
+			Bucket:  cfg.S3.Bucket,
+			Options: optionsOf(cfg),
+		},
+		store:       database.NewLfsStorageStore(),
+		metaObjects: database.NewLfsMetaObjectStore(),
+		storages:    make(map[int64]cachedLfsStorage),
+	}, nil
+}
+
+func (r *lfsRouterImpl) ForNamespace(ctx context.Context, namespace string) (*LfsStorage, error) {
+	storage, err := r.store.FindByNamespace(ctx, namespace)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return r.defaultStorage, nil
+		}
+		return nil, fmt.Errorf("failed to find lfs storage of namespace '%s', error: %w", namespace, err)
+	}
+	return r.fromDB(storage)
+}
+
+func (r *lfsRouterImpl) ByID(ctx context.Context, id int64) (*LfsStorage, error) {
+	if id == DefaultLfsStorageID {
+		return r.defaultStorage, nil
+	}
+	storage, err := r.store.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lfs storage by id %d, error: %w", id, err)
+	}
+	return r.fromDB(storage)
+}
+
+func (r *lfsRouterImpl) ForObject(ctx context.Context, repo *database.Repository, oid string) (*LfsStorage, error) {
+	obj, err := r.metaObjects.FindByOID(ctx, repo.ID, oid)
+	if err == nil {
+		return r.ByID(ctx, obj.StorageID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to find lfs meta object, error: %w", err)
+	}
+	namespace, _ := repo.NamespaceAndName()
+	return r.ForNamespace(ctx, namespace)
+}
+
+func (r *lfsRouterImpl) fromDB(storage *database.LfsStorage) (*LfsStorage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cached, ok := r.storages[storage.ID]
+	if ok && cached.updatedAt.Equal(storage.UpdatedAt) {
+		return cached.storage, nil
+	}
+	opts := Options{
+		Endpoint:         storage.Endpoint,
+		InternalEndpoint: storage.InternalEndpoint,
+		AccessKeyID:      storage.AccessKeyID,
+		AccessKeySecret:  storage.AccessKeySecret,
+		Region:           storage.Region,
+		EnableSSL:        storage.EnableSSL,
+	}
+	client, err := NewMinioWithOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init client of lfs storage '%s', error: %w", storage.Name, err)
+	}
+	s := &LfsStorage{
+		ID:      storage.ID,
+		Name:    storage.Name,
+		Client:  client,
+		Bucket:  storage.Bucket,
+		Options: opts,
+	}
+	r.storages[storage.ID] = cachedLfsStorage{storage: s, updatedAt: storage.UpdatedAt}
+	return s, nil
+}
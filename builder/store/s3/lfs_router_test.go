@@ -0,0 +1,94 @@
+package s3
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+)
+
+type fakeLfsStorageStore struct {
+	database.LfsStorageStore
+	storages map[int64]*database.LfsStorage
+	routes   map[string]int64
+}
+
+func (s *fakeLfsStorageStore) FindByID(ctx context.Context, id int64) (*database.LfsStorage, error) {
+	storage, ok := s.storages[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return storage, nil
+}
+
+func (s *fakeLfsStorageStore) FindByNamespace(ctx context.Context, namespace string) (*database.LfsStorage, error) {
+	id, ok := s.routes[namespace]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return s.FindByID(ctx, id)
+}
+
+type fakeLfsMetaObjectStore struct {
+	database.LfsMetaObjectStore
+	objects map[string]*database.LfsMetaObject
+}
+
+func (s *fakeLfsMetaObjectStore) FindByOID(ctx context.Context, repoID int64, oid string) (*database.LfsMetaObject, error) {
+	obj, ok := s.objects[oid]
+	if !ok || obj.RepositoryID != repoID {
+		return nil, sql.ErrNoRows
+	}
+	return obj, nil
+}
+
+func TestLfsRouter_ForObject(t *testing.T) {
+	ctx := context.Background()
+	storages := &fakeLfsStorageStore{
+		storages: map[int64]*database.LfsStorage{
+			1: {ID: 1, Name: "cold", Endpoint: "cold.example.com", Bucket: "cold-lfs"},
+			2: {ID: 2, Name: "hot", Endpoint: "hot.example.com", Bucket: "hot-lfs", EnableSSL: true},
+		},
+		routes: map[string]int64{"big-org": 2},
+	}
+	r := &lfsRouterImpl{
+		defaultStorage: &LfsStorage{ID: DefaultLfsStorageID, Name: "default", Bucket: "lfs"},
+		store:          storages,
+		metaObjects: &fakeLfsMetaObjectStore{objects: map[string]*database.LfsMetaObject{
+			"in-default": {RepositoryID: 1, Oid: "in-default", StorageID: DefaultLfsStorageID},
+			"in-cold":    {RepositoryID: 1, Oid: "in-cold", StorageID: 1},
+		}},
+		storages: make(map[int64]cachedLfsStorage),
+	}
+	repo := &database.Repository{ID: 1, Path: "big-org/model"}
+
+	// objects stay in the storage recorded by their meta objects, even if the namespace is routed elsewhere
+	storage, err := r.ForObject(ctx, repo, "in-default")
+	require.NoError(t, err)
+	require.Equal(t, "lfs", storage.Bucket)
+	storage, err = r.ForObject(ctx, repo, "in-cold")
+	require.NoError(t, err)
+	require.Equal(t, "cold-lfs", storage.Bucket)
+	require.Equal(t, "cold.example.com", storage.Options.Endpoint)
+
+	// new objects go to the storage of the namespace
+	hot, err := r.ForObject(ctx, repo, "new")
+	require.NoError(t, err)
+	require.Equal(t, "hot-lfs", hot.Bucket)
+	require.True(t, hot.Options.EnableSSL)
+	storage, err = r.ForObject(ctx, &database.Repository{ID: 2, Path: "user/model"}, "new")
+	require.NoError(t, err)
+	require.Equal(t, "lfs", storage.Bucket)
+
+	// clients are reused until the storage is updated
+	again, err := r.ByID(ctx, 2)
+	require.NoError(t, err)
+	require.Same(t, hot, again)
+	storages.storages[2].UpdatedAt = time.Now()
+	again, err = r.ByID(ctx, 2)
+	require.NoError(t, err)
+	require.NotSame(t, hot, again)
+}
@@ -13,11 +13,36 @@ import (
 )
 
 func NewMinio(cfg *config.Config) (*Client, error) {
-	minioClient, err := minio.New(cfg.S3.Endpoint, &minio.Options{
-		Creds:        credentials.NewStaticV4(cfg.S3.AccessKeyID, cfg.S3.AccessKeySecret, ""),
-		Secure:       cfg.S3.EnableSSL,
+	return NewMinioWithOptions(optionsOf(cfg))
+}
+
+func optionsOf(cfg *config.Config) Options {
+	return Options{
+		Endpoint:         cfg.S3.Endpoint,
+		InternalEndpoint: cfg.S3.InternalEndpoint,
+		AccessKeyID:      cfg.S3.AccessKeyID,
+		AccessKeySecret:  cfg.S3.AccessKeySecret,
+		Region:           cfg.S3.Region,
+		EnableSSL:        cfg.S3.EnableSSL,
+	}
+}
+
+// Options is the connection options of an s3 compatible storage
+type Options struct {
+	Endpoint         string
+	InternalEndpoint string
+	AccessKeyID      string
+	AccessKeySecret  string
+	Region           string
+	EnableSSL        bool
+}
+
+func NewMinioWithOptions(opts Options) (*Client, error) {
+	minioClient, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(opts.AccessKeyID, opts.AccessKeySecret, ""),
+		Secure:       opts.EnableSSL,
 		BucketLookup: minio.BucketLookupAuto,
-		Region:       cfg.S3.Region,
+		Region:       opts.Region,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to init s3 client, error:%w", err)
@@ -25,12 +50,12 @@ func NewMinio(cfg *config.Config) (*Client, error) {
 	client := &Client{
 		Client: minioClient,
 	}
-	if len(cfg.S3.InternalEndpoint) > 0 {
-		minioClientInternal, err := minio.New(cfg.S3.InternalEndpoint, &minio.Options{
-			Creds:        credentials.NewStaticV4(cfg.S3.AccessKeyID, cfg.S3.AccessKeySecret, ""),
-			Secure:       cfg.S3.EnableSSL,
+	if len(opts.InternalEndpoint) > 0 {
+		minioClientInternal, err := minio.New(opts.InternalEndpoint, &minio.Options{
+			Creds:        credentials.NewStaticV4(opts.AccessKeyID, opts.AccessKeySecret, ""),
+			Secure:       opts.EnableSSL,
 			BucketLookup: minio.BucketLookupAuto,
-			Region:       cfg.S3.Region,
+			Region:       opts.Region,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to init s3 internal client, error:%w", err)
@@ -2,7 +2,10 @@ package s3
 
 import (
 	"context"
+	"slices"
 	"testing"
+
+	"github.com/minio/minio-go/v7"
 )
 
 // test for useInternalClient
@@ -22,3 +25,37 @@ func Test_useInternalClient(t *testing.T) {
 	}
 
 }
+
+func TestMultipartChunkSize(t *testing.T) {
+	chunkSize := int64(100 << 20)
+	if got := MultipartChunkSize(50<<30, chunkSize); got != chunkSize {
+		t.Errorf("expected chunk size %d, got %d", chunkSize, got)
+	}
+	size := int64(2 << 40)
+	got := MultipartChunkSize(size, chunkSize)
+	if (size+got-1)/got > maxMultipartParts {
+		t.Errorf("chunk size %d makes more than %d parts", got, maxMultipartParts)
+	}
+}
+
+func TestMissingParts(t *testing.T) {
+	chunkSize := int64(100)
+	size := int64(350)
+	if got := MissingParts(size, chunkSize, nil); !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Errorf("expected all parts to be missing, got %v", got)
+	}
+	uploaded := []minio.ObjectPart{
+		{PartNumber: 1, Size: 100},
+		// uploaded with another chunk size
+		{PartNumber: 2, Size: 50},
+		{PartNumber: 4, Size: 50},
+	}
+	if got := MissingParts(size, chunkSize, uploaded); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("expected part 2 and 3 to be missing, got %v", got)
+	}
+	uploaded = append(uploaded, minio.ObjectPart{PartNumber: 3, Size: 100})
+	uploaded[1].Size = 100
+	if got := MissingParts(size, chunkSize, uploaded); len(got) != 0 {
+		t.Errorf("expected no part to be missing, got %v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// s3 allows at most 10000 parts in a multipart upload
+const maxMultipartParts = 10000
+
+// MultipartChunkSize returns the part size to upload the object of the size in, it is enlarged when
+// the object would have too many parts
+func MultipartChunkSize(size, chunkSize int64) int64 {
+	if minChunkSize := (size + maxMultipartParts - 1) / maxMultipartParts; chunkSize < minChunkSize {
+		return minChunkSize
+	}
+	return chunkSize
+}
+
+// FindMultipartUpload returns the id of the unfinished multipart upload of the object, or empty string if there is none
+func (c *Client) FindMultipartUpload(ctx context.Context, bucketName, objectName string) (string, error) {
+	core := minio.Core{Client: c.Client}
+	var keyMarker, uploadIDMarker string
+	for {
+		result, err := core.ListMultipartUploads(ctx, bucketName, objectName, keyMarker, uploadIDMarker, "", 1000)
+		if err != nil {
+			return "", fmt.Errorf("failed to list multipart uploads, error: %w", err)
+		}
+		for _, upload := range result.Uploads {
+			if upload.Key == objectName {
+				return upload.UploadID, nil
+			}
+		}
+		if !result.IsTruncated {
+			return "", nil
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+}
+
+// FindOrCreateMultipartUpload resumes the unfinished multipart upload of the object, or creates a new one
+func (c *Client) FindOrCreateMultipartUpload(ctx context.Context, bucketName, objectName string, opts minio.PutObjectOptions) (string, error) {
+	uploadID, err := c.FindMultipartUpload(ctx, bucketName, objectName)
+	if err != nil {
+		return "", err
+	}
+	if uploadID != "" {
+		return uploadID, nil
+	}
+	core := minio.Core{Client: c.Client}
+	uploadID, err = core.NewMultipartUpload(ctx, bucketName, objectName, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to create multipart upload, error: %w", err)
+	}
+	return uploadID, nil
+}
+
+// ListUploadedParts returns the parts uploaded to s3 of the multipart upload
+func (c *Client) ListUploadedParts(ctx context.Context, bucketName, objectName, uploadID string) ([]minio.ObjectPart, error) {
+	core := minio.Core{Client: c.Client}
+	var (
+		parts  []minio.ObjectPart
+		marker int
+	)
+	for {
+		result, err := core.ListObjectParts(ctx, bucketName, objectName, uploadID, marker, 1000)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list uploaded parts, error: %w", err)
+		}
+		parts = append(parts, result.ObjectParts...)
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+// MissingParts returns the numbers of parts not uploaded yet of the object of the size uploaded in chunks,
+// parts uploaded with another size, like before the chunk size is changed, are uploaded again
+func MissingParts(size, chunkSize int64, uploaded []minio.ObjectPart) []int {
+	uploadedSizes := make(map[int]int64, len(uploaded))
+	for _, part := range uploaded {
+		uploadedSizes[part.PartNumber] = part.Size
+	}
+	parts := int((size + chunkSize - 1) / chunkSize)
+	var missing []int
+	for i := 1; i <= parts; i++ {
+		partSize := chunkSize
+		if i == parts {
+			partSize = size - chunkSize*int64(parts-1)
+		}
+		if uploadedSize, ok := uploadedSizes[i]; !ok || uploadedSize != partSize {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// PresignedPutObjectParts presigns the urls to upload the parts of a multipart upload by part numbers,
+// which start from 1
+func (c *Client) PresignedPutObjectParts(ctx context.Context, bucketName, objectName, uploadID string, partNumbers []int, expires time.Duration) (map[int]*url.URL, error) {
+	urls := make(map[int]*url.URL, len(partNumbers))
+	for _, i := range partNumbers {
+		reqParams := make(url.Values)
+		reqParams.Set("partNumber", strconv.Itoa(i))
+		reqParams.Set("uploadId", uploadID)
+		u, err := c.Client.Presign(ctx, "PUT", bucketName, objectName, expires, reqParams)
+		if err != nil {
+			return nil, fmt.Errorf("failed to presign part %d, error: %w", i, err)
+		}
+		urls[i] = u
+	}
+	return urls, nil
+}
+
+// CompleteMultipartUpload completes the multipart upload with all the parts uploaded to s3,
+// so that clients do not need to keep the etags of parts uploaded before resuming
+func (c *Client) CompleteMultipartUpload(ctx context.Context, bucketName, objectName, uploadID string) (minio.UploadInfo, error) {
+	uploaded, err := c.ListUploadedParts(ctx, bucketName, objectName, uploadID)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	parts := make([]minio.CompletePart, 0, len(uploaded))
+	for _, part := range uploaded {
+		parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	if len(parts) == 0 {
+		return minio.UploadInfo{}, fmt.Errorf("no part of multipart upload %s is uploaded", uploadID)
+	}
+	core := minio.Core{Client: c.Client}
+	info, err := core.CompleteMultipartUpload(ctx, bucketName, objectName, uploadID, parts, minio.PutObjectOptions{})
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to complete multipart upload, error: %w", err)
+	}
+	return info, nil
+}
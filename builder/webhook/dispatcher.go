@@ -0,0 +1,176 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"opencsg.com/csghub-server/builder/safehttp"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+const (
+	// MaxAttempts is the times a delivery is tried before it is marked as failed
+	MaxAttempts = 5
+
+	SignatureHeader = "X-CSGHub-Signature-256"
+	EventHeader     = "X-CSGHub-Event"
+	DeliveryHeader  = "X-CSGHub-Delivery"
+
+	pollInterval    = 5 * time.Second
+	claimBatchSize  = 50
+	deliveryTimeout = 10 * time.Second
+	// deliveries claimed by a dispatcher are not claimed again by others within the lease,
+	// it must be longer than the time to send a batch
+	claimLease = 10 * time.Minute
+
+	// the response body is kept for debugging in the delivery log, only the beginning of it to not echo the content
+	// of arbitrary urls back
+	maxResponseBodyLen = 256
+	minRetryBackoff    = 30 * time.Second
+	maxRetryBackoff    = time.Hour
+)
+
+// Dispatcher sends the queued webhook deliveries and retries the failed ones with backoff
+type Dispatcher struct {
+	webhookStore database.WebhookStore
+	hc           *http.Client
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		webhookStore: database.NewWebhookStore(),
+		// webhook urls are given by users, they are not allowed to reach the internal services
+		hc: safehttp.NewClient(deliveryTimeout),
+	}
+}
+
+// Run sends due deliveries until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.dispatchDue(ctx)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	for {
+		deliveries, err := d.webhookStore.ClaimDueDeliveries(ctx, claimBatchSize, claimLease)
+		if err != nil {
+			slog.Error("fail to claim due webhook deliveries", slog.Any("error", err))
+			return
+		}
+		for _, delivery := range deliveries {
+			webhook, err := d.webhookStore.FindByID(ctx, delivery.WebhookID)
+			if err != nil {
+				slog.Error("fail to find webhook of delivery", slog.Int64("delivery_id", delivery.ID),
+					slog.Int64("webhook_id", delivery.WebhookID), slog.Any("error", err))
+				continue
+			}
+			if !webhook.Active {
+				delivery.Status = types.WebhookDeliveryFailed
+				delivery.Error = "webhook is inactive"
+				if err := d.webhookStore.UpdateDelivery(ctx, delivery); err != nil {
+					slog.Error("fail to update webhook delivery", slog.Int64("delivery_id", delivery.ID), slog.Any("error", err))
+				}
+				continue
+			}
+			_, _ = d.Deliver(ctx, webhook, &delivery)
+		}
+		if len(deliveries) < claimBatchSize {
+			return
+		}
+	}
+}
+
+// Deliver sends the delivery once and records the result, a failed delivery is scheduled to retry until MaxAttempts
+func (d *Dispatcher) Deliver(ctx context.Context, webhook *database.Webhook, delivery *database.WebhookDelivery) (*database.WebhookDelivery, error) {
+	delivery.Attempts++
+	statusCode, body, sendErr := d.send(ctx, webhook, delivery)
+	delivery.ResponseStatus = statusCode
+	delivery.ResponseBody = body
+	if sendErr == nil {
+		delivery.Status = types.WebhookDeliverySucceeded
+		delivery.Error = ""
+		delivery.DeliveredAt = time.Now()
+	} else {
+		delivery.Error = sendErr.Error()
+		if delivery.Attempts >= MaxAttempts {
+			delivery.Status = types.WebhookDeliveryFailed
+		} else {
+			delivery.Status = types.WebhookDeliveryPending
+			delivery.NextAttemptAt = time.Now().Add(RetryBackoff(delivery.Attempts))
+		}
+		slog.Warn("fail to deliver webhook", slog.Int64("webhook_id", webhook.ID), slog.Int64("delivery_id", delivery.ID),
+			slog.Int("attempts", delivery.Attempts), slog.Any("error", sendErr))
+	}
+	err := d.webhookStore.UpdateDelivery(ctx, *delivery)
+	if err != nil {
+		slog.Error("fail to update webhook delivery", slog.Int64("delivery_id", delivery.ID), slog.Any("error", err))
+		return delivery, fmt.Errorf("failed to update webhook delivery, error: %w", err)
+	}
+	return delivery, nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, webhook *database.Webhook, delivery *database.WebhookDelivery) (int, string, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request, error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CSGHub-Hookshot")
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	if webhook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(webhook.Secret, payload))
+	}
+	resp, err := d.hc.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to post payload, error: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyLen))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(body), fmt.Errorf("unexpected response status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// Sign returns the signature of the payload in the form of `sha256=<hex encoded HMAC-SHA256>`
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryBackoff returns the delay before retrying a delivery failed for the attempts times,
+// it doubles from 30 seconds and is capped at 1 hour
+func RetryBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return minRetryBackoff
+	}
+	backoff := minRetryBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+	return backoff
+}
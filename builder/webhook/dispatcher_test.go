@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+	for _, c := range cases {
+		if got := RetryBackoff(c.attempts); got != c.want {
+			t.Errorf("RetryBackoff(%d) = %s, want %s", c.attempts, got, c.want)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// the HMAC-SHA256 example of RFC 4231 test case 2
+	got := Sign("Jefe", []byte("what do ya want for nothing?"))
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// Notifier queues the events of repositories for delivery to the webhooks subscribing them,
+// deliveries are sent by Dispatcher asynchronously so that notifying never blocks the caller
+type Notifier interface {
+	Notify(ctx context.Context, repo *database.Repository, event types.WebhookEvent, data any) error
+}
+
+type notifierImpl struct {
+	webhookStore database.WebhookStore
+}
+
+func NewNotifier() Notifier {
+	return &notifierImpl{
+		webhookStore: database.NewWebhookStore(),
+	}
+}
+
+func (n *notifierImpl) Notify(ctx context.Context, repo *database.Repository, event types.WebhookEvent, data any) error {
+	namespace, _ := repo.NamespaceAndName()
+	webhooks, err := n.webhookStore.ListActiveForRepo(ctx, repo.ID, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks of repository '%s', error: %w", repo.Path, err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+	payload, err := NewPayload(repo, event, data)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, webhook := range webhooks {
+		if !slices.Contains(webhook.Events, event) {
+			continue
+		}
+		_, err := n.webhookStore.CreateDelivery(ctx, database.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        types.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		})
+		if err != nil {
+			slog.Error("fail to queue webhook delivery", slog.Int64("webhook_id", webhook.ID),
+				slog.String("event", string(event)), slog.String("repo", repo.Path), slog.Any("error", err))
+		}
+	}
+	return nil
+}
+
+// NewPayload encodes the body posted to webhooks for the event of the repository, repo is nil for events of organizations
+func NewPayload(repo *database.Repository, event types.WebhookEvent, data any) ([]byte, error) {
+	p := types.WebhookPayload{
+		Event:     event,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+	if repo != nil {
+		p.Repository = &types.WebhookRepository{
+			ID:       repo.ID,
+			Path:     repo.Path,
+			RepoType: repo.RepositoryType,
+			Private:  repo.Private,
+		}
+	}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook payload, error: %w", err)
+	}
+	return payload, nil
+}
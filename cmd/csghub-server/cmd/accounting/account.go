@@ -7,6 +7,8 @@ import (
 func init() {
 	// add subcommands here
 	Cmd.AddCommand(launchCmd)
+	Cmd.AddCommand(dlqCmd)
+	Cmd.AddCommand(usageCmd)
 }
 
 var Cmd = &cobra.Command{
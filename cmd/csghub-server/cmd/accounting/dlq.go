@@ -0,0 +1,167 @@
+package accounting
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/mq"
+)
+
+var (
+	dlqComp      component.DLQComponent
+	dlqFromSeq   uint64
+	dlqLimit     int
+	dlqPatch     string
+	dlqReplayAll bool
+	dlqPurgeAll  bool
+)
+
+func init() {
+	dlqListCmd.Flags().Uint64Var(&dlqFromSeq, "from", 0, "the sequence of DLQ message to list from")
+	dlqListCmd.Flags().IntVar(&dlqLimit, "limit", 20, "the max number of DLQ messages to list")
+	dlqReplayCmd.Flags().StringVar(&dlqPatch, "patch", "", `json of the metering event fields to correct before replay, e.g. '{"user_uuid":"..."}'`)
+	dlqReplayCmd.Flags().BoolVar(&dlqReplayAll, "all", false, "replay all DLQ messages")
+	dlqPurgeCmd.Flags().BoolVar(&dlqPurgeAll, "all", false, "remove all DLQ messages")
+
+	dlqCmd.AddCommand(dlqListCmd)
+	dlqCmd.AddCommand(dlqInspectCmd)
+	dlqCmd.AddCommand(dlqReplayCmd)
+	dlqCmd.AddCommand(dlqPurgeCmd)
+}
+
+var dlqCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "inspect and replay the metering events in dead-letter queue",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config,%w", err)
+		}
+		dbConfig := database.DBConfig{
+			Dialect: database.DatabaseDialect(cfg.Database.Driver),
+			DSN:     cfg.Database.DSN,
+		}
+		database.InitDB(dbConfig)
+
+		mqHandler, err := mq.Init(cfg)
+		if err != nil {
+			return fmt.Errorf("fail to build message queue handler: %w", err)
+		}
+		dlqComp = component.NewDLQComponent(mqHandler)
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var dlqListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list DLQ messages",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		msgs, err := dlqComp.ListMessages(cmd.Context(), dlqFromSeq, dlqLimit)
+		if err != nil {
+			return err
+		}
+		return printJSON(msgs)
+	},
+}
+
+var dlqInspectCmd = &cobra.Command{
+	Use:   "inspect [sequence]",
+	Short: "show a DLQ message and its metering event",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		seq, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid sequence %s, %w", args[0], err)
+		}
+		msg, err := dlqComp.GetMessage(cmd.Context(), seq)
+		if err != nil {
+			return err
+		}
+		return printJSON(msg)
+	},
+}
+
+var dlqReplayCmd = &cobra.Command{
+	Use:   "replay [sequence]",
+	Short: "handle a DLQ message again, events already billed are never billed twice",
+	Example: `
+csghub-server accounting dlq replay 12 --patch '{"resource_id":"1"}'
+csghub-server accounting dlq replay --all
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dlqReplayAll {
+			results, err := dlqComp.ReplayAll(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return printJSON(results)
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("sequence of DLQ message is required without --all")
+		}
+		seq, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid sequence %s, %w", args[0], err)
+		}
+		var patch *types.MeteringEventPatch
+		if dlqPatch != "" {
+			patch = &types.MeteringEventPatch{}
+			err = json.Unmarshal([]byte(dlqPatch), patch)
+			if err != nil {
+				return fmt.Errorf("invalid patch, %w", err)
+			}
+		}
+		err = dlqComp.Replay(cmd.Context(), seq, patch)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("DLQ message %d replayed\n", seq)
+		return nil
+	},
+}
+
+var dlqPurgeCmd = &cobra.Command{
+	Use:   "purge [sequence]",
+	Short: "remove a DLQ message, or all DLQ messages with --all",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dlqPurgeAll {
+			err := dlqComp.Purge(cmd.Context())
+			if err != nil {
+				return err
+			}
+			fmt.Println("DLQ purged")
+			return nil
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("sequence of DLQ message is required without --all")
+		}
+		seq, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid sequence %s, %w", args[0], err)
+		}
+		err = dlqComp.DeleteMessage(cmd.Context(), seq)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("DLQ message %d removed\n", seq)
+		return nil
+	},
+}
+
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
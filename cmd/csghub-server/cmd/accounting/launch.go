@@ -1,10 +1,12 @@
 package accounting
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 
 	"github.com/spf13/cobra"
+	"opencsg.com/csghub-server/accounting/component"
 	"opencsg.com/csghub-server/accounting/consumer"
 	"opencsg.com/csghub-server/accounting/router"
 	"opencsg.com/csghub-server/api/httpbase"
@@ -42,7 +44,12 @@ var launchCmd = &cobra.Command{
 		meter := consumer.NewMetering(mqHandler, cfg)
 		meter.Run()
 
-		r, err := router.NewAccountRouter(cfg)
+		// Generate monthly invoices
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go component.NewBillingComponent(mqHandler).RunInvoicing(ctx)
+
+		r, err := router.NewAccountRouter(cfg, mqHandler)
 		if err != nil {
 			return fmt.Errorf("failed to init router: %w", err)
 		}
@@ -0,0 +1,65 @@
+package accounting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"opencsg.com/csghub-server/accounting/component"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+)
+
+var (
+	usageFrom string
+	usageTo   string
+)
+
+func init() {
+	usageRebuildCmd.Flags().StringVar(&usageFrom, "from", "", "the first day to roll up, e.g. 2024-06-01")
+	usageRebuildCmd.Flags().StringVar(&usageTo, "to", "", "the day after the last day to roll up, e.g. 2024-07-01")
+	_ = usageRebuildCmd.MarkFlagRequired("from")
+	_ = usageRebuildCmd.MarkFlagRequired("to")
+
+	usageCmd.AddCommand(usageRebuildCmd)
+}
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "manage the daily rollups of usage",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var usageRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "roll up the saved metering events of the days in UTC again, it's safe to run again",
+	Example: `
+csghub-server accounting usage rebuild --from 2024-06-01 --to 2024-07-01
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		start, err := time.Parse(time.DateOnly, usageFrom)
+		if err != nil {
+			return fmt.Errorf("invalid from day %s, %w", usageFrom, err)
+		}
+		end, err := time.Parse(time.DateOnly, usageTo)
+		if err != nil {
+			return fmt.Errorf("invalid to day %s, %w", usageTo, err)
+		}
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config,%w", err)
+		}
+		database.InitDB(database.DBConfig{
+			Dialect: database.DatabaseDialect(cfg.Database.Driver),
+			DSN:     cfg.Database.DSN,
+		})
+		rows, err := component.NewUsageComponent().RebuildUsage(cmd.Context(), start, end)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d daily usage rollups rebuilt\n", rows)
+		return nil
+	},
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+)
+
+var storageOpts database.LfsStorage
+
+func init() {
+	createLfsStorageCmd.Flags().StringVar(&storageOpts.Name, "name", "", "name of the lfs storage")
+	createLfsStorageCmd.Flags().StringVar(&storageOpts.Endpoint, "endpoint", "", "endpoint of the s3 compatible storage")
+	createLfsStorageCmd.Flags().StringVar(&storageOpts.InternalEndpoint, "internal-endpoint", "", "internal endpoint of the s3 compatible storage")
+	createLfsStorageCmd.Flags().StringVar(&storageOpts.AccessKeyID, "access-key-id", "", "access key id of the s3 compatible storage")
+	createLfsStorageCmd.Flags().StringVar(&storageOpts.AccessKeySecret, "access-key-secret", "", "access key secret of the s3 compatible storage")
+	createLfsStorageCmd.Flags().StringVar(&storageOpts.Region, "region", "", "region of the s3 compatible storage")
+	createLfsStorageCmd.Flags().StringVar(&storageOpts.Bucket, "bucket", "", "bucket to store lfs objects in")
+	createLfsStorageCmd.Flags().BoolVar(&storageOpts.EnableSSL, "enable-ssl", false, "whether to connect the storage with ssl")
+	_ = createLfsStorageCmd.MarkFlagRequired("name")
+	_ = createLfsStorageCmd.MarkFlagRequired("endpoint")
+	_ = createLfsStorageCmd.MarkFlagRequired("access-key-id")
+	_ = createLfsStorageCmd.MarkFlagRequired("access-key-secret")
+	_ = createLfsStorageCmd.MarkFlagRequired("bucket")
+}
+
+var createLfsStorageCmd = &cobra.Command{
+	Use:   "create-lfs-storage",
+	Short: "the cmd to add an s3 compatible storage which namespaces can be routed to for lfs objects",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		config, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config,%w", err)
+		}
+
+		dbConfig := database.DBConfig{
+			Dialect: database.DatabaseDialect(config.Database.Driver),
+			DSN:     config.Database.DSN,
+		}
+
+		database.InitDB(dbConfig)
+		return
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		storage, err := database.NewLfsStorageStore().Create(context.Background(), storageOpts)
+		if err != nil {
+			return err
+		}
+		slog.Info("lfs storage created", slog.Int64("id", storage.ID), slog.String("name", storage.Name),
+			slog.String("endpoint", storage.Endpoint), slog.String("bucket", storage.Bucket))
+		return nil
+	},
+}
@@ -53,7 +53,7 @@ var generateLfsMetaObjectsCmd = &cobra.Command{
 			return
 		}
 
-		s3Client, err := s3.NewMinio(config)
+		router, err := s3.NewLfsRouter(config)
 		if err != nil {
 			newError := fmt.Errorf("fail to init s3 client for code,error:%w", err)
 			slog.Error(newError.Error())
@@ -84,7 +84,7 @@ var generateLfsMetaObjectsCmd = &cobra.Command{
 				break
 			}
 			for _, repo := range repos {
-				err := fetchAllPointersForRepo(config, gitServer, s3Client, lfsMetaObjectStore, repo)
+				err := fetchAllPointersForRepo(gitServer, router, lfsMetaObjectStore, repo)
 				if err != nil {
 					slog.Error("fail to fetch all pointers for repository", slog.Any("err", err))
 					continue
@@ -94,7 +94,7 @@ var generateLfsMetaObjectsCmd = &cobra.Command{
 	},
 }
 
-func fetchAllPointersForRepo(config *config.Config, gitServer gitserver.GitServer, s3Client *s3.Client, lfsMetaObjectStore database.LfsMetaObjectStore, repo database.Repository) error {
+func fetchAllPointersForRepo(gitServer gitserver.GitServer, router s3.LfsRouter, lfsMetaObjectStore database.LfsMetaObjectStore, repo database.Repository) error {
 	namespace := strings.Split(repo.Path, "/")[0]
 	name := strings.Split(repo.Path, "/")[1]
 	ref := repo.DefaultBranch
@@ -119,17 +119,29 @@ func fetchAllPointersForRepo(config *config.Config, gitServer gitserver.GitServe
 			Oid:  lfsPointer.FileOid,
 			Size: lfsPointer.FileSize,
 		}
-		checkAndUpdateLfsMetaObjects(config, s3Client, lfsMetaObjectStore, repo, &pointer)
+		checkAndUpdateLfsMetaObjects(router, lfsMetaObjectStore, repo, &pointer)
 	}
 	return nil
 }
 
-func checkAndUpdateLfsMetaObjects(config *config.Config, s3Client *s3.Client, lfsMetaObjectStore database.LfsMetaObjectStore, repo database.Repository, pointer *types.Pointer) {
+func checkAndUpdateLfsMetaObjects(router s3.LfsRouter, lfsMetaObjectStore database.LfsMetaObjectStore, repo database.Repository, pointer *types.Pointer) {
 	var exists bool
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
+	// keep the object in the storage it was recorded in, or look for it in the storage of the namespace
+	var storage *s3.LfsStorage
+	lfsMetaObject, err := lfsMetaObjectStore.FindByOID(ctx, repo.ID, pointer.Oid)
+	if err == nil {
+		storage, err = router.ByID(ctx, lfsMetaObject.StorageID)
+	} else {
+		storage, err = router.ForNamespace(ctx, strings.Split(repo.Path, "/")[0])
+	}
+	if err != nil {
+		slog.Error("failed to find lfs storage of object", slog.String("oid", pointer.Oid), slog.Any("error", err))
+		return
+	}
 	objectKey := path.Join("lfs", pointer.RelativePath())
-	_, err := s3Client.StatObject(ctx, config.S3.Bucket, objectKey, minio.StatObjectOptions{})
+	_, err = storage.Client.StatObject(ctx, storage.Bucket, objectKey, minio.StatObjectOptions{})
 	if err != nil {
 		if os.IsNotExist(err) {
 			exists = false
@@ -145,5 +157,6 @@ func checkAndUpdateLfsMetaObjects(config *config.Config, s3Client *s3.Client, lf
 		Size:         pointer.Size,
 		RepositoryID: repo.ID,
 		Existing:     exists,
+		StorageID:    storage.ID,
 	})
 }
@@ -6,6 +6,9 @@ import (
 
 func init() {
 	Cmd.AddCommand(generateLfsMetaObjectsCmd)
+	Cmd.AddCommand(createLfsStorageCmd)
+	Cmd.AddCommand(migrateLfsStorageCmd)
+	Cmd.AddCommand(lfsGCCmd)
 }
 
 var Cmd = &cobra.Command{
@@ -0,0 +1,191 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/spf13/cobra"
+	"opencsg.com/csghub-server/builder/git"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/store/s3"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+var (
+	gcDryRun      bool
+	gcGracePeriod time.Duration
+)
+
+func init() {
+	lfsGCCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "only report the unreferenced lfs objects without deleting them")
+	lfsGCCmd.Flags().DurationVar(&gcGracePeriod, "grace-period", 7*24*time.Hour, "unreferenced lfs objects modified within the period are kept, as their pushes may be in progress")
+}
+
+var lfsGCCmd = &cobra.Command{
+	Use:   "lfs-gc",
+	Short: "the cmd to delete lfs objects which are not referenced by any repository",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		config, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config,%w", err)
+		}
+
+		dbConfig := database.DBConfig{
+			Dialect: database.DatabaseDialect(config.Database.Driver),
+			DSN:     config.Database.DSN,
+		}
+
+		database.InitDB(dbConfig)
+		ctx := context.WithValue(cmd.Context(), "config", config)
+		cmd.SetContext(ctx)
+		return
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		config, ok := ctx.Value("config").(*config.Config)
+		if !ok {
+			return fmt.Errorf("config not found in context")
+		}
+
+		if config.GitServer.Type == types.GitServerTypeGitea {
+			return fmt.Errorf("lfs gc is not supported by git server %s", config.GitServer.Type)
+		}
+
+		gitServer, err := git.NewGitServer(config)
+		if err != nil {
+			return fmt.Errorf("fail to create git server,error:%w", err)
+		}
+		router, err := s3.NewLfsRouter(config)
+		if err != nil {
+			return fmt.Errorf("fail to init lfs storage router,error:%w", err)
+		}
+
+		// a missing repository would make its objects look unreferenced, so any failure aborts the gc
+		reachable, err := reachableLfsOids(gitServer, database.NewRepoStore())
+		if err != nil {
+			return err
+		}
+		slog.Info("reachable lfs objects collected", slog.Int("count", len(reachable)))
+
+		storages := []int64{s3.DefaultLfsStorageID}
+		dbStorages, err := database.NewLfsStorageStore().List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list lfs storages, error: %w", err)
+		}
+		for _, s := range dbStorages {
+			storages = append(storages, s.ID)
+		}
+
+		lfsMetaObjectStore := database.NewLfsMetaObjectStore()
+		cutoff := time.Now().Add(-gcGracePeriod)
+		var total, deleted int
+		var totalSize int64
+		for _, id := range storages {
+			storage, err := router.ByID(ctx, id)
+			if err != nil {
+				return err
+			}
+			for obj := range storage.Client.ListObjects(ctx, storage.Bucket, minio.ListObjectsOptions{Prefix: "lfs/", Recursive: true}) {
+				if obj.Err != nil {
+					return fmt.Errorf("failed to list objects of lfs storage '%s', error: %w", storage.Name, obj.Err)
+				}
+				oid := oidFromObjectKey(obj.Key)
+				if _, ok := reachable[oid]; ok {
+					continue
+				}
+				if obj.LastModified.After(cutoff) {
+					continue
+				}
+				inUse, err := recentlyUsedLfsObject(ctx, lfsMetaObjectStore, oid, storage.ID, cutoff)
+				if err != nil {
+					return err
+				}
+				if inUse {
+					continue
+				}
+				total++
+				totalSize += obj.Size
+				slog.Info("unreferenced lfs object", slog.String("storage", storage.Name), slog.String("key", obj.Key),
+					slog.Int64("size", obj.Size), slog.Time("last_modified", obj.LastModified))
+				if gcDryRun {
+					continue
+				}
+				err = storage.Client.RemoveObject(ctx, storage.Bucket, obj.Key, minio.RemoveObjectOptions{})
+				if err != nil {
+					slog.Error("fail to remove lfs object", slog.String("storage", storage.Name), slog.String("key", obj.Key), slog.Any("error", err))
+					continue
+				}
+				err = lfsMetaObjectStore.RemoveByOidAndStorage(ctx, oid, storage.ID)
+				if err != nil {
+					slog.Error("fail to remove lfs meta objects", slog.String("oid", oid), slog.Any("error", err))
+				}
+				deleted++
+			}
+		}
+		slog.Info("lfs gc finished", slog.Bool("dry_run", gcDryRun), slog.Int("unreferenced", total),
+			slog.Int64("unreferenced_size", totalSize), slog.Int("deleted", deleted))
+		return nil
+	},
+}
+
+// reachableLfsOids collects the oids of lfs objects referenced by any repository,
+// objects shared by forks and mirrors are kept as long as one of the repositories references them
+func reachableLfsOids(gitServer gitserver.GitServer, repoStore database.RepoStore) (map[string]struct{}, error) {
+	reachable := make(map[string]struct{})
+	var i int
+	for {
+		queryCtx, queryCancel := context.WithTimeout(context.Background(), time.Second*15)
+		repos, err := repoStore.FindWithBatch(queryCtx, 1000, i)
+		queryCancel()
+		i += 1
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch get repositories, error: %w", err)
+		}
+		if len(repos) == 0 {
+			break
+		}
+		for _, repo := range repos {
+			namespace, name := repo.NamespaceAndName()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+			pointers, err := gitServer.GetRepoAllLfsPointers(ctx, gitserver.GetRepoAllFilesReq{
+				Namespace: namespace,
+				Name:      name,
+				RepoType:  repo.RepositoryType,
+			})
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get lfs pointers of repository '%s', error: %w", repo.Path, err)
+			}
+			for _, p := range pointers {
+				reachable[p.FileOid] = struct{}{}
+			}
+		}
+	}
+	return reachable, nil
+}
+
+// recentlyUsedLfsObject checks whether the object was recorded in the storage within the grace period,
+// the batch api records existing objects for new pushes before the refs are updated
+func recentlyUsedLfsObject(ctx context.Context, lfsMetaObjectStore database.LfsMetaObjectStore, oid string, storageID int64, cutoff time.Time) (bool, error) {
+	objects, err := lfsMetaObjectStore.ListByOid(ctx, oid)
+	if err != nil {
+		return false, fmt.Errorf("failed to list lfs meta objects of oid '%s', error: %w", oid, err)
+	}
+	for _, obj := range objects {
+		if obj.StorageID == storageID && obj.UpdatedAt.After(cutoff) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// oidFromObjectKey reverts the object key `lfs/{oid[0:2]}/{oid[2:4]}/{oid[4:]}` to the oid
+func oidFromObjectKey(key string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(key, "lfs/"), "/", "")
+}
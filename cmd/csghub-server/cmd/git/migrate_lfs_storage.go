@@ -0,0 +1,166 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/spf13/cobra"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/store/s3"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+var (
+	migrateNamespace    string
+	migrateStorage      string
+	migrateRemoveSource bool
+)
+
+func init() {
+	migrateLfsStorageCmd.Flags().StringVar(&migrateNamespace, "namespace", "", "namespace whose lfs objects are migrated")
+	migrateLfsStorageCmd.Flags().StringVar(&migrateStorage, "storage", "default", "name of the lfs storage to migrate to, 'default' is the storage in config")
+	migrateLfsStorageCmd.Flags().BoolVar(&migrateRemoveSource, "remove-source", false, "remove objects from the old storage once no repository references them there")
+	_ = migrateLfsStorageCmd.MarkFlagRequired("namespace")
+}
+
+var migrateLfsStorageCmd = &cobra.Command{
+	Use:   "migrate-lfs-storage",
+	Short: "the cmd to route a namespace to an lfs storage and move its existing lfs objects there",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		config, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config,%w", err)
+		}
+
+		dbConfig := database.DBConfig{
+			Dialect: database.DatabaseDialect(config.Database.Driver),
+			DSN:     config.Database.DSN,
+		}
+
+		database.InitDB(dbConfig)
+		ctx := context.WithValue(cmd.Context(), "config", config)
+		cmd.SetContext(ctx)
+		return
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		config, ok := ctx.Value("config").(*config.Config)
+		if !ok {
+			return fmt.Errorf("config not found in context")
+		}
+
+		router, err := s3.NewLfsRouter(config)
+		if err != nil {
+			return fmt.Errorf("fail to init lfs storage router,error:%w", err)
+		}
+		storageStore := database.NewLfsStorageStore()
+		lfsMetaObjectStore := database.NewLfsMetaObjectStore()
+		repoStore := database.NewRepoStore()
+
+		targetID := s3.DefaultLfsStorageID
+		if migrateStorage != "default" {
+			storage, err := storageStore.FindByName(ctx, migrateStorage)
+			if err != nil {
+				return fmt.Errorf("failed to find lfs storage '%s', error: %w", migrateStorage, err)
+			}
+			targetID = storage.ID
+		}
+		target, err := router.ByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+
+		// route the namespace first, so that objects uploaded during migration go to the new storage
+		err = storageStore.SetRoute(ctx, migrateNamespace, target.ID)
+		if err != nil {
+			return fmt.Errorf("failed to route namespace '%s' to lfs storage '%s', error: %w", migrateNamespace, target.Name, err)
+		}
+		slog.Info("namespace routed to lfs storage", slog.String("namespace", migrateNamespace), slog.String("storage", target.Name))
+
+		repos, err := repoStore.ByNamespace(ctx, migrateNamespace)
+		if err != nil {
+			return fmt.Errorf("failed to find repositories of namespace '%s', error: %w", migrateNamespace, err)
+		}
+		var migrated, failed int
+		for _, repo := range repos {
+			objects, err := lfsMetaObjectStore.FindByRepoID(ctx, repo.ID)
+			if err != nil {
+				slog.Error("fail to find lfs meta objects of repository", slog.String("repo", repo.Path), slog.Any("error", err))
+				failed++
+				continue
+			}
+			for _, obj := range objects {
+				if obj.StorageID == target.ID {
+					continue
+				}
+				err := migrateLfsObject(router, lfsMetaObjectStore, obj, target)
+				if err != nil {
+					slog.Error("fail to migrate lfs object", slog.String("repo", repo.Path), slog.String("oid", obj.Oid), slog.Any("error", err))
+					failed++
+					continue
+				}
+				migrated++
+			}
+		}
+		slog.Info("lfs objects migrated", slog.String("namespace", migrateNamespace), slog.String("storage", target.Name),
+			slog.Int("migrated", migrated), slog.Int("failed", failed))
+		if failed > 0 {
+			return fmt.Errorf("%d lfs objects failed to migrate, run the cmd again to retry", failed)
+		}
+		return nil
+	},
+}
+
+// migrateLfsObject copies the object to the target storage if it is not there yet and points the meta object to it
+func migrateLfsObject(router s3.LfsRouter, lfsMetaObjectStore database.LfsMetaObjectStore, obj database.LfsMetaObject, target *s3.LfsStorage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	source, err := router.ByID(ctx, obj.StorageID)
+	if err != nil {
+		return err
+	}
+	objectKey := path.Join("lfs", types.Pointer{Oid: obj.Oid}.RelativePath())
+	_, err = target.Client.StatObject(ctx, target.Bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		reader, err := source.Client.GetObject(ctx, source.Bucket, objectKey, minio.GetObjectOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get object from lfs storage '%s', error: %w", source.Name, err)
+		}
+		defer reader.Close()
+		uploadInfo, err := target.Client.PutObject(ctx, target.Bucket, objectKey, reader, obj.Size, minio.PutObjectOptions{
+			ContentType: "application/octet-stream",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to put object to lfs storage '%s', error: %w", target.Name, err)
+		}
+		if uploadInfo.Size != obj.Size {
+			return fmt.Errorf("uploaded object size does not match expected size: %d != %d", uploadInfo.Size, obj.Size)
+		}
+	}
+	err = lfsMetaObjectStore.UpdateStorageID(ctx, obj.ID, target.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update storage of lfs meta object, error: %w", err)
+	}
+
+	if !migrateRemoveSource {
+		return nil
+	}
+	// objects are shared by oid, forks in other namespaces may still reference it in the old storage
+	referenced, err := lfsMetaObjectStore.ExistsInStorage(ctx, obj.Oid, source.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check references of object in lfs storage '%s', error: %w", source.Name, err)
+	}
+	if referenced {
+		return nil
+	}
+	err = source.Client.RemoveObject(ctx, source.Bucket, objectKey, minio.RemoveObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to remove object from lfs storage '%s', error: %w", source.Name, err)
+	}
+	return nil
+}
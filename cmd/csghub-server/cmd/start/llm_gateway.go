@@ -0,0 +1,55 @@
+package start
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"opencsg.com/csghub-server/api/httpbase"
+	"opencsg.com/csghub-server/api/router"
+	"opencsg.com/csghub-server/builder/event"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+)
+
+var llmGatewayCmd = &cobra.Command{
+	Use:     "llm-gateway",
+	Short:   "Start the openai compatible gateway of deployed models",
+	Example: llmGatewayExample(),
+	RunE: func(*cobra.Command, []string) (err error) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+
+		dbConfig := database.DBConfig{
+			Dialect: database.DatabaseDialect(cfg.Database.Driver),
+			DSN:     cfg.Database.DSN,
+		}
+		database.InitDB(dbConfig)
+		// publish the token metering events of model calls
+		err = event.InitEventPublisher(cfg)
+		if err != nil {
+			return fmt.Errorf("fail to init event publisher: %w", err)
+		}
+		r, err := router.NewLLMGatewayRouter(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to init router: %w", err)
+		}
+		server := httpbase.NewGracefulServer(
+			httpbase.GraceServerOpt{
+				Port: cfg.LLMGateway.Port,
+			},
+			r,
+		)
+		server.Run()
+
+		return nil
+	},
+}
+
+func llmGatewayExample() string {
+	return `
+# for development
+csghub-server start llm-gateway
+`
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 	"opencsg.com/csghub-server/api/httpbase"
 	"opencsg.com/csghub-server/api/router"
+	"opencsg.com/csghub-server/builder/event"
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/config"
 )
@@ -25,6 +26,10 @@ var rproxyCmd = &cobra.Command{
 			DSN:     cfg.Database.DSN,
 		}
 		database.InitDB(dbConfig)
+		err = event.InitEventPublisher(cfg)
+		if err != nil {
+			return fmt.Errorf("fail to init event publisher: %w", err)
+		}
 		r, err := router.NewRProxyRouter(cfg)
 		if err != nil {
 			return fmt.Errorf("failed to init router: %w", err)
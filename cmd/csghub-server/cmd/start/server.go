@@ -1,6 +1,7 @@
 package start
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"time"
@@ -13,8 +14,10 @@ import (
 	"opencsg.com/csghub-server/builder/deploy/common"
 	"opencsg.com/csghub-server/builder/event"
 	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/webhook"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/component"
 	"opencsg.com/csghub-server/docs"
 	"opencsg.com/csghub-server/mirror"
 )
@@ -78,6 +81,10 @@ var serverCmd = &cobra.Command{
 			ModelDownloadEndpoint:   cfg.Model.DownloadEndpoint,
 			PublicRootDomain:        cfg.Space.PublicRootDomain,
 			S3Internal:              s3Internal,
+			MaxRunningTasks:         cfg.Space.SchedulerMaxRunningTasks,
+			MaxRunningTasksPerUser:  cfg.Space.SchedulerMaxRunningTasksPerUser,
+			MaxRunningTasksPerOrg:   cfg.Space.SchedulerMaxRunningTasksPerOrg,
+			TaskTimeoutInMin:        cfg.Space.SchedulerTaskTimeoutInMin,
 		})
 		r, err := router.NewRouter(cfg, enableSwagger)
 		if err != nil {
@@ -104,7 +111,32 @@ var serverCmd = &cobra.Command{
 			mirrorService.EnqueueMirrorTasks()
 		}
 
+		batchInference, err := component.NewBatchInferenceJobComponent(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to init batch inference job runner: %w", err)
+		}
+
+		accountingComp, err := component.NewAccountingComponent(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to init accounting component: %w", err)
+		}
+
+		// deliver queued webhook events until the server stops
+		webhookCtx, stopWebhookDispatcher := context.WithCancel(context.Background())
+		go webhook.NewDispatcher().Run(webhookCtx)
+
+		// run submitted batch inference jobs until the server stops
+		batchInferenceCtx, stopBatchInference := context.WithCancel(context.Background())
+		go batchInference.Run(batchInferenceCtx)
+
+		// stop the deploys of users without balance until the server stops
+		balanceCtx, stopBalanceActions := context.WithCancel(context.Background())
+		go accountingComp.RunBalanceActions(balanceCtx)
+
 		server.Run()
+		stopBalanceActions()
+		stopBatchInference()
+		stopWebhookDispatcher()
 		workflow.StopWorker()
 
 		return nil
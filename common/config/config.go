@@ -91,6 +91,10 @@ type Config struct {
 		InternalEndpoint string `env:"STARHUB_SERVER_S3_INTERNAL_ENDPOINT, default="`
 		Bucket           string `env:"STARHUB_SERVER_S3_BUCKET, default=opencsg-test"`
 		EnableSSL        bool   `env:"STARHUB_SERVER_S3_ENABLE_SSL, default=false"`
+		// lfs objects larger than the chunk size are uploaded in parts by clients supporting multipart transfer
+		LfsMultipartChunkSize int64 `env:"STARHUB_SERVER_S3_LFS_MULTIPART_CHUNK_SIZE, default=104857600"`
+		// lfs clients upload objects to presigned urls of s3 directly instead of through the api server
+		LfsDirectUpload bool `env:"STARHUB_SERVER_S3_LFS_DIRECT_UPLOAD, default=true"`
 	}
 
 	SensitiveCheck struct {
@@ -133,6 +137,11 @@ type Config struct {
 		ReadnessDelaySeconds     int    `env:"STARHUB_SERVER_READNESS_DELAY_SECONDS, default=120"`
 		ReadnessPeriodSeconds    int    `env:"STARHUB_SERVER_READNESS_PERIOD_SECONDS, default=10"`
 		ReadnessFailureThreshold int    `env:"STARHUB_SERVER_READNESS_FAILURE_THRESHOLD, default=3"`
+		// max deploy tasks the scheduler runs at the same time, in total, per user and per organization
+		SchedulerMaxRunningTasks        int `env:"STARHUB_SERVER_SPACE_SCHEDULER_MAX_RUNNING_TASKS, default=100"`
+		SchedulerMaxRunningTasksPerUser int `env:"STARHUB_SERVER_SPACE_SCHEDULER_MAX_RUNNING_TASKS_PER_USER, default=10"`
+		SchedulerMaxRunningTasksPerOrg  int `env:"STARHUB_SERVER_SPACE_SCHEDULER_MAX_RUNNING_TASKS_PER_ORG, default=30"`
+		SchedulerTaskTimeoutInMin       int `env:"STARHUB_SERVER_SPACE_SCHEDULER_TASK_TIMEOUT_IN_MINUTES, default=60"`
 	}
 
 	Model struct {
@@ -205,11 +214,31 @@ type Config struct {
 		Port int    `env:"OPENCSG_MODERATION_SERVER_PORT, default=8089"`
 		// comma splitted, and base64 encoded
 		EncodedSensitiveWords string `env:"OPENCSG_MODERATION_SERVER_ENCODED_SENSITIVE_WORDS, default=5Lmg6L+R5bmzLHhpamlucGluZw=="`
+		// comma splitted providers chained to check the content in order, which are dfa (the sensitive words above),
+		// regex (the rules in regex rules file), classifier (a classifier service deployed locally) and aliyun
+		Providers string `env:"OPENCSG_MODERATION_SERVER_PROVIDERS, default=dfa,aliyun"`
+		// providers of the scenarios checked differently, like `nickname_detection:dfa,regex;baselineCheck:classifier`
+		ScenarioProviders string `env:"OPENCSG_MODERATION_SERVER_SCENARIO_PROVIDERS"`
+		// file of regex rules, one rule of `label pattern` per line
+		RegexRulesFile     string `env:"OPENCSG_MODERATION_SERVER_REGEX_RULES_FILE"`
+		ClassifierEndpoint string `env:"OPENCSG_MODERATION_SERVER_CLASSIFIER_ENDPOINT"`
+		ClassifierApiKey   string `env:"OPENCSG_MODERATION_SERVER_CLASSIFIER_API_KEY"`
+		// timeout in seconds of calling classifier service
+		ClassifierTimeout int `env:"OPENCSG_MODERATION_SERVER_CLASSIFIER_TIMEOUT, default=10"`
+		// bucket to upload images for the providers to check, S3.Bucket is used if empty. For aliyun, it must be
+		// an oss bucket in the same region
+		ImageBucket string `env:"OPENCSG_MODERATION_SERVER_IMAGE_BUCKET"`
 	}
 
 	WorkFLow struct {
 		Endpoint string `env:"OPENCSG_WORKFLOW_SERVER_ENDPOINT, default=localhost:7233"`
 	}
+
+	LLMGateway struct {
+		Port int `env:"OPENCSG_LLM_GATEWAY_PORT, default=8090"`
+		// requests per minute a user can send through the gateway, 0 means no limit
+		RateLimitPerMinute int `env:"OPENCSG_LLM_GATEWAY_RATE_LIMIT_PER_MINUTE, default=60"`
+	}
 }
 
 func SetConfigFile(file string) {
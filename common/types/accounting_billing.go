@@ -0,0 +1,128 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// Money is an amount of money in micro units, 1/1,000,000 of the currency unit, so that amounts
+// add up exactly
+type Money int64
+
+// MoneyUnit is the Money of one currency unit
+const MoneyUnit Money = 1_000_000
+
+// String formats the money in currency units, e.g. 12.50 for 12,500,000 micro units
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	frac := fmt.Sprintf("%06d", int64(m%MoneyUnit))
+	for len(frac) > 2 && frac[len(frac)-1] == '0' {
+		frac = frac[:len(frac)-1]
+	}
+	return fmt.Sprintf("%s%d.%s", sign, int64(m/MoneyUnit), frac)
+}
+
+type AccountOwnerType string
+
+const (
+	AccountOwnerUser AccountOwnerType = "user"
+	AccountOwnerOrg  AccountOwnerType = "org"
+)
+
+type AccountStatus string
+
+const (
+	AccountStatusNormal AccountStatus = "normal"
+	// balance is below the low balance threshold of account, owner is notified
+	AccountStatusLowBalance AccountStatus = "low_balance"
+	// balance is overdrawn, deploys charged to the account are stopped
+	AccountStatusArrears AccountStatus = "arrears"
+)
+
+// AccountStatusOf returns the status of account with the balance
+func AccountStatusOf(balance, lowBalanceThreshold Money) AccountStatus {
+	switch {
+	case balance < 0:
+		return AccountStatusArrears
+	case balance < lowBalanceThreshold:
+		return AccountStatusLowBalance
+	default:
+		return AccountStatusNormal
+	}
+}
+
+type AccountStatementType string
+
+const (
+	// consumption of a metering event
+	AccountStatementCharge AccountStatementType = "charge"
+	// balance paid by owner
+	AccountStatementRecharge AccountStatementType = "recharge"
+	// prepaid credit granted by admin
+	AccountStatementCredit AccountStatementType = "credit"
+)
+
+type AccountPriceReq struct {
+	SkuType SKUType   `json:"sku_type"`
+	Scene   SceneType `json:"scene" binding:"required"`
+	// sku of space resource, empty for the default price of scene
+	ResourceID string `json:"resource_id"`
+	// minute or token
+	UnitType string `json:"unit_type" binding:"required,oneof=minute token repository byte"`
+	// number of units the price is for, e.g. 1000 tokens
+	SkuUnit int64 `json:"sku_unit" binding:"required,min=1"`
+	// price of sku_unit units in micro units of the currency
+	Price       Money  `json:"price" binding:"min=0"`
+	Description string `json:"description"`
+}
+
+type RechargeAccountReq struct {
+	OwnerType AccountOwnerType     `json:"owner_type" binding:"required,oneof=user org"`
+	Type      AccountStatementType `json:"type" binding:"required,oneof=recharge credit"`
+	// in micro units of the currency
+	Amount      Money  `json:"amount" binding:"required,gt=0"`
+	Description string `json:"description"`
+	OpUID       string `json:"op_uid"`
+}
+
+type UpdateAccountReq struct {
+	OwnerType AccountOwnerType `json:"owner_type" binding:"required,oneof=user org"`
+	// in micro units of the currency
+	LowBalanceThreshold *Money `json:"low_balance_threshold" binding:"omitempty,min=0"`
+	// uuid of org account the consumption of user is charged to, empty to charge user self
+	PayerUUID *string `json:"payer_uuid"`
+}
+
+// AccountBill is the consumption of a resource in a period
+type AccountBill struct {
+	Scene        SceneType `json:"scene"`
+	ResourceID   string    `json:"resource_id"`
+	ResourceName string    `json:"resource_name"`
+	CustomerID   string    `json:"customer_id"`
+	UnitType     string    `json:"unit_type"`
+	Value        float64   `json:"value"`
+	Amount       Money     `json:"amount"`
+}
+
+type BalanceEvent string
+
+const (
+	BalanceEventLow       BalanceEvent = "low_balance"
+	BalanceEventNo        BalanceEvent = "no_balance"
+	BalanceEventRecovered BalanceEvent = "recovered"
+)
+
+// BalanceNotification is published by accounting when the status of an account changes
+type BalanceNotification struct {
+	Event     BalanceEvent     `json:"event"`
+	OwnerUUID string           `json:"owner_uuid"`
+	OwnerType AccountOwnerType `json:"owner_type"`
+	Balance   Money            `json:"balance"`
+	// users whose consumption is charged to the account
+	UserUUIDs []string  `json:"user_uuids"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountStatusOf(t *testing.T) {
+	require.Equal(t, AccountStatusNormal, AccountStatusOf(0, 0))
+	require.Equal(t, AccountStatusNormal, AccountStatusOf(100*MoneyUnit, 10*MoneyUnit))
+	require.Equal(t, AccountStatusLowBalance, AccountStatusOf(9_500_000, 10*MoneyUnit))
+	require.Equal(t, AccountStatusLowBalance, AccountStatusOf(0, 10*MoneyUnit))
+	require.Equal(t, AccountStatusArrears, AccountStatusOf(-10_000, 10*MoneyUnit))
+	require.Equal(t, AccountStatusArrears, AccountStatusOf(-1, 0))
+}
+
+func TestMoney_String(t *testing.T) {
+	require.Equal(t, "0.00", Money(0).String())
+	require.Equal(t, "12.50", Money(12_500_000).String())
+	require.Equal(t, "0.000001", Money(1).String())
+	require.Equal(t, "-3.25", Money(-3_250_000).String())
+}
@@ -0,0 +1,66 @@
+package types
+
+import "time"
+
+// DLQMessage is a metering event parked in the dead-letter queue after failing to be handled
+type DLQMessage struct {
+	Sequence   uint64    `json:"sequence"`
+	Subject    string    `json:"subject"`
+	ReceivedAt time.Time `json:"received_at"`
+	Data       string    `json:"data"`
+	// nil if data is not a valid metering event
+	Event      *METERING_EVENT `json:"event,omitempty"`
+	ParseError string          `json:"parse_error,omitempty"`
+}
+
+// MeteringEventPatch corrects the fields of a metering event before it's replayed, the uuid of event
+// is never changed so that a replayed event is not charged twice
+type MeteringEventPatch struct {
+	UserUUID     *string    `json:"user_uuid,omitempty"`
+	Value        *int64     `json:"value,omitempty"`
+	ValueType    *int       `json:"value_type,omitempty"`
+	Scene        *int       `json:"scene,omitempty"`
+	ResourceID   *string    `json:"resource_id,omitempty"`
+	ResourceName *string    `json:"resource_name,omitempty"`
+	CustomerID   *string    `json:"customer_id,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	Extra        *string    `json:"extra,omitempty"`
+}
+
+func (p *MeteringEventPatch) Apply(event *METERING_EVENT) {
+	if p == nil {
+		return
+	}
+	if p.UserUUID != nil {
+		event.UserUUID = *p.UserUUID
+	}
+	if p.Value != nil {
+		event.Value = *p.Value
+	}
+	if p.ValueType != nil {
+		event.ValueType = *p.ValueType
+	}
+	if p.Scene != nil {
+		event.Scene = *p.Scene
+	}
+	if p.ResourceID != nil {
+		event.ResourceID = *p.ResourceID
+	}
+	if p.ResourceName != nil {
+		event.ResourceName = *p.ResourceName
+	}
+	if p.CustomerID != nil {
+		event.CustomerID = *p.CustomerID
+	}
+	if p.CreatedAt != nil {
+		event.CreatedAt = *p.CreatedAt
+	}
+	if p.Extra != nil {
+		event.Extra = *p.Extra
+	}
+}
+
+type DLQReplayResult struct {
+	Sequence uint64 `json:"sequence"`
+	Error    string `json:"error,omitempty"`
+}
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeteringEventPatchApply(t *testing.T) {
+	id := uuid.New()
+	event := METERING_EVENT{
+		Uuid:       id,
+		UserUUID:   "user",
+		Value:      10,
+		ResourceID: "1",
+		CreatedAt:  time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+	}
+	var nilPatch *MeteringEventPatch
+	nilPatch.Apply(&event)
+	require.Equal(t, int64(10), event.Value)
+
+	value := int64(20)
+	resourceID := "2"
+	patch := &MeteringEventPatch{Value: &value, ResourceID: &resourceID}
+	patch.Apply(&event)
+	require.Equal(t, id, event.Uuid)
+	require.Equal(t, "user", event.UserUUID)
+	require.Equal(t, int64(20), event.Value)
+	require.Equal(t, "2", event.ResourceID)
+}
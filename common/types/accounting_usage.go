@@ -0,0 +1,72 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// UsagePeriod is the time bucket usage is aggregated by
+type UsagePeriod string
+
+const (
+	UsagePeriodDay   UsagePeriod = "day"
+	UsagePeriodWeek  UsagePeriod = "week"
+	UsagePeriodMonth UsagePeriod = "month"
+)
+
+// UsageGroupBy is the dimension usage is aggregated by in every period
+type UsageGroupBy string
+
+const (
+	UsageGroupByNone     UsageGroupBy = ""
+	UsageGroupByScene    UsageGroupBy = "scene"
+	UsageGroupByResource UsageGroupBy = "resource"
+	// members of org whose consumption is charged to the org account
+	UsageGroupByMember UsageGroupBy = "member"
+)
+
+type UsageAggregateReq struct {
+	OwnerUUID string
+	OwnerType AccountOwnerType
+	Period    UsagePeriod
+	GroupBy   UsageGroupBy
+	// nil for all scenes
+	Scene *SceneType
+	// the days in [StartDate, EndDate) are aggregated
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+func (r UsageAggregateReq) Validate() error {
+	switch r.Period {
+	case UsagePeriodDay, UsagePeriodWeek, UsagePeriodMonth:
+	default:
+		return fmt.Errorf("invalid usage period %q", r.Period)
+	}
+	switch r.GroupBy {
+	case UsageGroupByNone, UsageGroupByScene, UsageGroupByResource, UsageGroupByMember:
+	default:
+		return fmt.Errorf("invalid usage group by %q", r.GroupBy)
+	}
+	switch r.OwnerType {
+	case AccountOwnerUser, AccountOwnerOrg:
+	default:
+		return fmt.Errorf("invalid owner type %q", r.OwnerType)
+	}
+	if !r.StartDate.Before(r.EndDate) {
+		return fmt.Errorf("start date must be before end date")
+	}
+	return nil
+}
+
+// UsageAggregate is the usage of a unit type in a period, the fields of the group by dimension are set
+type UsageAggregate struct {
+	PeriodStart  time.Time `json:"period_start"`
+	Scene        SceneType `json:"scene,omitempty"`
+	ResourceID   string    `json:"resource_id,omitempty"`
+	ResourceName string    `json:"resource_name,omitempty"`
+	UserUUID     string    `json:"user_uuid,omitempty"`
+	UnitType     string    `json:"unit_type"`
+	Value        float64   `json:"value"`
+	EventCount   int64     `json:"event_count"`
+}
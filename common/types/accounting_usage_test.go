@@ -0,0 +1,37 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageAggregateReqValidate(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	valid := UsageAggregateReq{
+		OwnerUUID: "uuid",
+		OwnerType: AccountOwnerOrg,
+		Period:    UsagePeriodWeek,
+		GroupBy:   UsageGroupByMember,
+		StartDate: start,
+		EndDate:   start.AddDate(0, 1, 0),
+	}
+	require.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.Period = "year"
+	require.Error(t, invalid.Validate())
+
+	invalid = valid
+	invalid.GroupBy = "cluster"
+	require.Error(t, invalid.Validate())
+
+	invalid = valid
+	invalid.OwnerType = "team"
+	require.Error(t, invalid.Validate())
+
+	invalid = valid
+	invalid.EndDate = start
+	require.Error(t, invalid.Validate())
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+type AutoscalingMetric string
+
+const (
+	// AutoscalingConcurrency scales on the number of in-flight requests per replica
+	AutoscalingConcurrency AutoscalingMetric = "concurrency"
+	// AutoscalingRPS scales on the requests per second per replica
+	AutoscalingRPS AutoscalingMetric = "rps"
+)
+
+// AutoscalingPolicy controls how the replicas of an inference endpoint follow its traffic,
+// between the min and max replica of the deploy
+type AutoscalingPolicy struct {
+	Metric AutoscalingMetric `json:"metric"`
+	// Target is the value of metric per replica the autoscaler tries to keep
+	Target float64 `json:"target"`
+	// StableWindowSeconds is the time window the metric is averaged over, 0 means the runner default
+	StableWindowSeconds int `json:"stable_window_seconds,omitempty"`
+	// ScaleDownDelaySeconds is how long the traffic must stay low before replicas are removed
+	ScaleDownDelaySeconds int `json:"scale_down_delay_seconds,omitempty"`
+	// ScaleToZeroIdleMinutes stops the endpoint after it receives no request for the minutes,
+	// it's woken up by the next request through the endpoint proxy, 0 means never scale to zero
+	ScaleToZeroIdleMinutes int `json:"scale_to_zero_idle_minutes,omitempty"`
+}
+
+// DefaultAutoscalingPolicy is used by the deploys without autoscaling policy
+var DefaultAutoscalingPolicy = AutoscalingPolicy{
+	Metric: AutoscalingConcurrency,
+	Target: 5,
+}
+
+func (p AutoscalingPolicy) Validate() error {
+	switch p.Metric {
+	case AutoscalingConcurrency, AutoscalingRPS:
+	default:
+		return fmt.Errorf("invalid autoscaling metric %q, should be one of %q, %q", p.Metric, AutoscalingConcurrency, AutoscalingRPS)
+	}
+	if p.Target <= 0 {
+		return errors.New("autoscaling target should be greater than 0")
+	}
+	// knative requires the stable window between 6s and 1h
+	if p.StableWindowSeconds != 0 && (p.StableWindowSeconds < 6 || p.StableWindowSeconds > 3600) {
+		return errors.New("autoscaling stable window should be between 6 and 3600 seconds")
+	}
+	if p.ScaleDownDelaySeconds < 0 || p.ScaleDownDelaySeconds > 3600 {
+		return errors.New("autoscaling scale down delay should be between 0 and 3600 seconds")
+	}
+	if p.ScaleToZeroIdleMinutes < 0 {
+		return errors.New("autoscaling scale to zero idle minutes should not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoscalingPolicyValidate(t *testing.T) {
+	require.NoError(t, DefaultAutoscalingPolicy.Validate())
+	require.NoError(t, AutoscalingPolicy{
+		Metric:                 AutoscalingRPS,
+		Target:                 0.5,
+		StableWindowSeconds:    60,
+		ScaleDownDelaySeconds:  300,
+		ScaleToZeroIdleMinutes: 30,
+	}.Validate())
+
+	invalid := []AutoscalingPolicy{
+		{Metric: "cpu", Target: 5},
+		{Metric: AutoscalingConcurrency},
+		{Metric: AutoscalingConcurrency, Target: 5, StableWindowSeconds: 5},
+		{Metric: AutoscalingConcurrency, Target: 5, StableWindowSeconds: 3601},
+		{Metric: AutoscalingConcurrency, Target: 5, ScaleDownDelaySeconds: -1},
+		{Metric: AutoscalingConcurrency, Target: 5, ScaleToZeroIdleMinutes: -1},
+	}
+	for _, p := range invalid {
+		require.Error(t, p.Validate(), p)
+	}
+}
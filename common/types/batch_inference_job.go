@@ -0,0 +1,93 @@
+package types
+
+import "time"
+
+type BatchInferenceJobStatus string
+
+const (
+	// BatchInferenceJobPending waits for the job runner to pick it up
+	BatchInferenceJobPending BatchInferenceJobStatus = "pending"
+	BatchInferenceJobRunning BatchInferenceJobStatus = "running"
+	// BatchInferenceJobWriting writes the outputs back to the output repo
+	BatchInferenceJobWriting   BatchInferenceJobStatus = "writing"
+	BatchInferenceJobSucceeded BatchInferenceJobStatus = "succeeded"
+	BatchInferenceJobFailed    BatchInferenceJobStatus = "failed"
+	BatchInferenceJobCancelled BatchInferenceJobStatus = "cancelled"
+)
+
+// Finished reports whether the job will not change any more
+func (s BatchInferenceJobStatus) Finished() bool {
+	return s == BatchInferenceJobSucceeded || s == BatchInferenceJobFailed || s == BatchInferenceJobCancelled
+}
+
+// BatchInferenceOutputType tells where the outputs of a batch inference job are written to
+type BatchInferenceOutputType string
+
+const (
+	// BatchInferenceOutputBranch writes the outputs to a new branch of the input dataset
+	BatchInferenceOutputBranch BatchInferenceOutputType = "branch"
+	// BatchInferenceOutputDataset writes the outputs to a new dataset repo
+	BatchInferenceOutputDataset BatchInferenceOutputType = "dataset"
+)
+
+type CreateBatchInferenceJobReq struct {
+	// id of the running inference or serverless deploy to call
+	DeployID int64 `json:"deploy_id" binding:"required"`
+	// path of the input dataset, namespace/name
+	Dataset string `json:"dataset" binding:"required" example:"OpenCSG/chinese-fineweb-edu"`
+	// revision of the input dataset, default to the default branch
+	Revision string `json:"revision"`
+	// parquet or jsonl file in the dataset to read rows from
+	InputFile string `json:"input_file" binding:"required" example:"data/train.parquet"`
+	// column of the rows sent to the model as user message
+	InputColumn  string  `json:"input_column" binding:"required" example:"text"`
+	SystemPrompt string  `json:"system_prompt"`
+	MaxTokens    int     `json:"max_tokens" binding:"omitempty,min=1" example:"512"`
+	Temperature  float64 `json:"temperature" binding:"omitempty,min=0,max=2" example:"0.7"`
+	// OutputType is branch to write to a new branch of the input dataset, or dataset to create a new dataset
+	OutputType BatchInferenceOutputType `json:"output_type" binding:"required,oneof=branch dataset" example:"branch"`
+	// name of the new branch for branch output, default to batch-inference-{job id}
+	OutputBranch string `json:"output_branch"`
+	// namespace to create the output dataset in for dataset output, default to current user
+	OutputNamespace string `json:"output_namespace"`
+	// name of the output dataset for dataset output
+	OutputName  string `json:"output_name"`
+	CurrentUser string `json:"-"`
+}
+
+type BatchInferenceJob struct {
+	ID           int64                    `json:"id"`
+	Username     string                   `json:"username"`
+	DeployID     int64                    `json:"deploy_id"`
+	Dataset      string                   `json:"dataset"`
+	Revision     string                   `json:"revision"`
+	InputFile    string                   `json:"input_file"`
+	InputColumn  string                   `json:"input_column"`
+	SystemPrompt string                   `json:"system_prompt,omitempty"`
+	MaxTokens    int                      `json:"max_tokens,omitempty"`
+	Temperature  float64                  `json:"temperature"`
+	Status       BatchInferenceJobStatus  `json:"status"`
+	Message      string                   `json:"message,omitempty"`
+	OutputType   BatchInferenceOutputType `json:"output_type"`
+	// OutputRepo is the path of the dataset the outputs are written to
+	OutputRepo string `json:"output_repo"`
+	// OutputBranch is the branch of the output repo the outputs are written to
+	OutputBranch     string     `json:"output_branch"`
+	TotalRows        int        `json:"total_rows"`
+	ProcessedRows    int        `json:"processed_rows"`
+	FailedRows       int        `json:"failed_rows"`
+	PromptTokens     int64      `json:"prompt_tokens"`
+	CompletionTokens int64      `json:"completion_tokens"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// BatchInferenceOutput is a line of the jsonl files written to the output repo
+type BatchInferenceOutput struct {
+	Row    int    `json:"row"`
+	Input  string `json:"input"`
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// error code returned in response when a change made in web violates the branch protection rules
+const ErrCodeBranchProtected = 40311
+
+type BranchProtectionReq struct {
+	// branch name or glob pattern like `release/*`
+	Pattern        string `json:"pattern" binding:"required" example:"main"`
+	BlockForcePush bool   `json:"block_force_push"`
+	BlockDeletion  bool   `json:"block_deletion"`
+	// only repository admins can push to the matched branches
+	RequireAdmin bool `json:"require_admin"`
+	// no one can push to the matched branches directly, changes have to be merged through pull requests
+	BlockDirectPush bool           `json:"block_direct_push"`
+	ID              int64          `json:"-"`
+	Namespace       string         `json:"-"`
+	Name            string         `json:"-"`
+	RepoType        RepositoryType `json:"-"`
+	CurrentUser     string         `json:"-"`
+}
+
+type BranchProtection struct {
+	ID              int64     `json:"id"`
+	Pattern         string    `json:"pattern"`
+	BlockForcePush  bool      `json:"block_force_push"`
+	BlockDeletion   bool      `json:"block_deletion"`
+	RequireAdmin    bool      `json:"require_admin"`
+	BlockDirectPush bool      `json:"block_direct_push"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// RefChange is a ref update of a push in format of `<old-rev> <new-rev> <ref>`
+type RefChange struct {
+	OldRev string
+	NewRev string
+	Ref    string
+}
+
+func isZeroRev(rev string) bool {
+	return rev != "" && strings.Trim(rev, "0") == ""
+}
+
+func (c RefChange) IsCreate() bool {
+	return isZeroRev(c.OldRev)
+}
+
+func (c RefChange) IsDelete() bool {
+	return isZeroRev(c.NewRev)
+}
+
+// Branch returns the branch name if the ref is a branch
+func (c RefChange) Branch() (string, bool) {
+	if !strings.HasPrefix(c.Ref, "refs/heads/") {
+		return "", false
+	}
+	return strings.TrimPrefix(c.Ref, "refs/heads/"), true
+}
+
+// ParseRefChanges parses the changes of a push sent to the pre-receive hook, one ref update per line,
+// lines not in format of `<old-rev> <new-rev> <ref>` like `_any` are ignored
+func ParseRefChanges(changes string) []RefChange {
+	var refChanges []RefChange
+	for _, line := range strings.Split(changes, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		refChanges = append(refChanges, RefChange{
+			OldRev: fields[0],
+			NewRev: fields[1],
+			Ref:    fields[2],
+		})
+	}
+	return refChanges
+}
+
+// GitObjectDirectoryEnv is the `env` of the pre-receive hook allowed request,
+// it tells where the quarantined objects of the push are
+type GitObjectDirectoryEnv struct {
+	ObjectDirectory            string   `json:"GIT_OBJECT_DIRECTORY_RELATIVE"`
+	AlternateObjectDirectories []string `json:"GIT_ALTERNATE_OBJECT_DIRECTORIES_RELATIVE"`
+}
@@ -0,0 +1,66 @@
+package types
+
+import "time"
+
+// ComputeQuotaKind is the kind of limit in a compute quota
+type ComputeQuotaKind string
+
+const (
+	ComputeQuotaGPUs             ComputeQuotaKind = "max_gpus"
+	ComputeQuotaRunningInstances ComputeQuotaKind = "max_running_instances"
+	ComputeQuotaMonthlyMinutes   ComputeQuotaKind = "monthly_minutes"
+)
+
+// error codes returned in response when a deploy exceeds a compute quota
+const (
+	ErrCodeGPUQuotaExceeded             = 40301
+	ErrCodeRunningInstanceQuotaExceeded = 40302
+	ErrCodeMonthlyMinuteQuotaExceeded   = 40303
+)
+
+// ComputeQuota limits the compute resources used by a user or an organization, a nil limit means unlimited
+type ComputeQuota struct {
+	ID int64 `json:"id"`
+	// Namespace is the path of the user or organization
+	Namespace           string `json:"namespace"`
+	MaxGPUs             *int   `json:"max_gpus"`
+	MaxRunningInstances *int   `json:"max_running_instances"`
+	// MonthlyMinutes is the max minutes per calendar month of each scene, scenes not in the map are unlimited
+	MonthlyMinutes map[SceneType]int64 `json:"monthly_minutes"`
+	Usage          *ComputeQuotaUsage  `json:"usage,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+// ComputeQuotaUsage is the resources used by a user or an organization at present
+type ComputeQuotaUsage struct {
+	GPUs             int                   `json:"gpus"`
+	RunningInstances int                   `json:"running_instances"`
+	MonthlyMinutes   map[SceneType]float64 `json:"monthly_minutes"`
+}
+
+type CreateComputeQuotaReq struct {
+	Namespace           string              `json:"namespace" binding:"required"`
+	MaxGPUs             *int                `json:"max_gpus" binding:"omitempty,min=0"`
+	MaxRunningInstances *int                `json:"max_running_instances" binding:"omitempty,min=0"`
+	MonthlyMinutes      map[SceneType]int64 `json:"monthly_minutes"`
+	CurrentUser         string              `json:"-"`
+}
+
+type UpdateComputeQuotaReq struct {
+	ID                  int64               `json:"-"`
+	MaxGPUs             *int                `json:"max_gpus" binding:"omitempty,min=0"`
+	MaxRunningInstances *int                `json:"max_running_instances" binding:"omitempty,min=0"`
+	MonthlyMinutes      map[SceneType]int64 `json:"monthly_minutes"`
+	CurrentUser         string              `json:"-"`
+}
+
+// ComputeQuotaExceeded describes the limit a deploy exceeds
+type ComputeQuotaExceeded struct {
+	Namespace string           `json:"namespace"`
+	Kind      ComputeQuotaKind `json:"kind"`
+	Scene     SceneType        `json:"scene,omitempty"`
+	Limit     int64            `json:"limit"`
+	Used      int64            `json:"used"`
+	Requested int64            `json:"requested"`
+}
@@ -0,0 +1,33 @@
+package types
+
+import "time"
+
+// DeployQueueTask is a deploy task running or waiting in the queue of deploy scheduler
+type DeployQueueTask struct {
+	TaskID int64 `json:"task_id"`
+	// 0: build, 1: run
+	TaskType   int    `json:"task_type"`
+	Status     int    `json:"status"`
+	DeployID   int64  `json:"deploy_id"`
+	DeployName string `json:"deploy_name"`
+	// 0-space, 1-inference, 2-finetune, 3-serverless
+	DeployType int   `json:"deploy_type"`
+	RepoID     int64 `json:"repo_id"`
+	UserID     int64 `json:"user_id"`
+	// Org is the organization the deployed repository belongs to, empty for repositories of users
+	Org string `json:"org"`
+	// Priority is the priority of deploy type plus the priority set by admin, higher runs first
+	Priority           int  `json:"priority"`
+	PriorityAdjustment int  `json:"priority_adjustment"`
+	Running            bool `json:"running"`
+	// Position is the order of waiting task to run, starts from 1, 0 for running tasks
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SetDeployTaskPriorityReq struct {
+	// Priority adds to the priority of deploy type, use a big value to run the task next
+	Priority    int    `json:"priority"`
+	TaskID      int64  `json:"-"`
+	CurrentUser string `json:"-"`
+}
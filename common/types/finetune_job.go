@@ -0,0 +1,122 @@
+package types
+
+import "time"
+
+type FinetuneJobStatus string
+
+const (
+	// FinetuneJobPending waits for the training container to start
+	FinetuneJobPending FinetuneJobStatus = "pending"
+	FinetuneJobRunning FinetuneJobStatus = "running"
+	// FinetuneJobUploading pushes the trained weights to the output model repo
+	FinetuneJobUploading FinetuneJobStatus = "uploading"
+	FinetuneJobSucceeded FinetuneJobStatus = "succeeded"
+	FinetuneJobFailed    FinetuneJobStatus = "failed"
+	FinetuneJobCancelled FinetuneJobStatus = "cancelled"
+)
+
+// Finished reports whether the job will not change any more
+func (s FinetuneJobStatus) Finished() bool {
+	return s == FinetuneJobSucceeded || s == FinetuneJobFailed || s == FinetuneJobCancelled
+}
+
+// FinetuneJobEventType is the type of events reported by the training container
+type FinetuneJobEventType string
+
+const (
+	// FinetuneJobEventProgress reports the metrics of current epoch and step
+	FinetuneJobEventProgress FinetuneJobEventType = "progress"
+	// FinetuneJobEventCheckpoint reports a checkpoint saved during training
+	FinetuneJobEventCheckpoint FinetuneJobEventType = "checkpoint"
+	// FinetuneJobEventUploading reports the training is done, the output model repo is created in response
+	FinetuneJobEventUploading FinetuneJobEventType = "uploading"
+	// FinetuneJobEventSucceeded reports the weights are pushed to the output model repo
+	FinetuneJobEventSucceeded FinetuneJobEventType = "succeeded"
+	FinetuneJobEventFailed    FinetuneJobEventType = "failed"
+)
+
+type FinetuneHyperparameters struct {
+	// finetuning method, lora or full
+	Method       string  `json:"method" binding:"omitempty,oneof=lora full" example:"lora"`
+	Epochs       int     `json:"epochs" binding:"required,min=1" example:"3"`
+	LearningRate float64 `json:"learning_rate" binding:"omitempty,gt=0" example:"0.0001"`
+	BatchSize    int     `json:"batch_size" binding:"omitempty,min=1" example:"4"`
+	MaxSeqLength int     `json:"max_seq_length" binding:"omitempty,min=1" example:"1024"`
+	// chat template of the model, such as llama3 or qwen
+	Template string `json:"template" example:"qwen"`
+}
+
+type CreateFinetuneJobReq struct {
+	// path of the base model, namespace/name
+	Model string `json:"model" binding:"required" example:"OpenCSG/csg-wukong-1B"`
+	// revision of the base model, default to the default branch
+	Revision string `json:"revision"`
+	// path of the dataset to train on, namespace/name
+	Dataset            string                  `json:"dataset" binding:"required" example:"OpenCSG/chinese-fineweb-edu"`
+	RuntimeFrameworkID int64                   `json:"runtime_framework_id" binding:"required"`
+	ResourceID         int64                   `json:"resource_id" binding:"required"`
+	ClusterID          string                  `json:"cluster_id"`
+	Hyperparameters    FinetuneHyperparameters `json:"hyperparameters" binding:"required"`
+	// namespace to create the output model in, default to current user
+	OutputNamespace string `json:"output_namespace"`
+	// name of the output model
+	OutputName  string `json:"output_name" binding:"required"`
+	CurrentUser string `json:"-"`
+}
+
+type FinetuneJob struct {
+	ID              int64                   `json:"id"`
+	Username        string                  `json:"username"`
+	Model           string                  `json:"model"`
+	Revision        string                  `json:"revision"`
+	Dataset         string                  `json:"dataset"`
+	Hyperparameters FinetuneHyperparameters `json:"hyperparameters"`
+	Status          FinetuneJobStatus       `json:"status"`
+	Message         string                  `json:"message,omitempty"`
+	DeployID        int64                   `json:"deploy_id"`
+	// OutputModel is the path of the model pushed on success
+	OutputModel  string               `json:"output_model"`
+	CurrentEpoch int                  `json:"current_epoch"`
+	CurrentStep  int                  `json:"current_step"`
+	Metrics      map[string]float64   `json:"metrics,omitempty"`
+	Checkpoints  []FinetuneCheckpoint `json:"checkpoints,omitempty"`
+	StartedAt    *time.Time           `json:"started_at,omitempty"`
+	FinishedAt   *time.Time           `json:"finished_at,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+type FinetuneCheckpoint struct {
+	ID    int64 `json:"id"`
+	Epoch int   `json:"epoch"`
+	Step  int   `json:"step"`
+	// Name is the directory the checkpoint saved in
+	Name      string             `json:"name"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// FinetuneJobEventReq is reported by the training container with the access token of job owner,
+// and the event token of the job in header FinetuneJobEventTokenHeader
+type FinetuneJobEventReq struct {
+	Type    FinetuneJobEventType `json:"type" binding:"required,oneof=progress checkpoint uploading succeeded failed"`
+	Epoch   int                  `json:"epoch"`
+	Step    int                  `json:"step"`
+	Metrics map[string]float64   `json:"metrics"`
+	// Checkpoint is the directory of the checkpoint saved, for checkpoint event
+	Checkpoint  string `json:"checkpoint"`
+	Message     string `json:"message"`
+	JobID       int64  `json:"-"`
+	CurrentUser string `json:"-"`
+	EventToken  string `json:"-"`
+}
+
+// FinetuneJobEventTokenHeader carries the event token passed to the training container in env FINETUNE_JOB_TOKEN
+const FinetuneJobEventTokenHeader = "X-Finetune-Job-Token"
+
+type FinetuneJobEventResp struct {
+	Status FinetuneJobStatus `json:"status"`
+	// OutputModel and HTTPCloneURL of the output model repo, returned for uploading event
+	OutputModel  string `json:"output_model,omitempty"`
+	HTTPCloneURL string `json:"http_clone_url,omitempty"`
+}
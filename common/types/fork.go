@@ -0,0 +1,53 @@
+package types
+
+type RepoRelationType string
+
+const (
+	// RepoRelationRelated links a repository to the repositories it mentions, like a model to its datasets
+	RepoRelationRelated RepoRelationType = "related"
+	// RepoRelationFork links a forked repository to its upstream repository
+	RepoRelationFork RepoRelationType = "fork"
+	// RepoRelationFinetune links a finetuned model to its base model and the datasets it was trained on
+	RepoRelationFinetune RepoRelationType = "finetune"
+)
+
+type ForkRepoReq struct {
+	// namespace to create the fork in, default to current user
+	Namespace string `json:"namespace" example:"user_or_org_name"`
+	// name of the fork, default to the name of upstream repository
+	Name            string         `json:"name" example:"repo_name"`
+	Private         bool           `json:"private"`
+	SourceNamespace string         `json:"-"`
+	SourceName      string         `json:"-"`
+	RepoType        RepositoryType `json:"-"`
+	CurrentUser     string         `json:"-"`
+}
+
+type ForkRepoResp struct {
+	Path          string         `json:"path"`
+	Upstream      string         `json:"upstream"`
+	RepoType      RepositoryType `json:"repo_type"`
+	DefaultBranch string         `json:"default_branch"`
+	Private       bool           `json:"private"`
+	HTTPCloneURL  string         `json:"http_clone_url"`
+	SSHCloneURL   string         `json:"ssh_clone_url"`
+}
+
+type ForkStatusReq struct {
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+	// branch to compare with the same branch of upstream, default to the default branch of the fork
+	Branch string `json:"branch"`
+}
+
+type ForkStatus struct {
+	Upstream       string `json:"upstream"`
+	UpstreamBranch string `json:"upstream_branch"`
+	Branch         string `json:"branch"`
+	// Behind is the number of upstream commits not in the fork yet
+	Behind int `json:"behind"`
+	// Ahead is the number of fork commits not in upstream
+	Ahead int `json:"ahead"`
+}
@@ -12,6 +12,14 @@ import (
 
 const LfsMediaType = "application/vnd.git-lfs+json"
 
+const (
+	LfsTransferBasic = "basic"
+	// LfsTransferMultipart uploads large objects in parts to the presigned urls in the header of upload action,
+	// and completes the upload by posting to the href of upload action. Only the parts not uploaded yet are in
+	// the header when resuming, upload actions without parts are uploaded in one request like basic transfer
+	LfsTransferMultipart = "multipart"
+)
+
 var (
 	oidPattern      = regexp.MustCompile(`^[a-f\d]{64}$`)
 	ErrHashMismatch = errors.New("content hash does not match OID")
@@ -77,6 +85,13 @@ type VerifyRequest struct {
 	RepoType    RepositoryType `json:"repo_type"`
 }
 
+// VerifyLfsObjectContentReq is the request to check the content of lfs object in the storage matches its oid, which
+// is done in background for the objects without sha256 checksum stored by s3, like the ones uploaded in parts
+type VerifyLfsObjectContentReq struct {
+	Oid       string `json:"oid"`
+	StorageID int64  `json:"storage_id"`
+}
+
 type Reference struct {
 	Name string `json:"name"`
 }
@@ -122,6 +137,9 @@ type SSHAllowedReq struct {
 	Krb5Principal string         `json:"krb5principal,omitempty"`
 	CheckIP       string         `json:"check_ip,omitempty"`
 	NamespacePath string         `json:"namespace_path,omitempty"`
+	UserID        string         `json:"user_id,omitempty"`
+	// Env is the quarantine directories of the pushed objects in json, sent by the pre-receive hook
+	Env string `json:"env,omitempty"`
 }
 
 type SSHAllowedResp struct {
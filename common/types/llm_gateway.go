@@ -0,0 +1,25 @@
+package types
+
+// OpenAIModel is a model served by the llm gateway, its id is the path of the model repository
+type OpenAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type OpenAIModelList struct {
+	Object string        `json:"object"`
+	Data   []OpenAIModel `json:"data"`
+}
+
+// OpenAIError is the error body of openai compatible apis
+type OpenAIError struct {
+	Error OpenAIErrorDetail `json:"error"`
+}
+
+type OpenAIErrorDetail struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
@@ -166,6 +166,8 @@ type Model struct {
 	CanManage           bool                 `json:"can_manage"`
 	Namespace           *Namespace           `json:"namespace"`
 	MirrorLastUpdatedAt time.Time            `json:"mirror_last_updated_at"`
+	// SecurityStatus is the result of scanning the weight files for unsafe serialization
+	SecurityStatus *ModelSecurityStatus `json:"security_status,omitempty"`
 }
 
 type SDKModelInfo struct {
@@ -192,6 +194,7 @@ type SDKModelInfo struct {
 	Siblings         []SDKFile              `json:"siblings"`
 	Spaces           []string               `json:"spaces,omitempty"`
 	SafeTensors      interface{}            `json:"safetensors,omitempty"` // SafeTensorsInfo
+	SecurityStatus   *ModelSecurityStatus   `json:"security_status,omitempty"`
 }
 
 type ModelWidgetType string
@@ -297,6 +300,8 @@ type DeployUpdateReq struct {
 	MaxReplica         *int    `json:"max_replica" validate:"min=1,gtefield=MinReplica"`
 	Revision           *string `json:"revision"`
 	SecureLevel        *int    `json:"secure_level"`
+	// Autoscaling replaces the autoscaling policy of deploy
+	Autoscaling *AutoscalingPolicy `json:"autoscaling,omitempty"`
 }
 
 type RelationModels struct {
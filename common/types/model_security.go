@@ -0,0 +1,46 @@
+package types
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// PickleFileExts are the extensions of model files which may be pickles, loading a pickle can run arbitrary code
+var PickleFileExts = []string{".bin", ".pt", ".pth", ".pkl", ".pickle", ".ckpt", ".joblib"}
+
+// SafeWeightFileExts are the extensions of model weight formats which can not carry code
+var SafeWeightFileExts = []string{".safetensors", ".gguf"}
+
+// IsPickleFile reports whether the file may be a pickle by its extension
+func IsPickleFile(filePath string) bool {
+	return hasExt(filePath, PickleFileExts)
+}
+
+// IsSafeWeightFile reports whether the file is in a weight format which can not carry code by its extension
+func IsSafeWeightFile(filePath string) bool {
+	return hasExt(filePath, SafeWeightFileExts)
+}
+
+func hasExt(filePath string, exts []string) bool {
+	ext := path.Ext(filePath)
+	return slices.ContainsFunc(exts, func(e string) bool {
+		return strings.EqualFold(ext, e)
+	})
+}
+
+// ModelSecurityStatus is the result of scanning the weight files of a model for unsafe serialization
+type ModelSecurityStatus struct {
+	// ScansDone is false if some weight files are not scanned yet
+	ScansDone       bool                `json:"scans_done"`
+	FilesWithIssues []SecurityFileIssue `json:"files_with_issues"`
+	// UnverifiedFiles are the weight files failed to scan, like a file broken or unavailable, they may be
+	// unsafe as well
+	UnverifiedFiles []SecurityFileIssue `json:"unverified_files"`
+}
+
+type SecurityFileIssue struct {
+	Path string `json:"path"`
+	// Message lists the dangerous imports found in the file, or why the file failed to scan
+	Message string `json:"message"`
+}
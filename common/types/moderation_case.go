@@ -0,0 +1,137 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// ModerationCaseStatus is the status of a moderation case opened for a file failing the sensitive check
+type ModerationCaseStatus string
+
+const (
+	// ModerationCaseOpen is the status of a new case, the repository is private until the case is cleared
+	ModerationCaseOpen ModerationCaseStatus = "open"
+	// ModerationCaseAppealed is the status of a case appealed by the owner and waiting for a moderator
+	ModerationCaseAppealed ModerationCaseStatus = "appealed"
+	// ModerationCaseUpheld is the status of a case whose appeal is rejected, it can not be appealed again
+	ModerationCaseUpheld ModerationCaseStatus = "upheld"
+	// ModerationCaseCleared is the status of a case whose file is judged not sensitive by a moderator
+	ModerationCaseCleared ModerationCaseStatus = "cleared"
+)
+
+// Resolved reports whether no action is expected on the case any more
+func (s ModerationCaseStatus) Resolved() bool {
+	return s == ModerationCaseCleared
+}
+
+// ModerationSystemOperator is the operator in audit logs of the actions taken by sensitive checks
+const ModerationSystemOperator = "system"
+
+// ModerationAction is an action taken on a moderation case, every action is kept in the audit log of case
+type ModerationAction string
+
+const (
+	ModerationActionOpen ModerationAction = "open"
+	// ModerationActionRecheck is logged when the file is checked again while the case is not cleared, the case
+	// is cleared by the system if the file passes the check
+	ModerationActionRecheck ModerationAction = "recheck"
+	ModerationActionAppeal  ModerationAction = "appeal"
+	// ModerationActionApprove approves the appeal of owner, the case is cleared
+	ModerationActionApprove ModerationAction = "approve"
+	// ModerationActionReject rejects the appeal of owner, the case is upheld
+	ModerationActionReject ModerationAction = "reject"
+	// ModerationActionOverride clears the case without an appeal, or reverses the rejection of an appeal
+	ModerationActionOverride ModerationAction = "override"
+)
+
+// ModerationDecisions are the actions moderators can take on a case
+var ModerationDecisions = []ModerationAction{
+	ModerationActionApprove,
+	ModerationActionReject,
+	ModerationActionOverride,
+}
+
+// Transit returns the status of a case in status s after the action, ok is false if the action
+// can not be taken on the case
+func (s ModerationCaseStatus) Transit(action ModerationAction) (next ModerationCaseStatus, ok bool) {
+	switch action {
+	case ModerationActionRecheck:
+		return s, !s.Resolved()
+	case ModerationActionAppeal:
+		return ModerationCaseAppealed, s == ModerationCaseOpen
+	case ModerationActionApprove:
+		return ModerationCaseCleared, s == ModerationCaseAppealed
+	case ModerationActionReject:
+		return ModerationCaseUpheld, s == ModerationCaseAppealed
+	case ModerationActionOverride:
+		return ModerationCaseCleared, !s.Resolved()
+	default:
+		return s, false
+	}
+}
+
+type ModerationCase struct {
+	ID             int64                `json:"id"`
+	RepositoryID   int64                `json:"repository_id"`
+	RepoPath       string               `json:"repo_path"`
+	RepoType       RepositoryType       `json:"repo_type"`
+	RepoFileID     int64                `json:"repo_file_id"`
+	Path           string               `json:"path"`
+	CommitSha      string               `json:"commit_sha"`
+	Message        string               `json:"message"`
+	Status         ModerationCaseStatus `json:"status"`
+	AppealReason   string               `json:"appeal_reason,omitempty"`
+	AppealedBy     string               `json:"appealed_by,omitempty"`
+	AppealedAt     *time.Time           `json:"appealed_at,omitempty"`
+	Decision       ModerationAction     `json:"decision,omitempty"`
+	DecisionReason string               `json:"decision_reason,omitempty"`
+	DecidedBy      string               `json:"decided_by,omitempty"`
+	DecidedAt      *time.Time           `json:"decided_at,omitempty"`
+	// Logs is the audit log of case, it's only returned for a single case
+	Logs      []ModerationCaseLog `json:"logs,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+type ModerationCaseLog struct {
+	ID         int64                `json:"id"`
+	Action     ModerationAction     `json:"action"`
+	Operator   string               `json:"operator"`
+	FromStatus ModerationCaseStatus `json:"from_status,omitempty"`
+	ToStatus   ModerationCaseStatus `json:"to_status"`
+	Comment    string               `json:"comment,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+// ModerationCasesReq lists the cases of a repository for its admins, or all cases for moderators if Name is empty
+type ModerationCasesReq struct {
+	Status      ModerationCaseStatus `json:"-"`
+	RepoType    RepositoryType       `json:"-"`
+	Namespace   string               `json:"-"`
+	Name        string               `json:"-"`
+	CurrentUser string               `json:"-"`
+	Per         int                  `json:"-"`
+	Page        int                  `json:"-"`
+}
+
+type AppealModerationCaseReq struct {
+	Reason      string `json:"reason" binding:"required"`
+	ID          int64  `json:"-"`
+	CurrentUser string `json:"-"`
+}
+
+type DecideModerationCaseReq struct {
+	Decision    ModerationAction `json:"decision" binding:"required"`
+	Reason      string           `json:"reason"`
+	ID          int64            `json:"-"`
+	CurrentUser string           `json:"-"`
+}
+
+func (r *DecideModerationCaseReq) Validate() error {
+	for _, d := range ModerationDecisions {
+		if r.Decision == d {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid decision %s, must be one of %v", r.Decision, ModerationDecisions)
+}
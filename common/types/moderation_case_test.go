@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModerationCaseStatusTransit(t *testing.T) {
+	cases := []struct {
+		from   ModerationCaseStatus
+		action ModerationAction
+		to     ModerationCaseStatus
+		ok     bool
+	}{
+		{ModerationCaseOpen, ModerationActionAppeal, ModerationCaseAppealed, true},
+		{ModerationCaseOpen, ModerationActionApprove, ModerationCaseCleared, false},
+		{ModerationCaseOpen, ModerationActionOverride, ModerationCaseCleared, true},
+		{ModerationCaseOpen, ModerationActionRecheck, ModerationCaseOpen, true},
+		{ModerationCaseAppealed, ModerationActionAppeal, ModerationCaseAppealed, false},
+		{ModerationCaseAppealed, ModerationActionApprove, ModerationCaseCleared, true},
+		{ModerationCaseAppealed, ModerationActionReject, ModerationCaseUpheld, true},
+		{ModerationCaseUpheld, ModerationActionAppeal, ModerationCaseAppealed, false},
+		{ModerationCaseUpheld, ModerationActionOverride, ModerationCaseCleared, true},
+		{ModerationCaseCleared, ModerationActionRecheck, ModerationCaseCleared, false},
+		{ModerationCaseCleared, ModerationActionOverride, ModerationCaseCleared, false},
+		{ModerationCaseOpen, ModerationActionOpen, ModerationCaseOpen, false},
+	}
+	for _, c := range cases {
+		to, ok := c.from.Transit(c.action)
+		require.Equal(t, c.ok, ok, "%s on %s", c.action, c.from)
+		if ok {
+			require.Equal(t, c.to, to, "%s on %s", c.action, c.from)
+		}
+	}
+}
+
+func TestDecideModerationCaseReqValidate(t *testing.T) {
+	req := DecideModerationCaseReq{Decision: ModerationActionReject}
+	require.NoError(t, req.Validate())
+	req.Decision = ModerationActionAppeal
+	require.Error(t, req.Validate())
+}
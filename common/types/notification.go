@@ -0,0 +1,22 @@
+package types
+
+import "time"
+
+type NotificationKind string
+
+const (
+	// balance of the account the user is charged to is low, overdrawn or recovered
+	NotificationBalance NotificationKind = "balance"
+	// a file of repository is found sensitive by the content moderation
+	NotificationSensitiveFile NotificationKind = "sensitive_file"
+)
+
+type Notification struct {
+	ID        int64            `json:"id"`
+	Kind      NotificationKind `json:"kind"`
+	Title     string           `json:"title"`
+	Content   string           `json:"content"`
+	Link      string           `json:"link"`
+	Read      bool             `json:"read"`
+	CreatedAt time.Time        `json:"created_at"`
+}
@@ -36,10 +36,17 @@ type LLMMessage struct {
 }
 
 type LLMReqBody struct {
-	Model       string       `json:"model"`
-	Messages    []LLMMessage `json:"messages"`
-	Stream      bool         `json:"stream"`
-	Temperature float64      `json:"temperature"`
+	Model         string            `json:"model"`
+	Messages      []LLMMessage      `json:"messages"`
+	Stream        bool              `json:"stream"`
+	StreamOptions *LLMStreamOptions `json:"stream_options,omitempty"`
+	Temperature   float64           `json:"temperature"`
+	MaxTokens     int               `json:"max_tokens,omitempty"`
+}
+
+type LLMStreamOptions struct {
+	// IncludeUsage asks for a last chunk with the token usage of the stream
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type ConversationMessageReq struct {
@@ -55,15 +62,25 @@ type LLMResponse struct {
 	Model             string      `json:"model"`
 	SystemFingerprint string      `json:"system_fingerprint"`
 	Choices           []LLMChoice `json:"choices"`
+	// Usage is returned by non-stream requests
+	Usage LLMUsage `json:"usage"`
 }
 
 type LLMChoice struct {
-	Index        int        `json:"index"`
-	Delta        LLMMessage `json:"delta"`
+	Index int        `json:"index"`
+	Delta LLMMessage `json:"delta"`
+	// Message is returned by non-stream requests instead of Delta
+	Message      LLMMessage `json:"message"`
 	LogProbs     string     `json:"logprobs"`
 	FinishReason string     `json:"finish_reason"`
 }
 
+type LLMUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
 type LLMDelta struct {
 	Content string `json:"content"`
 }
@@ -0,0 +1,198 @@
+package types
+
+import "time"
+
+type PullRequestStatus string
+
+const (
+	PullRequestStatusOpen   PullRequestStatus = "open"
+	PullRequestStatusClosed PullRequestStatus = "closed"
+	PullRequestStatusMerged PullRequestStatus = "merged"
+)
+
+type MergeStrategy string
+
+const (
+	// MergeStrategyMerge creates a merge commit on the target branch
+	MergeStrategyMerge MergeStrategy = "merge"
+	// MergeStrategySquash squashes all source commits into one commit on the target branch
+	MergeStrategySquash MergeStrategy = "squash"
+	// MergeStrategyFastForward only moves the target branch, fails if the branches diverged
+	MergeStrategyFastForward MergeStrategy = "fast-forward"
+)
+
+func (s MergeStrategy) Valid() bool {
+	switch s {
+	case MergeStrategyMerge, MergeStrategySquash, MergeStrategyFastForward:
+		return true
+	default:
+		return false
+	}
+}
+
+type CreatePullRequestReq struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	// path of the source repository in format of namespace/name, empty means the same repository
+	SourceRepo   string         `json:"source_repo" example:"user_or_org_name/repo_name"`
+	SourceBranch string         `json:"source_branch" binding:"required"`
+	TargetBranch string         `json:"target_branch" binding:"required"`
+	RepoType     RepositoryType `json:"-"`
+	Namespace    string         `json:"-"`
+	Name         string         `json:"-"`
+	CurrentUser  string         `json:"-"`
+}
+
+// CreatePullRequestReq implements SensitiveRequestV2
+var _ SensitiveRequestV2 = (*CreatePullRequestReq)(nil)
+
+func (req *CreatePullRequestReq) GetSensitiveFields() []SensitiveField {
+	return []SensitiveField{
+		{
+			Name: "title",
+			Value: func() string {
+				return req.Title
+			},
+		},
+		{
+			Name: "description",
+			Value: func() string {
+				return req.Description
+			},
+		},
+	}
+}
+
+type UpdatePullRequestReq struct {
+	Title       *string        `json:"title"`
+	Description *string        `json:"description"`
+	ID          int64          `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+// UpdatePullRequestReq implements SensitiveRequestV2
+var _ SensitiveRequestV2 = (*UpdatePullRequestReq)(nil)
+
+func (req *UpdatePullRequestReq) GetSensitiveFields() []SensitiveField {
+	var fields []SensitiveField
+	if req.Title != nil {
+		fields = append(fields, SensitiveField{
+			Name: "title",
+			Value: func() string {
+				return *req.Title
+			},
+		})
+	}
+	if req.Description != nil {
+		fields = append(fields, SensitiveField{
+			Name: "description",
+			Value: func() string {
+				return *req.Description
+			},
+		})
+	}
+	return fields
+}
+
+type ListPullRequestsReq struct {
+	RepoType    RepositoryType    `json:"-"`
+	Namespace   string            `json:"-"`
+	Name        string            `json:"-"`
+	Status      PullRequestStatus `json:"status"`
+	CurrentUser string            `json:"-"`
+	Per         int               `json:"per"`
+	Page        int               `json:"page"`
+}
+
+// PullRequestActReq identifies a single pull request of a repository
+type PullRequestActReq struct {
+	RepoType    RepositoryType `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	ID          int64          `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type MergePullRequestReq struct {
+	Strategy MergeStrategy `json:"strategy" example:"merge"`
+	// commit message of the merge or squash commit, generated from the pull request if empty
+	Message string `json:"message"`
+	PullRequestActReq
+}
+
+type PullRequestUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"name"`
+	Avatar   string `json:"avatar"`
+}
+
+type PullRequest struct {
+	ID             int64                 `json:"id"`
+	Number         int64                 `json:"number"`
+	Title          string                `json:"title"`
+	Description    string                `json:"description"`
+	Status         PullRequestStatus     `json:"status"`
+	SourceRepo     string                `json:"source_repo"`
+	SourceBranch   string                `json:"source_branch"`
+	TargetRepo     string                `json:"target_repo"`
+	TargetBranch   string                `json:"target_branch"`
+	User           *PullRequestUser      `json:"user"`
+	MergeStrategy  MergeStrategy         `json:"merge_strategy,omitempty"`
+	MergeCommitID  string                `json:"merge_commit_id,omitempty"`
+	MergedBy       *PullRequestUser      `json:"merged_by,omitempty"`
+	MergedAt       *time.Time            `json:"merged_at,omitempty"`
+	ClosedAt       *time.Time            `json:"closed_at,omitempty"`
+	CommentCount   int64                 `json:"comment_count"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	CanMerge       bool                  `json:"can_merge"`
+	ReviewComments []*PullRequestComment `json:"review_comments,omitempty"`
+}
+
+type PullRequestDiff struct {
+	BaseCommitID      string       `json:"base_commit_id"`
+	HeadCommitID      string       `json:"head_commit_id"`
+	MergeBaseCommitID string       `json:"merge_base_commit_id"`
+	Files             []string     `json:"files"`
+	Stats             *CommitStats `json:"stats"`
+	Diff              []byte       `json:"diff"`
+}
+
+type CreatePullRequestCommentReq struct {
+	Content string `json:"content" binding:"required"`
+	// file path the comment is anchored to, empty for a general comment
+	Path string `json:"path"`
+	// line number in the new version of the file, 0 if not anchored to a line
+	Line int `json:"line"`
+	// commit the comment was made against, defaults to the current head of the source branch
+	CommitID string `json:"commit_id"`
+	PullRequestActReq
+}
+
+// CreatePullRequestCommentReq implements SensitiveRequestV2
+var _ SensitiveRequestV2 = (*CreatePullRequestCommentReq)(nil)
+
+func (req *CreatePullRequestCommentReq) GetSensitiveFields() []SensitiveField {
+	return []SensitiveField{
+		{
+			Name: "content",
+			Value: func() string {
+				return req.Content
+			},
+			Scenario: "comment_detection",
+		},
+	}
+}
+
+type PullRequestComment struct {
+	ID        int64            `json:"id"`
+	Content   string           `json:"content"`
+	Path      string           `json:"path,omitempty"`
+	Line      int              `json:"line,omitempty"`
+	CommitID  string           `json:"commit_id,omitempty"`
+	User      *PullRequestUser `json:"user"`
+	CreatedAt time.Time        `json:"created_at"`
+}
@@ -0,0 +1,131 @@
+package types
+
+import "time"
+
+type GetGitTagsReq struct {
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+	Per         int            `json:"-"`
+	Page        int            `json:"-"`
+}
+
+type CreateGitTagReq struct {
+	Tag string `json:"tag" binding:"required" example:"v1.0"`
+	// branch or commit id the tag points to, default to the default branch
+	Target string `json:"target" example:"main"`
+	// an annotated tag is created if message is not empty
+	Message     string         `json:"message"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type DeleteGitTagReq struct {
+	Tag         string         `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type CreateReleaseReq struct {
+	// tag of the release, it will be created from target if not exists
+	TagName string `json:"tag_name" binding:"required" example:"v1.0"`
+	// branch or commit id to create the tag from, default to the default branch
+	Target string `json:"target" example:"main"`
+	Title  string `json:"title"`
+	Notes  string `json:"notes"`
+	// paths of repository files attached to the release as assets
+	Assets      []string       `json:"assets" example:"model.safetensors"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+// CreateReleaseReq implements SensitiveRequestV2
+var _ SensitiveRequestV2 = (*CreateReleaseReq)(nil)
+
+func (req *CreateReleaseReq) GetSensitiveFields() []SensitiveField {
+	return []SensitiveField{
+		{
+			Name: "title",
+			Value: func() string {
+				return req.Title
+			},
+		},
+		{
+			Name: "notes",
+			Value: func() string {
+				return req.Notes
+			},
+		},
+	}
+}
+
+type UpdateReleaseReq struct {
+	Title       *string        `json:"title"`
+	Notes       *string        `json:"notes"`
+	Assets      *[]string      `json:"assets"`
+	TagName     string         `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+// UpdateReleaseReq implements SensitiveRequestV2
+var _ SensitiveRequestV2 = (*UpdateReleaseReq)(nil)
+
+func (req *UpdateReleaseReq) GetSensitiveFields() []SensitiveField {
+	var fields []SensitiveField
+	if req.Title != nil {
+		fields = append(fields, SensitiveField{
+			Name: "title",
+			Value: func() string {
+				return *req.Title
+			},
+		})
+	}
+	if req.Notes != nil {
+		fields = append(fields, SensitiveField{
+			Name: "notes",
+			Value: func() string {
+				return *req.Notes
+			},
+		})
+	}
+	return fields
+}
+
+type ReleaseReq struct {
+	TagName     string         `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	CurrentUser string         `json:"-"`
+	Per         int            `json:"-"`
+	Page        int            `json:"-"`
+}
+
+type Release struct {
+	ID      int64  `json:"id"`
+	TagName string `json:"tag_name"`
+	Title   string `json:"title"`
+	Notes   string `json:"notes"`
+	// CommitID is the commit pinned when the release was created,
+	// the release keeps pointing to it even if the tag is moved or deleted
+	CommitID  string         `json:"commit_id"`
+	Assets    []ReleaseAsset `json:"assets"`
+	Author    string         `json:"author"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type ReleaseAsset struct {
+	Path        string `json:"path"`
+	DownloadURL string `json:"download_url"`
+}
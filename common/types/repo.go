@@ -35,6 +35,7 @@ const (
 	SensitiveCheckPass      SensitiveCheckStatus = 1  //pass
 	SensitiveCheckSkip      SensitiveCheckStatus = 2  //skip
 	SensitiveCheckException SensitiveCheckStatus = 3  //error happen
+	SensitiveCheckUnsafe    SensitiveCheckStatus = 4  //unsafe serialization which runs code when loaded
 
 	EndpointPublic  int = 1 // public - anyone can access
 	EndpointPrivate int = 2 // private - access with read permission
@@ -125,6 +126,10 @@ type DeployRepo struct {
 	SKU              string     `json:"sku,omitempty"`
 	ResourceType     string     `json:"resource_type,omitempty"`
 	RepoTag          string     `json:"repo_tag,omitempty"`
+	// Autoscaling is the autoscaling policy of inference endpoint
+	Autoscaling *AutoscalingPolicy `json:"autoscaling,omitempty"`
+	// LastRequestAt is the time of the last request through the endpoint proxy
+	LastRequestAt *time.Time `json:"last_request_at,omitempty"`
 }
 
 type RuntimeFrameworkReq struct {
@@ -0,0 +1,118 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+type SecretFindingStatus string
+
+const (
+	// SecretFindingOpen is a secret found in the latest version of file
+	SecretFindingOpen SecretFindingStatus = "open"
+	// SecretFindingDismissed is dismissed by the admins of repository, like a test credential or a revoked key
+	SecretFindingDismissed SecretFindingStatus = "dismissed"
+	// SecretFindingFixed is not found anymore when the file is checked again
+	SecretFindingFixed SecretFindingStatus = "fixed"
+)
+
+type SecretFinding struct {
+	ID           int64               `json:"id"`
+	RepositoryID int64               `json:"repository_id"`
+	Path         string              `json:"path"`
+	CommitSha    string              `json:"commit_sha"`
+	Line         int                 `json:"line"`
+	RuleID       string              `json:"rule_id"`
+	Description  string              `json:"description"`
+	Fingerprint  string              `json:"fingerprint"`
+	Redacted     string              `json:"redacted"`
+	Status       SecretFindingStatus `json:"status"`
+	DismissedBy  string              `json:"dismissed_by,omitempty"`
+	DismissedAt  *time.Time          `json:"dismissed_at,omitempty"`
+	// DismissReason is the reason of admin to dismiss the finding
+	DismissReason string    `json:"dismiss_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SecretAllowlistEntry allows the secrets of the fingerprint, or all secrets in the files matching the path
+// pattern, secrets allowed are not reported by the secret scanning of repository
+type SecretAllowlistEntry struct {
+	ID           int64     `json:"id"`
+	RepositoryID int64     `json:"repository_id"`
+	Fingerprint  string    `json:"fingerprint,omitempty"`
+	PathPattern  string    `json:"path_pattern,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedBy    string    `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Allows reports whether the secret of the fingerprint in the file is allowed by the entry
+func (e *SecretAllowlistEntry) Allows(filePath, fingerprint string) bool {
+	if e.Fingerprint != "" && e.Fingerprint != fingerprint {
+		return false
+	}
+	if e.PathPattern != "" && !MatchSecretPathPattern(e.PathPattern, filePath) {
+		return false
+	}
+	return e.Fingerprint != "" || e.PathPattern != ""
+}
+
+// MatchSecretPathPattern reports whether the file path matches the pattern of allowlist, the pattern is
+// matched against the path and all of its parent folders, so `tests` or `tests/*` allows the files in tests
+func MatchSecretPathPattern(pattern, filePath string) bool {
+	pattern = strings.Trim(pattern, "/")
+	for p := strings.Trim(filePath, "/"); p != "." && p != ""; p = path.Dir(p) {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type SecretFindingsReq struct {
+	Status      SecretFindingStatus `json:"-"`
+	RepoType    RepositoryType      `json:"-"`
+	Namespace   string              `json:"-"`
+	Name        string              `json:"-"`
+	CurrentUser string              `json:"-"`
+	Per         int                 `json:"-"`
+	Page        int                 `json:"-"`
+}
+
+type DismissSecretFindingReq struct {
+	Reason string `json:"reason" binding:"required"`
+	// Allowlist adds the fingerprint of secret to the allowlist of repository, so the same secret is not
+	// reported again in any file
+	Allowlist   bool           `json:"allowlist"`
+	ID          int64          `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type CreateSecretAllowlistEntryReq struct {
+	Fingerprint string         `json:"fingerprint"`
+	PathPattern string         `json:"path_pattern"`
+	Reason      string         `json:"reason"`
+	RepoType    RepositoryType `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+func (r *CreateSecretAllowlistEntryReq) Validate() error {
+	if r.Fingerprint == "" && r.PathPattern == "" {
+		return errors.New("one of fingerprint and path_pattern is required")
+	}
+	if r.PathPattern != "" {
+		if _, err := path.Match(r.PathPattern, ""); err != nil {
+			return fmt.Errorf("invalid path pattern %s, error: %w", r.PathPattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretAllowlistEntry_Allows(t *testing.T) {
+	byFingerprint := SecretAllowlistEntry{Fingerprint: "fp1"}
+	require.True(t, byFingerprint.Allows("a/b.py", "fp1"))
+	require.False(t, byFingerprint.Allows("a/b.py", "fp2"))
+
+	byPath := SecretAllowlistEntry{PathPattern: "tests"}
+	require.True(t, byPath.Allows("tests/fixtures/creds.yaml", "fp1"))
+	require.False(t, byPath.Allows("src/tests.py", "fp1"))
+
+	byGlob := SecretAllowlistEntry{PathPattern: "*.ipynb"}
+	require.True(t, byGlob.Allows("demo.ipynb", "fp1"))
+	require.False(t, byGlob.Allows("notebooks/demo.ipynb", "fp1"))
+
+	both := SecretAllowlistEntry{Fingerprint: "fp1", PathPattern: "/examples/*"}
+	require.True(t, both.Allows("examples/demo/app.py", "fp1"))
+	require.False(t, both.Allows("examples/app.py", "fp2"))
+	require.False(t, both.Allows("src/app.py", "fp1"))
+
+	require.False(t, (&SecretAllowlistEntry{}).Allows("a.py", "fp1"))
+}
+
+func TestCreateSecretAllowlistEntryReq_Validate(t *testing.T) {
+	require.Error(t, (&CreateSecretAllowlistEntryReq{}).Validate())
+	require.Error(t, (&CreateSecretAllowlistEntryReq{PathPattern: "tests/["}).Validate())
+	require.NoError(t, (&CreateSecretAllowlistEntryReq{PathPattern: "tests/*"}).Validate())
+	require.NoError(t, (&CreateSecretAllowlistEntryReq{Fingerprint: "fp1"}).Validate())
+}
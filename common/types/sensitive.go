@@ -9,4 +9,6 @@ type SensitiveField struct {
 	Value func() string
 	// like nickname, chat, comment, etc. See sensitive.Scenario for more details.
 	Scenario string
+	// the value is the url of an image, like avatar, which is checked as image instead of text
+	IsImage bool
 }
@@ -34,6 +34,8 @@ type (
 		DeployType       int    `json:"deploy_type"`
 		UserID           string `json:"user_id"`
 		Sku              string `json:"sku"`
+
+		Autoscaling *AutoscalingPolicy `json:"autoscaling,omitempty"` // autoscaling policy of inference endpoint
 	}
 
 	RunResponse struct {
@@ -181,5 +183,7 @@ type (
 		DeployType int               `json:"deploy_type"`
 		UserID     string            `json:"user_id"`
 		Sku        string            `json:"sku"`
+
+		Autoscaling *AutoscalingPolicy `json:"autoscaling,omitempty"`
 	}
 )
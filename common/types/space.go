@@ -14,6 +14,21 @@ type CreateSpaceReq struct {
 	ClusterID     string `json:"cluster_id"`
 }
 
+var _ SensitiveRequestV2 = (*CreateSpaceReq)(nil)
+
+func (c *CreateSpaceReq) GetSensitiveFields() []SensitiveField {
+	fields := c.CreateRepoReq.GetSensitiveFields()
+	fields = append(fields, SensitiveField{
+		Name: "cover_image_url",
+		Value: func() string {
+			return c.CoverImageUrl
+		},
+		Scenario: "baselineCheck",
+		IsImage:  true,
+	})
+	return fields
+}
+
 // Space is the domain object for spaces
 type Space struct {
 	ID            int64       `json:"id,omitempty"`
@@ -65,3 +80,20 @@ type UpdateSpaceReq struct {
 	ResourceID    *int64  `json:"resource_id"`
 	Secrets       *string `json:"secrets"`
 }
+
+var _ SensitiveRequestV2 = (*UpdateSpaceReq)(nil)
+
+func (c *UpdateSpaceReq) GetSensitiveFields() []SensitiveField {
+	fields := c.UpdateRepoReq.GetSensitiveFields()
+	if c.CoverImageUrl != nil {
+		fields = append(fields, SensitiveField{
+			Name: "cover_image_url",
+			Value: func() string {
+				return *c.CoverImageUrl
+			},
+			Scenario: "baselineCheck",
+			IsImage:  true,
+		})
+	}
+	return fields
+}
@@ -0,0 +1,19 @@
+package types
+
+type TransferRepoReq struct {
+	// namespace of the user or organization to move the repository to
+	NewNamespace string         `json:"new_namespace" binding:"required" example:"user_or_org_name"`
+	Namespace    string         `json:"-"`
+	Name         string         `json:"-"`
+	RepoType     RepositoryType `json:"-"`
+	CurrentUser  string         `json:"-"`
+}
+
+type TransferRepoResp struct {
+	Path string `json:"path"`
+	// OldPath redirects to the new path of the repository
+	OldPath      string         `json:"old_path"`
+	RepoType     RepositoryType `json:"repo_type"`
+	HTTPCloneURL string         `json:"http_clone_url"`
+	SSHCloneURL  string         `json:"ssh_clone_url"`
+}
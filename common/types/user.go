@@ -78,6 +78,17 @@ func (u *UpdateUserRequest) GetSensitiveFields() []SensitiveField {
 			Scenario: "chat_detection",
 		})
 	}
+
+	if u.Avatar != nil {
+		fields = append(fields, SensitiveField{
+			Name: "avatar",
+			Value: func() string {
+				return *u.Avatar
+			},
+			Scenario: "profilePhotoCheck",
+			IsImage:  true,
+		})
+	}
 	return fields
 }
 
@@ -0,0 +1,147 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookEventPush           WebhookEvent = "push"
+	WebhookEventDiscussion     WebhookEvent = "discussion"
+	WebhookEventDeployStatus   WebhookEvent = "deploy_status"
+	WebhookEventMirrorFinished WebhookEvent = "mirror_finished"
+	// WebhookEventModeration is sent when a moderation case of the repository is opened or decided
+	WebhookEventModeration WebhookEvent = "moderation"
+	// WebhookEventSecretScanning is sent when secrets are found in the files pushed to repository
+	WebhookEventSecretScanning WebhookEvent = "secret_scanning"
+	// WebhookEventPing is sent by the test endpoint of webhook, it can not be subscribed
+	WebhookEventPing WebhookEvent = "ping"
+)
+
+var WebhookEvents = []WebhookEvent{
+	WebhookEventPush,
+	WebhookEventDiscussion,
+	WebhookEventDeployStatus,
+	WebhookEventMirrorFinished,
+	WebhookEventModeration,
+	WebhookEventSecretScanning,
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookReq creates or updates a webhook of a repository, or of an organization if Name is empty
+type WebhookReq struct {
+	URL string `json:"url" binding:"required"`
+	// Secret signs the payload with HMAC-SHA256, it is kept unchanged if empty on update
+	Secret string         `json:"secret"`
+	Events []WebhookEvent `json:"events" binding:"required"`
+	Active bool           `json:"active"`
+
+	ID          int64          `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	CurrentUser string         `json:"-"`
+}
+
+type WebhookDeliveriesReq struct {
+	WebhookID   int64          `json:"-"`
+	DeliveryID  int64          `json:"-"`
+	RepoType    RepositoryType `json:"-"`
+	Namespace   string         `json:"-"`
+	Name        string         `json:"-"`
+	CurrentUser string         `json:"-"`
+	Per         int            `json:"-"`
+	Page        int            `json:"-"`
+}
+
+type Webhook struct {
+	ID        int64          `json:"id"`
+	URL       string         `json:"url"`
+	HasSecret bool           `json:"has_secret"`
+	Events    []WebhookEvent `json:"events"`
+	Active    bool           `json:"active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	WebhookID      int64                 `json:"webhook_id"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        json.RawMessage       `json:"payload" swaggertype:"object"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus int                   `json:"response_status"`
+	ResponseBody   string                `json:"response_body"`
+	Error          string                `json:"error"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+// WebhookPayload is the body posted to webhook urls
+type WebhookPayload struct {
+	Event WebhookEvent `json:"event"`
+	// Repository is empty for the ping event of organization webhooks
+	Repository *WebhookRepository `json:"repository,omitempty"`
+	Data       any                `json:"data"`
+	Timestamp  time.Time          `json:"timestamp"`
+}
+
+type WebhookRepository struct {
+	ID       int64          `json:"id"`
+	Path     string         `json:"path"`
+	RepoType RepositoryType `json:"repo_type"`
+	Private  bool           `json:"private"`
+}
+
+type WebhookPushData struct {
+	Ref     string                        `json:"ref"`
+	Commits []GiteaCallbackPushReq_Commit `json:"commits"`
+	Message string                        `json:"message"`
+}
+
+type WebhookDiscussionData struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	Username string `json:"username"`
+}
+
+type WebhookModerationData struct {
+	CaseID  int64                `json:"case_id"`
+	Action  ModerationAction     `json:"action"`
+	Status  ModerationCaseStatus `json:"status"`
+	Path    string               `json:"path"`
+	Message string               `json:"message"`
+	// Reason is the reason of moderator for the decision
+	Reason string `json:"reason,omitempty"`
+}
+
+// WebhookSecretScanningData has the new secrets found in a file, secrets are redacted
+type WebhookSecretScanningData struct {
+	Path      string          `json:"path"`
+	CommitSha string          `json:"commit_sha"`
+	Findings  []SecretFinding `json:"findings"`
+}
+
+type WebhookDeployStatusData struct {
+	DeployID   int64  `json:"deploy_id"`
+	DeployName string `json:"deploy_name"`
+	SvcName    string `json:"svc_name"`
+	Status     int    `json:"status"`
+	Message    string `json:"message"`
+}
+
+type WebhookMirrorFinishedData struct {
+	MirrorID  int64  `json:"mirror_id"`
+	SourceURL string `json:"source_url"`
+}
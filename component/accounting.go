@@ -2,23 +2,35 @@ package component
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/nats-io/nats.go/jetstream"
 	"opencsg.com/csghub-server/builder/accounting"
+	"opencsg.com/csghub-server/builder/deploy"
+	"opencsg.com/csghub-server/builder/event"
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
 )
 
 type accountingComponentImpl struct {
-	acctClient *accounting.AccountingClient
-	user       database.UserStore
-	deploy     database.DeployTaskStore
+	acctClient   *accounting.AccountingClient
+	user         database.UserStore
+	deploy       database.DeployTaskStore
+	repo         database.RepoStore
+	deployer     deploy.Deployer
+	notification database.NotificationStore
 }
 
 type AccountingComponent interface {
 	ListMeteringsByUserIDAndTime(ctx context.Context, req types.ACCT_STATEMENTS_REQ) (interface{}, error)
+	// RunBalanceActions handles the balance notifications of accounting until ctx is done, the deploys
+	// of users whose account is overdrawn are stopped
+	RunBalanceActions(ctx context.Context)
 }
 
 func NewAccountingComponent(config *config.Config) (AccountingComponent, error) {
@@ -27,9 +39,12 @@ func NewAccountingComponent(config *config.Config) (AccountingComponent, error)
 		return nil, err
 	}
 	return &accountingComponentImpl{
-		acctClient: c,
-		user:       database.NewUserStore(),
-		deploy:     database.NewDeployTaskStore(),
+		acctClient:   c,
+		user:         database.NewUserStore(),
+		deploy:       database.NewDeployTaskStore(),
+		repo:         database.NewRepoStore(),
+		deployer:     deploy.NewDeployer(),
+		notification: database.NewNotificationStore(),
 	}, nil
 }
 
@@ -43,3 +58,146 @@ func (ac *accountingComponentImpl) ListMeteringsByUserIDAndTime(ctx context.Cont
 	}
 	return ac.acctClient.ListMeteringsByUserIDAndTime(req)
 }
+
+const balanceNotifyRetryInterval = 10 * time.Second
+
+func (ac *accountingComponentImpl) RunBalanceActions(ctx context.Context) {
+	for ctx.Err() == nil {
+		consumer, err := event.DefaultEventPublisher.Connector.BuildNotifyConsumer(event.CSGHubServerDurableConsumerName)
+		if err != nil {
+			slog.Error("fail to build balance notification consumer", slog.Any("error", err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(balanceNotifyRetryInterval):
+			}
+			continue
+		}
+		ac.consumeBalanceNotifications(ctx, consumer)
+	}
+}
+
+func (ac *accountingComponentImpl) consumeBalanceNotifications(ctx context.Context, consumer jetstream.Consumer) {
+	for ctx.Err() == nil {
+		msgs, err := consumer.Fetch(10, jetstream.FetchMaxWait(5*time.Second))
+		if err != nil {
+			slog.Error("fail to fetch balance notifications", slog.Any("error", err))
+			return
+		}
+		for msg := range msgs.Messages() {
+			var notification types.BalanceNotification
+			err := json.Unmarshal(msg.Data(), &notification)
+			if err != nil {
+				slog.Error("invalid balance notification", slog.String("data", string(msg.Data())), slog.Any("error", err))
+			} else {
+				ac.handleBalanceNotification(ctx, notification)
+			}
+			if err := msg.Ack(); err != nil {
+				slog.Warn("fail to ack balance notification", slog.String("data", string(msg.Data())), slog.Any("error", err))
+			}
+		}
+		if msgs.Error() != nil {
+			slog.Error("fail to read balance notifications", slog.Any("error", msgs.Error()))
+			return
+		}
+	}
+}
+
+func (ac *accountingComponentImpl) handleBalanceNotification(ctx context.Context, notification types.BalanceNotification) {
+	switch notification.Event {
+	case types.BalanceEventNo:
+		slog.Warn("account is overdrawn, stop deploys of users charged to it", slog.String("owner_uuid", notification.OwnerUUID),
+			slog.String("balance", notification.Balance.String()), slog.Any("user_uuids", notification.UserUUIDs))
+		for _, userUUID := range notification.UserUUIDs {
+			err := ac.stopUserDeploys(ctx, userUUID)
+			if err != nil {
+				slog.Error("fail to stop deploys of user without balance", slog.String("user_uuid", userUUID), slog.Any("error", err))
+			}
+		}
+	case types.BalanceEventLow:
+		slog.Warn("account balance is low", slog.String("owner_uuid", notification.OwnerUUID),
+			slog.String("balance", notification.Balance.String()), slog.Any("user_uuids", notification.UserUUIDs))
+	case types.BalanceEventRecovered:
+		slog.Info("account balance is recovered", slog.String("owner_uuid", notification.OwnerUUID),
+			slog.String("balance", notification.Balance.String()))
+	default:
+		return
+	}
+	ac.notifyBalanceUsers(ctx, notification)
+}
+
+// notifyBalanceUsers sends an in-app notification of the balance event to the users charged to the account
+func (ac *accountingComponentImpl) notifyBalanceUsers(ctx context.Context, notification types.BalanceNotification) {
+	title, content := balanceNotificationMessage(notification)
+	for _, userUUID := range notification.UserUUIDs {
+		user, err := ac.user.FindByUUID(ctx, userUUID)
+		if err != nil {
+			slog.Error("fail to find user to notify of balance", slog.String("user_uuid", userUUID), slog.Any("error", err))
+			continue
+		}
+		_, err = ac.notification.Create(ctx, database.Notification{
+			UserID:  user.ID,
+			Kind:    types.NotificationBalance,
+			Title:   title,
+			Content: content,
+		})
+		if err != nil {
+			slog.Error("fail to notify user of balance", slog.String("user_uuid", userUUID), slog.Any("error", err))
+		}
+	}
+}
+
+func balanceNotificationMessage(notification types.BalanceNotification) (string, string) {
+	account := "Your account"
+	if notification.OwnerType == types.AccountOwnerOrg {
+		account = "The organization account you are charged to"
+	}
+	switch notification.Event {
+	case types.BalanceEventNo:
+		return "Account overdrawn", fmt.Sprintf("%s is overdrawn with a balance of %s, your running deploys are stopped. "+
+			"Recharge the account to start them again.", account, notification.Balance)
+	case types.BalanceEventLow:
+		return "Low account balance", fmt.Sprintf("%s has a low balance of %s, deploys will be stopped when it is overdrawn.",
+			account, notification.Balance)
+	default:
+		return "Account balance recovered", fmt.Sprintf("%s has a balance of %s now.", account, notification.Balance)
+	}
+}
+
+func (ac *accountingComponentImpl) stopUserDeploys(ctx context.Context, userUUID string) error {
+	user, err := ac.user.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return fmt.Errorf("fail to find user, %w", err)
+	}
+	deploys, err := ac.deploy.ListAllDeployments(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("fail to list running deploys, %w", err)
+	}
+	for _, d := range deploys {
+		repo, err := ac.repo.FindById(ctx, d.RepoID)
+		if err != nil {
+			slog.Error("fail to find repo of deploy", slog.Int64("deploy_id", d.ID), slog.Any("error", err))
+			continue
+		}
+		namespace, name := repo.NamespaceAndName()
+		err = ac.deployer.Stop(ctx, types.DeployRepo{
+			DeployID:  d.ID,
+			SpaceID:   d.SpaceID,
+			ModelID:   d.ModelID,
+			Namespace: namespace,
+			Name:      name,
+			SvcName:   d.SvcName,
+			ClusterID: d.ClusterID,
+		})
+		if err != nil {
+			// fail to stop deploy instance, maybe service is gone
+			slog.Warn("stop deploy instance with error", slog.Int64("deploy_id", d.ID), slog.Any("error", err))
+		}
+		err = ac.deploy.StopDeploy(ctx, repo.RepositoryType, d.RepoID, user.ID, d.ID)
+		if err != nil {
+			slog.Error("fail to stop deploy without balance", slog.Int64("deploy_id", d.ID), slog.Any("error", err))
+			continue
+		}
+		slog.Info("deploy is stopped for no balance", slog.Int64("deploy_id", d.ID), slog.String("user_uuid", userUUID))
+	}
+	return nil
+}
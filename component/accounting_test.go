@@ -0,0 +1,59 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type fakeNotificationUserStore struct {
+	database.UserStore
+	users map[string]*database.User
+}
+
+func (s *fakeNotificationUserStore) FindByUUID(ctx context.Context, uuid string) (*database.User, error) {
+	user, ok := s.users[uuid]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+type fakeNotificationStore struct {
+	database.NotificationStore
+	notifications []database.Notification
+}
+
+func (s *fakeNotificationStore) Create(ctx context.Context, notification database.Notification) (*database.Notification, error) {
+	s.notifications = append(s.notifications, notification)
+	return &notification, nil
+}
+
+func TestAccountingComponent_HandleBalanceNotification(t *testing.T) {
+	notifications := &fakeNotificationStore{}
+	c := &accountingComponentImpl{
+		user: &fakeNotificationUserStore{users: map[string]*database.User{
+			"uuid1": {ID: 1, UUID: "uuid1"},
+			"uuid2": {ID: 2, UUID: "uuid2"},
+		}},
+		notification: notifications,
+	}
+
+	c.handleBalanceNotification(context.Background(), types.BalanceNotification{
+		Event:     types.BalanceEventLow,
+		OwnerUUID: "org1",
+		OwnerType: types.AccountOwnerOrg,
+		Balance:   2_500_000,
+		UserUUIDs: []string{"uuid1", "unknown", "uuid2"},
+	})
+	require.Len(t, notifications.notifications, 2)
+	require.Equal(t, int64(1), notifications.notifications[0].UserID)
+	require.Equal(t, int64(2), notifications.notifications[1].UserID)
+	require.Equal(t, types.NotificationBalance, notifications.notifications[0].Kind)
+	require.Equal(t, "Low account balance", notifications.notifications[0].Title)
+	require.Contains(t, notifications.notifications[0].Content, "2.50")
+}
@@ -0,0 +1,818 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"opencsg.com/csghub-server/builder/deploy"
+	deployCommon "opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/event"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/llm"
+	"opencsg.com/csghub-server/builder/parquet"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+const (
+	batchInferencePollInterval = 10 * time.Second
+	// jobs claimed by a runner are not claimed again by others within the lease, it is renewed
+	// after every chunk of rows, so it must be longer than the time to process a chunk
+	batchInferenceLease = 15 * time.Minute
+	// jobs whose deploy is not running yet are checked again after the interval
+	batchInferenceRetryInterval = time.Minute
+	batchInferenceChunkRows     = 10
+	// rows of a lfs input file read at once, the chunks are taken from them
+	batchInferenceReadRows = 1000
+	batchInferenceTimeout  = time.Minute
+	// rows written to each output file
+	batchInferencePartRows = 5000
+)
+
+var (
+	errBatchInferenceDeployNotReady = errors.New("deploy of batch inference job is not running yet")
+	errBatchInferenceJobFinished    = errors.New("batch inference job is finished")
+)
+
+type BatchInferenceJobComponent interface {
+	// Create submits a job to call a deployed model with every row of a dataset file
+	Create(ctx context.Context, req types.CreateBatchInferenceJobReq) (*types.BatchInferenceJob, error)
+	Index(ctx context.Context, currentUser string, per, page int) ([]types.BatchInferenceJob, int, error)
+	Show(ctx context.Context, currentUser string, id int64) (*types.BatchInferenceJob, error)
+	// Cancel stops an unfinished job, the outputs of processed rows are not written
+	Cancel(ctx context.Context, currentUser string, id int64) error
+	// Run runs the unfinished jobs until ctx is done
+	Run(ctx context.Context)
+}
+
+func NewBatchInferenceJobComponent(config *config.Config) (BatchInferenceJobComponent, error) {
+	c := &batchInferenceJobComponentImpl{}
+	var err error
+	c.repoComponentImpl, err = NewRepoComponentImpl(config)
+	if err != nil {
+		return nil, err
+	}
+	c.dataset, err = NewDatasetComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	c.jobStore = database.NewBatchInferenceJobStore()
+	c.llm = llm.NewClient()
+	c.eventPub = &event.DefaultEventPublisher
+	c.preaders = parquet.NewLfsReaders()
+	return c, nil
+}
+
+type batchInferenceJobComponentImpl struct {
+	*repoComponentImpl
+	dataset  DatasetComponent
+	jobStore database.BatchInferenceJobStore
+	llm      *llm.Client
+	eventPub *event.EventPublisher
+	preaders *parquet.LfsReaders
+}
+
+func (c *batchInferenceJobComponentImpl) Create(ctx context.Context, req types.CreateBatchInferenceJobReq) (*types.BatchInferenceJob, error) {
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	d, err := c.deploy.GetDeployByID(ctx, req.DeployID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("deploy %d, %w", req.DeployID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get deploy %d, error: %w", req.DeployID, err)
+	}
+	if d.Type != types.InferenceType && d.Type != types.ServerlessType {
+		return nil, fmt.Errorf("%w: deploy %d is not an inference or serverless endpoint", ErrBadRequest, d.ID)
+	}
+	allow, err := c.AllowAccessEndpoint(ctx, user.Username, d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permission of deploy %d, error: %w", d.ID, err)
+	}
+	if !allow {
+		return nil, fmt.Errorf("no permission to call deploy %d, %w", d.ID, ErrForbidden)
+	}
+
+	datasetRepo, err := c.findReadableRepo(ctx, types.DatasetRepo, req.Dataset, user.Username)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := parquet.FormatOf(req.InputFile); !ok {
+		return nil, fmt.Errorf("%w: input file must be a parquet or jsonl file", ErrBadRequest)
+	}
+	if req.Revision == "" {
+		req.Revision = datasetRepo.DefaultBranch
+	}
+	datasetNamespace, datasetName, _ := strings.Cut(datasetRepo.Path, "/")
+	_, err = c.git.GetRepoFileContents(ctx, gitserver.GetRepoInfoByPathReq{
+		Namespace: datasetNamespace,
+		Name:      datasetName,
+		Ref:       req.Revision,
+		Path:      req.InputFile,
+		RepoType:  types.DatasetRepo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: input file %s is not found in %s of %s", ErrBadRequest, req.InputFile, req.Revision, datasetRepo.Path)
+	}
+
+	job := database.BatchInferenceJob{
+		UserID:        user.ID,
+		DeployID:      d.ID,
+		DatasetRepoID: datasetRepo.ID,
+		Revision:      req.Revision,
+		InputFile:     req.InputFile,
+		InputColumn:   req.InputColumn,
+		SystemPrompt:  req.SystemPrompt,
+		MaxTokens:     req.MaxTokens,
+		Temperature:   req.Temperature,
+		OutputType:    req.OutputType,
+		Status:        types.BatchInferenceJobPending,
+	}
+	switch req.OutputType {
+	case types.BatchInferenceOutputBranch:
+		permission, err := c.getUserRepoPermission(ctx, user.Username, datasetRepo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+		}
+		if !permission.CanWrite {
+			return nil, fmt.Errorf("no permission to write dataset %s, %w", datasetRepo.Path, ErrForbidden)
+		}
+		if req.OutputBranch != "" {
+			_, err = c.git.GetRepoLastCommit(ctx, gitserver.GetRepoLastCommitReq{
+				Namespace: datasetNamespace,
+				Name:      datasetName,
+				Ref:       req.OutputBranch,
+				RepoType:  types.DatasetRepo,
+			})
+			if err == nil {
+				return nil, fmt.Errorf("branch %s of dataset %s, %w", req.OutputBranch, datasetRepo.Path, ErrAlreadyExists)
+			}
+		}
+		job.OutputNamespace = datasetNamespace
+		job.OutputName = datasetName
+		job.OutputBranch = req.OutputBranch
+	case types.BatchInferenceOutputDataset:
+		if req.OutputName == "" {
+			return nil, fmt.Errorf("%w: output_name is required for dataset output", ErrBadRequest)
+		}
+		if req.OutputNamespace == "" {
+			req.OutputNamespace = user.Username
+		}
+		namespace, err := c.namespace.FindByPath(ctx, req.OutputNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("%w: namespace %s does not exist", ErrBadRequest, req.OutputNamespace)
+		}
+		err = c.checkCreateRepoPermission(ctx, user, &namespace, types.DatasetRepo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrForbidden, err)
+		}
+		_, err = c.repo.FindByPath(ctx, types.DatasetRepo, req.OutputNamespace, req.OutputName)
+		if err == nil {
+			return nil, fmt.Errorf("dataset %s/%s, %w", req.OutputNamespace, req.OutputName, ErrAlreadyExists)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to find dataset %s/%s, error: %w", req.OutputNamespace, req.OutputName, err)
+		}
+		job.OutputNamespace = req.OutputNamespace
+		job.OutputName = req.OutputName
+	}
+
+	created, err := c.jobStore.Create(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	if created.OutputType == types.BatchInferenceOutputBranch && created.OutputBranch == "" {
+		created.OutputBranch = fmt.Sprintf("batch-inference-%d", created.ID)
+		err = c.jobStore.Update(ctx, created, "output_branch")
+		if err != nil {
+			return nil, fmt.Errorf("failed to save output branch of batch inference job, error: %w", err)
+		}
+	}
+	created.User = &user
+	created.DatasetRepo = datasetRepo
+	res := toBatchInferenceJob(created)
+	return &res, nil
+}
+
+func (c *batchInferenceJobComponentImpl) Index(ctx context.Context, currentUser string, per, page int) ([]types.BatchInferenceJob, int, error) {
+	user, err := c.user.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return nil, 0, ErrUserNotFound
+	}
+	jobs, total, err := c.jobStore.ListByUserID(ctx, user.ID, per, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list batch inference jobs, error: %w", err)
+	}
+	var res []types.BatchInferenceJob
+	for i := range jobs {
+		res = append(res, toBatchInferenceJob(&jobs[i]))
+	}
+	return res, total, nil
+}
+
+func (c *batchInferenceJobComponentImpl) Show(ctx context.Context, currentUser string, id int64) (*types.BatchInferenceJob, error) {
+	job, err := c.getOwnBatchInferenceJob(ctx, currentUser, id)
+	if err != nil {
+		return nil, err
+	}
+	res := toBatchInferenceJob(job)
+	return &res, nil
+}
+
+func (c *batchInferenceJobComponentImpl) Cancel(ctx context.Context, currentUser string, id int64) error {
+	job, err := c.getOwnBatchInferenceJob(ctx, currentUser, id)
+	if err != nil {
+		return err
+	}
+	if job.Status.Finished() {
+		return fmt.Errorf("%w: batch inference job is %s already", ErrBadRequest, job.Status)
+	}
+	return c.finish(ctx, job, types.BatchInferenceJobCancelled, fmt.Sprintf("cancelled by %s", currentUser))
+}
+
+func (c *batchInferenceJobComponentImpl) Run(ctx context.Context) {
+	ticker := time.NewTicker(batchInferencePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.runUnfinished(ctx)
+		}
+	}
+}
+
+func (c *batchInferenceJobComponentImpl) runUnfinished(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := c.jobStore.ClaimUnfinished(ctx, batchInferenceLease)
+		if err != nil {
+			slog.Error("fail to claim unfinished batch inference job", slog.Any("error", err))
+			return
+		}
+		if job == nil {
+			return
+		}
+		err = c.runJob(ctx, job)
+		switch {
+		case err == nil, errors.Is(err, errBatchInferenceJobFinished), ctx.Err() != nil:
+		case errors.Is(err, errBatchInferenceDeployNotReady):
+			job.LeaseUntil = time.Now().Add(batchInferenceRetryInterval)
+			err = c.jobStore.Update(ctx, job, "lease_until")
+			if err != nil {
+				slog.Error("fail to postpone batch inference job", slog.Int64("job_id", job.ID), slog.Any("error", err))
+			}
+		default:
+			slog.Error("batch inference job failed", slog.Int64("job_id", job.ID), slog.Any("error", err))
+			err = c.finish(ctx, job, types.BatchInferenceJobFailed, err.Error())
+			if err != nil {
+				slog.Error("fail to fail batch inference job", slog.Int64("job_id", job.ID), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// runJob calls the model with the rows not processed yet, and writes the outputs to the output repo
+// once all rows are processed
+func (c *batchInferenceJobComponentImpl) runJob(ctx context.Context, job *database.BatchInferenceJob) error {
+	if job.Status == types.BatchInferenceJobPending {
+		job.Status = types.BatchInferenceJobRunning
+		job.StartedAt = time.Now()
+		err := c.jobStore.Update(ctx, job, "status", "started_at")
+		if err != nil {
+			return fmt.Errorf("failed to start batch inference job, error: %w", err)
+		}
+	}
+	if job.Status == types.BatchInferenceJobRunning {
+		err := c.infer(ctx, job)
+		if err != nil {
+			return err
+		}
+		job.Status = types.BatchInferenceJobWriting
+		err = c.jobStore.Update(ctx, job, "status")
+		if err != nil {
+			return fmt.Errorf("failed to update batch inference job, error: %w", err)
+		}
+	}
+	message, err := c.writeOutputs(ctx, job)
+	if err != nil {
+		return err
+	}
+	current, err := c.jobStore.FindByID(ctx, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get batch inference job, error: %w", err)
+	}
+	if current.Status.Finished() {
+		return errBatchInferenceJobFinished
+	}
+	return c.finish(ctx, job, types.BatchInferenceJobSucceeded, message)
+}
+
+// infer calls the model chunk by chunk, the results and progress are saved after every chunk,
+// so that the job is resumed from the first unprocessed row if the runner stops
+func (c *batchInferenceJobComponentImpl) infer(ctx context.Context, job *database.BatchInferenceJob) error {
+	d, err := c.deploy.GetDeployByID(ctx, job.DeployID)
+	if err != nil {
+		return fmt.Errorf("failed to get deploy %d, error: %w", job.DeployID, err)
+	}
+	switch d.Status {
+	case deployCommon.Running:
+	case deployCommon.BuildFailed, deployCommon.DeployFailed, deployCommon.RunTimeError, deployCommon.Stopped, deployCommon.Deleted:
+		return fmt.Errorf("deploy %d is not running", d.ID)
+	default:
+		return errBatchInferenceDeployNotReady
+	}
+	modelRepo, err := c.repo.FindById(ctx, d.RepoID)
+	if err != nil {
+		return fmt.Errorf("failed to find model of deploy %d, error: %w", d.ID, err)
+	}
+	var resourceName string
+	if resourceID, err := strconv.ParseInt(d.SKU, 10, 64); err == nil {
+		if resource, err := c.srs.FindByID(ctx, resourceID); err == nil {
+			resourceName = resource.Name
+		}
+	}
+
+	input, err := c.openInput(ctx, job)
+	if err != nil {
+		return err
+	}
+	if job.TotalRows == 0 {
+		job.TotalRows, err = input.count()
+		if err != nil {
+			return err
+		}
+		if job.TotalRows == 0 {
+			return fmt.Errorf("input file %s has no rows", job.InputFile)
+		}
+	}
+	for job.ProcessedRows < job.TotalRows {
+		current, err := c.jobStore.FindByID(ctx, job.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get batch inference job, error: %w", err)
+		}
+		if current.Status.Finished() {
+			return errBatchInferenceJobFinished
+		}
+		inputs, err := input.read(job.ProcessedRows, batchInferenceChunkRows)
+		if err != nil {
+			return err
+		}
+		if len(inputs) == 0 {
+			// the input file has fewer rows than counted
+			job.TotalRows = job.ProcessedRows
+			break
+		}
+
+		var results []database.BatchInferenceResult
+		var usage types.LLMUsage
+		for i, input := range inputs {
+			result := database.BatchInferenceResult{
+				JobID:    job.ID,
+				RowIndex: job.ProcessedRows + i,
+				Input:    input,
+			}
+			resp, err := c.complete(ctx, d, modelRepo.Path, job, input)
+			if err != nil {
+				result.Error = err.Error()
+				job.FailedRows++
+			} else {
+				result.Output = resp.Choices[0].Message.Content
+				usage.PromptTokens += resp.Usage.PromptTokens
+				usage.CompletionTokens += resp.Usage.CompletionTokens
+			}
+			results = append(results, result)
+		}
+		if ctx.Err() != nil {
+			// the rows failed because the runner is stopping, process them again next time
+			return ctx.Err()
+		}
+		job.ProcessedRows += len(inputs)
+		job.PromptTokens += usage.PromptTokens
+		job.CompletionTokens += usage.CompletionTokens
+		job.LeaseUntil = time.Now().Add(batchInferenceLease)
+		err = c.jobStore.SaveProgress(ctx, job, results)
+		if err != nil {
+			return fmt.Errorf("failed to save progress of batch inference job, error: %w", err)
+		}
+		c.publishTokenUsage(job, d, resourceName, usage)
+	}
+	return nil
+}
+
+func (c *batchInferenceJobComponentImpl) complete(ctx context.Context, d *database.Deploy, model string, job *database.BatchInferenceJob, input string) (*types.LLMResponse, error) {
+	var messages []types.LLMMessage
+	if job.SystemPrompt != "" {
+		messages = append(messages, types.LLMMessage{Role: "system", Content: job.SystemPrompt})
+	}
+	messages = append(messages, types.LLMMessage{Role: "user", Content: input})
+
+	ctx, cancel := context.WithTimeout(ctx, batchInferenceTimeout)
+	defer cancel()
+	resp, err := c.llm.ChatCompletion(ctx, c.chatCompletionsURL(d), nil, types.LLMReqBody{
+		Model:       model,
+		Messages:    messages,
+		Temperature: job.Temperature,
+		MaxTokens:   job.MaxTokens,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("no choice in model response")
+	}
+	return resp, nil
+}
+
+// chatCompletionsURL returns the openai compatible api of the deploy, which is called in cluster
+// in the same way as rproxy
+func (c *batchInferenceJobComponentImpl) chatCompletionsURL(d *database.Deploy) string {
+	target := fmt.Sprintf("http://%s.%s", d.SvcName, c.config.Space.InternalRootDomain)
+	if d.Endpoint != "" {
+		target = d.Endpoint
+	}
+	return strings.TrimSuffix(target, "/") + "/v1/chat/completions"
+}
+
+func (c *batchInferenceJobComponentImpl) publishTokenUsage(job *database.BatchInferenceJob, d *database.Deploy, resourceName string, usage types.LLMUsage) {
+	if job.User == nil {
+		return
+	}
+	publishTokenMetering(c.eventPub, types.METERING_EVENT{
+		UserUUID:     job.User.UUID,
+		Scene:        int(deploy.GetValidSceneType(d.Type)),
+		ResourceID:   d.SKU,
+		ResourceName: resourceName,
+		CustomerID:   d.SvcName,
+	}, usage, map[string]any{
+		"batch_inference_job_id": job.ID,
+	})
+}
+
+// batchInferenceInput reads the input column of the input file, the file is fetched once for a run of the job
+type batchInferenceInput struct {
+	job    *database.BatchInferenceJob
+	format parquet.FileFormat
+	// lines of a jsonl file stored in git
+	lines []string
+	// reader and object name of a file stored in lfs
+	reader  parquet.Reader
+	objName string
+	// inputs of the rows read from the lfs file last time, from row windowStart
+	window      []string
+	windowStart int
+}
+
+func (c *batchInferenceJobComponentImpl) openInput(ctx context.Context, job *database.BatchInferenceJob) (*batchInferenceInput, error) {
+	format, _ := parquet.FormatOf(job.InputFile)
+	namespace, name, _ := strings.Cut(job.DatasetRepo.Path, "/")
+	f, err := c.git.GetRepoFileContents(ctx, gitserver.GetRepoInfoByPathReq{
+		Namespace: namespace,
+		Name:      name,
+		Ref:       job.Revision,
+		Path:      job.InputFile,
+		RepoType:  types.DatasetRepo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get input file %s, error: %w", job.InputFile, err)
+	}
+	input := &batchInferenceInput{job: job, format: format}
+	if f.LfsRelativePath == "" {
+		if format != parquet.FormatJSONL {
+			return nil, fmt.Errorf("input file %s is not stored in lfs", job.InputFile)
+		}
+		content, err := base64.StdEncoding.DecodeString(f.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode input file %s, error: %w", job.InputFile, err)
+		}
+		input.lines = jsonlLines(string(content))
+		return input, nil
+	}
+
+	storage, err := c.lfsRouter.ForObject(ctx, job.DatasetRepo, oidFromLfsRelativePath(f.LfsRelativePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lfs storage of input file %s, error: %w", job.InputFile, err)
+	}
+	input.reader, err = c.preaders.Of(storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet reader, error: %w", err)
+	}
+	input.objName = "lfs/" + f.LfsRelativePath
+	return input, nil
+}
+
+func (in *batchInferenceInput) count() (int, error) {
+	if in.reader == nil {
+		return len(in.lines), nil
+	}
+	count, err := in.reader.CountRows(in.objName, in.format)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count rows of input file %s, error: %w", in.job.InputFile, err)
+	}
+	return count, nil
+}
+
+// read returns the values of input column of at most limit rows from offset
+func (in *batchInferenceInput) read(offset, limit int) ([]string, error) {
+	if in.reader == nil {
+		var inputs []string
+		for i := offset; i < len(in.lines) && i < offset+limit; i++ {
+			var row map[string]any
+			err := json.Unmarshal([]byte(in.lines[i]), &row)
+			if err != nil {
+				return nil, fmt.Errorf("invalid json in row %d of input file %s, error: %w", i, in.job.InputFile, err)
+			}
+			value, ok := row[in.job.InputColumn]
+			if !ok {
+				return nil, fmt.Errorf("column %s is not found in row %d of input file %s", in.job.InputColumn, i, in.job.InputFile)
+			}
+			inputs = append(inputs, cellString(value))
+		}
+		return inputs, nil
+	}
+
+	if offset < in.windowStart || offset+limit > in.windowStart+len(in.window) {
+		err := in.readWindow(offset, max(limit, batchInferenceReadRows))
+		if err != nil {
+			return nil, err
+		}
+	}
+	from := offset - in.windowStart
+	to := min(from+limit, len(in.window))
+	if from >= to {
+		return nil, nil
+	}
+	return in.window[from:to], nil
+}
+
+func (in *batchInferenceInput) readWindow(offset, limit int) error {
+	columns, rows, err := in.reader.ReadRows(in.objName, in.format, offset, limit)
+	if err != nil {
+		return fmt.Errorf("failed to read rows of input file %s, error: %w", in.job.InputFile, err)
+	}
+	index := slices.Index(columns, in.job.InputColumn)
+	if index < 0 {
+		return fmt.Errorf("column %s is not found in input file %s", in.job.InputColumn, in.job.InputFile)
+	}
+	in.window = make([]string, 0, len(rows))
+	for _, row := range rows {
+		in.window = append(in.window, cellString(row[index]))
+	}
+	in.windowStart = offset
+	return nil
+}
+
+// writeOutputs writes the results to jsonl files of the output repo, a file for every batchInferencePartRows rows.
+// The files written by a run stopped halfway are kept if they are not changed, so it's safe to write again.
+func (c *batchInferenceJobComponentImpl) writeOutputs(ctx context.Context, job *database.BatchInferenceJob) (string, error) {
+	startBranch := job.Revision
+	if job.OutputType == types.BatchInferenceOutputDataset {
+		err := c.createOutputDataset(ctx, job)
+		if err != nil {
+			return "", err
+		}
+		startBranch = job.OutputBranch
+	} else {
+		_, err := c.git.GetRepoLastCommit(ctx, gitserver.GetRepoLastCommitReq{
+			Namespace: job.OutputNamespace,
+			Name:      job.OutputName,
+			Ref:       job.OutputBranch,
+			RepoType:  types.DatasetRepo,
+		})
+		if err == nil {
+			// created by the run stopped halfway
+			startBranch = job.OutputBranch
+		}
+	}
+	for part, offset := 0, 0; offset < job.ProcessedRows; part++ {
+		results, err := c.jobStore.ListResults(ctx, job.ID, offset, batchInferencePartRows)
+		if err != nil {
+			return "", fmt.Errorf("failed to list results of batch inference job, error: %w", err)
+		}
+		if len(results) == 0 {
+			break
+		}
+		content, err := batchInferenceOutputContent(results)
+		if err != nil {
+			return "", err
+		}
+		err = c.writeOutputPart(ctx, job, startBranch, batchInferenceOutputPath(job.ID, part), content)
+		if err != nil {
+			return "", fmt.Errorf("failed to write outputs of batch inference job, error: %w", err)
+		}
+		startBranch = job.OutputBranch
+		offset = results[len(results)-1].RowIndex + 1
+	}
+	return fmt.Sprintf("outputs are written to branch %s of %s/%s", job.OutputBranch, job.OutputNamespace, job.OutputName), nil
+}
+
+// writeOutputPart creates the output file, or updates it if it's written before with other content
+func (c *batchInferenceJobComponentImpl) writeOutputPart(ctx context.Context, job *database.BatchInferenceJob, startBranch, filePath string, content []byte) error {
+	var existing *types.File
+	if startBranch == job.OutputBranch {
+		f, err := c.git.GetRepoFileContents(ctx, gitserver.GetRepoInfoByPathReq{
+			Namespace: job.OutputNamespace,
+			Name:      job.OutputName,
+			Ref:       job.OutputBranch,
+			Path:      filePath,
+			RepoType:  types.DatasetRepo,
+		})
+		if err == nil {
+			existing = f
+		}
+	}
+	encoded := base64.StdEncoding.EncodeToString(content)
+	message := fmt.Sprintf("Add outputs of batch inference job %d", job.ID)
+	if existing == nil {
+		_, err := c.CreateFile(ctx, &types.CreateFileReq{
+			Username:        job.User.Username,
+			CurrentUser:     job.User.Username,
+			Message:         message,
+			Branch:          startBranch,
+			NewBranch:       job.OutputBranch,
+			Content:         encoded,
+			OriginalContent: content,
+			Namespace:       job.OutputNamespace,
+			Name:            job.OutputName,
+			FilePath:        filePath,
+			RepoType:        types.DatasetRepo,
+		})
+		return err
+	}
+	if existing.Content == encoded {
+		return nil
+	}
+	_, err := c.UpdateFile(ctx, &types.UpdateFileReq{
+		Username:        job.User.Username,
+		CurrentUser:     job.User.Username,
+		Message:         message,
+		Branch:          job.OutputBranch,
+		Content:         encoded,
+		OriginalContent: content,
+		OriginPath:      filePath,
+		SHA:             existing.SHA,
+		Namespace:       job.OutputNamespace,
+		Name:            job.OutputName,
+		FilePath:        filePath,
+		RepoType:        types.DatasetRepo,
+	})
+	return err
+}
+
+// createOutputDataset creates the dataset to write outputs to, the dataset created before is
+// used if the job is resumed
+func (c *batchInferenceJobComponentImpl) createOutputDataset(ctx context.Context, job *database.BatchInferenceJob) error {
+	if job.OutputRepoID > 0 {
+		return nil
+	}
+	// the dataset may be created by the run stopped before saving it to the job
+	repo, err := c.repo.FindByPath(ctx, types.DatasetRepo, job.OutputNamespace, job.OutputName)
+	if err == nil {
+		job.OutputRepoID = repo.ID
+		job.OutputBranch = repo.DefaultBranch
+		return c.jobStore.Update(ctx, job, "output_repo_id", "output_branch")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to find output dataset of batch inference job, error: %w", err)
+	}
+	dataset, err := c.dataset.Create(ctx, &types.CreateDatasetReq{
+		CreateRepoReq: types.CreateRepoReq{
+			Username:    job.User.Username,
+			Namespace:   job.OutputNamespace,
+			Name:        job.OutputName,
+			Description: fmt.Sprintf("Outputs of batch inference job %d on %s", job.ID, job.DatasetRepo.Path),
+			Private:     true,
+			License:     job.DatasetRepo.License,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create output dataset of batch inference job, error: %w", err)
+	}
+	job.OutputRepoID = dataset.RepositoryID
+	job.OutputBranch = dataset.DefaultBranch
+	return c.jobStore.Update(ctx, job, "output_repo_id", "output_branch")
+}
+
+func (c *batchInferenceJobComponentImpl) finish(ctx context.Context, job *database.BatchInferenceJob, status types.BatchInferenceJobStatus, message string) error {
+	job.Status = status
+	job.Message = message
+	job.FinishedAt = time.Now()
+	return c.jobStore.Update(ctx, job, "status", "message", "finished_at")
+}
+
+func (c *batchInferenceJobComponentImpl) getOwnBatchInferenceJob(ctx context.Context, currentUser string, id int64) (*database.BatchInferenceJob, error) {
+	job, err := c.jobStore.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get batch inference job, error: %w", err)
+	}
+	if job.User == nil || job.User.Username != currentUser {
+		return nil, ErrForbidden
+	}
+	return job, nil
+}
+
+// jsonlLines returns the non-empty lines of jsonl content
+func jsonlLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// cellString converts a cell of a row to the text sent to the model, non-string cells are sent as json
+func cellString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func batchInferenceOutputPath(jobID int64, part int) string {
+	return fmt.Sprintf("batch_inference/job-%d/part-%05d.jsonl", jobID, part)
+}
+
+func batchInferenceOutputContent(results []database.BatchInferenceResult) ([]byte, error) {
+	var buf strings.Builder
+	for _, result := range results {
+		line, err := json.Marshal(types.BatchInferenceOutput{
+			Row:    result.RowIndex,
+			Input:  result.Input,
+			Output: result.Output,
+			Error:  result.Error,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal output of row %d, error: %w", result.RowIndex, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return []byte(buf.String()), nil
+}
+
+func toBatchInferenceJob(job *database.BatchInferenceJob) types.BatchInferenceJob {
+	res := types.BatchInferenceJob{
+		ID:               job.ID,
+		DeployID:         job.DeployID,
+		Revision:         job.Revision,
+		InputFile:        job.InputFile,
+		InputColumn:      job.InputColumn,
+		SystemPrompt:     job.SystemPrompt,
+		MaxTokens:        job.MaxTokens,
+		Temperature:      job.Temperature,
+		Status:           job.Status,
+		Message:          job.Message,
+		OutputType:       job.OutputType,
+		OutputBranch:     job.OutputBranch,
+		TotalRows:        job.TotalRows,
+		ProcessedRows:    job.ProcessedRows,
+		FailedRows:       job.FailedRows,
+		PromptTokens:     job.PromptTokens,
+		CompletionTokens: job.CompletionTokens,
+		CreatedAt:        job.CreatedAt,
+		UpdatedAt:        job.UpdatedAt,
+	}
+	if job.User != nil {
+		res.Username = job.User.Username
+	}
+	if job.DatasetRepo != nil {
+		res.Dataset = job.DatasetRepo.Path
+	}
+	if job.OutputType == types.BatchInferenceOutputBranch || job.OutputRepoID > 0 {
+		res.OutputRepo = fmt.Sprintf("%s/%s", job.OutputNamespace, job.OutputName)
+	}
+	if !job.StartedAt.IsZero() {
+		res.StartedAt = &job.StartedAt
+	}
+	if !job.FinishedAt.IsZero() {
+		res.FinishedAt = &job.FinishedAt
+	}
+	return res
+}
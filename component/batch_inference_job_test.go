@@ -0,0 +1,274 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/parquet"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestJsonlLines(t *testing.T) {
+	require.Equal(t, []string{`{"a":1}`, `{"a":2}`}, jsonlLines("{\"a\":1}\r\n\n  {\"a\":2}\n"))
+	require.Empty(t, jsonlLines("\n \n"))
+}
+
+func TestCellString(t *testing.T) {
+	require.Equal(t, "", cellString(nil))
+	require.Equal(t, "hello", cellString("hello"))
+	require.Equal(t, "raw", cellString([]byte("raw")))
+	require.Equal(t, "42", cellString(42))
+	require.Equal(t, `{"q":"hi"}`, cellString(map[string]any{"q": "hi"}))
+}
+
+func TestBatchInferenceOutputContent(t *testing.T) {
+	content, err := batchInferenceOutputContent([]database.BatchInferenceResult{
+		{RowIndex: 0, Input: "1+1", Output: "2"},
+		{RowIndex: 1, Input: "1/0", Error: "timeout"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "{\"row\":0,\"input\":\"1+1\",\"output\":\"2\"}\n{\"row\":1,\"input\":\"1/0\",\"output\":\"\",\"error\":\"timeout\"}\n", string(content))
+	require.Equal(t, "batch_inference/job-7/part-00002.jsonl", batchInferenceOutputPath(7, 2))
+}
+
+// fakeInputReader serves rows with the row index as input, and counts the reads
+type fakeInputReader struct {
+	parquet.Reader
+	rows  int
+	reads int
+}
+
+func (r *fakeInputReader) ReadRows(objName string, format parquet.FileFormat, offset, limit int) ([]string, [][]interface{}, error) {
+	r.reads++
+	var rows [][]interface{}
+	for i := offset; i < r.rows && i < offset+limit; i++ {
+		rows = append(rows, []interface{}{i, fmt.Sprintf("q%d", i)})
+	}
+	return []string{"id", "question"}, rows, nil
+}
+
+func TestBatchInferenceInput_Read(t *testing.T) {
+	job := &database.BatchInferenceJob{InputFile: "data.parquet", InputColumn: "question"}
+	reader := &fakeInputReader{rows: batchInferenceReadRows + 5}
+	input := &batchInferenceInput{job: job, format: parquet.FormatParquet, reader: reader, objName: "lfs/ab/cd/abcd"}
+
+	var all []string
+	for offset := 0; ; offset += batchInferenceChunkRows {
+		inputs, err := input.read(offset, batchInferenceChunkRows)
+		require.NoError(t, err)
+		if len(inputs) == 0 {
+			break
+		}
+		all = append(all, inputs...)
+	}
+	require.Len(t, all, batchInferenceReadRows+5)
+	require.Equal(t, "q1003", all[1003])
+	// a window for the first rows, one for the rest and one finding no more rows
+	require.Equal(t, 3, reader.reads)
+
+	// resumed from the middle
+	inputs, err := input.read(10, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"q10", "q11"}, inputs)
+
+	job.InputColumn = "answer"
+	input = &batchInferenceInput{job: job, format: parquet.FormatParquet, reader: reader}
+	_, err = input.read(0, 10)
+	require.Error(t, err)
+
+	// jsonl stored in git
+	job.InputColumn = "q"
+	input = &batchInferenceInput{job: job, format: parquet.FormatJSONL, lines: jsonlLines("{\"q\":\"a\"}\n{\"q\":\"b\"}\n{\"q\":3}\n")}
+	count, err := input.count()
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	inputs, err = input.read(1, 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "3"}, inputs)
+}
+
+type fakeBatchInferenceJobStore struct {
+	database.BatchInferenceJobStore
+	results []database.BatchInferenceResult
+}
+
+func (s *fakeBatchInferenceJobStore) ListResults(ctx context.Context, jobID int64, offset, limit int) ([]database.BatchInferenceResult, error) {
+	var results []database.BatchInferenceResult
+	for _, r := range s.results {
+		if r.RowIndex >= offset && len(results) < limit {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+func (s *fakeBatchInferenceJobStore) Update(ctx context.Context, job *database.BatchInferenceJob, columns ...string) error {
+	return nil
+}
+
+// outputGitServer keeps the files written to the branches of the output dataset
+type outputGitServer struct {
+	gitserver.GitServer
+	// base64 content of files by branch and path
+	files   map[string]map[string]string
+	created []*types.CreateFileReq
+	updated []*types.UpdateFileReq
+}
+
+func (g *outputGitServer) GetRepoLastCommit(ctx context.Context, req gitserver.GetRepoLastCommitReq) (*types.Commit, error) {
+	if _, ok := g.files[req.Ref]; !ok {
+		return nil, errors.New("branch not found")
+	}
+	return &types.Commit{}, nil
+}
+
+func (g *outputGitServer) GetRepoFileContents(ctx context.Context, req gitserver.GetRepoInfoByPathReq) (*types.File, error) {
+	content, ok := g.files[req.Ref][req.Path]
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+	return &types.File{Path: req.Path, Content: content, SHA: "sha-" + req.Path}, nil
+}
+
+func (g *outputGitServer) CreateRepoFile(req *types.CreateFileReq) error {
+	g.created = append(g.created, req)
+	if g.files[req.NewBranch] == nil {
+		g.files[req.NewBranch] = make(map[string]string)
+	}
+	g.files[req.NewBranch][req.FilePath] = req.Content
+	return nil
+}
+
+func (g *outputGitServer) UpdateRepoFile(req *types.UpdateFileReq) error {
+	g.updated = append(g.updated, req)
+	g.files[req.Branch][req.FilePath] = req.Content
+	return nil
+}
+
+type outputRepoStore struct {
+	database.RepoStore
+	repo *database.Repository
+}
+
+func (s *outputRepoStore) FindByPath(ctx context.Context, repoType types.RepositoryType, namespace, name string) (*database.Repository, error) {
+	if s.repo == nil || s.repo.Path != namespace+"/"+name {
+		return nil, sql.ErrNoRows
+	}
+	return s.repo, nil
+}
+
+func (s *outputRepoStore) SetUpdateTimeByPath(ctx context.Context, repoType types.RepositoryType, namespace, name string, update time.Time) error {
+	return nil
+}
+
+type outputUserStore struct {
+	database.UserStore
+}
+
+func (s *outputUserStore) FindByUsername(ctx context.Context, username string) (database.User, error) {
+	return database.User{Username: username}, nil
+}
+
+type outputNamespaceStore struct {
+	database.NamespaceStore
+}
+
+func (s *outputNamespaceStore) FindByPath(ctx context.Context, path string) (database.Namespace, error) {
+	return database.Namespace{Path: path, NamespaceType: database.UserNamespace}, nil
+}
+
+type outputTagComponent struct {
+	TagComponent
+}
+
+func (c *outputTagComponent) UpdateLibraryTags(ctx context.Context, tagScope database.TagScope, namespace, name, oldFilePath, newFilePath string) error {
+	return nil
+}
+
+func TestBatchInferenceJobComponent_WriteOutputs(t *testing.T) {
+	ctx := context.Background()
+	git := &outputGitServer{files: map[string]map[string]string{"main": {}}}
+	repoStore := &outputRepoStore{}
+	jobStore := &fakeBatchInferenceJobStore{results: []database.BatchInferenceResult{
+		{RowIndex: 0, Input: "1+1", Output: "2"},
+		{RowIndex: 1, Input: "2+2", Output: "4"},
+	}}
+	c := &batchInferenceJobComponentImpl{
+		repoComponentImpl: &repoComponentImpl{
+			git:              git,
+			repo:             repoStore,
+			user:             &outputUserStore{},
+			namespace:        &outputNamespaceStore{},
+			branchProtection: &fakeBranchProtectionStore{},
+			tc:               &outputTagComponent{},
+			config:           &config.Config{},
+		},
+		jobStore: jobStore,
+	}
+	repoStore.repo = &database.Repository{ID: 1, Path: "user1/data", DefaultBranch: "main"}
+	job := &database.BatchInferenceJob{
+		ID:              7,
+		User:            &database.User{Username: "user1"},
+		Revision:        "main",
+		OutputType:      types.BatchInferenceOutputBranch,
+		OutputNamespace: "user1",
+		OutputName:      "data",
+		OutputBranch:    "batch-inference-7",
+		ProcessedRows:   2,
+	}
+
+	_, err := c.writeOutputs(ctx, job)
+	require.NoError(t, err)
+	require.Len(t, git.created, 1)
+	require.Equal(t, "main", git.created[0].Branch)
+	require.Equal(t, "batch-inference-7", git.created[0].NewBranch)
+	path := batchInferenceOutputPath(7, 0)
+
+	// written again after the runner stops before finishing the job
+	_, err = c.writeOutputs(ctx, job)
+	require.NoError(t, err)
+	require.Len(t, git.created, 1)
+	require.Empty(t, git.updated)
+
+	// the outputs changed, e.g. the results of the run stopped halfway were not all saved
+	jobStore.results = append(jobStore.results, database.BatchInferenceResult{RowIndex: 2, Input: "3+3", Output: "6"})
+	job.ProcessedRows = 3
+	_, err = c.writeOutputs(ctx, job)
+	require.NoError(t, err)
+	require.Len(t, git.created, 1)
+	require.Len(t, git.updated, 1)
+	require.Equal(t, "sha-"+path, git.updated[0].SHA)
+	content, err := base64.StdEncoding.DecodeString(git.files["batch-inference-7"][path])
+	require.NoError(t, err)
+	expected, err := batchInferenceOutputContent(jobStore.results)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(content))
+}
+
+func TestBatchInferenceJobComponent_CreateOutputDatasetExisting(t *testing.T) {
+	c := &batchInferenceJobComponentImpl{
+		repoComponentImpl: &repoComponentImpl{repo: &outputRepoStore{
+			repo: &database.Repository{ID: 3, Path: "user1/outputs", DefaultBranch: "main"},
+		}},
+		jobStore: &fakeBatchInferenceJobStore{},
+	}
+	job := &database.BatchInferenceJob{
+		ID:              7,
+		OutputType:      types.BatchInferenceOutputDataset,
+		OutputNamespace: "user1",
+		OutputName:      "outputs",
+	}
+	// the dataset was created by the run stopped before saving it to the job
+	require.NoError(t, c.createOutputDataset(context.Background(), job))
+	require.Equal(t, int64(3), job.OutputRepoID)
+	require.Equal(t, "main", job.OutputBranch)
+}
@@ -0,0 +1,358 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type branchProtectionComponentImpl struct {
+	*repoComponentImpl
+}
+
+type BranchProtectionComponent interface {
+	Index(ctx context.Context, req types.BranchProtectionReq) ([]types.BranchProtection, error)
+	Create(ctx context.Context, req types.BranchProtectionReq) (*types.BranchProtection, error)
+	Update(ctx context.Context, req types.BranchProtectionReq) (*types.BranchProtection, error)
+	Delete(ctx context.Context, req types.BranchProtectionReq) error
+}
+
+func NewBranchProtectionComponent(config *config.Config) (BranchProtectionComponent, error) {
+	rc, err := NewRepoComponentImpl(config)
+	if err != nil {
+		return nil, err
+	}
+	return &branchProtectionComponentImpl{
+		repoComponentImpl: rc,
+	}, nil
+}
+
+func (c *branchProtectionComponentImpl) Index(ctx context.Context, req types.BranchProtectionReq) ([]types.BranchProtection, error) {
+	repo, err := c.findRepoForAdmin(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	protections, err := c.branchProtection.ListByRepoID(ctx, repo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branch protections, error: %w", err)
+	}
+	resp := make([]types.BranchProtection, 0, len(protections))
+	for _, p := range protections {
+		resp = append(resp, toBranchProtection(&p))
+	}
+	return resp, nil
+}
+
+func (c *branchProtectionComponentImpl) Create(ctx context.Context, req types.BranchProtectionReq) (*types.BranchProtection, error) {
+	repo, err := c.findRepoForAdmin(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := path.Match(req.Pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid branch pattern '%s', error: %w", req.Pattern, err)
+	}
+	protections, err := c.branchProtection.ListByRepoID(ctx, repo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branch protections, error: %w", err)
+	}
+	for _, p := range protections {
+		if p.Pattern == req.Pattern {
+			return nil, ErrAlreadyExists
+		}
+	}
+	if err := c.checkForcePushSupported(req); err != nil {
+		return nil, err
+	}
+	protection, err := c.branchProtection.Create(ctx, database.BranchProtection{
+		RepositoryID:    repo.ID,
+		Pattern:         req.Pattern,
+		BlockForcePush:  req.BlockForcePush,
+		BlockDeletion:   req.BlockDeletion,
+		RequireAdmin:    req.RequireAdmin,
+		BlockDirectPush: req.BlockDirectPush,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := toBranchProtection(protection)
+	return &resp, nil
+}
+
+func (c *branchProtectionComponentImpl) Update(ctx context.Context, req types.BranchProtectionReq) (*types.BranchProtection, error) {
+	repo, err := c.findRepoForAdmin(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	protection, err := c.findBranchProtection(ctx, repo.ID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := path.Match(req.Pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid branch pattern '%s', error: %w", req.Pattern, err)
+	}
+	if err := c.checkForcePushSupported(req); err != nil {
+		return nil, err
+	}
+	protection.Pattern = req.Pattern
+	protection.BlockForcePush = req.BlockForcePush
+	protection.BlockDeletion = req.BlockDeletion
+	protection.RequireAdmin = req.RequireAdmin
+	protection.BlockDirectPush = req.BlockDirectPush
+	protection, err = c.branchProtection.Update(ctx, *protection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update branch protection, error: %w", err)
+	}
+	resp := toBranchProtection(protection)
+	return &resp, nil
+}
+
+func (c *branchProtectionComponentImpl) Delete(ctx context.Context, req types.BranchProtectionReq) error {
+	repo, err := c.findRepoForAdmin(ctx, req)
+	if err != nil {
+		return err
+	}
+	protection, err := c.findBranchProtection(ctx, repo.ID, req.ID)
+	if err != nil {
+		return err
+	}
+	err = c.branchProtection.Delete(ctx, *protection)
+	if err != nil {
+		return fmt.Errorf("failed to delete branch protection, error: %w", err)
+	}
+	return nil
+}
+
+// checkForcePushSupported rejects the rules blocking force pushes on gitea, the pushed commits are not on gitea
+// when the push is checked, so it can not tell a fast-forward push from a force push
+func (c *branchProtectionComponentImpl) checkForcePushSupported(req types.BranchProtectionReq) error {
+	if req.BlockForcePush && c.config.GitServer.Type == types.GitServerTypeGitea {
+		return fmt.Errorf("%w: blocking force pushes is not supported by git server %s", ErrBadRequest, c.config.GitServer.Type)
+	}
+	return nil
+}
+
+func (c *branchProtectionComponentImpl) findRepoForAdmin(ctx context.Context, req types.BranchProtectionReq) (*database.Repository, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanAdmin {
+		return nil, ErrForbidden
+	}
+	return repo, nil
+}
+
+func (c *branchProtectionComponentImpl) findBranchProtection(ctx context.Context, repoID, id int64) (*database.BranchProtection, error) {
+	protection, err := c.branchProtection.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find branch protection, error: %w", err)
+	}
+	if protection.RepositoryID != repoID {
+		return nil, ErrNotFound
+	}
+	return protection, nil
+}
+
+func toBranchProtection(p *database.BranchProtection) types.BranchProtection {
+	return types.BranchProtection{
+		ID:              p.ID,
+		Pattern:         p.Pattern,
+		BlockForcePush:  p.BlockForcePush,
+		BlockDeletion:   p.BlockDeletion,
+		RequireAdmin:    p.RequireAdmin,
+		BlockDirectPush: p.BlockDirectPush,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+	}
+}
+
+func matchBranchPattern(pattern, branch string) bool {
+	if pattern == branch {
+		return true
+	}
+	matched, _ := path.Match(pattern, branch)
+	return matched
+}
+
+// checkBranchProtection rejects the ref changes of a push which violate the branch protection rules of the repository.
+// objectEnv is the quarantine directories of the pushed objects, it is nil before the objects are received,
+// in that case force pushes of new commits can not be detected and are left to the pre-receive hook of gitaly.
+// Rules blocking force pushes can not be created on gitea, which has no such hook
+func (c *repoComponentImpl) checkBranchProtection(ctx context.Context, repo *database.Repository, username string, changes []types.RefChange, objectEnv *types.GitObjectDirectoryEnv) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	protections, err := c.branchProtection.ListByRepoID(ctx, repo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list branch protections, error: %w", err)
+	}
+	if len(protections) == 0 {
+		return nil
+	}
+	var permission *types.UserRepoPermission
+	namespace, name := repo.NamespaceAndName()
+	for _, change := range changes {
+		branch, ok := change.Branch()
+		if !ok {
+			continue
+		}
+		for _, p := range protections {
+			if !matchBranchPattern(p.Pattern, branch) {
+				continue
+			}
+			// creating and deleting branches are not direct pushes, deletions are guarded by BlockDeletion
+			directPush := !change.IsCreate() && !change.IsDelete()
+			permission, err = c.checkBranchRules(ctx, repo, username, permission, &p, branch, directPush, change.IsDelete())
+			if err != nil {
+				return err
+			}
+			if p.BlockForcePush && !change.IsCreate() && !change.IsDelete() {
+				req := gitserver.IsAncestorReq{
+					Namespace:  namespace,
+					Name:       name,
+					RepoType:   repo.RepositoryType,
+					Ancestor:   change.OldRev,
+					Descendant: change.NewRev,
+				}
+				if objectEnv != nil {
+					req.ObjectDirectory = objectEnv.ObjectDirectory
+					req.AlternateObjectDirectories = objectEnv.AlternateObjectDirectories
+				}
+				fastForward, err := c.git.IsAncestor(ctx, req)
+				if err != nil {
+					// the new commits are not received yet, which is always the case of fast-forward pushes
+					if objectEnv == nil {
+						slog.Warn("skip force push check before receiving objects", slog.String("repo", repo.Path),
+							slog.String("branch", branch), slog.Any("error", err))
+						continue
+					}
+					return fmt.Errorf("failed to check force push, error: %w", err)
+				}
+				if !fastForward {
+					return fmt.Errorf("%w: force push to branch '%s' is not allowed", ErrBranchProtected, branch)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkBranchRules checks the rules of protection other than force pushes, the permission of user is loaded on
+// demand and returned to be reused. Direct pushes are updates of existing branches not made by merging pull
+// requests, repo admins can bypass the rule blocking them
+func (c *repoComponentImpl) checkBranchRules(ctx context.Context, repo *database.Repository, username string, permission *types.UserRepoPermission,
+	p *database.BranchProtection, branch string, directPush, isDelete bool) (*types.UserRepoPermission, error) {
+	if (p.BlockDirectPush && directPush) || p.RequireAdmin {
+		if permission == nil {
+			var err error
+			permission, err = c.getUserRepoPermission(ctx, username, repo)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+			}
+		}
+		if p.BlockDirectPush && directPush && !permission.CanAdmin {
+			return permission, fmt.Errorf("%w: direct push to branch '%s' is not allowed, please create a pull request", ErrBranchProtected, branch)
+		}
+		if p.RequireAdmin && !permission.CanAdmin {
+			return permission, fmt.Errorf("%w: only admins can push to branch '%s'", ErrBranchProtected, branch)
+		}
+	}
+	if p.BlockDeletion && isDelete {
+		return permission, fmt.Errorf("%w: branch '%s' can not be deleted", ErrBranchProtected, branch)
+	}
+	return permission, nil
+}
+
+// checkBranchWrite rejects the changes made in web to the branch which violate the branch protection rules of
+// the repository, like editing files and merging pull requests. Such changes are committed by csghub itself, so
+// they are not checked by the pre-receive hook. The commits of them are never force pushes, and merging pull
+// requests is not a direct push
+func (c *repoComponentImpl) checkBranchWrite(ctx context.Context, repo *database.Repository, username, branch string, fromPullRequest bool) error {
+	if branch == "" {
+		branch = repo.DefaultBranch
+	}
+	protections, err := c.branchProtection.ListByRepoID(ctx, repo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list branch protections, error: %w", err)
+	}
+	var permission *types.UserRepoPermission
+	for _, p := range protections {
+		if !matchBranchPattern(p.Pattern, branch) {
+			continue
+		}
+		permission, err = c.checkBranchRules(ctx, repo, username, permission, &p, branch, !fromPullRequest, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileTargetBranch returns the branch which the file changes in web are committed to, they are committed to the
+// new branch created from the branch if it's not empty
+func fileTargetBranch(branch, newBranch string) string {
+	if newBranch != "" {
+		return newBranch
+	}
+	return branch
+}
+
+// readReceivePackCommands reads the ref update commands at the beginning of a receive-pack request,
+// and returns the bytes consumed so that they can be sent to git server again
+func readReceivePackCommands(body io.Reader) ([]types.RefChange, []byte, error) {
+	var (
+		consumed bytes.Buffer
+		changes  []types.RefChange
+	)
+	// pkt-lines are length prefixed, so the reader never reads beyond the flush-pkt
+	reader := io.TeeReader(body, &consumed)
+	for {
+		header := make([]byte, 4)
+		_, err := io.ReadFull(reader, header)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, fmt.Errorf("failed to read pkt-line length, error: %w", err)
+		}
+		length, err := strconv.ParseUint(string(header), 16, 16)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pkt-line length '%s'", header)
+		}
+		// flush-pkt ends the command list
+		if length == 0 {
+			break
+		}
+		if length < 4 {
+			return nil, nil, fmt.Errorf("invalid pkt-line length '%s'", header)
+		}
+		payload := make([]byte, length-4)
+		_, err = io.ReadFull(reader, payload)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read pkt-line, error: %w", err)
+		}
+		// capabilities follow the first command after a NUL
+		line, _, _ := strings.Cut(string(payload), "\x00")
+		changes = append(changes, types.ParseRefChanges(line)...)
+	}
+	return changes, consumed.Bytes(), nil
+}
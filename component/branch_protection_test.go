@@ -0,0 +1,159 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func pktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+func TestReadReceivePackCommands(t *testing.T) {
+	oldRev := strings.Repeat("a", 40)
+	newRev := strings.Repeat("b", 40)
+	zeroRev := strings.Repeat("0", 40)
+	commands := pktLine(oldRev+" "+newRev+" refs/heads/main\x00report-status side-band-64k\n") +
+		pktLine(oldRev+" "+zeroRev+" refs/heads/dev\n") +
+		"0000"
+	pack := "PACK-data"
+	body := bytes.NewBufferString(commands + pack)
+
+	changes, consumed, err := readReceivePackCommands(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 ref changes, got %d", len(changes))
+	}
+	branch, ok := changes[0].Branch()
+	if !ok || branch != "main" || changes[0].OldRev != oldRev || changes[0].NewRev != newRev {
+		t.Errorf("unexpected first ref change: %+v", changes[0])
+	}
+	if changes[0].IsCreate() || changes[0].IsDelete() {
+		t.Errorf("first ref change should be an update: %+v", changes[0])
+	}
+	if !changes[1].IsDelete() {
+		t.Errorf("second ref change should be a deletion: %+v", changes[1])
+	}
+	if string(consumed) != commands {
+		t.Errorf("consumed bytes should be the commands, got %q", consumed)
+	}
+	rest, _ := io.ReadAll(body)
+	if string(rest) != pack {
+		t.Errorf("pack data should be left in body, got %q", rest)
+	}
+}
+
+func TestMatchBranchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, branch string
+		matched         bool
+	}{
+		{"main", "main", true},
+		{"main", "main2", false},
+		{"release/*", "release/v1", true},
+		{"release/*", "release/v1/fix", false},
+		{"*", "dev", true},
+	}
+	for _, c := range cases {
+		if matchBranchPattern(c.pattern, c.branch) != c.matched {
+			t.Errorf("expected match of pattern '%s' and branch '%s' to be %v", c.pattern, c.branch, c.matched)
+		}
+	}
+}
+
+type fakeBranchProtectionStore struct {
+	database.BranchProtectionStore
+	protections []database.BranchProtection
+}
+
+func (s *fakeBranchProtectionStore) ListByRepoID(ctx context.Context, repoID int64) ([]database.BranchProtection, error) {
+	return s.protections, nil
+}
+
+type fakeAncestorGitServer struct {
+	gitserver.GitServer
+	err error
+}
+
+func (g *fakeAncestorGitServer) IsAncestor(ctx context.Context, req gitserver.IsAncestorReq) (bool, error) {
+	return false, g.err
+}
+
+func TestCheckBranchWrite(t *testing.T) {
+	c := &repoComponentImpl{
+		branchProtection: &fakeBranchProtectionStore{protections: []database.BranchProtection{
+			{Pattern: "main", BlockDirectPush: true},
+			{Pattern: "release/*", BlockDeletion: true},
+		}},
+		namespace: &fakeQuotaNamespaceStore{},
+	}
+	repo := &database.Repository{ID: 1, Path: "owner/model", DefaultBranch: "main"}
+	cases := []struct {
+		username        string
+		branch          string
+		fromPullRequest bool
+		protected       bool
+	}{
+		{"user", "main", false, true},
+		{"user", "", false, true},
+		{"user", "main", true, false},
+		{"user", "dev", false, false},
+		{"user", "release/v1", false, false},
+		// admins can bypass the rule blocking direct pushes
+		{"owner", "main", false, false},
+	}
+	for _, tc := range cases {
+		err := c.checkBranchWrite(context.Background(), repo, tc.username, tc.branch, tc.fromPullRequest)
+		if errors.Is(err, ErrBranchProtected) != tc.protected {
+			t.Errorf("expected change of %s to branch '%s' from pull request %v to be protected %v, got error: %v",
+				tc.username, tc.branch, tc.fromPullRequest, tc.protected, err)
+		}
+	}
+
+	if fileTargetBranch("main", "") != "main" || fileTargetBranch("main", "patch-1") != "patch-1" {
+		t.Error("expected file changes to be committed to the new branch if it's set")
+	}
+}
+
+func TestCheckBranchProtection(t *testing.T) {
+	c := &repoComponentImpl{
+		branchProtection: &fakeBranchProtectionStore{protections: []database.BranchProtection{
+			{Pattern: "main", BlockDirectPush: true, BlockForcePush: true},
+		}},
+		namespace: &fakeQuotaNamespaceStore{},
+		// the new commits are not on the git server before they are received
+		git: &fakeAncestorGitServer{err: errors.New("commit not found")},
+	}
+	repo := &database.Repository{ID: 1, Path: "owner/model", RepositoryType: types.ModelRepo}
+	zeroRev := strings.Repeat("0", 40)
+	oldRev := strings.Repeat("a", 40)
+	newRev := strings.Repeat("b", 40)
+	cases := []struct {
+		username  string
+		change    types.RefChange
+		protected bool
+	}{
+		{"user", types.RefChange{OldRev: oldRev, NewRev: newRev, Ref: "refs/heads/main"}, true},
+		{"user", types.RefChange{OldRev: zeroRev, NewRev: newRev, Ref: "refs/heads/main"}, false},
+		{"user", types.RefChange{OldRev: oldRev, NewRev: newRev, Ref: "refs/heads/dev"}, false},
+		{"owner", types.RefChange{OldRev: oldRev, NewRev: newRev, Ref: "refs/heads/main"}, false},
+	}
+	for _, tc := range cases {
+		err := c.checkBranchProtection(context.Background(), repo, tc.username, []types.RefChange{tc.change}, nil)
+		if errors.Is(err, ErrBranchProtected) != tc.protected || (!tc.protected && err != nil) {
+			t.Errorf("expected push of %s to %s from %s to %s to be protected %v, got error: %v",
+				tc.username, tc.change.Ref, tc.change.OldRev, tc.change.NewRev, tc.protected, err)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"opencsg.com/csghub-server/builder/git/gitserver"
 	"opencsg.com/csghub-server/builder/rpc"
 	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/webhook"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
 	"opencsg.com/csghub-server/component"
@@ -40,6 +41,7 @@ type GitCallbackComponent struct {
 	setRepoVisibility bool
 	pp                component.PromptComponent
 	maxPromptFS       int64
+	webhookNotifier   webhook.Notifier
 }
 
 // new CallbackComponent
@@ -97,6 +99,8 @@ func NewGitCallback(config *config.Config) (*GitCallbackComponent, error) {
 		pp:           pp,
 		ts:           ts,
 		maxPromptFS:  config.Dataset.PromptMaxJsonlFileSize,
+
+		webhookNotifier: webhook.NewNotifier(),
 	}, nil
 }
 
@@ -205,6 +209,26 @@ func (c *GitCallbackComponent) SensitiveCheck(ctx context.Context, req *types.Gi
 	return nil
 }
 
+// NotifyWebhooks queues push events for the webhooks of the repository and its organization
+func (c *GitCallbackComponent) NotifyWebhooks(ctx context.Context, req *types.GiteaCallbackPushReq) error {
+	// split req.Repository.FullName by '/'
+	splits := strings.Split(req.Repository.FullName, "/")
+	fullNamespace, repoName := splits[0], splits[1]
+	repoType, namespace, _ := strings.Cut(fullNamespace, "_")
+	adjustedRepoType := types.RepositoryType(strings.TrimRight(repoType, "s"))
+
+	repo, err := c.rs.FindByPath(ctx, adjustedRepoType, namespace, repoName)
+	if err != nil {
+		slog.Error("failed to find repo for webhooks", slog.Any("error", err), slog.String("repo_type", string(adjustedRepoType)), slog.String("namespace", namespace), slog.String("name", repoName))
+		return err
+	}
+	return c.webhookNotifier.Notify(ctx, repo, types.WebhookEventPush, types.WebhookPushData{
+		Ref:     req.Ref,
+		Commits: req.Commits,
+		Message: req.HeadCommit.Message,
+	})
+}
+
 // modifyFiles method handles modified files, skip if not modify README.md
 func (c *GitCallbackComponent) modifyFiles(ctx context.Context, repoType, namespace, repoName, ref string, fileNames []string) error {
 	for _, fileName := range fileNames {
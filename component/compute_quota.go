@@ -0,0 +1,435 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"time"
+
+	"opencsg.com/csghub-server/builder/deploy"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// QuotaExceededError is returned when a deploy exceeds the compute quota of the user or organization
+type QuotaExceededError struct {
+	types.ComputeQuotaExceeded
+}
+
+func (e *QuotaExceededError) Error() string {
+	if e.Kind == types.ComputeQuotaMonthlyMinutes {
+		return fmt.Sprintf("%s: %s has used %d of %d minutes of scene %d this month",
+			ErrQuotaExceeded, e.Namespace, e.Used, e.Limit, e.Scene)
+	}
+	return fmt.Sprintf("%s: %s of %s is %d, %d in use and %d requested",
+		ErrQuotaExceeded, e.Kind, e.Namespace, e.Limit, e.Used, e.Requested)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Code returns the error code of the exceeded limit
+func (e *QuotaExceededError) Code() int {
+	switch e.Kind {
+	case types.ComputeQuotaGPUs:
+		return types.ErrCodeGPUQuotaExceeded
+	case types.ComputeQuotaRunningInstances:
+		return types.ErrCodeRunningInstanceQuotaExceeded
+	default:
+		return types.ErrCodeMonthlyMinuteQuotaExceeded
+	}
+}
+
+type ComputeQuotaComponent interface {
+	Index(ctx context.Context, currentUser string, per, page int) ([]types.ComputeQuota, int, error)
+	// Show returns the quota with the resources used by the namespace at present
+	Show(ctx context.Context, currentUser string, id int64) (*types.ComputeQuota, error)
+	Create(ctx context.Context, req types.CreateComputeQuotaReq) (*types.ComputeQuota, error)
+	Update(ctx context.Context, req types.UpdateComputeQuotaReq) (*types.ComputeQuota, error)
+	Delete(ctx context.Context, currentUser string, id int64) error
+}
+
+func NewComputeQuotaComponent(config *config.Config) (ComputeQuotaComponent, error) {
+	c := &computeQuotaComponentImpl{}
+	c.userStore = database.NewUserStore()
+	c.namespaceStore = database.NewNamespaceStore()
+	c.quotaStore = database.NewComputeQuotaStore()
+	c.checker = newComputeQuotaChecker()
+	return c, nil
+}
+
+type computeQuotaComponentImpl struct {
+	userStore      database.UserStore
+	namespaceStore database.NamespaceStore
+	quotaStore     database.ComputeQuotaStore
+	checker        *computeQuotaChecker
+}
+
+func (c *computeQuotaComponentImpl) Index(ctx context.Context, currentUser string, per, page int) ([]types.ComputeQuota, int, error) {
+	err := c.checkAdmin(ctx, currentUser)
+	if err != nil {
+		return nil, 0, err
+	}
+	quotas, total, err := c.quotaStore.Index(ctx, per, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list compute quotas, error: %w", err)
+	}
+	var res []types.ComputeQuota
+	for _, quota := range quotas {
+		res = append(res, toComputeQuota(quota))
+	}
+	return res, total, nil
+}
+
+func (c *computeQuotaComponentImpl) Show(ctx context.Context, currentUser string, id int64) (*types.ComputeQuota, error) {
+	err := c.checkAdmin(ctx, currentUser)
+	if err != nil {
+		return nil, err
+	}
+	quota, err := c.getQuota(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := c.namespaceStore.FindByPath(ctx, quota.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find namespace %s, error: %w", quota.Namespace, err)
+	}
+	usage, err := c.checker.usage(ctx, namespace, nil)
+	if err != nil {
+		return nil, err
+	}
+	res := toComputeQuota(*quota)
+	res.Usage = usage
+	return &res, nil
+}
+
+func (c *computeQuotaComponentImpl) Create(ctx context.Context, req types.CreateComputeQuotaReq) (*types.ComputeQuota, error) {
+	err := c.checkAdmin(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := c.namespaceStore.Exists(ctx, req.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check namespace %s, error: %w", req.Namespace, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("namespace %s does not exist, %w", req.Namespace, ErrNotFound)
+	}
+	_, err = c.quotaStore.FindByNamespace(ctx, req.Namespace)
+	if err == nil {
+		return nil, fmt.Errorf("compute quota of %s, %w", req.Namespace, ErrAlreadyExists)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to find compute quota of %s, error: %w", req.Namespace, err)
+	}
+	quota, err := c.quotaStore.Create(ctx, database.ComputeQuota{
+		Namespace:           req.Namespace,
+		MaxGPUs:             req.MaxGPUs,
+		MaxRunningInstances: req.MaxRunningInstances,
+		MonthlyMinutes:      req.MonthlyMinutes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := toComputeQuota(*quota)
+	return &res, nil
+}
+
+func (c *computeQuotaComponentImpl) Update(ctx context.Context, req types.UpdateComputeQuotaReq) (*types.ComputeQuota, error) {
+	err := c.checkAdmin(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	quota, err := c.getQuota(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	quota.MaxGPUs = req.MaxGPUs
+	quota.MaxRunningInstances = req.MaxRunningInstances
+	quota.MonthlyMinutes = req.MonthlyMinutes
+	quota, err = c.quotaStore.Update(ctx, *quota)
+	if err != nil {
+		return nil, err
+	}
+	res := toComputeQuota(*quota)
+	return &res, nil
+}
+
+func (c *computeQuotaComponentImpl) Delete(ctx context.Context, currentUser string, id int64) error {
+	err := c.checkAdmin(ctx, currentUser)
+	if err != nil {
+		return err
+	}
+	quota, err := c.getQuota(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = c.quotaStore.Delete(ctx, *quota)
+	if err != nil {
+		return fmt.Errorf("failed to delete compute quota, error: %w", err)
+	}
+	return nil
+}
+
+func (c *computeQuotaComponentImpl) getQuota(ctx context.Context, id int64) (*database.ComputeQuota, error) {
+	quota, err := c.quotaStore.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get compute quota, error: %w", err)
+	}
+	return quota, nil
+}
+
+func (c *computeQuotaComponentImpl) checkAdmin(ctx context.Context, currentUser string) error {
+	user, err := c.userStore.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.CanAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func toComputeQuota(quota database.ComputeQuota) types.ComputeQuota {
+	return types.ComputeQuota{
+		ID:                  quota.ID,
+		Namespace:           quota.Namespace,
+		MaxGPUs:             quota.MaxGPUs,
+		MaxRunningInstances: quota.MaxRunningInstances,
+		MonthlyMinutes:      quota.MonthlyMinutes,
+		CreatedAt:           quota.CreatedAt,
+		UpdatedAt:           quota.UpdatedAt,
+	}
+}
+
+// computeQuotaChecker checks a new deploy against the compute quota of the namespace charged for it
+type computeQuotaChecker struct {
+	namespaceStore database.NamespaceStore
+	quotaStore     database.ComputeQuotaStore
+}
+
+func newComputeQuotaChecker() *computeQuotaChecker {
+	return &computeQuotaChecker{
+		namespaceStore: database.NewNamespaceStore(),
+		quotaStore:     database.NewComputeQuotaStore(),
+	}
+}
+
+// quotaDeploy describes a deploy to be checked against the compute quotas
+type quotaDeploy struct {
+	user *database.User
+	// namespace of the deployed repo, spaces are charged to it
+	repoNamespace string
+	deployType    int
+	hardware      types.HardWare
+	replicas      int
+	// deployID is the deploy being started or updated, it is not counted in the usage
+	deployID int64
+	// spaceID is the space being redeployed, its running deploys are replaced and not counted in the usage
+	spaceID int64
+}
+
+// replaces reports whether the active deploy d goes away or is the deploy being checked
+func (q quotaDeploy) replaces(d database.Deploy) bool {
+	return (q.deployID != 0 && d.ID == q.deployID) || (q.spaceID != 0 && d.SpaceID == q.spaceID)
+}
+
+// chargedNamespaces returns the paths of the namespaces whose quota a deploy takes in a fixed order, spaces are
+// charged to the user or organization owning them, other deploys to the user who starts them and also to the
+// organization owning the deployed repo
+func (c *computeQuotaChecker) chargedNamespaces(ctx context.Context, q quotaDeploy) ([]string, error) {
+	if q.deployType == types.SpaceType {
+		return []string{q.repoNamespace}, nil
+	}
+	paths := []string{q.user.Username}
+	if q.repoNamespace == "" || q.repoNamespace == q.user.Username {
+		return paths, nil
+	}
+	namespace, err := c.namespaceStore.FindByPath(ctx, q.repoNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find namespace %s, error: %w", q.repoNamespace, err)
+	}
+	if namespace.NamespaceType == database.OrgNamespace {
+		paths = append(paths, q.repoNamespace)
+	}
+	// concurrent checks lock the namespaces in the same order to avoid deadlock
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Check returns a QuotaExceededError if the deploy exceeds the quota of any charged namespace.
+// The namespaces stay locked until release is called, call it after the deploy is created so that
+// concurrent deploys cannot pass the check together.
+func (c *computeQuotaChecker) Check(ctx context.Context, q quotaDeploy) (func(), error) {
+	paths, err := c.chargedNamespaces(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	var releases []func()
+	release := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+	for _, path := range paths {
+		r, err := c.quotaStore.LockNamespace(ctx, path)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	for _, path := range paths {
+		err = c.check(ctx, q, path)
+		if err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+func (c *computeQuotaChecker) check(ctx context.Context, q quotaDeploy, path string) error {
+	quota, err := c.quotaStore.FindByNamespace(ctx, path)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to find compute quota of %s, error: %w", path, err)
+	}
+	namespace, err := c.namespaceStore.FindByPath(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to find namespace %s, error: %w", path, err)
+	}
+	usage, err := c.usage(ctx, namespace, q.replaces)
+	if err != nil {
+		return err
+	}
+	err = checkQuota(quota, usage, quotaRequest{
+		gpus:  deployGPUs(q.hardware, q.replicas),
+		scene: deploy.GetValidSceneType(q.deployType),
+	})
+	if err != nil {
+		slog.Warn("deploy exceeds compute quota", slog.String("user", q.user.Username),
+			slog.String("namespace", path), slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+// usage sums the resources used by the namespace. Organizations are counted all deploys of the repos they own,
+// users the spaces they own and other deploys they started. Active deploys matched by skip are left out.
+func (c *computeQuotaChecker) usage(ctx context.Context, namespace database.Namespace, skip func(database.Deploy) bool) (*types.ComputeQuotaUsage, error) {
+	nsDeploys, err := c.quotaStore.ListActiveDeploysByNamespace(ctx, namespace.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active deploys of repos of %s, error: %w", namespace.Path, err)
+	}
+	deploys := nsDeploys
+	if namespace.NamespaceType != database.OrgNamespace {
+		deploys = nil
+		for _, d := range nsDeploys {
+			if d.Type == types.SpaceType {
+				deploys = append(deploys, d)
+			}
+		}
+		userDeploys, err := c.quotaStore.ListActiveDeploysByUser(ctx, namespace.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list active deploys of %s, error: %w", namespace.Path, err)
+		}
+		deploys = append(deploys, userDeploys...)
+	}
+	usage := &types.ComputeQuotaUsage{
+		MonthlyMinutes: make(map[types.SceneType]float64),
+	}
+	for _, d := range deploys {
+		if skip != nil && skip(d) {
+			continue
+		}
+		usage.RunningInstances++
+		var hardware types.HardWare
+		if err := json.Unmarshal([]byte(d.Hardware), &hardware); err != nil {
+			slog.Warn("invalid hardware of deploy", slog.Int64("deploy_id", d.ID), slog.Any("error", err))
+			continue
+		}
+		usage.GPUs += deployGPUs(hardware, d.MaxReplica)
+	}
+
+	since := startOfMonth(time.Now())
+	for _, scene := range []types.SceneType{types.SceneModelInference, types.SceneSpace, types.SceneModelFinetune} {
+		var minutes float64
+		switch {
+		case scene == types.SceneSpace || namespace.NamespaceType == database.OrgNamespace:
+			minutes, err = c.quotaStore.SumMinutesByNamespace(ctx, namespace.Path, scene, since)
+		case namespace.NamespaceType != database.OrgNamespace:
+			minutes, err = c.quotaStore.SumMinutesByUser(ctx, namespace.User.UUID, scene, since)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum minutes of %s, error: %w", namespace.Path, err)
+		}
+		usage.MonthlyMinutes[scene] = minutes
+	}
+	return usage, nil
+}
+
+type quotaRequest struct {
+	gpus  int
+	scene types.SceneType
+}
+
+// checkQuota checks whether one more deploy fits in the quota, given the resources already in use
+func checkQuota(quota *database.ComputeQuota, usage *types.ComputeQuotaUsage, request quotaRequest) error {
+	if quota.MaxRunningInstances != nil && usage.RunningInstances+1 > *quota.MaxRunningInstances {
+		return &QuotaExceededError{types.ComputeQuotaExceeded{
+			Namespace: quota.Namespace,
+			Kind:      types.ComputeQuotaRunningInstances,
+			Limit:     int64(*quota.MaxRunningInstances),
+			Used:      int64(usage.RunningInstances),
+			Requested: 1,
+		}}
+	}
+	if quota.MaxGPUs != nil && request.gpus > 0 && usage.GPUs+request.gpus > *quota.MaxGPUs {
+		return &QuotaExceededError{types.ComputeQuotaExceeded{
+			Namespace: quota.Namespace,
+			Kind:      types.ComputeQuotaGPUs,
+			Limit:     int64(*quota.MaxGPUs),
+			Used:      int64(usage.GPUs),
+			Requested: int64(request.gpus),
+		}}
+	}
+	if limit, ok := quota.MonthlyMinutes[request.scene]; ok && usage.MonthlyMinutes[request.scene] >= float64(limit) {
+		return &QuotaExceededError{types.ComputeQuotaExceeded{
+			Namespace: quota.Namespace,
+			Kind:      types.ComputeQuotaMonthlyMinutes,
+			Scene:     request.scene,
+			Limit:     limit,
+			Used:      int64(usage.MonthlyMinutes[request.scene]),
+		}}
+	}
+	return nil
+}
+
+// deployGPUs returns the gpus taken by a deploy when it scales to max replicas
+func deployGPUs(hardware types.HardWare, replicas int) int {
+	num, err := strconv.Atoi(hardware.Gpu.Num)
+	if err != nil || num <= 0 {
+		return 0
+	}
+	if replicas < 1 {
+		replicas = 1
+	}
+	return num * replicas
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,170 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestDeployGPUs(t *testing.T) {
+	require.Equal(t, 0, deployGPUs(types.HardWare{}, 2))
+	require.Equal(t, 2, deployGPUs(types.HardWare{Gpu: types.GPU{Num: "2"}}, 0))
+	require.Equal(t, 6, deployGPUs(types.HardWare{Gpu: types.GPU{Num: "2"}}, 3))
+}
+
+func TestCheckQuota(t *testing.T) {
+	quota := &database.ComputeQuota{
+		Namespace:           "org1",
+		MaxGPUs:             intPtr(4),
+		MaxRunningInstances: intPtr(3),
+		MonthlyMinutes:      map[types.SceneType]int64{types.SceneSpace: 600},
+	}
+	usage := &types.ComputeQuotaUsage{
+		GPUs:             2,
+		RunningInstances: 2,
+		MonthlyMinutes:   map[types.SceneType]float64{types.SceneSpace: 100, types.SceneModelInference: 10000},
+	}
+
+	// within quota, and scenes not in quota are unlimited
+	require.NoError(t, checkQuota(quota, usage, quotaRequest{gpus: 2, scene: types.SceneModelInference}))
+
+	err := checkQuota(quota, usage, quotaRequest{gpus: 3, scene: types.SceneModelInference})
+	require.True(t, errors.Is(err, ErrQuotaExceeded))
+	var quotaErr *QuotaExceededError
+	require.True(t, errors.As(err, &quotaErr))
+	require.Equal(t, types.ComputeQuotaGPUs, quotaErr.Kind)
+	require.Equal(t, types.ErrCodeGPUQuotaExceeded, quotaErr.Code())
+
+	usage.RunningInstances = 3
+	err = checkQuota(quota, usage, quotaRequest{scene: types.SceneSpace})
+	require.True(t, errors.As(err, &quotaErr))
+	require.Equal(t, types.ComputeQuotaRunningInstances, quotaErr.Kind)
+
+	usage.RunningInstances = 0
+	usage.MonthlyMinutes[types.SceneSpace] = 600
+	err = checkQuota(quota, usage, quotaRequest{scene: types.SceneSpace})
+	require.True(t, errors.As(err, &quotaErr))
+	require.Equal(t, types.ComputeQuotaMonthlyMinutes, quotaErr.Kind)
+	require.Equal(t, types.ErrCodeMonthlyMinuteQuotaExceeded, quotaErr.Code())
+
+	// no limits
+	require.NoError(t, checkQuota(&database.ComputeQuota{Namespace: "user1"}, usage, quotaRequest{gpus: 100, scene: types.SceneSpace}))
+}
+
+type fakeQuotaStore struct {
+	database.ComputeQuotaStore
+	quotas      map[string]*database.ComputeQuota
+	userDeploys []database.Deploy
+	nsDeploys   map[string][]database.Deploy
+	locked      map[string]bool
+}
+
+func (s *fakeQuotaStore) FindByNamespace(ctx context.Context, namespace string) (*database.ComputeQuota, error) {
+	quota, ok := s.quotas[namespace]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return quota, nil
+}
+
+func (s *fakeQuotaStore) ListActiveDeploysByUser(ctx context.Context, userID int64) ([]database.Deploy, error) {
+	return s.userDeploys, nil
+}
+
+func (s *fakeQuotaStore) ListActiveDeploysByNamespace(ctx context.Context, namespace string) ([]database.Deploy, error) {
+	return s.nsDeploys[namespace], nil
+}
+
+func (s *fakeQuotaStore) SumMinutesByUser(ctx context.Context, userUUID string, scene types.SceneType, since time.Time) (float64, error) {
+	return 0, nil
+}
+
+func (s *fakeQuotaStore) SumMinutesByNamespace(ctx context.Context, namespace string, scene types.SceneType, since time.Time) (float64, error) {
+	return 0, nil
+}
+
+func (s *fakeQuotaStore) LockNamespace(ctx context.Context, namespace string) (func(), error) {
+	s.locked[namespace] = true
+	return func() { s.locked[namespace] = false }, nil
+}
+
+type fakeQuotaNamespaceStore struct {
+	database.NamespaceStore
+}
+
+func (s *fakeQuotaNamespaceStore) FindByPath(ctx context.Context, path string) (database.Namespace, error) {
+	if path == "org1" {
+		return database.Namespace{Path: path, NamespaceType: database.OrgNamespace}, nil
+	}
+	return database.Namespace{Path: path, NamespaceType: database.UserNamespace, UserID: 1, User: database.User{UUID: "uuid1"}}, nil
+}
+
+func TestComputeQuotaChecker_Check(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeQuotaStore{
+		quotas: map[string]*database.ComputeQuota{
+			"org1":  {Namespace: "org1", MaxRunningInstances: intPtr(1)},
+			"user1": {Namespace: "user1", MaxRunningInstances: intPtr(1)},
+		},
+		nsDeploys: map[string][]database.Deploy{
+			"org1": {{ID: 10, SpaceID: 5, Type: types.SpaceType}},
+		},
+		locked: map[string]bool{},
+	}
+	checker := &computeQuotaChecker{namespaceStore: &fakeQuotaNamespaceStore{}, quotaStore: store}
+	user := &database.User{ID: 1, Username: "user1"}
+
+	// a model of the user is charged to the user only
+	release, err := checker.Check(ctx, quotaDeploy{user: user, repoNamespace: "user1", deployType: types.InferenceType})
+	require.NoError(t, err)
+	require.True(t, store.locked["user1"])
+	require.False(t, store.locked["org1"])
+	release()
+	require.False(t, store.locked["user1"])
+
+	// a model of the org is charged to both the user and the org, whose space takes its only instance
+	_, err = checker.Check(ctx, quotaDeploy{user: user, repoNamespace: "org1", deployType: types.InferenceType})
+	var quotaErr *QuotaExceededError
+	require.True(t, errors.As(err, &quotaErr))
+	require.Equal(t, "org1", quotaErr.Namespace)
+	require.False(t, store.locked["user1"])
+	require.False(t, store.locked["org1"])
+
+	store.quotas["org1"].MaxRunningInstances = intPtr(2)
+	release, err = checker.Check(ctx, quotaDeploy{user: user, repoNamespace: "org1", deployType: types.InferenceType})
+	require.NoError(t, err)
+	require.True(t, store.locked["user1"])
+	require.True(t, store.locked["org1"])
+	release()
+	require.False(t, store.locked["user1"])
+	require.False(t, store.locked["org1"])
+	store.quotas["org1"].MaxRunningInstances = intPtr(1)
+
+	// another space of the org is charged to the org
+	_, err = checker.Check(ctx, quotaDeploy{user: user, repoNamespace: "org1", deployType: types.SpaceType, spaceID: 6})
+	require.True(t, errors.Is(err, ErrQuotaExceeded))
+	require.False(t, store.locked["org1"])
+
+	// redeploying the running space replaces it
+	release, err = checker.Check(ctx, quotaDeploy{user: user, repoNamespace: "org1", deployType: types.SpaceType, spaceID: 5})
+	require.NoError(t, err)
+	release()
+
+	// starting a stopped deploy does not count itself
+	store.userDeploys = []database.Deploy{{ID: 20, Type: types.InferenceType}}
+	_, err = checker.Check(ctx, quotaDeploy{user: user, repoNamespace: "user1", deployType: types.InferenceType})
+	require.True(t, errors.Is(err, ErrQuotaExceeded))
+	release, err = checker.Check(ctx, quotaDeploy{user: user, repoNamespace: "user1", deployType: types.InferenceType, deployID: 20})
+	require.NoError(t, err)
+	release()
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"sync"
 
 	"opencsg.com/csghub-server/builder/git"
 	"opencsg.com/csghub-server/builder/git/gitserver"
 	"opencsg.com/csghub-server/builder/parquet"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/store/s3"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
 )
@@ -29,10 +30,11 @@ type ViewParquetFileResp struct {
 	Rows    [][]interface{} `json:"rows"`
 }
 type datasetViewerComponentImpl struct {
-	gs      gitserver.GitServer
-	preader parquet.Reader
-	once    *sync.Once
-	cfg     *config.Config
+	gs        gitserver.GitServer
+	repoStore database.RepoStore
+	// parquet files are read from the lfs storages holding them
+	lfsRouter s3.LfsRouter
+	preaders  *parquet.LfsReaders
 }
 
 type DatasetViewerComponent interface {
@@ -44,38 +46,43 @@ func NewDatasetViewerComponent(cfg *config.Config) (DatasetViewerComponent, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to create git server,cause:%w", err)
 	}
+	lfsRouter, err := s3.NewLfsRouter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lfs router,cause:%w", err)
+	}
 	return &datasetViewerComponentImpl{
-		gs:   gs,
-		once: new(sync.Once),
-		cfg:  cfg,
+		gs:        gs,
+		repoStore: database.NewRepoStore(),
+		lfsRouter: lfsRouter,
+		preaders:  parquet.NewLfsReaders(),
 	}, nil
 }
 
-func (c *datasetViewerComponentImpl) lazyInit() {
-	c.once.Do(func() {
-		r, err := parquet.NewS3Reader(c.cfg)
-		if err != nil {
-			slog.Error("failed to create parquet reader,cause:%w", slog.Any("error", err))
-		}
-		c.preader = r
-	})
-}
-
 func (c *datasetViewerComponentImpl) ViewParquetFile(ctx context.Context, req *ViewParquetFileReq) (*ViewParquetFileResp, error) {
-	c.lazyInit()
-
-	objName, err := c.getParquetObject(req)
+	objName, relativePath, err := c.getParquetObject(req)
 	if err != nil {
 		slog.Error("Failed to view parquet file", slog.Any("error", err))
 		return nil, err
 	}
+	repo, err := c.repoStore.FindByPath(ctx, types.DatasetRepo, req.Namespace, req.RepoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dataset,cause:%w", err)
+	}
+	storage, err := c.lfsRouter.ForObject(ctx, repo, oidFromLfsRelativePath(relativePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lfs storage of parquet file,cause:%w", err)
+	}
+	preader, err := c.preaders.Of(storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet reader,cause:%w", err)
+	}
 	rowCount := req.RowCount
 	if rowCount < 1 {
 		rowCount = 20
 	} else if rowCount > 100 {
 		rowCount = 100
 	}
-	columns, rows, err := c.preader.TopN(objName, rowCount)
+	columns, rows, err := preader.TopN(objName, rowCount)
 	if err != nil {
 		slog.Error("Failed to view parquet file", slog.Any("error", err))
 		return nil, err
@@ -87,7 +94,8 @@ func (c *datasetViewerComponentImpl) ViewParquetFile(ctx context.Context, req *V
 	return resp, nil
 }
 
-func (c *datasetViewerComponentImpl) getParquetObject(req *ViewParquetFileReq) (string, error) {
+// getParquetObject returns the object name and lfs relative path of the parquet file
+func (c *datasetViewerComponentImpl) getParquetObject(req *ViewParquetFileReq) (string, string, error) {
 	getFileContentReq := gitserver.GetRepoInfoByPathReq{
 		Namespace: req.Namespace,
 		Name:      req.RepoName,
@@ -97,8 +105,8 @@ func (c *datasetViewerComponentImpl) getParquetObject(req *ViewParquetFileReq) (
 	}
 	f, err := c.gs.GetRepoFileContents(context.Background(), getFileContentReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to get file contents,cause:%v", err)
+		return "", "", fmt.Errorf("failed to get file contents,cause:%v", err)
 	}
 
-	return "lfs/" + f.LfsRelativePath, nil
+	return "lfs/" + f.LfsRelativePath, f.LfsRelativePath, nil
 }
@@ -0,0 +1,68 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"opencsg.com/csghub-server/builder/deploy"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type DeployQueueComponent interface {
+	// Index lists the running deploy tasks and the waiting ones in the order they will run
+	Index(ctx context.Context, currentUser string) ([]types.DeployQueueTask, error)
+	// SetPriority moves a waiting deploy task up or down in the queue
+	SetPriority(ctx context.Context, req types.SetDeployTaskPriorityReq) error
+}
+
+func NewDeployQueueComponent(config *config.Config) (DeployQueueComponent, error) {
+	c := &deployQueueComponentImpl{}
+	c.deployer = deploy.NewDeployer()
+	c.userStore = database.NewUserStore()
+	c.deployTaskStore = database.NewDeployTaskStore()
+	return c, nil
+}
+
+type deployQueueComponentImpl struct {
+	deployer        deploy.Deployer
+	userStore       database.UserStore
+	deployTaskStore database.DeployTaskStore
+}
+
+func (c *deployQueueComponentImpl) Index(ctx context.Context, currentUser string) ([]types.DeployQueueTask, error) {
+	err := c.checkAdmin(ctx, currentUser)
+	if err != nil {
+		return nil, err
+	}
+	return c.deployer.ListDeployQueue(ctx)
+}
+
+func (c *deployQueueComponentImpl) SetPriority(ctx context.Context, req types.SetDeployTaskPriorityReq) error {
+	err := c.checkAdmin(ctx, req.CurrentUser)
+	if err != nil {
+		return err
+	}
+	_, err = c.deployTaskStore.GetDeployTask(ctx, req.TaskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get deploy task, error: %w", err)
+	}
+	return c.deployer.SetDeployTaskPriority(ctx, req.TaskID, req.Priority)
+}
+
+func (c *deployQueueComponentImpl) checkAdmin(ctx context.Context, currentUser string) error {
+	user, err := c.userStore.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.CanAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
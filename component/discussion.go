@@ -4,17 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"opencsg.com/csghub-server/builder/sensitive"
 	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/webhook"
 	"opencsg.com/csghub-server/common/types"
 )
 
 type discussionComponentImpl struct {
-	ds database.DiscussionStore
-	rs database.RepoStore
-	us database.UserStore
+	ds              database.DiscussionStore
+	rs              database.RepoStore
+	us              database.UserStore
+	webhookNotifier webhook.Notifier
 }
 
 type DiscussionComponent interface {
@@ -33,7 +36,7 @@ func NewDiscussionComponent() DiscussionComponent {
 	ds := database.NewDiscussionStore()
 	rs := database.NewRepoStore()
 	us := database.NewUserStore()
-	return &discussionComponentImpl{ds: ds, rs: rs, us: us}
+	return &discussionComponentImpl{ds: ds, rs: rs, us: us, webhookNotifier: webhook.NewNotifier()}
 }
 
 func (c *discussionComponentImpl) CreateRepoDiscussion(ctx context.Context, req CreateRepoDiscussionRequest) (*CreateDiscussionResponse, error) {
@@ -57,6 +60,14 @@ func (c *discussionComponentImpl) CreateRepoDiscussion(ctx context.Context, req
 	if err != nil {
 		return nil, fmt.Errorf("failed to create discussion: %w", err)
 	}
+	err = c.webhookNotifier.Notify(ctx, repo, types.WebhookEventDiscussion, types.WebhookDiscussionData{
+		ID:       discussion.ID,
+		Title:    discussion.Title,
+		Username: user.Username,
+	})
+	if err != nil {
+		slog.Error("failed to notify webhooks of new discussion", slog.Int64("discussion_id", discussion.ID), slog.Any("error", err))
+	}
 	resp := &CreateDiscussionResponse{
 		ID: discussion.ID,
 		User: &DiscussionResponse_User{
@@ -9,4 +9,7 @@ var (
 	ErrUserNotFound     = errors.New("user not found, please login first")
 	ErrAlreadyExists    = errors.New("the record already exists")
 	ErrPermissionDenied = errors.New("permission denied")
+	ErrBranchProtected  = errors.New("branch is protected")
+	ErrBadRequest       = errors.New("bad request")
+	ErrQuotaExceeded    = errors.New("compute quota exceeded")
 )
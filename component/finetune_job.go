@@ -0,0 +1,533 @@
+package component
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"opencsg.com/csghub-server/builder/deploy"
+	deployCommon "opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/common/utils/common"
+)
+
+type FinetuneJobComponent interface {
+	// Create submits a job to finetune a model on a dataset
+	Create(ctx context.Context, req types.CreateFinetuneJobReq) (*types.FinetuneJob, error)
+	Index(ctx context.Context, currentUser string, per, page int) ([]types.FinetuneJob, int, error)
+	// Show returns the job with its checkpoints
+	Show(ctx context.Context, currentUser string, id int64) (*types.FinetuneJob, error)
+	// Cancel stops the training container of an unfinished job
+	Cancel(ctx context.Context, currentUser string, id int64) error
+	// Logs reads the logs of the training container
+	Logs(ctx context.Context, currentUser string, id int64) (*deploy.MultiLogReader, error)
+	// ReportEvent records the progress reported by the training container
+	ReportEvent(ctx context.Context, req types.FinetuneJobEventReq) (*types.FinetuneJobEventResp, error)
+}
+
+func NewFinetuneJobComponent(config *config.Config) (FinetuneJobComponent, error) {
+	c := &finetuneJobComponentImpl{}
+	var err error
+	c.repoComponentImpl, err = NewRepoComponentImpl(config)
+	if err != nil {
+		return nil, err
+	}
+	c.model, err = NewModelComponent(config)
+	if err != nil {
+		return nil, err
+	}
+	c.jobStore = database.NewFinetuneJobStore()
+	c.quota = newComputeQuotaChecker()
+	return c, nil
+}
+
+type finetuneJobComponentImpl struct {
+	*repoComponentImpl
+	model    ModelComponent
+	jobStore database.FinetuneJobStore
+	quota    *computeQuotaChecker
+}
+
+func (c *finetuneJobComponentImpl) Create(ctx context.Context, req types.CreateFinetuneJobReq) (*types.FinetuneJob, error) {
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	modelRepo, err := c.findReadableRepo(ctx, types.ModelRepo, req.Model, user.Username)
+	if err != nil {
+		return nil, err
+	}
+	model, err := c.modelStore.FindByPath(ctx, strings.Split(modelRepo.Path, "/")[0], modelRepo.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find model %s, error: %w", req.Model, err)
+	}
+	datasetRepo, err := c.findReadableRepo(ctx, types.DatasetRepo, req.Dataset, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.OutputNamespace == "" {
+		req.OutputNamespace = user.Username
+	}
+	namespace, err := c.namespace.FindByPath(ctx, req.OutputNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("%w: namespace %s does not exist", ErrBadRequest, req.OutputNamespace)
+	}
+	err = c.checkCreateRepoPermission(ctx, user, &namespace, types.ModelRepo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrForbidden, err)
+	}
+	_, err = c.repo.FindByPath(ctx, types.ModelRepo, req.OutputNamespace, req.OutputName)
+	if err == nil {
+		return nil, fmt.Errorf("model %s/%s, %w", req.OutputNamespace, req.OutputName, ErrAlreadyExists)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to find model %s/%s, error: %w", req.OutputNamespace, req.OutputName, err)
+	}
+
+	frame, err := c.rtfm.FindEnabledByID(ctx, req.RuntimeFrameworkID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find available runtime framework, %w", err)
+	}
+	if frame.Type != types.FinetuneType {
+		return nil, fmt.Errorf("%w: runtime framework %s is not for finetune", ErrBadRequest, frame.FrameName)
+	}
+	resource, err := c.srs.FindByID(ctx, req.ResourceID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find resource, %w", err)
+	}
+	var hardware types.HardWare
+	err = json.Unmarshal([]byte(resource.Resources), &hardware)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hardware setting, %w", err)
+	}
+	_, err = c.deployer.CheckResourceAvailable(ctx, req.ClusterID, &hardware)
+	if err != nil {
+		return nil, fmt.Errorf("fail to check resource, %w", err)
+	}
+	release, err := c.quota.Check(ctx, quotaDeploy{
+		user:       &user,
+		deployType: types.FinetuneType,
+		hardware:   hardware,
+		replicas:   1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if req.Revision == "" {
+		req.Revision = modelRepo.DefaultBranch
+	}
+	eventToken, err := newFinetuneJobEventToken()
+	if err != nil {
+		return nil, err
+	}
+	job, err := c.jobStore.Create(ctx, database.FinetuneJob{
+		UserID:          user.ID,
+		ModelRepoID:     modelRepo.ID,
+		Revision:        req.Revision,
+		DatasetRepoID:   datasetRepo.ID,
+		Hyperparameters: req.Hyperparameters,
+		Status:          types.FinetuneJobPending,
+		OutputNamespace: req.OutputNamespace,
+		OutputName:      req.OutputName,
+		EventToken:      eventToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	job.User = &user
+	job.ModelRepo = modelRepo
+	job.DatasetRepo = datasetRepo
+
+	env, err := c.jobEnv(job)
+	if err != nil {
+		return nil, err
+	}
+	annotations := map[string]string{
+		types.ResTypeKey: string(types.ModelRepo),
+		types.ResNameKey: modelRepo.Path,
+	}
+	annoStr, err := json.Marshal(annotations)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create annotations for finetune job, %w", err)
+	}
+	deployID, err := c.deployer.Deploy(ctx, types.DeployRepo{
+		DeployName:       fmt.Sprintf("finetune-job-%d", job.ID),
+		Path:             modelRepo.Path,
+		GitPath:          modelRepo.GitPath,
+		GitBranch:        req.Revision,
+		Env:              env,
+		Hardware:         resource.Resources,
+		UserID:           user.ID,
+		ModelID:          model.ID,
+		RepoID:           modelRepo.ID,
+		RuntimeFramework: frame.FrameName,
+		ContainerPort:    frame.ContainerPort,
+		ImageID:          frame.FrameImage,
+		MinReplica:       1,
+		MaxReplica:       1,
+		Annotation:       string(annoStr),
+		ClusterID:        req.ClusterID,
+		SecureLevel:      types.EndpointPrivate,
+		Type:             types.FinetuneType,
+		UserUUID:         user.UUID,
+		SKU:              strconv.FormatInt(resource.ID, 10),
+	})
+	if err != nil {
+		c.finish(ctx, job, types.FinetuneJobFailed, fmt.Sprintf("failed to start training container: %s", err))
+		return nil, fmt.Errorf("failed to deploy finetune job, error: %w", err)
+	}
+	job.DeployID = deployID
+	err = c.jobStore.Update(ctx, job, "deploy_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to save deploy of finetune job, error: %w", err)
+	}
+	res := toFinetuneJob(job)
+	return &res, nil
+}
+
+func (c *finetuneJobComponentImpl) Index(ctx context.Context, currentUser string, per, page int) ([]types.FinetuneJob, int, error) {
+	user, err := c.user.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return nil, 0, ErrUserNotFound
+	}
+	jobs, total, err := c.jobStore.ListByUserID(ctx, user.ID, per, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list finetune jobs, error: %w", err)
+	}
+	var res []types.FinetuneJob
+	for i := range jobs {
+		c.syncDeployStatus(ctx, &jobs[i])
+		res = append(res, toFinetuneJob(&jobs[i]))
+	}
+	return res, total, nil
+}
+
+func (c *finetuneJobComponentImpl) Show(ctx context.Context, currentUser string, id int64) (*types.FinetuneJob, error) {
+	job, err := c.getOwnJob(ctx, currentUser, id)
+	if err != nil {
+		return nil, err
+	}
+	c.syncDeployStatus(ctx, job)
+	checkpoints, err := c.jobStore.ListCheckpoints(ctx, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints of finetune job, error: %w", err)
+	}
+	res := toFinetuneJob(job)
+	for _, checkpoint := range checkpoints {
+		res.Checkpoints = append(res.Checkpoints, types.FinetuneCheckpoint{
+			ID:        checkpoint.ID,
+			Epoch:     checkpoint.Epoch,
+			Step:      checkpoint.Step,
+			Name:      checkpoint.Name,
+			Metrics:   checkpoint.Metrics,
+			CreatedAt: checkpoint.CreatedAt,
+		})
+	}
+	return &res, nil
+}
+
+func (c *finetuneJobComponentImpl) Cancel(ctx context.Context, currentUser string, id int64) error {
+	job, err := c.getOwnJob(ctx, currentUser, id)
+	if err != nil {
+		return err
+	}
+	if job.Status.Finished() {
+		return fmt.Errorf("%w: finetune job is %s already", ErrBadRequest, job.Status)
+	}
+	return c.finish(ctx, job, types.FinetuneJobCancelled, fmt.Sprintf("cancelled by %s", currentUser))
+}
+
+func (c *finetuneJobComponentImpl) Logs(ctx context.Context, currentUser string, id int64) (*deploy.MultiLogReader, error) {
+	job, err := c.getOwnJob(ctx, currentUser, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.DeployID == 0 {
+		return nil, fmt.Errorf("training container of finetune job, %w", ErrNotFound)
+	}
+	d, err := c.deploy.GetDeployByID(ctx, job.DeployID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deploy of finetune job, error: %w", err)
+	}
+	dr := jobDeployRepo(job, d)
+	_, _, instances, err := c.deployer.GetReplica(ctx, dr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instances of finetune job, error: %w", err)
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("training container of finetune job, %w", ErrNotFound)
+	}
+	dr.InstanceName = instances[0].Name
+	return c.deployer.InstanceLogs(ctx, dr)
+}
+
+func (c *finetuneJobComponentImpl) ReportEvent(ctx context.Context, req types.FinetuneJobEventReq) (*types.FinetuneJobEventResp, error) {
+	job, err := c.getOwnJob(ctx, req.CurrentUser, req.JobID)
+	if err != nil {
+		return nil, err
+	}
+	// only the training container knows the event token, the owner cannot report events by hand
+	if job.EventToken == "" || subtle.ConstantTimeCompare([]byte(job.EventToken), []byte(req.EventToken)) != 1 {
+		return nil, fmt.Errorf("invalid event token of finetune job, %w", ErrForbidden)
+	}
+	if job.Status.Finished() {
+		return nil, fmt.Errorf("%w: finetune job is %s already", ErrBadRequest, job.Status)
+	}
+
+	resp := &types.FinetuneJobEventResp{}
+	switch req.Type {
+	case types.FinetuneJobEventProgress, types.FinetuneJobEventCheckpoint:
+		if req.Type == types.FinetuneJobEventCheckpoint {
+			_, err = c.jobStore.CreateCheckpoint(ctx, database.FinetuneCheckpoint{
+				JobID:   job.ID,
+				Epoch:   req.Epoch,
+				Step:    req.Step,
+				Name:    req.Checkpoint,
+				Metrics: req.Metrics,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if job.StartedAt.IsZero() {
+			job.StartedAt = time.Now()
+		}
+		job.Status = types.FinetuneJobRunning
+		job.CurrentEpoch = req.Epoch
+		job.CurrentStep = req.Step
+		if len(req.Metrics) > 0 {
+			job.Metrics = req.Metrics
+		}
+		err = c.jobStore.Update(ctx, job, "status", "started_at", "current_epoch", "current_step", "metrics")
+	case types.FinetuneJobEventUploading:
+		var outputRepo *database.Repository
+		outputRepo, err = c.createOutputModel(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		resp.OutputModel = outputRepo.Path
+		resp.HTTPCloneURL = common.BuildCloneInfo(c.config, outputRepo).HTTPCloneURL
+		job.Status = types.FinetuneJobUploading
+		job.OutputRepoID = outputRepo.ID
+		err = c.jobStore.Update(ctx, job, "status", "output_repo_id")
+	case types.FinetuneJobEventSucceeded:
+		if job.OutputRepoID == 0 {
+			return nil, fmt.Errorf("%w: output model of finetune job is not created", ErrBadRequest)
+		}
+		err = c.rel.SetFinetuneSources(ctx, job.OutputRepoID, job.ModelRepoID, job.DatasetRepoID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to relate output model to base model and dataset, error: %w", err)
+		}
+		err = c.finish(ctx, job, types.FinetuneJobSucceeded, req.Message)
+	case types.FinetuneJobEventFailed:
+		err = c.finish(ctx, job, types.FinetuneJobFailed, req.Message)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update finetune job, error: %w", err)
+	}
+	resp.Status = job.Status
+	return resp, nil
+}
+
+// createOutputModel creates the model repo to push the trained weights to, the repo created before is
+// returned if the container retries
+func (c *finetuneJobComponentImpl) createOutputModel(ctx context.Context, job *database.FinetuneJob) (*database.Repository, error) {
+	if job.OutputRepoID > 0 {
+		return c.repo.FindById(ctx, job.OutputRepoID)
+	}
+	model, err := c.model.Create(ctx, &types.CreateModelReq{
+		BaseModel: job.ModelRepo.Path,
+		CreateRepoReq: types.CreateRepoReq{
+			Username:    job.User.Username,
+			Namespace:   job.OutputNamespace,
+			Name:        job.OutputName,
+			Description: fmt.Sprintf("Finetuned from %s on %s", job.ModelRepo.Path, job.DatasetRepo.Path),
+			Private:     true,
+			License:     job.ModelRepo.License,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output model of finetune job, error: %w", err)
+	}
+	return c.repo.FindById(ctx, model.RepositoryID)
+}
+
+// finish moves the job to a final status and stops its training container
+func (c *finetuneJobComponentImpl) finish(ctx context.Context, job *database.FinetuneJob, status types.FinetuneJobStatus, message string) error {
+	job.Status = status
+	job.Message = message
+	job.FinishedAt = time.Now()
+	err := c.jobStore.Update(ctx, job, "status", "message", "finished_at")
+	if err != nil {
+		return err
+	}
+	if job.DeployID == 0 {
+		return nil
+	}
+	d, err := c.deploy.GetDeployByID(ctx, job.DeployID)
+	if err != nil {
+		slog.Error("failed to get deploy of finetune job", slog.Int64("job_id", job.ID), slog.Any("error", err))
+		return nil
+	}
+	err = c.deployer.Stop(ctx, jobDeployRepo(job, d))
+	if err != nil {
+		// the container may be gone already
+		slog.Warn("failed to stop training container of finetune job", slog.Int64("job_id", job.ID), slog.Any("error", err))
+	}
+	err = c.deploy.StopDeploy(ctx, types.ModelRepo, d.RepoID, d.UserID, d.ID)
+	if err != nil {
+		slog.Warn("failed to set deploy of finetune job stopped", slog.Int64("job_id", job.ID), slog.Any("error", err))
+	}
+	return nil
+}
+
+// syncDeployStatus fails the job if its training container fails or is stopped without reporting
+func (c *finetuneJobComponentImpl) syncDeployStatus(ctx context.Context, job *database.FinetuneJob) {
+	if job.Status.Finished() || job.DeployID == 0 {
+		return
+	}
+	d, err := c.deploy.GetDeployByID(ctx, job.DeployID)
+	if err != nil {
+		slog.Warn("failed to get deploy of finetune job", slog.Int64("job_id", job.ID), slog.Any("error", err))
+		return
+	}
+	var message string
+	switch d.Status {
+	case deployCommon.BuildFailed, deployCommon.DeployFailed, deployCommon.RunTimeError:
+		message = "training container failed, check the logs for details"
+	case deployCommon.Stopped, deployCommon.Deleted:
+		message = "training container was stopped"
+	default:
+		return
+	}
+	err = c.finish(ctx, job, types.FinetuneJobFailed, message)
+	if err != nil {
+		slog.Warn("failed to fail finetune job", slog.Int64("job_id", job.ID), slog.Any("error", err))
+	}
+}
+
+func (c *finetuneJobComponentImpl) getOwnJob(ctx context.Context, currentUser string, id int64) (*database.FinetuneJob, error) {
+	job, err := c.jobStore.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get finetune job, error: %w", err)
+	}
+	if job.User == nil || job.User.Username != currentUser {
+		return nil, ErrForbidden
+	}
+	return job, nil
+}
+
+func (c *repoComponentImpl) findReadableRepo(ctx context.Context, repoType types.RepositoryType, path, username string) (*database.Repository, error) {
+	namespace, name, found := strings.Cut(path, "/")
+	if !found {
+		return nil, fmt.Errorf("%w: invalid %s path %s", ErrBadRequest, repoType, path)
+	}
+	repo, err := c.repo.FindByPath(ctx, repoType, namespace, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s %s, %w", repoType, path, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to find %s %s, error: %w", repoType, path, err)
+	}
+	allow, err := c.AllowReadAccessRepo(ctx, repo, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permission of %s %s, error: %w", repoType, path, err)
+	}
+	if !allow {
+		return nil, fmt.Errorf("no permission to read %s %s, %w", repoType, path, ErrForbidden)
+	}
+	return repo, nil
+}
+
+// jobEnv passes the job to the training container, which reports events to the callback url
+// with the access token of job owner and the event token of the job
+func (c *finetuneJobComponentImpl) jobEnv(job *database.FinetuneJob) (string, error) {
+	hyperparameters, err := json.Marshal(job.Hyperparameters)
+	if err != nil {
+		return "", fmt.Errorf("invalid hyperparameters, %w", err)
+	}
+	env, err := json.Marshal(map[string]string{
+		"FINETUNE_JOB_ID":       strconv.FormatInt(job.ID, 10),
+		"FINETUNE_JOB_CALLBACK": fmt.Sprintf("%s/api/v1/finetune_jobs/%d/events", strings.TrimSuffix(c.config.APIServer.PublicDomain, "/"), job.ID),
+		"FINETUNE_JOB_TOKEN":    job.EventToken,
+		"DATASET_ID":            job.DatasetRepo.Path,
+		"HYPERPARAMETERS":       string(hyperparameters),
+	})
+	if err != nil {
+		return "", fmt.Errorf("fail to create env for finetune job, %w", err)
+	}
+	return string(env), nil
+}
+
+func newFinetuneJobEventToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate event token of finetune job, error: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func jobDeployRepo(job *database.FinetuneJob, d *database.Deploy) types.DeployRepo {
+	namespace, name, _ := strings.Cut(job.ModelRepo.Path, "/")
+	return types.DeployRepo{
+		DeployID:  d.ID,
+		ModelID:   d.ModelID,
+		Namespace: namespace,
+		Name:      name,
+		SvcName:   d.SvcName,
+		ClusterID: d.ClusterID,
+	}
+}
+
+func toFinetuneJob(job *database.FinetuneJob) types.FinetuneJob {
+	res := types.FinetuneJob{
+		ID:              job.ID,
+		Revision:        job.Revision,
+		Hyperparameters: job.Hyperparameters,
+		Status:          job.Status,
+		Message:         job.Message,
+		DeployID:        job.DeployID,
+		CurrentEpoch:    job.CurrentEpoch,
+		CurrentStep:     job.CurrentStep,
+		Metrics:         job.Metrics,
+		CreatedAt:       job.CreatedAt,
+		UpdatedAt:       job.UpdatedAt,
+	}
+	if job.User != nil {
+		res.Username = job.User.Username
+	}
+	if job.ModelRepo != nil {
+		res.Model = job.ModelRepo.Path
+	}
+	if job.DatasetRepo != nil {
+		res.Dataset = job.DatasetRepo.Path
+	}
+	if job.OutputRepoID > 0 {
+		res.OutputModel = fmt.Sprintf("%s/%s", job.OutputNamespace, job.OutputName)
+	}
+	if !job.StartedAt.IsZero() {
+		res.StartedAt = &job.StartedAt
+	}
+	if !job.FinishedAt.IsZero() {
+		res.FinishedAt = &job.FinishedAt
+	}
+	return res
+}
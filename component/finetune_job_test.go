@@ -0,0 +1,121 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type fakeFinetuneJobStore struct {
+	database.FinetuneJobStore
+	job         *database.FinetuneJob
+	checkpoints []database.FinetuneCheckpoint
+}
+
+func (s *fakeFinetuneJobStore) FindByID(ctx context.Context, id int64) (*database.FinetuneJob, error) {
+	job := *s.job
+	return &job, nil
+}
+
+func (s *fakeFinetuneJobStore) Update(ctx context.Context, job *database.FinetuneJob, columns ...string) error {
+	s.job = job
+	return nil
+}
+
+func (s *fakeFinetuneJobStore) CreateCheckpoint(ctx context.Context, checkpoint database.FinetuneCheckpoint) (*database.FinetuneCheckpoint, error) {
+	s.checkpoints = append(s.checkpoints, checkpoint)
+	return &checkpoint, nil
+}
+
+type fakeFinetuneRelationsStore struct {
+	database.RepoRelationsStore
+	sources []int64
+}
+
+func (s *fakeFinetuneRelationsStore) SetFinetuneSources(ctx context.Context, repoID int64, sourceRepoIDs ...int64) error {
+	s.sources = sourceRepoIDs
+	return nil
+}
+
+func TestFinetuneJobComponent_ReportEvent(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeFinetuneJobStore{job: &database.FinetuneJob{
+		ID:            1,
+		User:          &database.User{Username: "user1"},
+		ModelRepoID:   2,
+		DatasetRepoID: 3,
+		Status:        types.FinetuneJobPending,
+		EventToken:    "secret",
+	}}
+	rel := &fakeFinetuneRelationsStore{}
+	c := &finetuneJobComponentImpl{
+		repoComponentImpl: &repoComponentImpl{rel: rel},
+		jobStore:          store,
+	}
+
+	// the owner token alone is not enough
+	_, err := c.ReportEvent(ctx, types.FinetuneJobEventReq{JobID: 1, CurrentUser: "user1", Type: types.FinetuneJobEventSucceeded})
+	require.True(t, errors.Is(err, ErrForbidden))
+	_, err = c.ReportEvent(ctx, types.FinetuneJobEventReq{JobID: 1, CurrentUser: "user1", EventToken: "wrong", Type: types.FinetuneJobEventFailed})
+	require.True(t, errors.Is(err, ErrForbidden))
+	_, err = c.ReportEvent(ctx, types.FinetuneJobEventReq{JobID: 1, CurrentUser: "user2", EventToken: "secret", Type: types.FinetuneJobEventFailed})
+	require.True(t, errors.Is(err, ErrForbidden))
+	require.Equal(t, types.FinetuneJobPending, store.job.Status)
+
+	resp, err := c.ReportEvent(ctx, types.FinetuneJobEventReq{
+		JobID:       1,
+		CurrentUser: "user1",
+		EventToken:  "secret",
+		Type:        types.FinetuneJobEventCheckpoint,
+		Epoch:       1,
+		Step:        100,
+		Checkpoint:  "checkpoint-100",
+		Metrics:     map[string]float64{"loss": 0.5},
+	})
+	require.NoError(t, err)
+	require.Equal(t, types.FinetuneJobRunning, resp.Status)
+	require.Equal(t, 100, store.job.CurrentStep)
+	require.Len(t, store.checkpoints, 1)
+	require.False(t, store.job.StartedAt.IsZero())
+
+	// succeeded before the output model is created
+	_, err = c.ReportEvent(ctx, types.FinetuneJobEventReq{JobID: 1, CurrentUser: "user1", EventToken: "secret", Type: types.FinetuneJobEventSucceeded})
+	require.True(t, errors.Is(err, ErrBadRequest))
+
+	store.job.OutputRepoID = 4
+	resp, err = c.ReportEvent(ctx, types.FinetuneJobEventReq{JobID: 1, CurrentUser: "user1", EventToken: "secret", Type: types.FinetuneJobEventSucceeded})
+	require.NoError(t, err)
+	require.Equal(t, types.FinetuneJobSucceeded, resp.Status)
+	require.Equal(t, []int64{2, 3}, rel.sources)
+
+	// finished jobs take no more events
+	_, err = c.ReportEvent(ctx, types.FinetuneJobEventReq{JobID: 1, CurrentUser: "user1", EventToken: "secret", Type: types.FinetuneJobEventFailed})
+	require.True(t, errors.Is(err, ErrBadRequest))
+}
+
+func TestFinetuneJobComponent_JobEnv(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.APIServer.PublicDomain = "https://hub.example.com/"
+	c := &finetuneJobComponentImpl{repoComponentImpl: &repoComponentImpl{config: cfg}}
+	token, err := newFinetuneJobEventToken()
+	require.NoError(t, err)
+	require.Len(t, token, 64)
+
+	env, err := c.jobEnv(&database.FinetuneJob{
+		ID:          1,
+		DatasetRepo: &database.Repository{Path: "user1/dataset1"},
+		EventToken:  token,
+	})
+	require.NoError(t, err)
+	var vars map[string]string
+	require.NoError(t, json.Unmarshal([]byte(env), &vars))
+	require.Equal(t, "https://hub.example.com/api/v1/finetune_jobs/1/events", vars["FINETUNE_JOB_CALLBACK"])
+	require.Equal(t, token, vars["FINETUNE_JOB_TOKEN"])
+	require.Equal(t, "user1/dataset1", vars["DATASET_ID"])
+}
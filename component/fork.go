@@ -0,0 +1,301 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func (c *repoComponentImpl) Fork(ctx context.Context, req types.ForkRepoReq) (*types.ForkRepoResp, error) {
+	upstream, err := c.repo.FindByPath(ctx, req.RepoType, req.SourceNamespace, req.SourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find upstream repo, error: %w", err)
+	}
+	canRead, err := c.AllowReadAccessRepo(ctx, upstream, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check upstream repo permission, error: %w", err)
+	}
+	if !canRead {
+		return nil, ErrUnauthorized
+	}
+
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, errors.New("user does not exist")
+	}
+	if req.Namespace == "" {
+		req.Namespace = user.Username
+	}
+	if req.Name == "" {
+		req.Name = req.SourceName
+	}
+	if req.Namespace == req.SourceNamespace && req.Name == req.SourceName {
+		return nil, errors.New("can not fork a repository to itself")
+	}
+	namespace, err := c.namespace.FindByPath(ctx, req.Namespace)
+	if err != nil {
+		return nil, errors.New("namespace does not exist")
+	}
+	err = c.checkCreateRepoPermission(ctx, user, &namespace, req.RepoType)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := c.repo.Exists(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check repo existence, error: %w", err)
+	}
+	if exists {
+		return nil, ErrAlreadyExists
+	}
+	// a fork of private repository can not be public
+	private := req.Private || upstream.Private
+
+	gitRepo, err := c.git.ForkRepo(ctx, gitserver.ForkRepoReq{
+		SourceNamespace: req.SourceNamespace,
+		SourceName:      req.SourceName,
+		Namespace:       req.Namespace,
+		Name:            req.Name,
+		Username:        user.Username,
+		Description:     upstream.Description,
+		DefaultBranch:   upstream.DefaultBranch,
+		RepoType:        req.RepoType,
+		Private:         private,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to fork repo in git, error: %w", err)
+	}
+
+	fork, err := c.repo.CreateRepo(ctx, database.Repository{
+		UserID:         user.ID,
+		Path:           path.Join(req.Namespace, req.Name),
+		GitPath:        gitRepo.GitPath,
+		Name:           req.Name,
+		Nickname:       upstream.Nickname,
+		Description:    upstream.Description,
+		Private:        private,
+		License:        upstream.License,
+		DefaultBranch:  upstream.DefaultBranch,
+		RepositoryType: req.RepoType,
+		HTTPCloneURL:   gitRepo.HttpCloneURL,
+		SSHCloneURL:    gitRepo.SshCloneURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to create database repo, error: %w", err)
+	}
+	err = c.createForkedTypedRepo(ctx, upstream, fork)
+	if err != nil {
+		return nil, err
+	}
+
+	// lfs objects are stored by oid, the fork shares them with upstream instead of uploading again
+	lfsObjects, err := c.lfsMetaObjectStore.FindByRepoID(ctx, upstream.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lfs objects of upstream repo, error: %w", err)
+	}
+	for i := range lfsObjects {
+		lfsObjects[i].ID = 0
+		lfsObjects[i].RepositoryID = fork.ID
+		lfsObjects[i].Repository = database.Repository{}
+	}
+	err = c.lfsMetaObjectStore.BulkUpdateOrCreate(ctx, lfsObjects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy lfs objects of upstream repo, error: %w", err)
+	}
+
+	err = c.rel.SetForkParent(ctx, fork.ID, upstream.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record upstream of forked repo, error: %w", err)
+	}
+
+	return &types.ForkRepoResp{
+		Path:          fork.Path,
+		Upstream:      upstream.Path,
+		RepoType:      fork.RepositoryType,
+		DefaultBranch: fork.DefaultBranch,
+		Private:       fork.Private,
+		HTTPCloneURL:  fork.HTTPCloneURL,
+		SSHCloneURL:   fork.SSHCloneURL,
+	}, nil
+}
+
+func (c *repoComponentImpl) ForkStatus(ctx context.Context, req types.ForkStatusReq) (*types.ForkStatus, error) {
+	fork, upstream, err := c.findForkAndUpstream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	canRead, err := c.AllowReadAccessRepo(ctx, fork, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check repo permission, error: %w", err)
+	}
+	if !canRead {
+		return nil, ErrUnauthorized
+	}
+	status, _, err := c.forkStatus(ctx, fork, upstream, req.Branch)
+	return status, err
+}
+
+func (c *repoComponentImpl) SyncFork(ctx context.Context, req types.ForkStatusReq) (*types.ForkStatus, error) {
+	fork, upstream, err := c.findForkAndUpstream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	canWrite, err := c.AllowWriteAccess(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check repo permission, error: %w", err)
+	}
+	if !canWrite {
+		return nil, ErrForbidden
+	}
+	canRead, err := c.AllowReadAccessRepo(ctx, upstream, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check upstream repo permission, error: %w", err)
+	}
+	if !canRead {
+		return nil, ErrUnauthorized
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, errors.New("user does not exist")
+	}
+
+	status, compareReq, err := c.forkStatus(ctx, fork, upstream, req.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if status.Behind == 0 {
+		return status, nil
+	}
+	strategy := types.MergeStrategyFastForward
+	if status.Ahead > 0 {
+		strategy = types.MergeStrategyMerge
+	}
+	_, err = c.git.MergeBranch(ctx, gitserver.MergeBranchReq{
+		CompareReq: compareReq,
+		Strategy:   strategy,
+		Message:    fmt.Sprintf("Merge branch '%s' of %s", status.UpstreamBranch, status.Upstream),
+		Username:   user.Username,
+		Email:      user.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync from upstream, error: %w", err)
+	}
+	err = c.repo.SetUpdateTimeByPath(ctx, fork.RepositoryType, req.Namespace, req.Name, time.Now())
+	if err != nil {
+		slog.Error("failed to update repo update time after syncing from upstream", slog.String("path", fork.Path), slog.Any("error", err))
+	}
+	status, _, err = c.forkStatus(ctx, fork, upstream, req.Branch)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (c *repoComponentImpl) findForkAndUpstream(ctx context.Context, req types.ForkStatusReq) (*database.Repository, *database.Repository, error) {
+	fork, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	relation, err := c.rel.ForkParent(ctx, fork.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to find upstream of repo, error: %w", err)
+	}
+	upstream, err := c.repo.FindById(ctx, relation.ToRepoID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find upstream repo, error: %w", err)
+	}
+	return fork, upstream, nil
+}
+
+func (c *repoComponentImpl) forkStatus(ctx context.Context, fork, upstream *database.Repository, branch string) (*types.ForkStatus, gitserver.CompareReq, error) {
+	upstreamBranch := branch
+	if branch == "" {
+		branch = fork.DefaultBranch
+		upstreamBranch = upstream.DefaultBranch
+	}
+	namespace, name := fork.NamespaceAndName()
+	upstreamNamespace, upstreamName := upstream.NamespaceAndName()
+	// upstream is the head to be merged into the fork
+	compareReq := gitserver.CompareReq{
+		Namespace:     namespace,
+		Name:          name,
+		RepoType:      fork.RepositoryType,
+		BaseRef:       branch,
+		HeadNamespace: upstreamNamespace,
+		HeadName:      upstreamName,
+		HeadRef:       upstreamBranch,
+		FetchRef:      "refs/upstream/" + upstreamBranch,
+	}
+	upstreamOnly, forkOnly, err := c.git.CountDivergingCommits(ctx, compareReq)
+	if err != nil {
+		return nil, compareReq, fmt.Errorf("failed to compare with upstream, error: %w", err)
+	}
+	return &types.ForkStatus{
+		Upstream:       upstream.Path,
+		UpstreamBranch: upstreamBranch,
+		Branch:         branch,
+		Behind:         upstreamOnly,
+		Ahead:          forkOnly,
+	}, compareReq, nil
+}
+
+// createForkedTypedRepo creates the model, dataset, code or space of the forked repository
+func (c *repoComponentImpl) createForkedTypedRepo(ctx context.Context, upstream, fork *database.Repository) error {
+	var err error
+	switch fork.RepositoryType {
+	case types.ModelRepo:
+		model := database.Model{
+			Repository:    fork,
+			RepositoryID:  fork.ID,
+			LastUpdatedAt: time.Now(),
+		}
+		if upstreamModel, err := c.modelStore.ByRepoID(ctx, upstream.ID); err == nil {
+			model.BaseModel = upstreamModel.BaseModel
+		}
+		_, err = c.modelStore.Create(ctx, model)
+	case types.DatasetRepo:
+		_, err = c.datasetStore.Create(ctx, database.Dataset{
+			Repository:    fork,
+			RepositoryID:  fork.ID,
+			LastUpdatedAt: time.Now(),
+		})
+	case types.CodeRepo:
+		_, err = c.codeStore.Create(ctx, database.Code{
+			Repository:    fork,
+			RepositoryID:  fork.ID,
+			LastUpdatedAt: time.Now(),
+		})
+	case types.SpaceRepo:
+		upstreamSpace, findErr := c.spaceStore.ByRepoID(ctx, upstream.ID)
+		if findErr != nil {
+			return fmt.Errorf("failed to find upstream space, error: %w", findErr)
+		}
+		// secrets belong to the upstream owner and are not copied
+		_, err = c.spaceStore.Create(ctx, database.Space{
+			Repository:    fork,
+			RepositoryID:  fork.ID,
+			Sdk:           upstreamSpace.Sdk,
+			SdkVersion:    upstreamSpace.SdkVersion,
+			Template:      upstreamSpace.Template,
+			CoverImageUrl: upstreamSpace.CoverImageUrl,
+			Env:           upstreamSpace.Env,
+			Hardware:      upstreamSpace.Hardware,
+			HasAppFile:    upstreamSpace.HasAppFile,
+			SKU:           upstreamSpace.SKU,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create forked %s, error: %w", fork.RepositoryType, err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package component
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"database/sql"
@@ -13,6 +14,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"slices"
 	"strconv"
 	"time"
 
@@ -28,7 +30,6 @@ import (
 type gitHTTPComponentImpl struct {
 	git                gitserver.GitServer
 	config             *config.Config
-	s3Client           *s3.Client
 	lfsMetaObjectStore database.LfsMetaObjectStore
 	lfsLockStore       database.LfsLockStore
 	repo               database.RepoStore
@@ -41,7 +42,11 @@ type GitHTTPComponent interface {
 	GitReceivePack(ctx context.Context, req types.GitReceivePackReq) error
 	BuildObjectResponse(ctx context.Context, req types.BatchRequest, isUpload bool) (*types.BatchResponse, error)
 	LfsUpload(ctx context.Context, body io.ReadCloser, req types.UploadRequest) error
-	LfsVerify(ctx context.Context, req types.VerifyRequest, p types.Pointer) error
+	// LfsVerify completes the upload of the object and checks its size and checksum, the request to check the content
+	// in background is returned if the checksum is not stored by s3
+	LfsVerify(ctx context.Context, req types.VerifyRequest, p types.Pointer) (*types.VerifyLfsObjectContentReq, error)
+	// VerifyLfsObjectContent reads the object to check its content matches the oid, objects not matching are removed
+	VerifyLfsObjectContent(ctx context.Context, req types.VerifyLfsObjectContentReq) error
 	CreateLock(ctx context.Context, req types.LfsLockReq) (*database.LfsLock, error)
 	ListLocks(ctx context.Context, req types.ListLFSLockReq) (*types.LFSLockList, error)
 	UnLock(ctx context.Context, req types.UnlockLFSReq) (*database.LfsLock, error)
@@ -59,12 +64,6 @@ func NewGitHTTPComponent(config *config.Config) (GitHTTPComponent, error) {
 		slog.Error(newError.Error())
 		return nil, newError
 	}
-	c.s3Client, err = s3.NewMinio(config)
-	if err != nil {
-		newError := fmt.Errorf("fail to init s3 client for code,error:%w", err)
-		slog.Error(newError.Error())
-		return nil, newError
-	}
 	c.lfsMetaObjectStore = database.NewLfsMetaObjectStore()
 	c.repo = database.NewRepoStore()
 	c.lfsLockStore = database.NewLfsLockStore()
@@ -140,7 +139,7 @@ func (c *gitHTTPComponentImpl) GitUploadPack(ctx context.Context, req types.GitU
 }
 
 func (c *gitHTTPComponentImpl) GitReceivePack(ctx context.Context, req types.GitReceivePackReq) error {
-	_, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
 	if err != nil {
 		return fmt.Errorf("failed to find repo, error: %w", err)
 	}
@@ -157,6 +156,21 @@ func (c *gitHTTPComponentImpl) GitReceivePack(ctx context.Context, req types.Git
 	if !allowed {
 		return ErrForbidden
 	}
+
+	changes, consumed, err := readReceivePackCommands(req.Request.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read receive-pack commands, error: %w", err)
+	}
+	err = c.checkBranchProtection(ctx, repo, user.Username, changes, nil)
+	if err != nil {
+		return err
+	}
+	// put the commands back to the request body for git server
+	req.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(consumed), req.Request.Body), req.Request.Body}
+
 	err = c.git.ReceivePack(ctx, gitserver.ReceivePackReq{
 		Namespace:   req.Namespace,
 		Name:        req.Name,
@@ -183,14 +197,27 @@ func (c *gitHTTPComponentImpl) BuildObjectResponse(ctx context.Context, req type
 
 	for _, obj := range req.Objects {
 		if !obj.Valid() {
-			respObjects = append(respObjects, c.buildObjectResponse(ctx, req, obj, false, false, &types.ObjectError{
+			respObjects = append(respObjects, c.buildObjectResponse(ctx, req, obj, nil, false, false, &types.ObjectError{
 				Code:    http.StatusUnprocessableEntity,
 				Message: "Oid or size are invalid",
 			}))
 			continue
 		}
 		objectKey := path.Join("lfs", obj.RelativePath())
-		_, err := c.s3Client.StatObject(ctx, c.config.S3.Bucket, objectKey, minio.StatObjectOptions{})
+		lfsMetaObject, err := c.lfsMetaObjectStore.FindByOID(ctx, repo.ID, obj.Oid)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to check if lfs file exists in database", slog.String("oid", objectKey), slog.Any("error", err))
+			return nil, err
+		}
+
+		// objects of the repository stay in the storage they were uploaded to until migrated,
+		// new objects go to the storage the namespace is routed to
+		storage, err := c.lfsRouter.ForObject(ctx, repo, obj.Oid)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = storage.Client.StatObject(ctx, storage.Bucket, objectKey, minio.StatObjectOptions{})
 		if err != nil {
 			if os.IsNotExist(err) {
 				exists = false
@@ -201,14 +228,8 @@ func (c *gitHTTPComponentImpl) BuildObjectResponse(ctx context.Context, req type
 			exists = true
 		}
 
-		lfsMetaObject, err := c.lfsMetaObjectStore.FindByOID(ctx, repo.ID, obj.Oid)
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
-			slog.Error("failed to check if lfs file exists in database", slog.String("oid", objectKey), slog.Any("error", err))
-			return nil, err
-		}
-
 		if lfsMetaObject != nil && obj.Size != lfsMetaObject.Size {
-			respObjects = append(respObjects, c.buildObjectResponse(ctx, req, obj, false, false, &types.ObjectError{
+			respObjects = append(respObjects, c.buildObjectResponse(ctx, req, obj, nil, false, false, &types.ObjectError{
 				Code:    http.StatusUnprocessableEntity,
 				Message: fmt.Sprintf("Object %s is not %d bytes", obj.Oid, obj.Size),
 			}))
@@ -237,6 +258,7 @@ func (c *gitHTTPComponentImpl) BuildObjectResponse(ctx context.Context, req type
 						Size:         obj.Size,
 						RepositoryID: repo.ID,
 						Existing:     true,
+						StorageID:    storage.ID,
 					})
 					if err != nil {
 						slog.Error("Unable to create LFS MetaObject [%s] for %s/%s. Error: %v", obj.Oid, req.Namespace, req.Name, err)
@@ -247,7 +269,7 @@ func (c *gitHTTPComponentImpl) BuildObjectResponse(ctx context.Context, req type
 				}
 			}
 
-			responseObject = c.buildObjectResponse(ctx, req, obj, false, !exists, err)
+			responseObject = c.buildObjectResponse(ctx, req, obj, storage, false, !exists, err)
 		} else {
 			var err *types.ObjectError
 			// if !exists || lfsMetaObject == nil {
@@ -257,15 +279,22 @@ func (c *gitHTTPComponentImpl) BuildObjectResponse(ctx context.Context, req type
 			// 	}
 			// }
 
-			responseObject = c.buildObjectResponse(ctx, req, obj, true, false, err)
+			responseObject = c.buildObjectResponse(ctx, req, obj, storage, true, false, err)
 		}
 		respObjects = append(respObjects, responseObject)
 	}
 	respobj := &types.BatchResponse{Objects: respObjects}
+	// multipart transfer is advertised only if some objects are uploaded in parts, objects without parts in
+	// the upload action are uploaded in one request like basic transfer
+	if isUpload && slices.ContainsFunc(respObjects, func(o *types.ObjectResponse) bool {
+		return isMultipartUpload(o.Actions["upload"])
+	}) {
+		respobj.Transfer = types.LfsTransferMultipart
+	}
 	return respobj, nil
 }
 
-func (c *gitHTTPComponentImpl) buildObjectResponse(ctx context.Context, req types.BatchRequest, pointer types.Pointer, download, upload bool, err *types.ObjectError) *types.ObjectResponse {
+func (c *gitHTTPComponentImpl) buildObjectResponse(ctx context.Context, req types.BatchRequest, pointer types.Pointer, storage *s3.LfsStorage, download, upload bool, err *types.ObjectError) *types.ObjectResponse {
 	rep := &types.ObjectResponse{Pointer: pointer}
 	if err != nil {
 		rep.Error = err
@@ -282,7 +311,7 @@ func (c *gitHTTPComponentImpl) buildObjectResponse(ctx context.Context, req type
 			var link *types.Link
 			reqParams := make(url.Values)
 			objectKey := path.Join("lfs", pointer.RelativePath())
-			url, err := c.s3Client.PresignedGetObject(ctx, c.config.S3.Bucket, objectKey, ossFileExpireSeconds, reqParams)
+			url, err := storage.Client.PresignedGetObject(ctx, storage.Bucket, objectKey, ossFileExpireSeconds, reqParams)
 			if url != nil && err == nil {
 				delete(header, "Authorization")
 				link = &types.Link{Href: url.String(), Header: header}
@@ -293,7 +322,7 @@ func (c *gitHTTPComponentImpl) buildObjectResponse(ctx context.Context, req type
 			rep.Actions["download"] = link
 		}
 		if upload {
-			rep.Actions["upload"] = &types.Link{Href: c.buildUploadLink(req, pointer), Header: header}
+			rep.Actions["upload"] = c.buildUploadAction(ctx, req, pointer, storage, header)
 
 			verifyHeader := make(map[string]string)
 			for key, value := range header {
@@ -322,8 +351,12 @@ func (c *gitHTTPComponentImpl) LfsUpload(ctx context.Context, body io.ReadCloser
 		return errors.New("invalid lfs oid")
 	}
 
+	storage, err := c.lfsRouter.ForNamespace(ctx, req.Namespace)
+	if err != nil {
+		return err
+	}
 	objectKey := path.Join("lfs", pointer.RelativePath())
-	_, err = c.s3Client.StatObject(ctx, c.config.S3.Bucket, objectKey, minio.StatObjectOptions{})
+	_, err = storage.Client.StatObject(ctx, storage.Bucket, objectKey, minio.StatObjectOptions{})
 	if err != nil {
 		if os.IsNotExist(err) {
 			exists = false
@@ -362,9 +395,9 @@ func (c *gitHTTPComponentImpl) LfsUpload(ctx context.Context, body io.ReadCloser
 				uploadErr  error
 				uploadInfo minio.UploadInfo
 			)
-			uploadInfo, uploadErr = c.s3Client.PutObject(
+			uploadInfo, uploadErr = storage.Client.PutObject(
 				ctx,
-				c.config.S3.Bucket,
+				storage.Bucket,
 				objectKey,
 				body,
 				req.Size,
@@ -381,9 +414,9 @@ func (c *gitHTTPComponentImpl) LfsUpload(ctx context.Context, body io.ReadCloser
 				uploadErr = types.ErrSizeMismatch
 			}
 			if uploadErr != nil {
-				err := c.s3Client.RemoveObject(
+				err := storage.Client.RemoveObject(
 					ctx,
-					c.config.S3.Bucket,
+					storage.Bucket,
 					objectKey,
 					minio.RemoveObjectOptions{},
 				)
@@ -397,6 +430,7 @@ func (c *gitHTTPComponentImpl) LfsUpload(ctx context.Context, body io.ReadCloser
 			Size:         pointer.Size,
 			RepositoryID: repo.ID,
 			Existing:     true,
+			StorageID:    storage.ID,
 		})
 		return err
 	}
@@ -416,37 +450,112 @@ func (c *gitHTTPComponentImpl) LfsUpload(ctx context.Context, body io.ReadCloser
 	return nil
 }
 
-func (c *gitHTTPComponentImpl) LfsVerify(ctx context.Context, req types.VerifyRequest, p types.Pointer) error {
+func (c *gitHTTPComponentImpl) LfsVerify(ctx context.Context, req types.VerifyRequest, p types.Pointer) (*types.VerifyLfsObjectContentReq, error) {
 	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
 	if err != nil {
-		return fmt.Errorf("failed to find repo, error: %w", err)
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+
+	if !p.Valid() {
+		return nil, errors.New("invalid lfs oid")
 	}
 
-	// _, err = c.lfsMetaObjectStore.FindByOID(ctx, repo.ID, p.Oid)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to find lfs meta object, error: %w", err)
-	// }
+	allowed, err := c.AllowWriteAccess(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user write access, error: %w", err)
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
+	// the same storage as the upload link of the object in batch response
+	storage, err := c.lfsRouter.ForObject(ctx, repo, p.Oid)
+	if err != nil {
+		return nil, err
+	}
 	objectKey := path.Join("lfs", p.RelativePath())
-	fileInfo, err := c.s3Client.StatObject(ctx, c.config.S3.Bucket, objectKey, minio.StatObjectOptions{})
+	// complete the multipart upload of the object before checking it, clients post to the verify link to complete it
+	uploadID, err := storage.Client.FindMultipartUpload(ctx, storage.Bucket, objectKey)
+	if err != nil {
+		return nil, err
+	}
+	if uploadID != "" {
+		_, err = storage.Client.CompleteMultipartUpload(ctx, storage.Bucket, objectKey, uploadID)
+		if err != nil {
+			slog.Error("failed to complete multipart upload", slog.String("oid", p.Oid), slog.Any("error", err))
+			return nil, err
+		}
+	}
+	fileInfo, err := storage.Client.StatObject(ctx, storage.Bucket, objectKey, minio.StatObjectOptions{})
 	if err != nil {
 		slog.Error("failed to stat object in s3", slog.Any("error", err))
-		return fmt.Errorf("failed to stat object in s3, error: %w", err)
+		return nil, fmt.Errorf("failed to stat object in s3, error: %w", err)
 	}
 
 	if fileInfo.Size != p.Size {
-		return types.ErrSizeMismatch
+		return nil, types.ErrSizeMismatch
+	}
+
+	// objects uploaded to s3 directly bypass the api server, make sure the content matches the oid. The checksum
+	// of objects uploaded to presigned urls is checked by s3, other objects are read in background to check
+	sha, err := storage.Client.StoredObjectSHA256(ctx, storage.Bucket, objectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sha256 of object in s3, error: %w", err)
+	}
+	if sha != "" && sha != p.Oid {
+		err = storage.Client.RemoveObject(ctx, storage.Bucket, objectKey, minio.RemoveObjectOptions{})
+		if err != nil {
+			slog.Error("failed to remove lfs object not matching its oid", slog.String("oid", p.Oid), slog.Any("error", err))
+		}
+		return nil, types.ErrHashMismatch
 	}
 
-	_, err = c.lfsMetaObjectStore.Create(ctx, database.LfsMetaObject{
+	_, err = c.lfsMetaObjectStore.UpdateOrCreate(ctx, database.LfsMetaObject{
 		Oid:          p.Oid,
-		Size:         p.Size,
+		Size:         fileInfo.Size,
 		RepositoryID: repo.ID,
 		Existing:     true,
+		StorageID:    storage.ID,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create lfs meta object in database: %w", err)
+		return nil, fmt.Errorf("failed to create lfs meta object in database: %w", err)
 	}
 
+	if sha == "" {
+		return &types.VerifyLfsObjectContentReq{Oid: p.Oid, StorageID: storage.ID}, nil
+	}
+	return nil, nil
+}
+
+func (c *gitHTTPComponentImpl) VerifyLfsObjectContent(ctx context.Context, req types.VerifyLfsObjectContentReq) error {
+	storage, err := c.lfsRouter.ByID(ctx, req.StorageID)
+	if err != nil {
+		return err
+	}
+	pointer := types.Pointer{Oid: req.Oid}
+	objectKey := path.Join("lfs", pointer.RelativePath())
+	sha, err := storage.Client.ObjectSHA256(ctx, storage.Bucket, objectKey)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil
+		}
+		return fmt.Errorf("failed to calculate sha256 of object in s3, error: %w", err)
+	}
+	if sha == req.Oid {
+		return nil
+	}
+
+	slog.Warn("remove lfs object not matching its oid", slog.String("oid", req.Oid), slog.String("sha256", sha),
+		slog.String("storage", storage.Name))
+	err = storage.Client.RemoveObject(ctx, storage.Bucket, objectKey, minio.RemoveObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to remove lfs object not matching its oid, error: %w", err)
+	}
+	// the object may be linked to other repositories by the batch api after uploaded
+	err = c.lfsMetaObjectStore.RemoveByOidAndStorage(ctx, req.Oid, storage.ID)
+	if err != nil {
+		return fmt.Errorf("failed to remove lfs meta objects of object not matching its oid, error: %w", err)
+	}
 	return nil
 }
 
@@ -672,10 +781,14 @@ func (c *gitHTTPComponentImpl) LfsDownload(ctx context.Context, req types.Downlo
 		return nil, errors.New("you have no permission to access this repo")
 	}
 
-	_, err = c.lfsMetaObjectStore.FindByOID(ctx, repo.ID, pointer.Oid)
+	lfsMetaObject, err := c.lfsMetaObjectStore.FindByOID(ctx, repo.ID, pointer.Oid)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find lfs meta object, error: %w", err)
 	}
+	storage, err := c.lfsRouter.ByID(ctx, lfsMetaObject.StorageID)
+	if err != nil {
+		return nil, err
+	}
 	objectKey := path.Join("lfs", pointer.RelativePath())
 
 	reqParams := make(url.Values)
@@ -683,7 +796,7 @@ func (c *gitHTTPComponentImpl) LfsDownload(ctx context.Context, req types.Downlo
 		// allow rename when download through content-disposition header
 		reqParams.Set("response-content-disposition", fmt.Sprintf("attachment;filename=%s", req.SaveAs))
 	}
-	signedUrl, err := c.s3Client.PresignedGetObject(ctx, c.config.S3.Bucket, objectKey, ossFileExpireSeconds, reqParams)
+	signedUrl, err := storage.Client.PresignedGetObject(ctx, storage.Bucket, objectKey, ossFileExpireSeconds, reqParams)
 	if err != nil {
 		return nil, err
 	}
@@ -698,13 +811,88 @@ func (c *gitHTTPComponentImpl) buildDownloadLink(req types.BatchRequest, pointer
 // 	return c.config.APIServer.PublicDomain + "/" + path.Join(fmt.Sprintf("%ss", req.RepoType), url.PathEscape(req.Namespace), url.PathEscape(req.Name+".git"), "info/lfs/objects", url.PathEscape(pointer.Oid), strconv.FormatInt(pointer.Size, 10))
 // }
 
-func (c *gitHTTPComponentImpl) buildUploadLink(req types.BatchRequest, pointer types.Pointer) string {
+// buildUploadLink returns the presigned url to upload the object to s3 directly if enabled,
+// otherwise the object is uploaded through the api server
+func (c *gitHTTPComponentImpl) buildUploadLink(ctx context.Context, req types.BatchRequest, pointer types.Pointer, storage *s3.LfsStorage, header map[string]string) *types.Link {
+	apiLink := &types.Link{
+		Href:   c.config.APIServer.PublicDomain + "/" + path.Join(fmt.Sprintf("%ss", req.RepoType), url.PathEscape(req.Namespace), url.PathEscape(req.Name+".git"), "info/lfs/objects", url.PathEscape(pointer.Oid), strconv.FormatInt(pointer.Size, 10)),
+		Header: header,
+	}
+	if !c.config.S3.LfsDirectUpload {
+		return apiLink
+	}
 	objectKey := path.Join("lfs", pointer.RelativePath())
-	u, err := c.s3Client.PresignedPutObject(context.Background(), c.config.S3.Bucket, objectKey, time.Hour*24)
+	u, signedHeader, err := storage.Client.PresignedPutLfsObject(ctx, storage.Bucket, objectKey, pointer.Oid, time.Hour*24)
 	if err != nil {
-		return c.config.APIServer.PublicDomain + "/" + path.Join(fmt.Sprintf("%ss", req.RepoType), url.PathEscape(req.Namespace), url.PathEscape(req.Name+".git"), "info/lfs/objects", url.PathEscape(pointer.Oid), strconv.FormatInt(pointer.Size, 10))
+		slog.Error("failed to presign lfs upload url, fallback to upload through api server", slog.String("oid", pointer.Oid), slog.Any("error", err))
+		return apiLink
+	}
+	// the presigned url carries the credential, s3 rejects requests with another authorization header
+	linkHeader := make(map[string]string)
+	for key, value := range header {
+		if key != "Authorization" {
+			linkHeader[key] = value
+		}
+	}
+	for key := range signedHeader {
+		linkHeader[key] = signedHeader.Get(key)
+	}
+	return &types.Link{Href: u.String(), Header: linkHeader}
+}
+
+// buildUploadAction returns the presigned urls of parts in header for large objects uploaded with multipart transfer,
+// posting to the href, which is the verify link, completes the upload
+func (c *gitHTTPComponentImpl) buildUploadAction(ctx context.Context, req types.BatchRequest, pointer types.Pointer, storage *s3.LfsStorage, header map[string]string) *types.Link {
+	basic := c.buildUploadLink(ctx, req, pointer, storage, header)
+	if !slices.Contains(req.Transfers, types.LfsTransferMultipart) || c.config.S3.LfsMultipartChunkSize <= 0 {
+		return basic
+	}
+	chunkSize := s3.MultipartChunkSize(pointer.Size, c.config.S3.LfsMultipartChunkSize)
+	if pointer.Size <= chunkSize {
+		return basic
+	}
+
+	objectKey := path.Join("lfs", pointer.RelativePath())
+	// an unfinished upload of the object is resumed, parts uploaded before are kept in s3
+	uploadID, err := storage.Client.FindOrCreateMultipartUpload(ctx, storage.Bucket, objectKey, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	if err != nil {
+		slog.Error("failed to start multipart upload, fallback to basic transfer", slog.String("oid", pointer.Oid), slog.Any("error", err))
+		return basic
+	}
+	uploaded, err := storage.Client.ListUploadedParts(ctx, storage.Bucket, objectKey, uploadID)
+	if err != nil {
+		slog.Error("failed to list uploaded parts of multipart upload, fallback to basic transfer", slog.String("oid", pointer.Oid), slog.Any("error", err))
+		return basic
+	}
+	// only the parts not uploaded yet are presigned when resuming the upload
+	missing := s3.MissingParts(pointer.Size, chunkSize, uploaded)
+	urls, err := storage.Client.PresignedPutObjectParts(ctx, storage.Bucket, objectKey, uploadID, missing, time.Hour*24)
+	if err != nil {
+		slog.Error("failed to presign parts of multipart upload, fallback to basic transfer", slog.String("oid", pointer.Oid), slog.Any("error", err))
+		return basic
+	}
+	partHeader := make(map[string]string, len(urls)+1)
+	partHeader[multipartChunkSizeHeader] = strconv.FormatInt(chunkSize, 10)
+	for i, u := range urls {
+		partHeader[fmt.Sprintf("%05d", i)] = u.String()
+	}
+	// the completion request carries no size, which is passed in the href to be verified
+	href := c.buildVerifyLink(req) + "?size=" + strconv.FormatInt(pointer.Size, 10)
+	return &types.Link{Href: href, Header: partHeader}
+}
+
+// the header of multipart upload action holding the size of parts
+const multipartChunkSizeHeader = "chunk_size"
+
+// isMultipartUpload returns whether the upload action is to upload the object in parts
+func isMultipartUpload(link *types.Link) bool {
+	if link == nil {
+		return false
 	}
-	return u.String()
+	_, ok := link.Header[multipartChunkSizeHeader]
+	return ok
 }
 
 func (c *gitHTTPComponentImpl) buildVerifyLink(req types.BatchRequest) string {
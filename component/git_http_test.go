@@ -0,0 +1,109 @@
+package component
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/store/s3"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// lfsObjectServer serves and deletes the lfs objects by path like s3 in path style
+func lfsObjectServer(t *testing.T, objects map[string]string) *s3.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := objects[r.URL.Path]
+		switch {
+		case r.Method == http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		case !ok:
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+		default:
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.Header().Set("ETag", `"etag"`)
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(content))
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client, err := s3.NewMinioWithOptions(s3.Options{
+		Endpoint:        strings.TrimPrefix(srv.URL, "http://"),
+		AccessKeyID:     "ak",
+		AccessKeySecret: "sk",
+		Region:          "us-east-1",
+	})
+	require.NoError(t, err)
+	return client
+}
+
+type singleLfsRouter struct {
+	s3.LfsRouter
+	storage *s3.LfsStorage
+}
+
+func (r *singleLfsRouter) ByID(ctx context.Context, id int64) (*s3.LfsStorage, error) {
+	return r.storage, nil
+}
+
+type removedLfsMetaObjectStore struct {
+	database.LfsMetaObjectStore
+	removed []string
+}
+
+func (s *removedLfsMetaObjectStore) RemoveByOidAndStorage(ctx context.Context, oid string, storageID int64) error {
+	s.removed = append(s.removed, oid)
+	return nil
+}
+
+func TestGitHTTPComponent_VerifyLfsObjectContent(t *testing.T) {
+	ctx := context.Background()
+	sum := sha256.Sum256([]byte("content"))
+	oid := hex.EncodeToString(sum[:])
+	badOid := strings.Repeat("a", 64)
+	pointer, badPointer := types.Pointer{Oid: oid}, types.Pointer{Oid: badOid}
+	objects := map[string]string{
+		"/lfs/lfs/" + pointer.RelativePath():    "content",
+		"/lfs/lfs/" + badPointer.RelativePath(): "content",
+	}
+	metaObjects := &removedLfsMetaObjectStore{}
+	c := &gitHTTPComponentImpl{
+		lfsMetaObjectStore: metaObjects,
+		repoComponentImpl: &repoComponentImpl{
+			lfsRouter: &singleLfsRouter{storage: &s3.LfsStorage{ID: 1, Name: "hot", Client: lfsObjectServer(t, objects), Bucket: "lfs"}},
+		},
+	}
+
+	err := c.VerifyLfsObjectContent(ctx, types.VerifyLfsObjectContentReq{Oid: oid, StorageID: 1})
+	require.NoError(t, err)
+	require.Contains(t, objects, "/lfs/lfs/"+pointer.RelativePath())
+	require.Empty(t, metaObjects.removed)
+
+	// objects not matching their oids are removed with the meta objects linking to them
+	err = c.VerifyLfsObjectContent(ctx, types.VerifyLfsObjectContentReq{Oid: badOid, StorageID: 1})
+	require.NoError(t, err)
+	require.NotContains(t, objects, "/lfs/lfs/"+badPointer.RelativePath())
+	require.Equal(t, []string{badOid}, metaObjects.removed)
+
+	// objects removed already are skipped
+	err = c.VerifyLfsObjectContent(ctx, types.VerifyLfsObjectContentReq{Oid: badOid, StorageID: 1})
+	require.NoError(t, err)
+}
+
+func TestIsMultipartUpload(t *testing.T) {
+	require.False(t, isMultipartUpload(nil))
+	require.False(t, isMultipartUpload(&types.Link{Href: "https://s3/lfs", Header: map[string]string{"X-Amz-Checksum-Sha256": "sum"}}))
+	// all the parts are uploaded when resuming
+	require.True(t, isMultipartUpload(&types.Link{Href: "https://hub/verify", Header: map[string]string{"chunk_size": "100"}}))
+}
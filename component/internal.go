@@ -2,6 +2,8 @@ package component
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -20,12 +22,15 @@ type internalComponentImpl struct {
 	config      *config.Config
 	sshKeyStore database.SSHKeyStore
 	repoStore   database.RepoStore
+	redirects   database.RepoRedirectStore
 	*repoComponentImpl
 }
 
 type InternalComponent interface {
 	Allowed(ctx context.Context) (bool, error)
 	SSHAllowed(ctx context.Context, req types.SSHAllowedReq) (*types.SSHAllowedResp, error)
+	// PushAllowed checks the ref changes sent by the pre-receive hook of http pushes against branch protection rules
+	PushAllowed(ctx context.Context, req types.SSHAllowedReq) error
 	GetAuthorizedKeys(ctx context.Context, key string) (*database.SSHKey, error)
 	GetCommitDiff(ctx context.Context, req types.GetDiffBetweenTwoCommitsReq) (*types.GiteaCallbackPushReq, error)
 	LfsAuthenticate(ctx context.Context, req types.LfsAuthenticateReq) (*types.LfsAuthenticateResp, error)
@@ -37,6 +42,7 @@ func NewInternalComponent(config *config.Config) (InternalComponent, error) {
 	c.config = config
 	c.sshKeyStore = database.NewSSHKeyStore()
 	c.repoStore = database.NewRepoStore()
+	c.redirects = database.NewRepoRedirectStore()
 	c.repoComponentImpl, err = NewRepoComponentImpl(config)
 	c.tokenStore = database.NewAccessTokenStore()
 	if err != nil {
@@ -50,14 +56,22 @@ func (c *internalComponentImpl) Allowed(ctx context.Context) (bool, error) {
 }
 
 func (c *internalComponentImpl) SSHAllowed(ctx context.Context, req types.SSHAllowedReq) (*types.SSHAllowedResp, error) {
-	namespace, err := c.namespace.FindByPath(ctx, req.Namespace)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find namespace %s: %v", req.Namespace, err)
-	}
 	repo, err := c.repoStore.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		// clone urls of a transferred repository keep working with its former path
+		redirect, redirectErr := c.redirects.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+		if redirectErr == nil {
+			repo, err = redirect.Repository, nil
+			req.Namespace, req.Name = repo.NamespaceAndName()
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find repo, err: %v", err)
 	}
+	namespace, err := c.namespace.FindByPath(ctx, req.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find namespace %s: %v", req.Namespace, err)
+	}
 	if repo == nil {
 		return nil, errors.New("repo not found")
 	}
@@ -77,6 +91,12 @@ func (c *internalComponentImpl) SSHAllowed(ctx context.Context, req types.SSHAll
 		if !allowed {
 			return nil, ErrForbidden
 		}
+		// changes are `_any` when gitlab-shell checks before the push, and the real ref updates
+		// when the pre-receive hook checks after the objects are received
+		err = c.checkHookChanges(ctx, repo, sshKey.User.Username, req)
+		if err != nil {
+			return nil, err
+		}
 	} else if req.Action == "git-upload-pack" {
 		if repo.Private {
 			allowed, err := c.AllowReadAccess(ctx, req.RepoType, req.Namespace, req.Name, sshKey.User.Username)
@@ -113,6 +133,37 @@ func (c *internalComponentImpl) SSHAllowed(ctx context.Context, req types.SSHAll
 	}, nil
 }
 
+func (c *internalComponentImpl) PushAllowed(ctx context.Context, req types.SSHAllowedReq) error {
+	repo, err := c.repoStore.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find repo, err: %v", err)
+	}
+	userID, err := strconv.Atoi(req.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to parse user ID, err: %v", err)
+	}
+	user, err := c.user.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find user by id, err: %v", err)
+	}
+	return c.checkHookChanges(ctx, repo, user.Username, req)
+}
+
+func (c *internalComponentImpl) checkHookChanges(ctx context.Context, repo *database.Repository, username string, req types.SSHAllowedReq) error {
+	changes := types.ParseRefChanges(req.Changes)
+	if len(changes) == 0 {
+		return nil
+	}
+	var objectEnv types.GitObjectDirectoryEnv
+	if req.Env != "" {
+		err := json.Unmarshal([]byte(req.Env), &objectEnv)
+		if err != nil {
+			return fmt.Errorf("failed to parse hook env, err: %v", err)
+		}
+	}
+	return c.checkBranchProtection(ctx, repo, username, changes, &objectEnv)
+}
+
 func (c *internalComponentImpl) GetAuthorizedKeys(ctx context.Context, key string) (*database.SSHKey, error) {
 	fingerprint, err := common.CalculateAuthorizedSSHKeyFingerprint(key)
 	if err != nil {
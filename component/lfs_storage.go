@@ -0,0 +1,10 @@
+package component
+
+import (
+	"strings"
+)
+
+// oidFromLfsRelativePath reverts types.Pointer.RelativePath
+func oidFromLfsRelativePath(relativePath string) string {
+	return strings.ReplaceAll(relativePath, "/", "")
+}
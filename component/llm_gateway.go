@@ -0,0 +1,160 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	deployCommon "opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type LLMGatewayComponent interface {
+	// ListModels returns the models which have an inference endpoint or serverless deploy the user can call
+	ListModels(ctx context.Context, currentUser string) ([]types.OpenAIModel, error)
+	// FindEndpoint returns the deploy to route the request of model to, the model is the path of
+	// the model repository. Running deploys go first, then the deploys of user self before serverless
+	// and the endpoints shared by others
+	FindEndpoint(ctx context.Context, currentUser, model string) (*database.Deploy, error)
+	// Target returns the base url of the deploy
+	Target(d *database.Deploy) string
+	RecordEndpointRequest(d *database.Deploy)
+	WakeupEndpoint(ctx context.Context, d *database.Deploy) error
+	PublishEndpointTokenUsage(ctx context.Context, currentUser string, d *database.Deploy, usage types.LLMUsage)
+}
+
+func NewLLMGatewayComponent(config *config.Config) (LLMGatewayComponent, error) {
+	c := &llmGatewayComponentImpl{}
+	var err error
+	c.repoComponentImpl, err = NewRepoComponentImpl(config)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type llmGatewayComponentImpl struct {
+	*repoComponentImpl
+}
+
+func (c *llmGatewayComponentImpl) ListModels(ctx context.Context, currentUser string) ([]types.OpenAIModel, error) {
+	deploys, err := c.deploy.ListServingEndpoints(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list serving endpoints, error: %w", err)
+	}
+	// the access of all endpoints is checked with the user found once
+	var user *database.User
+	if currentUser != "" {
+		u, err := c.user.FindByUsername(ctx, currentUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user %s, error: %w", currentUser, err)
+		}
+		user = &u
+	}
+	var repoIDs []int64
+	allowed := make(map[int64]*database.Deploy)
+	for i := range deploys {
+		d := &deploys[i]
+		if _, ok := allowed[d.RepoID]; ok {
+			continue
+		}
+		if endpointAllowed(d, user) {
+			allowed[d.RepoID] = d
+			repoIDs = append(repoIDs, d.RepoID)
+		}
+	}
+	if len(repoIDs) == 0 {
+		return []types.OpenAIModel{}, nil
+	}
+
+	repos, err := c.repo.FindByIds(ctx, repoIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find models of endpoints, error: %w", err)
+	}
+	models := make([]types.OpenAIModel, 0, len(repos))
+	for _, repo := range repos {
+		namespace, _ := repo.NamespaceAndName()
+		models = append(models, types.OpenAIModel{
+			ID:      repo.Path,
+			Object:  "model",
+			Created: allowed[repo.ID].CreatedAt.Unix(),
+			OwnedBy: namespace,
+		})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+func (c *llmGatewayComponentImpl) FindEndpoint(ctx context.Context, currentUser, model string) (*database.Deploy, error) {
+	namespace, name, ok := strings.Cut(model, "/")
+	if !ok || namespace == "" || name == "" {
+		return nil, fmt.Errorf("model %s: %w", model, ErrNotFound)
+	}
+	repo, err := c.repo.FindByPath(ctx, types.ModelRepo, namespace, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("model %s: %w", model, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find model %s, error: %w", model, err)
+	}
+	user, err := c.user.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user %s, error: %w", currentUser, err)
+	}
+	deploys, err := c.deploy.ListServingEndpoints(ctx, repo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list serving endpoints of model %s, error: %w", model, err)
+	}
+	sort.SliceStable(deploys, func(i, j int) bool {
+		ri, rj := endpointRank(&deploys[i], user.ID), endpointRank(&deploys[j], user.ID)
+		return ri < rj
+	})
+	for i := range deploys {
+		d := &deploys[i]
+		if endpointAllowed(d, &user) {
+			return d, nil
+		}
+	}
+	if len(deploys) > 0 {
+		return nil, fmt.Errorf("no endpoint of model %s can be called by %s: %w", model, currentUser, ErrForbidden)
+	}
+	return nil, fmt.Errorf("no endpoint of model %s: %w", model, ErrNotFound)
+}
+
+// endpointAllowed reports whether user can call the endpoint as AllowAccessEndpoint does, user is nil
+// for anonymous calls
+func endpointAllowed(d *database.Deploy, user *database.User) bool {
+	if d.SecureLevel == types.EndpointPublic {
+		return true
+	}
+	return user != nil && d.UserID == user.ID
+}
+
+// endpointRank orders the deploys of a model for routing, a smaller rank goes first
+func endpointRank(d *database.Deploy, userID int64) int {
+	rank := 0
+	if d.Status != deployCommon.Running {
+		rank += 10
+	}
+	switch {
+	case d.Type == types.InferenceType && d.UserID == userID:
+	case d.Type == types.ServerlessType:
+		rank += 1
+	default:
+		rank += 2
+	}
+	return rank
+}
+
+func (c *llmGatewayComponentImpl) Target(d *database.Deploy) string {
+	if d.Endpoint != "" {
+		//support multi-cluster
+		return d.Endpoint
+	}
+	return fmt.Sprintf("http://%s.%s", d.SvcName, c.config.Space.InternalRootDomain)
+}
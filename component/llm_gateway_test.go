@@ -0,0 +1,36 @@
+package component
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	deployCommon "opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestEndpointRank(t *testing.T) {
+	deploys := []database.Deploy{
+		{ID: 1, Type: types.InferenceType, UserID: 2, Status: deployCommon.Running},
+		{ID: 2, Type: types.InferenceType, UserID: 1, Status: deployCommon.Sleeping},
+		{ID: 3, Type: types.ServerlessType, UserID: 2, Status: deployCommon.Running},
+		{ID: 4, Type: types.InferenceType, UserID: 1, Status: deployCommon.Running},
+	}
+	sort.SliceStable(deploys, func(i, j int) bool {
+		return endpointRank(&deploys[i], 1) < endpointRank(&deploys[j], 1)
+	})
+	var ids []int64
+	for _, d := range deploys {
+		ids = append(ids, d.ID)
+	}
+	require.Equal(t, []int64{4, 3, 1, 2}, ids)
+}
+
+func TestEndpointAllowed(t *testing.T) {
+	user := &database.User{ID: 1}
+	require.True(t, endpointAllowed(&database.Deploy{SecureLevel: types.EndpointPublic, UserID: 2}, nil))
+	require.True(t, endpointAllowed(&database.Deploy{SecureLevel: types.EndpointPrivate, UserID: 1}, user))
+	require.False(t, endpointAllowed(&database.Deploy{SecureLevel: types.EndpointPrivate, UserID: 2}, user))
+	require.False(t, endpointAllowed(&database.Deploy{SecureLevel: types.EndpointPrivate, UserID: 1}, nil))
+}
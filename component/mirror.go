@@ -27,8 +27,7 @@ type mirrorComponentImpl struct {
 	saas               bool
 	repoComp           RepoComponent
 	git                gitserver.GitServer
-	s3Client           *s3.Client
-	lfsBucket          string
+	lfsRouter          s3.LfsRouter
 	modelStore         database.ModelStore
 	datasetStore       database.DatasetStore
 	codeStore          database.CodeStore
@@ -76,13 +75,12 @@ func NewMirrorComponent(config *config.Config) (MirrorComponent, error) {
 		slog.Error(newError.Error())
 		return nil, newError
 	}
-	c.s3Client, err = s3.NewMinio(config)
+	c.lfsRouter, err = s3.NewLfsRouter(config)
 	if err != nil {
-		newError := fmt.Errorf("fail to init s3 client for code,error:%w", err)
+		newError := fmt.Errorf("fail to init lfs router,error:%w", err)
 		slog.Error(newError.Error())
 		return nil, newError
 	}
-	c.lfsBucket = config.S3.Bucket
 	c.modelStore = database.NewModelStore()
 	c.datasetStore = database.NewDatasetStore()
 	c.codeStore = database.NewCodeStore()
@@ -580,7 +578,12 @@ func (c *mirrorComponentImpl) countMirrorProgress(ctx context.Context, mirror da
 	for _, f := range lfsFiles {
 		objectKey := f.LfsRelativePath
 		objectKey = path.Join("lfs", objectKey)
-		_, err := c.s3Client.StatObject(ctx, c.lfsBucket, objectKey, minio.GetObjectOptions{})
+		storage, err := c.lfsRouter.ForObject(ctx, mirror.Repository, oidFromLfsRelativePath(f.LfsRelativePath))
+		if err != nil {
+			slog.Error("fail to find lfs storage", slog.Int64("mirrorId", mirror.ID), slog.String("namespace", namespace), slog.String("name", name), slog.String("filename", f.Path), slog.String("error", err.Error()))
+			return 0, err
+		}
+		_, err = storage.Client.StatObject(ctx, storage.Bucket, objectKey, minio.GetObjectOptions{})
 		if err != nil {
 			if minio.ToErrorResponse(err).Code != "NoSuchKey" {
 				slog.Error("fail to check lfs file", slog.Int64("mirrorId", mirror.ID), slog.String("namespace", namespace), slog.String("name", name), slog.String("filename", f.Path), slog.String("error", err.Error()))
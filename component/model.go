@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
 
 	"opencsg.com/csghub-server/builder/deploy"
@@ -108,6 +109,7 @@ func NewModelComponent(config *config.Config) (ModelComponent, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.quota = newComputeQuotaChecker()
 	return c, nil
 }
 
@@ -124,6 +126,7 @@ type modelComponentImpl struct {
 	ts            database.TagStore
 	rac           RuntimeArchitectureComponent
 	ds            database.DatasetStore
+	quota         *computeQuotaChecker
 }
 
 func (c *modelComponentImpl) Index(ctx context.Context, filter *types.RepoFilter, per, page int) ([]types.Model, int, error) {
@@ -455,6 +458,10 @@ func (c *modelComponentImpl) Show(ctx context.Context, namespace, name, currentU
 	if len(finetunes) > 0 {
 		resModel.EnableFinetune = true
 	}
+	resModel.SecurityStatus, err = c.securityStatus(ctx, model.Repository, nil)
+	if err != nil {
+		slog.Error("failed to get security status of model", slog.String("path", model.Repository.Path), slog.Any("error", err))
+	}
 	return resModel, nil
 }
 
@@ -580,10 +587,55 @@ func (c *modelComponentImpl) SDKModelInfo(ctx context.Context, namespace, name,
 		Spaces:           spaceNames,
 		SafeTensors:      nil,
 	}
+	resModel.SecurityStatus, err = c.securityStatus(ctx, model.Repository, filePaths)
+	if err != nil {
+		slog.Error("failed to get security status of model", slog.String("path", model.Repository.Path), slog.Any("error", err))
+	}
 
 	return resModel, nil
 }
 
+// securityStatus summarizes the scan results of the weight files of model on the default branch, only the
+// files in paths are summarized if paths is not nil
+func (c *modelComponentImpl) securityStatus(ctx context.Context, repo *database.Repository, paths []string) (*types.ModelSecurityStatus, error) {
+	exts := append(slices.Clone(types.PickleFileExts), types.SafeWeightFileExts...)
+	files, err := c.repoFile.ListLatestChecksByExts(ctx, repo.ID, repo.DefaultBranch, exts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list check results of weight files, error: %w", err)
+	}
+	if paths != nil {
+		files = slices.DeleteFunc(files, func(f database.RepositoryFileWithCheck) bool {
+			return !slices.Contains(paths, f.Path)
+		})
+	}
+	return toModelSecurityStatus(files), nil
+}
+
+func toModelSecurityStatus(files []database.RepositoryFileWithCheck) *types.ModelSecurityStatus {
+	status := &types.ModelSecurityStatus{
+		ScansDone:       true,
+		FilesWithIssues: []types.SecurityFileIssue{},
+		UnverifiedFiles: []types.SecurityFileIssue{},
+	}
+	for _, f := range files {
+		switch f.Status {
+		case types.SensitiveCheckPending:
+			status.ScansDone = false
+		case types.SensitiveCheckUnsafe:
+			status.FilesWithIssues = append(status.FilesWithIssues, types.SecurityFileIssue{
+				Path:    f.Path,
+				Message: f.Message,
+			})
+		case types.SensitiveCheckException:
+			status.UnverifiedFiles = append(status.UnverifiedFiles, types.SecurityFileIssue{
+				Path:    f.Path,
+				Message: f.Message,
+			})
+		}
+	}
+	return status
+}
+
 func (c *modelComponentImpl) Relations(ctx context.Context, namespace, name, currentUser string) (*types.Relations, error) {
 	model, err := c.ms.FindByPath(ctx, namespace, name)
 	if err != nil {
@@ -925,6 +977,18 @@ func (c *modelComponentImpl) Deploy(ctx context.Context, deployReq types.DeployA
 		return -1, fmt.Errorf("fail to check resource, %w", err)
 	}
 
+	release, err := c.quota.Check(ctx, quotaDeploy{
+		user:          &user,
+		repoNamespace: deployReq.Namespace,
+		deployType:    deployReq.DeployType,
+		hardware:      hardware,
+		replicas:      req.MaxReplica,
+	})
+	if err != nil {
+		return -1, err
+	}
+	defer release()
+
 	// choose image
 	containerImg := frame.FrameCpuImage
 	if hardware.Gpu.Num != "" {
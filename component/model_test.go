@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/common/tests"
 	"opencsg.com/csghub-server/common/types"
 )
@@ -32,3 +34,30 @@ func TestSetRelationDatasetsAndPrompts(t *testing.T) {
 		t.Errorf("failed to set relation datasets: %v", err)
 	}
 }
+
+func TestToModelSecurityStatus(t *testing.T) {
+	status := toModelSecurityStatus([]database.RepositoryFileWithCheck{
+		{Path: "model.safetensors", Status: types.SensitiveCheckPass},
+		{Path: "pytorch_model.bin", Status: types.SensitiveCheckUnsafe, Message: "dangerous imports in pickle: os.system"},
+	})
+	require.True(t, status.ScansDone)
+	require.Equal(t, []types.SecurityFileIssue{
+		{Path: "pytorch_model.bin", Message: "dangerous imports in pickle: os.system"},
+	}, status.FilesWithIssues)
+
+	status = toModelSecurityStatus([]database.RepositoryFileWithCheck{
+		{Path: "model.safetensors", Status: types.SensitiveCheckPending},
+	})
+	require.False(t, status.ScansDone)
+	require.Empty(t, status.FilesWithIssues)
+
+	status = toModelSecurityStatus([]database.RepositoryFileWithCheck{
+		{Path: "model.safetensors", Status: types.SensitiveCheckPass},
+		{Path: "pytorch_model.bin", Status: types.SensitiveCheckException, Message: "invalid pickle"},
+	})
+	require.True(t, status.ScansDone)
+	require.Empty(t, status.FilesWithIssues)
+	require.Equal(t, []types.SecurityFileIssue{
+		{Path: "pytorch_model.bin", Message: "invalid pickle"},
+	}, status.UnverifiedFiles)
+}
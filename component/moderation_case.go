@@ -0,0 +1,304 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/webhook"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// ModerationCaseComponent handles the moderation cases opened for repository files failing the sensitive check.
+// Owners of repository can see why a file is reported and appeal the case, moderators decide the cases in the
+// review queue. Admins of the site are the moderators
+type ModerationCaseComponent interface {
+	Index(ctx context.Context, req types.ModerationCasesReq) ([]types.ModerationCase, int, error)
+	// Show returns the case with its audit log
+	Show(ctx context.Context, currentUser string, id int64) (*types.ModerationCase, error)
+	Appeal(ctx context.Context, req types.AppealModerationCaseReq) (*types.ModerationCase, error)
+	// Decide takes the decision of moderator on the case, the repository is made public again once all of
+	// its cases are cleared if it was public before
+	Decide(ctx context.Context, req types.DecideModerationCaseReq) (*types.ModerationCase, error)
+}
+
+func NewModerationCaseComponent(config *config.Config) (ModerationCaseComponent, error) {
+	c := &moderationCaseComponentImpl{}
+	var err error
+	c.repoComponentImpl, err = NewRepoComponentImpl(config)
+	if err != nil {
+		return nil, err
+	}
+	c.caseStore = database.NewModerationCaseStore()
+	c.fileCheckStore = database.NewRepoFileCheckStore()
+	c.webhookNotifier = webhook.NewNotifier()
+	return c, nil
+}
+
+type moderationCaseComponentImpl struct {
+	*repoComponentImpl
+	caseStore       database.ModerationCaseStore
+	fileCheckStore  database.RepoFileCheckStore
+	webhookNotifier webhook.Notifier
+}
+
+func (c *moderationCaseComponentImpl) Index(ctx context.Context, req types.ModerationCasesReq) ([]types.ModerationCase, int, error) {
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, 0, ErrUserNotFound
+	}
+	var repoID int64
+	if req.Name != "" {
+		repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, ErrNotFound
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to find repo, error: %w", err)
+		}
+		if err := c.checkCaseAccess(ctx, &user, repo); err != nil {
+			return nil, 0, err
+		}
+		repoID = repo.ID
+	} else if !user.CanAdmin() {
+		return nil, 0, ErrForbidden
+	}
+
+	cases, total, err := c.caseStore.Index(ctx, repoID, req.Status, req.Per, req.Page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list moderation cases, error: %w", err)
+	}
+	resp := make([]types.ModerationCase, 0, len(cases))
+	for _, mc := range cases {
+		resp = append(resp, toModerationCase(&mc))
+	}
+	return resp, total, nil
+}
+
+func (c *moderationCaseComponentImpl) Show(ctx context.Context, currentUser string, id int64) (*types.ModerationCase, error) {
+	_, mc, err := c.findCase(ctx, currentUser, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.withLogs(ctx, mc)
+}
+
+func (c *moderationCaseComponentImpl) Appeal(ctx context.Context, req types.AppealModerationCaseReq) (*types.ModerationCase, error) {
+	_, mc, err := c.findCase(ctx, req.CurrentUser, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, mc.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	// moderators can not appeal the cases of repositories they don't own
+	if !permission.CanAdmin {
+		return nil, ErrForbidden
+	}
+
+	from := mc.Status
+	next, ok := from.Transit(types.ModerationActionAppeal)
+	if !ok {
+		return nil, fmt.Errorf("moderation case in status %s can not be appealed: %w", from, ErrBadRequest)
+	}
+	mc.Status = next
+	mc.AppealReason = req.Reason
+	mc.AppealedBy = req.CurrentUser
+	mc.AppealedAt = time.Now()
+	err = c.transit(ctx, mc, from, types.ModerationActionAppeal, req.CurrentUser, req.Reason,
+		"appeal_reason", "appealed_by", "appealed_at")
+	if err != nil {
+		return nil, err
+	}
+	return c.withLogs(ctx, mc)
+}
+
+func (c *moderationCaseComponentImpl) Decide(ctx context.Context, req types.DecideModerationCaseReq) (*types.ModerationCase, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", err.Error(), ErrBadRequest)
+	}
+	user, mc, err := c.findCase(ctx, req.CurrentUser, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.CanAdmin() {
+		return nil, ErrForbidden
+	}
+
+	from := mc.Status
+	next, ok := from.Transit(req.Decision)
+	if !ok {
+		return nil, fmt.Errorf("moderation case in status %s can not be decided with %s: %w", from, req.Decision, ErrBadRequest)
+	}
+	mc.Status = next
+	mc.Decision = req.Decision
+	mc.DecisionReason = req.Reason
+	mc.DecidedBy = req.CurrentUser
+	mc.DecidedAt = time.Now()
+	err = c.transit(ctx, mc, from, req.Decision, req.CurrentUser, req.Reason,
+		"decision", "decision_reason", "decided_by", "decided_at")
+	if err != nil {
+		return nil, err
+	}
+	if mc.Status == types.ModerationCaseCleared {
+		err = c.clearFile(ctx, mc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = c.webhookNotifier.Notify(ctx, mc.Repository, types.WebhookEventModeration, types.WebhookModerationData{
+		CaseID:  mc.ID,
+		Action:  req.Decision,
+		Status:  mc.Status,
+		Path:    mc.Path,
+		Message: mc.Message,
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		slog.Error("failed to notify moderation case decision", slog.Int64("case_id", mc.ID), slog.Any("error", err))
+	}
+	return c.withLogs(ctx, mc)
+}
+
+// transit saves the case in the status changed by action with the audit log
+func (c *moderationCaseComponentImpl) transit(ctx context.Context, mc *database.ModerationCase, from types.ModerationCaseStatus,
+	action types.ModerationAction, operator, comment string, columns ...string) error {
+	updated, err := c.caseStore.Transit(ctx, mc, from, database.ModerationCaseLog{
+		Action:     action,
+		Operator:   operator,
+		FromStatus: from,
+		ToStatus:   mc.Status,
+		Comment:    comment,
+	}, columns...)
+	if err != nil {
+		return fmt.Errorf("failed to update moderation case %d, error: %w", mc.ID, err)
+	}
+	if !updated {
+		return fmt.Errorf("moderation case %d has been changed by others, please retry: %w", mc.ID, ErrBadRequest)
+	}
+	return nil
+}
+
+// clearFile marks the file of cleared case as passing the check, and restores the repository if
+// all of its cases are cleared
+func (c *moderationCaseComponentImpl) clearFile(ctx context.Context, mc *database.ModerationCase) error {
+	err := c.fileCheckStore.Upsert(ctx, database.RepositoryFileCheck{
+		RepoFileID: mc.RepoFileID,
+		Status:     types.SensitiveCheckPass,
+		Message:    fmt.Sprintf("cleared by moderator in moderation case %d", mc.ID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update check result of repo file %d, error: %w", mc.RepoFileID, err)
+	}
+	unresolved, err := c.caseStore.ListUnresolvedByRepo(ctx, mc.RepositoryID)
+	if err != nil {
+		return fmt.Errorf("failed to list moderation cases of repository %d, error: %w", mc.RepositoryID, err)
+	}
+	if len(unresolved) > 0 {
+		return nil
+	}
+	repo := mc.Repository
+	repo.SensitiveCheckStatus = types.SensitiveCheckPass
+	repo.Private = mc.RepoWasPrivate
+	_, err = c.repo.UpdateRepo(ctx, *repo)
+	if err != nil {
+		return fmt.Errorf("failed to restore repository %d, error: %w", repo.ID, err)
+	}
+	slog.Info("all moderation cases of repository cleared", slog.Int64("repository_id", repo.ID),
+		slog.Bool("private", repo.Private))
+	return nil
+}
+
+// findCase returns the case for the admins of its repository or moderators
+func (c *moderationCaseComponentImpl) findCase(ctx context.Context, currentUser string, id int64) (*database.User, *database.ModerationCase, error) {
+	user, err := c.user.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+	mc, err := c.caseStore.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find moderation case %d, error: %w", id, err)
+	}
+	if mc.Repository == nil {
+		return nil, nil, ErrNotFound
+	}
+	if err := c.checkCaseAccess(ctx, &user, mc.Repository); err != nil {
+		return nil, nil, err
+	}
+	return &user, mc, nil
+}
+
+func (c *moderationCaseComponentImpl) checkCaseAccess(ctx context.Context, user *database.User, repo *database.Repository) error {
+	if user.CanAdmin() {
+		return nil
+	}
+	permission, err := c.getUserRepoPermission(ctx, user.Username, repo)
+	if err != nil {
+		return fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanAdmin {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (c *moderationCaseComponentImpl) withLogs(ctx context.Context, mc *database.ModerationCase) (*types.ModerationCase, error) {
+	logs, err := c.caseStore.ListLogs(ctx, mc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list logs of moderation case %d, error: %w", mc.ID, err)
+	}
+	resp := toModerationCase(mc)
+	resp.Logs = make([]types.ModerationCaseLog, 0, len(logs))
+	for _, l := range logs {
+		resp.Logs = append(resp.Logs, types.ModerationCaseLog{
+			ID:         l.ID,
+			Action:     l.Action,
+			Operator:   l.Operator,
+			FromStatus: l.FromStatus,
+			ToStatus:   l.ToStatus,
+			Comment:    l.Comment,
+			CreatedAt:  l.CreatedAt,
+		})
+	}
+	return &resp, nil
+}
+
+func toModerationCase(mc *database.ModerationCase) types.ModerationCase {
+	resp := types.ModerationCase{
+		ID:             mc.ID,
+		RepositoryID:   mc.RepositoryID,
+		RepoFileID:     mc.RepoFileID,
+		Path:           mc.Path,
+		CommitSha:      mc.CommitSha,
+		Message:        mc.Message,
+		Status:         mc.Status,
+		AppealReason:   mc.AppealReason,
+		AppealedBy:     mc.AppealedBy,
+		Decision:       mc.Decision,
+		DecisionReason: mc.DecisionReason,
+		DecidedBy:      mc.DecidedBy,
+		CreatedAt:      mc.CreatedAt,
+		UpdatedAt:      mc.UpdatedAt,
+	}
+	if mc.Repository != nil {
+		resp.RepoPath = mc.Repository.Path
+		resp.RepoType = mc.Repository.RepositoryType
+	}
+	if !mc.AppealedAt.IsZero() {
+		resp.AppealedAt = &mc.AppealedAt
+	}
+	if !mc.DecidedAt.IsZero() {
+		resp.DecidedAt = &mc.DecidedAt
+	}
+	return resp
+}
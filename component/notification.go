@@ -0,0 +1,82 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type NotificationComponent interface {
+	// Index lists the notifications of current user, the latest go first
+	Index(ctx context.Context, currentUser string, unreadOnly bool, per, page int) ([]types.Notification, int, error)
+	MarkRead(ctx context.Context, currentUser string, id int64) error
+	MarkAllRead(ctx context.Context, currentUser string) error
+}
+
+func NewNotificationComponent(config *config.Config) (NotificationComponent, error) {
+	return &notificationComponentImpl{
+		userStore:         database.NewUserStore(),
+		notificationStore: database.NewNotificationStore(),
+	}, nil
+}
+
+type notificationComponentImpl struct {
+	userStore         database.UserStore
+	notificationStore database.NotificationStore
+}
+
+func (c *notificationComponentImpl) Index(ctx context.Context, currentUser string, unreadOnly bool, per, page int) ([]types.Notification, int, error) {
+	user, err := c.userStore.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return nil, 0, ErrUserNotFound
+	}
+	notifications, total, err := c.notificationStore.ListByUserID(ctx, user.ID, unreadOnly, per, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications of user %s, error: %w", currentUser, err)
+	}
+	resp := make([]types.Notification, 0, len(notifications))
+	for _, n := range notifications {
+		resp = append(resp, types.Notification{
+			ID:        n.ID,
+			Kind:      n.Kind,
+			Title:     n.Title,
+			Content:   n.Content,
+			Link:      n.Link,
+			Read:      !n.ReadAt.IsZero(),
+			CreatedAt: n.CreatedAt,
+		})
+	}
+	return resp, total, nil
+}
+
+func (c *notificationComponentImpl) MarkRead(ctx context.Context, currentUser string, id int64) error {
+	user, err := c.userStore.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	err = c.notificationStore.MarkRead(ctx, user.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark notification %d as read, error: %w", id, err)
+	}
+	return nil
+}
+
+func (c *notificationComponentImpl) MarkAllRead(ctx context.Context, currentUser string) error {
+	user, err := c.userStore.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	err = c.notificationStore.MarkAllRead(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notifications of user %s as read, error: %w", currentUser, err)
+	}
+	return nil
+}
@@ -466,7 +466,17 @@ func (c *promptComponentImpl) SubmitMessage(ctx context.Context, req types.Conve
 	}
 
 	slog.Debug("llm request", slog.Any("reqData", reqData))
-	ch, err := c.llm.Chat(ctx, llmConfig.ApiEndpoint, headers, reqData)
+	onUsage := func(usage types.LLMUsage) {
+		publishTokenMetering(c.eventPub, types.METERING_EVENT{
+			UserUUID:     user.UUID,
+			Scene:        int(types.SceneModelInference),
+			ResourceName: llmConfig.ModelName,
+			CustomerID:   llmConfig.ModelName,
+		}, usage, map[string]any{
+			"conversation_id": req.Uuid,
+		})
+	}
+	ch, err := c.llm.Chat(ctx, llmConfig.ApiEndpoint, headers, reqData, onUsage)
 	if err != nil {
 		return nil, fmt.Errorf("call llm error: %w", err)
 	}
@@ -0,0 +1,496 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type PullRequestComponent interface {
+	Create(ctx context.Context, req *types.CreatePullRequestReq) (*types.PullRequest, error)
+	Index(ctx context.Context, req *types.ListPullRequestsReq) ([]types.PullRequest, int, error)
+	Show(ctx context.Context, req *types.PullRequestActReq) (*types.PullRequest, error)
+	Update(ctx context.Context, req *types.UpdatePullRequestReq) (*types.PullRequest, error)
+	Close(ctx context.Context, req *types.PullRequestActReq) error
+	Reopen(ctx context.Context, req *types.PullRequestActReq) error
+	Diff(ctx context.Context, req *types.PullRequestActReq) (*types.PullRequestDiff, error)
+	Merge(ctx context.Context, req *types.MergePullRequestReq) (*types.PullRequest, error)
+	CreateComment(ctx context.Context, req *types.CreatePullRequestCommentReq) (*types.PullRequestComment, error)
+	ListComments(ctx context.Context, req *types.PullRequestActReq) ([]*types.PullRequestComment, error)
+	DeleteComment(ctx context.Context, req *types.PullRequestActReq, commentID int64) error
+}
+
+func NewPullRequestComponent(config *config.Config) (PullRequestComponent, error) {
+	c := &pullRequestComponentImpl{}
+	var err error
+	c.repoComponentImpl, err = NewRepoComponentImpl(config)
+	if err != nil {
+		return nil, err
+	}
+	c.prs = database.NewPullRequestStore()
+	return c, nil
+}
+
+type pullRequestComponentImpl struct {
+	*repoComponentImpl
+	prs database.PullRequestStore
+}
+
+// pullRequestCheckRef is the ref of target repository the source branch is fetched to, to check it can be
+// compared before a cross repository pull request is created
+func pullRequestCheckRef(sourceRepoID int64, sourceBranch string) string {
+	return fmt.Sprintf("refs/pull-check/%d/%s", sourceRepoID, sourceBranch)
+}
+
+// pullRequestFetchRef is the ref of target repository the source branch of a cross repository pull request is fetched to
+func pullRequestFetchRef(number int64) string {
+	return fmt.Sprintf("refs/pull/%d/head", number)
+}
+
+func (c *pullRequestComponentImpl) Create(ctx context.Context, req *types.CreatePullRequestReq) (*types.PullRequest, error) {
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user '%s', error: %w", req.CurrentUser, err)
+	}
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanRead {
+		return nil, ErrUnauthorized
+	}
+
+	sourceRepo := repo
+	if req.SourceRepo != "" && req.SourceRepo != repo.PathWithOutPrefix() {
+		fields := strings.Split(req.SourceRepo, "/")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid source repo '%s', should be in format of namespace/name", req.SourceRepo)
+		}
+		sourceRepo, err = c.repo.FindByPath(ctx, req.RepoType, fields[0], fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to find source repo '%s', error: %w", req.SourceRepo, err)
+		}
+		canRead, err := c.AllowReadAccessRepo(ctx, sourceRepo, req.CurrentUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check source repo permission, error: %w", err)
+		}
+		if !canRead {
+			return nil, ErrUnauthorized
+		}
+	}
+	if sourceRepo.ID == repo.ID && req.SourceBranch == req.TargetBranch {
+		return nil, errors.New("source branch and target branch can not be the same")
+	}
+
+	pr := &database.PullRequest{
+		RepositoryID: repo.ID,
+		SourceRepoID: sourceRepo.ID,
+		SourceBranch: req.SourceBranch,
+		TargetBranch: req.TargetBranch,
+		Title:        req.Title,
+		Description:  req.Description,
+		Status:       types.PullRequestStatusOpen,
+		UserID:       user.ID,
+		Repository:   repo,
+		SourceRepo:   sourceRepo,
+	}
+	// make sure the branches can be compared before the pull request is saved, the source branch is fetched
+	// to a ref of the source branch as the pull request has no number yet
+	compareReq := c.compareReq(pr)
+	compareReq.FetchRef = pullRequestCheckRef(sourceRepo.ID, req.SourceBranch)
+	_, err = c.git.GetCompareDiff(ctx, compareReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare source branch '%s' with target branch '%s', error: %w", req.SourceBranch, req.TargetBranch, err)
+	}
+
+	pr, err = c.prs.Create(ctx, *pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request, error: %w", err)
+	}
+	pr.Repository = repo
+	pr.SourceRepo = sourceRepo
+	pr.User = &user
+
+	return c.toPullRequest(pr, permission.CanWrite), nil
+}
+
+func (c *pullRequestComponentImpl) Index(ctx context.Context, req *types.ListPullRequestsReq) ([]types.PullRequest, int, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanRead {
+		return nil, 0, ErrUnauthorized
+	}
+	prs, total, err := c.prs.ListByRepoID(ctx, repo.ID, req.Status, req.Per, req.Page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list pull requests, error: %w", err)
+	}
+	var resp []types.PullRequest
+	for _, pr := range prs {
+		pr.Repository = repo
+		resp = append(resp, *c.toPullRequest(&pr, permission.CanWrite))
+	}
+	return resp, total, nil
+}
+
+func (c *pullRequestComponentImpl) Show(ctx context.Context, req *types.PullRequestActReq) (*types.PullRequest, error) {
+	pr, permission, err := c.findPullRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp := c.toPullRequest(pr, permission.CanWrite)
+	comments, err := c.prs.ListComments(ctx, pr.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list pull request comments, error: %w", err)
+	}
+	for _, comment := range comments {
+		resp.ReviewComments = append(resp.ReviewComments, toPullRequestComment(&comment))
+	}
+	return resp, nil
+}
+
+func (c *pullRequestComponentImpl) Update(ctx context.Context, req *types.UpdatePullRequestReq) (*types.PullRequest, error) {
+	pr, permission, err := c.findPullRequest(ctx, &types.PullRequestActReq{
+		RepoType:    req.RepoType,
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		ID:          req.ID,
+		CurrentUser: req.CurrentUser,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if pr.User.Username != req.CurrentUser && !permission.CanWrite {
+		return nil, ErrForbidden
+	}
+	if req.Title != nil {
+		pr.Title = *req.Title
+	}
+	if req.Description != nil {
+		pr.Description = *req.Description
+	}
+	_, err = c.prs.Update(ctx, *pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update pull request, error: %w", err)
+	}
+	return c.toPullRequest(pr, permission.CanWrite), nil
+}
+
+func (c *pullRequestComponentImpl) Close(ctx context.Context, req *types.PullRequestActReq) error {
+	pr, permission, err := c.findPullRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	if pr.User.Username != req.CurrentUser && !permission.CanWrite {
+		return ErrForbidden
+	}
+	if pr.Status != types.PullRequestStatusOpen {
+		return fmt.Errorf("pull request is %s", pr.Status)
+	}
+	pr.Status = types.PullRequestStatusClosed
+	pr.ClosedAt = time.Now()
+	_, err = c.prs.Update(ctx, *pr)
+	if err != nil {
+		return fmt.Errorf("failed to close pull request, error: %w", err)
+	}
+	return nil
+}
+
+func (c *pullRequestComponentImpl) Reopen(ctx context.Context, req *types.PullRequestActReq) error {
+	pr, permission, err := c.findPullRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	if pr.User.Username != req.CurrentUser && !permission.CanWrite {
+		return ErrForbidden
+	}
+	if pr.Status != types.PullRequestStatusClosed {
+		return fmt.Errorf("pull request is %s", pr.Status)
+	}
+	pr.Status = types.PullRequestStatusOpen
+	pr.ClosedAt = time.Time{}
+	_, err = c.prs.Update(ctx, *pr)
+	if err != nil {
+		return fmt.Errorf("failed to reopen pull request, error: %w", err)
+	}
+	return nil
+}
+
+func (c *pullRequestComponentImpl) Diff(ctx context.Context, req *types.PullRequestActReq) (*types.PullRequestDiff, error) {
+	pr, _, err := c.findPullRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if pr.Status == types.PullRequestStatusMerged {
+		// the source branch is part of target branch now, show the merged changes instead
+		commit, err := c.git.GetSingleCommit(ctx, gitserver.GetRepoLastCommitReq{
+			Namespace: req.Namespace,
+			Name:      req.Name,
+			Ref:       pr.MergeCommitID,
+			RepoType:  req.RepoType,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get merge commit '%s', error: %w", pr.MergeCommitID, err)
+		}
+		return &types.PullRequestDiff{
+			HeadCommitID: pr.MergeCommitID,
+			Files:        commit.Files,
+			Stats:        commit.Stats,
+			Diff:         commit.Diff,
+		}, nil
+	}
+	diff, err := c.git.GetCompareDiff(ctx, c.compareReq(pr))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request diff, error: %w", err)
+	}
+	return diff, nil
+}
+
+func (c *pullRequestComponentImpl) Merge(ctx context.Context, req *types.MergePullRequestReq) (*types.PullRequest, error) {
+	pr, permission, err := c.findPullRequest(ctx, &req.PullRequestActReq)
+	if err != nil {
+		return nil, err
+	}
+	if !permission.CanWrite {
+		return nil, ErrForbidden
+	}
+	if pr.Status != types.PullRequestStatusOpen {
+		return nil, fmt.Errorf("pull request is %s", pr.Status)
+	}
+	if req.Strategy == "" {
+		req.Strategy = types.MergeStrategyMerge
+	}
+	if !req.Strategy.Valid() {
+		return nil, fmt.Errorf("unknown merge strategy '%s'", req.Strategy)
+	}
+	err = c.checkBranchWrite(ctx, pr.Repository, req.CurrentUser, pr.TargetBranch, true)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user '%s', error: %w", req.CurrentUser, err)
+	}
+	message := req.Message
+	if message == "" {
+		message = fmt.Sprintf("Merge pull request #%d from %s:%s\n\n%s", pr.Number, pr.SourceRepo.PathWithOutPrefix(), pr.SourceBranch, pr.Title)
+	}
+	commitID, err := c.git.MergeBranch(ctx, gitserver.MergeBranchReq{
+		CompareReq: c.compareReq(pr),
+		Strategy:   req.Strategy,
+		Message:    message,
+		Username:   user.Username,
+		Email:      user.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge pull request, error: %w", err)
+	}
+
+	pr.Status = types.PullRequestStatusMerged
+	pr.MergeStrategy = req.Strategy
+	pr.MergeCommitID = commitID
+	pr.MergedByID = user.ID
+	pr.MergedBy = &user
+	pr.MergedAt = time.Now()
+	_, err = c.prs.Update(ctx, *pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update merged pull request, error: %w", err)
+	}
+	return c.toPullRequest(pr, permission.CanWrite), nil
+}
+
+func (c *pullRequestComponentImpl) CreateComment(ctx context.Context, req *types.CreatePullRequestCommentReq) (*types.PullRequestComment, error) {
+	pr, _, err := c.findPullRequest(ctx, &req.PullRequestActReq)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user '%s', error: %w", req.CurrentUser, err)
+	}
+	if req.Line > 0 && req.Path == "" {
+		return nil, errors.New("path is required for a line comment")
+	}
+	commitID := req.CommitID
+	if req.Path != "" && commitID == "" {
+		if pr.Status == types.PullRequestStatusMerged {
+			commitID = pr.MergeCommitID
+		} else {
+			diff, err := c.git.GetCompareDiff(ctx, c.compareReq(pr))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get head commit of pull request, error: %w", err)
+			}
+			commitID = diff.HeadCommitID
+		}
+	}
+	comment, err := c.prs.CreateComment(ctx, database.PullRequestComment{
+		PullRequestID: pr.ID,
+		UserID:        user.ID,
+		Content:       req.Content,
+		Path:          req.Path,
+		Line:          req.Line,
+		CommitID:      commitID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request comment, error: %w", err)
+	}
+	comment.User = &user
+	return toPullRequestComment(comment), nil
+}
+
+func (c *pullRequestComponentImpl) ListComments(ctx context.Context, req *types.PullRequestActReq) ([]*types.PullRequestComment, error) {
+	pr, _, err := c.findPullRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := c.prs.ListComments(ctx, pr.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull request comments, error: %w", err)
+	}
+	resp := make([]*types.PullRequestComment, 0, len(comments))
+	for _, comment := range comments {
+		resp = append(resp, toPullRequestComment(&comment))
+	}
+	return resp, nil
+}
+
+func (c *pullRequestComponentImpl) DeleteComment(ctx context.Context, req *types.PullRequestActReq, commentID int64) error {
+	pr, permission, err := c.findPullRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	comment, err := c.prs.FindCommentByID(ctx, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to find pull request comment '%d', error: %w", commentID, err)
+	}
+	if comment.PullRequestID != pr.ID {
+		return ErrNotFound
+	}
+	if comment.User.Username != req.CurrentUser && !permission.CanAdmin {
+		return ErrForbidden
+	}
+	err = c.prs.DeleteComment(ctx, *comment)
+	if err != nil {
+		return fmt.Errorf("failed to delete pull request comment '%d', error: %w", commentID, err)
+	}
+	return nil
+}
+
+// findPullRequest finds the pull request by its number in the repository and checks the read permission of current user
+func (c *pullRequestComponentImpl) findPullRequest(ctx context.Context, req *types.PullRequestActReq) (*database.PullRequest, *types.UserRepoPermission, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanRead {
+		return nil, nil, ErrUnauthorized
+	}
+	pr, err := c.prs.FindByNumber(ctx, repo.ID, req.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to find pull request #%d, error: %w", req.ID, err)
+	}
+	return pr, permission, nil
+}
+
+func (c *pullRequestComponentImpl) compareReq(pr *database.PullRequest) gitserver.CompareReq {
+	namespace, name := pr.Repository.NamespaceAndName()
+	sourceNamespace, sourceName := pr.SourceRepo.NamespaceAndName()
+	return gitserver.CompareReq{
+		Namespace:     namespace,
+		Name:          name,
+		RepoType:      pr.Repository.RepositoryType,
+		BaseRef:       pr.TargetBranch,
+		HeadNamespace: sourceNamespace,
+		HeadName:      sourceName,
+		HeadRef:       pr.SourceBranch,
+		FetchRef:      pullRequestFetchRef(pr.Number),
+	}
+}
+
+func (c *pullRequestComponentImpl) toPullRequest(pr *database.PullRequest, canWrite bool) *types.PullRequest {
+	resp := &types.PullRequest{
+		ID:            pr.ID,
+		Number:        pr.Number,
+		Title:         pr.Title,
+		Description:   pr.Description,
+		Status:        pr.Status,
+		SourceBranch:  pr.SourceBranch,
+		TargetBranch:  pr.TargetBranch,
+		MergeStrategy: pr.MergeStrategy,
+		MergeCommitID: pr.MergeCommitID,
+		CommentCount:  pr.CommentCount,
+		CreatedAt:     pr.CreatedAt,
+		UpdatedAt:     pr.UpdatedAt,
+		CanMerge:      canWrite && pr.Status == types.PullRequestStatusOpen,
+	}
+	if pr.Repository != nil {
+		resp.TargetRepo = pr.Repository.PathWithOutPrefix()
+	}
+	if pr.SourceRepo != nil {
+		resp.SourceRepo = pr.SourceRepo.PathWithOutPrefix()
+	}
+	if pr.User != nil {
+		resp.User = &types.PullRequestUser{
+			ID:       pr.User.ID,
+			Username: pr.User.Username,
+			Avatar:   pr.User.Avatar,
+		}
+	}
+	if pr.MergedBy != nil && pr.MergedBy.ID != 0 {
+		resp.MergedBy = &types.PullRequestUser{
+			ID:       pr.MergedBy.ID,
+			Username: pr.MergedBy.Username,
+			Avatar:   pr.MergedBy.Avatar,
+		}
+	}
+	if !pr.MergedAt.IsZero() {
+		resp.MergedAt = &pr.MergedAt
+	}
+	if !pr.ClosedAt.IsZero() {
+		resp.ClosedAt = &pr.ClosedAt
+	}
+	return resp
+}
+
+func toPullRequestComment(comment *database.PullRequestComment) *types.PullRequestComment {
+	resp := &types.PullRequestComment{
+		ID:        comment.ID,
+		Content:   comment.Content,
+		Path:      comment.Path,
+		Line:      comment.Line,
+		CommitID:  comment.CommitID,
+		CreatedAt: comment.CreatedAt,
+	}
+	if comment.User != nil {
+		resp.User = &types.PullRequestUser{
+			ID:       comment.User.ID,
+			Username: comment.User.Username,
+			Avatar:   comment.User.Avatar,
+		}
+	}
+	return resp
+}
@@ -0,0 +1,70 @@
+package component
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestPullRequestComponent_compareReq(t *testing.T) {
+	c := &pullRequestComponentImpl{}
+	pr := &database.PullRequest{
+		Number:       3,
+		SourceBranch: "feature",
+		TargetBranch: "main",
+		Repository:   &database.Repository{Path: "org/model", RepositoryType: types.ModelRepo},
+		SourceRepo:   &database.Repository{Path: "user/model-fork", RepositoryType: types.ModelRepo},
+	}
+
+	req := c.compareReq(pr)
+	require.Equal(t, "org", req.Namespace)
+	require.Equal(t, "model", req.Name)
+	require.Equal(t, "main", req.BaseRef)
+	require.Equal(t, "user", req.HeadNamespace)
+	require.Equal(t, "model-fork", req.HeadName)
+	require.Equal(t, "feature", req.HeadRef)
+	require.Equal(t, "refs/pull/3/head", req.FetchRef)
+	require.True(t, req.CrossRepo())
+}
+
+func TestPullRequestComponent_toPullRequest(t *testing.T) {
+	c := &pullRequestComponentImpl{}
+	pr := &database.PullRequest{
+		ID:         1,
+		Number:     2,
+		Status:     types.PullRequestStatusOpen,
+		Repository: &database.Repository{Path: "org/model"},
+		SourceRepo: &database.Repository{Path: "org/model"},
+		User:       &database.User{ID: 5, Username: "alice"},
+		MergedBy:   &database.User{},
+	}
+
+	resp := c.toPullRequest(pr, true)
+	require.True(t, resp.CanMerge)
+	require.Equal(t, "org/model", resp.TargetRepo)
+	require.Equal(t, "alice", resp.User.Username)
+	require.Nil(t, resp.MergedBy)
+	require.Nil(t, resp.MergedAt)
+	require.Nil(t, resp.ClosedAt)
+
+	require.False(t, c.toPullRequest(pr, false).CanMerge)
+
+	pr.Status = types.PullRequestStatusMerged
+	pr.MergedBy = &database.User{ID: 6, Username: "bob"}
+	pr.MergedAt = time.Now()
+	resp = c.toPullRequest(pr, true)
+	require.False(t, resp.CanMerge)
+	require.Equal(t, "bob", resp.MergedBy.Username)
+	require.NotNil(t, resp.MergedAt)
+}
+
+func TestMergeStrategy_Valid(t *testing.T) {
+	require.True(t, types.MergeStrategyMerge.Valid())
+	require.True(t, types.MergeStrategySquash.Valid())
+	require.True(t, types.MergeStrategyFastForward.Valid())
+	require.False(t, types.MergeStrategy("rebase").Valid())
+	require.False(t, types.MergeStrategy("").Valid())
+}
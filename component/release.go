@@ -0,0 +1,342 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func (c *repoComponentImpl) GitTags(ctx context.Context, req *types.GetGitTagsReq) ([]*types.Tag, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanRead {
+		return nil, ErrUnauthorized
+	}
+
+	tags, err := c.git.GetRepoTags(ctx, gitserver.GetRepoTagsReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		Per:       req.Per,
+		Page:      req.Page,
+		RepoType:  req.RepoType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git %s repository tags, error: %w", req.RepoType, err)
+	}
+	return tags, nil
+}
+
+func (c *repoComponentImpl) CreateGitTag(ctx context.Context, req *types.CreateGitTagReq) (*types.Tag, error) {
+	repo, user, err := c.findRepoForWrite(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	if req.Target == "" {
+		req.Target = repo.DefaultBranch
+	}
+	tag, err := c.git.CreateTag(ctx, gitserver.CreateTagReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Tag:       req.Tag,
+		Target:    req.Target,
+		Message:   req.Message,
+		Username:  user.Username,
+		Email:     user.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create git tag, error: %w", err)
+	}
+	return tag, nil
+}
+
+func (c *repoComponentImpl) DeleteGitTag(ctx context.Context, req *types.DeleteGitTagReq) error {
+	repo, user, err := c.findRepoForWrite(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return err
+	}
+	_, err = c.release.FindByTagName(ctx, repo.ID, req.Tag)
+	if err == nil {
+		return fmt.Errorf("tag '%s' is used by a release, delete the release first", req.Tag)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to find release of tag, error: %w", err)
+	}
+	err = c.git.DeleteTag(ctx, gitserver.DeleteTagReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Tag:       req.Tag,
+		Username:  user.Username,
+		Email:     user.Email,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete git tag, error: %w", err)
+	}
+	return nil
+}
+
+func (c *repoComponentImpl) CreateRelease(ctx context.Context, req *types.CreateReleaseReq) (*types.Release, error) {
+	repo, user, err := c.findRepoForWrite(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.release.FindByTagName(ctx, repo.ID, req.TagName)
+	if err == nil {
+		return nil, ErrAlreadyExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to find release, error: %w", err)
+	}
+
+	commitID, err := c.resolveReleaseTag(ctx, repo, user, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.checkReleaseAssets(ctx, repo, commitID, req.Assets)
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.release.Create(ctx, database.Release{
+		RepositoryID: repo.ID,
+		TagName:      req.TagName,
+		Title:        req.Title,
+		Notes:        req.Notes,
+		CommitID:     commitID,
+		Assets:       req.Assets,
+		UserID:       user.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	release.User = &user
+	return c.toRelease(repo, release), nil
+}
+
+// resolveReleaseTag returns the commit of the release tag, the existing tag is reused and a missing one is
+// created from the target
+func (c *repoComponentImpl) resolveReleaseTag(ctx context.Context, repo *database.Repository, user database.User, req *types.CreateReleaseReq) (string, error) {
+	tag, err := c.git.GetTag(ctx, gitserver.GetTagReq{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		RepoType:  req.RepoType,
+		Tag:       req.TagName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get git tag '%s', error: %w", req.TagName, err)
+	}
+	if tag == nil {
+		target := req.Target
+		if target == "" {
+			target = repo.DefaultBranch
+		}
+		tag, err = c.git.CreateTag(ctx, gitserver.CreateTagReq{
+			Namespace: req.Namespace,
+			Name:      req.Name,
+			RepoType:  req.RepoType,
+			Tag:       req.TagName,
+			Target:    target,
+			Message:   req.Title,
+			Username:  user.Username,
+			Email:     user.Email,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create git tag of release, error: %w", err)
+		}
+	}
+	if tag.Commit.ID == "" {
+		return "", fmt.Errorf("failed to resolve commit of tag '%s'", req.TagName)
+	}
+	return tag.Commit.ID, nil
+}
+
+func (c *repoComponentImpl) ListReleases(ctx context.Context, req *types.ReleaseReq) ([]types.Release, int, error) {
+	repo, err := c.findRepoForRead(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, 0, err
+	}
+	releases, total, err := c.release.ListByRepoID(ctx, repo.ID, req.Per, req.Page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list releases, error: %w", err)
+	}
+	resp := make([]types.Release, 0, len(releases))
+	for i := range releases {
+		resp = append(resp, *c.toRelease(repo, &releases[i]))
+	}
+	return resp, total, nil
+}
+
+func (c *repoComponentImpl) GetRelease(ctx context.Context, req *types.ReleaseReq) (*types.Release, error) {
+	repo, err := c.findRepoForRead(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.findRelease(ctx, repo.ID, req.TagName)
+	if err != nil {
+		return nil, err
+	}
+	return c.toRelease(repo, release), nil
+}
+
+func (c *repoComponentImpl) UpdateRelease(ctx context.Context, req *types.UpdateReleaseReq) (*types.Release, error) {
+	repo, _, err := c.findRepoForWrite(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.findRelease(ctx, repo.ID, req.TagName)
+	if err != nil {
+		return nil, err
+	}
+	if req.Title != nil {
+		release.Title = *req.Title
+	}
+	if req.Notes != nil {
+		release.Notes = *req.Notes
+	}
+	if req.Assets != nil {
+		err = c.checkReleaseAssets(ctx, repo, release.CommitID, *req.Assets)
+		if err != nil {
+			return nil, err
+		}
+		release.Assets = *req.Assets
+	}
+	release, err = c.release.Update(ctx, *release)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update release, error: %w", err)
+	}
+	return c.toRelease(repo, release), nil
+}
+
+// DeleteRelease deletes the release only, the git tag is kept
+func (c *repoComponentImpl) DeleteRelease(ctx context.Context, req *types.ReleaseReq) error {
+	repo, _, err := c.findRepoForWrite(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return err
+	}
+	release, err := c.findRelease(ctx, repo.ID, req.TagName)
+	if err != nil {
+		return err
+	}
+	err = c.release.Delete(ctx, *release)
+	if err != nil {
+		return fmt.Errorf("failed to delete release, error: %w", err)
+	}
+	return nil
+}
+
+// resolveReleaseRef returns the pinned commit if ref is the tag of a release,
+// so that `repo@tag` always gets the same files even if the tag is moved
+func (c *repoComponentImpl) resolveReleaseRef(ctx context.Context, repoID int64, ref string) string {
+	if ref == "" {
+		return ref
+	}
+	release, err := c.release.FindByTagName(ctx, repoID, ref)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to find release by ref", slog.Int64("repo_id", repoID), slog.String("ref", ref), slog.Any("error", err))
+		}
+		return ref
+	}
+	return release.CommitID
+}
+
+func (c *repoComponentImpl) findRepoForRead(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string) (*database.Repository, error) {
+	repo, err := c.repo.FindByPath(ctx, repoType, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, currentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanRead {
+		return nil, ErrUnauthorized
+	}
+	return repo, nil
+}
+
+func (c *repoComponentImpl) findRepoForWrite(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string) (*database.Repository, database.User, error) {
+	repo, err := c.repo.FindByPath(ctx, repoType, namespace, name)
+	if err != nil {
+		return nil, database.User{}, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	user, err := c.user.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return nil, database.User{}, ErrUserNotFound
+	}
+	permission, err := c.getUserRepoPermission(ctx, currentUser, repo)
+	if err != nil {
+		return nil, database.User{}, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanWrite {
+		return nil, database.User{}, ErrForbidden
+	}
+	return repo, user, nil
+}
+
+func (c *repoComponentImpl) findRelease(ctx context.Context, repoID int64, tagName string) (*database.Release, error) {
+	release, err := c.release.FindByTagName(ctx, repoID, tagName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find release, error: %w", err)
+	}
+	return release, nil
+}
+
+// checkReleaseAssets makes sure all assets are files of the repository at the pinned commit
+func (c *repoComponentImpl) checkReleaseAssets(ctx context.Context, repo *database.Repository, commitID string, assets []string) error {
+	namespace, name := repo.NamespaceAndName()
+	for _, asset := range assets {
+		_, err := c.git.GetRepoFileContents(ctx, gitserver.GetRepoInfoByPathReq{
+			Namespace: namespace,
+			Name:      name,
+			Ref:       commitID,
+			Path:      asset,
+			RepoType:  repo.RepositoryType,
+		})
+		if err != nil {
+			return fmt.Errorf("release asset '%s' is not a file of commit %s, error: %w", asset, commitID, err)
+		}
+	}
+	return nil
+}
+
+func (c *repoComponentImpl) toRelease(repo *database.Repository, release *database.Release) *types.Release {
+	resp := &types.Release{
+		ID:        release.ID,
+		TagName:   release.TagName,
+		Title:     release.Title,
+		Notes:     release.Notes,
+		CommitID:  release.CommitID,
+		Assets:    []types.ReleaseAsset{},
+		CreatedAt: release.CreatedAt,
+		UpdatedAt: release.UpdatedAt,
+	}
+	if release.User != nil {
+		resp.Author = release.User.Username
+	}
+	for _, asset := range release.Assets {
+		downloadURL, _ := url.JoinPath(c.serverBaseUrl, "api/v1", string(repo.RepositoryType)+"s", repo.Path, "resolve", asset)
+		resp.Assets = append(resp.Assets, types.ReleaseAsset{
+			Path:        asset,
+			DownloadURL: downloadURL + "?ref=" + url.QueryEscape(release.CommitID),
+		})
+	}
+	return resp
+}
@@ -0,0 +1,63 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// tagGitServer is a git server of tags in memory, other methods are not implemented
+type tagGitServer struct {
+	gitserver.GitServer
+	tags    map[string]string
+	getErr  error
+	created []gitserver.CreateTagReq
+}
+
+func (s *tagGitServer) GetTag(ctx context.Context, req gitserver.GetTagReq) (*types.Tag, error) {
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	commitID, ok := s.tags[req.Tag]
+	if !ok {
+		return nil, nil
+	}
+	return &types.Tag{Name: req.Tag, Commit: types.DatasetTagCommit{ID: commitID}}, nil
+}
+
+func (s *tagGitServer) CreateTag(ctx context.Context, req gitserver.CreateTagReq) (*types.Tag, error) {
+	s.created = append(s.created, req)
+	return &types.Tag{Name: req.Tag, Commit: types.DatasetTagCommit{ID: "commit-of-" + req.Target}}, nil
+}
+
+func TestRepoComponent_resolveReleaseTag(t *testing.T) {
+	ctx := context.Background()
+	gs := &tagGitServer{tags: map[string]string{"v1.0": "abc"}}
+	c := &repoComponentImpl{git: gs}
+	repo := &database.Repository{DefaultBranch: "main"}
+	user := database.User{Username: "alice"}
+
+	// existing tag is reused
+	commitID, err := c.resolveReleaseTag(ctx, repo, user, &types.CreateReleaseReq{TagName: "v1.0", Target: "dev"})
+	require.NoError(t, err)
+	require.Equal(t, "abc", commitID)
+	require.Empty(t, gs.created)
+
+	// missing tag is created from the default branch
+	commitID, err = c.resolveReleaseTag(ctx, repo, user, &types.CreateReleaseReq{TagName: "v2.0"})
+	require.NoError(t, err)
+	require.Equal(t, "commit-of-main", commitID)
+	require.Len(t, gs.created, 1)
+	require.Equal(t, "v2.0", gs.created[0].Tag)
+
+	// tag is not created if it can't be looked up
+	gs.getErr = errors.New("unavailable")
+	_, err = c.resolveReleaseTag(ctx, repo, user, &types.CreateReleaseReq{TagName: "v3.0"})
+	require.Error(t, err)
+	require.Len(t, gs.created, 1)
+}
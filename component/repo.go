@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -15,12 +16,14 @@ import (
 	"path"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"opencsg.com/csghub-server/builder/deploy"
 	deployStatus "opencsg.com/csghub-server/builder/deploy/common"
+	"opencsg.com/csghub-server/builder/event"
 	"opencsg.com/csghub-server/builder/git"
 	"opencsg.com/csghub-server/builder/git/gitserver"
 	"opencsg.com/csghub-server/builder/git/membership"
@@ -51,14 +54,14 @@ type repoComponentImpl struct {
 	rel                database.RepoRelationsStore
 	mirror             database.MirrorStore
 	git                gitserver.GitServer
-	s3Client           *s3.Client
+	lfsRouter          s3.LfsRouter
 	userSvcClient      rpc.UserSvcClient
-	lfsBucket          string
 	uls                database.UserLikesStore
 	mirrorServer       mirrorserver.MirrorServer
 	runFrame           database.RuntimeFrameworksStore
 	deploy             database.DeployTaskStore
 	deployer           deploy.Deployer
+	endpoints          *deploy.EndpointActivity
 	publicRootDomain   string
 	serverBaseUrl      string
 	cluster            database.ClusterInfoStore
@@ -75,6 +78,14 @@ type repoComponentImpl struct {
 	lfsMetaObjectStore database.LfsMetaObjectStore
 	recom              database.RecomStore
 	mq                 *queue.PriorityQueue
+	modelStore         database.ModelStore
+	datasetStore       database.DatasetStore
+	codeStore          database.CodeStore
+	spaceStore         database.SpaceStore
+	release            database.ReleaseStore
+	branchProtection   database.BranchProtectionStore
+	eventPub           *event.EventPublisher
+	quota              *computeQuotaChecker
 }
 
 type RepoComponent interface {
@@ -129,6 +140,12 @@ type RepoComponent interface {
 	AllowAccessByRepoID(ctx context.Context, repoID int64, username string) (bool, error)
 	// check access endpoint for rproxy
 	AllowAccessEndpoint(ctx context.Context, currentUser string, deploy *database.Deploy) (bool, error)
+	// record endpoint request for the scale to zero of autoscaling
+	RecordEndpointRequest(deploy *database.Deploy)
+	// wake up endpoint scaled to zero for rproxy
+	WakeupEndpoint(ctx context.Context, deploy *database.Deploy) error
+	// publish token metering of endpoint call for rproxy
+	PublishEndpointTokenUsage(ctx context.Context, currentUser string, deploy *database.Deploy, usage types.LLMUsage)
 	// check access deploy permission
 	AllowAccessDeploy(ctx context.Context, req types.DeployActReq) (bool, error)
 	DeployStop(ctx context.Context, stopReq types.DeployActReq) error
@@ -139,6 +156,24 @@ type RepoComponent interface {
 	DeployUpdate(ctx context.Context, updateReq types.DeployActReq, req *types.DeployUpdateReq) error
 	DeployStart(ctx context.Context, startReq types.DeployActReq) error
 	AllFiles(ctx context.Context, req types.GetAllFilesReq) ([]*types.File, error)
+	// Fork creates a copy of the repository in the given namespace and tracks it as upstream
+	Fork(ctx context.Context, req types.ForkRepoReq) (*types.ForkRepoResp, error)
+	// ForkStatus compares a forked repository with its upstream repository
+	ForkStatus(ctx context.Context, req types.ForkStatusReq) (*types.ForkStatus, error)
+	// SyncFork merges the new commits of upstream repository into the forked repository
+	SyncFork(ctx context.Context, req types.ForkStatusReq) (*types.ForkStatus, error)
+	// Transfer moves the repository to another namespace, the old path redirects to the new one
+	Transfer(ctx context.Context, req types.TransferRepoReq) (*types.TransferRepoResp, error)
+	// GitTags lists the git tags of the repository, unlike Tags which returns the category tags
+	GitTags(ctx context.Context, req *types.GetGitTagsReq) ([]*types.Tag, error)
+	CreateGitTag(ctx context.Context, req *types.CreateGitTagReq) (*types.Tag, error)
+	DeleteGitTag(ctx context.Context, req *types.DeleteGitTagReq) error
+	// CreateRelease creates a release pinned to the commit of its tag, the tag is created from target if not exists
+	CreateRelease(ctx context.Context, req *types.CreateReleaseReq) (*types.Release, error)
+	ListReleases(ctx context.Context, req *types.ReleaseReq) ([]types.Release, int, error)
+	GetRelease(ctx context.Context, req *types.ReleaseReq) (*types.Release, error)
+	UpdateRelease(ctx context.Context, req *types.UpdateReleaseReq) (*types.Release, error)
+	DeleteRelease(ctx context.Context, req *types.ReleaseReq) error
 }
 
 func NewRepoComponentImpl(config *config.Config) (*repoComponentImpl, error) {
@@ -188,18 +223,18 @@ func NewRepoComponent(config *config.Config) (RepoComponent, error) {
 		slog.Error(newError.Error())
 		return nil, newError
 	}
-	c.s3Client, err = s3.NewMinio(config)
+	c.lfsRouter, err = s3.NewLfsRouter(config)
 	if err != nil {
 		newError := fmt.Errorf("fail to init s3 client for code,error:%w", err)
 		slog.Error(newError.Error())
 		return nil, newError
 	}
-	c.lfsBucket = config.S3.Bucket
 	c.userSvcClient = rpc.NewUserSvcHttpClient(fmt.Sprintf("%s:%d", config.User.Host, config.User.Port),
 		rpc.AuthWithApiKey(config.APIToken))
 	c.runFrame = database.NewRuntimeFrameworksStore()
 	c.deploy = database.NewDeployTaskStore()
 	c.deployer = deploy.NewDeployer()
+	c.endpoints = deploy.NewEndpointActivity()
 	c.publicRootDomain = config.Space.PublicRootDomain
 	c.serverBaseUrl = config.APIServer.PublicDomain
 	c.cluster = database.NewClusterInfoStore()
@@ -212,6 +247,14 @@ func NewRepoComponent(config *config.Config) (RepoComponent, error) {
 	c.srs = database.NewSpaceResourceStore()
 	c.lfsMetaObjectStore = database.NewLfsMetaObjectStore()
 	c.recom = database.NewRecomStore()
+	c.modelStore = database.NewModelStore()
+	c.datasetStore = database.NewDatasetStore()
+	c.codeStore = database.NewCodeStore()
+	c.spaceStore = database.NewSpaceStore()
+	c.release = database.NewReleaseStore()
+	c.branchProtection = database.NewBranchProtectionStore()
+	c.eventPub = &event.DefaultEventPublisher
+	c.quota = newComputeQuotaChecker()
 	c.config = config
 	return c, nil
 }
@@ -231,20 +274,9 @@ func (c *repoComponentImpl) CreateRepo(ctx context.Context, req types.CreateRepo
 		return nil, nil, fmt.Errorf("please set your email first")
 	}
 
-	if !user.CanAdmin() {
-		if namespace.NamespaceType == database.OrgNamespace {
-			canWrite, err := c.checkCurrentUserPermission(ctx, req.Username, req.Namespace, membership.RoleWrite)
-			if err != nil {
-				return nil, nil, err
-			}
-			if !canWrite {
-				return nil, nil, fmt.Errorf("users do not have permission to create %s in this organization", req.RepoType)
-			}
-		} else {
-			if namespace.Path != user.Username {
-				return nil, nil, fmt.Errorf("users do not have permission to create %s in this namespace", req.RepoType)
-			}
-		}
+	err = c.checkCreateRepoPermission(ctx, user, &namespace, req.RepoType)
+	if err != nil {
+		return nil, nil, err
 	}
 	if req.DefaultBranch == "" {
 		req.DefaultBranch = types.MainBranch
@@ -291,6 +323,27 @@ func (c *repoComponentImpl) CreateRepo(ctx context.Context, req types.CreateRepo
 	return gitRepo, newDBRepo, nil
 }
 
+// checkCreateRepoPermission checks whether the user can create a repository in the namespace
+func (c *repoComponentImpl) checkCreateRepoPermission(ctx context.Context, user database.User, namespace *database.Namespace, repoType types.RepositoryType) error {
+	if user.CanAdmin() {
+		return nil
+	}
+	if namespace.NamespaceType == database.OrgNamespace {
+		canWrite, err := c.checkCurrentUserPermission(ctx, user.Username, namespace.Path, membership.RoleWrite)
+		if err != nil {
+			return err
+		}
+		if !canWrite {
+			return fmt.Errorf("users do not have permission to create %s in this organization", repoType)
+		}
+	} else {
+		if namespace.Path != user.Username {
+			return fmt.Errorf("users do not have permission to create %s in this namespace", repoType)
+		}
+	}
+	return nil
+}
+
 func (c *repoComponentImpl) UpdateRepo(ctx context.Context, req types.UpdateRepoReq) (*database.Repository, error) {
 	repo, err := c.repo.Find(ctx, req.Namespace, string(req.RepoType), req.Name)
 	if err != nil {
@@ -524,6 +577,10 @@ func (c *repoComponentImpl) CreateFile(ctx context.Context, req *types.CreateFil
 	if !permission.CanWrite {
 		return nil, ErrUnauthorized
 	}
+	err = c.checkBranchWrite(ctx, repo, req.CurrentUser, fileTargetBranch(req.Branch, req.NewBranch), false)
+	if err != nil {
+		return nil, err
+	}
 
 	user, err = c.user.FindByUsername(ctx, req.Username)
 	if err != nil {
@@ -555,8 +612,12 @@ func (c *repoComponentImpl) CreateFile(ctx context.Context, req *types.CreateFil
 	}
 
 	if useLfs {
+		storage, err := c.lfsRouter.ForNamespace(ctx, req.Namespace)
+		if err != nil {
+			return nil, err
+		}
 		objectKey := filepath.Join("lfs", req.Pointer.RelativePath())
-		uploadInfo, err := c.s3Client.PutObject(ctx, c.config.S3.Bucket, objectKey, bytes.NewReader(req.OriginalContent), req.Pointer.Size, minio.PutObjectOptions{})
+		uploadInfo, err := storage.Client.PutObject(ctx, storage.Bucket, objectKey, bytes.NewReader(req.OriginalContent), req.Pointer.Size, minio.PutObjectOptions{})
 		if err != nil {
 			return nil, fmt.Errorf("failed to upload to Minio: %w", err)
 		}
@@ -569,6 +630,7 @@ func (c *repoComponentImpl) CreateFile(ctx context.Context, req *types.CreateFil
 			Size:         req.Pointer.Size,
 			RepositoryID: repo.ID,
 			Existing:     true,
+			StorageID:    storage.ID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create LFS meta object: %w", err)
@@ -637,6 +699,10 @@ func (c *repoComponentImpl) UpdateFile(ctx context.Context, req *types.UpdateFil
 	if !permission.CanWrite {
 		return nil, ErrUnauthorized
 	}
+	err = c.checkBranchWrite(ctx, repo, req.CurrentUser, fileTargetBranch(req.Branch, req.NewBranch), false)
+	if err != nil {
+		return nil, err
+	}
 
 	user, err = c.user.FindByUsername(ctx, req.Username)
 	if err != nil {
@@ -674,8 +740,12 @@ func (c *repoComponentImpl) UpdateFile(ctx context.Context, req *types.UpdateFil
 	}
 
 	if useLfs {
+		storage, err := c.lfsRouter.ForNamespace(ctx, req.Namespace)
+		if err != nil {
+			return nil, err
+		}
 		objectKey := filepath.Join("lfs", req.Pointer.RelativePath())
-		uploadInfo, err := c.s3Client.PutObject(ctx, c.config.S3.Bucket, objectKey, bytes.NewReader(req.OriginalContent), req.Pointer.Size, minio.PutObjectOptions{})
+		uploadInfo, err := storage.Client.PutObject(ctx, storage.Bucket, objectKey, bytes.NewReader(req.OriginalContent), req.Pointer.Size, minio.PutObjectOptions{})
 		if err != nil {
 			return nil, fmt.Errorf("failed to upload to Minio: %w", err)
 		}
@@ -688,6 +758,7 @@ func (c *repoComponentImpl) UpdateFile(ctx context.Context, req *types.UpdateFil
 			Size:         req.Pointer.Size,
 			RepositoryID: repo.ID,
 			Existing:     true,
+			StorageID:    storage.ID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create LFS meta object: %w", err)
@@ -723,6 +794,10 @@ func (c *repoComponentImpl) DeleteFile(ctx context.Context, req *types.DeleteFil
 	if !permission.CanWrite {
 		return nil, ErrUnauthorized
 	}
+	err = c.checkBranchWrite(ctx, repo, req.CurrentUser, fileTargetBranch(req.Branch, req.NewBranch), false)
+	if err != nil {
+		return nil, err
+	}
 
 	user, err = c.user.FindByUsername(ctx, req.Username)
 	if err != nil {
@@ -947,13 +1022,17 @@ func (c *repoComponentImpl) DownloadFile(ctx context.Context, req *types.GetFile
 	}
 	if req.Lfs {
 		objectKey := path.Join("lfs", req.Path)
+		storage, err := c.lfsRouter.ForObject(ctx, repo, oidFromLfsRelativePath(req.Path))
+		if err != nil {
+			return nil, 0, downloadUrl, err
+		}
 
 		reqParams := make(url.Values)
 		if req.SaveAs != "" {
 			// allow rename when download through content-disposition header
 			reqParams.Set("response-content-disposition", fmt.Sprintf("attachment;filename=%s", req.SaveAs))
 		}
-		signedUrl, err := c.s3Client.PresignedGetObject(ctx, c.lfsBucket, objectKey, ossFileExpireSeconds, reqParams)
+		signedUrl, err := storage.Client.PresignedGetObject(ctx, storage.Bucket, objectKey, ossFileExpireSeconds, reqParams)
 		if err != nil {
 			return nil, 0, downloadUrl, err
 		}
@@ -1169,6 +1248,11 @@ func (c *repoComponentImpl) SDKListFiles(ctx context.Context, repoType types.Rep
 	if ref == "" {
 		ref = repo.DefaultBranch
 	}
+	sha := repo.DefaultBranch
+	if commitID := c.resolveReleaseRef(ctx, repo.ID, ref); commitID != ref {
+		ref = commitID
+		sha = commitID
+	}
 
 	filePaths, err := getFilePaths(namespace, name, "", repoType, ref, c.git.GetRepoFileTree)
 	if err != nil {
@@ -1185,11 +1269,15 @@ func (c *repoComponentImpl) SDKListFiles(ctx context.Context, repoType types.Rep
 		Downloads: repo.DownloadCount,
 		Likes:     repo.Likes,
 		Tags:      []string{},
-		SHA:       repo.DefaultBranch,
+		SHA:       sha,
 	}, nil
 }
 
 func (c *repoComponentImpl) IsLfs(ctx context.Context, req *types.GetFileReq) (bool, int64, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err == nil {
+		req.Ref = c.resolveReleaseRef(ctx, repo.ID, req.Ref)
+	}
 	getFileRawReq := gitserver.GetRepoInfoByPathReq{
 		Namespace: req.Namespace,
 		Name:      req.Name,
@@ -1224,6 +1312,7 @@ func (c *repoComponentImpl) HeadDownloadFile(ctx context.Context, req *types.Get
 	if req.Ref == "" {
 		req.Ref = repo.DefaultBranch
 	}
+	req.Ref = c.resolveReleaseRef(ctx, repo.ID, req.Ref)
 	getFileContentReq := gitserver.GetRepoInfoByPathReq{
 		Namespace: req.Namespace,
 		Name:      req.Name,
@@ -1259,6 +1348,7 @@ func (c *repoComponentImpl) SDKDownloadFile(ctx context.Context, req *types.GetF
 	if req.Ref == "" {
 		req.Ref = repo.DefaultBranch
 	}
+	req.Ref = c.resolveReleaseRef(ctx, repo.ID, req.Ref)
 	if req.Lfs {
 		getFileContentReq := gitserver.GetRepoInfoByPathReq{
 			Namespace: req.Namespace,
@@ -1273,12 +1363,16 @@ func (c *repoComponentImpl) SDKDownloadFile(ctx context.Context, req *types.GetF
 		}
 		objectKey := file.LfsRelativePath
 		objectKey = path.Join("lfs", objectKey)
+		storage, err := c.lfsRouter.ForObject(ctx, repo, oidFromLfsRelativePath(file.LfsRelativePath))
+		if err != nil {
+			return nil, 0, downloadUrl, err
+		}
 		reqParams := make(url.Values)
 		if req.SaveAs != "" {
 			// allow rename when download through content-disposition header
 			reqParams.Set("response-content-disposition", fmt.Sprintf("attachment;filename=%s", req.SaveAs))
 		}
-		signedUrl, err := c.s3Client.PresignedGetObject(ctx, c.lfsBucket, objectKey, ossFileExpireSeconds, reqParams)
+		signedUrl, err := storage.Client.PresignedGetObject(ctx, storage.Bucket, objectKey, ossFileExpireSeconds, reqParams)
 		if err != nil {
 			if err.Error() == ErrNotFoundMessage || err.Error() == ErrGetContentsOrList {
 				return nil, 0, downloadUrl, ErrNotFound
@@ -2110,6 +2204,10 @@ func (c *repoComponentImpl) DeployDetail(ctx context.Context, detailReq types.De
 		Path:             repoPath,
 		ProxyEndpoint:    proxyEndPoint,
 		SKU:              deploy.SKU,
+		Autoscaling:      deploy.Autoscaling,
+	}
+	if !deploy.LastRequestAt.IsZero() {
+		resDeploy.LastRequestAt = &deploy.LastRequestAt
 	}
 
 	return &resDeploy, nil
@@ -2241,6 +2339,41 @@ func (c *repoComponentImpl) AllowAccessEndpoint(ctx context.Context, currentUser
 	return c.checkAccessDeployForUser(ctx, deploy.RepoID, currentUser, deploy)
 }
 
+func (c *repoComponentImpl) RecordEndpointRequest(deploy *database.Deploy) {
+	c.endpoints.RecordRequest(deploy.ID)
+}
+
+func (c *repoComponentImpl) WakeupEndpoint(ctx context.Context, deploy *database.Deploy) error {
+	return c.endpoints.Wakeup(ctx, deploy)
+}
+
+func (c *repoComponentImpl) PublishEndpointTokenUsage(ctx context.Context, currentUser string, d *database.Deploy, usage types.LLMUsage) {
+	// anonymous calls of public endpoint are not metered
+	if currentUser == "" {
+		return
+	}
+	user, err := c.user.FindByUsername(ctx, currentUser)
+	if err != nil {
+		slog.Error("failed to find user for token metering", slog.String("username", currentUser), slog.Any("error", err))
+		return
+	}
+	var resourceName string
+	if resourceID, err := strconv.ParseInt(d.SKU, 10, 64); err == nil {
+		if resource, err := c.srs.FindByID(ctx, resourceID); err == nil {
+			resourceName = resource.Name
+		}
+	}
+	publishTokenMetering(c.eventPub, types.METERING_EVENT{
+		UserUUID:     user.UUID,
+		Scene:        int(deploy.GetValidSceneType(d.Type)),
+		ResourceID:   d.SKU,
+		ResourceName: resourceName,
+		CustomerID:   d.SvcName,
+	}, usage, map[string]any{
+		"deploy_id": d.ID,
+	})
+}
+
 // check access deploy permission
 func (c *repoComponentImpl) AllowAccessDeploy(ctx context.Context, req types.DeployActReq) (bool, error) {
 	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
@@ -2486,13 +2619,14 @@ func (c *repoComponentImpl) checkDeployPermissionForServerless(ctx context.Conte
 
 func (c *repoComponentImpl) DeployUpdate(ctx context.Context, updateReq types.DeployActReq, req *types.DeployUpdateReq) error {
 	var (
+		user   *database.User   = nil
 		deploy *database.Deploy = nil
 		err    error            = nil
 	)
 	if updateReq.DeployType == types.ServerlessType {
-		_, deploy, err = c.checkDeployPermissionForServerless(ctx, updateReq)
+		user, deploy, err = c.checkDeployPermissionForServerless(ctx, updateReq)
 	} else {
-		_, deploy, err = c.checkDeployPermissionForUser(ctx, updateReq)
+		user, deploy, err = c.checkDeployPermissionForUser(ctx, updateReq)
 	}
 	if err != nil {
 		return fmt.Errorf("fail to check permission for update deploy, %w", err)
@@ -2534,6 +2668,12 @@ func (c *repoComponentImpl) DeployUpdate(ctx context.Context, updateReq types.De
 		return errors.New("stop deploy first")
 	}
 
+	release, err := c.checkDeployQuota(ctx, user, updateReq.Namespace, deploy, req.ResourceID, req.MaxReplica)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// update inference service and keep deploy_id and svc_name unchanged
 	err = c.deployer.UpdateDeploy(ctx, req, deploy)
 	return err
@@ -2541,13 +2681,14 @@ func (c *repoComponentImpl) DeployUpdate(ctx context.Context, updateReq types.De
 
 func (c *repoComponentImpl) DeployStart(ctx context.Context, startReq types.DeployActReq) error {
 	var (
+		user   *database.User   = nil
 		deploy *database.Deploy = nil
 		err    error            = nil
 	)
 	if startReq.DeployType == types.ServerlessType {
-		_, deploy, err = c.checkDeployPermissionForServerless(ctx, startReq)
+		user, deploy, err = c.checkDeployPermissionForServerless(ctx, startReq)
 	} else {
-		_, deploy, err = c.checkDeployPermissionForUser(ctx, startReq)
+		user, deploy, err = c.checkDeployPermissionForUser(ctx, startReq)
 	}
 
 	if err != nil {
@@ -2573,6 +2714,12 @@ func (c *repoComponentImpl) DeployStart(ctx context.Context, startReq types.Depl
 		return errors.New("stop deploy first")
 	}
 
+	release, err := c.checkDeployQuota(ctx, user, startReq.Namespace, deploy, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// start deploy
 	err = c.deployer.StartDeploy(ctx, deploy)
 	if err != nil {
@@ -2582,6 +2729,37 @@ func (c *repoComponentImpl) DeployStart(ctx context.Context, startReq types.Depl
 	return err
 }
 
+// checkDeployQuota checks an existing deploy against the compute quota with the resource and max
+// replicas it will run with, the deploy itself is not counted as in use
+func (c *repoComponentImpl) checkDeployQuota(ctx context.Context, user *database.User, repoNamespace string, deploy *database.Deploy, resourceID *int64, maxReplica *int) (func(), error) {
+	resources := deploy.Hardware
+	if resourceID != nil {
+		resource, err := c.srs.FindByID(ctx, *resourceID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find resource, %w", err)
+		}
+		resources = resource.Resources
+	}
+	var hardware types.HardWare
+	err := json.Unmarshal([]byte(resources), &hardware)
+	if err != nil {
+		// deploys without valid hardware take no gpus
+		slog.Warn("invalid hardware setting of deploy", slog.Int64("deploy_id", deploy.ID), slog.Any("error", err))
+	}
+	replicas := deploy.MaxReplica
+	if maxReplica != nil {
+		replicas = *maxReplica
+	}
+	return c.quota.Check(ctx, quotaDeploy{
+		user:          user,
+		repoNamespace: repoNamespace,
+		deployType:    deploy.Type,
+		hardware:      hardware,
+		replicas:      replicas,
+		deployID:      deploy.ID,
+	})
+}
+
 func (c *repoComponentImpl) AllFiles(ctx context.Context, req types.GetAllFilesReq) ([]*types.File, error) {
 	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
 	if err != nil {
@@ -0,0 +1,238 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// SecretScanningComponent handles the secrets found in the files of repository by the secret scanning of
+// moderation service. The admins of repository review the findings, dismiss the false positives or the
+// revoked secrets, and manage the allowlist of secrets not to be reported
+type SecretScanningComponent interface {
+	IndexFindings(ctx context.Context, req types.SecretFindingsReq) ([]types.SecretFinding, int, error)
+	// DismissFinding dismisses the finding, and adds its secret to the allowlist of repository if asked
+	DismissFinding(ctx context.Context, req types.DismissSecretFindingReq) (*types.SecretFinding, error)
+	ListAllowlist(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string) ([]types.SecretAllowlistEntry, error)
+	// CreateAllowlistEntry adds the entry to allowlist, the open findings allowed by it are dismissed
+	CreateAllowlistEntry(ctx context.Context, req types.CreateSecretAllowlistEntryReq) (*types.SecretAllowlistEntry, error)
+	DeleteAllowlistEntry(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string, id int64) error
+}
+
+func NewSecretScanningComponent(config *config.Config) (SecretScanningComponent, error) {
+	c := &secretScanningComponentImpl{}
+	var err error
+	c.repoComponentImpl, err = NewRepoComponentImpl(config)
+	if err != nil {
+		return nil, err
+	}
+	c.findingStore = database.NewSecretFindingStore()
+	c.allowlistStore = database.NewSecretAllowlistStore()
+	return c, nil
+}
+
+type secretScanningComponentImpl struct {
+	*repoComponentImpl
+	findingStore   database.SecretFindingStore
+	allowlistStore database.SecretAllowlistStore
+}
+
+func (c *secretScanningComponentImpl) IndexFindings(ctx context.Context, req types.SecretFindingsReq) ([]types.SecretFinding, int, error) {
+	repo, err := c.findAdminRepo(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, 0, err
+	}
+	findings, total, err := c.findingStore.Index(ctx, repo.ID, req.Status, req.Per, req.Page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list secret findings, error: %w", err)
+	}
+	resp := make([]types.SecretFinding, 0, len(findings))
+	for _, f := range findings {
+		resp = append(resp, toSecretFinding(&f))
+	}
+	return resp, total, nil
+}
+
+func (c *secretScanningComponentImpl) DismissFinding(ctx context.Context, req types.DismissSecretFindingReq) (*types.SecretFinding, error) {
+	repo, err := c.findAdminRepo(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	finding, err := c.findingStore.FindByID(ctx, req.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find secret finding %d, error: %w", req.ID, err)
+	}
+	if finding.RepositoryID != repo.ID {
+		return nil, ErrNotFound
+	}
+	if finding.Status == types.SecretFindingDismissed {
+		return nil, fmt.Errorf("secret finding %d has been dismissed: %w", finding.ID, ErrBadRequest)
+	}
+
+	if req.Allowlist {
+		_, err = c.allowlistStore.Create(ctx, database.SecretAllowlistEntry{
+			RepositoryID: repo.ID,
+			Fingerprint:  finding.Fingerprint,
+			Reason:       req.Reason,
+			CreatedBy:    req.CurrentUser,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = c.dismiss(ctx, finding, req.CurrentUser, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	resp := toSecretFinding(finding)
+	return &resp, nil
+}
+
+func (c *secretScanningComponentImpl) ListAllowlist(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string) ([]types.SecretAllowlistEntry, error) {
+	repo, err := c.findAdminRepo(ctx, repoType, namespace, name, currentUser)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.allowlistStore.ListByRepo(ctx, repo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secret allowlist, error: %w", err)
+	}
+	resp := make([]types.SecretAllowlistEntry, 0, len(entries))
+	for _, e := range entries {
+		resp = append(resp, toSecretAllowlistEntry(&e))
+	}
+	return resp, nil
+}
+
+func (c *secretScanningComponentImpl) CreateAllowlistEntry(ctx context.Context, req types.CreateSecretAllowlistEntryReq) (*types.SecretAllowlistEntry, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", err.Error(), ErrBadRequest)
+	}
+	repo, err := c.findAdminRepo(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.allowlistStore.Create(ctx, database.SecretAllowlistEntry{
+		RepositoryID: repo.ID,
+		Fingerprint:  req.Fingerprint,
+		PathPattern:  req.PathPattern,
+		Reason:       req.Reason,
+		CreatedBy:    req.CurrentUser,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toSecretAllowlistEntry(entry)
+	open, err := c.findingStore.ListByRepo(ctx, repo.ID, types.SecretFindingOpen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open secret findings, error: %w", err)
+	}
+	for _, f := range open {
+		if !resp.Allows(f.Path, f.Fingerprint) {
+			continue
+		}
+		err = c.dismiss(ctx, &f, req.CurrentUser, fmt.Sprintf("allowed by secret allowlist entry %d", entry.ID))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &resp, nil
+}
+
+func (c *secretScanningComponentImpl) DeleteAllowlistEntry(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string, id int64) error {
+	repo, err := c.findAdminRepo(ctx, repoType, namespace, name, currentUser)
+	if err != nil {
+		return err
+	}
+	entries, err := c.allowlistStore.ListByRepo(ctx, repo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list secret allowlist, error: %w", err)
+	}
+	for _, e := range entries {
+		if e.ID == id {
+			// findings dismissed by the entry are kept, the secrets are reported again by the next scan
+			return c.allowlistStore.Delete(ctx, repo.ID, id)
+		}
+	}
+	return ErrNotFound
+}
+
+func (c *secretScanningComponentImpl) dismiss(ctx context.Context, finding *database.SecretFinding, currentUser, reason string) error {
+	finding.Status = types.SecretFindingDismissed
+	finding.DismissedBy = currentUser
+	finding.DismissedAt = time.Now()
+	finding.DismissReason = reason
+	err := c.findingStore.Update(ctx, finding, "status", "dismissed_by", "dismissed_at", "dismiss_reason")
+	if err != nil {
+		return err
+	}
+	slog.Info("secret finding dismissed", slog.Int64("finding_id", finding.ID), slog.String("path", finding.Path),
+		slog.String("dismissed_by", currentUser))
+	return nil
+}
+
+// findAdminRepo returns the repository if the current user can admin it, only the admins of repository
+// can see the findings of its secrets
+func (c *secretScanningComponentImpl) findAdminRepo(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string) (*database.Repository, error) {
+	repo, err := c.repo.FindByPath(ctx, repoType, namespace, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	permission, err := c.getUserRepoPermission(ctx, currentUser, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+	}
+	if !permission.CanAdmin {
+		return nil, ErrForbidden
+	}
+	return repo, nil
+}
+
+func toSecretFinding(f *database.SecretFinding) types.SecretFinding {
+	resp := types.SecretFinding{
+		ID:            f.ID,
+		RepositoryID:  f.RepositoryID,
+		Path:          f.Path,
+		CommitSha:     f.CommitSha,
+		Line:          f.Line,
+		RuleID:        f.RuleID,
+		Description:   f.Description,
+		Fingerprint:   f.Fingerprint,
+		Redacted:      f.Redacted,
+		Status:        f.Status,
+		DismissedBy:   f.DismissedBy,
+		DismissReason: f.DismissReason,
+		CreatedAt:     f.CreatedAt,
+		UpdatedAt:     f.UpdatedAt,
+	}
+	if !f.DismissedAt.IsZero() {
+		resp.DismissedAt = &f.DismissedAt
+	}
+	return resp
+}
+
+func toSecretAllowlistEntry(e *database.SecretAllowlistEntry) types.SecretAllowlistEntry {
+	return types.SecretAllowlistEntry{
+		ID:           e.ID,
+		RepositoryID: e.RepositoryID,
+		Fingerprint:  e.Fingerprint,
+		PathPattern:  e.PathPattern,
+		Reason:       e.Reason,
+		CreatedBy:    e.CreatedBy,
+		CreatedAt:    e.CreatedAt,
+	}
+}
@@ -67,13 +67,22 @@ func (c *sensitiveComponentImpl) CheckRequestV2(ctx context.Context, req types.S
 		if len(field.Value()) == 0 {
 			continue
 		}
-		result, err := c.checker.PassTextCheck(ctx, field.Scenario, field.Value())
+		var result *rpc.CheckResult
+		var err error
+		if field.IsImage {
+			result, err = c.checker.PassImageURLCheck(ctx, field.Scenario, field.Value())
+		} else {
+			result, err = c.checker.PassTextCheck(ctx, field.Scenario, field.Value())
+		}
 		if err != nil {
 			slog.Error("fail to check request sensitivity", slog.String("field", field.Name), slog.Any("error", err))
 			return false, fmt.Errorf("fail to check '%s' sensitivity, error: %w", field.Name, err)
 		}
 		if result.IsSensitive {
-			slog.Error("found sensitive words in request", slog.String("field", field.Name))
+			slog.Error("found sensitive content in request", slog.String("field", field.Name))
+			if field.IsImage {
+				return false, errors.New("found sensitive image in field: " + field.Name)
+			}
 			return false, errors.New("found sensitive words in field: " + field.Name)
 		}
 	}
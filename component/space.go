@@ -70,6 +70,7 @@ func NewSpaceComponent(config *config.Config) (SpaceComponent, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.quota = newComputeQuotaChecker()
 
 	return c, nil
 }
@@ -84,6 +85,7 @@ type spaceComponentImpl struct {
 	deployer         deploy.Deployer
 	publicRootDomain string
 	ac               AccountingComponent
+	quota            *computeQuotaChecker
 }
 
 func (c *spaceComponentImpl) Create(ctx context.Context, req types.CreateSpaceReq) (*types.Space, error) {
@@ -603,6 +605,25 @@ func (c *spaceComponentImpl) Deploy(ctx context.Context, namespace, name, curren
 		return -1, err
 	}
 
+	var hardware types.HardWare
+	err = json.Unmarshal([]byte(s.Hardware), &hardware)
+	if err != nil {
+		// spaces without valid hardware take no gpus
+		slog.Warn("invalid hardware setting of space", slog.Any("error", err), slog.String("namespace", namespace), slog.String("name", name))
+	}
+	release, err := c.quota.Check(ctx, quotaDeploy{
+		user:          &user,
+		repoNamespace: namespace,
+		deployType:    types.SpaceType,
+		hardware:      hardware,
+		replicas:      1,
+		spaceID:       s.ID,
+	})
+	if err != nil {
+		return -1, err
+	}
+	defer release()
+
 	// put repo-type and namespace/name in annotation
 	annotations := make(map[string]string)
 	annotations[types.ResTypeKey] = string(types.SpaceRepo)
@@ -0,0 +1,43 @@
+package component
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"opencsg.com/csghub-server/builder/event"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// publishTokenMetering publishes the token usage of a llm call as a token metering event of meter,
+// the prompt and completion tokens are added to extra of the event for the statements of accounting
+func publishTokenMetering(eventPub *event.EventPublisher, meter types.METERING_EVENT, usage types.LLMUsage, extra map[string]any) {
+	tokens := usage.PromptTokens + usage.CompletionTokens
+	if tokens == 0 || meter.UserUUID == "" {
+		return
+	}
+	if extra == nil {
+		extra = make(map[string]any)
+	}
+	extra["prompt_tokens"] = usage.PromptTokens
+	extra["completion_tokens"] = usage.CompletionTokens
+	extraStr, _ := json.Marshal(extra)
+
+	meter.Uuid = uuid.New()
+	meter.Value = tokens
+	meter.ValueType = types.TokenNumberType
+	meter.CreatedAt = time.Now()
+	meter.Extra = string(extraStr)
+	str, err := json.Marshal(meter)
+	if err != nil {
+		slog.Error("error marshal token metering event", slog.Any("event", meter), slog.Any("error", err))
+		return
+	}
+	err = eventPub.PublishTokenMeteringEvent(str)
+	if err != nil {
+		slog.Error("failed to pub token metering event", slog.Any("data", string(str)), slog.Any("error", err))
+	} else {
+		slog.Debug("pub token metering event success", slog.Any("data", string(str)))
+	}
+}
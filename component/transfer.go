@@ -0,0 +1,110 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func (c *repoComponentImpl) Transfer(ctx context.Context, req types.TransferRepoReq) (*types.TransferRepoResp, error) {
+	repo, err := c.repo.FindByPath(ctx, req.RepoType, req.Namespace, req.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find repo, error: %w", err)
+	}
+	user, err := c.user.FindByUsername(ctx, req.CurrentUser)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !user.CanAdmin() {
+		permission, err := c.getUserRepoPermission(ctx, req.CurrentUser, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+		}
+		if !permission.CanAdmin {
+			return nil, ErrForbidden
+		}
+	}
+	if req.NewNamespace == req.Namespace {
+		return nil, fmt.Errorf("%w: repository is in namespace %s already", ErrBadRequest, req.NewNamespace)
+	}
+	target, err := c.namespace.FindByPath(ctx, req.NewNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("%w: namespace %s does not exist", ErrBadRequest, req.NewNamespace)
+	}
+	err = c.checkCreateRepoPermission(ctx, user, &target, req.RepoType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrForbidden, err)
+	}
+	exists, err := c.repo.Exists(ctx, req.RepoType, target.Path, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check repo existence, error: %w", err)
+	}
+	if exists {
+		return nil, ErrAlreadyExists
+	}
+
+	gitReq := gitserver.TransferRepoReq{
+		Namespace:     req.Namespace,
+		Name:          req.Name,
+		NewNamespace:  target.Path,
+		Username:      req.CurrentUser,
+		Nickname:      repo.Nickname,
+		Description:   repo.Description,
+		DefaultBranch: repo.DefaultBranch,
+		RepoType:      req.RepoType,
+		Private:       repo.Private,
+	}
+	gitRepo, err := c.git.TransferRepo(ctx, gitReq)
+	if err != nil {
+		return nil, fmt.Errorf("fail to transfer repo in git, error: %w", err)
+	}
+
+	oldPath := repo.Path
+	repo.Path = path.Join(target.Path, req.Name)
+	repo.GitPath = gitRepo.GitPath
+	// the repository belongs to the user if moved to a personal namespace, otherwise it keeps the creator
+	if target.NamespaceType == database.UserNamespace {
+		repo.UserID = target.UserID
+	}
+	if gitRepo.HttpCloneURL != "" {
+		repo.HTTPCloneURL = gitRepo.HttpCloneURL
+	}
+	if gitRepo.SshCloneURL != "" {
+		repo.SSHCloneURL = gitRepo.SshCloneURL
+	}
+	// likes, downloads, relations and deploys reference the repository by id, so they are kept
+	repo, err = c.repo.Transfer(ctx, *repo, oldPath)
+	if err != nil {
+		// undo the move in git, otherwise the database points to a path which does not exist
+		revertErr := c.git.AbortTransferRepo(ctx, gitReq)
+		if revertErr != nil {
+			slog.Error("fail to abort transfer of git repo", slog.String("repo_type", string(req.RepoType)),
+				slog.String("path", oldPath), slog.String("new_namespace", target.Path), slog.Any("error", revertErr))
+		}
+		return nil, fmt.Errorf("fail to transfer database repo, error: %w", err)
+	}
+	// the database points to the new path already, a failure only leaves the old git repository behind
+	err = c.git.CompleteTransferRepo(ctx, gitReq)
+	if err != nil {
+		slog.Error("fail to complete transfer of git repo", slog.String("repo_type", string(req.RepoType)),
+			slog.String("path", oldPath), slog.String("new_namespace", target.Path), slog.Any("error", err))
+	}
+
+	return &types.TransferRepoResp{
+		Path:         repo.Path,
+		OldPath:      oldPath,
+		RepoType:     repo.RepositoryType,
+		HTTPCloneURL: repo.HTTPCloneURL,
+		SSHCloneURL:  repo.SSHCloneURL,
+	}, nil
+}
@@ -0,0 +1,126 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/git/gitserver"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type transferRepoStore struct {
+	database.RepoStore
+	repo        *database.Repository
+	transferErr error
+	transferred *database.Repository
+}
+
+func (s *transferRepoStore) FindByPath(ctx context.Context, repoType types.RepositoryType, namespace, name string) (*database.Repository, error) {
+	if s.repo.Path != namespace+"/"+name {
+		return nil, sql.ErrNoRows
+	}
+	repo := *s.repo
+	return &repo, nil
+}
+
+func (s *transferRepoStore) Exists(ctx context.Context, repoType types.RepositoryType, namespace, name string) (bool, error) {
+	return s.repo.Path == namespace+"/"+name, nil
+}
+
+func (s *transferRepoStore) Transfer(ctx context.Context, input database.Repository, oldPath string) (*database.Repository, error) {
+	if s.transferErr != nil {
+		return nil, s.transferErr
+	}
+	s.transferred = &input
+	return &input, nil
+}
+
+type transferUserStore struct {
+	database.UserStore
+}
+
+func (s *transferUserStore) FindByUsername(ctx context.Context, username string) (database.User, error) {
+	return database.User{Username: username, RoleMask: "admin"}, nil
+}
+
+type transferNamespaceStore struct {
+	database.NamespaceStore
+}
+
+func (s *transferNamespaceStore) FindByPath(ctx context.Context, path string) (database.Namespace, error) {
+	return database.Namespace{Path: path, NamespaceType: database.OrgNamespace}, nil
+}
+
+// transferGitServer records the phases of transfers
+type transferGitServer struct {
+	gitserver.GitServer
+	completed []gitserver.TransferRepoReq
+	aborted   []gitserver.TransferRepoReq
+}
+
+func (s *transferGitServer) TransferRepo(ctx context.Context, req gitserver.TransferRepoReq) (*gitserver.CreateRepoResp, error) {
+	return &gitserver.CreateRepoResp{GitPath: "models_" + req.NewNamespace + "/" + req.Name}, nil
+}
+
+func (s *transferGitServer) CompleteTransferRepo(ctx context.Context, req gitserver.TransferRepoReq) error {
+	s.completed = append(s.completed, req)
+	return nil
+}
+
+func (s *transferGitServer) AbortTransferRepo(ctx context.Context, req gitserver.TransferRepoReq) error {
+	s.aborted = append(s.aborted, req)
+	return nil
+}
+
+func newTransferTestComponent(repos *transferRepoStore, git *transferGitServer) *repoComponentImpl {
+	return &repoComponentImpl{
+		repo:      repos,
+		user:      &transferUserStore{},
+		namespace: &transferNamespaceStore{},
+		git:       git,
+	}
+}
+
+func TestRepoComponent_Transfer(t *testing.T) {
+	ctx := context.Background()
+	req := types.TransferRepoReq{
+		Namespace: "alice", Name: "llm", NewNamespace: "acme", RepoType: types.ModelRepo, CurrentUser: "admin",
+	}
+
+	repos := &transferRepoStore{repo: &database.Repository{
+		ID: 1, Path: "alice/llm", Nickname: "LLM", Private: true, DefaultBranch: "main", RepositoryType: types.ModelRepo,
+	}}
+	git := &transferGitServer{}
+	resp, err := newTransferTestComponent(repos, git).Transfer(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "acme/llm", resp.Path)
+	require.Equal(t, "alice/llm", resp.OldPath)
+	require.Equal(t, "models_acme/llm", repos.transferred.GitPath)
+	require.Len(t, git.completed, 1)
+	require.Empty(t, git.aborted)
+
+	// the move in git is undone with the attributes of repository if it can not be saved in database
+	repos = &transferRepoStore{
+		repo: &database.Repository{
+			ID: 1, Path: "alice/llm", Nickname: "LLM", Private: true, DefaultBranch: "main", RepositoryType: types.ModelRepo,
+		},
+		transferErr: errors.New("db is down"),
+	}
+	git = &transferGitServer{}
+	_, err = newTransferTestComponent(repos, git).Transfer(ctx, req)
+	require.Error(t, err)
+	require.Empty(t, git.completed)
+	require.Equal(t, []gitserver.TransferRepoReq{{
+		Namespace: "alice", Name: "llm", NewNamespace: "acme", Username: "admin", Nickname: "LLM",
+		DefaultBranch: "main", RepoType: types.ModelRepo, Private: true,
+	}}, git.aborted)
+
+	_, err = newTransferTestComponent(repos, git).Transfer(ctx, types.TransferRepoReq{
+		Namespace: "alice", Name: "llm", NewNamespace: "alice", RepoType: types.ModelRepo, CurrentUser: "admin",
+	})
+	require.ErrorIs(t, err, ErrBadRequest)
+}
@@ -0,0 +1,357 @@
+package component
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"time"
+
+	"opencsg.com/csghub-server/builder/git/membership"
+	"opencsg.com/csghub-server/builder/safehttp"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/webhook"
+	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type webhookComponentImpl struct {
+	*repoComponentImpl
+	webhookStore database.WebhookStore
+	dispatcher   *webhook.Dispatcher
+}
+
+// WebhookComponent manages the webhooks of a repository, or of an organization when the repository name is empty
+type WebhookComponent interface {
+	Index(ctx context.Context, req types.WebhookReq) ([]types.Webhook, error)
+	Create(ctx context.Context, req types.WebhookReq) (*types.Webhook, error)
+	Get(ctx context.Context, req types.WebhookReq) (*types.Webhook, error)
+	Update(ctx context.Context, req types.WebhookReq) (*types.Webhook, error)
+	Delete(ctx context.Context, req types.WebhookReq) error
+	// Ping sends a ping event to the webhook and waits for the result
+	Ping(ctx context.Context, req types.WebhookReq) (*types.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, req types.WebhookDeliveriesReq) ([]types.WebhookDelivery, int, error)
+	// Redeliver sends the payload of a delivery again as a new delivery and waits for the result
+	Redeliver(ctx context.Context, req types.WebhookDeliveriesReq) (*types.WebhookDelivery, error)
+}
+
+func NewWebhookComponent(config *config.Config) (WebhookComponent, error) {
+	rc, err := NewRepoComponentImpl(config)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookComponentImpl{
+		repoComponentImpl: rc,
+		webhookStore:      database.NewWebhookStore(),
+		dispatcher:        webhook.NewDispatcher(),
+	}, nil
+}
+
+// webhookScope is the repository or the organization which webhooks belong to
+type webhookScope struct {
+	repo      *database.Repository
+	namespace string
+	user      *database.User
+}
+
+func (s *webhookScope) owns(w *database.Webhook) bool {
+	if s.repo != nil {
+		return w.RepositoryID == s.repo.ID
+	}
+	return w.RepositoryID == 0 && w.Namespace == s.namespace
+}
+
+func (c *webhookComponentImpl) Index(ctx context.Context, req types.WebhookReq) ([]types.Webhook, error) {
+	scope, err := c.findScopeForAdmin(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []database.Webhook
+	if scope.repo != nil {
+		webhooks, err = c.webhookStore.ListByRepoID(ctx, scope.repo.ID)
+	} else {
+		webhooks, err = c.webhookStore.ListByNamespace(ctx, scope.namespace)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks, error: %w", err)
+	}
+	resp := make([]types.Webhook, 0, len(webhooks))
+	for _, w := range webhooks {
+		if !scope.owns(&w) {
+			continue
+		}
+		resp = append(resp, toWebhook(&w))
+	}
+	return resp, nil
+}
+
+func (c *webhookComponentImpl) Create(ctx context.Context, req types.WebhookReq) (*types.Webhook, error) {
+	scope, err := c.findScopeForAdmin(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhookReq(req); err != nil {
+		return nil, err
+	}
+	w := database.Webhook{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+		Active: req.Active,
+		UserID: scope.user.ID,
+	}
+	if scope.repo != nil {
+		w.RepositoryID = scope.repo.ID
+	} else {
+		w.Namespace = scope.namespace
+	}
+	created, err := c.webhookStore.Create(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	resp := toWebhook(created)
+	return &resp, nil
+}
+
+func (c *webhookComponentImpl) Get(ctx context.Context, req types.WebhookReq) (*types.Webhook, error) {
+	_, w, err := c.findWebhookForAdmin(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp := toWebhook(w)
+	return &resp, nil
+}
+
+func (c *webhookComponentImpl) Update(ctx context.Context, req types.WebhookReq) (*types.Webhook, error) {
+	_, w, err := c.findWebhookForAdmin(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhookReq(req); err != nil {
+		return nil, err
+	}
+	w.URL = req.URL
+	if req.Secret != "" {
+		w.Secret = req.Secret
+	}
+	w.Events = req.Events
+	w.Active = req.Active
+	w, err = c.webhookStore.Update(ctx, *w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook, error: %w", err)
+	}
+	resp := toWebhook(w)
+	return &resp, nil
+}
+
+func (c *webhookComponentImpl) Delete(ctx context.Context, req types.WebhookReq) error {
+	_, w, err := c.findWebhookForAdmin(ctx, req)
+	if err != nil {
+		return err
+	}
+	err = c.webhookStore.Delete(ctx, *w)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook, error: %w", err)
+	}
+	return nil
+}
+
+func (c *webhookComponentImpl) Ping(ctx context.Context, req types.WebhookReq) (*types.WebhookDelivery, error) {
+	scope, w, err := c.findWebhookForAdmin(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := webhook.NewPayload(scope.repo, types.WebhookEventPing, map[string]any{
+		"webhook_id": w.ID,
+		"events":     w.Events,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.deliverNow(ctx, w, types.WebhookEventPing, string(payload))
+}
+
+func (c *webhookComponentImpl) ListDeliveries(ctx context.Context, req types.WebhookDeliveriesReq) ([]types.WebhookDelivery, int, error) {
+	_, w, err := c.findWebhookForAdmin(ctx, types.WebhookReq{
+		ID:          req.WebhookID,
+		RepoType:    req.RepoType,
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		CurrentUser: req.CurrentUser,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	deliveries, total, err := c.webhookStore.ListDeliveries(ctx, w.ID, req.Per, req.Page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries, error: %w", err)
+	}
+	resp := make([]types.WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		resp = append(resp, toWebhookDelivery(&d))
+	}
+	return resp, total, nil
+}
+
+func (c *webhookComponentImpl) Redeliver(ctx context.Context, req types.WebhookDeliveriesReq) (*types.WebhookDelivery, error) {
+	_, w, err := c.findWebhookForAdmin(ctx, types.WebhookReq{
+		ID:          req.WebhookID,
+		RepoType:    req.RepoType,
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		CurrentUser: req.CurrentUser,
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := c.webhookStore.FindDeliveryByID(ctx, req.DeliveryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find webhook delivery, error: %w", err)
+	}
+	if delivery.WebhookID != w.ID {
+		return nil, ErrNotFound
+	}
+	return c.deliverNow(ctx, w, delivery.Event, delivery.Payload)
+}
+
+// deliverNow records a new delivery and sends it immediately, it is retried in background if failed
+func (c *webhookComponentImpl) deliverNow(ctx context.Context, w *database.Webhook, event types.WebhookEvent, payload string) (*types.WebhookDelivery, error) {
+	// the delivery is due after the attempt, so that dispatchers do not send it at the same time
+	delivery, err := c.webhookStore.CreateDelivery(ctx, database.WebhookDelivery{
+		WebhookID:     w.ID,
+		Event:         event,
+		Payload:       payload,
+		Status:        types.WebhookDeliveryPending,
+		NextAttemptAt: time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery, err = c.dispatcher.Deliver(ctx, w, delivery)
+	if err != nil {
+		return nil, err
+	}
+	resp := toWebhookDelivery(delivery)
+	return &resp, nil
+}
+
+func (c *webhookComponentImpl) findScopeForAdmin(ctx context.Context, repoType types.RepositoryType, namespace, name, currentUser string) (*webhookScope, error) {
+	user, err := c.user.FindByUsername(ctx, currentUser)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	scope := &webhookScope{namespace: namespace, user: &user}
+	if name != "" {
+		repo, err := c.repo.FindByPath(ctx, repoType, namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find repo, error: %w", err)
+		}
+		permission, err := c.getUserRepoPermission(ctx, currentUser, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user repo permission, error: %w", err)
+		}
+		if !permission.CanAdmin {
+			return nil, ErrForbidden
+		}
+		scope.repo = repo
+		return scope, nil
+	}
+
+	ns, err := c.namespace.FindByPath(ctx, namespace)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find namespace, error: %w", err)
+	}
+	if ns.NamespaceType != database.OrgNamespace {
+		return nil, ErrNotFound
+	}
+	canAdmin, err := c.checkCurrentUserPermission(ctx, currentUser, namespace, membership.RoleAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check organization permission, error: %w", err)
+	}
+	if !canAdmin {
+		return nil, ErrForbidden
+	}
+	return scope, nil
+}
+
+func (c *webhookComponentImpl) findWebhookForAdmin(ctx context.Context, req types.WebhookReq) (*webhookScope, *database.Webhook, error) {
+	scope, err := c.findScopeForAdmin(ctx, req.RepoType, req.Namespace, req.Name, req.CurrentUser)
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err := c.webhookStore.FindByID(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to find webhook, error: %w", err)
+	}
+	if !scope.owns(w) {
+		return nil, nil, ErrNotFound
+	}
+	return scope, w, nil
+}
+
+func validateWebhookReq(req types.WebhookReq) error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: invalid webhook url '%s'", ErrBadRequest, req.URL)
+	}
+	// hostnames resolved to internal addresses are rejected when delivering
+	if ip := net.ParseIP(u.Hostname()); u.Hostname() == "localhost" || (ip != nil && safehttp.IsInternalIP(ip)) {
+		return fmt.Errorf("%w: webhook url '%s' of internal address is not allowed", ErrBadRequest, req.URL)
+	}
+	if len(req.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrBadRequest)
+	}
+	for _, e := range req.Events {
+		if !slices.Contains(types.WebhookEvents, e) {
+			return fmt.Errorf("%w: unknown webhook event '%s'", ErrBadRequest, e)
+		}
+	}
+	return nil
+}
+
+func toWebhook(w *database.Webhook) types.Webhook {
+	return types.Webhook{
+		ID:        w.ID,
+		URL:       w.URL,
+		HasSecret: w.Secret != "",
+		Events:    w.Events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func toWebhookDelivery(d *database.WebhookDelivery) types.WebhookDelivery {
+	resp := types.WebhookDelivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		Event:          d.Event,
+		Payload:        json.RawMessage(d.Payload),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		Error:          d.Error,
+		CreatedAt:      d.CreatedAt,
+	}
+	if d.Status == types.WebhookDeliveryPending && !d.NextAttemptAt.IsZero() {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+	if !d.DeliveredAt.IsZero() {
+		resp.DeliveredAt = &d.DeliveredAt
+	}
+	return resp
+}
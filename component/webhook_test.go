@@ -0,0 +1,30 @@
+package component
+
+import (
+	"errors"
+	"testing"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestValidateWebhookReq(t *testing.T) {
+	cases := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://example.com/hook", true},
+		{"http://8.8.8.8:8080/hook", true},
+		{"ftp://example.com/hook", false},
+		{"http://localhost:8080/hook", false},
+		{"http://127.0.0.1/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://[::1]/hook", false},
+		{"http://10.0.0.1/hook", false},
+	}
+	for _, c := range cases {
+		err := validateWebhookReq(types.WebhookReq{URL: c.url, Events: []types.WebhookEvent{types.WebhookEventPush}})
+		if (err == nil) != c.valid || (err != nil && !errors.Is(err, ErrBadRequest)) {
+			t.Errorf("expected webhook url '%s' to be valid %v, got error: %v", c.url, c.valid, err)
+		}
+	}
+}
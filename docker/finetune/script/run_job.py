@@ -0,0 +1,182 @@
+"""
+Run a CSGHub finetune job with LLaMA-Factory
+
+The job is passed by env FINETUNE_JOB_ID, FINETUNE_JOB_CALLBACK, DATASET_ID and HYPERPARAMETERS,
+progress and checkpoints are reported to the callback url with the access token of job owner,
+and the trained weights are pushed to the output model created by CSGHub when training is done.
+"""
+
+import glob
+import json
+import os
+import re
+import shutil
+import subprocess
+import time
+import urllib.error
+import urllib.request
+
+JOB_DIR = "/workspace/finetune_job"
+DATA_DIR = os.path.join(JOB_DIR, "data")
+OUTPUT_DIR = os.path.join(JOB_DIR, "output")
+UPLOAD_DIR = os.path.join(JOB_DIR, "upload")
+DATASET_NAME = "csghub_job_dataset"
+REPORT_INTERVAL = 30
+
+
+class JobFinished(Exception):
+    pass
+
+
+def report(event_type, **kwargs):
+    body = dict(kwargs, type=event_type)
+    req = urllib.request.Request(
+        os.environ["FINETUNE_JOB_CALLBACK"],
+        data=json.dumps(body).encode("utf-8"),
+        headers={
+            "Content-Type": "application/json",
+            "Authorization": "Bearer " + os.environ["ACCESS_TOKEN"],
+        },
+        method="POST",
+    )
+    for attempt in range(5):
+        try:
+            with urllib.request.urlopen(req, timeout=30) as resp:
+                return json.loads(resp.read()).get("data") or {}
+        except urllib.error.HTTPError as e:
+            if e.code == 400:
+                # the job is finished or cancelled
+                raise JobFinished(e.read().decode("utf-8", "replace"))
+            print(f"failed to report {event_type} event: {e}", flush=True)
+        except Exception as e:
+            print(f"failed to report {event_type} event: {e}", flush=True)
+        time.sleep(2 ** attempt)
+    raise RuntimeError(f"failed to report {event_type} event")
+
+
+def train_args(hp):
+    method = hp.get("method") or "lora"
+    args = {
+        "stage": "sft",
+        "do_train": True,
+        "model_name_or_path": os.environ["REPO_ID"],
+        "finetuning_type": method,
+        "dataset": DATASET_NAME,
+        "dataset_dir": DATA_DIR,
+        "template": hp.get("template") or "default",
+        "cutoff_len": hp.get("max_seq_length") or 1024,
+        "output_dir": OUTPUT_DIR,
+        "overwrite_output_dir": True,
+        "num_train_epochs": hp["epochs"],
+        "learning_rate": hp.get("learning_rate") or 1e-4,
+        "per_device_train_batch_size": hp.get("batch_size") or 1,
+        "logging_steps": 10,
+        "save_strategy": "epoch",
+        "report_to": "none",
+    }
+    if method == "lora":
+        args["lora_target"] = "all"
+    return args
+
+
+def metrics_of(log):
+    return {k: v for k, v in log.items() if isinstance(v, (int, float)) and not isinstance(v, bool)}
+
+
+def train(hp):
+    os.makedirs(DATA_DIR, exist_ok=True)
+    with open(os.path.join(DATA_DIR, "dataset_info.json"), "w") as f:
+        json.dump({DATASET_NAME: {"hf_hub_url": os.environ["DATASET_ID"]}}, f)
+    args_file = os.path.join(JOB_DIR, "train_args.json")
+    with open(args_file, "w") as f:
+        json.dump(train_args(hp), f, indent=2)
+
+    proc = subprocess.Popen(["llamafactory-cli", "train", args_file])
+    log_file = os.path.join(OUTPUT_DIR, "trainer_log.jsonl")
+    log_pos, last_log, last_report = 0, None, 0
+    checkpoints = set()
+    while True:
+        running = proc.poll() is None
+        if os.path.exists(log_file):
+            with open(log_file) as f:
+                f.seek(log_pos)
+                for line in f:
+                    try:
+                        last_log = json.loads(line)
+                    except ValueError:
+                        pass
+                log_pos = f.tell()
+        if last_log and time.time() - last_report > REPORT_INTERVAL:
+            report("progress", epoch=int(last_log.get("epoch", 0)),
+                   step=last_log.get("current_steps", 0), metrics=metrics_of(last_log))
+            last_report = time.time()
+        for path in sorted(glob.glob(os.path.join(OUTPUT_DIR, "checkpoint-*"))):
+            name = os.path.basename(path)
+            if name in checkpoints:
+                continue
+            checkpoints.add(name)
+            step = int(re.sub(r"\D", "", name) or 0)
+            log = last_log or {}
+            report("checkpoint", epoch=int(round(log.get("epoch", 0))), step=step,
+                   checkpoint=name, metrics=metrics_of(log))
+        if not running:
+            return proc.returncode
+        time.sleep(5)
+
+
+def push(clone_url):
+    token_url = re.sub(r"^(https?://)", r"\g<1>" + os.environ["ACCESS_TOKEN"] + "@", clone_url)
+    shutil.rmtree(UPLOAD_DIR, ignore_errors=True)
+    subprocess.run(["git", "clone", token_url, UPLOAD_DIR], check=True)
+    for name in os.listdir(OUTPUT_DIR):
+        if name.startswith("checkpoint-") or name == "README.md":
+            continue
+        src = os.path.join(OUTPUT_DIR, name)
+        dst = os.path.join(UPLOAD_DIR, name)
+        if os.path.isdir(src):
+            shutil.copytree(src, dst, dirs_exist_ok=True)
+        else:
+            shutil.copy2(src, dst)
+    git = ["git", "-C", UPLOAD_DIR]
+    subprocess.run(git + ["lfs", "install", "--local"], check=True)
+    subprocess.run(git + ["config", "user.name", "csghub"], check=True)
+    subprocess.run(git + ["config", "user.email", "csghub@opencsg.com"], check=True)
+    subprocess.run(git + ["add", "."], check=True)
+    subprocess.run(git + ["commit", "-m", f"Upload weights of finetune job {os.environ['FINETUNE_JOB_ID']}"], check=True)
+    subprocess.run(git + ["push", "origin", "HEAD"], check=True)
+
+
+def main():
+    hp = json.loads(os.environ["HYPERPARAMETERS"])
+    # also tells whether the job is finished, the container may be restarted after the job is done
+    report("progress", epoch=0, step=0)
+
+    code = train(hp)
+    if code != 0:
+        report("failed", message=f"training exited with code {code}, check the logs for details")
+        return
+
+    resp = report("uploading")
+    try:
+        push(resp["http_clone_url"])
+    except subprocess.CalledProcessError as e:
+        # do not report the command, the clone url has the access token in it
+        report("failed", message=f"failed to push weights to {resp.get('output_model')}, git exited with code {e.returncode}")
+        return
+    report("succeeded", message=f"weights are pushed to {resp.get('output_model')}")
+
+
+if __name__ == "__main__":
+    try:
+        main()
+    except JobFinished as e:
+        print(f"finetune job is finished: {e}", flush=True)
+    except Exception as e:
+        print(f"finetune job failed: {e}", flush=True)
+        try:
+            report("failed", message=str(e))
+        except Exception:
+            pass
+    # keep the container alive until CSGHub stops it, so that it is not restarted to train again
+    while True:
+        time.sleep(3600)
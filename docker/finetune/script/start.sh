@@ -1,5 +1,10 @@
 #!/bin/bash
 
+if [ "x${FINETUNE_JOB_ID}" != "x" ]; then
+    # run finetune job instead of the web ui
+    exec python3 /etc/csghub/run_job.py
+fi
+
 if [ "x${REPO_ID}" != "x" ]; then
     MODEL_NAME=$(echo "$REPO_ID" | cut -d'/' -f2)
     grep -qF "${REPO_ID}" /etc/csghub/LLaMA-Factory/src/llamafactory/extras/constants.py
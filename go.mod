@@ -40,6 +40,7 @@ require (
 	github.com/uptrace/bun/extra/bundebug v1.1.16
 	gitlab.com/gitlab-org/gitaly/v16 v16.11.8
 	go.temporal.io/sdk v1.30.0
+	golang.org/x/time v0.5.0
 	google.golang.org/grpc v1.66.0
 	gopkg.in/yaml.v2 v2.4.0
 	gopkg.in/yaml.v3 v3.0.1
@@ -237,7 +238,6 @@ require (
 	golang.org/x/sys v0.24.0 // indirect
 	golang.org/x/term v0.23.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
-	golang.org/x/time v0.5.0 // indirect
 	golang.org/x/tools v0.21.1-0.20240508182429-e35e4ccd0d2d // indirect
 	gomodules.xyz/jsonpatch/v2 v2.4.0 // indirect
 	google.golang.org/protobuf v1.34.2
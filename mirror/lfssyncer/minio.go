@@ -16,6 +16,7 @@ import (
 	"github.com/minio/minio-go/v7"
 	"opencsg.com/csghub-server/builder/store/database"
 	"opencsg.com/csghub-server/builder/store/s3"
+	"opencsg.com/csghub-server/builder/webhook"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
 	"opencsg.com/csghub-server/mirror/queue"
@@ -27,25 +28,27 @@ type MinioLFSSyncWorker struct {
 	wg                 sync.WaitGroup
 	mirrorStore        database.MirrorStore
 	lfsMetaObjectStore database.LfsMetaObjectStore
-	s3Client           *s3.Client
+	lfsRouter          s3.LfsRouter
 	config             *config.Config
 	repoStore          database.RepoStore
 	numWorkers         int
+	webhookNotifier    webhook.Notifier
 }
 
 func NewMinioLFSSyncWorker(config *config.Config, numWorkers int) (*MinioLFSSyncWorker, error) {
 	var err error
 	w := &MinioLFSSyncWorker{}
 	w.numWorkers = numWorkers
-	w.s3Client, err = s3.NewMinio(config)
+	w.lfsRouter, err = s3.NewLfsRouter(config)
 	if err != nil {
-		newError := fmt.Errorf("fail to init s3 client for code,error:%w", err)
+		newError := fmt.Errorf("fail to init lfs router,error:%w", err)
 		slog.Error(newError.Error())
 		return nil, newError
 	}
 	w.mirrorStore = database.NewMirrorStore()
 	w.repoStore = database.NewRepoStore()
 	w.lfsMetaObjectStore = database.NewLfsMetaObjectStore()
+	w.webhookNotifier = webhook.NewNotifier()
 	w.config = config
 	mq, err := queue.GetPriorityQueueInstance()
 	if err != nil {
@@ -224,13 +227,18 @@ func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFiles(ctx context.Context, mirr
 	lfsFilesCount := len(pointers)
 	for _, pointer := range pointers {
 		objectKey := filepath.Join("lfs", pointer.RelativePath())
-		fileInfo, err := w.s3Client.StatObject(ctx, w.config.S3.Bucket, objectKey, minio.StatObjectOptions{})
+		// objects synced before are kept in their storage, new objects go to the storage of the namespace
+		storage, err := w.lfsRouter.ForObject(ctx, mirror.Repository, pointer.Oid)
+		if err != nil {
+			return fmt.Errorf("failed to find lfs storage: %w", err)
+		}
+		fileInfo, err := storage.Client.StatObject(ctx, storage.Bucket, objectKey, minio.StatObjectOptions{})
 		if err != nil && err.Error() != "The specified key does not exist." {
 			slog.Error("failed to check if LFS file exists", slog.Any("error", err))
 			continue
 		}
 		if (err != nil && err.Error() != "The specified key does not exist.") || fileInfo.Size != pointer.Size {
-			err = w.DownloadAndUploadLFSFile(ctx, mirror, pointer)
+			err = w.DownloadAndUploadLFSFile(ctx, storage, pointer)
 			if err != nil {
 				slog.Error("failed to download and upload LFS file", slog.Any("error", err))
 			}
@@ -241,6 +249,7 @@ func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFiles(ctx context.Context, mirr
 			Oid:          pointer.Oid,
 			RepositoryID: mirror.Repository.ID,
 			Existing:     true,
+			StorageID:    storage.ID,
 		}
 		_, err = w.lfsMetaObjectStore.UpdateOrCreate(ctx, lfsMetaObject)
 		if err != nil {
@@ -260,10 +269,17 @@ func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFiles(ctx context.Context, mirr
 	if err != nil {
 		return fmt.Errorf("failed to update mirror status: %w", err)
 	}
+	err = w.webhookNotifier.Notify(ctx, mirror.Repository, types.WebhookEventMirrorFinished, types.WebhookMirrorFinishedData{
+		MirrorID:  mirror.ID,
+		SourceURL: mirror.SourceUrl,
+	})
+	if err != nil {
+		slog.Error("failed to notify webhooks of finished mirror", slog.Int64("mirror_id", mirror.ID), slog.Any("error", err))
+	}
 	return nil
 }
 
-func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFile(ctx context.Context, mirror *database.Mirror, pointer *types.Pointer) error {
+func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFile(ctx context.Context, storage *s3.LfsStorage, pointer *types.Pointer) error {
 	objectKey := filepath.Join("lfs", pointer.RelativePath())
 	slog.Info("downloading LFS file from", slog.Any("url", pointer.DownloadURL))
 
@@ -293,7 +309,7 @@ func (w *MinioLFSSyncWorker) DownloadAndUploadLFSFile(ctx context.Context, mirro
 		return fmt.Errorf("failed to download LFS file: %s", resp.Status)
 	}
 	slog.Info("uploading LFS file", slog.Any("object_key", objectKey))
-	uploadInfo, err := w.s3Client.PutObject(ctx, w.config.S3.Bucket, objectKey, resp.Body, resp.ContentLength, minio.PutObjectOptions{})
+	uploadInfo, err := storage.Client.PutObject(ctx, storage.Bucket, objectKey, resp.Body, resp.ContentLength, minio.PutObjectOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to upload to Minio: %w", err)
 	}
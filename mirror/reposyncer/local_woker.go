@@ -15,6 +15,7 @@ import (
 	"opencsg.com/csghub-server/builder/git/gitserver"
 	"opencsg.com/csghub-server/builder/git/gitserver/gitaly"
 	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/webhook"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
 	"opencsg.com/csghub-server/mirror/queue"
@@ -31,6 +32,7 @@ type LocalMirrorWoker struct {
 	repoStore          database.RepoStore
 	git                gitserver.GitServer
 	config             *config.Config
+	webhookNotifier    webhook.Notifier
 }
 
 func NewLocalMirrorWoker(config *config.Config, numWorkers int) (*LocalMirrorWoker, error) {
@@ -46,6 +48,7 @@ func NewLocalMirrorWoker(config *config.Config, numWorkers int) (*LocalMirrorWok
 	w.mirrorStore = database.NewMirrorStore()
 	w.repoStore = database.NewRepoStore()
 	w.lfsMetaObjectStore = database.NewLfsMetaObjectStore()
+	w.webhookNotifier = webhook.NewNotifier()
 	w.saas = config.Saas
 	w.config = config
 	mq, err := queue.GetPriorityQueueInstance()
@@ -198,6 +201,16 @@ func (w *LocalMirrorWoker) SyncRepo(ctx context.Context, task queue.MirrorTask)
 	if err != nil {
 		return fmt.Errorf("failed to update mirror: %w", err)
 	}
+	// mirrors with lfs files are finished by the lfs syncer
+	if mirror.Status == types.MirrorFinished {
+		err = w.webhookNotifier.Notify(ctx, mirror.Repository, types.WebhookEventMirrorFinished, types.WebhookMirrorFinishedData{
+			MirrorID:  mirror.ID,
+			SourceURL: mirror.SourceUrl,
+		})
+		if err != nil {
+			slog.Error("failed to notify webhooks of finished mirror", slog.Int64("mirror_id", mirror.ID), slog.Any("error", err))
+		}
+	}
 
 	// Trigger git callback
 
@@ -6,7 +6,6 @@ import (
 	"slices"
 	"strings"
 
-	"opencsg.com/csghub-server/builder/sensitive"
 	"opencsg.com/csghub-server/common/types"
 )
 
@@ -23,29 +22,44 @@ type FileChecker interface {
 //
 // The checkers are chosen as follows:
 // - folder: FolderChecker
-// - LFS files: LfsFileChecker
+// - model files which may be pickles (with extensions .bin, .pt, .pth, .pkl, .pickle, .ckpt, .joblib): PickleFileChecker
+// - safe model weight files (with extensions .safetensors, .gguf): SafeWeightFileChecker
+// - image files (with extensions .png, .jpg, .jpeg, .gif, .tif, .tiff, .svg, .bmp, .webp), lfs or not: ImageFileChecker
+// - other LFS files: LfsFileChecker
 // - unknown files: UnkownFileChecker
-// - image files (with extensions .png, .jpg, .jpeg, .gif, .tif, .tiff, .svg, .bmp, .webp): ImageFileChecker
-// - text files (with extensions .md, .txt, .csv, .json, .jsonl, .html, .cs, .js, .ts, .py, .php, .java, .c, .cpp, .go, .rb, .sh): TextFileChecker
+// - markdown files (with extension .md), which may embed images: MarkdownFileChecker
+// - other text files (with extensions .txt, .csv, .json, .jsonl, .html, .cs, .js, .ts, .py, .php, .java, .c, .cpp, .go, .rb, .sh): TextFileChecker
 func GetFileChecker(fileType string, filePath, lfsRelativePath string) FileChecker {
 
 	if fileType == "folder" {
 		return &FolderChecker{}
 	}
 
+	ext := path.Ext(filePath)
+	if types.IsPickleFile(filePath) {
+		return NewPickleFileChecker()
+	}
+	if types.IsSafeWeightFile(filePath) {
+		return NewSafeWeightFileChecker(ext)
+	}
+
+	// images are often tracked by lfs, which are checked as well
+	if slices.ContainsFunc(knownImageFileExts, func(imageExt string) bool {
+		return strings.EqualFold(ext, imageExt)
+	}) {
+		return NewImageFileChecker()
+	}
+
 	if lfsRelativePath != "" {
 		return &LfsFileChecker{}
 	}
 
-	ext := path.Ext(filePath)
 	if len(ext) == 0 {
 		return &UnkownFileChecker{}
 	}
 
-	if slices.ContainsFunc(knownImageFileExts, func(imageExt string) bool {
-		return strings.EqualFold(ext, imageExt)
-	}) {
-		return NewImageFileChecker()
+	if strings.EqualFold(ext, ".md") {
+		return NewMarkdownFileChecker()
 	}
 
 	if slices.ContainsFunc(knownTextFileExts, func(textExt string) bool {
@@ -57,20 +71,6 @@ func GetFileChecker(fileType string, filePath, lfsRelativePath string) FileCheck
 	return &UnkownFileChecker{}
 }
 
-type ImageFileChecker struct {
-	checker sensitive.SensitiveChecker
-}
-
-func NewImageFileChecker() FileChecker {
-	return &ImageFileChecker{
-		checker: contentChecker,
-	}
-}
-func (c *ImageFileChecker) Run(io.Reader) (types.SensitiveCheckStatus, string) {
-	//TODO:check image in the future
-	return types.SensitiveCheckSkip, "skip image file"
-}
-
 type LfsFileChecker struct {
 }
 
@@ -0,0 +1,124 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"opencsg.com/csghub-server/builder/safehttp"
+	"opencsg.com/csghub-server/builder/sensitive"
+	"opencsg.com/csghub-server/builder/store/s3"
+)
+
+// the max size of image to check, larger images are skipped as the moderation services reject them
+const maxImageSize = 10 << 20
+
+// images are uploaded under the prefix of moderation bucket for the moderation services to read, and removed
+// after checked
+const moderationImagePrefix = "moderation/images"
+
+const imageDownloadTimeout = 30 * time.Second
+
+var (
+	ErrImageTooLarge    = errors.New("image is too large")
+	ErrImageUnsupported = errors.New("image format is not supported")
+	// ErrImageUnavailable means the image of url can not be downloaded, like a broken link
+	ErrImageUnavailable = errors.New("image is unavailable")
+)
+
+// the image formats supported by the moderation services, svg is not in the list as it's a text file
+var supportedImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/bmp", "image/webp"}
+
+var (
+	imageStorage *s3.Client
+	imageBucket  string
+)
+
+// ImageChecker checks images by uploading them to the moderation bucket and calling the image check of
+// sensitive checker with the object, like the moderation services of cloud which read images from oss
+type ImageChecker struct {
+	checker sensitive.SensitiveChecker
+	storage *s3.Client
+	bucket  string
+	hc      *http.Client
+}
+
+func NewImageChecker() *ImageChecker {
+	return &ImageChecker{
+		checker: contentChecker,
+		storage: imageStorage,
+		bucket:  imageBucket,
+		// image urls are given by users, they must not reach the internal services
+		hc: safehttp.NewClient(imageDownloadTimeout),
+	}
+}
+
+// Check checks the image read from reader in the scenario
+func (c *ImageChecker) Check(ctx context.Context, scenario sensitive.Scenario, reader io.Reader) (*sensitive.CheckResult, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image, error: %w", err)
+	}
+	if len(data) > maxImageSize {
+		return nil, ErrImageTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(supportedImageTypes, contentType) {
+		return nil, ErrImageUnsupported
+	}
+
+	// a unique object for every check, so that concurrent checks of the same image never remove the object
+	// the other is checking
+	object := path.Join(moderationImagePrefix, uuid.NewString())
+	_, err = c.storage.PutObject(ctx, c.bucket, object, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload image to bucket %s, error: %w", c.bucket, err)
+	}
+	defer func() {
+		// the context may be done already, remove the object anyway
+		err := c.storage.RemoveObject(context.Background(), c.bucket, object, minio.RemoveObjectOptions{})
+		if err != nil {
+			slog.Error("failed to remove moderation image", slog.String("object", object), slog.Any("error", err))
+		}
+	}()
+
+	return c.checker.PassImageCheck(ctx, scenario, c.bucket, object)
+}
+
+// CheckURL downloads the image of http(s) url and checks it in the scenario, ErrImageUnavailable is returned if
+// the image can not be downloaded
+func (c *ImageChecker) CheckURL(ctx context.Context, scenario sensitive.Scenario, imageURL string) (*sensitive.CheckResult, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w, invalid image url %s", ErrImageUnavailable, imageURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w, error: %w", ErrImageUnavailable, err)
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w, error: %w", ErrImageUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w, unexpected status code %d", ErrImageUnavailable, resp.StatusCode)
+	}
+	if resp.ContentLength > maxImageSize {
+		return nil, ErrImageTooLarge
+	}
+	return c.Check(ctx, scenario, resp.Body)
+}
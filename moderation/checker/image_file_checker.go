@@ -0,0 +1,126 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"opencsg.com/csghub-server/builder/sensitive"
+	"opencsg.com/csghub-server/common/types"
+)
+
+const imageCheckTimeout = time.Minute
+
+// ImageFileChecker checks the image files in repository by the image check of sensitive checker
+type ImageFileChecker struct {
+	images *ImageChecker
+}
+
+func NewImageFileChecker() FileChecker {
+	return &ImageFileChecker{
+		images: NewImageChecker(),
+	}
+}
+
+func (c *ImageFileChecker) Run(reader io.Reader) (types.SensitiveCheckStatus, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), imageCheckTimeout)
+	defer cancel()
+	result, err := c.images.Check(ctx, sensitive.ScenarioImageBaseLineCheck, reader)
+	switch {
+	case errors.Is(err, ErrImageTooLarge):
+		return types.SensitiveCheckSkip, fmt.Sprintf("skip image larger than %dMB", maxImageSize>>20)
+	case errors.Is(err, ErrImageUnsupported):
+		return types.SensitiveCheckSkip, "skip image in unsupported format"
+	case err != nil:
+		slog.Error("failed to check image", slog.Any("error", err))
+		return types.SensitiveCheckException, "call image checker api failed"
+	}
+	if result.IsSensitive {
+		return types.SensitiveCheckFail, result.Reason
+	}
+	return types.SensitiveCheckPass, ""
+}
+
+// the max number of images embedded in a markdown file to check
+const maxMarkdownImages = 20
+
+var (
+	// ![alt](url "title")
+	markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^\s)>]+)>?(?:\s+["'][^"']*["'])?\s*\)`)
+	// <img src="url">
+	htmlImagePattern = regexp.MustCompile(`(?i)<img\s[^>]*?src\s*=\s*["']([^"']+)["']`)
+)
+
+// MarkdownFileChecker checks the text of markdown files like README, and the images embedded by absolute urls.
+// Images embedded by relative paths are files of the repository, which are checked by ImageFileChecker
+type MarkdownFileChecker struct {
+	text   *TextFileChecker
+	images *ImageChecker
+}
+
+func NewMarkdownFileChecker() FileChecker {
+	return &MarkdownFileChecker{
+		text:   NewTextFileChecker(),
+		images: NewImageChecker(),
+	}
+}
+
+func (c *MarkdownFileChecker) Run(reader io.Reader) (types.SensitiveCheckStatus, string) {
+	//at most 1MB, same as text file checker
+	content, err := io.ReadAll(io.LimitReader(reader, 1024*1024))
+	if err != nil {
+		return types.SensitiveCheckException, "failed to read file content"
+	}
+	status, msg := c.text.Run(bytes.NewReader(content))
+	if status != types.SensitiveCheckPass {
+		return status, msg
+	}
+
+	for _, imageURL := range markdownImageURLs(string(content), maxMarkdownImages) {
+		ctx, cancel := context.WithTimeout(context.Background(), imageCheckTimeout)
+		result, err := c.images.CheckURL(ctx, sensitive.ScenarioImageBaseLineCheck, imageURL)
+		cancel()
+		switch {
+		case errors.Is(err, ErrImageUnavailable), errors.Is(err, ErrImageTooLarge), errors.Is(err, ErrImageUnsupported):
+			// broken links and badges in svg are common in markdown, which should not fail the check
+			slog.Debug("skip image embedded in markdown", slog.String("url", imageURL), slog.Any("error", err))
+			continue
+		case err != nil:
+			slog.Error("failed to check image embedded in markdown", slog.String("url", imageURL), slog.Any("error", err))
+			return types.SensitiveCheckException, "call image checker api failed"
+		}
+		if result.IsSensitive {
+			return types.SensitiveCheckFail, fmt.Sprintf("embedded image %s: %s", imageURL, result.Reason)
+		}
+	}
+	return types.SensitiveCheckPass, ""
+}
+
+// markdownImageURLs returns the distinct absolute http(s) urls of images embedded in markdown, at most limit urls
+func markdownImageURLs(content string, limit int) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, pattern := range []*regexp.Regexp{markdownImagePattern, htmlImagePattern} {
+		for _, m := range pattern.FindAllStringSubmatch(content, -1) {
+			u := strings.TrimSpace(m[1])
+			if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+				continue
+			}
+			if seen[u] {
+				continue
+			}
+			if len(urls) >= limit {
+				return urls
+			}
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/sensitive"
+	"opencsg.com/csghub-server/common/types"
+)
+
+func TestGetFileChecker_Images(t *testing.T) {
+	require.IsType(t, &ImageFileChecker{}, GetFileChecker("file", "images/cover.PNG", ""))
+	require.IsType(t, &ImageFileChecker{}, GetFileChecker("file", "images/cover.jpg", "ab/cd/abcd"))
+	require.IsType(t, &LfsFileChecker{}, GetFileChecker("file", "data/train.parquet", "ab/cd/abcd"))
+	require.IsType(t, &MarkdownFileChecker{}, GetFileChecker("file", "README.md", ""))
+	require.IsType(t, &TextFileChecker{}, GetFileChecker("file", "notes.txt", ""))
+}
+
+func TestImageFileChecker_Skip(t *testing.T) {
+	c := NewImageFileChecker()
+
+	status, msg := c.Run(strings.NewReader(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`))
+	require.Equal(t, types.SensitiveCheckSkip, status)
+	require.Equal(t, "skip image in unsupported format", msg)
+
+	large := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, maxImageSize)...)
+	status, msg = c.Run(bytes.NewReader(large))
+	require.Equal(t, types.SensitiveCheckSkip, status)
+	require.Equal(t, "skip image larger than 10MB", msg)
+}
+
+func TestImageChecker_CheckURL_Unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	c := NewImageChecker()
+
+	_, err := c.CheckURL(context.Background(), sensitive.ScenarioImageProfileCheck, srv.URL+"/avatar.png")
+	require.ErrorIs(t, err, ErrImageUnavailable)
+	_, err = c.CheckURL(context.Background(), sensitive.ScenarioImageProfileCheck, "file:///etc/passwd")
+	require.ErrorIs(t, err, ErrImageUnavailable)
+	_, err = c.CheckURL(context.Background(), sensitive.ScenarioImageProfileCheck, "/avatars/me.png")
+	require.ErrorIs(t, err, ErrImageUnavailable)
+}
+
+func Test_markdownImageURLs(t *testing.T) {
+	content := `# Model
+![logo](https://example.com/logo.png "Logo")
+![local](./images/local.png)
+![badge]( <https://img.shields.io/badge/license-MIT-green> )
+<p align="center"><IMG width="200" src='http://example.com/arch.jpg' /></p>
+![again](https://example.com/logo.png)
+[not an image](https://example.com/page.png)
+`
+	urls := markdownImageURLs(content, 10)
+	require.Equal(t, []string{
+		"https://example.com/logo.png",
+		"https://img.shields.io/badge/license-MIT-green",
+		"http://example.com/arch.jpg",
+	}, urls)
+
+	require.Len(t, markdownImageURLs(content, 1), 1)
+}
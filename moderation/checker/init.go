@@ -1,61 +1,30 @@
 package checker
 
 import (
-	"bufio"
-	"encoding/base64"
-	"strings"
-
 	"opencsg.com/csghub-server/builder/sensitive"
+	"opencsg.com/csghub-server/builder/store/s3"
 	"opencsg.com/csghub-server/common/config"
 )
 
 var contentChecker sensitive.SensitiveChecker
-var localWordChecker *DFA
 
 func Init(config *config.Config) {
 	if !config.SensitiveCheck.Enable {
 		panic("SensitiveCheck is not enable")
 	}
-	//init aliyun green checker
-	contentChecker = sensitive.NewAliyunGreenChecker(config)
-	//init local word checker
-	localWordChecker = NewDFA()
-
-	localWordChecker.BuildDFA(getSensitiveWordList(config.Moderation.EncodedSensitiveWords))
-}
-
-func getSensitiveWordList(encodedWords string) []string {
-	r := base64.NewDecoder(base64.StdEncoding, strings.NewReader(encodedWords))
-	s := bufio.NewScanner(r)
-	s.Split(commaSplit)
-
-	var words []string
-	for s.Scan() {
-		words = append(words, s.Text())
-	}
-
-	return words
-}
-
-// Custom split function that splits on commas
-func commaSplit(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	if len(data) == 0 {
-		// When there's no data, return normally
-		return 0, nil, nil
+	//init the providers chained to check content, the sensitive words are checked by the dfa provider
+	var err error
+	contentChecker, err = sensitive.NewSensitiveChecker(config)
+	if err != nil {
+		panic(err)
 	}
-
-	// Find the first comma
-	if i := strings.IndexByte(string(data), ','); i >= 0 {
-		// We've found a comma, return the part before it
-		return i + 1, data[:i], nil
+	//images are uploaded to the moderation bucket for the providers to read
+	imageStorage, err = s3.NewMinio(config)
+	if err != nil {
+		panic(err)
 	}
-
-	// If we've reached EOF and there's data left, return it
-	if atEOF {
-		return len(data), data, nil
+	imageBucket = config.Moderation.ImageBucket
+	if imageBucket == "" {
+		imageBucket = config.S3.Bucket
 	}
-
-	// If we haven't found a comma and we're not at EOF,
-	// we need more data
-	return 0, nil, nil
 }
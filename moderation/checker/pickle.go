@@ -0,0 +1,467 @@
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the max size of a string argument kept by the scanner, longer strings are skipped as they can't be
+// the module or name of a global
+const pickleMaxStringLen = 1 << 10
+
+var errNotPickle = errors.New("not a pickle")
+
+// dangerousGlobals are the globals which can run code or access the system when imported by a pickle,
+// a module with "*" is dangerous as a whole, including its sub modules
+var dangerousGlobals = map[string][]string{
+	"builtins":                     {"eval", "exec", "execfile", "compile", "open", "getattr", "setattr", "delattr", "apply", "__import__", "breakpoint", "globals", "locals", "vars"},
+	"__builtin__":                  {"eval", "exec", "execfile", "compile", "open", "getattr", "setattr", "delattr", "apply", "__import__", "breakpoint", "globals", "locals", "vars"},
+	"os":                           {"*"},
+	"posix":                        {"*"},
+	"nt":                           {"*"},
+	"subprocess":                   {"*"},
+	"sys":                          {"*"},
+	"socket":                       {"*"},
+	"shutil":                       {"*"},
+	"runpy":                        {"*"},
+	"pty":                          {"*"},
+	"pdb":                          {"*"},
+	"bdb":                          {"*"},
+	"commands":                     {"*"},
+	"webbrowser":                   {"*"},
+	"ctypes":                       {"*"},
+	"importlib":                    {"*"},
+	"code":                         {"*"},
+	"codeop":                       {"*"},
+	"marshal":                      {"*"},
+	"pickle":                       {"*"},
+	"_pickle":                      {"*"},
+	"dill":                         {"*"},
+	"multiprocessing":              {"*"},
+	"asyncio":                      {"*"},
+	"timeit":                       {"*"},
+	"httplib":                      {"*"},
+	"http":                         {"*"},
+	"urllib":                       {"*"},
+	"requests":                     {"*"},
+	"aiohttp":                      {"*"},
+	"types":                        {"CodeType", "FunctionType"},
+	"operator":                     {"attrgetter", "methodcaller"},
+	"numpy.testing._private.utils": {"runstring"},
+	"torch.hub":                    {"*"},
+}
+
+func isDangerousGlobal(module, name string) bool {
+	for m, names := range dangerousGlobals {
+		if module != m && !strings.HasPrefix(module, m+".") {
+			continue
+		}
+		for _, n := range names {
+			if n == "*" || n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pickleGlobal is a global imported by a pickle, the module and name are empty if they are not
+// strings pushed by the pickle directly, which is a way to hide the import
+type pickleGlobal struct {
+	Module string
+	Name   string
+}
+
+func (g pickleGlobal) String() string {
+	if g.Module == "" || g.Name == "" {
+		return "unknown global"
+	}
+	return g.Module + "." + g.Name
+}
+
+func (g pickleGlobal) dangerous() bool {
+	return g.Module == "" || g.Name == "" || isDangerousGlobal(g.Module, g.Name)
+}
+
+// scanPickleFile returns the globals imported by the pickles in a file without running them. Besides plain
+// pickle files, the zip archives of pytorch are scanned by the pickles in them, and the pickles followed by
+// raw data like the legacy pytorch format are scanned until the data. errNotPickle is returned if the file
+// contains no pickle
+func scanPickleFile(r io.Reader) ([]pickleGlobal, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	magic, err := br.Peek(4)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if bytes.Equal(magic, []byte("PK\x03\x04")) {
+		return scanPickleZip(br)
+	}
+
+	globals, err := newPickleScanner(br).scan()
+	if err != nil {
+		return globals, err
+	}
+	// a file can be several pickles in a row, the scan stops at the first data which is not a complete
+	// pickle, as it's the raw data following the pickles
+	for {
+		if _, err := br.Peek(1); err != nil {
+			break
+		}
+		more, err := newPickleScanner(br).scan()
+		if err != nil {
+			break
+		}
+		globals = append(globals, more...)
+	}
+	return globals, nil
+}
+
+const (
+	zipLocalFileHeaderSig = 0x04034b50
+	zipDataDescriptorSig  = 0x08074b50
+	zipFlagDataDescriptor = 0x8
+	zipMethodStore        = 0
+	zipMethodDeflate      = 8
+	zip64ExtraID          = 0x0001
+)
+
+// scanPickleZip reads the entries of zip archive in order, so that the archive is streamed without
+// seeking to its central directory
+func scanPickleZip(br *bufio.Reader) ([]pickleGlobal, error) {
+	var globals []pickleGlobal
+	var scanned bool
+	for {
+		var header [30]byte
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			return nil, fmt.Errorf("failed to read zip entry header, %w", err)
+		}
+		if binary.LittleEndian.Uint32(header[0:4]) != zipLocalFileHeaderSig {
+			// central directory after the entries
+			break
+		}
+		flags := binary.LittleEndian.Uint16(header[6:8])
+		method := binary.LittleEndian.Uint16(header[8:10])
+		compressedSize := uint64(binary.LittleEndian.Uint32(header[18:22]))
+		nameLen := int(binary.LittleEndian.Uint16(header[26:28]))
+		extraLen := int(binary.LittleEndian.Uint16(header[28:30]))
+		nameAndExtra := make([]byte, nameLen+extraLen)
+		if _, err := io.ReadFull(br, nameAndExtra); err != nil {
+			return nil, fmt.Errorf("failed to read zip entry header, %w", err)
+		}
+		name := string(nameAndExtra[:nameLen])
+		if compressedSize == 0xffffffff {
+			compressedSize = zip64CompressedSize(nameAndExtra[nameLen:])
+		}
+		hasDescriptor := flags&zipFlagDataDescriptor != 0
+		if hasDescriptor && method != zipMethodDeflate {
+			return nil, fmt.Errorf("unsupported zip entry %s of unknown size", name)
+		}
+		if method != zipMethodStore && method != zipMethodDeflate {
+			return nil, fmt.Errorf("unsupported compression method %d of zip entry %s", method, name)
+		}
+
+		var entry io.Reader = br
+		if !hasDescriptor {
+			entry = io.LimitReader(br, int64(compressedSize))
+		}
+		if method == zipMethodDeflate {
+			entry = flate.NewReader(entry)
+		}
+		if strings.HasSuffix(name, ".pkl") || strings.HasSuffix(name, ".pickle") {
+			g, err := newPickleScanner(bufio.NewReader(entry)).scan()
+			globals = append(globals, g...)
+			if err != nil {
+				return globals, fmt.Errorf("failed to scan pickle %s in zip, %w", name, err)
+			}
+			scanned = true
+		}
+		if _, err := io.Copy(io.Discard, entry); err != nil {
+			return nil, fmt.Errorf("failed to read zip entry %s, %w", name, err)
+		}
+		if hasDescriptor {
+			if err := skipZipDataDescriptor(br); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !scanned {
+		return nil, errNotPickle
+	}
+	return globals, nil
+}
+
+func zip64CompressedSize(extra []byte) uint64 {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+size {
+			break
+		}
+		// uncompressed size goes first in the zip64 extra field of local header
+		if id == zip64ExtraID && size >= 16 {
+			return binary.LittleEndian.Uint64(extra[12:20])
+		}
+		extra = extra[4+size:]
+	}
+	return 0
+}
+
+func skipZipDataDescriptor(br *bufio.Reader) error {
+	sig, err := br.Peek(4)
+	if err != nil {
+		return fmt.Errorf("failed to read zip data descriptor, %w", err)
+	}
+	n := 12
+	if binary.LittleEndian.Uint32(sig) == zipDataDescriptorSig {
+		n += 4
+	}
+	// the descriptor is followed by the signature of next header, its sizes are in 8 bytes for zip64
+	next, err := br.Peek(n + 4)
+	if err == nil && binary.LittleEndian.Uint32(next[n:]) != zipLocalFileHeaderSig &&
+		binary.LittleEndian.Uint32(next[n:]) != 0x02014b50 {
+		n += 8
+	}
+	_, err = br.Discard(n)
+	return err
+}
+
+// pickleScanner walks the opcodes of a pickle to find the globals it imports, the pickle is never run
+type pickleScanner struct {
+	r       *bufio.Reader
+	globals []pickleGlobal
+	// values pushed to the stack, only strings are tracked to find the module and name of STACK_GLOBAL,
+	// other values are kept as nil
+	stack []*string
+	memo  map[uint64]*string
+}
+
+func newPickleScanner(r *bufio.Reader) *pickleScanner {
+	return &pickleScanner{
+		r:    r,
+		memo: make(map[uint64]*string),
+	}
+}
+
+func (s *pickleScanner) push(v *string) {
+	s.stack = append(s.stack, v)
+}
+
+func (s *pickleScanner) top() *string {
+	if len(s.stack) == 0 {
+		return nil
+	}
+	return s.stack[len(s.stack)-1]
+}
+
+// scan reads opcodes until the STOP opcode. The globals found before an error are returned with the error,
+// as the globals are imported by python before it fails at the error. errNotPickle is returned if the data
+// is not a pickle of protocol 2 or above, and no global is found before the error
+func (s *pickleScanner) scan() ([]pickleGlobal, error) {
+	var proto bool
+	for first := true; ; first = false {
+		op, err := s.r.ReadByte()
+		if err != nil {
+			if first {
+				return nil, errNotPickle
+			}
+			return s.result(proto, fmt.Errorf("failed to read pickle opcode, %w", err))
+		}
+		if first && op == 0x80 {
+			proto = true
+		}
+		stop, err := s.step(op)
+		if err != nil {
+			return s.result(proto, err)
+		}
+		if stop {
+			return s.globals, nil
+		}
+	}
+}
+
+func (s *pickleScanner) result(proto bool, err error) ([]pickleGlobal, error) {
+	if !proto && len(s.globals) == 0 {
+		return nil, errNotPickle
+	}
+	return s.globals, err
+}
+
+func (s *pickleScanner) step(op byte) (stop bool, err error) {
+	switch op {
+	case '.': // STOP
+		return true, nil
+	case '(', '0', '1', '2', 'N', 'Q', 'R', 'a', 'b', 'd', '}', 'e', 'l', ']', 'o', 's', 't', ')', 'u',
+		0x81, 0x85, 0x86, 0x87, 0x88, 0x89, 0x8f, 0x90, 0x91, 0x92, 0x97, 0x98:
+		// opcodes without argument, their effects on the stack don't matter for finding globals
+		s.push(nil)
+	case 0x94: // MEMOIZE
+		s.memo[uint64(len(s.memo))] = s.top()
+	case 0x93: // STACK_GLOBAL
+		g := pickleGlobal{}
+		if n := len(s.stack); n >= 2 && s.stack[n-2] != nil && s.stack[n-1] != nil {
+			g.Module, g.Name = *s.stack[n-2], *s.stack[n-1]
+		}
+		s.globals = append(s.globals, g)
+		s.push(nil)
+	case 'c', 'i': // GLOBAL, INST
+		module, err := s.readLine()
+		if err != nil {
+			return false, err
+		}
+		name, err := s.readLine()
+		if err != nil {
+			return false, err
+		}
+		s.globals = append(s.globals, pickleGlobal{Module: module, Name: name})
+		s.push(nil)
+	case 'S', 'V': // STRING, UNICODE
+		line, err := s.readLine()
+		if err != nil {
+			return false, err
+		}
+		if op == 'S' {
+			line = strings.Trim(line, `'"`)
+		}
+		s.push(&line)
+	case 'F', 'I', 'L', 'P': // FLOAT, INT, LONG, PERSID
+		if _, err := s.readLine(); err != nil {
+			return false, err
+		}
+		s.push(nil)
+	case 'g': // GET
+		line, err := s.readLine()
+		if err != nil {
+			return false, err
+		}
+		var idx uint64
+		if _, err := fmt.Sscan(line, &idx); err != nil {
+			return false, fmt.Errorf("invalid memo index %s, %w", line, err)
+		}
+		s.push(s.memo[idx])
+	case 'p': // PUT
+		line, err := s.readLine()
+		if err != nil {
+			return false, err
+		}
+		var idx uint64
+		if _, err := fmt.Sscan(line, &idx); err != nil {
+			return false, fmt.Errorf("invalid memo index %s, %w", line, err)
+		}
+		s.memo[idx] = s.top()
+	case 'h': // BINGET
+		idx, err := s.readUint(1)
+		if err != nil {
+			return false, err
+		}
+		s.push(s.memo[idx])
+	case 'j': // LONG_BINGET
+		idx, err := s.readUint(4)
+		if err != nil {
+			return false, err
+		}
+		s.push(s.memo[idx])
+	case 'q': // BINPUT
+		idx, err := s.readUint(1)
+		if err != nil {
+			return false, err
+		}
+		s.memo[idx] = s.top()
+	case 'r': // LONG_BINPUT
+		idx, err := s.readUint(4)
+		if err != nil {
+			return false, err
+		}
+		s.memo[idx] = s.top()
+	case 0x80: // PROTO
+		if _, err := s.readUint(1); err != nil {
+			return false, err
+		}
+	case 0x95: // FRAME
+		if _, err := s.readUint(8); err != nil {
+			return false, err
+		}
+	case 'K', 0x82: // BININT1, EXT1
+		return false, s.skipAndPush(1)
+	case 'M', 0x83: // BININT2, EXT2
+		return false, s.skipAndPush(2)
+	case 'J', 0x84: // BININT, EXT4
+		return false, s.skipAndPush(4)
+	case 'G': // BINFLOAT
+		return false, s.skipAndPush(8)
+	case 0x8a: // LONG1
+		return false, s.readBytes(1, false)
+	case 0x8b: // LONG4
+		return false, s.readBytes(4, false)
+	case 'U', 'C': // SHORT_BINSTRING, SHORT_BINBYTES
+		return false, s.readBytes(1, op == 'U')
+	case 'T', 'B': // BINSTRING, BINBYTES
+		return false, s.readBytes(4, op == 'T')
+	case 0x8e, 0x96: // BINBYTES8, BYTEARRAY8
+		return false, s.readBytes(8, false)
+	case 0x8c: // SHORT_BINUNICODE
+		return false, s.readBytes(1, true)
+	case 'X': // BINUNICODE
+		return false, s.readBytes(4, true)
+	case 0x8d: // BINUNICODE8
+		return false, s.readBytes(8, true)
+	default:
+		return false, fmt.Errorf("unknown pickle opcode 0x%02x", op)
+	}
+	return false, nil
+}
+
+func (s *pickleScanner) readLine() (string, error) {
+	line, err := s.r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read pickle argument, %w", err)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func (s *pickleScanner) readUint(n int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(s.r, buf[:n]); err != nil {
+		return 0, fmt.Errorf("failed to read pickle argument, %w", err)
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+func (s *pickleScanner) skipAndPush(n int) error {
+	if _, err := s.r.Discard(n); err != nil {
+		return fmt.Errorf("failed to read pickle argument, %w", err)
+	}
+	s.push(nil)
+	return nil
+}
+
+// readBytes reads the bytes argument prefixed by its length in lenSize bytes, the argument
+// is pushed as a string if asString is true and it's short enough to be a module or name
+func (s *pickleScanner) readBytes(lenSize int, asString bool) error {
+	n, err := s.readUint(lenSize)
+	if err != nil {
+		return err
+	}
+	if n > 1<<40 {
+		return fmt.Errorf("invalid pickle argument length %d", n)
+	}
+	if !asString || n > pickleMaxStringLen {
+		if _, err := io.CopyN(io.Discard, s.r, int64(n)); err != nil {
+			return fmt.Errorf("failed to read pickle argument, %w", err)
+		}
+		s.push(nil)
+		return nil
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(s.r, buf); err != nil {
+		return fmt.Errorf("failed to read pickle argument, %w", err)
+	}
+	str := string(buf)
+	s.push(&str)
+	return nil
+}
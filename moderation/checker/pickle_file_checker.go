@@ -0,0 +1,80 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"opencsg.com/csghub-server/common/types"
+)
+
+// PickleFileChecker scans the model files which may be pickles for the imports of dangerous globals,
+// loading such a file runs arbitrary code
+type PickleFileChecker struct {
+}
+
+func NewPickleFileChecker() FileChecker {
+	return &PickleFileChecker{}
+}
+
+func (c *PickleFileChecker) Run(reader io.Reader) (types.SensitiveCheckStatus, string) {
+	globals, err := scanPickleFile(reader)
+	if errors.Is(err, errNotPickle) {
+		return types.SensitiveCheckSkip, "skip file which is not a pickle"
+	}
+	var dangerous []string
+	for _, g := range globals {
+		if g.dangerous() {
+			dangerous = append(dangerous, g.String())
+		}
+	}
+	// the dangerous globals found before an error are reported, as they are imported before python fails
+	if len(dangerous) > 0 {
+		return types.SensitiveCheckUnsafe, fmt.Sprintf("dangerous imports in pickle: %s", strings.Join(dangerous, ", "))
+	}
+	if err != nil {
+		slog.Error("failed to scan pickle file", slog.Any("error", err))
+		return types.SensitiveCheckException, fmt.Sprintf("failed to scan pickle, %s", err.Error())
+	}
+	return types.SensitiveCheckPass, fmt.Sprintf("no dangerous imports in pickle of %d globals", len(globals))
+}
+
+// SafeWeightFileChecker verifies the model weight files in safetensors or GGUF format, which can not
+// carry code. Files not in the format of their extensions are scanned as pickles
+type SafeWeightFileChecker struct {
+	ext string
+}
+
+func NewSafeWeightFileChecker(ext string) FileChecker {
+	return &SafeWeightFileChecker{ext: strings.ToLower(ext)}
+}
+
+// the max size of safetensors header, same as the limit of safetensors library
+const safetensorsMaxHeaderSize = 100 << 20
+
+func (c *SafeWeightFileChecker) Run(reader io.Reader) (types.SensitiveCheckStatus, string) {
+	head := make([]byte, 9)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return types.SensitiveCheckException, "failed to read file contents"
+	}
+	head = head[:n]
+
+	switch c.ext {
+	case ".safetensors":
+		// 8 bytes of the json header size followed by the json header
+		if n == 9 && head[8] == '{' && binary.LittleEndian.Uint64(head[:8]) <= safetensorsMaxHeaderSize {
+			return types.SensitiveCheckPass, "safetensors file can not carry code"
+		}
+	case ".gguf":
+		if bytes.HasPrefix(head, []byte("GGUF")) {
+			return types.SensitiveCheckPass, "gguf file can not carry code"
+		}
+	}
+	slog.Warn("weight file is not in the format of its extension, scan it as pickle", slog.String("ext", c.ext))
+	return NewPickleFileChecker().Run(io.MultiReader(bytes.NewReader(head), reader))
+}
@@ -0,0 +1,159 @@
+package checker
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/common/types"
+)
+
+const (
+	// pickle.dumps of an object reduced to os.system('echo hi') in protocol 4 and 2
+	maliciousPickleV4 = "\x80\x04\x95\"\x00\x00\x00\x00\x00\x00\x00\x8c\x05posix\x94\x8c\x06system\x94\x93\x94\x8c\x07echo hi\x94\x85\x94R\x94."
+	maliciousPickleV2 = "\x80\x02cposix\nsystem\nq\x00X\x07\x00\x00\x00echo hiq\x01\x85q\x02Rq\x03."
+	// pickle.dumps of {'a': [1, 2.0, 'x'], 'b': collections.OrderedDict()} in protocol 4
+	benignPickleV4 = "\x80\x04\x95A\x00\x00\x00\x00\x00\x00\x00}\x94(\x8c\x01a\x94]\x94(K\x01G@\x00\x00\x00\x00\x00\x00\x00\x8c\x01x\x94e\x8c\x01b\x94\x8c\x0bcollections\x94\x8c\x0bOrderedDict\x94\x93\x94)R\x94u."
+)
+
+func TestPickleFileChecker_Run(t *testing.T) {
+	c := NewPickleFileChecker()
+
+	status, msg := c.Run(strings.NewReader(maliciousPickleV4))
+	require.Equal(t, types.SensitiveCheckUnsafe, status)
+	require.Contains(t, msg, "posix.system")
+
+	status, msg = c.Run(strings.NewReader(maliciousPickleV2))
+	require.Equal(t, types.SensitiveCheckUnsafe, status)
+	require.Contains(t, msg, "posix.system")
+
+	status, _ = c.Run(strings.NewReader(benignPickleV4))
+	require.Equal(t, types.SensitiveCheckPass, status)
+
+	// protocol 0 pickle which fails after the import is still reported
+	status, msg = c.Run(strings.NewReader("cbuiltins\neval\n(S'1'\ntR\xff"))
+	require.Equal(t, types.SensitiveCheckUnsafe, status)
+	require.Contains(t, msg, "builtins.eval")
+
+	// module and name of STACK_GLOBAL from memo
+	status, msg = c.Run(strings.NewReader("\x80\x04\x8c\x02os\x94\x8c\x03foo\x94h\x00\x8c\x06system\x93)R."))
+	require.Equal(t, types.SensitiveCheckUnsafe, status)
+	require.Contains(t, msg, "os.system")
+
+	// STACK_GLOBAL of values not pushed as strings
+	status, msg = c.Run(strings.NewReader("\x80\x04K\x01K\x02\x93."))
+	require.Equal(t, types.SensitiveCheckUnsafe, status)
+	require.Contains(t, msg, "unknown global")
+
+	status, _ = c.Run(strings.NewReader("just some binary weights"))
+	require.Equal(t, types.SensitiveCheckSkip, status)
+
+	status, _ = c.Run(strings.NewReader(""))
+	require.Equal(t, types.SensitiveCheckSkip, status)
+}
+
+func TestPickleFileChecker_RunPickles(t *testing.T) {
+	c := NewPickleFileChecker()
+
+	// pickles followed by raw data, like the legacy format of pytorch
+	status, _ := c.Run(strings.NewReader(benignPickleV4 + benignPickleV4 + "\x00\x01\x02raw tensor data"))
+	require.Equal(t, types.SensitiveCheckPass, status)
+
+	status, msg := c.Run(strings.NewReader(benignPickleV4 + maliciousPickleV4 + "\x00\x01\x02raw tensor data"))
+	require.Equal(t, types.SensitiveCheckUnsafe, status)
+	require.Contains(t, msg, "posix.system")
+}
+
+func TestPickleFileChecker_RunZip(t *testing.T) {
+	c := NewPickleFileChecker()
+
+	status, msg := c.Run(bytes.NewReader(buildZip(t, map[string]string{
+		"archive/data.pkl": maliciousPickleV2,
+		"archive/data/0":   strings.Repeat("\x00", 1024),
+	}, false)))
+	require.Equal(t, types.SensitiveCheckUnsafe, status)
+	require.Contains(t, msg, "posix.system")
+
+	status, _ = c.Run(bytes.NewReader(buildZip(t, map[string]string{
+		"archive/data/0":   strings.Repeat("\x00", 1024),
+		"archive/data.pkl": benignPickleV4,
+	}, false)))
+	require.Equal(t, types.SensitiveCheckPass, status)
+
+	// deflated entries with data descriptor
+	status, msg = c.Run(bytes.NewReader(buildZip(t, map[string]string{
+		"archive/data/0":   strings.Repeat("\x00", 1024),
+		"archive/data.pkl": maliciousPickleV4,
+	}, true)))
+	require.Equal(t, types.SensitiveCheckUnsafe, status)
+	require.Contains(t, msg, "posix.system")
+
+	status, _ = c.Run(bytes.NewReader(buildZip(t, map[string]string{
+		"README.md": "not a model",
+	}, false)))
+	require.Equal(t, types.SensitiveCheckSkip, status)
+}
+
+// buildZip writes the files in order of names, as stored entries with sizes in headers like pytorch does,
+// or as deflated entries with data descriptors
+func buildZip(t *testing.T, files map[string]string, deflate bool) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	names := []string{"archive/data/0", "archive/data.pkl", "README.md"}
+	for _, name := range names {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		if deflate {
+			f, err := w.Create(name)
+			require.NoError(t, err)
+			_, err = f.Write([]byte(content))
+			require.NoError(t, err)
+			continue
+		}
+		f, err := w.CreateRaw(&zip.FileHeader{
+			Name:               name,
+			Method:             zip.Store,
+			CRC32:              crc32.ChecksumIEEE([]byte(content)),
+			CompressedSize64:   uint64(len(content)),
+			UncompressedSize64: uint64(len(content)),
+		})
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestSafeWeightFileChecker_Run(t *testing.T) {
+	header := `{"w":{"dtype":"F32","shape":[1],"data_offsets":[0,4]}}`
+	safetensors := make([]byte, 8)
+	binary.LittleEndian.PutUint64(safetensors, uint64(len(header)))
+	safetensors = append(safetensors, header...)
+	safetensors = append(safetensors, 0, 0, 0, 0)
+
+	status, _ := NewSafeWeightFileChecker(".safetensors").Run(bytes.NewReader(safetensors))
+	require.Equal(t, types.SensitiveCheckPass, status)
+
+	status, _ = NewSafeWeightFileChecker(".GGUF").Run(strings.NewReader("GGUF\x03\x00\x00\x00"))
+	require.Equal(t, types.SensitiveCheckPass, status)
+
+	// pickle disguised as safetensors
+	status, msg := NewSafeWeightFileChecker(".safetensors").Run(strings.NewReader(maliciousPickleV4))
+	require.Equal(t, types.SensitiveCheckUnsafe, status)
+	require.Contains(t, msg, "posix.system")
+}
+
+func TestGetFileChecker(t *testing.T) {
+	require.IsType(t, &PickleFileChecker{}, GetFileChecker("file", "pytorch_model.bin", "ab/cd/abcd"))
+	require.IsType(t, &PickleFileChecker{}, GetFileChecker("file", "model.PT", ""))
+	require.IsType(t, &SafeWeightFileChecker{}, GetFileChecker("file", "model.safetensors", "ab/cd/abcd"))
+	require.IsType(t, &LfsFileChecker{}, GetFileChecker("file", "data.parquet", "ab/cd/abcd"))
+	require.IsType(t, &FolderChecker{}, GetFileChecker("folder", "model.bin", ""))
+}
@@ -0,0 +1,249 @@
+package checker
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// secretScanFileExts are the text files scanned for leaked secrets, besides the known text files the
+// notebooks and config files are scanned as credentials are usually pushed with them
+var secretScanFileExts = append([]string{".ipynb", ".yaml", ".yml", ".toml", ".ini", ".cfg", ".conf",
+	".env", ".properties", ".xml", ".tf", ".pem", ".key"}, knownTextFileExts...)
+
+// maxSecretScanSize is the max size of file content scanned for secrets
+const maxSecretScanSize = 4 * 1024 * 1024
+
+// IsSecretScanFile reports whether the file should be scanned for leaked secrets, LFS files are not
+// scanned as they are data or model weights
+func IsSecretScanFile(fileType string, filePath, lfsRelativePath string) bool {
+	if fileType == "folder" || lfsRelativePath != "" {
+		return false
+	}
+	// the ext of dot files like .env is the file name
+	ext := path.Ext(filePath)
+	return slices.ContainsFunc(secretScanFileExts, func(scanExt string) bool {
+		return strings.EqualFold(ext, scanExt)
+	})
+}
+
+const privateKeyRuleID = "private-key"
+
+// secretRule detects one kind of secret, the first submatch of pattern is the secret if there is one,
+// otherwise the whole match is. Matches with lower entropy than minEntropy are ignored, as they are
+// usually placeholders or variable names
+type secretRule struct {
+	ID          string
+	Description string
+	Pattern     *regexp.Regexp
+	MinEntropy  float64
+}
+
+var secretRules = []secretRule{
+	{
+		ID:          privateKeyRuleID,
+		Description: "private key",
+		Pattern:     regexp.MustCompile(`-----BEGIN[ A-Z0-9_-]{0,32} PRIVATE KEY(?: BLOCK)?-----`),
+	},
+	{
+		ID:          "aws-access-key-id",
+		Description: "AWS access key ID",
+		Pattern:     regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`),
+		MinEntropy:  3,
+	},
+	{
+		ID:          "aws-secret-access-key",
+		Description: "AWS secret access key",
+		Pattern:     regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+		MinEntropy:  4,
+	},
+	{
+		ID:          "aliyun-access-key-id",
+		Description: "Alibaba Cloud AccessKey ID",
+		Pattern:     regexp.MustCompile(`\b(LTAI[A-Za-z0-9]{12,20})\b`),
+		MinEntropy:  3,
+	},
+	{
+		ID:          "tencent-secret-id",
+		Description: "Tencent Cloud SecretId",
+		Pattern:     regexp.MustCompile(`\b(AKID[A-Za-z0-9]{13,40})\b`),
+		MinEntropy:  3,
+	},
+	{
+		ID:          "google-api-key",
+		Description: "Google API key",
+		Pattern:     regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`),
+		MinEntropy:  3,
+	},
+	{
+		ID:          "github-token",
+		Description: "GitHub token",
+		Pattern:     regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{82})\b`),
+		MinEntropy:  3,
+	},
+	{
+		ID:          "gitlab-token",
+		Description: "GitLab personal access token",
+		Pattern:     regexp.MustCompile(`\b(glpat-[A-Za-z0-9_-]{20})\b`),
+		MinEntropy:  3,
+	},
+	{
+		ID:          "huggingface-token",
+		Description: "Hugging Face access token",
+		Pattern:     regexp.MustCompile(`\b(hf_[A-Za-z]{34})\b`),
+		MinEntropy:  3,
+	},
+	{
+		ID:          "openai-api-key",
+		Description: "OpenAI API key",
+		Pattern:     regexp.MustCompile(`\b(sk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{32,})\b`),
+		MinEntropy:  3.5,
+	},
+	{
+		ID:          "slack-token",
+		Description: "Slack token",
+		Pattern:     regexp.MustCompile(`\b(xox[abposr]-[0-9A-Za-z-]{10,})\b`),
+		MinEntropy:  3,
+	},
+	{
+		ID:          "stripe-secret-key",
+		Description: "Stripe secret key",
+		Pattern:     regexp.MustCompile(`\b((?:sk|rk)_live_[0-9A-Za-z]{24,})\b`),
+		MinEntropy:  3,
+	},
+	{
+		// assignments of random strings to variables named like credentials, in code, configs or notebooks
+		// which have the quotes escaped in json
+		ID:          "generic-secret",
+		Description: "generic secret",
+		Pattern: regexp.MustCompile(`(?i)(?:api_?key|secret(?:_?key)?|access_?token|auth_?token|passw(?:or)?d|` +
+			`access_?key|client_?secret)\\?["']?\s*[:=]\s*\\?["']([A-Za-z0-9_\-+/=.~]{16,128})\\?["']`),
+		MinEntropy: 3.5,
+	},
+}
+
+// secretPlaceholders are the words found in example values, which are not real secrets
+var secretPlaceholders = []string{"example", "placeholder", "xxxx", "your_", "your-", "<", "${", "{{", "changeme", "dummy"}
+
+// SecretMatch is a secret found in file content
+type SecretMatch struct {
+	RuleID      string
+	Description string
+	// Line is the 1-based line number of the secret
+	Line   int
+	Secret string
+}
+
+// Fingerprint identifies the secret without keeping it, the same secret found in different files
+// or commits has the same fingerprint
+func (m SecretMatch) Fingerprint() string {
+	sum := sha256.Sum256([]byte(m.RuleID + ":" + m.Secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Redacted returns the secret with only a few chars at both ends kept, to be shown to the owners
+func (m SecretMatch) Redacted() string {
+	if len(m.Secret) <= 12 {
+		return strings.Repeat("*", len(m.Secret))
+	}
+	return m.Secret[:4] + strings.Repeat("*", len(m.Secret)-8) + m.Secret[len(m.Secret)-4:]
+}
+
+func (m SecretMatch) String() string {
+	return fmt.Sprintf("%s at line %d", m.Description, m.Line)
+}
+
+// ScanSecrets scans the text content line by line for secrets, at most maxSecretScanSize of content is
+// scanned. The same secret is reported once per line
+func ScanSecrets(reader io.Reader) ([]SecretMatch, error) {
+	s := bufio.NewScanner(io.LimitReader(reader, maxSecretScanSize))
+	// minified json and notebook outputs can be very long lines
+	s.Buffer(make([]byte, 0, 64*1024), maxSecretScanSize)
+
+	var matches []SecretMatch
+	line := 0
+	// the headers of all private keys are the same, the first line of key content is added to the
+	// secret of private key to tell them apart
+	privateKey := -1
+	for s.Scan() {
+		line++
+		text := s.Text()
+		if privateKey >= 0 {
+			matches[privateKey].Secret += "\n" + strings.TrimSpace(text)
+			privateKey = -1
+		}
+		for _, m := range scanLine(text, line) {
+			if m.RuleID == privateKeyRuleID {
+				privateKey = len(matches)
+			}
+			matches = append(matches, m)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return matches, fmt.Errorf("failed to read file content, error: %w", err)
+	}
+	return matches, nil
+}
+
+func scanLine(text string, line int) []SecretMatch {
+	var matches []SecretMatch
+	seen := make(map[string]bool)
+	for _, rule := range secretRules {
+		for _, sub := range rule.Pattern.FindAllStringSubmatch(text, -1) {
+			secret := sub[0]
+			if len(sub) > 1 {
+				secret = sub[1]
+			}
+			if seen[secret] || !rule.accept(secret) {
+				continue
+			}
+			seen[secret] = true
+			matches = append(matches, SecretMatch{
+				RuleID:      rule.ID,
+				Description: rule.Description,
+				Line:        line,
+				Secret:      secret,
+			})
+		}
+	}
+	return matches
+}
+
+func (r secretRule) accept(secret string) bool {
+	if r.MinEntropy == 0 {
+		return true
+	}
+	lower := strings.ToLower(secret)
+	for _, p := range secretPlaceholders {
+		if strings.Contains(lower, p) {
+			return false
+		}
+	}
+	return shannonEntropy(secret) >= r.MinEntropy
+}
+
+// shannonEntropy returns the entropy of string in bits per char
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
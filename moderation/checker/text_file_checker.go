@@ -46,13 +46,8 @@ func (c *TextFileChecker) Run(reader io.Reader) (types.SensitiveCheckStatus, str
 		var result *sensitive.CheckResult
 		var err error
 		slog.Debug("check text", slog.String("scenario", string(sensitive.ScenarioCommentDetection)), slog.String("text", buf.String()))
-		//do local check first
 		txt := buf.String()
-		contains := localWordChecker.ContainsSensitiveWord(txt)
-		if contains {
-			return types.SensitiveCheckFail, "contains sensitive word"
-		}
-		//call remote checker
+		//call the providers chained, local ones go first
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		result, err = c.PassTextCheck(ctx, sensitive.ScenarioCommentDetection, txt)
 		cancel()
@@ -0,0 +1,66 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/store/s3"
+)
+
+// LfsFileContentReader streams the content of lfs file from the s3 storage holding the object
+type LfsFileContentReader struct {
+	file        *database.RepositoryFile
+	lfsRouter   s3.LfsRouter
+	innerReader io.ReadCloser
+	once        *sync.Once
+}
+
+var _ io.ReadCloser = (*LfsFileContentReader)(nil)
+
+func NewLfsFileContentReader(file *database.RepositoryFile, lfsRouter s3.LfsRouter) *LfsFileContentReader {
+	return &LfsFileContentReader{
+		file:      file,
+		lfsRouter: lfsRouter,
+		once:      &sync.Once{},
+	}
+}
+
+func (c *LfsFileContentReader) Read(p []byte) (n int, err error) {
+	c.lazyInit()
+
+	if c.innerReader == nil {
+		return 0, errors.New("failed to read file content as lfs object reader not initialized")
+	}
+	return c.innerReader.Read(p)
+}
+
+func (c *LfsFileContentReader) Close() error {
+	if c.innerReader == nil {
+		return nil
+	}
+	return c.innerReader.Close()
+}
+
+func (c *LfsFileContentReader) lazyInit() {
+	c.once.Do(func() {
+		ctx := context.Background()
+		oid := strings.ReplaceAll(c.file.LfsRelativePath, "/", "")
+		storage, err := c.lfsRouter.ForObject(ctx, c.file.Repository, oid)
+		if err != nil {
+			slog.Error("failed to find storage of lfs file", slog.Any("error", err), slog.String("path", c.file.Path), slog.Int64("repository_file_id", c.file.ID))
+			return
+		}
+		objectKey := path.Join("lfs", c.file.LfsRelativePath)
+		c.innerReader, err = storage.Client.GetObject(ctx, storage.Bucket, objectKey, minio.GetObjectOptions{})
+		if err != nil {
+			slog.Error("failed to create lfs object reader", slog.Any("error", err), slog.String("path", c.file.Path), slog.Int64("repository_file_id", c.file.ID))
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package component
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"opencsg.com/csghub-server/builder/git/membership"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+// reportSensitiveFile opens a moderation case for the file failing the sensitive check, or logs the recheck
+// in the case of file not cleared yet. The case cleared by moderators is returned as is if the file is not
+// changed since, so that the same file is not reported again
+func (c *repoComponentImpl) reportSensitiveFile(ctx context.Context, file *database.RepositoryFile, msg string) (*database.ModerationCase, error) {
+	latest, err := c.mcs.FindLatestByRepoFile(ctx, file.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find moderation case of repo file %d, error: %w", file.ID, err)
+	}
+	if latest != nil {
+		if latest.Status == types.ModerationCaseCleared && latest.CommitSha == file.CommitSha {
+			return latest, nil
+		}
+		if latest.Status != types.ModerationCaseCleared {
+			from := latest.Status
+			latest.Message = msg
+			latest.CommitSha = file.CommitSha
+			_, err = c.mcs.Transit(ctx, latest, from, database.ModerationCaseLog{
+				Action:     types.ModerationActionRecheck,
+				Operator:   types.ModerationSystemOperator,
+				FromStatus: from,
+				ToStatus:   from,
+				Comment:    msg,
+			}, "message", "commit_sha")
+			if err != nil {
+				return nil, fmt.Errorf("failed to update moderation case %d, error: %w", latest.ID, err)
+			}
+			return latest, nil
+		}
+	}
+
+	repoWasPrivate := file.Repository.Private
+	unresolved, err := c.mcs.ListUnresolvedByRepo(ctx, file.RepositoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list moderation cases of repository %d, error: %w", file.RepositoryID, err)
+	}
+	if len(unresolved) > 0 {
+		// the repository has been made private by the cases opened before
+		repoWasPrivate = unresolved[0].RepoWasPrivate
+	}
+	mc, err := c.mcs.Create(ctx, database.ModerationCase{
+		RepositoryID:   file.RepositoryID,
+		RepoFileID:     file.ID,
+		Path:           file.Path,
+		CommitSha:      file.CommitSha,
+		Message:        msg,
+		Status:         types.ModerationCaseOpen,
+		RepoWasPrivate: repoWasPrivate,
+	}, database.ModerationCaseLog{
+		Action:   types.ModerationActionOpen,
+		Operator: types.ModerationSystemOperator,
+		ToStatus: types.ModerationCaseOpen,
+		Comment:  msg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("moderation case opened", slog.Int64("case_id", mc.ID), slog.String("path", file.Path),
+		slog.Int64("repository_id", file.RepositoryID))
+
+	err = c.webhookNotifier.Notify(ctx, file.Repository, types.WebhookEventModeration, types.WebhookModerationData{
+		CaseID:  mc.ID,
+		Action:  types.ModerationActionOpen,
+		Status:  mc.Status,
+		Path:    mc.Path,
+		Message: mc.Message,
+	})
+	if err != nil {
+		slog.Error("failed to notify moderation case", slog.Int64("case_id", mc.ID), slog.Any("error", err))
+	}
+	c.notifyRepoOwners(ctx, file.Repository, mc)
+	return mc, nil
+}
+
+// resolveSensitiveFile clears the unresolved case of the file passing the recheck, as its content is removed or
+// the file is deleted. The repository is restored as clearing the case by moderators once all of its cases
+// are cleared
+func (c *repoComponentImpl) resolveSensitiveFile(ctx context.Context, file *database.RepositoryFile) error {
+	latest, err := c.mcs.FindLatestByRepoFile(ctx, file.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find moderation case of repo file %d, error: %w", file.ID, err)
+	}
+	if latest == nil || latest.Status.Resolved() {
+		return nil
+	}
+	from := latest.Status
+	comment := "the file passes the recheck"
+	latest.Status = types.ModerationCaseCleared
+	latest.CommitSha = file.CommitSha
+	updated, err := c.mcs.Transit(ctx, latest, from, database.ModerationCaseLog{
+		Action:     types.ModerationActionRecheck,
+		Operator:   types.ModerationSystemOperator,
+		FromStatus: from,
+		ToStatus:   latest.Status,
+		Comment:    comment,
+	}, "commit_sha")
+	if err != nil {
+		return fmt.Errorf("failed to update moderation case %d, error: %w", latest.ID, err)
+	}
+	if !updated {
+		return fmt.Errorf("moderation case %d has been changed by others", latest.ID)
+	}
+	slog.Info("moderation case cleared by recheck", slog.Int64("case_id", latest.ID), slog.String("path", file.Path),
+		slog.Int64("repository_id", file.RepositoryID))
+
+	err = c.webhookNotifier.Notify(ctx, file.Repository, types.WebhookEventModeration, types.WebhookModerationData{
+		CaseID:  latest.ID,
+		Action:  types.ModerationActionRecheck,
+		Status:  latest.Status,
+		Path:    latest.Path,
+		Message: latest.Message,
+		Reason:  comment,
+	})
+	if err != nil {
+		slog.Error("failed to notify moderation case", slog.Int64("case_id", latest.ID), slog.Any("error", err))
+	}
+
+	unresolved, err := c.mcs.ListUnresolvedByRepo(ctx, file.RepositoryID)
+	if err != nil {
+		return fmt.Errorf("failed to list moderation cases of repository %d, error: %w", file.RepositoryID, err)
+	}
+	if len(unresolved) > 0 {
+		return nil
+	}
+	repo := file.Repository
+	repo.SensitiveCheckStatus = types.SensitiveCheckPass
+	repo.Private = latest.RepoWasPrivate
+	_, err = c.rs.UpdateRepo(ctx, *repo)
+	if err != nil {
+		return fmt.Errorf("failed to restore repository %d, error: %w", repo.ID, err)
+	}
+	slog.Info("all moderation cases of repository cleared", slog.Int64("repository_id", repo.ID),
+		slog.Bool("private", repo.Private))
+	return nil
+}
+
+// reportRetryDelays are the delays between the attempts to report a sensitive file
+var reportRetryDelays = []time.Duration{time.Second, 5 * time.Second}
+
+// reportSensitiveFileWithRetry reports the sensitive file, and tries again on errors like a database outage
+func (c *repoComponentImpl) reportSensitiveFileWithRetry(ctx context.Context, file *database.RepositoryFile, msg string) (*database.ModerationCase, error) {
+	mc, err := c.reportSensitiveFile(ctx, file, msg)
+	for _, delay := range reportRetryDelays {
+		if err == nil {
+			break
+		}
+		slog.Warn("failed to report sensitive file, retry later", slog.Int64("repo_file_id", file.ID),
+			slog.Duration("delay", delay), slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		mc, err = c.reportSensitiveFile(ctx, file, msg)
+	}
+	return mc, err
+}
+
+// notifyRepoOwners sends an in-app notification of the opened case to the owners of repository, they are
+// the user of a user namespace or the admins of an organization
+func (c *repoComponentImpl) notifyRepoOwners(ctx context.Context, repo *database.Repository, mc *database.ModerationCase) {
+	ownerIDs, err := c.repoOwnerIDs(ctx, repo)
+	if err != nil {
+		slog.Error("failed to find owners of repository to notify moderation case", slog.Int64("case_id", mc.ID),
+			slog.String("repo", repo.Path), slog.Any("error", err))
+		return
+	}
+	for _, userID := range ownerIDs {
+		_, err := c.nfs.Create(ctx, database.Notification{
+			UserID: userID,
+			Kind:   types.NotificationSensitiveFile,
+			Title:  "Sensitive file found in your repository",
+			Content: fmt.Sprintf("File %s of %s failed the content moderation and the repository is made private. "+
+				"You can appeal moderation case %d if the file is reported by mistake.", mc.Path, repo.Path, mc.ID),
+			Link: fmt.Sprintf("/%ss/%s", repo.RepositoryType, repo.Path),
+		})
+		if err != nil {
+			slog.Error("failed to notify repository owner of moderation case", slog.Int64("case_id", mc.ID),
+				slog.Int64("user_id", userID), slog.Any("error", err))
+		}
+	}
+}
+
+func (c *repoComponentImpl) repoOwnerIDs(ctx context.Context, repo *database.Repository) ([]int64, error) {
+	namespace, _ := repo.NamespaceAndName()
+	ns, err := c.nss.FindByPath(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find namespace %s, error: %w", namespace, err)
+	}
+	if ns.NamespaceType == database.UserNamespace {
+		return []int64{ns.UserID}, nil
+	}
+	org, err := c.ogs.FindByPath(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find organization %s, error: %w", namespace, err)
+	}
+	admins, err := c.mbs.ListByRole(ctx, org.ID, string(membership.RoleAdmin))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admins of organization %s, error: %w", namespace, err)
+	}
+	ownerIDs := make([]int64, 0, len(admins))
+	for _, m := range admins {
+		ownerIDs = append(ownerIDs, m.UserID)
+	}
+	return ownerIDs, nil
+}
@@ -0,0 +1,166 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+)
+
+type fakeCaseStore struct {
+	database.ModerationCaseStore
+	err   error
+	cases []database.ModerationCase
+	logs  []database.ModerationCaseLog
+}
+
+func (s *fakeCaseStore) FindLatestByRepoFile(ctx context.Context, repoFileID int64) (*database.ModerationCase, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	for i := len(s.cases) - 1; i >= 0; i-- {
+		if s.cases[i].RepoFileID == repoFileID {
+			mc := s.cases[i]
+			return &mc, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeCaseStore) ListUnresolvedByRepo(ctx context.Context, repoID int64) ([]database.ModerationCase, error) {
+	var unresolved []database.ModerationCase
+	for _, mc := range s.cases {
+		if mc.RepositoryID == repoID && !mc.Status.Resolved() {
+			unresolved = append(unresolved, mc)
+		}
+	}
+	return unresolved, nil
+}
+
+func (s *fakeCaseStore) Transit(ctx context.Context, mc *database.ModerationCase, from types.ModerationCaseStatus, log database.ModerationCaseLog, columns ...string) (bool, error) {
+	for i := range s.cases {
+		if s.cases[i].ID == mc.ID && s.cases[i].Status == from {
+			s.cases[i] = *mc
+			s.logs = append(s.logs, log)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeCaseStore) Create(ctx context.Context, mc database.ModerationCase, log database.ModerationCaseLog) (*database.ModerationCase, error) {
+	mc.ID = int64(len(s.cases) + 1)
+	s.cases = append(s.cases, mc)
+	return &mc, nil
+}
+
+type fakeCaseRepoStore struct {
+	database.RepoStore
+	updated []database.Repository
+}
+
+func (s *fakeCaseRepoStore) UpdateRepo(ctx context.Context, input database.Repository) (*database.Repository, error) {
+	s.updated = append(s.updated, input)
+	return &input, nil
+}
+
+type fakeFileCheckStore struct {
+	database.RepoFileCheckStore
+	checks []database.RepositoryFileCheck
+}
+
+func (s *fakeFileCheckStore) Upsert(ctx context.Context, history database.RepositoryFileCheck) error {
+	s.checks = append(s.checks, history)
+	return nil
+}
+
+type fakeWebhookNotifier struct{}
+
+func (fakeWebhookNotifier) Notify(ctx context.Context, repo *database.Repository, event types.WebhookEvent, data any) error {
+	return nil
+}
+
+type fakeNamespaceStore struct {
+	database.NamespaceStore
+}
+
+func (s *fakeNamespaceStore) FindByPath(ctx context.Context, path string) (database.Namespace, error) {
+	return database.Namespace{Path: path, UserID: 7, NamespaceType: database.UserNamespace}, nil
+}
+
+type fakeNotificationStore struct {
+	database.NotificationStore
+	notifications []database.Notification
+}
+
+func (s *fakeNotificationStore) Create(ctx context.Context, notification database.Notification) (*database.Notification, error) {
+	s.notifications = append(s.notifications, notification)
+	return &notification, nil
+}
+
+func TestRepoComponent_SaveCheckResult(t *testing.T) {
+	reportRetryDelays = nil
+	ctx := context.Background()
+	newFile := func() *database.RepositoryFile {
+		return &database.RepositoryFile{
+			ID:           1,
+			RepositoryID: 2,
+			Path:         "README.md",
+			Repository:   &database.Repository{ID: 2, Path: "user1/model1", RepositoryType: types.ModelRepo},
+		}
+	}
+	cases := &fakeCaseStore{err: errors.New("db is down")}
+	repos := &fakeCaseRepoStore{}
+	checks := &fakeFileCheckStore{}
+	notifications := &fakeNotificationStore{}
+	c := &repoComponentImpl{
+		rs:              repos,
+		rfcs:            checks,
+		mcs:             cases,
+		webhookNotifier: fakeWebhookNotifier{},
+		nss:             &fakeNamespaceStore{},
+		nfs:             notifications,
+	}
+
+	// the file is left unchecked and the repository untouched if the case can't be opened
+	err := c.saveCheckResult(ctx, newFile(), types.SensitiveCheckFail, "bad words")
+	require.Error(t, err)
+	require.Empty(t, repos.updated)
+	require.Empty(t, checks.checks)
+
+	cases.err = nil
+	err = c.saveCheckResult(ctx, newFile(), types.SensitiveCheckFail, "bad words")
+	require.NoError(t, err)
+	require.Len(t, cases.cases, 1)
+	require.Len(t, repos.updated, 1)
+	require.True(t, repos.updated[0].Private)
+	require.Len(t, checks.checks, 1)
+	require.Equal(t, types.SensitiveCheckFail, checks.checks[0].Status)
+	require.Len(t, notifications.notifications, 1)
+	require.Equal(t, int64(7), notifications.notifications[0].UserID)
+	require.Equal(t, types.NotificationSensitiveFile, notifications.notifications[0].Kind)
+	require.Equal(t, "/models/user1/model1", notifications.notifications[0].Link)
+
+	// the case is cleared by the recheck the file passes, and the repository is restored
+	err = c.saveCheckResult(ctx, newFile(), types.SensitiveCheckPass, "")
+	require.NoError(t, err)
+	require.Equal(t, types.ModerationCaseCleared, cases.cases[0].Status)
+	require.Len(t, cases.logs, 1)
+	require.Equal(t, types.ModerationActionRecheck, cases.logs[0].Action)
+	require.Equal(t, types.ModerationSystemOperator, cases.logs[0].Operator)
+	require.Equal(t, types.ModerationCaseCleared, cases.logs[0].ToStatus)
+	require.Len(t, repos.updated, 2)
+	require.False(t, repos.updated[1].Private)
+	require.Equal(t, types.SensitiveCheckPass, repos.updated[1].SensitiveCheckStatus)
+	require.Len(t, checks.checks, 2)
+	require.Equal(t, types.SensitiveCheckPass, checks.checks[1].Status)
+
+	// files without unresolved cases pass without touching the repository
+	err = c.saveCheckResult(ctx, newFile(), types.SensitiveCheckPass, "")
+	require.NoError(t, err)
+	require.Len(t, cases.logs, 1)
+	require.Len(t, repos.updated, 2)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"time"
 
@@ -11,6 +12,8 @@ import (
 	"opencsg.com/csghub-server/builder/git/gitserver"
 	"opencsg.com/csghub-server/builder/sensitive"
 	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/builder/store/s3"
+	"opencsg.com/csghub-server/builder/webhook"
 	"opencsg.com/csghub-server/common/config"
 	"opencsg.com/csghub-server/common/types"
 	"opencsg.com/csghub-server/moderation/checker"
@@ -21,7 +24,22 @@ type repoComponentImpl struct {
 	rs      database.RepoStore
 	rfs     database.RepoFileStore
 	rfcs    database.RepoFileCheckStore
-	git     gitserver.GitServer
+	mcs     database.ModerationCaseStore
+	// findings and allowlist of secret scanning
+	sfs database.SecretFindingStore
+	sas database.SecretAllowlistStore
+	git gitserver.GitServer
+	// lfs objects of model files are streamed from s3 to scan for unsafe serialization
+	lfsRouter s3.LfsRouter
+	// notifies the owners of moderation cases by the webhooks of repository
+	webhookNotifier webhook.Notifier
+	// notifies the owners of moderation cases in app
+	nss database.NamespaceStore
+	ogs database.OrgStore
+	mbs database.MemberStore
+	nfs database.NotificationStore
+	// checks the image urls of requests, like avatars
+	images *checker.ImageChecker
 }
 
 type RepoComponent interface {
@@ -31,7 +49,11 @@ type RepoComponent interface {
 }
 
 func NewRepoComponent(cfg *config.Config) (RepoComponent, error) {
-	c := &repoComponentImpl{checker: sensitive.NewAliyunGreenChecker(cfg)}
+	sc, err := sensitive.NewSensitiveChecker(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sensitive checker for sensitive component: %w", err)
+	}
+	c := &repoComponentImpl{checker: sc, images: checker.NewImageChecker()}
 	gs, err := git.NewGitServer(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create git server for sensitive component: %w", err)
@@ -39,7 +61,19 @@ func NewRepoComponent(cfg *config.Config) (RepoComponent, error) {
 	c.rs = database.NewRepoStore()
 	c.rfs = database.NewRepoFileStore()
 	c.rfcs = database.NewRepoFileCheckStore()
+	c.mcs = database.NewModerationCaseStore()
+	c.sfs = database.NewSecretFindingStore()
+	c.sas = database.NewSecretAllowlistStore()
 	c.git = gs
+	c.lfsRouter, err = s3.NewLfsRouter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lfs router for sensitive component: %w", err)
+	}
+	c.webhookNotifier = webhook.NewNotifier()
+	c.nss = database.NewNamespaceStore()
+	c.ogs = database.NewOrgStore()
+	c.mbs = database.NewMemberStore()
+	c.nfs = database.NewNotificationStore()
 
 	return c, nil
 }
@@ -103,7 +137,13 @@ func (c *repoComponentImpl) CheckRepoFiles(ctx context.Context, repoType types.R
 }
 
 func (c *repoComponentImpl) processFile(ctx context.Context, file *database.RepositoryFile) {
-	reader := NewRepoFileContentReader(file, c.git)
+	var reader io.ReadCloser
+	if file.LfsRelativePath != "" {
+		reader = NewLfsFileContentReader(file, c.lfsRouter)
+	} else {
+		reader = NewRepoFileContentReader(file, c.git)
+	}
+	defer reader.Close()
 	checker := checker.GetFileChecker(file.FileType, file.Path, file.LfsRelativePath)
 	status, msg := checker.Run(reader)
 	if status == types.SensitiveCheckException {
@@ -112,6 +152,7 @@ func (c *repoComponentImpl) processFile(ctx context.Context, file *database.Repo
 	}
 
 	c.saveCheckResult(ctx, file, status, msg)
+	c.scanSecrets(ctx, file)
 }
 
 func (c *repoComponentImpl) saveCheckResult(ctx context.Context, file *database.RepositoryFile, status types.SensitiveCheckStatus, msg string) error {
@@ -124,7 +165,31 @@ func (c *repoComponentImpl) saveCheckResult(ctx context.Context, file *database.
 
 	fail := status == types.SensitiveCheckFail
 	if fail {
-		// TODO: public a sensitive check status event to message queue
+		// the case must be opened before the repository is made private, to restore its visibility
+		// once the case is cleared. The file is left unchecked if the case can't be opened, to be checked
+		// again by the next check of repository
+		mc, err := c.reportSensitiveFileWithRetry(ctx, file, msg)
+		if err != nil {
+			slog.Error("failed to report sensitive file to moderation case, leave it unchecked", slog.Int64("repo_file_id", file.ID),
+				slog.String("path", file.Path), slog.Any("error", err))
+			return fmt.Errorf("failed to report sensitive file %d, error: %w", file.ID, err)
+		}
+		if mc.Status == types.ModerationCaseCleared {
+			fail = false
+			fcr.Status = types.SensitiveCheckPass
+			fcr.Message = fmt.Sprintf("cleared by moderator in moderation case %d", mc.ID)
+		}
+	}
+	if status == types.SensitiveCheckPass {
+		// the file is left unchecked if its case can't be cleared, to be checked again by the next check of repository
+		err := c.resolveSensitiveFile(ctx, file)
+		if err != nil {
+			slog.Error("failed to clear moderation case of repo file, leave it unchecked", slog.Int64("repo_file_id", file.ID),
+				slog.String("path", file.Path), slog.Any("error", err))
+			return fmt.Errorf("failed to clear moderation case of repo file %d, error: %w", file.ID, err)
+		}
+	}
+	if fail {
 		// set repository to private and set sensitive_check_status to fail
 		file.Repository.Private = true
 		file.Repository.SensitiveCheckStatus = types.SensitiveCheckFail
@@ -150,7 +215,21 @@ func (c *repoComponentImpl) saveCheckResult(ctx context.Context, file *database.
 func (cc *repoComponentImpl) CheckRequestV2(ctx context.Context, req types.SensitiveRequestV2) (bool, error) {
 	fields := req.GetSensitiveFields()
 	for _, field := range fields {
-		pass, err := cc.checker.PassTextCheck(ctx, sensitive.Scenario(field.Scenario), field.Value())
+		if len(field.Value()) == 0 {
+			continue
+		}
+		var pass *sensitive.CheckResult
+		var err error
+		if field.IsImage {
+			pass, err = cc.images.CheckURL(ctx, sensitive.Scenario(field.Scenario), field.Value())
+			if errors.Is(err, checker.ErrImageUnsupported) || errors.Is(err, checker.ErrImageTooLarge) {
+				// the moderation services can't check them, like svg avatars, skip them as the markdown check does
+				slog.Debug("skip image of request", slog.String("field", field.Name), slog.Any("error", err))
+				continue
+			}
+		} else {
+			pass, err = cc.checker.PassTextCheck(ctx, sensitive.Scenario(field.Scenario), field.Value())
+		}
 		if err != nil {
 			slog.Error("fail to check request sensitivity", slog.String("field", field.Name), slog.Any("error", err))
 			return false, fmt.Errorf("fail to check '%s' sensitivity, error: %w", field.Name, err)
@@ -0,0 +1,147 @@
+package component
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"opencsg.com/csghub-server/builder/store/database"
+	"opencsg.com/csghub-server/common/types"
+	"opencsg.com/csghub-server/moderation/checker"
+)
+
+// scanSecrets scans the text file for leaked secrets like cloud keys and tokens, the owners of repository are
+// notified of the new secrets found. Leaked secrets don't fail the sensitive check, it's up to the owners to
+// revoke them and dismiss the findings
+func (c *repoComponentImpl) scanSecrets(ctx context.Context, file *database.RepositoryFile) {
+	if !checker.IsSecretScanFile(file.FileType, file.Path, file.LfsRelativePath) {
+		return
+	}
+	reader := NewRepoFileContentReader(file, c.git)
+	defer reader.Close()
+	matches, err := checker.ScanSecrets(reader)
+	if err != nil {
+		// findings are not synced with a partial scan, or the secrets not scanned are taken as fixed
+		slog.Error("failed to scan secrets of repo file", slog.Int64("repo_file_id", file.ID),
+			slog.String("path", file.Path), slog.Any("error", err))
+		return
+	}
+	created, err := c.saveSecretFindings(ctx, file, matches)
+	if err != nil {
+		slog.Error("failed to save secret findings of repo file", slog.Int64("repo_file_id", file.ID),
+			slog.String("path", file.Path), slog.Any("error", err))
+	}
+	if len(created) == 0 {
+		return
+	}
+	slog.Info("secrets found in repo file", slog.Int64("repository_id", file.RepositoryID),
+		slog.String("path", file.Path), slog.Int("count", len(created)))
+
+	data := types.WebhookSecretScanningData{
+		Path:      file.Path,
+		CommitSha: file.CommitSha,
+	}
+	for _, f := range created {
+		data.Findings = append(data.Findings, types.SecretFinding{
+			ID:           f.ID,
+			RepositoryID: f.RepositoryID,
+			Path:         f.Path,
+			CommitSha:    f.CommitSha,
+			Line:         f.Line,
+			RuleID:       f.RuleID,
+			Description:  f.Description,
+			Fingerprint:  f.Fingerprint,
+			Redacted:     f.Redacted,
+			Status:       f.Status,
+			CreatedAt:    f.CreatedAt,
+			UpdatedAt:    f.UpdatedAt,
+		})
+	}
+	err = c.webhookNotifier.Notify(ctx, file.Repository, types.WebhookEventSecretScanning, data)
+	if err != nil {
+		slog.Error("failed to notify secret findings", slog.Int64("repo_file_id", file.ID), slog.Any("error", err))
+	}
+}
+
+// saveSecretFindings syncs the findings of file with the secrets found in its latest version. Secrets allowed
+// by the allowlist of repository are ignored, findings not found anymore are fixed, and fixed findings found
+// again are reopened. Dismissed findings are kept dismissed. The findings created are returned
+func (c *repoComponentImpl) saveSecretFindings(ctx context.Context, file *database.RepositoryFile, matches []checker.SecretMatch) ([]database.SecretFinding, error) {
+	allowlist, err := c.sas.ListByRepo(ctx, file.RepositoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secret allowlist of repository %d, error: %w", file.RepositoryID, err)
+	}
+	findings, err := c.sfs.ListByRepoPath(ctx, file.RepositoryID, file.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secret findings, error: %w", err)
+	}
+	existing := make(map[string]*database.SecretFinding, len(findings))
+	for i := range findings {
+		existing[findings[i].Fingerprint] = &findings[i]
+	}
+
+	var created []database.SecretFinding
+	found := make(map[string]bool)
+	for _, m := range matches {
+		fp := m.Fingerprint()
+		// the same secret in a file is reported once at its first line
+		if found[fp] || allowed(allowlist, file.Path, fp) {
+			continue
+		}
+		found[fp] = true
+
+		if f, ok := existing[fp]; ok {
+			f.RepoFileID = file.ID
+			f.CommitSha = file.CommitSha
+			f.Line = m.Line
+			if f.Status == types.SecretFindingFixed {
+				f.Status = types.SecretFindingOpen
+			}
+			err = c.sfs.Update(ctx, f, "repo_file_id", "commit_sha", "line", "status")
+			if err != nil {
+				return created, err
+			}
+			continue
+		}
+		f, err := c.sfs.Create(ctx, database.SecretFinding{
+			RepositoryID: file.RepositoryID,
+			RepoFileID:   file.ID,
+			Path:         file.Path,
+			CommitSha:    file.CommitSha,
+			Line:         m.Line,
+			RuleID:       m.RuleID,
+			Description:  m.Description,
+			Fingerprint:  fp,
+			Redacted:     m.Redacted(),
+			Status:       types.SecretFindingOpen,
+		})
+		if err != nil {
+			return created, err
+		}
+		created = append(created, *f)
+	}
+
+	for fp, f := range existing {
+		if found[fp] || f.Status != types.SecretFindingOpen {
+			continue
+		}
+		f.RepoFileID = file.ID
+		f.CommitSha = file.CommitSha
+		f.Status = types.SecretFindingFixed
+		err = c.sfs.Update(ctx, f, "repo_file_id", "commit_sha", "status")
+		if err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+func allowed(allowlist []database.SecretAllowlistEntry, path, fingerprint string) bool {
+	for _, e := range allowlist {
+		entry := types.SecretAllowlistEntry{Fingerprint: e.Fingerprint, PathPattern: e.PathPattern}
+		if entry.Allows(path, fingerprint) {
+			return true
+		}
+	}
+	return false
+}
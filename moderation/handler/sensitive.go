@@ -1,21 +1,30 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 
 	"github.com/gin-gonic/gin"
 	"opencsg.com/csghub-server/api/httpbase"
 	"opencsg.com/csghub-server/builder/sensitive"
 	"opencsg.com/csghub-server/common/config"
+	"opencsg.com/csghub-server/moderation/checker"
 )
 
 type SensitiveHandler struct {
-	c sensitive.SensitiveChecker
+	c  sensitive.SensitiveChecker
+	ic *checker.ImageChecker
 }
 
 func NewSensitiveHandler(cfg *config.Config) (*SensitiveHandler, error) {
+	c, err := sensitive.NewSensitiveChecker(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sensitive checker: %w", err)
+	}
 	return &SensitiveHandler{
-		c: sensitive.NewAliyunGreenChecker(cfg),
+		c:  c,
+		ic: checker.NewImageChecker(),
 	}, nil
 }
 
@@ -66,3 +75,32 @@ func (h *SensitiveHandler) Image(ctx *gin.Context) {
 
 	httpbase.OK(ctx, result)
 }
+
+// ImageURL checks the image of url, like user avatars and space cover images
+func (h *SensitiveHandler) ImageURL(ctx *gin.Context) {
+	type req struct {
+		Scenario sensitive.Scenario `json:"scenario"`
+		URL      string             `json:"url" binding:"required"`
+	}
+	var (
+		r   req
+		err error
+	)
+	if err = ctx.ShouldBindJSON(&r); err != nil {
+		slog.Error("Bad request format", slog.String("err", err.Error()))
+		httpbase.BadRequest(ctx, err.Error())
+		return
+	}
+	result, err := h.ic.CheckURL(ctx, r.Scenario, r.URL)
+	if err != nil {
+		if errors.Is(err, checker.ErrImageUnavailable) || errors.Is(err, checker.ErrImageTooLarge) ||
+			errors.Is(err, checker.ErrImageUnsupported) {
+			httpbase.BadRequest(ctx, err.Error())
+			return
+		}
+		httpbase.ServerError(ctx, err)
+		return
+	}
+
+	httpbase.OK(ctx, result)
+}
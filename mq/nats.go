@@ -46,6 +46,10 @@ var (
 		StreamName:   "meteringEventStream", // metering request
 		ConsumerName: "metertingServerDurableConsumer",
 	}
+
+	// balance notifications of accounting, consumed by each service with its own durable consumer
+	notifyStreamName         string = "accountingNotifyStream"
+	balanceNotifySubjectName string = "accounting.notify.balance"
 )
 
 type NatsHandler struct {
@@ -166,6 +170,26 @@ func (nh *NatsHandler) BuildDLQStream() error {
 	return err
 }
 
+func (nh *NatsHandler) BuildNotifyStream() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := nh.GetJetStream()
+	if err != nil {
+		return err
+	}
+	streamCfg, _ := initStreamAndConsumerConfig(EventConfig{StreamName: notifyStreamName}, []string{balanceNotifySubjectName})
+	_, err = nh.CreateOrUpdateStream(ctx, notifyStreamName, streamCfg)
+	return err
+}
+
+// BuildNotifyConsumer creates the durable consumer of balance notifications, the notifications are
+// delivered to every consumer of different names
+func (nh *NatsHandler) BuildNotifyConsumer(consumerName string) (jetstream.Consumer, error) {
+	cfg := EventConfig{StreamName: notifyStreamName, ConsumerName: consumerName}
+	streamCfg, consumerCfg := initStreamAndConsumerConfig(cfg, []string{balanceNotifySubjectName})
+	return nh.BuildEventStreamAndConsumer(cfg, streamCfg, consumerCfg)
+}
+
 func (nh *NatsHandler) FetchMeterEventMessages(batch int) (jetstream.MessageBatch, error) {
 	msgs, err := nh.meterJsc.Fetch(batch, jetstream.FetchMaxWait(time.Duration(nh.msgFetchTimeoutInSec)*time.Second))
 	return msgs, err
@@ -200,3 +224,69 @@ func (nh *NatsHandler) PublishMeterDataToDLQ(data []byte) error {
 func (nh *NatsHandler) PublishMeterDurationData(data []byte) error {
 	return nh.PublishData(nh.meterReqSub.duration, data)
 }
+
+func (nh *NatsHandler) PublishMeterTokenData(data []byte) error {
+	return nh.PublishData(nh.meterReqSub.token, data)
+}
+
+func (nh *NatsHandler) PublishBalanceNotification(data []byte) error {
+	return nh.PublishData(balanceNotifySubjectName, data)
+}
+
+func (nh *NatsHandler) dlqStream(ctx context.Context) (jetstream.Stream, error) {
+	err := nh.GetJetStream()
+	if err != nil {
+		return nil, err
+	}
+	return nh.js.Stream(ctx, dlqCfg.StreamName)
+}
+
+// ListDLQMessages returns at most limit meter messages of DLQ from the sequence fromSeq on
+func (nh *NatsHandler) ListDLQMessages(ctx context.Context, fromSeq uint64, limit int) ([]*jetstream.RawStreamMsg, error) {
+	stream, err := nh.dlqStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []*jetstream.RawStreamMsg
+	seq := fromSeq
+	for len(msgs) < limit {
+		// get the next message at or after seq, deleted messages are skipped
+		msg, err := stream.GetMsg(ctx, seq, jetstream.WithGetMsgSubject(dlq.MeterSubjectName))
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+		seq = msg.Sequence + 1
+	}
+	return msgs, nil
+}
+
+// GetDLQMessage returns the message of DLQ with the sequence, jetstream.ErrMsgNotFound is returned
+// if there is no such message
+func (nh *NatsHandler) GetDLQMessage(ctx context.Context, seq uint64) (*jetstream.RawStreamMsg, error) {
+	stream, err := nh.dlqStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return stream.GetMsg(ctx, seq)
+}
+
+func (nh *NatsHandler) DeleteDLQMessage(ctx context.Context, seq uint64) error {
+	stream, err := nh.dlqStream(ctx)
+	if err != nil {
+		return err
+	}
+	return stream.DeleteMsg(ctx, seq)
+}
+
+// PurgeDLQ removes all messages of DLQ
+func (nh *NatsHandler) PurgeDLQ(ctx context.Context) error {
+	stream, err := nh.dlqStream(ctx)
+	if err != nil {
+		return err
+	}
+	return stream.Purge(ctx)
+}
@@ -117,6 +117,17 @@ func (s *ServiceComponent) GenerateService(ctx context.Context, cluster cluster.
 		templateAnnotations["autoscaling.knative.dev/min-scale"] = strconv.Itoa(request.MinReplica)
 		templateAnnotations["autoscaling.knative.dev/max-scale"] = strconv.Itoa(request.MaxReplica)
 		templateAnnotations["serving.knative.dev/progress-deadline"] = fmt.Sprintf("%dm", s.env.Model.DeployTimeoutInMin)
+		// scale to zero is done by server after idle minutes, so knative never scales below min replica
+		if policy := request.Autoscaling; policy != nil {
+			templateAnnotations["autoscaling.knative.dev/metric"] = string(policy.Metric)
+			templateAnnotations["autoscaling.knative.dev/target"] = strconv.FormatFloat(policy.Target, 'f', -1, 64)
+			if policy.StableWindowSeconds > 0 {
+				templateAnnotations["autoscaling.knative.dev/window"] = fmt.Sprintf("%ds", policy.StableWindowSeconds)
+			}
+			if policy.ScaleDownDelaySeconds > 0 {
+				templateAnnotations["autoscaling.knative.dev/scale-down-delay"] = fmt.Sprintf("%ds", policy.ScaleDownDelaySeconds)
+			}
+		}
 	}
 	initialDelaySeconds := 10
 	periodSeconds := 10